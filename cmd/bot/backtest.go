@@ -0,0 +1,111 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"MarketSentinel/internal/backtest"
+	"MarketSentinel/internal/collector"
+	"MarketSentinel/internal/config"
+	"MarketSentinel/internal/fund"
+)
+
+// runBacktest replays the configured strategy and fund rules over history:
+//
+//	bot backtest -from 2020-01-01 [-to 2020-12-31] [-daily bars.csv] [-csv weeks.csv]
+//
+// Bars come from data_source unless -daily names a bars file as read by the
+// file provider. The fund starts fresh with fund.monthly_budget. The config
+// is not validated, so no Telegram token is needed.
+func runBacktest(args []string) error {
+	fs := flag.NewFlagSet("backtest", flag.ExitOnError)
+	cfgPath := fs.String("config", configPath(), "config file")
+	fromFlag := fs.String("from", "", "first day to simulate (YYYY-MM-DD)")
+	toFlag := fs.String("to", "", "last day to simulate (YYYY-MM-DD), default today")
+	dailyPath := fs.String("daily", "", "daily bars file (.csv or .json) instead of data_source")
+	csvPath := fs.String("csv", "", "write every simulated week to this CSV file")
+	fs.Parse(args)
+
+	r, cfg, err := newBacktestRunner(*cfgPath, *fromFlag, *toFlag, *dailyPath)
+	if err != nil {
+		return err
+	}
+	if err := configureStrategy(cfg, r.Now); err != nil {
+		return err
+	}
+	log.Printf("[INFO] backtesting %s from %s to %s on %s", r.Symbol, *fromFlag, r.To.Format(time.DateOnly), r.Fetcher.Name())
+	res, err := r.Run()
+	if err != nil {
+		return err
+	}
+
+	if *csvPath != "" {
+		f, err := os.Create(*csvPath)
+		if err != nil {
+			return err
+		}
+		if err := res.WriteCSV(f); err != nil {
+			f.Close()
+			return fmt.Errorf("write %s: %w", *csvPath, err)
+		}
+		if err := f.Close(); err != nil {
+			return err
+		}
+		log.Printf("[INFO] wrote %d weeks to %s", len(res.Weeks), *csvPath)
+	}
+	fmt.Print(res.Summary())
+	return nil
+}
+
+// newBacktestRunner builds the backtest of the -from, -to and -daily flags
+// shared by backtest and tune, with the config at cfgPath. The strategy is
+// left for the caller to configure.
+func newBacktestRunner(cfgPath, fromFlag, toFlag, dailyPath string) (*backtest.Runner, *config.Config, error) {
+	if fromFlag == "" {
+		return nil, nil, fmt.Errorf("-from is required")
+	}
+	from, err := time.Parse(time.DateOnly, fromFlag)
+	if err != nil {
+		return nil, nil, fmt.Errorf("-from: %w", err)
+	}
+	to := time.Now().UTC()
+	if toFlag != "" {
+		if to, err = time.Parse(time.DateOnly, toFlag); err != nil {
+			return nil, nil, fmt.Errorf("-to: %w", err)
+		}
+	}
+
+	cfg, err := config.Load(cfgPath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("load config: %w", err)
+	}
+	var fetcher collector.Fetcher
+	if dailyPath != "" {
+		fetcher = collector.NewFileFetcher(dailyPath, "")
+	} else {
+		proxy, err := config.ParseProxyURL(cfg.Proxy.ForDataSource())
+		if err != nil {
+			return nil, nil, fmt.Errorf("proxy.data_source: %w", err)
+		}
+		fetcher = newFetcher(cfg, proxy)
+	}
+	cal, err := tradingCalendar(cfg, fetcher)
+	if err != nil {
+		return nil, nil, err
+	}
+	// The backtest replays the primary symbol alone, on the whole budget.
+	policy := fundPolicy(cfg)
+	policy.Allocation = nil
+	return &backtest.Runner{
+		Fetcher: fetcher,
+		Symbol:  cfg.DataSource.Symbol,
+		From:    from,
+		To:      to,
+		Start:   fund.InitialState(cfg.Fund.MonthlyBudget, policy),
+		Policy:  policy,
+		Trading: cal,
+	}, cfg, nil
+}
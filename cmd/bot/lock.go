@@ -0,0 +1,91 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"MarketSentinel/internal/config"
+)
+
+// lockHolder is what the lockfile records about the instance holding it.
+type lockHolder struct {
+	PID   int       `json:"pid"`
+	Since time.Time `json:"since"`
+}
+
+// instanceLock keeps a second bot from polling Telegram and writing the fund
+// state alongside the first: an exclusive flock on a lockfile held open while
+// the bot runs. The kernel drops the flock when the process dies, so a crash
+// leaves nothing to clean up; the file's contents only name the holder in the
+// error a second instance reports.
+type instanceLock struct {
+	f *os.File
+}
+
+// lockPath is the lockfile next to the fund state file.
+func lockPath(cfg *config.Config) string {
+	return filepath.Join(filepath.Dir(cfg.Fund.StateFile), "market_sentinel.lock")
+}
+
+// acquireLock takes the lockfile at path for this process. It fails while
+// another live instance holds it.
+func acquireLock(path string) (*instanceLock, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("create lock dir: %w", err)
+	}
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("open lock %s: %w", path, err)
+	}
+	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB); err != nil {
+		f.Close()
+		if !errors.Is(err, syscall.EWOULDBLOCK) {
+			return nil, fmt.Errorf("lock %s: %w", path, err)
+		}
+		h, rerr := readLock(path)
+		if rerr != nil {
+			return nil, fmt.Errorf("another MarketSentinel instance is running (lock %s: %v)", path, rerr)
+		}
+		return nil, fmt.Errorf("another MarketSentinel instance is running (pid %d, since %s)",
+			h.PID, h.Since.Format(time.DateTime))
+	}
+
+	data, err := json.Marshal(lockHolder{PID: os.Getpid(), Since: time.Now()})
+	if err == nil {
+		err = f.Truncate(0)
+	}
+	if err == nil {
+		_, err = f.WriteAt(data, 0)
+	}
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("write lock %s: %w", path, err)
+	}
+	return &instanceLock{f: f}, nil
+}
+
+// readLock reads the holder recorded in the lockfile at path.
+func readLock(path string) (lockHolder, error) {
+	var h lockHolder
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return h, err
+	}
+	err = json.Unmarshal(data, &h)
+	return h, err
+}
+
+// release drops the flock by closing the lockfile. The file itself stays:
+// removing it would let a third instance lock a new file while a second
+// still holds the old one.
+func (l *instanceLock) release() {
+	if err := l.f.Close(); err != nil {
+		log.Printf("[ERROR] release instance lock: %v", err)
+	}
+}
@@ -2,10 +2,13 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"log"
+	"net/url"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"MarketSentinel/internal/collector"
 	"MarketSentinel/internal/config"
@@ -13,74 +16,66 @@ import (
 	"MarketSentinel/internal/notifier"
 	"MarketSentinel/internal/recorder"
 	"MarketSentinel/internal/scheduler"
+	"MarketSentinel/internal/strategy"
 )
 
 func main() {
 	log.SetFlags(log.LstdFlags | log.Lshortfile)
+	if len(os.Args) > 1 && os.Args[1] == "backtest" {
+		if err := runBacktest(os.Args[2:]); err != nil {
+			log.Fatalf("[FATAL] backtest: %v", err)
+		}
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "tune" {
+		if err := runTune(os.Args[2:]); err != nil {
+			log.Fatalf("[FATAL] tune: %v", err)
+		}
+		return
+	}
 	log.Println("[INFO] MarketSentinel starting...")
 
 	// Load config
-	cfgPath := "configs/config.yaml"
-	if v := os.Getenv("CONFIG_PATH"); v != "" {
-		cfgPath = v
-	}
-	cfg, err := config.Load(cfgPath)
+	cfg, err := config.Load(configPath())
 	if err != nil {
 		log.Fatalf("[FATAL] load config: %v", err)
 	}
 	if err := cfg.Validate(); err != nil {
-		log.Fatalf("[FATAL] config validation: %v", err)
+		log.Fatalf("[FATAL] config validation:\n%v", err)
 	}
-
-	// Init fetcher
-	var fetcher collector.Fetcher
-	if cfg.DataSource.BaseURL != "" {
-		fetcher = collector.NewVsTraderFetcher(cfg.DataSource.BaseURL, cfg.DataSource.APIKey, cfg.Proxy)
-	} else {
-		fetcher = collector.NewYahooFetcher(cfg.Proxy)
+	if err := run(cfg); err != nil {
+		log.Fatalf("[FATAL] %v", err)
 	}
-	log.Printf("[INFO] data source: %s", fetcher.Name())
+	log.Println("[INFO] MarketSentinel stopped")
+}
 
-	// Init collector
-	col := collector.NewCollector(fetcher, cfg.DataSource.Symbol)
+// run runs the bot until a shutdown signal. Its errors are returned rather
+// than fatal so that the deferred cleanup, the instance lock's release
+// among it, runs first.
+func run(cfg *config.Config) error {
+	// Context for graceful shutdown
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
 
-	// Init fund manager
-	fm, err := fund.NewManager(cfg.Fund.StateFile, cfg.Fund.MonthlyBudget)
+	// One instance per state: a second would answer every command twice.
+	lock, err := acquireLock(lockPath(cfg))
 	if err != nil {
-		log.Fatalf("[FATAL] init fund manager: %v", err)
+		return err
 	}
+	defer lock.release()
 
-	// Init Telegram notifier
-	tn := notifier.NewTelegramNotifier(cfg.Telegram.BotToken, cfg.Telegram.ChatID, cfg.Proxy)
-
-	// Init recorder
-	var rec recorder.Recorder
-	if cfg.Database.SQLitePath != "" {
-		sr, err := recorder.NewSQLiteRecorder(cfg.Database.SQLitePath)
-		if err != nil {
-			log.Printf("[WARN] init sqlite recorder failed, using noop: %v", err)
-			rec = recorder.NewNoopRecorder()
-		} else {
-			rec = sr
-			defer sr.Close()
-		}
-	} else {
-		rec = recorder.NewNoopRecorder()
+	a, err := setup(ctx, cfg)
+	if err != nil {
+		return err
 	}
+	defer a.close()
+	sched, tn := a.sched, a.tn
 
-	// Context for graceful shutdown
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
-
-	// Init scheduler
-	sched := scheduler.NewScheduler(ctx, col, fm, tn, rec)
-	if err := sched.RegisterAll(cfg.Schedule.WeeklyCron, cfg.Schedule.DailyCron, cfg.Schedule.MonthlyCron); err != nil {
-		log.Fatalf("[FATAL] register cron tasks: %v", err)
-	}
 	sched.Start()
 	defer sched.Stop()
 
 	// Start Telegram polling
+	tn.ChatOnly = sched.ChatOnly
 	go tn.StartPolling(ctx, sched.HandleCommand)
 	log.Println("[INFO] Telegram polling started")
 
@@ -99,5 +94,364 @@ func main() {
 
 	log.Println("[INFO] shutdown signal received, stopping...")
 	cancel()
-	log.Println("[INFO] MarketSentinel stopped")
+	return nil
+}
+
+// configPath is CONFIG_PATH, or configs/config.yaml when unset.
+func configPath() string {
+	if v := os.Getenv("CONFIG_PATH"); v != "" {
+		return v
+	}
+	return "configs/config.yaml"
+}
+
+// app is the wired bot, ready to start.
+type app struct {
+	sched *scheduler.Scheduler
+	tn    *notifier.TelegramNotifier
+	close func()
+}
+
+// newSecondaryFetcher builds the verification fetcher of
+// data_source.secondary, or nil when none is configured. Unlike the primary
+// it has no fallback, so a divergence always compares two distinct sources.
+func newSecondaryFetcher(cfg *config.Config, proxy *url.URL) collector.Fetcher {
+	ds, sec := cfg.DataSource, cfg.DataSource.Secondary
+	switch sec.Provider {
+	case "yahoo":
+		f := collector.NewYahooFetcher(proxy, ds.SymbolMap)
+		f.Adjusted = ds.UseAdjusted
+		return f
+	case "vstrader":
+		return collector.NewVsTraderFetcher(sec.BaseURL, sec.APIKey, proxy)
+	case "stooq":
+		return collector.NewStooqFetcher(proxy, ds.SymbolMap)
+	case "eastmoney":
+		return collector.NewEastMoneyFetcher(proxy, ds.SymbolMap)
+	case "binance":
+		f := collector.NewBinanceFetcher(proxy, ds.SymbolMap)
+		if sec.BaseURL != "" {
+			f.BaseURL = sec.BaseURL
+		}
+		return f
+	default:
+		return nil
+	}
+}
+
+// newFetcher builds the fetcher named by data_source.provider. Without a
+// provider the source follows the configured paths: parquet, then vstrader,
+// then Yahoo. proxy, nil for none, is the data source proxy.
+func newFetcher(cfg *config.Config, proxy *url.URL) collector.Fetcher {
+	ds := cfg.DataSource
+	provider := ds.Provider
+	if provider == "" {
+		switch {
+		case ds.ParquetPath != "":
+			provider = "parquet"
+		case ds.BaseURL != "":
+			provider = "vstrader"
+		default:
+			provider = "yahoo"
+		}
+	}
+	retry := collector.DefaultRetryPolicy
+	retry.MaxAttempts = ds.MaxRetries + 1
+	yahoo := func() collector.Fetcher {
+		f := collector.NewYahooFetcher(proxy, ds.SymbolMap)
+		f.Retry = retry
+		f.Adjusted = ds.UseAdjusted
+		return f
+	}
+
+	switch provider {
+	case "parquet":
+		cols := ds.ParquetColumns
+		return collector.NewParquetFetcher(ds.ParquetPath, collector.ParquetColumns{
+			Date: cols.Date, Open: cols.Open, High: cols.High,
+			Low: cols.Low, Close: cols.Close, Volume: cols.Volume,
+		})
+	case "vstrader":
+		// Yahoo covers vstrader outages; each call tries vstrader first.
+		vs := collector.NewVsTraderFetcher(ds.BaseURL, ds.APIKey, proxy)
+		vs.Retry = retry
+		return collector.NewChainFetcher(vs, yahoo())
+	case "stooq":
+		return collector.NewStooqFetcher(proxy, ds.SymbolMap)
+	case "eastmoney":
+		return collector.NewEastMoneyFetcher(proxy, ds.SymbolMap)
+	case "mock":
+		return &collector.MockFetcher{Scenario: ds.MockScenario}
+	case "file":
+		f := collector.NewFileFetcher(ds.File.DailyPath, ds.File.WeeklyPath)
+		if asOf, err := time.Parse("2006-01-02", ds.File.AsOf); err == nil {
+			// Include every bar of the as-of day.
+			f.SetAsOf(asOf.Add(24*time.Hour - time.Nanosecond))
+		}
+		return f
+	case "binance":
+		f := collector.NewBinanceFetcher(proxy, ds.SymbolMap)
+		if ds.BaseURL != "" {
+			f.BaseURL = ds.BaseURL
+		}
+		return f
+	default:
+		return yahoo()
+	}
+}
+
+// tradingCalendar is the exchange calendar configured for fetcher's symbol,
+// or nil for the built-in one.
+func tradingCalendar(cfg *config.Config, fetcher collector.Fetcher) (*collector.TradingCalendar, error) {
+	tc := cfg.DataSource.Calendar
+	if tc.Exchange == "" && len(tc.Holidays) == 0 {
+		return nil, nil
+	}
+	exchange := tc.Exchange
+	if exchange == "" {
+		exchange = collector.DefaultExchange(fetcher)
+	}
+	cal, err := collector.NewTradingCalendar(exchange, tc.Holidays)
+	if err != nil {
+		return nil, fmt.Errorf("init trading calendar: %w", err)
+	}
+	return cal, nil
+}
+
+// fundPolicy is the optional fund rules of cfg.
+func fundPolicy(cfg *config.Config) fund.Policy {
+	return fund.Policy{
+		RegularShare:            cfg.Fund.RegularRatio,
+		WeeksPerMonth:           cfg.Fund.WeeksPerMonth,
+		AdaptiveSplit:           cfg.Fund.AdaptiveSplit.Enabled,
+		MinReserveShare:         cfg.Fund.AdaptiveSplit.MinReserveShare,
+		MaxReserveShare:         cfg.Fund.AdaptiveSplit.MaxReserveShare,
+		MaxWeeklyDeployMultiple: cfg.Fund.MaxWeeklyDeployMultiple,
+		NudgeAfterWeeks:         cfg.Fund.LowParticipationNudgeWeeks,
+
+		ReserveFloorMultiple:        cfg.Fund.ReserveFloorMultiple,
+		MaxQuarterlyReserveMultiple: cfg.Fund.MaxQuarterlyReserveMultiple,
+		Rounding:                    fund.Rounding{Mode: cfg.Fund.Rounding.Mode, Increment: cfg.Fund.Rounding.Increment},
+		PauseReplenish:              cfg.Fund.PauseReplenish,
+		RegularCapMonths:            cfg.Fund.RegularCapMonths,
+
+		BottomFishLevelMultipliers: cfg.Fund.BottomFishLevelMultipliers,
+
+		Symbol:     cfg.DataSource.Symbol,
+		Allocation: cfg.Fund.Allocation,
+	}
+}
+
+// fundStore opens the fund state store of fund.state_backend, importing the
+// JSON state file into a new SQLite store, and returns the function closing it.
+func fundStore(cfg *config.Config) (fund.StateStore, func(), error) {
+	if cfg.Fund.StateBackend != "sqlite" {
+		return fund.NewFileStore(cfg.Fund.StateFile), func() {}, nil
+	}
+	store, err := fund.OpenSQLiteStore(cfg.Database.SQLitePath)
+	if err != nil {
+		return nil, nil, err
+	}
+	if _, err := store.ImportFile(cfg.Fund.StateFile); err != nil {
+		store.Close()
+		return nil, nil, fmt.Errorf("import %s: %w", cfg.Fund.StateFile, err)
+	}
+	return store, func() { store.Close() }, nil
+}
+
+// configureStrategy registers the optional factors of cfg, reading the date
+// from now, disables the ones it lists and applies its weights.
+func configureStrategy(cfg *config.Config, now func() time.Time) error {
+	if sz := cfg.Strategy.Seasonal; sz.Enabled {
+		tilts := make(map[time.Month]float64, len(strategy.DefaultSeasonalTilts))
+		for m, v := range strategy.DefaultSeasonalTilts {
+			tilts[m] = v
+		}
+		for m, v := range sz.Tilts {
+			tilts[time.Month(m)] = v
+		}
+		strategy.RegisterFactor(strategy.NewSeasonalFactor(tilts, sz.Weight, now))
+		log.Printf("[INFO] seasonal factor enabled, weight %.2f", sz.Weight)
+	}
+	if w := cfg.Strategy.MACD.Weight; w > 0 {
+		strategy.RegisterFactor(strategy.NewMACDFactor(w))
+		log.Printf("[INFO] MACD factor enabled, weight %.2f", w)
+	}
+	if w := cfg.Strategy.MA50w.Weight; w > 0 {
+		strategy.RegisterFactor(strategy.NewMA50wFactor(w))
+		log.Printf("[INFO] MA50w deviation factor enabled, weight %.2f", w)
+	}
+	strategy.SetRSIThresholds(strategy.RSIThresholds{
+		BottomFish:       cfg.Strategy.Thresholds.BottomFishRSI,
+		DeeperBottomFish: cfg.Strategy.Thresholds.DeeperBottomFishRSI,
+		TakeProfit:       cfg.Strategy.Thresholds.TakeProfitRSI,
+		SellScore:        cfg.Strategy.Thresholds.SellScore,
+		SellRSI:          cfg.Strategy.Thresholds.SellRSI,
+		SellPercent:      cfg.Strategy.Thresholds.SellPercent,
+	})
+	if sm := cfg.Strategy.Smoothing; sm.Enabled {
+		strategy.SetSmoothing(sm.Alpha)
+		log.Printf("[INFO] score smoothing enabled, alpha %.2f", sm.Alpha)
+	}
+	if cd := cfg.Strategy.Cooldown; cd.Weeks > 0 {
+		strategy.SetCooldown(cd.Weeks, cd.Margin)
+		log.Printf("[INFO] extreme tier cooldown enabled, %d weeks, margin %.2f", cd.Weeks, cd.Margin)
+	}
+	if m := cfg.Strategy.MinConfidence; m > 0 {
+		strategy.SetConfidenceDemotion(m)
+		log.Printf("[INFO] low-confidence demotion enabled below %.0f%%", m*100)
+	}
+	if bb := cfg.Strategy.BearBoost; bb.Weeks > 0 {
+		strategy.SetBearBoost(bb.Weeks, bb.Multiplier)
+		log.Printf("[INFO] bear market boost enabled after %d weeks, %.2fx", bb.Weeks, bb.Multiplier)
+	}
+	if off := cfg.Strategy.DisabledFactors; len(off) > 0 {
+		if err := strategy.DisableFactors(off...); err != nil {
+			return fmt.Errorf("strategy.disabled_factors: %w", err)
+		}
+		log.Printf("[INFO] factors disabled: %v", off)
+	}
+	if w := cfg.Strategy.Weights; len(w) > 0 {
+		if err := strategy.SetFactorWeights(w); err != nil {
+			return fmt.Errorf("strategy.weights: %w", err)
+		}
+		log.Printf("[INFO] factor weights overridden: %v", w)
+	}
+	return nil
+}
+
+// setup builds every component from a validated cfg. Missing data directories
+// are created on first write, so an empty volume is enough.
+func setup(ctx context.Context, cfg *config.Config) (*app, error) {
+	// Init recorder
+	var rec recorder.Recorder
+	closeRec := func() {}
+	if cfg.Database.SQLitePath != "" {
+		sr, err := recorder.NewSQLiteRecorder(cfg.Database.SQLitePath)
+		if err != nil {
+			log.Printf("[WARN] init sqlite recorder failed, using noop: %v", err)
+			rec = recorder.NewNoopRecorder()
+		} else {
+			policy, _ := recorder.ParseDedupPolicy(cfg.Database.PeriodDedup) // checked by cfg.Validate
+			sr.SetDedupPolicy(policy)
+			rec = sr
+			closeRec = func() { sr.Close() }
+		}
+	} else {
+		rec = recorder.NewNoopRecorder()
+	}
+
+	// Init fetcher
+	dataProxy, err := config.ParseProxyURL(cfg.Proxy.ForDataSource())
+	if err != nil {
+		return nil, fmt.Errorf("proxy.data_source: %w", err)
+	}
+	fetcher := newFetcher(cfg, dataProxy)
+	if bc := cfg.DataSource.BarCache; bc.Enabled {
+		if sr, ok := rec.(*recorder.SQLiteRecorder); ok {
+			fetcher = collector.NewCachedFetcher(fetcher, sr, time.Duration(bc.TTLMinutes)*time.Minute)
+		} else {
+			log.Printf("[WARN] bar cache needs the sqlite recorder, fetching without cache")
+		}
+	}
+	log.Printf("[INFO] data source: %s", fetcher.Name())
+
+	// Init collector
+	col := collector.NewCollector(fetcher, cfg.DataSource.Symbol)
+	if verifier := newSecondaryFetcher(cfg, dataProxy); verifier != nil {
+		col = collector.NewVerifiedCollector(fetcher, verifier, cfg.DataSource.Symbol, cfg.DataSource.Secondary.TolerancePct/100)
+		log.Printf("[INFO] verification source: %s (tolerance %.2f%%)", verifier.Name(), cfg.DataSource.Secondary.TolerancePct)
+	}
+	col.AuxSymbol = cfg.DataSource.AuxSymbol
+	col.WeeklyFromDaily = cfg.Indicators.WeeklyFromDaily
+	col.PercentileMinHistory = cfg.Indicators.PercentileMinHistory
+	if fx := cfg.DataSource.FXSymbol; fx != "" {
+		col.FX = collector.NewFXFetcher(collector.NewYahooFetcher(dataProxy, nil), fx)
+	}
+	if fred := cfg.DataSource.FRED; fred.APIKey != "" {
+		col.Macro = collector.NewFREDFetcher(fred.APIKey, dataProxy)
+		col.YieldSeries = fred.YieldSeries
+	}
+	cal, err := tradingCalendar(cfg, fetcher)
+	if err != nil {
+		return nil, err
+	}
+	col.Trading = cal
+	q := cfg.DataSource.Quality
+	col.Quality = collector.QualityPolicy{
+		MaxStaleness:   time.Duration(q.MaxStaleDays) * 24 * time.Hour,
+		MaxGapSessions: q.MaxGapSessions,
+		Reject:         q.Reject,
+	}
+
+	// Init fund manager
+	store, closeStore, err := fundStore(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("init fund state store: %w", err)
+	}
+	fm, err := fund.NewManager(store, cfg.Fund.MonthlyBudget, fundPolicy(cfg))
+	if err != nil {
+		return nil, fmt.Errorf("init fund manager: %w", err)
+	}
+
+	// Optional strategy factors
+	if err := configureStrategy(cfg, time.Now); err != nil {
+		return nil, err
+	}
+
+	// Init Telegram notifier
+	chatID, err := notifier.ResolveChatID(cfg.Telegram.ChatStateFile, cfg.Telegram.ChatID)
+	if err != nil {
+		log.Printf("[WARN] load telegram chat state: %v", err)
+	}
+	if chatID != cfg.Telegram.ChatID {
+		log.Printf("[INFO] using migrated telegram chat %s (configured %s)", chatID, cfg.Telegram.ChatID)
+	}
+	telegramProxy, err := config.ParseProxyURL(cfg.Proxy.ForTelegram())
+	if err != nil {
+		return nil, fmt.Errorf("proxy.telegram: %w", err)
+	}
+	tn := notifier.NewTelegramNotifier(cfg.Telegram.BotToken, chatID, telegramProxy)
+	tn.ThreadID = cfg.Telegram.MessageThreadID
+	tn.OnChatMigrated = func(_, newID string) {
+		// Keep the configured id as the key so repeated migrations still resolve.
+		m := notifier.ChatMigration{From: cfg.Telegram.ChatID, To: newID}
+		if err := notifier.SaveChatMigration(cfg.Telegram.ChatStateFile, m); err != nil {
+			log.Printf("[ERROR] save telegram chat state: %v", err)
+		}
+	}
+
+	// Init scheduler
+	sched := scheduler.NewScheduler(ctx, col, fm, tn, rec)
+	sched.ThreadReplies = cfg.Telegram.ThreadReplies
+	sched.WeeklyStyle = notifier.StylePolicy{NeutralBand: cfg.Telegram.CompactNeutralBand}
+	sched.SetJitter(time.Duration(cfg.Schedule.JitterSeconds) * time.Second)
+	sched.Pipeline.Budget = time.Duration(cfg.Schedule.WeeklyBudgetSeconds) * time.Second
+	if ba := cfg.Database.BarsArchive; ba.Enabled {
+		sched.Pipeline.ArchiveBars = ba.Keep
+	}
+	if syms := cfg.DataSource.Symbols; len(syms) > 1 {
+		sched.Pipeline.Symbols = syms[1:]
+	}
+	if err := sched.RegisterAll(cfg.Schedule.WeeklyCron, cfg.Schedule.DailyCron, cfg.Schedule.MonthlyCron); err != nil {
+		return nil, fmt.Errorf("register cron tasks: %w", err)
+	}
+	if cfg.Schedule.RecapCron != "" {
+		if err := sched.RegisterRecap(cfg.Schedule.RecapCron); err != nil {
+			return nil, fmt.Errorf("register recap task: %w", err)
+		}
+	}
+	if ga := cfg.GapAlert; ga.Cron != "" {
+		if err := sched.RegisterGapCheck(ga.Cron, collector.NewYahooFetcher(dataProxy, nil), ga.Symbol, ga.ThresholdPct); err != nil {
+			return nil, err
+		}
+	}
+	if cfg.Metrics.Enabled {
+		retention := time.Duration(cfg.Metrics.RetentionDays) * 24 * time.Hour
+		if err := sched.EnableMetrics(cfg.Metrics.SampleCron, retention); err != nil {
+			return nil, fmt.Errorf("register metrics sampling: %w", err)
+		}
+	}
+
+	return &app{sched: sched, tn: tn, close: func() { closeStore(); closeRec() }}, nil
 }
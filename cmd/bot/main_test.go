@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"MarketSentinel/internal/config"
+)
+
+// TestSetup_EnvOnly boots the full wiring with no config file and every
+// setting taken from the environment, into a data directory that does not
+// exist yet.
+func TestSetup_EnvOnly(t *testing.T) {
+	dir := t.TempDir()
+	dataDir := filepath.Join(dir, "pvc", "data")
+	t.Setenv("TELEGRAM_BOT_TOKEN", "123:abc")
+	t.Setenv("TELEGRAM_CHAT_ID", "-1001")
+	t.Setenv("MONTHLY_BUDGET", "8000")
+	t.Setenv("DATA_DIR", dataDir)
+
+	cfg, err := config.Load(filepath.Join(dir, "missing.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("env-only config rejected: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	a, err := setup(ctx, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.close()
+
+	for _, name := range []string{"fund_state.json", "market_sentinel.db"} {
+		if _, err := os.Stat(filepath.Join(dataDir, name)); err != nil {
+			t.Errorf("%s not created under DATA_DIR: %v", name, err)
+		}
+	}
+	if got := a.sched.Fund.GetState().MonthlyBudget; got != 8000 {
+		t.Errorf("monthly budget = %.0f, want 8000 from MONTHLY_BUDGET", got)
+	}
+	if reply := a.sched.HandleCommand(1, "/fund"); !strings.Contains(reply, "资金池状态") {
+		t.Errorf("wired scheduler did not answer /fund: %q", reply)
+	}
+}
+
+func TestNewFetcher_Provider(t *testing.T) {
+	tests := []struct {
+		name     string
+		provider string
+		baseURL  string
+		parquet  string
+		want     string
+	}{
+		{"default", "", "", "", "yahoo"},
+		{"base_url heuristic", "", "http://vs", "", "vstrader>yahoo"},
+		{"parquet heuristic", "", "http://vs", "data/spx", "parquet"},
+		{"binance ignores heuristic", "binance", "", "", "binance"},
+		{"explicit yahoo", "yahoo", "http://vs", "", "yahoo"},
+		{"stooq", "stooq", "", "", "stooq"},
+		{"file", "file", "", "", "file"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{}
+			cfg.DataSource.Provider = tt.provider
+			cfg.DataSource.BaseURL = tt.baseURL
+			cfg.DataSource.ParquetPath = tt.parquet
+			if got := newFetcher(cfg, nil).Name(); got != tt.want {
+				t.Errorf("fetcher = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAcquireLock(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data", "market_sentinel.lock")
+	first, err := acquireLock(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := fmt.Sprintf("another MarketSentinel instance is running (pid %d, since ", os.Getpid())
+	if _, err := acquireLock(path); err == nil || !strings.Contains(err.Error(), want) {
+		t.Fatalf("second instance: err = %v, want %q", err, want)
+	}
+
+	first.release()
+	next, err := acquireLock(path)
+	if err != nil {
+		t.Fatalf("lock not released on shutdown: %v", err)
+	}
+	next.release()
+}
+
+// TestAcquireLock_StaleFile checks that a lockfile left by a process that
+// died, its flock gone with it, does not block the next start.
+func TestAcquireLock_StaleFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "market_sentinel.lock")
+	if err := os.WriteFile(path, []byte(`{"pid":1,"since":"2025-01-01T00:00:00Z"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	l, err := acquireLock(path)
+	if err != nil {
+		t.Fatalf("stale lockfile blocked the start: %v", err)
+	}
+	defer l.release()
+	if h, err := readLock(path); err != nil || h.PID != os.Getpid() {
+		t.Errorf("lockfile holder %+v (%v), want this process", h, err)
+	}
+}
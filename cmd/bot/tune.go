@@ -0,0 +1,91 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"MarketSentinel/internal/backtest"
+)
+
+// runTune grid-searches strategy parameters over history:
+//
+//	bot tune -from 2020-01-01 [-to 2020-12-31] [-daily bars.csv] -out sweep.csv \
+//		-param weights.ma200=0.2:0.4:0.05 -param cooldown.weeks=0:4:1 [-samples 500] [-resume]
+//
+// Every combination of the -param ranges is backtested as by backtest, the
+// rest of the strategy configured from the config file, and written to -out
+// as one row of parameters and outcome metrics. With -resume the rows
+// already in -out are kept and their combinations skipped.
+func runTune(args []string) error {
+	fs := flag.NewFlagSet("tune", flag.ExitOnError)
+	cfgPath := fs.String("config", configPath(), "config file")
+	fromFlag := fs.String("from", "", "first day to simulate (YYYY-MM-DD)")
+	toFlag := fs.String("to", "", "last day to simulate (YYYY-MM-DD), default today")
+	dailyPath := fs.String("daily", "", "daily bars file (.csv or .json) instead of data_source")
+	outPath := fs.String("out", "", "CSV file to write one row per combination to")
+	workers := fs.Int("workers", 0, "combinations simulated at a time, default one per CPU")
+	samples := fs.Int("samples", 0, "run only this many combinations drawn at random, 0 for all")
+	seed := fs.Uint64("seed", 1, "random seed of -samples; keep it to -resume a sample")
+	resume := fs.Bool("resume", false, "skip the combinations already in -out and append to it")
+	var ranges []backtest.Range
+	fs.Func("param", "parameter range param=from:to:step or param=value, repeatable", func(s string) error {
+		r, err := backtest.ParseRange(s)
+		if err == nil {
+			ranges = append(ranges, r)
+		}
+		return err
+	})
+	fs.Parse(args)
+	if *outPath == "" {
+		return fmt.Errorf("-out is required")
+	}
+	if len(ranges) == 0 {
+		return fmt.Errorf("at least one -param is required")
+	}
+
+	r, cfg, err := newBacktestRunner(*cfgPath, *fromFlag, *toFlag, *dailyPath)
+	if err != nil {
+		return err
+	}
+	if err := configureStrategy(cfg, r.Now); err != nil {
+		return err
+	}
+	sw := &backtest.Sweep{Runner: r, Ranges: ranges, Workers: *workers, Samples: *samples, Seed: *seed}
+
+	var done map[string]bool
+	flags := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+	if *resume {
+		switch f, err := os.Open(*outPath); {
+		case err == nil:
+			done, err = sw.Done(f)
+			f.Close()
+			if err != nil {
+				return fmt.Errorf("resume %s: %w", *outPath, err)
+			}
+			flags = os.O_WRONLY | os.O_APPEND
+		case !errors.Is(err, os.ErrNotExist):
+			return err
+		}
+	}
+	f, err := os.OpenFile(*outPath, flags, 0644)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("[INFO] tuning %s from %s to %s on %s, %d combinations already done",
+		r.Symbol, *fromFlag, r.To.Format(time.DateOnly), r.Fetcher.Name(), len(done))
+	start := time.Now()
+	n, err := sw.Run(f, done)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return fmt.Errorf("write %s: %w", *outPath, err)
+	}
+	log.Printf("[INFO] wrote %d combinations to %s in %s", n, *outPath, time.Since(start).Round(time.Second))
+	return nil
+}
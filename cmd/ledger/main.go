@@ -0,0 +1,154 @@
+// Command ledger exports the simulated fund ledger to CSV and imports executed
+// brokerage trades for the /reconcile-report comparison.
+//
+//	ledger export [-from 2025-01-01] [-to 2025-12-31] -out ledger.csv
+//	ledger import -file real_trades.csv
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"MarketSentinel/internal/config"
+	"MarketSentinel/internal/ledger"
+	"MarketSentinel/internal/recorder"
+)
+
+func main() {
+	log.SetFlags(0)
+	if len(os.Args) < 2 {
+		usage()
+	}
+
+	var err error
+	switch os.Args[1] {
+	case "export":
+		err = runExport(os.Args[2:])
+	case "import":
+		err = runImport(os.Args[2:])
+	default:
+		usage()
+	}
+	if err != nil {
+		log.Fatalf("ledger %s: %v", os.Args[1], err)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage:\n  ledger export [-config path] [-from YYYY-MM-DD] [-to YYYY-MM-DD] -out ledger.csv\n  ledger import [-config path] -file trades.csv")
+	os.Exit(2)
+}
+
+// configPath defaults like the bot: CONFIG_PATH, then configs/config.yaml.
+func configPath() string {
+	if v := os.Getenv("CONFIG_PATH"); v != "" {
+		return v
+	}
+	return "configs/config.yaml"
+}
+
+func openRecorder(path string) (*recorder.SQLiteRecorder, error) {
+	cfg, err := config.Load(path)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Database.SQLitePath == "" {
+		return nil, fmt.Errorf("database.sqlite_path is not configured in %s", path)
+	}
+	return recorder.NewSQLiteRecorder(cfg.Database.SQLitePath)
+}
+
+func runExport(args []string) error {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	cfgPath := fs.String("config", configPath(), "config file")
+	fromFlag := fs.String("from", "", "first day to include (YYYY-MM-DD), default all history")
+	toFlag := fs.String("to", "", "last day to include (YYYY-MM-DD), default today")
+	out := fs.String("out", "", "output CSV file")
+	fs.Parse(args)
+	if *out == "" {
+		return fmt.Errorf("-out is required")
+	}
+
+	var from time.Time
+	if *fromFlag != "" {
+		t, err := time.ParseInLocation(ledger.DateLayout, *fromFlag, time.Local)
+		if err != nil {
+			return fmt.Errorf("-from: %w", err)
+		}
+		from = t
+	}
+	to := time.Now()
+	if *toFlag != "" {
+		t, err := time.ParseInLocation(ledger.DateLayout, *toFlag, time.Local)
+		if err != nil {
+			return fmt.Errorf("-to: %w", err)
+		}
+		to = t
+	}
+	// -to is inclusive, so stop at the start of the following day.
+	y, m, d := to.Date()
+	to = time.Date(y, m, d+1, 0, 0, 0, 0, time.Local)
+
+	rec, err := openRecorder(*cfgPath)
+	if err != nil {
+		return err
+	}
+	defer rec.Close()
+
+	events, err := rec.FundEventsBetween(from, to)
+	if err != nil {
+		return fmt.Errorf("load fund history: %w", err)
+	}
+	f, err := os.Create(*out)
+	if err != nil {
+		return err
+	}
+	if err := ledger.WriteExport(f, events); err != nil {
+		f.Close()
+		return fmt.Errorf("write %s: %w", *out, err)
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	log.Printf("exported %d fund events to %s", len(events), *out)
+	return nil
+}
+
+func runImport(args []string) error {
+	fs := flag.NewFlagSet("import", flag.ExitOnError)
+	cfgPath := fs.String("config", configPath(), "config file")
+	file := fs.String("file", "", "trades CSV with columns date,amount,price,units")
+	fs.Parse(args)
+	if *file == "" {
+		return fmt.Errorf("-file is required")
+	}
+
+	f, err := os.Open(*file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	trades, rejects, err := ledger.ReadTrades(f, time.Local)
+	if err != nil {
+		return fmt.Errorf("read %s: %w", *file, err)
+	}
+
+	rec, err := openRecorder(*cfgPath)
+	if err != nil {
+		return err
+	}
+	defer rec.Close()
+
+	inserted, err := rec.ImportTrades(trades)
+	if err != nil {
+		return fmt.Errorf("store trades: %w", err)
+	}
+	log.Printf("imported %d trades, %d already present, %d rejected", inserted, len(trades)-inserted, len(rejects))
+	for _, r := range rejects {
+		log.Printf("  line %d: %s", r.Line, r.Reason)
+	}
+	return nil
+}
@@ -0,0 +1,292 @@
+// Package backtest replays the strategy and the fund rules over historical
+// bars, one simulated week at a time.
+package backtest
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"time"
+
+	"MarketSentinel/internal/calculator"
+	"MarketSentinel/internal/collector"
+	"MarketSentinel/internal/evaluate"
+	"MarketSentinel/internal/fund"
+	"MarketSentinel/internal/model"
+	"MarketSentinel/internal/strategy"
+)
+
+// warmupDays is how much history before From is fetched: enough for MA200
+// and the three years the weekly RSI percentile is ranked over.
+const warmupDays = 4 * 365
+
+// Runner simulates the weekly task between From and To. The strategy is the
+// package-level factor registry, configured as for the bot beforehand.
+type Runner struct {
+	Fetcher  collector.Fetcher
+	Symbol   string
+	From, To time.Time       // first and last day simulated, inclusive
+	Start    model.FundState // fund at From, see fund.InitialState
+	Policy   fund.Policy
+	// Trading is the exchange calendar, as Collector.Trading; nil picks the
+	// built-in calendar of the fetcher's market.
+	Trading *collector.TradingCalendar
+	// Params are the strategy settings the weeks are evaluated with; nil
+	// uses the ones in effect, see strategy.CurrentParams.
+	Params *strategy.Params
+
+	now time.Time
+}
+
+// Now is the day being simulated, for factors and anything else that would
+// read the clock; it is the zero time outside Run.
+func (r *Runner) Now() time.Time { return r.now }
+
+// Week is one simulated weekly run.
+type Week struct {
+	Date          time.Time // session the week was evaluated at
+	Close         float64
+	Score         float64
+	Tier          string
+	Invested      float64 // bought this week, reserve included
+	ReserveUsed   float64
+	Units         float64 // bought this week at Close
+	TotalUnits    float64
+	TotalInvested float64
+	Value         float64 // TotalUnits at Close
+	Regular       float64 // pool balances after the week
+	Reserve       float64
+}
+
+// Result is the outcome of Run.
+type Result struct {
+	Weeks         []Week
+	TotalInvested float64
+	TotalUnits    float64
+	FinalValue    float64 // TotalUnits at the last close
+	Cash          float64 // left in both pools
+	// IRR is the annualized internal rate of return of the weekly buys
+	// against FinalValue; NaN when there is none.
+	IRR float64
+	// MaxDrawdown is the largest fall of the position's value per unit
+	// invested from its running peak, as a fraction, so new buys neither
+	// hide a fall nor count as a recovery.
+	MaxDrawdown float64
+	// StrategyVersion is the rule set the weeks were evaluated by, see
+	// strategy.Version.
+	StrategyVersion string
+}
+
+// Run fetches the daily bars once and simulates every ISO week with a
+// session in [From, To] at its last session: indicators and signal as of its
+// close as evaluate.Series computes them, and the weekly investment bought at
+// the close. The
+// monthly replenish runs before the first week of each new month, and the
+// quarterly rebalance after it at a new quarter. Intra-week bottom-fishing is
+// not simulated.
+func (r *Runner) Run() (*Result, error) {
+	sessions, err := r.prepare()
+	if err != nil {
+		return nil, err
+	}
+	return r.simulate(sessions)
+}
+
+// session is the last session of a simulated week with the indicators as of
+// its close, which do not depend on the strategy settings.
+type session struct {
+	bar model.OHLCV
+	ind *model.MarketIndicators
+}
+
+// prepare fetches the daily bars and computes the indicators of every
+// simulated week, oldest first.
+func (r *Runner) prepare() ([]session, error) {
+	if r.To.Before(r.From) {
+		return nil, errors.New("backtest: to is before from")
+	}
+	days := int(time.Since(r.From).Hours()/24) + warmupDays
+	daily, err := r.Fetcher.FetchDailyBars(r.Symbol, days)
+	if err != nil {
+		return nil, fmt.Errorf("backtest: fetch daily bars: %w", err)
+	}
+
+	col := collector.NewCollector(r.Fetcher, r.Symbol)
+	col.Quality = collector.QualityPolicy{} // the staleness check would measure against today
+	col.WeeklyFromDaily = true
+	col.Trading = r.Trading
+
+	var sessions []session
+	for i, bar := range daily {
+		if bar.Time.Before(r.From) || !sameDayOrBefore(bar.Time, r.To) {
+			continue
+		}
+		if i+1 < len(daily) && sameISOWeek(bar.Time, daily[i+1].Time) && sameDayOrBefore(daily[i+1].Time, r.To) {
+			continue // not the week's last session
+		}
+		ind, err := evaluate.Indicators(col.InputsAsOf(daily, bar.Time), bar.Time, col)
+		if err != nil {
+			return nil, fmt.Errorf("backtest: %s: %w", bar.Time.Format(time.DateOnly), err)
+		}
+		sessions = append(sessions, session{bar, ind})
+	}
+	if len(sessions) == 0 {
+		return nil, fmt.Errorf("backtest: no bars between %s and %s", r.From.Format(time.DateOnly), r.To.Format(time.DateOnly))
+	}
+	return sessions, nil
+}
+
+// simulate runs the strategy and the fund rules over sessions.
+func (r *Runner) simulate(sessions []session) (*Result, error) {
+	p := strategy.CurrentParams()
+	if r.Params != nil {
+		p = *r.Params
+	}
+	fm := fund.NewMemoryManager(r.Start, r.Now)
+	fm.SetPolicy(r.Policy)
+	defer func() { r.now = time.Time{} }()
+
+	res := &Result{StrategyVersion: p.Version()}
+	var flows []calculator.CashFlow
+	var last time.Time
+	peak := 0.0
+	for _, s := range sessions {
+		bar := s.bar
+		r.now = bar.Time
+
+		if !last.IsZero() && bar.Time.Month() != last.Month() {
+			fm.MonthlyReplenish()
+			if quarter(bar.Time) != quarter(last) {
+				fm.QuarterlyRebalance()
+			}
+		}
+		last = bar.Time
+
+		signal := p.EvaluateAt(s.ind, bar.Time)
+		p.Smooth(signal, fm.GetState().RecentScores)
+		p.ApplyCooldown(signal, recentTiers(res.Weeks, p.CooldownWeeks))
+		p.ApplyConfidence(signal)
+		p.ApplyBearBoost(signal, fm.GetState().ConsecutiveHighScoreWeeks)
+		fm.ResetWeeklyFlags()
+		invested, reserveUsed := fm.CalculateWeeklyInvestment(signal)
+
+		w := Week{Date: bar.Time, Close: bar.Close, Score: signal.TotalScore, Tier: signal.Tier.Label,
+			Invested: invested, ReserveUsed: reserveUsed}
+		if bar.Close > 0 {
+			w.Units = invested / bar.Close
+		}
+		res.TotalInvested += invested
+		res.TotalUnits += w.Units
+		w.TotalUnits, w.TotalInvested = res.TotalUnits, res.TotalInvested
+		w.Value = res.TotalUnits * bar.Close
+		st := fm.GetState()
+		w.Regular, w.Reserve = st.RegularBalance, st.ReserveBalance
+		res.Weeks = append(res.Weeks, w)
+		if invested > 0 {
+			flows = append(flows, calculator.CashFlow{Date: bar.Time, Amount: -invested})
+		}
+
+		if res.TotalInvested > 0 {
+			ratio := w.Value / res.TotalInvested
+			peak = math.Max(peak, ratio)
+			res.MaxDrawdown = math.Max(res.MaxDrawdown, (peak-ratio)/peak)
+		}
+	}
+	final := res.Weeks[len(res.Weeks)-1]
+	res.FinalValue = final.Value
+	res.Cash = final.Regular + final.Reserve
+	res.IRR = math.NaN()
+	if len(flows) > 0 {
+		if irr, err := calculator.XIRR(append(flows, calculator.CashFlow{Date: final.Date, Amount: res.FinalValue})); err == nil {
+			res.IRR = irr
+		}
+	}
+	return res, nil
+}
+
+// sameDayOrBefore reports whether t falls on day's date or earlier.
+func sameDayOrBefore(t, day time.Time) bool {
+	return t.Before(time.Date(day.Year(), day.Month(), day.Day()+1, 0, 0, 0, 0, day.Location()))
+}
+
+func sameISOWeek(a, b time.Time) bool {
+	ay, aw := a.ISOWeek()
+	by, bw := b.ISOWeek()
+	return ay == by && aw == bw
+}
+
+// recentTiers returns the tier labels of up to n most recent weeks, newest
+// first, as recorder.Recorder.RecentWeeklyTiers would.
+func recentTiers(weeks []Week, n int) []string {
+	n = min(len(weeks), n)
+	tiers := make([]string, n)
+	for i := range tiers {
+		tiers[i] = weeks[len(weeks)-1-i].Tier
+	}
+	return tiers
+}
+
+// ReserveEmptyWeeks is how many weeks ended with the reserve pool empty.
+func (r *Result) ReserveEmptyWeeks() int {
+	n := 0
+	for _, w := range r.Weeks {
+		if w.Reserve < 0.005 {
+			n++
+		}
+	}
+	return n
+}
+
+func quarter(t time.Time) int { return (int(t.Month()) - 1) / 3 }
+
+// Summary is a short plain-text report of r.
+func (r *Result) Summary() string {
+	first, last := r.Weeks[0].Date, r.Weeks[len(r.Weeks)-1].Date
+	irr := "n/a"
+	if !math.IsNaN(r.IRR) {
+		irr = fmt.Sprintf("%.2f%%", r.IRR*100)
+	}
+	return fmt.Sprintf("%s .. %s, %d weeks\n"+
+		"total invested: %s\n"+
+		"final value:    %s (%.4f units)\n"+
+		"cash in pools:  %s\n"+
+		"IRR:            %s\n"+
+		"max drawdown:   %.2f%%\n"+
+		"strategy:       %s\n",
+		first.Format(time.DateOnly), last.Format(time.DateOnly), len(r.Weeks),
+		model.FormatAmount(r.TotalInvested), model.FormatAmount(r.FinalValue), r.TotalUnits,
+		model.FormatAmount(r.Cash), irr, r.MaxDrawdown*100, r.StrategyVersion)
+}
+
+// CSVHeader is the first row written by WriteCSV, followed by one row per
+// simulated week, oldest first.
+var CSVHeader = []string{
+	"date", "close", "score", "tier", "invested", "reserve_used", "units",
+	"total_units", "total_invested", "value", "regular", "reserve",
+}
+
+// WriteCSV writes every simulated week of r as CSV with CSVHeader.
+func (r *Result) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(CSVHeader); err != nil {
+		return err
+	}
+	for _, wk := range r.Weeks {
+		if err := cw.Write([]string{
+			wk.Date.Format(time.DateOnly),
+			num(wk.Close, 4), num(wk.Score, 2), wk.Tier,
+			num(wk.Invested, 2), num(wk.ReserveUsed, 2), num(wk.Units, 6),
+			num(wk.TotalUnits, 6), num(wk.TotalInvested, 2), num(wk.Value, 2),
+			num(wk.Regular, 2), num(wk.Reserve, 2),
+		}); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func num(v float64, prec int) string { return strconv.FormatFloat(v, 'f', prec, 64) }
@@ -0,0 +1,279 @@
+package backtest
+
+import (
+	"bytes"
+	"encoding/csv"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"MarketSentinel/internal/collector"
+	"MarketSentinel/internal/evaluate"
+	"MarketSentinel/internal/fund"
+	"MarketSentinel/internal/model"
+	"MarketSentinel/internal/strategy"
+)
+
+// crashBars are weekday bars from 2016 through 2020 rising 0.05% a session,
+// with a 35% fall over the four weeks from 2020-02-24 and a recovery by June.
+func crashBars() []model.OHLCV {
+	var bars []model.OHLCV
+	p := 100.0
+	crash := time.Date(2020, 2, 24, 0, 0, 0, 0, time.UTC)
+	for d := time.Date(2016, 1, 4, 0, 0, 0, 0, time.UTC); d.Year() <= 2020; d = d.AddDate(0, 0, 1) {
+		if d.Weekday() == time.Saturday || d.Weekday() == time.Sunday {
+			continue
+		}
+		switch days := d.Sub(crash).Hours() / 24; {
+		case days >= 0 && days < 28:
+			p *= 0.978
+		case days >= 28 && days < 100:
+			p *= 1.008
+		default:
+			p *= 1.0005
+		}
+		bars = append(bars, model.OHLCV{Time: d, Open: p, High: p * 1.01, Low: p * 0.99, Close: p, Volume: 1e6})
+	}
+	return bars
+}
+
+func TestRun_2020(t *testing.T) {
+	start := fund.InitialState(10000, fund.Policy{})
+	r := &Runner{
+		Fetcher: &collector.MockFetcher{DailyData: crashBars()},
+		Symbol:  "TEST",
+		From:    time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
+		To:      time.Date(2020, 12, 31, 0, 0, 0, 0, time.UTC),
+		Start:   start,
+	}
+	res, err := r.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 2020-01-01 is in ISO week 1 and 2020-12-31 in week 53.
+	if len(res.Weeks) != 53 {
+		t.Fatalf("simulated %d weeks, want 53", len(res.Weeks))
+	}
+	if d := res.Weeks[0].Date; d.Weekday() != time.Friday || d.Day() != 3 {
+		t.Errorf("first week evaluated on %s, want Friday 2020-01-03", d.Format(time.DateOnly))
+	}
+	if d := res.Weeks[52].Date; !d.Equal(r.To) {
+		t.Errorf("last week evaluated on %s, want the range's end", d.Format(time.DateOnly))
+	}
+
+	// Eleven monthly budgets were added after the first; only a quarterly
+	// emergency top-up of the reserve adds more.
+	if got, want := res.TotalInvested+res.Cash, 12*start.MonthlyBudget; got < want-1e-6 {
+		t.Errorf("invested + cash = %.2f, want at least 12 budgets = %.2f", got, want)
+	}
+	units := 0.0
+	for _, w := range res.Weeks {
+		units += w.Invested / w.Close
+	}
+	if math.Abs(units-res.TotalUnits) > 1e-9 {
+		t.Errorf("total units %.6f, want the sum of weekly buys %.6f", res.TotalUnits, units)
+	}
+	if last := res.Weeks[52]; math.Abs(res.FinalValue-res.TotalUnits*last.Close) > 1e-6 {
+		t.Errorf("final value %.2f, want units at the last close", res.FinalValue)
+	}
+
+	var crashMax, calmMax float64
+	for _, w := range res.Weeks {
+		if w.Date.Month() == time.March || w.Date.Month() == time.April {
+			crashMax = math.Max(crashMax, w.Invested)
+		} else if w.Date.Month() == time.January {
+			calmMax = math.Max(calmMax, w.Invested)
+		}
+	}
+	if crashMax <= calmMax {
+		t.Errorf("largest buy in the crash %.2f, want more than in January %.2f", crashMax, calmMax)
+	}
+	if res.MaxDrawdown < 0.1 || res.MaxDrawdown >= 0.35 {
+		t.Errorf("max drawdown %.3f, want the crash softened by the earlier cost", res.MaxDrawdown)
+	}
+	if math.IsNaN(res.IRR) || res.IRR <= 0 {
+		t.Errorf("IRR %v, want positive after buying the dip", res.IRR)
+	}
+	if !r.Now().IsZero() {
+		t.Error("Now is still set after Run")
+	}
+	if v := strategy.Version(); res.StrategyVersion != v || !strings.Contains(res.Summary(), "strategy:       "+v) {
+		t.Errorf("strategy version %q not reported, want %q", res.StrategyVersion, v)
+	}
+
+	var buf bytes.Buffer
+	if err := res.WriteCSV(&buf); err != nil {
+		t.Fatal(err)
+	}
+	rows, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 54 || len(rows[1]) != len(CSVHeader) || rows[1][0] != "2020-01-03" {
+		t.Errorf("CSV has %d rows starting %v, want the header and 53 weeks", len(rows), rows[1])
+	}
+}
+
+func TestRun_NoBarsInRange(t *testing.T) {
+	r := &Runner{
+		Fetcher: &collector.MockFetcher{DailyData: crashBars()},
+		From:    time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC),
+		To:      time.Date(2022, 12, 31, 0, 0, 0, 0, time.UTC),
+		Start:   fund.InitialState(10000, fund.Policy{}),
+	}
+	if _, err := r.Run(); err == nil {
+		t.Error("want an error for a range past the bars")
+	}
+}
+
+// bear2022Bars are weekday bars from 2017 rising 0.05% a session to a top at
+// the start of 2022, then falling about 0.12% a session through 2022 in
+// month-long waves, so the score stays high in a bear market for months.
+func bear2022Bars() []model.OHLCV {
+	var bars []model.OHLCV
+	p := 100.0
+	top := time.Date(2022, 1, 3, 0, 0, 0, 0, time.UTC)
+	for d := time.Date(2017, 1, 2, 0, 0, 0, 0, time.UTC); d.Year() <= 2022; d = d.AddDate(0, 0, 1) {
+		if d.Weekday() == time.Saturday || d.Weekday() == time.Sunday {
+			continue
+		}
+		if d.Before(top) {
+			p *= 1.0005
+		} else {
+			p *= 1 - 0.0012 + 0.004*math.Sin(d.Sub(top).Hours()/24/20)
+		}
+		bars = append(bars, model.OHLCV{Time: d, Open: p, High: p * 1.01, Low: p * 0.99, Close: p, Volume: 1e6})
+	}
+	return bars
+}
+
+func TestRun_BearBoost2022(t *testing.T) {
+	run := func(params *strategy.Params) *Result {
+		t.Helper()
+		r := &Runner{
+			Fetcher: &collector.MockFetcher{DailyData: bear2022Bars()},
+			Symbol:  "TEST",
+			From:    time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC),
+			To:      time.Date(2022, 12, 31, 0, 0, 0, 0, time.UTC),
+			Start:   fund.InitialState(10000, fund.Policy{}),
+			Params:  params,
+		}
+		res, err := r.Run()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return res
+	}
+	reserveUsed := func(res *Result) float64 {
+		sum := 0.0
+		for _, w := range res.Weeks {
+			sum += w.ReserveUsed
+		}
+		return sum
+	}
+
+	// Off by default.
+	def := run(nil)
+	off := strategy.CurrentParams()
+	if off.BearBoostWeeks != 0 {
+		t.Fatalf("bear boost on by default: %+v", off)
+	}
+
+	boost := strategy.CurrentParams()
+	boost.BearBoostWeeks, boost.BearBoostMultiplier = 4, 1.5
+	got := run(&boost)
+
+	// The boost buys from the regular pool instead, so the reserve pool
+	// lasts through the bear market.
+	if def.ReserveEmptyWeeks() == 0 {
+		t.Fatalf("fixture never empties the reserve pool without the boost")
+	}
+	if got.ReserveEmptyWeeks() != 0 || reserveUsed(got) >= reserveUsed(def) {
+		t.Errorf("boosted: %d weeks with the reserve empty, %.2f drawn; unboosted %d, %.2f",
+			got.ReserveEmptyWeeks(), reserveUsed(got), def.ReserveEmptyWeeks(), reserveUsed(def))
+	}
+	if got.Cash <= def.Cash || got.StrategyVersion == def.StrategyVersion {
+		t.Errorf("boosted cash %.2f (%s), unboosted %.2f (%s)", got.Cash, got.StrategyVersion, def.Cash, def.StrategyVersion)
+	}
+}
+
+// TestRun_MatchesSeries checks each week scores what evaluate.Series gives
+// the bars up to its session, with nothing carried between weeks.
+func TestRun_MatchesSeries(t *testing.T) {
+	daily := crashBars()
+	params := strategy.Params{}
+	r := &Runner{
+		Fetcher: &collector.MockFetcher{DailyData: daily},
+		Symbol:  "TEST",
+		From:    time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
+		To:      time.Date(2020, 6, 30, 0, 0, 0, 0, time.UTC),
+		Start:   fund.InitialState(10000, fund.Policy{}),
+		Params:  &params,
+	}
+	res, err := r.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	col := collector.NewCollector(r.Fetcher, r.Symbol)
+	col.Quality = collector.QualityPolicy{}
+	col.WeeklyFromDaily = true
+	for _, w := range res.Weeks {
+		in := col.InputsAsOf(daily, w.Date)
+		sig, _, err := evaluate.Series(in.Daily, in.Weekly, in.Price, w.Date, evaluate.Config{Params: params, Collector: col})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if sig.TotalScore != w.Score || sig.Tier.Label != w.Tier {
+			t.Errorf("%s: backtest scored %.6f (%s), Series %.6f (%s)",
+				w.Date.Format(time.DateOnly), w.Score, w.Tier, sig.TotalScore, sig.Tier.Label)
+		}
+	}
+}
+
+func TestRun_RegularCap2021(t *testing.T) {
+	run := func(policy fund.Policy) *Result {
+		t.Helper()
+		r := &Runner{
+			Fetcher: &collector.MockFetcher{DailyData: bear2022Bars()},
+			Symbol:  "TEST",
+			From:    time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC),
+			To:      time.Date(2022, 12, 31, 0, 0, 0, 0, time.UTC),
+			Start:   fund.InitialState(10000, policy),
+			Policy:  policy,
+		}
+		res, err := r.Run()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return res
+	}
+	maxRegular := func(res *Result) float64 {
+		most := 0.0
+		for _, w := range res.Weeks {
+			most = math.Max(most, w.Regular)
+		}
+		return most
+	}
+
+	// Off by default: the light weeks of 2021 leave months of budget in
+	// the regular pool while the reserve runs dry in 2022.
+	def := run(fund.Policy{})
+	if maxRegular(def) < 6*7000 || def.ReserveEmptyWeeks() == 0 {
+		t.Fatalf("fixture peaks the regular pool at %.2f with %d weeks of empty reserve",
+			maxRegular(def), def.ReserveEmptyWeeks())
+	}
+
+	// Capped at three months of its 7000 share, the surplus is carried over
+	// to the reserve, which lasts through the bear market and buys more.
+	capped := run(fund.Policy{RegularCapMonths: 3})
+	if got := maxRegular(capped); got > 3*7000+1e-6 {
+		t.Errorf("capped regular pool peaks at %.2f, over three months", got)
+	}
+	if capped.ReserveEmptyWeeks() != 0 || capped.TotalUnits <= def.TotalUnits {
+		t.Errorf("capped: %d weeks with the reserve empty, %.2f units; uncapped %d, %.2f",
+			capped.ReserveEmptyWeeks(), capped.TotalUnits, def.ReserveEmptyWeeks(), def.TotalUnits)
+	}
+}
@@ -0,0 +1,336 @@
+package backtest
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"math"
+	"math/bits"
+	"math/rand/v2"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"MarketSentinel/internal/strategy"
+)
+
+// Parameters a Range can sweep, named as in the strategy section of the
+// config. A core factor weight is "weights." followed by the factor name,
+// e.g. "weights.ma200".
+const (
+	ParamSmoothingAlpha = "smoothing.alpha"
+	ParamCooldownWeeks  = "cooldown.weeks"
+	ParamCooldownMargin = "cooldown.margin"
+	ParamMinConfidence  = "min_confidence"
+	ParamBearBoostWeeks = "bear_boost.weeks"
+	ParamBearBoostMult  = "bear_boost.multiplier"
+	paramWeightPrefix   = "weights."
+)
+
+// Range is the values a parameter is swept over: From to To inclusive in
+// steps of Step. A zero Step sweeps From alone.
+type Range struct {
+	Param          string
+	From, To, Step float64
+}
+
+// ParseRange parses "param=from:to:step", or "param=value" for a single
+// value, e.g. "weights.ma200=0.2:0.4:0.05".
+func ParseRange(s string) (Range, error) {
+	param, spec, ok := strings.Cut(s, "=")
+	if !ok || param == "" {
+		return Range{}, fmt.Errorf("range %q: want param=from:to:step", s)
+	}
+	parts := strings.Split(spec, ":")
+	if len(parts) != 1 && len(parts) != 3 {
+		return Range{}, fmt.Errorf("range %q: want param=from:to:step", s)
+	}
+	vals := make([]float64, len(parts))
+	for i, p := range parts {
+		v, err := strconv.ParseFloat(p, 64)
+		if err != nil || math.IsNaN(v) || math.IsInf(v, 0) {
+			return Range{}, fmt.Errorf("range %q: bad number %q", s, p)
+		}
+		vals[i] = v
+	}
+	r := Range{Param: param, From: vals[0], To: vals[0]}
+	if len(vals) == 3 {
+		r.To, r.Step = vals[1], vals[2]
+		if r.Step <= 0 || r.To < r.From {
+			return Range{}, fmt.Errorf("range %q: want from ≤ to and a positive step", s)
+		}
+	}
+	return r, nil
+}
+
+// Values returns the values of r in ascending order.
+func (r Range) Values() []float64 {
+	if r.Step <= 0 {
+		return []float64{r.From}
+	}
+	n := int(math.Floor((r.To-r.From)/r.Step+1e-9)) + 1
+	vals := make([]float64, n)
+	for i := range vals {
+		// Rounded so 0.1+0.2 prints and compares as 0.3.
+		vals[i] = math.Round((r.From+float64(i)*r.Step)*1e9) / 1e9
+	}
+	return vals
+}
+
+// set applies value v of param to p, whose Weights it may replace.
+func set(p *strategy.Params, param string, v float64) error {
+	switch param {
+	case ParamSmoothingAlpha:
+		p.SmoothingAlpha = v
+	case ParamCooldownWeeks:
+		if v != math.Trunc(v) {
+			return fmt.Errorf("%s: %v is not a whole number of weeks", param, v)
+		}
+		p.CooldownWeeks = int(v)
+	case ParamCooldownMargin:
+		p.CooldownMargin = v
+	case ParamMinConfidence:
+		p.MinConfidence = v
+	case ParamBearBoostWeeks:
+		if v != math.Trunc(v) {
+			return fmt.Errorf("%s: %v is not a whole number of weeks", param, v)
+		}
+		p.BearBoostWeeks = int(v)
+	case ParamBearBoostMult:
+		p.BearBoostMultiplier = v
+	default:
+		name, ok := strings.CutPrefix(param, paramWeightPrefix)
+		if !ok {
+			return fmt.Errorf("unknown parameter %q", param)
+		}
+		p.Weights = maps.Clone(p.Weights)
+		if p.Weights == nil {
+			p.Weights = make(map[string]float64)
+		}
+		p.Weights[name] = v
+	}
+	return nil
+}
+
+// SweepMetrics are the outcome columns of a sweep row, after the parameters.
+var SweepMetrics = []string{
+	"total_invested", "final_value", "cash", "irr", "max_drawdown",
+	"reserve_empty_weeks", "weeks", "strategy_version",
+}
+
+// Sweep runs a backtest for every combination of the values of Ranges.
+// The weeks are fetched and their indicators computed once; the
+// combinations are then simulated concurrently from them.
+type Sweep struct {
+	// Runner is the backtest each combination varies: its Params, or the
+	// settings in effect when nil, with the swept parameters replaced.
+	// Factors reading Runner.Now rather than FactorContext.AsOf see the zero
+	// time, as combinations run concurrently.
+	Runner *Runner
+	Ranges []Range
+	// Workers is how many combinations are simulated at a time; zero means
+	// one per CPU.
+	Workers int
+	// Samples limits the sweep to this many combinations drawn at random by
+	// Seed, the same ones for the same Seed; zero runs them all.
+	Samples int
+	Seed    uint64
+}
+
+// Header is the first row of the CSV Run writes: the swept parameters in
+// the order of s.Ranges, then SweepMetrics.
+func (s *Sweep) Header() []string {
+	header := make([]string, 0, len(s.Ranges)+len(SweepMetrics))
+	for _, r := range s.Ranges {
+		header = append(header, r.Param)
+	}
+	return append(header, SweepMetrics...)
+}
+
+// Done reads a CSV an earlier Run of s wrote and returns the parameter
+// columns of its rows joined by commas, as Run skips them. It fails when
+// the header is not s.Header.
+func (s *Sweep) Done(r io.Reader) (map[string]bool, error) {
+	rows, err := csv.NewReader(r).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	done := make(map[string]bool, len(rows))
+	if len(rows) == 0 {
+		return done, nil
+	}
+	if !slices.Equal(rows[0], s.Header()) {
+		return nil, fmt.Errorf("header %v does not match the sweep's %v", rows[0], s.Header())
+	}
+	for _, row := range rows[1:] {
+		done[strings.Join(row[:len(s.Ranges)], ",")] = true
+	}
+	return done, nil
+}
+
+// Run simulates the combinations whose parameters are not in done, see
+// Done, and writes one CSV row per combination to w as it finishes, in no
+// particular order, so an interrupted sweep can be resumed. The header is
+// written first unless done is non-nil. It returns how many combinations
+// were simulated.
+func (s *Sweep) Run(w io.Writer, done map[string]bool) (int, error) {
+	base := strategy.CurrentParams()
+	if s.Runner.Params != nil {
+		base = *s.Runner.Params
+	}
+	values := make([][]float64, len(s.Ranges))
+	total := 1
+	for i, r := range s.Ranges {
+		values[i] = r.Values()
+		hi, lo := bits.Mul64(uint64(total), uint64(len(values[i])))
+		if hi != 0 || lo > math.MaxInt32 {
+			return 0, errors.New("backtest: sweep grid too large")
+		}
+		total = int(lo)
+	}
+
+	// combination decodes index i into one value per range, the last range
+	// varying fastest.
+	combination := func(i int) []float64 {
+		combo := make([]float64, len(values))
+		for j := len(values) - 1; j >= 0; j-- {
+			combo[j] = values[j][i%len(values[j])]
+			i /= len(values[j])
+		}
+		return combo
+	}
+	key := func(combo []float64) []string {
+		cols := make([]string, len(combo))
+		for j, v := range combo {
+			cols[j] = strconv.FormatFloat(v, 'f', -1, 64)
+		}
+		return cols
+	}
+	params := func(combo []float64) (strategy.Params, error) {
+		p := base
+		for j, v := range combo {
+			if err := set(&p, s.Ranges[j].Param, v); err != nil {
+				return p, err
+			}
+		}
+		if err := p.Validate(); err != nil {
+			return p, fmt.Errorf("%s: %w", strings.Join(key(combo), ","), err)
+		}
+		return p, nil
+	}
+
+	type job struct {
+		combo  []float64
+		params strategy.Params
+	}
+	var todo []job
+	for _, i := range s.indices(total) {
+		combo := combination(i)
+		if done[strings.Join(key(combo), ",")] {
+			continue
+		}
+		p, err := params(combo)
+		if err != nil {
+			return 0, err
+		}
+		todo = append(todo, job{combo, p})
+	}
+	cw := csv.NewWriter(w)
+	if done == nil {
+		cw.Write(s.Header())
+	}
+	if len(todo) == 0 {
+		cw.Flush()
+		return 0, cw.Error()
+	}
+
+	sessions, err := s.Runner.prepare()
+	if err != nil {
+		return 0, err
+	}
+
+	type row struct {
+		combo []float64
+		res   *Result
+		err   error
+	}
+	jobs := make(chan job)
+	rows := make(chan row)
+	workers := s.Workers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	var wg sync.WaitGroup
+	for range min(workers, len(todo)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range jobs {
+				r := *s.Runner
+				r.Params = &j.params
+				res, err := r.simulate(sessions)
+				rows <- row{j.combo, res, err}
+			}
+		}()
+	}
+	go func() {
+		for _, j := range todo {
+			jobs <- j
+		}
+		close(jobs)
+		wg.Wait()
+		close(rows)
+	}()
+
+	n := 0
+	var firstErr error
+	for r := range rows {
+		if firstErr != nil {
+			continue // drain so the workers finish
+		}
+		if r.err != nil {
+			firstErr = r.err
+			continue
+		}
+		irr := ""
+		if !math.IsNaN(r.res.IRR) {
+			irr = num(r.res.IRR, 6)
+		}
+		cw.Write(append(key(r.combo),
+			num(r.res.TotalInvested, 2), num(r.res.FinalValue, 2), num(r.res.Cash, 2), irr,
+			num(r.res.MaxDrawdown, 6), strconv.Itoa(r.res.ReserveEmptyWeeks()),
+			strconv.Itoa(len(r.res.Weeks)), r.res.StrategyVersion))
+		cw.Flush()
+		if firstErr = cw.Error(); firstErr == nil {
+			n++
+		}
+	}
+	return n, firstErr
+}
+
+// indices returns the combinations of a grid of total to run, ascending:
+// all of them, or s.Samples drawn at random by s.Seed.
+func (s *Sweep) indices(total int) []int {
+	if s.Samples <= 0 || s.Samples >= total {
+		idx := make([]int, total)
+		for i := range idx {
+			idx[i] = i
+		}
+		return idx
+	}
+	// Floyd's algorithm: Samples distinct indices without listing them all.
+	rng := rand.New(rand.NewPCG(s.Seed, 0))
+	picked := make(map[int]bool, s.Samples)
+	for j := total - s.Samples; j < total; j++ {
+		if t := rng.IntN(j + 1); !picked[t] {
+			picked[t] = true
+		} else {
+			picked[j] = true
+		}
+	}
+	return slices.Sorted(maps.Keys(picked))
+}
@@ -0,0 +1,111 @@
+package backtest
+
+import (
+	"bytes"
+	"encoding/csv"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"MarketSentinel/internal/collector"
+	"MarketSentinel/internal/fund"
+	"MarketSentinel/internal/strategy"
+)
+
+func TestParseRange(t *testing.T) {
+	r, err := ParseRange("weights.ma200=0.1:0.3:0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := r.Values(); !slices.Equal(got, []float64{0.1, 0.2, 0.3}) {
+		t.Errorf("values %v, want 0.1 0.2 0.3", got)
+	}
+	if r, err := ParseRange("cooldown.weeks=2"); err != nil || !slices.Equal(r.Values(), []float64{2}) {
+		t.Errorf("single value: %+v, %v", r, err)
+	}
+	for _, bad := range []string{"ma200", "=1", "x=1:2", "x=a", "x=3:1:1", "x=1:2:0"} {
+		if _, err := ParseRange(bad); err == nil {
+			t.Errorf("%q accepted", bad)
+		}
+	}
+}
+
+func TestSweep(t *testing.T) {
+	newSweep := func() *Sweep {
+		return &Sweep{
+			Runner: &Runner{
+				Fetcher: &collector.MockFetcher{DailyData: crashBars()},
+				Symbol:  "TEST",
+				From:    time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
+				To:      time.Date(2020, 12, 31, 0, 0, 0, 0, time.UTC),
+				Start:   fund.InitialState(10000, fund.Policy{}),
+			},
+			Ranges: []Range{
+				{Param: "weights.ma200", From: 0.2, To: 0.4, Step: 0.2},
+				{Param: ParamCooldownWeeks, From: 0, To: 2, Step: 1},
+			},
+			Workers: 3,
+		}
+	}
+
+	var out bytes.Buffer
+	sw := newSweep()
+	n, err := sw.Run(&out, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows, err := csv.NewReader(strings.NewReader(out.String())).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 6 || len(rows) != 7 || !slices.Equal(rows[0], sw.Header()) {
+		t.Fatalf("ran %d, wrote %d rows, header %v", n, len(rows), rows[0])
+	}
+
+	// The row of the settings in effect matches a plain backtest.
+	p := strategy.CurrentParams()
+	p.Weights = map[string]float64{"ma200": 0.2}
+	r := *sw.Runner
+	r.Params = &p
+	want, err := r.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	i := slices.IndexFunc(rows, func(row []string) bool { return row[0] == "0.2" && row[1] == "0" })
+	if i < 0 || rows[i][2] != num(want.TotalInvested, 2) || rows[i][len(rows[i])-1] != want.StrategyVersion {
+		t.Errorf("row %v, want invested %.2f by %s", rows[i], want.TotalInvested, want.StrategyVersion)
+	}
+	if strategy.Version() == want.StrategyVersion {
+		t.Error("swept weights left the strategy version unchanged")
+	}
+
+	// Resuming skips the rows already written.
+	done, err := sw.Done(strings.NewReader(out.String()))
+	if err != nil || len(done) != 6 {
+		t.Fatalf("done %v, %v", done, err)
+	}
+	sw = newSweep()
+	sw.Ranges[1].To = 3
+	if _, err := sw.Done(strings.NewReader(out.String())); err != nil {
+		t.Fatal(err)
+	}
+	var more bytes.Buffer
+	if n, err := sw.Run(&more, done); err != nil || n != 2 || strings.Count(more.String(), "\n") != 2 {
+		t.Errorf("resume ran %d (%v):\n%s", n, err, more.String())
+	}
+
+	// A sample is the same for the same seed.
+	sw = newSweep()
+	sw.Samples, sw.Seed = 4, 7
+	a, b := sw.indices(6), sw.indices(6)
+	if len(a) != 4 || !slices.Equal(a, b) {
+		t.Errorf("samples %v and %v", a, b)
+	}
+
+	sw = newSweep()
+	sw.Ranges = append(sw.Ranges, Range{Param: "weights.nope", From: 1})
+	if _, err := sw.Run(&bytes.Buffer{}, nil); err == nil {
+		t.Error("unknown factor accepted")
+	}
+}
@@ -0,0 +1,49 @@
+package calculator
+
+import (
+	"errors"
+	"math"
+	"time"
+)
+
+// CashFlow is money paid out (negative) or received (positive) on a date.
+type CashFlow struct {
+	Date   time.Time
+	Amount float64
+}
+
+// XIRR returns the annual rate at which flows have a net present value of
+// zero, discounting by actual days over 365 from the first flow. It bisects
+// between -99% and +1000% a year and fails when the value does not change
+// sign in that range, as when every flow has the same sign.
+func XIRR(flows []CashFlow) (float64, error) {
+	if len(flows) < 2 {
+		return 0, errors.New("need at least two cash flows")
+	}
+	npv := func(rate float64) float64 {
+		sum := 0.0
+		for _, f := range flows {
+			years := f.Date.Sub(flows[0].Date).Hours() / 24 / 365
+			sum += f.Amount / math.Pow(1+rate, years)
+		}
+		return sum
+	}
+	lo, hi := -0.99, 10.0
+	flo, fhi := npv(lo), npv(hi)
+	if math.IsNaN(flo) || math.IsNaN(fhi) || flo*fhi > 0 {
+		return 0, errors.New("no rate in range sets the net present value to zero")
+	}
+	for range 200 {
+		mid := (lo + hi) / 2
+		fmid := npv(mid)
+		if fmid == 0 || hi-lo < 1e-10 {
+			return mid, nil
+		}
+		if flo*fmid < 0 {
+			hi = mid
+		} else {
+			lo, flo = mid, fmid
+		}
+	}
+	return (lo + hi) / 2, nil
+}
@@ -0,0 +1,30 @@
+package calculator
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestXIRR(t *testing.T) {
+	d := func(y int, m time.Month, day int) time.Time { return time.Date(y, m, day, 0, 0, 0, 0, time.UTC) }
+
+	// 10% over 366 days.
+	got, err := XIRR([]CashFlow{{d(2020, 1, 1), -100}, {d(2021, 1, 1), 110}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := math.Pow(1.1, 365.0/366) - 1; math.Abs(got-want) > 1e-8 {
+		t.Errorf("XIRR = %.10f, want %.10f", got, want)
+	}
+
+	// Two buys that end at their cost have a zero return.
+	got, err = XIRR([]CashFlow{{d(2020, 1, 1), -100}, {d(2020, 7, 1), -100}, {d(2021, 1, 1), 200}})
+	if err != nil || math.Abs(got) > 1e-8 {
+		t.Errorf("XIRR = %v, %v, want 0", got, err)
+	}
+
+	if _, err := XIRR([]CashFlow{{d(2020, 1, 1), -100}, {d(2021, 1, 1), -10}}); err == nil {
+		t.Error("want an error when every flow is a payment")
+	}
+}
@@ -2,23 +2,106 @@ package calculator
 
 import (
 	"errors"
+	"math"
 
 	"MarketSentinel/internal/model"
 )
 
 // CalculateSMA computes the simple moving average of the given prices over the specified period.
 func CalculateSMA(prices []float64, period int) (float64, error) {
+	series, err := CalculateSMASeries(prices, period)
+	if err != nil {
+		return 0, err
+	}
+	return series[len(series)-1], nil
+}
+
+// CalculateSMASeries computes the simple moving average ending at each of
+// prices. The first period-1 values, whose window is incomplete, are NaN.
+func CalculateSMASeries(prices []float64, period int) ([]float64, error) {
 	if period <= 0 {
-		return 0, errors.New("period must be positive")
+		return nil, errors.New("period must be positive")
 	}
 	if len(prices) < period {
-		return 0, errors.New("not enough data for SMA calculation")
+		return nil, errors.New("not enough data for SMA calculation")
 	}
+	series := make([]float64, len(prices))
 	sum := 0.0
-	for i := len(prices) - period; i < len(prices); i++ {
-		sum += prices[i]
+	for i, p := range prices {
+		sum += p
+		if i >= period {
+			sum -= prices[i-period]
+		}
+		if i < period-1 {
+			series[i] = math.NaN()
+		} else {
+			series[i] = sum / float64(period)
+		}
+	}
+	return series, nil
+}
+
+// CalculateSlope returns the percentage change of series over its last
+// lookback steps, e.g. 1.2 for a moving average that rose 1.2%. Both ends
+// must be defined and the earlier one positive.
+func CalculateSlope(series []float64, lookback int) (float64, error) {
+	if lookback <= 0 {
+		return 0, errors.New("lookback must be positive")
+	}
+	if len(series) < lookback+1 {
+		return 0, errors.New("not enough data for slope calculation")
+	}
+	last, base := series[len(series)-1], series[len(series)-1-lookback]
+	if math.IsNaN(last) || math.IsNaN(base) {
+		return 0, errors.New("not enough data for slope calculation")
+	}
+	if base <= 0 {
+		return 0, errors.New("non-positive base for slope calculation")
+	}
+	return (last - base) / base * 100, nil
+}
+
+// CrossStreaks compares two series of equal length, such as closes and their
+// moving average, from the end: below is how many of the latest values of a
+// lie below b, and aboveBefore how many values right before those lie above
+// it. An undefined (NaN) value ends a streak.
+func CrossStreaks(a, b []float64) (below, aboveBefore int) {
+	i := min(len(a), len(b)) - 1
+	for ; i >= 0 && a[i] < b[i]; i-- {
+		below++
 	}
-	return sum / float64(period), nil
+	for ; i >= 0 && a[i] > b[i]; i-- {
+		aboveBefore++
+	}
+	return below, aboveBefore
+}
+
+// CalculateZScore returns how many standard deviations current lies from the
+// mean of the last window values of series, using the population standard
+// deviation. It is zero when the window barely varies, where the ratio would
+// be meaningless.
+func CalculateZScore(current float64, series []float64, window int) (float64, error) {
+	if window < 2 {
+		return 0, errors.New("window must be at least 2")
+	}
+	if len(series) < window {
+		return 0, errors.New("not enough data for z-score calculation")
+	}
+	values := series[len(series)-window:]
+	mean := 0.0
+	for _, v := range values {
+		mean += v
+	}
+	mean /= float64(window)
+	variance := 0.0
+	for _, v := range values {
+		variance += (v - mean) * (v - mean)
+	}
+	std := math.Sqrt(variance / float64(window))
+	if std <= 1e-9*max(math.Abs(mean), 1) {
+		return 0, nil
+	}
+	return (current - mean) / std, nil
 }
 
 // CalculateMA200 returns the 200-day simple moving average from daily bars.
@@ -27,6 +110,12 @@ func CalculateMA200(dailyBars []model.OHLCV) (float64, error) {
 	return CalculateSMA(closes, 200)
 }
 
+// CalculateMA20d returns the 20-day simple moving average from daily bars.
+func CalculateMA20d(dailyBars []model.OHLCV) (float64, error) {
+	closes := extractCloses(dailyBars)
+	return CalculateSMA(closes, 20)
+}
+
 // CalculateMA20w returns the 20-week simple moving average from weekly bars.
 func CalculateMA20w(weeklyBars []model.OHLCV) (float64, error) {
 	closes := extractCloses(weeklyBars)
@@ -0,0 +1,147 @@
+package calculator
+
+import (
+	"math"
+	"testing"
+)
+
+func TestCalculateSMASeries(t *testing.T) {
+	// The 10-day SMA example of StockCharts' ChartSchool.
+	closes := []float64{
+		22.27, 22.19, 22.08, 22.17, 22.18, 22.13, 22.23, 22.43, 22.24, 22.29,
+		22.15, 22.39, 22.38, 22.61, 23.36, 24.05, 23.75, 23.83, 23.95, 23.63,
+	}
+	want := []float64{22.22, 22.21, 22.23, 22.26, 22.30, 22.42, 22.61, 22.77, 22.91, 23.08, 23.21}
+
+	series, err := CalculateSMASeries(closes, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(series) != len(closes) {
+		t.Fatalf("got %d values for %d prices", len(series), len(closes))
+	}
+	for i := 0; i < 9; i++ {
+		if !math.IsNaN(series[i]) {
+			t.Errorf("warm-up value %d = %v, want NaN", i, series[i])
+		}
+	}
+	for i, w := range want {
+		if got := series[9+i]; math.Abs(got-w) > 0.006 { // the table rounds to cents
+			t.Errorf("SMA at price %d = %.4f, want %.2f", 10+i, got, w)
+		}
+	}
+
+	for n := 10; n <= len(closes); n++ {
+		got, err := CalculateSMA(closes[:n], 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if math.Abs(got-series[n-1]) > 1e-9 {
+			t.Errorf("CalculateSMA over %d prices = %v, series has %v", n, got, series[n-1])
+		}
+	}
+}
+
+func TestCalculateSMASeries_Errors(t *testing.T) {
+	if _, err := CalculateSMASeries([]float64{1, 2}, 3); err == nil {
+		t.Error("fewer prices than the period accepted")
+	}
+	if _, err := CalculateSMASeries([]float64{1, 2}, 0); err == nil {
+		t.Error("period 0 accepted")
+	}
+	if got, err := CalculateSMASeries([]float64{4}, 1); err != nil || got[0] != 4 {
+		t.Errorf("period 1: got %v, %v", got, err)
+	}
+}
+
+func TestCalculateZScore(t *testing.T) {
+	// The last four values 2..5 have mean 3.5 and population standard
+	// deviation sqrt(1.25).
+	series := []float64{100, 2, 3, 4, 5}
+	z, err := CalculateZScore(6, series, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := 2.5 / math.Sqrt(1.25); math.Abs(z-want) > 1e-12 {
+		t.Errorf("z = %v, want %v", z, want)
+	}
+
+	for name, flat := range map[string][]float64{
+		"constant":      {4000, 4000, 4000, 4000},
+		"near-constant": {4000, 4000 + 1e-10, 4000 - 1e-10, 4000},
+	} {
+		z, err := CalculateZScore(4100, flat, 4)
+		if err != nil || z != 0 {
+			t.Errorf("%s window: z = %v, %v; want 0 rather than a division by zero", name, z, err)
+		}
+	}
+
+	if _, err := CalculateZScore(1, series, 6); err == nil {
+		t.Error("window longer than the series accepted")
+	}
+	if _, err := CalculateZScore(1, series, 1); err == nil {
+		t.Error("window of 1 accepted")
+	}
+}
+
+func TestCalculateSlope(t *testing.T) {
+	rising := make([]float64, 60)
+	falling := make([]float64, 60)
+	flat := make([]float64, 60)
+	for i := range rising {
+		rising[i] = 100 + float64(i)
+		falling[i] = 200 - float64(i)
+		flat[i] = 100
+	}
+	for _, tt := range []struct {
+		name   string
+		closes []float64
+		want   float64
+	}{
+		// SMA(10) of 100+i ends at 154.5 and stood at 134.5 20 bars back.
+		{"rising", rising, 20.0 / 134.5 * 100},
+		{"falling", falling, -20.0 / 165.5 * 100},
+		{"flat", flat, 0},
+	} {
+		sma, err := CalculateSMASeries(tt.closes, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := CalculateSlope(sma, 20)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("%s: slope %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	// The average only starts at bar 9, so 55 bars back is undefined.
+	sma, _ := CalculateSMASeries(rising, 10)
+	if _, err := CalculateSlope(sma, 55); err == nil {
+		t.Error("lookback into the warm-up: want an error")
+	}
+	if _, err := CalculateSlope(sma, 60); err == nil {
+		t.Error("lookback past the series: want an error")
+	}
+}
+
+func TestCrossStreaks(t *testing.T) {
+	nan := math.NaN()
+	for _, tt := range []struct {
+		name               string
+		a, b               []float64
+		below, aboveBefore int
+	}{
+		{"fresh break", []float64{5, 5, 5, 1, 1}, []float64{3, 3, 3, 3, 3}, 2, 3},
+		{"still above", []float64{5, 5, 5}, []float64{3, 3, 3}, 0, 3},
+		{"touch ends the run-up", []float64{5, 3, 5, 1}, []float64{3, 3, 3, 3}, 1, 1},
+		{"undefined average", []float64{5, 5, 1}, []float64{nan, 3, 3}, 1, 1},
+		{"empty", nil, nil, 0, 0},
+	} {
+		below, above := CrossStreaks(tt.a, tt.b)
+		if below != tt.below || above != tt.aboveBefore {
+			t.Errorf("%s: streaks %d/%d, want %d/%d", tt.name, below, above, tt.below, tt.aboveBefore)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package calculator
+
+import (
+	"errors"
+	"math"
+
+	"MarketSentinel/internal/model"
+)
+
+// Standard MACD periods: 12- and 26-bar EMAs and a 9-bar signal line.
+const (
+	MACDFast   = 12
+	MACDSlow   = 26
+	MACDSignal = 9
+)
+
+// CalculateEMA computes the exponential moving average of the given prices over the specified period.
+func CalculateEMA(prices []float64, period int) (float64, error) {
+	series, err := CalculateEMASeries(prices, period)
+	if err != nil {
+		return 0, err
+	}
+	return series[len(series)-1], nil
+}
+
+// CalculateEMASeries computes the exponential moving average ending at each of
+// prices, with smoothing 2/(period+1). It is seeded with the simple average of
+// the first period prices; the period-1 values before it are NaN.
+func CalculateEMASeries(prices []float64, period int) ([]float64, error) {
+	if period <= 0 {
+		return nil, errors.New("period must be positive")
+	}
+	if len(prices) < period {
+		return nil, errors.New("not enough data for EMA calculation")
+	}
+	series := make([]float64, len(prices))
+	k := 2 / float64(period+1)
+	sum := 0.0
+	for i, p := range prices {
+		switch {
+		case i < period-1:
+			sum += p
+			series[i] = math.NaN()
+		case i == period-1:
+			series[i] = (sum + p) / float64(period)
+		default:
+			series[i] = series[i-1] + k*(p-series[i-1])
+		}
+	}
+	return series, nil
+}
+
+// CalculateMACD returns the latest MACD line, signal line and histogram of
+// bars' closes; see CalculateMACDSeries.
+func CalculateMACD(bars []model.OHLCV, fast, slow, signal int) (macd, signalLine, histogram float64, err error) {
+	m, s, h, err := CalculateMACDSeries(bars, fast, slow, signal)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	n := len(bars) - 1
+	return m[n], s[n], h[n], nil
+}
+
+// CalculateMACDSeries computes, at each of bars, the MACD line (the fast EMA
+// of closes minus the slow one), its signal EMA and the histogram (MACD minus
+// signal). The MACD line starts at bar slow-1 and the signal line and
+// histogram at bar slow+signal-2; earlier values are NaN. It needs
+// slow+signal-1 bars.
+func CalculateMACDSeries(bars []model.OHLCV, fast, slow, signal int) (macd, signalLine, histogram []float64, err error) {
+	if fast <= 0 || signal <= 0 || slow <= fast {
+		return nil, nil, nil, errors.New("MACD periods must be positive with fast < slow")
+	}
+	if len(bars) < slow+signal-1 {
+		return nil, nil, nil, errors.New("not enough data for MACD calculation")
+	}
+	closes := extractCloses(bars)
+	fastEMA, _ := CalculateEMASeries(closes, fast)
+	slowEMA, _ := CalculateEMASeries(closes, slow)
+
+	macd = make([]float64, len(closes))
+	for i := range macd {
+		macd[i] = fastEMA[i] - slowEMA[i] // NaN until the slow EMA starts
+	}
+	sig, err := CalculateEMASeries(macd[slow-1:], signal)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	signalLine = make([]float64, len(closes))
+	histogram = make([]float64, len(closes))
+	for i := range signalLine {
+		if i < slow-1 {
+			signalLine[i], histogram[i] = math.NaN(), math.NaN()
+			continue
+		}
+		signalLine[i] = sig[i-slow+1]
+		histogram[i] = macd[i] - signalLine[i]
+	}
+	return macd, signalLine, histogram, nil
+}
@@ -0,0 +1,108 @@
+package calculator
+
+import (
+	"math"
+	"testing"
+)
+
+func TestCalculateEMASeries_Reference(t *testing.T) {
+	// The 10-day EMA example of StockCharts' ChartSchool, seeded with the
+	// 10-day SMA.
+	closes := []float64{
+		22.27, 22.19, 22.08, 22.17, 22.18, 22.13, 22.23, 22.43, 22.24, 22.29,
+		22.15, 22.39, 22.38, 22.61, 23.36, 24.05, 23.75, 23.83, 23.95, 23.63,
+		23.82, 23.87, 23.65, 23.19, 23.10, 23.33, 22.68, 23.10, 22.40, 22.17,
+	}
+	want := []float64{
+		22.22, 22.21, 22.24, 22.27, 22.33, 22.52, 22.80, 22.97, 23.13, 23.28,
+		23.34, 23.43, 23.51, 23.53, 23.47, 23.40, 23.39, 23.26, 23.23, 23.08,
+		22.92,
+	}
+
+	series, err := CalculateEMASeries(closes, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 9; i++ {
+		if !math.IsNaN(series[i]) {
+			t.Errorf("warm-up value %d = %v, want NaN", i, series[i])
+		}
+	}
+	for i, w := range want {
+		if got := series[9+i]; math.Abs(got-w) > 0.005 {
+			t.Errorf("EMA at close %d = %.4f, want %.2f", 10+i, got, w)
+		}
+	}
+	if last, _ := CalculateEMA(closes, 10); last != series[len(series)-1] {
+		t.Errorf("CalculateEMA = %v, series ends %v", last, series[len(series)-1])
+	}
+	if _, err := CalculateEMA(closes[:9], 10); err == nil {
+		t.Error("fewer prices than the period accepted")
+	}
+}
+
+func TestCalculateMACD_LinearTrend(t *testing.T) {
+	// On a straight line an SMA-seeded EMA lags the price by exactly
+	// slope*(period-1)/2, so the 12/26 MACD is slope*(25-11)/2 throughout and
+	// its signal line the same, leaving a zero histogram.
+	closes := make([]float64, 60)
+	for i := range closes {
+		closes[i] = 100 + 2*float64(i)
+	}
+	bars := barsOf(closes)
+
+	macd, sig, hist, err := CalculateMACDSeries(bars, MACDFast, MACDSlow, MACDSignal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range bars {
+		switch {
+		case i < MACDSlow-1:
+			if !math.IsNaN(macd[i]) || !math.IsNaN(sig[i]) {
+				t.Errorf("bar %d: MACD %v, signal %v before the slow EMA starts", i, macd[i], sig[i])
+			}
+		case i < MACDSlow+MACDSignal-2:
+			if math.Abs(macd[i]-14) > 1e-9 || !math.IsNaN(sig[i]) || !math.IsNaN(hist[i]) {
+				t.Errorf("bar %d: MACD %v, signal %v, histogram %v", i, macd[i], sig[i], hist[i])
+			}
+		default:
+			if math.Abs(macd[i]-14) > 1e-9 || math.Abs(sig[i]-14) > 1e-9 || math.Abs(hist[i]) > 1e-9 {
+				t.Errorf("bar %d: MACD %v, signal %v, histogram %v, want 14, 14, 0", i, macd[i], sig[i], hist[i])
+			}
+		}
+	}
+
+	m, s, h, err := CalculateMACD(bars, MACDFast, MACDSlow, MACDSignal)
+	if err != nil || m != macd[59] || s != sig[59] || h != hist[59] {
+		t.Errorf("CalculateMACD = %v, %v, %v, %v; series ends %v, %v, %v", m, s, h, err, macd[59], sig[59], hist[59])
+	}
+}
+
+func TestCalculateMACD_Turn(t *testing.T) {
+	// A rally followed by a selloff: the MACD line crosses below its signal.
+	closes := make([]float64, 80)
+	for i := range closes {
+		if i < 50 {
+			closes[i] = 100 + float64(i)
+		} else {
+			closes[i] = 150 - 3*float64(i-50)
+		}
+	}
+	m, s, h, err := CalculateMACD(barsOf(closes), MACDFast, MACDSlow, MACDSignal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m >= 0 || h >= 0 || math.Abs(h-(m-s)) > 1e-12 {
+		t.Errorf("after the selloff: MACD %v, signal %v, histogram %v", m, s, h)
+	}
+}
+
+func TestCalculateMACD_Errors(t *testing.T) {
+	bars := barsOf(make([]float64, MACDSlow+MACDSignal-2))
+	if _, _, _, err := CalculateMACD(bars, MACDFast, MACDSlow, MACDSignal); err == nil {
+		t.Errorf("%d bars accepted, want %d", len(bars), MACDSlow+MACDSignal-1)
+	}
+	if _, _, _, err := CalculateMACD(barsOf(make([]float64, 60)), 26, 12, 9); err == nil {
+		t.Error("fast period above the slow one accepted")
+	}
+}
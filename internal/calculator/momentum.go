@@ -0,0 +1,35 @@
+package calculator
+
+import (
+	"errors"
+	"fmt"
+
+	"MarketSentinel/internal/model"
+)
+
+// InsufficientDataError is returned when a series is shorter than a
+// calculation needs.
+type InsufficientDataError struct {
+	Need, Have int // bars
+}
+
+func (e *InsufficientDataError) Error() string {
+	return fmt.Sprintf("need %d bars, have %d", e.Need, e.Have)
+}
+
+// CalculateROC returns the percentage change of the last close over the
+// lookback bars before it, e.g. -8.5 for an 8.5% decline. It returns an
+// *InsufficientDataError when bars holds fewer than lookback+1 bars.
+func CalculateROC(bars []model.OHLCV, lookback int) (float64, error) {
+	if lookback <= 0 {
+		return 0, errors.New("lookback must be positive")
+	}
+	if len(bars) < lookback+1 {
+		return 0, &InsufficientDataError{Need: lookback + 1, Have: len(bars)}
+	}
+	last, base := bars[len(bars)-1].Close, bars[len(bars)-1-lookback].Close
+	if base <= 0 {
+		return 0, fmt.Errorf("non-positive close %.2f %d bars ago", base, lookback)
+	}
+	return (last/base - 1) * 100, nil
+}
@@ -0,0 +1,38 @@
+package calculator
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestCalculateROC(t *testing.T) {
+	closes := []float64{200, 180, 150, 160, 170}
+	for _, tt := range []struct {
+		lookback int
+		want     float64
+	}{
+		{1, 6.25},  // 160 -> 170
+		{3, -5.55}, // 180 -> 170, rounded below
+		{4, -15},   // 200 -> 170
+	} {
+		got, err := CalculateROC(barsOf(closes), tt.lookback)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if math.Abs(got-tt.want) > 0.006 {
+			t.Errorf("ROC over %d bars = %.4f%%, want %.2f%%", tt.lookback, got, tt.want)
+		}
+	}
+}
+
+func TestCalculateROC_ShortHistory(t *testing.T) {
+	_, err := CalculateROC(barsOf([]float64{100, 101, 102}), 3)
+	var short *InsufficientDataError
+	if !errors.As(err, &short) || short.Need != 4 || short.Have != 3 {
+		t.Errorf("lookback longer than the series: got %v, want an InsufficientDataError needing 4 of 3 bars", err)
+	}
+	if _, err := CalculateROC(barsOf([]float64{100, 101}), 0); err == nil || errors.As(err, &short) {
+		t.Errorf("lookback 0: got %v, want a plain error", err)
+	}
+}
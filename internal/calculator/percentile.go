@@ -0,0 +1,68 @@
+package calculator
+
+import (
+	"errors"
+	"math"
+
+	"MarketSentinel/internal/model"
+)
+
+// CalculatePercentileRank returns where current ranks among the values of
+// series as a percentage: the share of them below it, counting current as one
+// more value and values equal to it as half below. It lies strictly between
+// 0 and 100, a middling value of a flat series being 50. NaN values are
+// skipped.
+func CalculatePercentileRank(series []float64, current float64) (float64, error) {
+	var n, below, equal int
+	for _, v := range series {
+		switch {
+		case math.IsNaN(v):
+			continue
+		case v < current:
+			below++
+		case v == current:
+			equal++
+		}
+		n++
+	}
+	if n == 0 {
+		return 0, errors.New("no values to rank against")
+	}
+	return (float64(below) + float64(equal+1)/2) / float64(n+1) * 100, nil
+}
+
+// RSIHistory returns the RSI over period at each of the last window bars
+// where it is defined, oldest first.
+func RSIHistory(bars []model.OHLCV, period, window int) ([]float64, error) {
+	series, err := CalculateRSISeries(bars, period)
+	if err != nil {
+		return nil, err
+	}
+	return lastDefined(series, window), nil
+}
+
+// MADeviationHistory returns the percentage deviation of the close from its
+// simple moving average over period, e.g. -8.5, at each of the last window
+// bars where the average is defined, oldest first.
+func MADeviationHistory(bars []model.OHLCV, period, window int) ([]float64, error) {
+	closes := extractCloses(bars)
+	sma, err := CalculateSMASeries(closes, period)
+	if err != nil {
+		return nil, err
+	}
+	dev := make([]float64, len(closes))
+	for i, c := range closes {
+		dev[i] = (c - sma[i]) / sma[i] * 100 // NaN until the average starts
+	}
+	return lastDefined(dev, window), nil
+}
+
+// lastDefined returns the values of series that are not NaN, at most the
+// last window of them.
+func lastDefined(series []float64, window int) []float64 {
+	start := max(0, len(series)-window)
+	for start < len(series) && math.IsNaN(series[start]) {
+		start++
+	}
+	return series[start:]
+}
@@ -0,0 +1,56 @@
+package calculator
+
+import (
+	"math"
+	"testing"
+)
+
+func TestCalculatePercentileRank(t *testing.T) {
+	series := []float64{10, 20, 30, 40, math.NaN(), 50, 60, 70, 80, 90}
+	for _, tt := range []struct {
+		current, want float64
+	}{
+		{5, 5},   // below everything: half of itself among 10 values
+		{95, 95}, // above everything
+		{50, 50}, // ties with one value: four below, two halves
+		{55, 55}, // five below
+		{math.Inf(1), 95},
+	} {
+		got, err := CalculatePercentileRank(series, tt.current)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("rank of %v = %v, want %v", tt.current, got, tt.want)
+		}
+	}
+
+	// A flat series ranks its own value in the middle.
+	if got, _ := CalculatePercentileRank([]float64{7, 7, 7}, 7); got != 50 {
+		t.Errorf("rank in a flat series = %v, want 50", got)
+	}
+	if _, err := CalculatePercentileRank([]float64{math.NaN()}, 1); err == nil {
+		t.Error("no values: want an error")
+	}
+}
+
+func TestMADeviationHistory(t *testing.T) {
+	closes := []float64{10, 10, 10, 13, 7, 10}
+	got, err := MADeviationHistory(barsOf(closes), 3, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Averages 10, 11, 10, 10 from the third close on.
+	want := []float64{0, 100.0 * 2 / 11, -30, 0}
+	if len(got) != len(want) {
+		t.Fatalf("got %d values, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if math.Abs(got[i]-want[i]) > 1e-9 {
+			t.Errorf("deviation %d = %v, want %v", i, got[i], want[i])
+		}
+	}
+	if got, _ := MADeviationHistory(barsOf(closes), 3, 2); len(got) != 2 || got[1] != 0 {
+		t.Errorf("window 2 = %v, want the last two deviations", got)
+	}
+}
@@ -3,40 +3,65 @@ package calculator
 import (
 	"errors"
 	"math"
+	"sort"
 
 	"MarketSentinel/internal/model"
 )
 
-// Calculate52WeekRange scans the most recent 252 trading days and returns the high and low.
+// Calculate52WeekRange returns the high and low of the daily bars in the 365
+// calendar days up to the newest one, however many sessions the market
+// trades in a year.
 func Calculate52WeekRange(dailyBars []model.OHLCV) (high, low float64, err error) {
+	return CalculateRangeByDate(dailyBars, 365)
+}
+
+// Calculate30DayRange returns the high and low of the daily bars in the 30
+// calendar days up to the newest one.
+func Calculate30DayRange(dailyBars []model.OHLCV) (high, low float64, err error) {
+	return CalculateRangeByDate(dailyBars, 30)
+}
+
+// CalculateRangeByDate returns the high and low of the daily bars dated less
+// than days calendar days before the newest one. A series shorter than the
+// window gives the range of all its bars.
+func CalculateRangeByDate(dailyBars []model.OHLCV, days int) (high, low float64, err error) {
 	if len(dailyBars) == 0 {
 		return 0, 0, errors.New("no daily bars provided")
 	}
-	n := len(dailyBars)
-	start := n - 252
-	if start < 0 {
-		start = 0
+	if days <= 0 {
+		return 0, 0, errors.New("days must be positive")
 	}
-	high = math.Inf(-1)
-	low = math.Inf(1)
-	for i := start; i < n; i++ {
-		if dailyBars[i].High > high {
-			high = dailyBars[i].High
-		}
-		if dailyBars[i].Low < low {
-			low = dailyBars[i].Low
+	cutoff := dailyBars[len(dailyBars)-1].Time.AddDate(0, 0, -days)
+	start := sort.Search(len(dailyBars), func(i int) bool { return dailyBars[i].Time.After(cutoff) })
+	return CalculateRange(dailyBars[start:], len(dailyBars)-start)
+}
+
+// SessionsSinceLow returns how many daily bars follow the one with the lowest
+// low among those dated less than days calendar days before the newest one;
+// zero when the newest bar set the low. On a tie the earliest bar counts, as
+// merely matching a low does not set a new one.
+func SessionsSinceLow(dailyBars []model.OHLCV, days int) (int, error) {
+	if len(dailyBars) == 0 {
+		return 0, errors.New("no daily bars provided")
+	}
+	cutoff := dailyBars[len(dailyBars)-1].Time.AddDate(0, 0, -days)
+	start := sort.Search(len(dailyBars), func(i int) bool { return dailyBars[i].Time.After(cutoff) })
+	low := start
+	for i := start; i < len(dailyBars); i++ {
+		if dailyBars[i].Low < dailyBars[low].Low {
+			low = i
 		}
 	}
-	return high, low, nil
+	return len(dailyBars) - 1 - low, nil
 }
 
-// Calculate30DayRange scans the most recent 22 trading days and returns the high and low.
-func Calculate30DayRange(dailyBars []model.OHLCV) (high, low float64, err error) {
+// CalculateRange scans the most recent sessions daily bars and returns the high and low.
+func CalculateRange(dailyBars []model.OHLCV, sessions int) (high, low float64, err error) {
 	if len(dailyBars) == 0 {
 		return 0, 0, errors.New("no daily bars provided")
 	}
 	n := len(dailyBars)
-	start := n - 22
+	start := n - sessions
 	if start < 0 {
 		start = 0
 	}
@@ -53,6 +78,33 @@ func Calculate30DayRange(dailyBars []model.OHLCV) (high, low float64, err error)
 	return high, low, nil
 }
 
+// CalculateDrawdown returns how far current is below high as a fraction, e.g.
+// -0.12 for 12% below; zero at or above high, or when high is not positive.
+func CalculateDrawdown(current, high float64) float64 {
+	if high <= 0 || current >= high {
+		return 0
+	}
+	return (current - high) / high
+}
+
+// CalculateMaxDrawdown returns the deepest decline of bars' closes from their
+// running peak to a later trough, as a fraction such as -0.34; zero when the
+// closes never fall below an earlier peak.
+func CalculateMaxDrawdown(bars []model.OHLCV) (float64, error) {
+	if len(bars) == 0 {
+		return 0, errors.New("no bars provided")
+	}
+	peak, maxDD := bars[0].Close, 0.0
+	for _, b := range bars[1:] {
+		if b.Close > peak {
+			peak = b.Close
+		} else if dd := CalculateDrawdown(b.Close, peak); dd < maxDD {
+			maxDD = dd
+		}
+	}
+	return maxDD, nil
+}
+
 // Calculate52WeekPosition returns where the current price sits within the 52-week range (0.0~1.0).
 func Calculate52WeekPosition(current, high, low float64) (float64, error) {
 	if high == low {
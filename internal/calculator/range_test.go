@@ -0,0 +1,136 @@
+package calculator
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"MarketSentinel/internal/model"
+)
+
+func TestCalculateDrawdown(t *testing.T) {
+	for _, tt := range []struct{ current, high, want float64 }{
+		{88, 100, -0.12},
+		{100, 100, 0},
+		{105, 100, 0}, // the quote is ahead of the daily highs
+		{50, 0, 0},
+	} {
+		if got := CalculateDrawdown(tt.current, tt.high); math.Abs(got-tt.want) > 1e-12 {
+			t.Errorf("CalculateDrawdown(%v, %v) = %v, want %v", tt.current, tt.high, got, tt.want)
+		}
+	}
+}
+
+func TestCalculateMaxDrawdown(t *testing.T) {
+	tests := []struct {
+		name   string
+		closes []float64
+		want   float64
+	}{
+		// Down 40% to the trough and fully recovered: the recovery does not
+		// undo the drawdown.
+		{"V-shaped", []float64{100, 90, 75, 60, 75, 90, 100, 105}, -0.40},
+		// Each step down bounces short of the previous high, so the running
+		// peak stays at 100 and the drawdown is to the final low.
+		{"staircase down", []float64{100, 92, 95, 85, 88, 78, 81, 70, 72}, -0.30},
+		// A deeper decline from a later, higher peak wins over an earlier one.
+		{"later peak", []float64{100, 80, 130, 91, 120}, -0.30},
+		{"only rising", []float64{100, 101, 102, 103}, 0},
+		{"single bar", []float64{100}, 0},
+	}
+	for _, tt := range tests {
+		got, err := CalculateMaxDrawdown(barsOf(tt.closes))
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if math.Abs(got-tt.want) > 1e-12 {
+			t.Errorf("%s: max drawdown %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	if _, err := CalculateMaxDrawdown(nil); err == nil {
+		t.Error("no bars accepted")
+	}
+}
+
+// datedBars returns one bar per step from start with high and low both i,
+// the bar's index.
+func datedBars(start time.Time, step time.Duration, n int, weekdays bool) []model.OHLCV {
+	var bars []model.OHLCV
+	for t := start; len(bars) < n; t = t.Add(step) {
+		if weekdays && (t.Weekday() == time.Saturday || t.Weekday() == time.Sunday) {
+			continue
+		}
+		i := float64(len(bars))
+		bars = append(bars, model.OHLCV{Time: t, High: i, Low: i, Close: i})
+	}
+	return bars
+}
+
+func TestCalculate52WeekRange_ByDate(t *testing.T) {
+	start := time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name      string
+		bars      []model.OHLCV
+		high, low float64
+	}{
+		// Crypto trades every day: the window holds 365 bars, not 252.
+		{"crypto", datedBars(start, 24*time.Hour, 500, false), 499, 135},
+		// Weekdays only: 52 weeks and a day are 261 sessions.
+		{"equity", datedBars(start, 24*time.Hour, 500, true), 499, 239},
+		// Thinner than a year: every bar counts.
+		{"thin", datedBars(start, 24*time.Hour, 150, true), 149, 0},
+	}
+	for _, tt := range tests {
+		high, low, err := Calculate52WeekRange(tt.bars)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if high != tt.high || low != tt.low {
+			t.Errorf("%s: range %v..%v, want %v..%v", tt.name, low, high, tt.low, tt.high)
+		}
+	}
+}
+
+func TestCalculate30DayRange_ByDate(t *testing.T) {
+	bars := datedBars(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), 24*time.Hour, 100, true)
+	high, low, err := Calculate30DayRange(bars)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The newest bar is Friday 2024-05-17; 30 days cover 2024-04-18 on,
+	// 22 sessions.
+	if high != 99 || low != 78 {
+		t.Errorf("range %v..%v, want 78..99", low, high)
+	}
+	if _, _, err := Calculate30DayRange(nil); err == nil {
+		t.Error("no bars: want an error")
+	}
+}
+
+func TestSessionsSinceLow(t *testing.T) {
+	start := time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC)
+	bars := datedBars(start, 24*time.Hour, 500, false)
+	for i := range bars {
+		bars[i].Low = 100
+	}
+	bars[480].Low = 50
+	bars[490].Low = 50
+	bars[100].Low = 10 // outside the window
+
+	n, err := SessionsSinceLow(bars, 365)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 19 {
+		t.Errorf("sessions since low = %d, want 19 (a matching low is not new)", n)
+	}
+
+	bars[499].Low = 40
+	if n, _ := SessionsSinceLow(bars, 365); n != 0 {
+		t.Errorf("sessions since low = %d, want 0 on the newest bar", n)
+	}
+	if _, err := SessionsSinceLow(nil, 365); err == nil {
+		t.Error("expected an error without bars")
+	}
+}
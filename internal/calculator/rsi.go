@@ -2,6 +2,7 @@ package calculator
 
 import (
 	"errors"
+	"math"
 
 	"MarketSentinel/internal/model"
 )
@@ -9,45 +10,112 @@ import (
 // CalculateRSI computes the Wilder-smoothed RSI over the given period.
 // Requires at least period+1 bars. Returns 50.0 if data is insufficient.
 func CalculateRSI(bars []model.OHLCV, period int) (float64, error) {
-	if period <= 0 {
-		return 0, errors.New("period must be positive")
+	series, err := CalculateRSISeries(bars, period)
+	if err != nil {
+		return 0, err
 	}
 	if len(bars) < period+1 {
 		return 50.0, nil // default when data insufficient
 	}
+	return series[len(series)-1], nil
+}
 
+// CalculateRSISeries computes the Wilder-smoothed RSI at each of bars. The
+// first period values, before period price changes are available, are NaN;
+// with fewer than period+1 bars every value is.
+func CalculateRSISeries(bars []model.OHLCV, period int) ([]float64, error) {
+	if period <= 0 {
+		return nil, errors.New("period must be positive")
+	}
 	closes := extractCloses(bars)
+	series := make([]float64, len(closes))
+	for i := range series {
+		series[i] = math.NaN()
+	}
+	if len(closes) < period+1 {
+		return series, nil
+	}
+
+	var state RSIState
+	series[period], _ = state.init(closes[:period+1], period)
+	for i := period + 1; i < len(closes); i++ {
+		series[i] = state.Update(closes[i])
+	}
+	return series, nil
+}
+
+// RSIState carries Wilder's smoothing of an RSI forward one close at a time,
+// so a series that grows by a bar a day does not have to be smoothed again
+// from its start. Fed the same closes, it gives exactly the values of
+// CalculateRSISeries. The zero value is not usable until Init.
+type RSIState struct {
+	period           int
+	avgGain, avgLoss float64
+	last             float64 // the latest close fed in
+	rsi              float64
+}
+
+// Init starts the state over bars, which need at least period+1 closes, and
+// returns the RSI at the last of them.
+func (s *RSIState) Init(bars []model.OHLCV, period int) (float64, error) {
+	if period <= 0 {
+		return 0, errors.New("period must be positive")
+	}
+	if len(bars) < period+1 {
+		return 0, errors.New("not enough data for RSI calculation")
+	}
+	return s.init(extractCloses(bars), period)
+}
+
+// init is Init over closes.
+func (s *RSIState) init(closes []float64, period int) (float64, error) {
+	*s = RSIState{period: period}
 
 	// Initial average gain/loss over the first `period` changes
-	var avgGain, avgLoss float64
 	for i := 1; i <= period; i++ {
 		change := closes[i] - closes[i-1]
 		if change > 0 {
-			avgGain += change
+			s.avgGain += change
 		} else {
-			avgLoss -= change // make positive
+			s.avgLoss -= change // make positive
 		}
 	}
-	avgGain /= float64(period)
-	avgLoss /= float64(period)
+	s.avgGain /= float64(period)
+	s.avgLoss /= float64(period)
+	s.last = closes[period]
+	s.rsi = rsiOf(s.avgGain, s.avgLoss)
 
-	// Wilder smoothing for remaining bars
-	for i := period + 1; i < len(closes); i++ {
-		change := closes[i] - closes[i-1]
-		gain, loss := 0.0, 0.0
-		if change > 0 {
-			gain = change
-		} else {
-			loss = -change
-		}
-		avgGain = (avgGain*float64(period-1) + gain) / float64(period)
-		avgLoss = (avgLoss*float64(period-1) + loss) / float64(period)
+	for _, c := range closes[period+1:] {
+		s.Update(c)
 	}
+	return s.rsi, nil
+}
+
+// Update feeds in the close after the last one seen and returns the RSI
+// including it.
+func (s *RSIState) Update(nextClose float64) float64 {
+	change := nextClose - s.last
+	gain, loss := 0.0, 0.0
+	if change > 0 {
+		gain = change
+	} else {
+		loss = -change
+	}
+	s.avgGain = (s.avgGain*float64(s.period-1) + gain) / float64(s.period)
+	s.avgLoss = (s.avgLoss*float64(s.period-1) + loss) / float64(s.period)
+	s.last = nextClose
+	s.rsi = rsiOf(s.avgGain, s.avgLoss)
+	return s.rsi
+}
+
+// RSI returns the RSI at the last close fed in.
+func (s *RSIState) RSI() float64 { return s.rsi }
 
+// rsiOf converts average gain and loss into an RSI.
+func rsiOf(avgGain, avgLoss float64) float64 {
 	if avgLoss == 0 {
-		return 100.0, nil
+		return 100.0
 	}
 	rs := avgGain / avgLoss
-	rsi := 100.0 - 100.0/(1.0+rs)
-	return rsi, nil
+	return 100.0 - 100.0/(1.0+rs)
 }
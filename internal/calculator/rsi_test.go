@@ -0,0 +1,128 @@
+package calculator
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+	"time"
+
+	"MarketSentinel/internal/model"
+)
+
+// stockChartsCloses are the 33 closes of the RSI worked example in
+// StockCharts' ChartSchool, with the 14-period RSI from the 15th close on as
+// its spreadsheet computes it, rounded to two decimals. (The article's text
+// rounds the average gain and loss first and so quotes 70.53 for 70.46.)
+var (
+	stockChartsCloses = []float64{
+		44.34, 44.09, 44.15, 43.61, 44.33, 44.83, 45.10, 45.42, 45.84, 46.08,
+		45.89, 46.03, 45.61, 46.28, 46.28, 46.00, 46.03, 46.41, 46.22, 45.64,
+		46.21, 46.25, 45.71, 46.45, 45.78, 45.35, 44.03, 44.18, 44.22, 44.57,
+		43.42, 42.66, 43.13,
+	}
+	stockChartsRSI14 = []float64{
+		70.46, 66.25, 66.48, 69.35, 66.29, 57.92, 62.88, 63.21, 56.01, 62.34,
+		54.67, 50.39, 40.02, 41.49, 41.90, 45.50, 37.32, 33.09, 37.79,
+	}
+)
+
+func barsOf(closes []float64) []model.OHLCV {
+	bars := make([]model.OHLCV, len(closes))
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i, c := range closes {
+		bars[i] = model.OHLCV{Time: start.AddDate(0, 0, i), Open: c, High: c, Low: c, Close: c}
+	}
+	return bars
+}
+
+func TestCalculateRSISeries_Reference(t *testing.T) {
+	series, err := CalculateRSISeries(barsOf(stockChartsCloses), 14)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(series) != len(stockChartsCloses) {
+		t.Fatalf("got %d values for %d bars", len(series), len(stockChartsCloses))
+	}
+	for i := 0; i < 14; i++ {
+		if !math.IsNaN(series[i]) {
+			t.Errorf("warm-up value %d = %v, want NaN", i, series[i])
+		}
+	}
+	for i, want := range stockChartsRSI14 {
+		if got := series[14+i]; math.Abs(got-want) > 0.005 {
+			t.Errorf("RSI at close %d = %.4f, want %.2f", 15+i, got, want)
+		}
+	}
+}
+
+func TestCalculateRSI_LastOfSeries(t *testing.T) {
+	bars := barsOf(stockChartsCloses)
+	series, _ := CalculateRSISeries(bars, 14)
+	for n := 15; n <= len(bars); n++ {
+		got, err := CalculateRSI(bars[:n], 14)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// Wilder smoothing restarts at the first bar, so the scalar on a
+		// prefix equals the series value at the prefix's end.
+		if got != series[n-1] {
+			t.Errorf("CalculateRSI over %d bars = %v, series has %v", n, got, series[n-1])
+		}
+	}
+
+	if got, err := CalculateRSI(bars[:14], 14); err != nil || got != 50 {
+		t.Errorf("short input: got %v, %v, want the default 50", got, err)
+	}
+	if series, err := CalculateRSISeries(bars[:14], 14); err != nil || !math.IsNaN(series[13]) {
+		t.Errorf("short input series: got %v, %v, want all NaN", series, err)
+	}
+	if got, _ := CalculateRSI(barsOf([]float64{1, 2, 3, 4}), 3); got != 100 {
+		t.Errorf("RSI without losses = %v, want 100", got)
+	}
+	if _, err := CalculateRSISeries(bars, 0); err == nil {
+		t.Error("period 0 accepted")
+	}
+}
+
+func TestRSIState_MatchesBatch(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	closes := make([]float64, 500)
+	closes[0] = 100
+	for i := 1; i < len(closes); i++ {
+		closes[i] = closes[i-1] * (1 + 0.02*rng.NormFloat64())
+		if i%50 == 0 {
+			closes[i] = closes[i-1] // flat bars take the loss branch
+		}
+	}
+	bars := barsOf(closes)
+
+	for _, period := range []int{2, 14, 30} {
+		var state RSIState
+		got, err := state.Init(bars[:period+1], period)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := period; i < len(bars); i++ {
+			if i > period {
+				got = state.Update(closes[i])
+			}
+			want, _ := CalculateRSI(bars[:i+1], period)
+			if math.Abs(got-want) > 1e-9 {
+				t.Fatalf("period %d: RSI after close %d = %.12f incrementally, %.12f in batch", period, i, got, want)
+			}
+		}
+		if state.RSI() != got {
+			t.Errorf("period %d: RSI() = %v, want the last Update %v", period, state.RSI(), got)
+		}
+	}
+}
+
+func TestRSIState_InitErrors(t *testing.T) {
+	var state RSIState
+	if _, err := state.Init(barsOf(stockChartsCloses[:14]), 14); err == nil {
+		t.Error("Init with 14 closes for period 14: want an error")
+	}
+	if _, err := state.Init(barsOf(stockChartsCloses), 0); err == nil {
+		t.Error("Init with period 0: want an error")
+	}
+}
@@ -0,0 +1,31 @@
+package calculator
+
+import "math"
+
+// ScoreStats summarizes a run of weekly scores: their mean, their sample
+// standard deviation, and the mean over the standard deviation, which is
+// large in magnitude when the scores held steadily to one side of zero and
+// small when they swung about. With fewer than two scores the standard
+// deviation and ratio are zero; with none, so is the mean. The ratio is also
+// zero when the scores are all equal.
+func ScoreStats(scores []float64) (mean, stddev, sharpeLike float64) {
+	n := len(scores)
+	if n == 0 {
+		return 0, 0, 0
+	}
+	for _, s := range scores {
+		mean += s
+	}
+	mean /= float64(n)
+	if n < 2 {
+		return mean, 0, 0
+	}
+	for _, s := range scores {
+		stddev += (s - mean) * (s - mean)
+	}
+	stddev = math.Sqrt(stddev / float64(n-1))
+	if stddev < 1e-12 {
+		return mean, 0, 0
+	}
+	return mean, stddev, mean / stddev
+}
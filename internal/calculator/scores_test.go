@@ -0,0 +1,28 @@
+package calculator
+
+import (
+	"math"
+	"testing"
+)
+
+func TestScoreStats(t *testing.T) {
+	tests := []struct {
+		name                 string
+		scores               []float64
+		mean, stddev, sharpe float64
+	}{
+		{"none", nil, 0, 0, 0},
+		{"one", []float64{1.5}, 1.5, 0, 0},
+		{"flat", []float64{-0.5, -0.5, -0.5}, -0.5, 0, 0},
+		// Sum of squared deviations 2, over n-1 = 3: stddev sqrt(2/3).
+		{"steady", []float64{1, 2, 3, 2}, 2, math.Sqrt(2.0 / 3), 2 / math.Sqrt(2.0/3)},
+		{"swinging", []float64{2, -2, 2, -2}, 0, math.Sqrt(16.0 / 3), 0},
+		{"negative", []float64{-1, -1.5, -0.5}, -1, 0.5, -2},
+	}
+	for _, tt := range tests {
+		mean, stddev, sharpe := ScoreStats(tt.scores)
+		if math.Abs(mean-tt.mean) > 1e-12 || math.Abs(stddev-tt.stddev) > 1e-12 || math.Abs(sharpe-tt.sharpe) > 1e-12 {
+			t.Errorf("%s: got (%v, %v, %v), want (%v, %v, %v)", tt.name, mean, stddev, sharpe, tt.mean, tt.stddev, tt.sharpe)
+		}
+	}
+}
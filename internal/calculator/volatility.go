@@ -0,0 +1,66 @@
+package calculator
+
+import (
+	"errors"
+	"math"
+
+	"MarketSentinel/internal/model"
+)
+
+// TradingDaysPerYear annualizes daily volatility.
+const TradingDaysPerYear = 252
+
+// CalculateATR computes the Wilder-smoothed average true range over the given
+// period. A bar's true range extends its high-low range to the previous close
+// when the market gapped past it. Requires period+1 bars.
+func CalculateATR(bars []model.OHLCV, period int) (float64, error) {
+	if period <= 0 {
+		return 0, errors.New("period must be positive")
+	}
+	if len(bars) < period+1 {
+		return 0, errors.New("not enough data for ATR calculation")
+	}
+	atr := 0.0
+	for i := 1; i <= period; i++ {
+		atr += trueRange(bars[i], bars[i-1].Close)
+	}
+	atr /= float64(period)
+	for i := period + 1; i < len(bars); i++ {
+		atr = (atr*float64(period-1) + trueRange(bars[i], bars[i-1].Close)) / float64(period)
+	}
+	return atr, nil
+}
+
+// trueRange is the largest of b's range and the distances from prevClose to
+// b's high and low.
+func trueRange(b model.OHLCV, prevClose float64) float64 {
+	return max(b.High-b.Low, math.Abs(b.High-prevClose), math.Abs(b.Low-prevClose))
+}
+
+// CalculateRealizedVol returns the annualized standard deviation of the daily
+// log returns of the last window+1 closes, as a fraction (0.2 is 20%).
+func CalculateRealizedVol(bars []model.OHLCV, window int) (float64, error) {
+	if window < 2 {
+		return 0, errors.New("window must be at least 2")
+	}
+	if len(bars) < window+1 {
+		return 0, errors.New("not enough data for volatility calculation")
+	}
+	closes := extractCloses(bars[len(bars)-window-1:])
+	returns := make([]float64, window)
+	mean := 0.0
+	for i := range returns {
+		if closes[i] <= 0 || closes[i+1] <= 0 {
+			return 0, errors.New("non-positive close in volatility window")
+		}
+		returns[i] = math.Log(closes[i+1] / closes[i])
+		mean += returns[i]
+	}
+	mean /= float64(window)
+	variance := 0.0
+	for _, r := range returns {
+		variance += (r - mean) * (r - mean)
+	}
+	variance /= float64(window - 1)
+	return math.Sqrt(variance * TradingDaysPerYear), nil
+}
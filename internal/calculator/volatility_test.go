@@ -0,0 +1,88 @@
+package calculator
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"MarketSentinel/internal/model"
+)
+
+func ohlc(h, l, c float64) model.OHLCV {
+	return model.OHLCV{Time: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), Open: c, High: h, Low: l, Close: c}
+}
+
+func TestTrueRange_Gaps(t *testing.T) {
+	tests := []struct {
+		name      string
+		bar       model.OHLCV
+		prevClose float64
+		want      float64
+	}{
+		{"inside range", ohlc(105, 100, 102), 103, 5},
+		{"gap up", ohlc(115, 112, 114), 100, 15}, // high - previous close
+		{"gap down", ohlc(92, 88, 90), 100, 12},  // previous close - low
+		{"close at high", ohlc(105, 100, 102), 105, 5},
+	}
+	for _, tt := range tests {
+		if got := trueRange(tt.bar, tt.prevClose); got != tt.want {
+			t.Errorf("%s: true range %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestCalculateATR(t *testing.T) {
+	// True ranges 4, 15 (gap up from the 101 close) and 12 (gap down from
+	// 114): the first two average 9.5, then Wilder smoothing gives
+	// (9.5*1 + 12)/2 = 10.75.
+	bars := []model.OHLCV{
+		ohlc(101, 99, 100),
+		ohlc(103, 99, 101),
+		ohlc(116, 112, 114),
+		ohlc(105, 102, 103),
+	}
+	got, err := CalculateATR(bars, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := 10.75; math.Abs(got-want) > 1e-12 {
+		t.Errorf("ATR = %v, want %v", got, want)
+	}
+	if _, err := CalculateATR(bars[:2], 2); err == nil {
+		t.Error("period+1 bars are required")
+	}
+}
+
+func TestCalculateRealizedVol(t *testing.T) {
+	flat := make([]float64, 31)
+	for i := range flat {
+		flat[i] = 4000
+	}
+	if vol, err := CalculateRealizedVol(barsOf(flat), 30); err != nil || vol != 0 {
+		t.Errorf("constant prices: vol %v, %v, want 0", vol, err)
+	}
+
+	// Log returns alternating +r and -r have mean 0 and sample variance
+	// r²·n/(n-1).
+	const r, n = 0.01, 30
+	closes := []float64{100}
+	for i := 0; i < n; i++ {
+		step := r
+		if i%2 == 1 {
+			step = -r
+		}
+		closes = append(closes, closes[i]*math.Exp(step))
+	}
+	want := math.Sqrt(r*r*n/(n-1)) * math.Sqrt(TradingDaysPerYear)
+	vol, err := CalculateRealizedVol(barsOf(append([]float64{1, 2, 3}, closes...)), n)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(vol-want) > 1e-12 {
+		t.Errorf("vol = %v, want %v from the last %d returns only", vol, want, n)
+	}
+
+	if _, err := CalculateRealizedVol(barsOf(closes[:n]), n); err == nil {
+		t.Error("window+1 closes are required")
+	}
+}
@@ -0,0 +1,60 @@
+package calculator
+
+import (
+	"errors"
+
+	"MarketSentinel/internal/model"
+)
+
+// CalculateVolumeRatio returns the last bar's volume over the average volume
+// of the period bars before it, 1.5 meaning half again the usual volume.
+// Requires period+1 bars.
+func CalculateVolumeRatio(bars []model.OHLCV, period int) (float64, error) {
+	if period <= 0 {
+		return 0, errors.New("period must be positive")
+	}
+	if len(bars) < period+1 {
+		return 0, errors.New("not enough data for volume ratio calculation")
+	}
+	n := len(bars)
+	avg := 0.0
+	for _, b := range bars[n-period-1 : n-1] {
+		avg += b.Volume
+	}
+	avg /= float64(period)
+	if avg <= 0 {
+		return 0, errors.New("no volume in the averaging window")
+	}
+	return bars[n-1].Volume / avg, nil
+}
+
+// CalculateOBV computes on-balance volume at each of bars: starting from zero,
+// each bar's volume is added when it closes up, subtracted when it closes
+// down and ignored when it closes flat.
+func CalculateOBV(bars []model.OHLCV) ([]float64, error) {
+	if len(bars) == 0 {
+		return nil, errors.New("no data for OBV calculation")
+	}
+	obv := make([]float64, len(bars))
+	for i := 1; i < len(bars); i++ {
+		obv[i] = obv[i-1]
+		switch {
+		case bars[i].Close > bars[i-1].Close:
+			obv[i] += bars[i].Volume
+		case bars[i].Close < bars[i-1].Close:
+			obv[i] -= bars[i].Volume
+		}
+	}
+	return obv, nil
+}
+
+// HasVolume reports whether any of bars has a nonzero volume. Index and FX
+// quotes often carry none, which would read as a collapse in volume.
+func HasVolume(bars []model.OHLCV) bool {
+	for _, b := range bars {
+		if b.Volume != 0 {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,51 @@
+package calculator
+
+import (
+	"math"
+	"testing"
+
+	"MarketSentinel/internal/model"
+)
+
+// barsWithVolume returns bars with the given closes and volumes.
+func barsWithVolume(closes, volumes []float64) []model.OHLCV {
+	bars := barsOf(closes)
+	for i := range bars {
+		bars[i].Volume = volumes[i]
+	}
+	return bars
+}
+
+func TestCalculateVolumeRatio(t *testing.T) {
+	bars := barsWithVolume([]float64{1, 1, 1, 1, 1}, []float64{999, 100, 200, 300, 400})
+	got, err := CalculateVolumeRatio(bars, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := 2.0; math.Abs(got-want) > 1e-12 { // 400 against the 200 average of 100..300
+		t.Errorf("ratio = %v, want %v", got, want)
+	}
+	if _, err := CalculateVolumeRatio(bars, 5); err == nil {
+		t.Error("period 5 over 5 bars: want an error")
+	}
+	if _, err := CalculateVolumeRatio(barsOf([]float64{1, 2, 3}), 2); err == nil {
+		t.Error("zero volumes: want an error")
+	}
+}
+
+func TestCalculateOBV(t *testing.T) {
+	bars := barsWithVolume([]float64{10, 11, 11, 9, 12}, []float64{50, 100, 70, 40, 30})
+	got, err := CalculateOBV(bars)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []float64{0, 100, 100, 60, 90} // up, flat, down, up
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("OBV[%d] = %v, want %v", i, got[i], want[i])
+		}
+	}
+	if _, err := CalculateOBV(nil); err == nil {
+		t.Error("no bars: want an error")
+	}
+}
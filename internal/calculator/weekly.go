@@ -0,0 +1,48 @@
+package calculator
+
+import "MarketSentinel/internal/model"
+
+// AggregateWeekly converts daily bars into weekly bars grouped by ISO week,
+// Monday to Sunday in the bars' time zone, for sources without native weekly
+// bars. Each week is stamped with the time of its first daily bar; the last
+// week may be partial.
+func AggregateWeekly(daily []model.OHLCV) []model.OHLCV {
+	if len(daily) == 0 {
+		return nil
+	}
+	var weekly []model.OHLCV
+	var week model.OHLCV
+	var weekStarted bool
+
+	for _, d := range daily {
+		year, isoWeek := d.Time.ISOWeek()
+		weekKey := year*100 + isoWeek
+
+		if !weekStarted {
+			week = model.OHLCV{Time: d.Time, Open: d.Open, High: d.High, Low: d.Low, Close: d.Close, Volume: d.Volume}
+			weekStarted = true
+			continue
+		}
+
+		cy, cw := week.Time.ISOWeek()
+		currentKey := cy*100 + cw
+
+		if weekKey != currentKey {
+			weekly = append(weekly, week)
+			week = model.OHLCV{Time: d.Time, Open: d.Open, High: d.High, Low: d.Low, Close: d.Close, Volume: d.Volume}
+		} else {
+			if d.High > week.High {
+				week.High = d.High
+			}
+			if d.Low < week.Low {
+				week.Low = d.Low
+			}
+			week.Close = d.Close
+			week.Volume += d.Volume
+		}
+	}
+	if weekStarted {
+		weekly = append(weekly, week)
+	}
+	return weekly
+}
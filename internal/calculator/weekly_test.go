@@ -0,0 +1,73 @@
+package calculator
+
+import (
+	"testing"
+	"time"
+
+	"MarketSentinel/internal/model"
+)
+
+// dailyOn returns one bar per date, closing at 100 plus its index.
+func dailyOn(dates ...string) []model.OHLCV {
+	bars := make([]model.OHLCV, len(dates))
+	for i, d := range dates {
+		t, err := time.Parse("2006-01-02", d)
+		if err != nil {
+			panic(err)
+		}
+		c := 100 + float64(i)
+		bars[i] = model.OHLCV{Time: t, Open: c - 0.5, High: c + 1, Low: c - 1, Close: c, Volume: 10}
+	}
+	return bars
+}
+
+func TestAggregateWeekly_YearBoundary(t *testing.T) {
+	tests := []struct {
+		name  string
+		daily []model.OHLCV
+		want  []string // first session of each week
+	}{
+		// 2020 has an ISO week 53 that runs into Friday 2021-01-01.
+		{"week 53", dailyOn("2020-12-28", "2020-12-29", "2020-12-30", "2020-12-31", "2021-01-01", "2021-01-04", "2021-01-05"),
+			[]string{"2020-12-28", "2021-01-04"}},
+		// Monday 2024-12-30 already belongs to 2025-W01.
+		{"week 1 starting in December", dailyOn("2024-12-23", "2024-12-27", "2024-12-30", "2024-12-31", "2025-01-02", "2025-01-03"),
+			[]string{"2024-12-23", "2024-12-30"}},
+		// The same week number a year apart must not merge.
+		{"same week number", dailyOn("2023-01-02", "2024-01-01"),
+			[]string{"2023-01-02", "2024-01-01"}},
+	}
+	for _, tt := range tests {
+		weekly := AggregateWeekly(tt.daily)
+		if len(weekly) != len(tt.want) {
+			t.Errorf("%s: got %d weeks, want %d", tt.name, len(weekly), len(tt.want))
+			continue
+		}
+		for i, w := range tt.want {
+			if got := weekly[i].Time.Format("2006-01-02"); got != w {
+				t.Errorf("%s: week %d starts %s, want %s", tt.name, i, got, w)
+			}
+		}
+	}
+}
+
+func TestAggregateWeekly_PartialWeek(t *testing.T) {
+	// A full week, then Monday and Tuesday of the current one.
+	daily := dailyOn("2024-03-18", "2024-03-19", "2024-03-20", "2024-03-21", "2024-03-22", "2024-03-25", "2024-03-26")
+	weekly := AggregateWeekly(daily)
+	if len(weekly) != 2 {
+		t.Fatalf("got %d weeks, want 2", len(weekly))
+	}
+	full, partial := weekly[0], weekly[1]
+	if full.Open != 99.5 || full.High != 105 || full.Low != 99 || full.Close != 104 || full.Volume != 50 {
+		t.Errorf("full week = %+v", full)
+	}
+	if !partial.Time.Equal(daily[5].Time) || partial.Open != 104.5 || partial.High != 107 || partial.Low != 104 ||
+		partial.Close != 106 || partial.Volume != 20 {
+		t.Errorf("partial week = %+v, want Monday and Tuesday only", partial)
+	}
+
+	if AggregateWeekly(nil) != nil {
+		t.Error("no daily bars should give no weeks")
+	}
+}
@@ -0,0 +1,182 @@
+package chart
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+	"time"
+)
+
+// Common series colors.
+var (
+	ColorBlue   = color.RGBA{0x1f, 0x77, 0xb4, 0xff}
+	ColorOrange = color.RGBA{0xff, 0x7f, 0x0e, 0xff}
+	ColorGreen  = color.RGBA{0x2c, 0xa0, 0x2c, 0xff}
+	ColorRed    = color.RGBA{0xd6, 0x27, 0x28, 0xff}
+	ColorGrid   = color.RGBA{0xe0, 0xe0, 0xe0, 0xff}
+	ColorAxis   = color.RGBA{0x60, 0x60, 0x60, 0xff}
+)
+
+// Point is one sample of a time series.
+type Point struct {
+	Time  time.Time
+	Value float64
+}
+
+// Series is a named line on the chart.
+type Series struct {
+	Name   string
+	Color  color.RGBA
+	Points []Point
+}
+
+// Marker is a vertical event line drawn at Time.
+type Marker struct {
+	Time  time.Time
+	Label string
+	Color color.RGBA
+}
+
+// LineChart is a minimal time-series line chart rendered without text; titles
+// and legends are expected to travel in the message caption.
+type LineChart struct {
+	Width   int
+	Height  int
+	Series  []Series
+	Markers []Marker
+}
+
+const (
+	padding   = 30
+	gridLines = 5
+)
+
+// RenderPNG draws the chart and returns PNG bytes.
+func (c *LineChart) RenderPNG() ([]byte, error) {
+	if c.Width <= 2*padding || c.Height <= 2*padding {
+		return nil, errors.New("chart too small")
+	}
+	tMin, tMax, vMin, vMax, ok := c.bounds()
+	if !ok {
+		return nil, errors.New("chart has no data")
+	}
+	if tMax.Equal(tMin) {
+		tMax = tMin.Add(time.Hour)
+	}
+	if vMax == vMin {
+		vMax = vMin + 1
+	}
+	// Headroom so lines don't sit on the border.
+	span := vMax - vMin
+	vMin -= span * 0.05
+	vMax += span * 0.05
+
+	img := image.NewRGBA(image.Rect(0, 0, c.Width, c.Height))
+	fillRect(img, img.Bounds(), color.RGBA{0xff, 0xff, 0xff, 0xff})
+
+	plotW := float64(c.Width - 2*padding)
+	plotH := float64(c.Height - 2*padding)
+	x := func(t time.Time) int {
+		return padding + int(math.Round(float64(t.Sub(tMin))/float64(tMax.Sub(tMin))*plotW))
+	}
+	y := func(v float64) int {
+		return c.Height - padding - int(math.Round((v-vMin)/(vMax-vMin)*plotH))
+	}
+
+	for i := 0; i <= gridLines; i++ {
+		gy := padding + int(math.Round(plotH*float64(i)/gridLines))
+		drawLine(img, padding, gy, c.Width-padding, gy, ColorGrid, 1)
+	}
+	drawLine(img, padding, padding, padding, c.Height-padding, ColorAxis, 1)
+	drawLine(img, padding, c.Height-padding, c.Width-padding, c.Height-padding, ColorAxis, 1)
+
+	for _, m := range c.Markers {
+		mx := x(m.Time)
+		for yy := padding; yy < c.Height-padding; yy += 6 {
+			drawLine(img, mx, yy, mx, yy+3, m.Color, 1)
+		}
+	}
+
+	for _, s := range c.Series {
+		for i := 1; i < len(s.Points); i++ {
+			p0, p1 := s.Points[i-1], s.Points[i]
+			drawLine(img, x(p0.Time), y(p0.Value), x(p1.Time), y(p1.Value), s.Color, 2)
+		}
+		for _, p := range s.Points {
+			px, py := x(p.Time), y(p.Value)
+			fillRect(img, image.Rect(px-2, py-2, px+3, py+3), s.Color)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (c *LineChart) bounds() (tMin, tMax time.Time, vMin, vMax float64, ok bool) {
+	vMin, vMax = math.Inf(1), math.Inf(-1)
+	for _, s := range c.Series {
+		for _, p := range s.Points {
+			if !ok || p.Time.Before(tMin) {
+				tMin = p.Time
+			}
+			if !ok || p.Time.After(tMax) {
+				tMax = p.Time
+			}
+			vMin = math.Min(vMin, p.Value)
+			vMax = math.Max(vMax, p.Value)
+			ok = true
+		}
+	}
+	return tMin, tMax, vMin, vMax, ok
+}
+
+func fillRect(img *image.RGBA, r image.Rectangle, col color.RGBA) {
+	r = r.Intersect(img.Bounds())
+	for yy := r.Min.Y; yy < r.Max.Y; yy++ {
+		for xx := r.Min.X; xx < r.Max.X; xx++ {
+			img.SetRGBA(xx, yy, col)
+		}
+	}
+}
+
+// drawLine draws a line of the given thickness using Bresenham's algorithm.
+func drawLine(img *image.RGBA, x0, y0, x1, y1 int, col color.RGBA, thickness int) {
+	dx := abs(x1 - x0)
+	dy := -abs(y1 - y0)
+	sx, sy := 1, 1
+	if x0 > x1 {
+		sx = -1
+	}
+	if y0 > y1 {
+		sy = -1
+	}
+	err := dx + dy
+	for {
+		fillRect(img, image.Rect(x0, y0, x0+thickness, y0+thickness), col)
+		if x0 == x1 && y0 == y1 {
+			return
+		}
+		e2 := 2 * err
+		if e2 >= dy {
+			err += dy
+			x0 += sx
+		}
+		if e2 <= dx {
+			err += dx
+			y0 += sy
+		}
+	}
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
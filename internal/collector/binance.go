@@ -0,0 +1,167 @@
+package collector
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"MarketSentinel/internal/model"
+)
+
+// defaultBinanceBaseURL is the public spot API host.
+const defaultBinanceBaseURL = "https://api.binance.com"
+
+// binanceMaxLimit is the most klines one request may return.
+const binanceMaxLimit = 1000
+
+// BinanceFetcher implements Fetcher using the Binance public spot API. Crypto
+// trades every day, so it reports CryptoCalendar. Weekly bars come from the
+// native 1w interval, which opens Monday 00:00 UTC.
+type BinanceFetcher struct {
+	Client    *http.Client
+	BaseURL   string            // API host, overridable for tests
+	SymbolMap map[string]string // maps internal symbol to Binance pair
+}
+
+// NewBinanceFetcher creates a new Binance fetcher with optional proxy support.
+// symbolMap entries override the built-in pair aliases.
+func NewBinanceFetcher(proxy *url.URL, symbolMap map[string]string) *BinanceFetcher {
+	transport := &http.Transport{}
+	if proxy != nil {
+		transport.Proxy = http.ProxyURL(proxy)
+	}
+	return &BinanceFetcher{
+		Client: &http.Client{
+			Timeout:   30 * time.Second,
+			Transport: transport,
+		},
+		BaseURL: defaultBinanceBaseURL,
+		SymbolMap: MergeSymbolMap(map[string]string{
+			"BTC": "BTCUSDT",
+			"ETH": "ETHUSDT",
+		}, symbolMap),
+	}
+}
+
+func (f *BinanceFetcher) Name() string { return "binance" }
+
+// Calendar reports that crypto has a daily bar for every calendar day.
+func (f *BinanceFetcher) Calendar() Calendar { return CryptoCalendar }
+
+func (f *BinanceFetcher) pair(symbol string) string {
+	if mapped, ok := f.SymbolMap[symbol]; ok {
+		return mapped
+	}
+	return symbol
+}
+
+func (f *BinanceFetcher) FetchDailyBars(symbol string, days int) ([]model.OHLCV, error) {
+	return f.fetchKlines(symbol, "1d", days)
+}
+
+func (f *BinanceFetcher) FetchWeeklyBars(symbol string, weeks int) ([]model.OHLCV, error) {
+	return f.fetchKlines(symbol, "1w", weeks)
+}
+
+func (f *BinanceFetcher) FetchCurrentPrice(symbol string) (float64, error) {
+	body, err := f.get("/api/v3/ticker/price", url.Values{"symbol": {f.pair(symbol)}})
+	if err != nil {
+		return 0, err
+	}
+	var ticker struct {
+		Price string `json:"price"`
+	}
+	if err := json.Unmarshal(body, &ticker); err != nil {
+		return 0, fmt.Errorf("binance decode: %w", err)
+	}
+	price, err := strconv.ParseFloat(ticker.Price, 64)
+	if err != nil {
+		return 0, fmt.Errorf("binance: bad price %q", ticker.Price)
+	}
+	return price, nil
+}
+
+// fetchKlines returns the newest limit bars of interval, oldest first. The
+// last bar is the period still in progress.
+func (f *BinanceFetcher) fetchKlines(symbol, interval string, limit int) ([]model.OHLCV, error) {
+	if limit > binanceMaxLimit {
+		limit = binanceMaxLimit
+	}
+	body, err := f.get("/api/v3/klines", url.Values{
+		"symbol":   {f.pair(symbol)},
+		"interval": {interval},
+		"limit":    {strconv.Itoa(limit)},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return parseKlines(body)
+}
+
+// parseKlines decodes kline arrays: [openTime, open, high, low, close, volume, ...]
+// with prices and volume as decimal strings.
+func parseKlines(body []byte) ([]model.OHLCV, error) {
+	var rows [][]json.RawMessage
+	if err := json.Unmarshal(body, &rows); err != nil {
+		return nil, fmt.Errorf("binance decode: %w", err)
+	}
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("binance: no data returned")
+	}
+	bars := make([]model.OHLCV, 0, len(rows))
+	for i, row := range rows {
+		if len(row) < 6 {
+			return nil, fmt.Errorf("binance: kline %d has %d fields", i, len(row))
+		}
+		var openTime int64
+		if err := json.Unmarshal(row[0], &openTime); err != nil {
+			return nil, fmt.Errorf("binance: kline %d open time: %w", i, err)
+		}
+		var v [5]float64
+		for j := range v {
+			var s string
+			if err := json.Unmarshal(row[j+1], &s); err != nil {
+				return nil, fmt.Errorf("binance: kline %d field %d: %w", i, j+1, err)
+			}
+			n, err := strconv.ParseFloat(s, 64)
+			if err != nil {
+				return nil, fmt.Errorf("binance: kline %d field %d: %w", i, j+1, err)
+			}
+			v[j] = n
+		}
+		bars = append(bars, model.OHLCV{
+			Time: time.UnixMilli(openTime).UTC(),
+			Open: v[0], High: v[1], Low: v[2], Close: v[3], Volume: v[4],
+		})
+	}
+	return bars, nil
+}
+
+// get performs a GET and returns the body of a 200 response. Binance error
+// bodies look like {"code":-1121,"msg":"Invalid symbol."}.
+func (f *BinanceFetcher) get(path string, q url.Values) ([]byte, error) {
+	resp, err := f.Client.Get(f.BaseURL + path + "?" + q.Encode())
+	if err != nil {
+		return nil, fmt.Errorf("binance fetch: %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("binance read body: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		var apiErr struct {
+			Code int    `json:"code"`
+			Msg  string `json:"msg"`
+		}
+		if json.Unmarshal(body, &apiErr) == nil && apiErr.Msg != "" {
+			return nil, fmt.Errorf("binance: status %d: %s (code %d)", resp.StatusCode, apiErr.Msg, apiErr.Code)
+		}
+		return nil, fmt.Errorf("binance: status %d, body: %s", resp.StatusCode, string(body))
+	}
+	return body, nil
+}
@@ -0,0 +1,160 @@
+package collector
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"MarketSentinel/internal/model"
+)
+
+// binanceServer serves n klines per request starting 2024-01-01 UTC, one
+// interval apart, with close = 100 + index. Symbol BAD returns an API error.
+func binanceServer(t *testing.T, requests *[]string) *BinanceFetcher {
+	t.Helper()
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if requests != nil {
+			*requests = append(*requests, r.URL.Path+"?"+r.URL.RawQuery)
+		}
+		if q.Get("symbol") == "BAD" {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"code":-1121,"msg":"Invalid symbol."}`)
+			return
+		}
+		switch r.URL.Path {
+		case "/api/v3/ticker/price":
+			fmt.Fprintf(w, `{"symbol":%q,"price":"67012.50000000"}`, q.Get("symbol"))
+		case "/api/v3/klines":
+			step := 24 * time.Hour
+			if q.Get("interval") == "1w" {
+				step *= 7
+			}
+			n, _ := strconv.Atoi(q.Get("limit"))
+			rows := make([]string, n)
+			for i := range rows {
+				open := start.Add(time.Duration(i) * step)
+				c := 100 + float64(i)
+				rows[i] = fmt.Sprintf(`[%d,"%.2f","%.2f","%.2f","%.2f","12.5",%d,"0",10,"0","0","0"]`,
+					open.UnixMilli(), c-1, c+2, c-2, c, open.Add(step).UnixMilli()-1)
+			}
+			fmt.Fprint(w, "["+strings.Join(rows, ",")+"]")
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	f := NewBinanceFetcher(nil, nil)
+	f.BaseURL = srv.URL
+	return f
+}
+
+func TestBinanceFetcher_Klines(t *testing.T) {
+	var requests []string
+	f := binanceServer(t, &requests)
+
+	daily, err := f.FetchDailyBars("BTC", 3)
+	if err != nil {
+		t.Fatalf("FetchDailyBars: %v", err)
+	}
+	if len(daily) != 3 {
+		t.Fatalf("expected 3 daily bars, got %d", len(daily))
+	}
+	want := model.OHLCV{Time: time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC), Open: 100, High: 103, Low: 99, Close: 101, Volume: 12.5}
+	if daily[1] != want {
+		t.Errorf("daily[1] = %+v, want %+v", daily[1], want)
+	}
+	if !strings.Contains(requests[0], "interval=1d") || !strings.Contains(requests[0], "symbol=BTCUSDT") {
+		t.Errorf("unexpected daily request %s", requests[0])
+	}
+
+	weekly, err := f.FetchWeeklyBars("ETH", 2)
+	if err != nil {
+		t.Fatalf("FetchWeeklyBars: %v", err)
+	}
+	if got := weekly[1].Time; !got.Equal(time.Date(2024, 1, 8, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("second week opens %s, want 2024-01-08", got)
+	}
+	if !strings.Contains(requests[1], "interval=1w") || !strings.Contains(requests[1], "symbol=ETHUSDT") {
+		t.Errorf("unexpected weekly request %s", requests[1])
+	}
+
+	// Limits above the API maximum are capped.
+	if _, err := f.FetchDailyBars("BTC", 1500); err != nil {
+		t.Fatalf("FetchDailyBars(1500): %v", err)
+	}
+	if !strings.Contains(requests[2], "limit=1000") {
+		t.Errorf("expected limit capped to 1000, got %s", requests[2])
+	}
+}
+
+func TestBinanceFetcher_PriceAndErrors(t *testing.T) {
+	f := binanceServer(t, nil)
+
+	price, err := f.FetchCurrentPrice("BTC")
+	if err != nil {
+		t.Fatalf("FetchCurrentPrice: %v", err)
+	}
+	if price != 67012.5 {
+		t.Errorf("price = %v, want 67012.5", price)
+	}
+
+	_, err = f.FetchDailyBars("BAD", 10)
+	if err == nil || !strings.Contains(err.Error(), "Invalid symbol. (code -1121)") {
+		t.Errorf("expected API error message, got %v", err)
+	}
+	if _, err := f.FetchCurrentPrice("BAD"); err == nil {
+		t.Error("expected error for invalid symbol")
+	}
+}
+
+// cryptoMock is a MockFetcher on CryptoCalendar.
+type cryptoMock struct{ MockFetcher }
+
+func (m *cryptoMock) Calendar() Calendar { return CryptoCalendar }
+
+func TestCollector_CryptoCalendarWindows(t *testing.T) {
+	// 400 daily bars, one a calendar day; a high 300 days back is inside
+	// the 52-week window whatever the calendar, one 380 days back is not.
+	bars := generateMockBars(100, 400)
+	bars[100].High = 500
+	bars[20].High = 900
+
+	equity := NewCollector(&MockFetcher{Price: 100, DailyData: bars}, "SPX500")
+	crypto := NewCollector(&cryptoMock{MockFetcher{Price: 100, DailyData: bars}}, "BTC")
+
+	in := Inputs{Daily: bars, Weekly: generateMockBars(100, 60), Price: 100}
+	if got := equity.Compute(in).High52w; got != 500 {
+		t.Errorf("equity 52w high = %v, want 500", got)
+	}
+	if got := crypto.Compute(in).High52w; got != 500 {
+		t.Errorf("crypto 52w high = %v, want 500", got)
+	}
+
+	var asked int
+	crypto.Fetcher = &countingCrypto{cryptoMock: cryptoMock{MockFetcher{Price: 100}}, asked: &asked}
+	if _, err := crypto.FetchDaily(); err != nil {
+		t.Fatalf("FetchDaily: %v", err)
+	}
+	// Three years of the crypto calendar after MA200's warm-up.
+	if want := 3*365 + 200; asked != want {
+		t.Errorf("crypto daily fetch asked for %d bars, want %d", asked, want)
+	}
+}
+
+// countingCrypto records the requested daily bar count.
+type countingCrypto struct {
+	cryptoMock
+	asked *int
+}
+
+func (m *countingCrypto) FetchDailyBars(symbol string, days int) ([]model.OHLCV, error) {
+	*m.asked = days
+	return m.cryptoMock.FetchDailyBars(symbol, days)
+}
@@ -0,0 +1,180 @@
+package collector
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"MarketSentinel/internal/model"
+)
+
+// BarStore persists bars between runs. recorder.SQLiteRecorder implements it
+// over its bars_cache table.
+type BarStore interface {
+	// LoadBars returns the stored bars oldest first and when the newest of
+	// them was fetched; no bars is not an error.
+	LoadBars(symbol, interval string) ([]model.OHLCV, time.Time, error)
+	// StoreBars replaces every stored bar dated on or after the first of bars,
+	// which are oldest first, with bars.
+	StoreBars(symbol, interval string, bars []model.OHLCV, fetchedAt time.Time) error
+}
+
+// Intervals under which CachedFetcher stores bars.
+const (
+	intervalDaily  = "1d"
+	intervalWeekly = "1w"
+)
+
+// DefaultCacheTTL is how long the newest cached bar is served before the tail
+// is fetched again.
+const DefaultCacheTTL = 15 * time.Minute
+
+// CachedFetcher wraps a Fetcher with a persistent bar cache. A request the
+// cache can cover is served from it while the newest bar is younger than TTL;
+// after that only the missing tail is fetched from Inner and merged in, so the
+// current, still-forming bar keeps updating. When Inner fails, whatever the
+// cache holds is returned instead. Store errors are logged and never fail a
+// fetch.
+type CachedFetcher struct {
+	Inner Fetcher
+	Store BarStore
+	TTL   time.Duration
+	Now   func() time.Time // overridable for tests
+
+	mu    sync.Mutex
+	stats CacheStats
+}
+
+// NewCachedFetcher wraps inner with a cache kept in store.
+func NewCachedFetcher(inner Fetcher, store BarStore, ttl time.Duration) *CachedFetcher {
+	return &CachedFetcher{Inner: inner, Store: store, TTL: ttl, Now: time.Now}
+}
+
+// CacheStats counts how bar requests were served since start.
+type CacheStats struct {
+	Hits        int // served from the cache alone
+	TailFetches int // cache extended by fetching only the newest bars
+	Misses      int // full fetch from the upstream source
+	Stale       int // upstream failed, served from the cache
+	BarsFetched int // bars received from the upstream source
+	StoreErrors int // cache reads or writes that failed
+}
+
+func (s CacheStats) String() string {
+	return fmt.Sprintf("hits=%d tail=%d misses=%d stale=%d bars_fetched=%d store_errors=%d",
+		s.Hits, s.TailFetches, s.Misses, s.Stale, s.BarsFetched, s.StoreErrors)
+}
+
+// Name is the upstream name marked as cached, e.g. "yahoo+cache".
+func (c *CachedFetcher) Name() string { return c.Inner.Name() + "+cache" }
+
+// Calendar is the calendar of the upstream source.
+func (c *CachedFetcher) Calendar() Calendar { return calendarOf(c.Inner) }
+
+// CacheStats returns a snapshot of the counters.
+func (c *CachedFetcher) CacheStats() CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.stats
+}
+
+func (c *CachedFetcher) FetchDailyBars(symbol string, days int) ([]model.OHLCV, error) {
+	return c.fetch(symbol, intervalDaily, 24*time.Hour, days, c.Inner.FetchDailyBars)
+}
+
+func (c *CachedFetcher) FetchWeeklyBars(symbol string, weeks int) ([]model.OHLCV, error) {
+	return c.fetch(symbol, intervalWeekly, 7*24*time.Hour, weeks, c.Inner.FetchWeeklyBars)
+}
+
+// FetchCurrentPrice is not cached; a quote is only useful fresh.
+func (c *CachedFetcher) FetchCurrentPrice(symbol string) (float64, error) {
+	return c.Inner.FetchCurrentPrice(symbol)
+}
+
+// FetchIntradayBars is not cached; the bars are only useful fresh.
+func (c *CachedFetcher) FetchIntradayBars(symbol, interval string, bars int) ([]model.OHLCV, error) {
+	return FetchIntradayBars(c.Inner, symbol, interval, bars)
+}
+
+// fetch serves n bars of one interval; period is the length of one bar.
+func (c *CachedFetcher) fetch(symbol, interval string, period time.Duration, n int,
+	upstream func(string, int) ([]model.OHLCV, error)) ([]model.OHLCV, error) {
+	now := c.Now()
+	cached, fetchedAt, err := c.Store.LoadBars(symbol, interval)
+	if err != nil {
+		log.Printf("[WARN] bar cache: load %s %s: %v", symbol, interval, err)
+		c.count(func(s *CacheStats) { s.StoreErrors++ })
+		cached = nil
+	}
+
+	want := n
+	switch {
+	case len(cached) >= n && now.Sub(fetchedAt) < c.TTL:
+		c.count(func(s *CacheStats) { s.Hits++ })
+		return tail(cached, n), nil
+	case len(cached) >= n:
+		// Refetch every bar since the newest cached one, plus that bar itself
+		// and one more for a bar that was still forming.
+		since := now.Sub(cached[len(cached)-1].Time)
+		want = min(n, int(since/period)+2)
+	}
+
+	fresh, err := upstream(symbol, want)
+	if err != nil {
+		if len(cached) == 0 {
+			return nil, err
+		}
+		log.Printf("[WARN] bar cache: %s, serving %d cached %s %s bars fetched %s",
+			err, min(len(cached), n), symbol, interval, fetchedAt.Format("2006-01-02 15:04"))
+		c.count(func(s *CacheStats) { s.Stale++ })
+		return tail(cached, n), nil
+	}
+	c.count(func(s *CacheStats) {
+		if want < n {
+			s.TailFetches++
+		} else {
+			s.Misses++
+		}
+		s.BarsFetched += len(fresh)
+	})
+
+	if err := c.Store.StoreBars(symbol, interval, fresh, now); err != nil {
+		log.Printf("[WARN] bar cache: store %s %s: %v", symbol, interval, err)
+		c.count(func(s *CacheStats) { s.StoreErrors++ })
+	}
+	if want == n {
+		return fresh, nil
+	}
+	return tail(mergeBars(cached, fresh), n), nil
+}
+
+func (c *CachedFetcher) count(fn func(*CacheStats)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	fn(&c.stats)
+}
+
+// mergeBars appends fresh to the cached bars dated before it, as StoreBars
+// does: the upstream source is authoritative for the range it returned, so a
+// forming bar whose date moved is not kept twice.
+func mergeBars(cached, fresh []model.OHLCV) []model.OHLCV {
+	if len(fresh) == 0 {
+		return cached
+	}
+	first := barDate(fresh[0])
+	n := sort.Search(len(cached), func(i int) bool { return barDate(cached[i]) >= first })
+	return append(cached[:n:n], fresh...)
+}
+
+// barDate is the cache key of a bar.
+func barDate(b model.OHLCV) string { return b.Time.UTC().Format("2006-01-02") }
+
+// tail returns the last n bars.
+func tail(bars []model.OHLCV, n int) []model.OHLCV {
+	if len(bars) > n {
+		return bars[len(bars)-n:]
+	}
+	return bars
+}
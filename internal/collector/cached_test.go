@@ -0,0 +1,135 @@
+package collector
+
+import (
+	"errors"
+	"sort"
+	"testing"
+	"time"
+
+	"MarketSentinel/internal/model"
+)
+
+// memBarStore is an in-memory BarStore with the replace semantics of the
+// SQLite one.
+type memBarStore struct {
+	bars    map[string][]model.OHLCV
+	fetched map[string]time.Time
+}
+
+func newMemBarStore() *memBarStore {
+	return &memBarStore{bars: map[string][]model.OHLCV{}, fetched: map[string]time.Time{}}
+}
+
+func (m *memBarStore) LoadBars(symbol, interval string) ([]model.OHLCV, time.Time, error) {
+	k := symbol + "/" + interval
+	return append([]model.OHLCV(nil), m.bars[k]...), m.fetched[k], nil
+}
+
+func (m *memBarStore) StoreBars(symbol, interval string, bars []model.OHLCV, fetchedAt time.Time) error {
+	if len(bars) == 0 {
+		return nil
+	}
+	k := symbol + "/" + interval
+	m.bars[k] = mergeBars(m.bars[k], bars)
+	m.fetched[k] = fetchedAt
+	return nil
+}
+
+// seriesFetcher serves the last n of its daily bars and records each request.
+type seriesFetcher struct {
+	MockFetcher
+	bars     []model.OHLCV
+	fail     bool
+	requests []int
+}
+
+func (f *seriesFetcher) FetchDailyBars(_ string, days int) ([]model.OHLCV, error) {
+	f.requests = append(f.requests, days)
+	if f.fail {
+		return nil, errors.New("status 503")
+	}
+	return tail(f.bars, days), nil
+}
+
+// addDay appends a bar one day after the last, closing at close.
+func (f *seriesFetcher) addDay(close float64) {
+	t := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	if len(f.bars) > 0 {
+		t = f.bars[len(f.bars)-1].Time.Add(24 * time.Hour)
+	}
+	f.bars = append(f.bars, model.OHLCV{Time: t, Open: close, High: close, Low: close, Close: close})
+}
+
+func TestCachedFetcher_FetchesOnlyTail(t *testing.T) {
+	up := &seriesFetcher{}
+	for i := 0; i < 30; i++ {
+		up.addDay(float64(100 + i))
+	}
+	now := up.bars[29].Time.Add(12 * time.Hour)
+	c := NewCachedFetcher(up, newMemBarStore(), time.Hour)
+	c.Now = func() time.Time { return now }
+
+	if bars, err := c.FetchDailyBars("SPX500", 20); err != nil || len(bars) != 20 {
+		t.Fatalf("first fetch: %d bars, %v", len(bars), err)
+	}
+	// Within the TTL the cache answers alone.
+	now = now.Add(30 * time.Minute)
+	if bars, _ := c.FetchDailyBars("SPX500", 20); len(bars) != 20 || bars[19].Close != 129 {
+		t.Fatalf("cached fetch: %+v", bars)
+	}
+	// Two days later the forming bar was revised and two new bars exist; only
+	// those plus a margin are requested.
+	up.bars[29].Close = 130.5
+	up.addDay(131)
+	up.addDay(132)
+	now = now.Add(48 * time.Hour)
+	bars, err := c.FetchDailyBars("SPX500", 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(bars) != 20 || bars[17].Close != 130.5 || bars[19].Close != 132 {
+		t.Errorf("merged bars end %v %v %v, want 130.5 131 132", bars[17].Close, bars[18].Close, bars[19].Close)
+	}
+	if !sort.SliceIsSorted(bars, func(i, j int) bool { return bars[i].Time.Before(bars[j].Time) }) {
+		t.Error("merged bars not sorted")
+	}
+	if len(up.requests) != 2 || up.requests[0] != 20 || up.requests[1] != 4 {
+		t.Errorf("upstream requests = %v, want [20 4]", up.requests)
+	}
+
+	st := c.CacheStats()
+	if st.Misses != 1 || st.Hits != 1 || st.TailFetches != 1 || st.BarsFetched != 24 {
+		t.Errorf("stats = %s", st)
+	}
+}
+
+func TestCachedFetcher_UpstreamFailure(t *testing.T) {
+	up := &seriesFetcher{fail: true}
+	c := NewCachedFetcher(up, newMemBarStore(), time.Hour)
+
+	// Nothing cached: the upstream error comes through.
+	if _, err := c.FetchDailyBars("SPX500", 10); err == nil {
+		t.Fatal("expected upstream error on an empty cache")
+	}
+
+	up.fail = false
+	for i := 0; i < 10; i++ {
+		up.addDay(float64(100 + i))
+	}
+	now := up.bars[9].Time.Add(time.Hour)
+	c.Now = func() time.Time { return now }
+	if _, err := c.FetchDailyBars("SPX500", 10); err != nil {
+		t.Fatal(err)
+	}
+
+	// Stale cache and a failing source: the cached bars are served.
+	up.fail = true
+	now = now.Add(72 * time.Hour)
+	bars, err := c.FetchDailyBars("SPX500", 10)
+	if err != nil || len(bars) != 10 || bars[9].Close != 109 {
+		t.Fatalf("expected the cached bars, got %d bars, %v", len(bars), err)
+	}
+	if st := c.CacheStats(); st.Stale != 1 {
+		t.Errorf("stats = %s", st)
+	}
+}
@@ -0,0 +1,115 @@
+package collector
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"MarketSentinel/internal/model"
+)
+
+// ChainFetcher tries an ordered list of fetchers and returns the first
+// successful result. The fallback is decided per call, so a source that failed
+// once is still tried first next time.
+type ChainFetcher struct {
+	Fetchers []Fetcher
+}
+
+// NewChainFetcher creates a ChainFetcher trying fetchers in order.
+func NewChainFetcher(fetchers ...Fetcher) *ChainFetcher {
+	return &ChainFetcher{Fetchers: fetchers}
+}
+
+// Name joins the source names in order, e.g. "vstrader>yahoo".
+func (c *ChainFetcher) Name() string {
+	names := make([]string, len(c.Fetchers))
+	for i, f := range c.Fetchers {
+		names[i] = f.Name()
+	}
+	return strings.Join(names, ">")
+}
+
+// Calendar is the calendar of the primary source; fallbacks are expected to
+// serve the same market.
+func (c *ChainFetcher) Calendar() Calendar {
+	if len(c.Fetchers) == 0 {
+		return EquityCalendar
+	}
+	return calendarOf(c.Fetchers[0])
+}
+
+func (c *ChainFetcher) FetchDailyBars(symbol string, days int) ([]model.OHLCV, error) {
+	return tryChain(c.Fetchers, "daily bars", func(f Fetcher) ([]model.OHLCV, error) {
+		return f.FetchDailyBars(symbol, days)
+	})
+}
+
+func (c *ChainFetcher) FetchWeeklyBars(symbol string, weeks int) ([]model.OHLCV, error) {
+	return tryChain(c.Fetchers, "weekly bars", func(f Fetcher) ([]model.OHLCV, error) {
+		return f.FetchWeeklyBars(symbol, weeks)
+	})
+}
+
+func (c *ChainFetcher) FetchCurrentPrice(symbol string) (float64, error) {
+	return tryChain(c.Fetchers, "current price", func(f Fetcher) (float64, error) {
+		return f.FetchCurrentPrice(symbol)
+	})
+}
+
+// FetchIntradayBars tries the sources serving intraday bars in order, and
+// returns ErrNotSupported when none does.
+func (c *ChainFetcher) FetchIntradayBars(symbol, interval string, bars int) ([]model.OHLCV, error) {
+	var intraday []Fetcher
+	for _, f := range c.Fetchers {
+		if _, ok := f.(IntradayFetcher); ok {
+			intraday = append(intraday, f)
+		}
+	}
+	if len(intraday) == 0 {
+		return nil, ErrNotSupported
+	}
+	return tryChain(intraday, "intraday bars", func(f Fetcher) ([]model.OHLCV, error) {
+		return FetchIntradayBars(f, symbol, interval, bars)
+	})
+}
+
+// chainError collects the failure of every source in a chain.
+type chainError struct {
+	errs []error
+}
+
+func (e *chainError) Error() string {
+	return "all sources failed: " + joinErrors(e.errs)
+}
+
+func (e *chainError) Unwrap() []error { return e.errs }
+
+func joinErrors(errs []error) string {
+	msgs := make([]string, len(errs))
+	for i, err := range errs {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// tryChain calls fn on each fetcher until one succeeds, logging the source
+// used when it is not the first. Each failure is wrapped with its source name.
+func tryChain[T any](fetchers []Fetcher, what string, fn func(Fetcher) (T, error)) (T, error) {
+	var zero T
+	if len(fetchers) == 0 {
+		return zero, errors.New("no data sources configured")
+	}
+	var errs []error
+	for i, f := range fetchers {
+		v, err := fn(f)
+		if err == nil {
+			if i > 0 {
+				log.Printf("[WARN] %s served by fallback source %s (%s)", what, f.Name(), joinErrors(errs))
+			}
+			return v, nil
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", f.Name(), err))
+	}
+	return zero, &chainError{errs: errs}
+}
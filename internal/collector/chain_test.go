@@ -0,0 +1,68 @@
+package collector
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"MarketSentinel/internal/model"
+)
+
+// flakyFetcher fails the next fails calls, then serves MockFetcher data.
+type flakyFetcher struct {
+	MockFetcher
+	name  string
+	fails int
+	calls int
+}
+
+func (f *flakyFetcher) Name() string { return f.name }
+
+func (f *flakyFetcher) FetchCurrentPrice(symbol string) (float64, error) {
+	f.calls++
+	if f.fails > 0 {
+		f.fails--
+		return 0, errors.New("status 429")
+	}
+	return f.MockFetcher.FetchCurrentPrice(symbol)
+}
+
+func (f *flakyFetcher) FetchDailyBars(symbol string, days int) ([]model.OHLCV, error) {
+	f.calls++
+	if f.fails > 0 {
+		f.fails--
+		return nil, errors.New("status 429")
+	}
+	return f.MockFetcher.FetchDailyBars(symbol, days)
+}
+
+func TestChainFetcher_FallsBackPerCall(t *testing.T) {
+	primary := &flakyFetcher{MockFetcher: MockFetcher{Price: 100}, name: "vstrader", fails: 1}
+	backup := &flakyFetcher{MockFetcher: MockFetcher{Price: 200}, name: "yahoo"}
+	c := NewChainFetcher(primary, backup)
+
+	if c.Name() != "vstrader>yahoo" {
+		t.Errorf("name = %q", c.Name())
+	}
+	if p, err := c.FetchCurrentPrice("SPX500"); err != nil || p != 200 {
+		t.Fatalf("first call: got %.0f, %v; want the backup's 200", p, err)
+	}
+	// The primary recovered, so the next call uses it again.
+	if p, err := c.FetchCurrentPrice("SPX500"); err != nil || p != 100 {
+		t.Fatalf("second call: got %.0f, %v; want the primary's 100", p, err)
+	}
+	if primary.calls != 2 || backup.calls != 1 {
+		t.Errorf("calls primary=%d backup=%d, want 2 and 1", primary.calls, backup.calls)
+	}
+}
+
+func TestChainFetcher_AllFail(t *testing.T) {
+	c := NewChainFetcher(
+		&flakyFetcher{name: "vstrader", fails: 1},
+		&flakyFetcher{name: "yahoo", fails: 1},
+	)
+	_, err := c.FetchDailyBars("SPX500", 10)
+	if err == nil || !strings.Contains(err.Error(), "vstrader: status 429; yahoo: status 429") {
+		t.Errorf("got %v, want both sources named", err)
+	}
+}
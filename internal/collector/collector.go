@@ -1,8 +1,14 @@
 package collector
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
+	"math"
+	"sort"
+	"strings"
+	"sync"
 	"time"
 
 	"MarketSentinel/internal/calculator"
@@ -10,15 +16,58 @@ import (
 )
 
 // MockFetcher returns controllable fixed data for development and testing.
+// The delays make individual fetches slow.
 type MockFetcher struct {
 	Price      float64
 	DailyData  []model.OHLCV
 	WeeklyData []model.OHLCV
+	// IntradayData is served by FetchIntradayBars; nil makes it return
+	// ErrNotSupported.
+	IntradayData []model.OHLCV
+	// Scenario names a bundled scenario, or a .json file, loaded on first
+	// fetch; see LoadScenario. It fills whichever of Price, DailyData and
+	// WeeklyData are unset.
+	Scenario string
+
+	DailyDelay  time.Duration
+	WeeklyDelay time.Duration
+	PriceDelay  time.Duration
+
+	once    sync.Once
+	loadErr error
 }
 
 func (m *MockFetcher) Name() string { return "mock" }
 
+// load fills the unset fields from Scenario once.
+func (m *MockFetcher) load() error {
+	m.once.Do(func() {
+		if m.Scenario == "" {
+			return
+		}
+		s, err := LoadScenario(m.Scenario)
+		if err != nil {
+			m.loadErr = err
+			return
+		}
+		if m.Price == 0 {
+			m.Price = s.Price
+		}
+		if m.DailyData == nil {
+			m.DailyData = s.Daily
+		}
+		if m.WeeklyData == nil {
+			m.WeeklyData = s.Weekly
+		}
+	})
+	return m.loadErr
+}
+
 func (m *MockFetcher) FetchDailyBars(_ string, days int) ([]model.OHLCV, error) {
+	time.Sleep(m.DailyDelay)
+	if err := m.load(); err != nil {
+		return nil, err
+	}
 	if m.DailyData != nil {
 		return m.DailyData, nil
 	}
@@ -26,6 +75,10 @@ func (m *MockFetcher) FetchDailyBars(_ string, days int) ([]model.OHLCV, error)
 }
 
 func (m *MockFetcher) FetchWeeklyBars(_ string, weeks int) ([]model.OHLCV, error) {
+	time.Sleep(m.WeeklyDelay)
+	if err := m.load(); err != nil {
+		return nil, err
+	}
 	if m.WeeklyData != nil {
 		return m.WeeklyData, nil
 	}
@@ -33,9 +86,20 @@ func (m *MockFetcher) FetchWeeklyBars(_ string, weeks int) ([]model.OHLCV, error
 }
 
 func (m *MockFetcher) FetchCurrentPrice(_ string) (float64, error) {
+	time.Sleep(m.PriceDelay)
+	if err := m.load(); err != nil {
+		return 0, err
+	}
 	return m.Price, nil
 }
 
+func (m *MockFetcher) FetchIntradayBars(_, _ string, _ int) ([]model.OHLCV, error) {
+	if m.IntradayData == nil {
+		return nil, ErrNotSupported
+	}
+	return m.IntradayData, nil
+}
+
 func generateMockBars(basePrice float64, count int) []model.OHLCV {
 	bars := make([]model.OHLCV, count)
 	for i := 0; i < count; i++ {
@@ -56,66 +120,539 @@ func generateMockBars(basePrice float64, count int) []model.OHLCV {
 type Collector struct {
 	Fetcher Fetcher
 	Symbol  string
+	Quality QualityPolicy
+	// AuxSymbol is a volatility index such as ^VIX whose level is reported
+	// in MarketIndicators.VIX; empty disables it. When set but not fetched,
+	// IndicatorVIX is marked missing.
+	AuxSymbol string
+	// Macro fetches YieldSeries, a 10-year yield series such as FRED's
+	// DGS10, reported in MarketIndicators.Yield10Y; nil disables it.
+	Macro       MacroFetcher
+	YieldSeries string
+	// FX quotes the rate reported in MarketIndicators.FXRate; nil disables it.
+	FX *FXFetcher
+	// Verifier is a secondary source the results are cross-checked against,
+	// see Verify; nil disables the check. Tolerance is the relative
+	// difference allowed, DefaultVerifyTolerance when zero.
+	Verifier  Fetcher
+	Tolerance float64
+	// Trading is the exchange calendar of Symbol, see IsTradingDay; nil
+	// picks the built-in calendar of the fetcher's market.
+	Trading *TradingCalendar
+	// WeeklyFromDaily skips the fetcher's weekly bars and derives them from
+	// the daily bars, see DeriveWeekly, so weekly indicators do not depend
+	// on how a source buckets weeks.
+	WeeklyFromDaily bool
+	// PercentileMinHistory is the fewest past values the weekly RSI and
+	// MA200 deviation are ranked against, see
+	// MarketIndicators.WeeklyRSIPercentile; DefaultPercentileMinHistory
+	// when zero.
+	PercentileMinHistory int
+
+	mu     sync.Mutex
+	cached Inputs                // last successful result of each fetch
+	others map[string]*Collector // collectors for other symbols, see For
+
+	rsiMu               sync.Mutex
+	dailyRSI, weeklyRSI rsiTracker // RSI state of Symbol's series, see Compute
+}
+
+// Inputs are the raw series indicators are computed from.
+type Inputs struct {
+	Daily  []model.OHLCV
+	Weekly []model.OHLCV
+	Price  float64
+	Aux    []model.OHLCV      // daily bars of AuxSymbol; empty when unset or failed
+	Yield  []model.MacroPoint // observations of YieldSeries; empty when unset or failed
+	FXRate float64            // rate quoted by FX; zero when unset or failed
 }
 
 // NewCollector creates a new Collector.
 func NewCollector(fetcher Fetcher, symbol string) *Collector {
-	return &Collector{Fetcher: fetcher, Symbol: symbol}
+	return &Collector{Fetcher: fetcher, Symbol: symbol, Quality: DefaultQualityPolicy}
 }
 
-// Collect fetches market data and computes all indicators.
+// For returns the collector for symbol: c itself for c.Symbol, otherwise a
+// collector sharing c's fetcher and quality policy with its own cache, kept
+// for later calls.
+func (c *Collector) For(symbol string) *Collector {
+	if symbol == c.Symbol {
+		return c
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if o, ok := c.others[symbol]; ok {
+		return o
+	}
+	if c.others == nil {
+		c.others = make(map[string]*Collector)
+	}
+	o := &Collector{Fetcher: c.Fetcher, Symbol: symbol, Quality: c.Quality, AuxSymbol: c.AuxSymbol,
+		Macro: c.Macro, YieldSeries: c.YieldSeries, FX: c.FX, Verifier: c.Verifier, Tolerance: c.Tolerance,
+		WeeklyFromDaily: c.WeeklyFromDaily, PercentileMinHistory: c.PercentileMinHistory}
+	c.others[symbol] = o
+	return o
+}
+
+// CollectAll collects indicators for each of symbols, keyed by symbol. It
+// stops at the first symbol that fails.
+func (c *Collector) CollectAll(symbols []string) (map[string]*model.MarketIndicators, error) {
+	all := make(map[string]*model.MarketIndicators, len(symbols))
+	for _, sym := range symbols {
+		ind, err := c.For(sym).Collect()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", sym, err)
+		}
+		all[sym] = ind
+	}
+	return all, nil
+}
+
+// closesOf returns the closes of bars.
+func closesOf(bars []model.OHLCV) []float64 {
+	closes := make([]float64, len(bars))
+	for i, b := range bars {
+		closes[i] = b.Close
+	}
+	return closes
+}
+
+// rsiPeriod is the lookback used for both daily and weekly RSI.
+const rsiPeriod = 14
+
+// atrPeriod is the lookback of MarketIndicators.ATR14.
+const atrPeriod = 14
+
+// percentileYears is how far back indicators are ranked against their own
+// history; weeklyBarsFetched covers it and the weekly RSI's warm-up, as
+// dailyBarsFor does for the MA200 deviation.
+const (
+	percentileYears   = 3
+	weeklyBarsFetched = percentileYears*52 + rsiPeriod + 1
+)
+
+// DefaultPercentileMinHistory is the fewest past values an indicator is
+// ranked against by default: a year of weekly RSI.
+const DefaultPercentileMinHistory = 52
+
+// ma200SlopeSessions and ma20wSlopeWeeks are the lookbacks of
+// MarketIndicators.MA200Slope and MA20wSlope, about a month each.
+const (
+	ma200SlopeSessions = 20
+	ma20wSlopeWeeks    = 4
+)
+
+// volumePeriod is the lookback of MarketIndicators.VolumeRatio20 and
+// OBVSlope20.
+const volumePeriod = 20
+
+// sign returns 1, -1 or 0 for positive, negative or zero x.
+func sign(x float64) int {
+	switch {
+	case x > 0:
+		return 1
+	case x < 0:
+		return -1
+	}
+	return 0
+}
+
+// dailyBarsFor is the number of daily bars fetched: percentileYears of the
+// calendar's sessions after MA200's warm-up, which covers the 52-week window.
+func dailyBarsFor(cal Calendar) int {
+	return percentileYears*cal.SessionsPerYear + 200
+}
+
+// Collect fetches market data and computes all indicators. Indicators that fall
+// back to defaults are flagged in MarketIndicators.Degraded; stale or gappy
+// daily data is a *DataQualityError when the quality policy rejects it.
+//
+// The fetches run concurrently, see FetchInputs. The auxiliary symbol, yield
+// and FX rate are optional: their failure is only logged. With a Verifier the
+// results are cross-checked afterwards, see Verify.
 func (c *Collector) Collect() (*model.MarketIndicators, error) {
-	dailyBars, err := c.Fetcher.FetchDailyBars(c.Symbol, 300)
+	ind, _, err := c.CollectSeries()
+	return ind, err
+}
+
+// CollectSeries is Collect that also returns the raw series the indicators
+// were computed from.
+func (c *Collector) CollectSeries() (*model.MarketIndicators, *model.PriceSeries, error) {
+	in, _, err := c.FetchInputs(context.Background())
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := c.Validate(in); err != nil {
+		return nil, nil, err
+	}
+	ind := c.Compute(in)
+	c.verifyInto(ind, in)
+	return ind, in.Series(c.Symbol), nil
+}
+
+// Series returns in as the price series of symbol, fetched now.
+func (in Inputs) Series(symbol string) *model.PriceSeries {
+	return &model.PriceSeries{
+		Symbol:       symbol,
+		DailyBars:    in.Daily,
+		WeeklyBars:   in.Weekly,
+		CurrentPrice: in.Price,
+		FetchedAt:    time.Now(),
+	}
+}
+
+// Validate returns a *DataQualityError when the quality policy rejects the
+// daily bars of in, and nil when it only warns.
+func (c *Collector) Validate(in Inputs) error {
+	return c.ValidateAt(in, time.Now())
+}
+
+// ValidateAt is Validate measuring staleness against asOf.
+func (c *Collector) ValidateAt(in Inputs, asOf time.Time) error {
+	if !c.Quality.Reject {
+		return nil
+	}
+	if problems := checkQuality(in.Daily, c.Quality, calendarOf(c.Fetcher), asOf); len(problems) > 0 {
+		return &DataQualityError{Problems: problems}
+	}
+	return nil
+}
+
+// FetchDaily fetches the daily bars Collect uses and caches them on success.
+func (c *Collector) FetchDaily() ([]model.OHLCV, error) {
+	bars, err := c.Fetcher.FetchDailyBars(c.Symbol, dailyBarsFor(calendarOf(c.Fetcher)))
 	if err != nil {
 		return nil, fmt.Errorf("fetch daily bars: %w", err)
 	}
-	weeklyBars, err := c.Fetcher.FetchWeeklyBars(c.Symbol, 60)
+	c.mu.Lock()
+	c.cached.Daily = bars
+	c.mu.Unlock()
+	return bars, nil
+}
+
+// FetchWeekly fetches the weekly bars Collect uses and caches them on success.
+func (c *Collector) FetchWeekly() ([]model.OHLCV, error) {
+	bars, err := c.Fetcher.FetchWeeklyBars(c.Symbol, weeklyBarsFetched)
 	if err != nil {
 		return nil, fmt.Errorf("fetch weekly bars: %w", err)
 	}
-	currentPrice, err := c.Fetcher.FetchCurrentPrice(c.Symbol)
+	c.mu.Lock()
+	c.cached.Weekly = bars
+	c.mu.Unlock()
+	return bars, nil
+}
+
+// DeriveWeekly aggregates daily into ISO weeks. The week of now is left out
+// until now is its last trading day, so every run during a week sees the same
+// complete weeks and a run after the week's last session includes it.
+func (c *Collector) DeriveWeekly(daily []model.OHLCV, now time.Time) []model.OHLCV {
+	weekly := calculator.AggregateWeekly(daily)
+	if n := len(weekly); n > 0 && !c.weekComplete(weekly[n-1].Time, now) {
+		weekly = weekly[:n-1]
+	}
+	return weekly
+}
+
+// InputsAsOf returns what Collect would have fetched with WeeklyFromDaily at
+// the close of t's session, given daily history oldest first: the bars up to
+// t's date trimmed to the count Collect fetches, the weekly bars derived from
+// them, and the last close as the price. Backtests use it to replay the past.
+func (c *Collector) InputsAsOf(daily []model.OHLCV, t time.Time) Inputs {
+	end := time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+	n := sort.Search(len(daily), func(i int) bool { return !daily[i].Time.Before(end) })
+	past := daily[:n]
+
+	var in Inputs
+	in.Weekly = c.DeriveWeekly(past, t)
+	in.Weekly = in.Weekly[max(0, len(in.Weekly)-weeklyBarsFetched):]
+	in.Daily = past[max(0, n-dailyBarsFor(calendarOf(c.Fetcher))):]
+	if n > 0 {
+		in.Price = past[n-1].Close
+	}
+	return in
+}
+
+// weekComplete reports whether the ISO week of t has no trading day left
+// after now.
+func (c *Collector) weekComplete(t, now time.Time) bool {
+	year, week := now.ISOWeek()
+	if y, w := t.ISOWeek(); y != year || w != week {
+		return true
+	}
+	for d := now.AddDate(0, 0, 1); ; d = d.AddDate(0, 0, 1) {
+		if y, w := d.ISOWeek(); y != year || w != week {
+			return true
+		}
+		if c.IsTradingDay(d) {
+			return false
+		}
+	}
+}
+
+// FetchPrice fetches the current price and caches it on success.
+func (c *Collector) FetchPrice() (float64, error) {
+	price, err := c.Fetcher.FetchCurrentPrice(c.Symbol)
+	if err != nil {
+		return 0, fmt.Errorf("fetch current price: %w", err)
+	}
+	c.mu.Lock()
+	c.cached.Price = price
+	c.mu.Unlock()
+	return price, nil
+}
+
+// auxBars is the number of daily bars fetched for AuxSymbol.
+const auxBars = 30
+
+// FetchAux fetches the daily bars of AuxSymbol and caches them on success. It
+// returns nothing when AuxSymbol is unset.
+func (c *Collector) FetchAux() ([]model.OHLCV, error) {
+	if c.AuxSymbol == "" {
+		return nil, nil
+	}
+	bars, err := c.Fetcher.FetchDailyBars(c.AuxSymbol, auxBars)
 	if err != nil {
-		return nil, fmt.Errorf("fetch current price: %w", err)
+		return nil, fmt.Errorf("fetch %s bars: %w", c.AuxSymbol, err)
 	}
+	c.mu.Lock()
+	c.cached.Aux = bars
+	c.mu.Unlock()
+	return bars, nil
+}
+
+// yieldDays is the calendar window of yield observations fetched, enough for
+// yieldAvgPoints of them.
+const (
+	yieldDays      = 45
+	yieldAvgPoints = 20
+)
+
+// FetchYield fetches the observations of YieldSeries and caches them on
+// success. It returns nothing when Macro is unset.
+func (c *Collector) FetchYield() ([]model.MacroPoint, error) {
+	if c.Macro == nil || c.YieldSeries == "" {
+		return nil, nil
+	}
+	points, err := c.Macro.FetchSeries(c.YieldSeries, yieldDays)
+	if err != nil {
+		return nil, fmt.Errorf("fetch yield: %w", err)
+	}
+	c.mu.Lock()
+	c.cached.Yield = points
+	c.mu.Unlock()
+	return points, nil
+}
+
+// FetchFX fetches the FX rate and caches it on success. It returns zero when
+// FX is unset.
+func (c *Collector) FetchFX() (float64, error) {
+	if c.FX == nil {
+		return 0, nil
+	}
+	rate, err := c.FX.Rate()
+	if err != nil {
+		return 0, err
+	}
+	c.mu.Lock()
+	c.cached.FXRate = rate
+	c.mu.Unlock()
+	return rate, nil
+}
+
+// IsTradingDay reports whether Symbol's exchange is open on t's date. A
+// calendar that cannot be built, such as without time zone data, counts every
+// day as a trading day so nothing is skipped by mistake.
+func (c *Collector) IsTradingDay(t time.Time) bool {
+	c.mu.Lock()
+	if c.Trading == nil {
+		cal, err := NewTradingCalendar(DefaultExchange(c.Fetcher), nil)
+		if err != nil {
+			c.mu.Unlock()
+			log.Printf("[WARN] trading calendar unavailable, assuming open: %v", err)
+			return true
+		}
+		c.Trading = cal
+	}
+	cal := c.Trading
+	c.mu.Unlock()
+	return cal.IsTradingDay(t)
+}
+
+// percentile ranks current against the history returned by series, less
+// its last value, which current replaces. It returns zero, logging a
+// warning, when there are fewer than PercentileMinHistory past values.
+func (c *Collector) percentile(name string, current float64, series func() ([]float64, error)) float64 {
+	minHistory := c.PercentileMinHistory
+	if minHistory <= 0 {
+		minHistory = DefaultPercentileMinHistory
+	}
+	hist, err := series()
+	if err != nil {
+		log.Printf("[WARN] %s percentile: %v, leaving it unset", name, err)
+		return 0
+	}
+	if len(hist) > 0 {
+		hist = hist[:len(hist)-1]
+	}
+	if len(hist) < minHistory {
+		log.Printf("[WARN] %s percentile: only %d past values, need %d, leaving it unset", name, len(hist), minHistory)
+		return 0
+	}
+	pct, err := calculator.CalculatePercentileRank(hist, current)
+	if err != nil {
+		log.Printf("[WARN] %s percentile: %v, leaving it unset", name, err)
+		return 0
+	}
+	return pct
+}
+
+// trackRSI returns the RSI of bars from t under rsiMu.
+func (c *Collector) trackRSI(t *rsiTracker, bars []model.OHLCV) (float64, error) {
+	c.rsiMu.Lock()
+	defer c.rsiMu.Unlock()
+	return t.rsi(bars)
+}
+
+// Cached returns the last successful result of each fetch since the process
+// started; fields never fetched are left empty.
+func (c *Collector) Cached() Inputs {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.cached
+}
+
+// Compute derives all indicators from in as of now. The daily and weekly RSI
+// carry their smoothing over from the previous call; see rsiTracker.
+func (c *Collector) Compute(in Inputs) *model.MarketIndicators {
+	return c.compute(in, time.Now(), c.trackRSI)
+}
+
+// ComputeAt derives all indicators from in as of asOf, the time the quality
+// policy measures staleness against. It neither reads the clock nor touches
+// the RSI state of Compute, smoothing the RSIs over the bars of in alone, so
+// the same inputs always give the same indicators; nothing is fetched, and
+// the collector's Fetcher may be nil, which selects the equity calendar.
+func (c *Collector) ComputeAt(in Inputs, asOf time.Time) *model.MarketIndicators {
+	return c.compute(in, asOf, func(_ *rsiTracker, bars []model.OHLCV) (float64, error) {
+		return calculator.CalculateRSI(bars, rsiPeriod)
+	})
+}
+
+// compute is Compute and ComputeAt: the indicators of in as of now, with the
+// RSIs taken from rsi.
+func (c *Collector) compute(in Inputs, now time.Time, rsi func(*rsiTracker, []model.OHLCV) (float64, error)) *model.MarketIndicators {
+	dailyBars, weeklyBars, currentPrice := in.Daily, in.Weekly, in.Price
 
 	ind := &model.MarketIndicators{CurrentPrice: currentPrice}
+	if currentPrice <= 0 {
+		ind.MarkDegraded(model.IndicatorPrice, fmt.Sprintf("invalid price %.2f", currentPrice))
+	}
+	cal := calendarOf(c.Fetcher)
+	if problems := checkQuality(dailyBars, c.Quality, cal, now); len(problems) > 0 {
+		ind.DataQualityWarning = strings.Join(problems, "; ")
+		log.Printf("[WARN] data quality: %s", ind.DataQualityWarning)
+	}
+	if n := len(dailyBars); n > 0 && c.Quality.MaxStaleness > 0 && now.Sub(dailyBars[n-1].Time) > c.Quality.MaxStaleness {
+		reason := fmt.Sprintf("last daily bar %s is stale", dailyBars[n-1].Time.Format("2006-01-02"))
+		ind.MarkDegraded(model.IndicatorDailyRSI, reason)
+		ind.MarkDegraded(model.IndicatorPrice, reason)
+	}
 
 	// MA200
 	if ma, err := calculator.CalculateMA200(dailyBars); err != nil {
-		log.Printf("[WARN] MA200 calculation failed: %v, using current price", err)
+		log.Printf("[WARN] MA200 calculation failed: %v, marking it missing", err)
 		ind.MA200 = currentPrice
+		ind.MarkMissing(model.IndicatorMA200, err.Error())
 	} else {
 		ind.MA200 = ma
 	}
 
+	// Z-score against the MA200 window
+	if z, err := calculator.CalculateZScore(currentPrice, closesOf(dailyBars), 200); err != nil {
+		log.Printf("[WARN] MA200 z-score calculation failed: %v, leaving it unset", err)
+	} else {
+		ind.ZScoreMA200 = z
+	}
+
 	// MA20w
 	if ma, err := calculator.CalculateMA20w(weeklyBars); err != nil {
-		log.Printf("[WARN] MA20w calculation failed: %v, using current price", err)
+		log.Printf("[WARN] MA20w calculation failed: %v, marking it missing", err)
 		ind.MA20w = currentPrice
+		ind.MarkMissing(model.IndicatorMA20w, err.Error())
 	} else {
 		ind.MA20w = ma
 	}
 
+	// Slopes of MA200 and MA20w, informational only
+	for _, m := range []struct {
+		name     string
+		dst      *float64
+		bars     []model.OHLCV
+		period   int
+		lookback int
+	}{
+		{"MA200", &ind.MA200Slope, dailyBars, 200, ma200SlopeSessions},
+		{"MA20w", &ind.MA20wSlope, weeklyBars, 20, ma20wSlopeWeeks},
+	} {
+		sma, err := calculator.CalculateSMASeries(closesOf(m.bars), m.period)
+		if err == nil {
+			*m.dst, err = calculator.CalculateSlope(sma, m.lookback)
+		}
+		if err != nil {
+			log.Printf("[WARN] %s slope calculation failed: %v, leaving it unset", m.name, err)
+		}
+	}
+
+	// Streaks against the moving averages, for the trend warnings
+	if sma, err := calculator.CalculateSMASeries(closesOf(dailyBars), 200); err == nil {
+		ind.DaysBelowMA200, ind.DaysAboveMA200Before = calculator.CrossStreaks(closesOf(dailyBars), sma)
+	}
+	ma20w, err20 := calculator.CalculateSMASeries(closesOf(weeklyBars), 20)
+	ma50w, err50 := calculator.CalculateSMASeries(closesOf(weeklyBars), 50)
+	if err20 == nil && err50 == nil {
+		ind.WeeksMA20wBelowMA50w, _ = calculator.CrossStreaks(ma20w, ma50w)
+	}
+
 	// MA50w
 	if ma, err := calculator.CalculateMA50w(weeklyBars); err != nil {
-		log.Printf("[WARN] MA50w calculation failed: %v, using current price", err)
+		log.Printf("[WARN] MA50w calculation failed: %v, marking it missing", err)
 		ind.MA50w = currentPrice
+		ind.MarkMissing(model.IndicatorMA50w, err.Error())
 	} else {
 		ind.MA50w = ma
 	}
 
-	// Weekly RSI
-	if rsi, err := calculator.CalculateRSI(weeklyBars, 14); err != nil {
-		log.Printf("[WARN] Weekly RSI calculation failed: %v, defaulting to 50", err)
+	// Weekly RSI (CalculateRSI silently returns 50 on short input, so flag it here)
+	if len(weeklyBars) < rsiPeriod+1 {
+		ind.MarkMissing(model.IndicatorWeeklyRSI, fmt.Sprintf("only %d weekly bars", len(weeklyBars)))
+	}
+	if rsi, err := rsi(&c.weeklyRSI, weeklyBars); err != nil {
+		log.Printf("[WARN] Weekly RSI calculation failed: %v, marking it missing", err)
 		ind.WeeklyRSI = 50
+		ind.MarkMissing(model.IndicatorWeeklyRSI, err.Error())
 	} else {
 		ind.WeeklyRSI = rsi
 	}
 
+	// Weekly MACD, scored only by the opt-in MACD factor
+	if m, s, h, err := calculator.CalculateMACDSeries(weeklyBars, calculator.MACDFast, calculator.MACDSlow, calculator.MACDSignal); err != nil {
+		log.Printf("[WARN] Weekly MACD calculation failed: %v, leaving it unset", err)
+	} else {
+		n := len(weeklyBars) - 1
+		ind.MACD, ind.MACDSignal, ind.MACDHist = m[n], s[n], h[n]
+		if !math.IsNaN(h[n-1]) {
+			ind.MACDHistPrev = h[n-1]
+		}
+	}
+
 	// Daily RSI
-	if rsi, err := calculator.CalculateRSI(dailyBars, 14); err != nil {
-		log.Printf("[WARN] Daily RSI calculation failed: %v, defaulting to 50", err)
+	if len(dailyBars) < rsiPeriod+1 {
+		ind.MarkMissing(model.IndicatorDailyRSI, fmt.Sprintf("only %d daily bars", len(dailyBars)))
+	}
+	if rsi, err := rsi(&c.dailyRSI, dailyBars); err != nil {
+		log.Printf("[WARN] Daily RSI calculation failed: %v, marking it missing", err)
 		ind.DailyRSI = 50
+		ind.MarkMissing(model.IndicatorDailyRSI, err.Error())
 	} else {
 		ind.DailyRSI = rsi
 	}
@@ -125,9 +662,13 @@ func (c *Collector) Collect() (*model.MarketIndicators, error) {
 		log.Printf("[WARN] 52-week range calculation failed: %v", err)
 		ind.High52w = currentPrice
 		ind.Low52w = currentPrice
+		ind.MarkMissing(model.IndicatorRange52w, err.Error())
 	} else {
 		ind.High52w = h
 		ind.Low52w = l
+		if n, err := calculator.SessionsSinceLow(dailyBars, 365); err == nil {
+			ind.Low52wAge = n + 1
+		}
 	}
 
 	// 30-day range
@@ -135,18 +676,105 @@ func (c *Collector) Collect() (*model.MarketIndicators, error) {
 		log.Printf("[WARN] 30-day range calculation failed: %v", err)
 		ind.High30d = currentPrice
 		ind.Low30d = currentPrice
+		ind.MarkMissing(model.IndicatorRange30d, err.Error())
 	} else {
 		ind.High30d = h
 		ind.Low30d = l
 	}
 
+	// Drawdowns
+	ind.DrawdownFromHigh = calculator.CalculateDrawdown(currentPrice, ind.High52w)
+	if dd, err := calculator.CalculateMaxDrawdown(dailyBars[max(0, len(dailyBars)-cal.SessionsPerYear):]); err != nil {
+		log.Printf("[WARN] max drawdown calculation failed: %v, leaving it unset", err)
+	} else {
+		ind.MaxDrawdown1y = dd
+	}
+
+	// Momentum over a quarter and half of the market's year, e.g. 63 and 126
+	// sessions for equities; a short history only leaves it unset.
+	for _, m := range []struct {
+		dst      *float64
+		sessions int
+	}{{&ind.ROC3m, cal.SessionsPerYear / 4}, {&ind.ROC6m, cal.SessionsPerYear / 2}} {
+		roc, err := calculator.CalculateROC(dailyBars, m.sessions)
+		var short *calculator.InsufficientDataError
+		switch {
+		case errors.As(err, &short):
+			log.Printf("[INFO] %d-session rate of change skipped: %v", m.sessions, err)
+		case err != nil:
+			log.Printf("[WARN] %d-session rate of change calculation failed: %v, leaving it unset", m.sessions, err)
+		default:
+			*m.dst = roc
+		}
+	}
+
+	// ATR and realized volatility, informational only
+	if atr, err := calculator.CalculateATR(dailyBars, atrPeriod); err != nil {
+		log.Printf("[WARN] ATR calculation failed: %v, leaving it unset", err)
+	} else {
+		ind.ATR14 = atr
+	}
+	if vol, err := calculator.CalculateRealizedVol(dailyBars, cal.SessionsPerMonth); err != nil {
+		log.Printf("[WARN] 30-day volatility calculation failed: %v, leaving it unset", err)
+	} else {
+		// Annualize over the market's own sessions, e.g. 365 for crypto.
+		ind.Vol30d = vol * math.Sqrt(float64(cal.SessionsPerYear)/calculator.TradingDaysPerYear)
+	}
+
+	// Percentile ranks against the indicators' own history, informational only
+	if ind.DegradedReason(model.IndicatorWeeklyRSI) == "" {
+		ind.WeeklyRSIPercentile = c.percentile("weekly RSI", ind.WeeklyRSI, func() ([]float64, error) {
+			return calculator.RSIHistory(weeklyBars, rsiPeriod, percentileYears*52+1)
+		})
+	}
+	if ind.DegradedReason(model.IndicatorMA200) == "" {
+		dev := (currentPrice - ind.MA200) / ind.MA200 * 100
+		ind.MA200DevPercentile = c.percentile("MA200 deviation", dev, func() ([]float64, error) {
+			return calculator.MADeviationHistory(dailyBars, 200, percentileYears*cal.SessionsPerYear+1)
+		})
+	}
+
+	// Volume, informational only
+	if !calculator.HasVolume(dailyBars) {
+		log.Printf("[WARN] %s daily bars carry no volume, leaving volume indicators unset", c.Symbol)
+	} else {
+		if r, err := calculator.CalculateVolumeRatio(dailyBars, volumePeriod); err != nil {
+			log.Printf("[WARN] Volume ratio calculation failed: %v, leaving it unset", err)
+		} else {
+			ind.VolumeRatio20 = r
+		}
+		if obv, err := calculator.CalculateOBV(dailyBars); err == nil && len(obv) > volumePeriod {
+			n := len(obv)
+			ind.OBVSlope20 = sign(obv[n-1] - obv[n-1-volumePeriod])
+		}
+	}
+
 	// 52-week position
 	if pos, err := calculator.Calculate52WeekPosition(currentPrice, ind.High52w, ind.Low52w); err != nil {
 		log.Printf("[WARN] 52-week position calculation failed: %v", err)
 		ind.Position52w = 0.5
+		ind.MarkMissing(model.IndicatorPosition52w, err.Error())
 	} else {
 		ind.Position52w = pos
+		if ind.High52w == ind.Low52w && ind.MissingReason(model.IndicatorRange52w) == "" {
+			ind.MarkMissing(model.IndicatorPosition52w, "52-week high equals low")
+		}
 	}
 
-	return ind, nil
+	// Volatility index, optional
+	if n := len(in.Aux); n > 0 {
+		ind.VIX = in.Aux[n-1].Close
+		if ma, err := calculator.CalculateMA20d(in.Aux); err == nil {
+			ind.VIX20d = ma
+		}
+	} else if c.AuxSymbol != "" {
+		ind.MarkMissing(model.IndicatorVIX, fmt.Sprintf("no bars for %s", c.AuxSymbol))
+	}
+
+	// 10-year yield, optional
+	ind.Yield10Y = MacroLatest(in.Yield)
+	ind.Yield10Y20d = MacroAverage(in.Yield, yieldAvgPoints)
+	ind.FXRate = in.FXRate
+
+	return ind
 }
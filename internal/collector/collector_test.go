@@ -0,0 +1,333 @@
+package collector
+
+import (
+	"errors"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"MarketSentinel/internal/calculator"
+	"MarketSentinel/internal/model"
+)
+
+func TestCollect_FetchesConcurrently(t *testing.T) {
+	const delay = 200 * time.Millisecond
+	c := NewCollector(&MockFetcher{Price: 5000, DailyDelay: delay, WeeklyDelay: delay, PriceDelay: delay}, "SPX500")
+
+	start := time.Now()
+	ind, err := c.Collect()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed >= 2*delay {
+		t.Errorf("Collect took %s, want about the slowest fetch (%s), not the sum", elapsed, delay)
+	}
+	if ind.CurrentPrice != 5000 || ind.MA200 == 0 || ind.MA20w == 0 {
+		t.Errorf("indicators not computed from all three fetches: %+v", ind)
+	}
+}
+
+// failingQuoteFetcher fails the quote at once while the bar fetches are slow.
+type failingQuoteFetcher struct {
+	MockFetcher
+}
+
+func (f *failingQuoteFetcher) FetchCurrentPrice(string) (float64, error) {
+	return 0, errors.New("status 503")
+}
+
+func TestCollect_ReturnsFirstError(t *testing.T) {
+	c := NewCollector(&failingQuoteFetcher{MockFetcher{Price: 5000, DailyDelay: time.Second, WeeklyDelay: time.Second}}, "SPX500")
+
+	start := time.Now()
+	_, err := c.Collect()
+	if err == nil || !strings.Contains(err.Error(), "fetch current price: status 503") {
+		t.Fatalf("got %v, want the quote error", err)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("Collect waited %s for the slow fetches after the quote failed", elapsed)
+	}
+}
+
+// auxFetcher serves VIX bars closing at 10..39 and fails for ^FAIL.
+type auxFetcher struct {
+	MockFetcher
+}
+
+func (f *auxFetcher) FetchDailyBars(symbol string, days int) ([]model.OHLCV, error) {
+	switch symbol {
+	case "^VIX":
+		bars := make([]model.OHLCV, 30)
+		for i := range bars {
+			bars[i] = model.OHLCV{Time: time.Now().AddDate(0, 0, i-30), Close: float64(10 + i)}
+		}
+		return bars, nil
+	case "^FAIL":
+		return nil, errors.New("status 404")
+	}
+	return f.MockFetcher.FetchDailyBars(symbol, days)
+}
+
+func TestCollect_AuxSymbol(t *testing.T) {
+	tests := []struct {
+		aux         string
+		vix, vix20d float64
+		missing     bool
+	}{
+		{"", 0, 0, false},
+		{"^VIX", 39, 29.5, false}, // closes 20..39
+		{"^FAIL", 0, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.aux, func(t *testing.T) {
+			c := NewCollector(&auxFetcher{MockFetcher{Price: 5000}}, "SPX500")
+			c.AuxSymbol = tt.aux
+			ind, err := c.Collect()
+			if err != nil {
+				t.Fatalf("aux symbol %q failed the collection: %v", tt.aux, err)
+			}
+			if ind.VIX != tt.vix || ind.VIX20d != tt.vix20d {
+				t.Errorf("VIX %.2f/%.2f, want %.2f/%.2f", ind.VIX, ind.VIX20d, tt.vix, tt.vix20d)
+			}
+			if missing := ind.MissingReason(model.IndicatorVIX) != ""; missing != tt.missing {
+				t.Errorf("VIX marked missing = %v, want %v", missing, tt.missing)
+			}
+			if ind.CurrentPrice != 5000 {
+				t.Errorf("price = %.0f", ind.CurrentPrice)
+			}
+		})
+	}
+}
+
+func TestCollect_FXRate(t *testing.T) {
+	c := NewCollector(&MockFetcher{Price: 5000}, "SPX500")
+	c.FX = NewFXFetcher(&MockFetcher{Price: 7.25}, "CNY=X")
+	ind, err := c.Collect()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ind.FXRate != 7.25 {
+		t.Errorf("FXRate = %v, want 7.25", ind.FXRate)
+	}
+
+	c.FX = NewFXFetcher(&failingQuoteFetcher{}, "CNY=X")
+	if ind, err = c.Collect(); err != nil {
+		t.Fatalf("a failing FX quote must not fail the collection: %v", err)
+	}
+	if ind.FXRate != 0 {
+		t.Errorf("FXRate = %v after a failed quote, want zero", ind.FXRate)
+	}
+}
+
+func TestCollect_WeeklyMACD(t *testing.T) {
+	ind, err := NewCollector(&MockFetcher{Price: 5000}, "SPX500").Collect()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The mock series rises steadily, so the fast EMA leads the slow one.
+	if ind.MACD <= 0 || ind.MACDSignal <= 0 {
+		t.Errorf("MACD %v, signal %v on a rising series", ind.MACD, ind.MACDSignal)
+	}
+
+	// A series that turns down leaves a histogram still falling.
+	weekly := generateMockBars(5000, 60)
+	for i := 40; i < len(weekly); i++ {
+		weekly[i].Close = weekly[39].Close - float64(i-39)*20
+	}
+	ind, err = NewCollector(&MockFetcher{Price: 5000, WeeklyData: weekly}, "SPX500").Collect()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ind.MACDHist >= 0 || ind.MACDHistPrev >= 0 || ind.MACDHist == ind.MACDHistPrev {
+		t.Errorf("after a turn down: histogram %v, previous %v", ind.MACDHist, ind.MACDHistPrev)
+	}
+
+	ind, err = NewCollector(&MockFetcher{Price: 5000, WeeklyData: generateMockBars(5000, 20)}, "SPX500").Collect()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ind.MACD != 0 || ind.MACDSignal != 0 || ind.MACDHist != 0 || ind.MACDHistPrev != 0 {
+		t.Errorf("20 weekly bars: MACD %v/%v/%v/%v, want it unset", ind.MACD, ind.MACDSignal, ind.MACDHist, ind.MACDHistPrev)
+	}
+}
+
+func TestCollect_TrendStreaks(t *testing.T) {
+	// A steady rise whose last two sessions close far below MA200 and set a
+	// new 52-week low.
+	daily := generateMockBars(5000, 300)
+	for i := len(daily) - 2; i < len(daily); i++ {
+		daily[i].Close, daily[i].Low = 4000, 3990
+	}
+	daily[len(daily)-1].Low = 3980
+	ind, err := NewCollector(&MockFetcher{Price: 4000, DailyData: daily}, "SPX500").Collect()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ind.DaysBelowMA200 != 2 || ind.DaysAboveMA200Before < 20 {
+		t.Errorf("MA200 streaks %d below after %d above", ind.DaysBelowMA200, ind.DaysAboveMA200Before)
+	}
+	if ind.Low52wAge != 1 {
+		t.Errorf("Low52wAge = %d, want 1", ind.Low52wAge)
+	}
+
+	// Weekly closes rising then falling far enough for MA20w to cross MA50w.
+	weekly := generateMockBars(5000, 80)
+	for i := 60; i < len(weekly); i++ {
+		weekly[i].Close = weekly[59].Close - float64(i-59)*150
+	}
+	ind, err = NewCollector(&MockFetcher{Price: 4000, WeeklyData: weekly}, "SPX500").Collect()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ind.WeeksMA20wBelowMA50w == 0 {
+		t.Error("MA20w did not cross below MA50w")
+	}
+}
+
+func TestCollect_DegenerateRange(t *testing.T) {
+	daily := generateMockBars(5000, 300)
+	for i := range daily {
+		daily[i].Open, daily[i].High, daily[i].Low, daily[i].Close = 5000, 5000, 5000, 5000
+	}
+	ind, err := NewCollector(&MockFetcher{Price: 5000, DailyData: daily}, "SPX500").Collect()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ind.High52w != ind.Low52w || ind.MissingReason(model.IndicatorPosition52w) == "" {
+		t.Errorf("range %v..%v, position missing %q", ind.Low52w, ind.High52w, ind.MissingReason(model.IndicatorPosition52w))
+	}
+}
+
+func TestCollect_Volatility(t *testing.T) {
+	c := NewCollector(&MockFetcher{Price: 5000}, "SPX500")
+	ind, err := c.Collect()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Mock bars span 1% a day and rise 0.1% of the base price a day.
+	daily, _ := c.FetchDaily()
+	last := daily[len(daily)-1].Close
+	if ind.ATR14 < last*0.008 || ind.ATR14 > last*0.012 {
+		t.Errorf("ATR14 = %v, want about 1%% of the last close %.0f", ind.ATR14, last)
+	}
+	if ind.Vol30d < 0 || ind.Vol30d > 0.01 {
+		t.Errorf("Vol30d = %v, want near zero for steady returns", ind.Vol30d)
+	}
+}
+
+func TestDeriveWeekly_CurrentWeek(t *testing.T) {
+	// Monday 2024-03-25 to Thursday 2024-03-28; Good Friday closes NYSE.
+	var daily []model.OHLCV
+	for d := time.Date(2024, 3, 18, 0, 0, 0, 0, time.UTC); d.Day() != 29; d = d.AddDate(0, 0, 1) {
+		if d.Weekday() != time.Saturday && d.Weekday() != time.Sunday {
+			daily = append(daily, model.OHLCV{Time: d, Open: 1, High: 1, Low: 1, Close: 1})
+		}
+	}
+	c := NewCollector(&MockFetcher{}, "SPX500")
+	c.Trading, _ = NewTradingCalendar(ExchangeNYSE, nil)
+
+	for _, tt := range []struct {
+		now  string
+		want int
+	}{
+		{"2024-03-27", 1}, // Thursday is still to trade
+		{"2024-03-28", 2}, // last session before Good Friday
+		{"2024-04-01", 2}, // the next week
+	} {
+		now, _ := time.Parse("2006-01-02", tt.now)
+		if got := len(c.DeriveWeekly(daily, now.Add(15*time.Hour))); got != tt.want {
+			t.Errorf("on %s: %d weeks, want %d", tt.now, got, tt.want)
+		}
+	}
+}
+
+func TestCollect_WeeklyFromDaily(t *testing.T) {
+	// Weekly bars that disagree wildly with the daily ones are ignored; the
+	// 300 mock days, every day a session, make 43 weeks.
+	m := &MockFetcher{Price: 5000, WeeklyData: generateMockBars(1, 60)}
+	c := NewCollector(m, "SPX500")
+	c.WeeklyFromDaily = true
+	openEveryDay, _ := NewTradingCalendar(ExchangeCrypto, nil)
+	c.Trading = openEveryDay
+
+	ind, series, err := c.CollectSeries()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ind.MA20w < 4000 || len(series.WeeklyBars) < 40 {
+		t.Errorf("MA20w %.2f from %d weekly bars, want weeks derived from the daily bars", ind.MA20w, len(series.WeeklyBars))
+	}
+}
+
+func TestRSITracker_CarriesState(t *testing.T) {
+	bars := make([]model.OHLCV, 320)
+	for i := range bars {
+		c := 100 + 10*math.Sin(float64(i)/7) + float64(i%5)
+		bars[i] = model.OHLCV{Time: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC).AddDate(0, 0, i), Close: c}
+	}
+	var tr rsiTracker
+	for end := 300; end <= len(bars); end++ {
+		// Each run fetches a sliding 300-bar window, as Collect does.
+		got, err := tr.rsi(bars[end-300 : end])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want, _ := calculator.CalculateRSI(bars[:end], rsiPeriod); math.Abs(got-want) > 1e-9 {
+			t.Fatalf("RSI at bar %d = %.12f, want %.12f over the whole history", end-1, got, want)
+		}
+	}
+
+	// A revised bar starts the state again on the window fetched.
+	revised := append([]model.OHLCV(nil), bars[len(bars)-300:]...)
+	revised[len(revised)-2].Close += 5
+	got, _ := tr.rsi(revised)
+	if want, _ := calculator.CalculateRSI(revised, rsiPeriod); math.Abs(got-want) > 1e-9 {
+		t.Errorf("RSI after a revision = %.12f, want %.12f", got, want)
+	}
+}
+
+func TestCollect_Volume(t *testing.T) {
+	ind, err := NewCollector(&MockFetcher{Price: 5000}, "SPX500").Collect()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Mock bars trade a steady volume and close higher every day.
+	if ind.VolumeRatio20 != 1 || ind.OBVSlope20 != 1 {
+		t.Errorf("volume ratio %v, OBV slope %d; want 1 and rising", ind.VolumeRatio20, ind.OBVSlope20)
+	}
+
+	daily := generateMockBars(5000, 300)
+	for i := range daily {
+		daily[i].Volume = 0
+	}
+	ind, err = NewCollector(&MockFetcher{Price: 5000, DailyData: daily}, "SPX500").Collect()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ind.VolumeRatio20 != 0 || ind.OBVSlope20 != 0 {
+		t.Errorf("no volume: ratio %v, OBV slope %d; want both unset", ind.VolumeRatio20, ind.OBVSlope20)
+	}
+}
+
+func TestCollect_Percentiles(t *testing.T) {
+	c := NewCollector(&MockFetcher{Price: 5000}, "SPX500")
+	ind, err := c.Collect()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Mock closes rise steadily, so a quote at the base price sits well
+	// below the usual distance above MA200.
+	if ind.WeeklyRSIPercentile <= 0 || ind.MA200DevPercentile <= 0 || ind.MA200DevPercentile > 10 {
+		t.Errorf("percentiles: weekly RSI %v, MA200 deviation %v; want both set, the latter low",
+			ind.WeeklyRSIPercentile, ind.MA200DevPercentile)
+	}
+
+	c.PercentileMinHistory = 1000
+	if ind, err = c.Collect(); err != nil {
+		t.Fatal(err)
+	}
+	if ind.WeeklyRSIPercentile != 0 || ind.MA200DevPercentile != 0 {
+		t.Errorf("short history: percentiles %v and %v, want both unset", ind.WeeklyRSIPercentile, ind.MA200DevPercentile)
+	}
+}
@@ -0,0 +1,196 @@
+package collector
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"MarketSentinel/internal/model"
+)
+
+// defaultEastMoneyBaseURL is the public historical kline host.
+const defaultEastMoneyBaseURL = "https://push2his.eastmoney.com"
+
+// EastMoney kline periods.
+const (
+	eastMoneyDaily  = "101"
+	eastMoneyWeekly = "102"
+)
+
+// EastMoneyFetcher implements Fetcher using East Money's public kline API for
+// Shanghai and Shenzhen listed indices and stocks. Securities are addressed
+// by secid, "<market>.<code>" with market 1 for Shanghai and 0 for Shenzhen.
+// The current price is the close of the newest daily bar, which is the
+// session in progress during trading hours.
+type EastMoneyFetcher struct {
+	Client    *http.Client
+	BaseURL   string            // API host, overridable for tests
+	SymbolMap map[string]string // maps internal symbol to secid
+}
+
+// NewEastMoneyFetcher creates a new East Money fetcher with optional proxy
+// support. symbolMap entries override the built-in index aliases.
+func NewEastMoneyFetcher(proxy *url.URL, symbolMap map[string]string) *EastMoneyFetcher {
+	transport := &http.Transport{}
+	if proxy != nil {
+		transport.Proxy = http.ProxyURL(proxy)
+	}
+	return &EastMoneyFetcher{
+		Client: &http.Client{
+			Timeout:   30 * time.Second,
+			Transport: transport,
+		},
+		BaseURL: defaultEastMoneyBaseURL,
+		SymbolMap: MergeSymbolMap(map[string]string{
+			"CSI300":  "1.000300",
+			"CSI500":  "1.000905",
+			"SSE50":   "1.000016",
+			"SSE":     "1.000001",
+			"SZSE":    "0.399001",
+			"CHINEXT": "0.399006",
+		}, symbolMap),
+	}
+}
+
+func (f *EastMoneyFetcher) Name() string { return "eastmoney" }
+
+// Calendar reports the A-share trading calendar.
+func (f *EastMoneyFetcher) Calendar() Calendar { return AShareCalendar }
+
+// secid resolves symbol to an East Money secid. Besides SymbolMap aliases it
+// accepts a secid as is, SH/SZ prefixed codes (SH600519), Yahoo suffixed
+// codes (600519.SS, 000001.SZ) and bare six-digit codes, which are placed by
+// their leading digit: 5, 6 and 9 trade in Shanghai, the rest in Shenzhen.
+// Shanghai indices such as 000300 share codes with Shenzhen stocks, so they
+// need an alias or an explicit prefix.
+func (f *EastMoneyFetcher) secid(symbol string) (string, error) {
+	if mapped, ok := f.SymbolMap[symbol]; ok {
+		return mapped, nil
+	}
+	s := strings.ToUpper(symbol)
+	switch {
+	case len(s) == 8 && (strings.HasPrefix(s, "1.") || strings.HasPrefix(s, "0.")) && isDigits(s[2:]):
+		return s, nil
+	case len(s) == 8 && strings.HasPrefix(s, "SH") && isDigits(s[2:]):
+		return "1." + s[2:], nil
+	case len(s) == 8 && strings.HasPrefix(s, "SZ") && isDigits(s[2:]):
+		return "0." + s[2:], nil
+	case len(s) == 9 && (strings.HasSuffix(s, ".SS") || strings.HasSuffix(s, ".SH")) && isDigits(s[:6]):
+		return "1." + s[:6], nil
+	case len(s) == 9 && strings.HasSuffix(s, ".SZ") && isDigits(s[:6]):
+		return "0." + s[:6], nil
+	case len(s) == 6 && isDigits(s):
+		if strings.ContainsRune("569", rune(s[0])) {
+			return "1." + s, nil
+		}
+		return "0." + s, nil
+	}
+	return "", fmt.Errorf("eastmoney: cannot map symbol %q to a secid, add it to data_source.symbol_map", symbol)
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return s != ""
+}
+
+func (f *EastMoneyFetcher) FetchDailyBars(symbol string, days int) ([]model.OHLCV, error) {
+	return f.fetchKlines(symbol, eastMoneyDaily, days)
+}
+
+func (f *EastMoneyFetcher) FetchWeeklyBars(symbol string, weeks int) ([]model.OHLCV, error) {
+	return f.fetchKlines(symbol, eastMoneyWeekly, weeks)
+}
+
+func (f *EastMoneyFetcher) FetchCurrentPrice(symbol string) (float64, error) {
+	bars, err := f.fetchKlines(symbol, eastMoneyDaily, 1)
+	if err != nil {
+		return 0, err
+	}
+	return bars[len(bars)-1].Close, nil
+}
+
+// fetchKlines returns the newest limit bars of period klt, oldest first,
+// unadjusted as indices are. It never returns an empty slice without an error.
+func (f *EastMoneyFetcher) fetchKlines(symbol, klt string, limit int) ([]model.OHLCV, error) {
+	secid, err := f.secid(symbol)
+	if err != nil {
+		return nil, err
+	}
+	q := url.Values{
+		"secid":   {secid},
+		"klt":     {klt},
+		"fqt":     {"0"},
+		"end":     {"20500101"},
+		"lmt":     {strconv.Itoa(limit)},
+		"fields1": {"f1,f2,f3"},
+		"fields2": {"f51,f52,f53,f54,f55,f56"},
+	}
+	resp, err := f.Client.Get(f.BaseURL + "/api/qt/stock/kline/get?" + q.Encode())
+	if err != nil {
+		return nil, fmt.Errorf("eastmoney fetch: %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("eastmoney read body: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("eastmoney: status %d, body: %s", resp.StatusCode, string(body))
+	}
+	bars, err := parseEastMoneyKlines(body)
+	if err != nil {
+		return nil, fmt.Errorf("eastmoney %s: %w", secid, err)
+	}
+	return bars, nil
+}
+
+// parseEastMoneyKlines decodes the kline response. Each kline is a string
+// "date,open,close,high,low,volume" in the order of fields2; note that close
+// precedes high and low. Unknown secids answer with "data": null.
+func parseEastMoneyKlines(body []byte) ([]model.OHLCV, error) {
+	var resp struct {
+		RC   int `json:"rc"`
+		Data *struct {
+			Code   string   `json:"code"`
+			Klines []string `json:"klines"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, fmt.Errorf("decode: %w", err)
+	}
+	if resp.Data == nil {
+		return nil, fmt.Errorf("unknown security (rc %d)", resp.RC)
+	}
+	if len(resp.Data.Klines) == 0 {
+		return nil, fmt.Errorf("no data returned")
+	}
+
+	bars := make([]model.OHLCV, 0, len(resp.Data.Klines))
+	for i, line := range resp.Data.Klines {
+		fields := strings.Split(line, ",")
+		if len(fields) < 6 {
+			return nil, fmt.Errorf("kline %d has %d fields, want at least 6", i, len(fields))
+		}
+		t, err := time.Parse("2006-01-02", fields[0])
+		if err != nil {
+			return nil, fmt.Errorf("kline %d: date %q", i, fields[0])
+		}
+		var v [5]float64
+		for j := range v {
+			if v[j], err = strconv.ParseFloat(fields[j+1], 64); err != nil {
+				return nil, fmt.Errorf("kline %d: field %d %q", i, j+1, fields[j+1])
+			}
+		}
+		bars = append(bars, model.OHLCV{Time: t, Open: v[0], Close: v[1], High: v[2], Low: v[3], Volume: v[4]})
+	}
+	return bars, nil
+}
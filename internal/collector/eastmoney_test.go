@@ -0,0 +1,130 @@
+package collector
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testdata/eastmoney holds kline responses named <secid>_<klt>.json:
+//
+//	1.000300_101  15 sessions from 2024-01-02 to 2024-01-22, close 3400 + 5/session
+//	1.000300_102  the same sessions by week, dated on each week's last session
+//
+// The server trims klines to lmt like the live API. Unknown secids get the
+// live API's "data": null answer.
+func eastMoneyFixtureServer(t *testing.T, requests *[]string) *EastMoneyFetcher {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if requests != nil {
+			*requests = append(*requests, q.Get("secid"))
+		}
+		if r.URL.Path != "/api/qt/stock/kline/get" {
+			http.NotFound(w, r)
+			return
+		}
+		data, err := os.ReadFile(filepath.Join("testdata", "eastmoney", q.Get("secid")+"_"+q.Get("klt")+".json"))
+		if err != nil {
+			w.Write([]byte(`{"rc":102,"rt":17,"svr":181669449,"lt":1,"full":0,"dlmkts":"","data":null}`))
+			return
+		}
+		var doc map[string]any
+		if err := json.Unmarshal(data, &doc); err != nil {
+			t.Fatal(err)
+		}
+		klines := doc["data"].(map[string]any)["klines"].([]any)
+		if n, _ := strconv.Atoi(q.Get("lmt")); n > 0 && n < len(klines) {
+			doc["data"].(map[string]any)["klines"] = klines[len(klines)-n:]
+		}
+		json.NewEncoder(w).Encode(doc)
+	}))
+	t.Cleanup(srv.Close)
+
+	f := NewEastMoneyFetcher(nil, nil)
+	f.BaseURL = srv.URL
+	return f
+}
+
+func TestEastMoneyFetcher_FixtureReplay(t *testing.T) {
+	f := eastMoneyFixtureServer(t, nil)
+
+	daily, err := f.FetchDailyBars("CSI300", 10)
+	if err != nil {
+		t.Fatalf("FetchDailyBars: %v", err)
+	}
+	if len(daily) != 10 {
+		t.Fatalf("expected 10 daily bars, got %d", len(daily))
+	}
+	// Klines list close before high and low.
+	last := daily[len(daily)-1]
+	if !last.Time.Equal(time.Date(2024, 1, 22, 0, 0, 0, 0, time.UTC)) ||
+		last.Open != 3468 || last.Close != 3470 || last.High != 3476 || last.Low != 3462 || last.Volume != 100000014 {
+		t.Errorf("unexpected last bar: %+v", last)
+	}
+
+	weekly, err := f.FetchWeeklyBars("CSI300", 60)
+	if err != nil {
+		t.Fatalf("FetchWeeklyBars: %v", err)
+	}
+	if len(weekly) != 4 || weekly[1].Open != 3418 || weekly[1].Close != 3440 || weekly[1].High != 3446 || weekly[1].Low != 3412 {
+		t.Errorf("unexpected weekly bars: %+v", weekly)
+	}
+
+	price, err := f.FetchCurrentPrice("CSI300")
+	if err != nil || price != 3470 {
+		t.Errorf("FetchCurrentPrice = %v, %v; want 3470", price, err)
+	}
+
+	if _, err := f.FetchDailyBars("SH000999", 10); err == nil || !strings.Contains(err.Error(), "eastmoney 1.000999: unknown security") {
+		t.Errorf("expected an unknown security error, got %v", err)
+	}
+}
+
+func TestEastMoneyFetcher_Secid(t *testing.T) {
+	f := NewEastMoneyFetcher(nil, map[string]string{"MYIDX": "0.399300"})
+	tests := map[string]string{
+		"CSI300":    "1.000300",
+		"CHINEXT":   "0.399006",
+		"MYIDX":     "0.399300",
+		"1.000001":  "1.000001",
+		"SH000001":  "1.000001", // Shanghai composite index
+		"sz000001":  "0.000001", // Ping An Bank
+		"600519.SS": "1.600519",
+		"000001.SZ": "0.000001",
+		"600519":    "1.600519",
+		"510300":    "1.510300",
+		"300750":    "0.300750",
+		"000001":    "0.000001",
+	}
+	for symbol, want := range tests {
+		if got, err := f.secid(symbol); err != nil || got != want {
+			t.Errorf("secid(%q) = %q, %v; want %q", symbol, got, err, want)
+		}
+	}
+	for _, symbol := range []string{"SPX500", "60051", "SH60051X"} {
+		if got, err := f.secid(symbol); err == nil {
+			t.Errorf("secid(%q) = %q, want an error", symbol, got)
+		}
+	}
+}
+
+func TestParseEastMoneyKlines_Malformed(t *testing.T) {
+	for name, body := range map[string]string{
+		"empty":  `{"rc":0,"data":{"code":"000300","klines":[]}}`,
+		"short":  `{"rc":0,"data":{"code":"000300","klines":["2024-01-02,1,2"]}}`,
+		"date":   `{"rc":0,"data":{"code":"000300","klines":["01/02/2024,1,2,3,0.5,10"]}}`,
+		"number": `{"rc":0,"data":{"code":"000300","klines":["2024-01-02,1,-,3,0.5,10"]}}`,
+		"json":   `<html>`,
+	} {
+		if bars, err := parseEastMoneyKlines([]byte(body)); err == nil {
+			t.Errorf("%s: parsed %+v, want an error", name, bars)
+		}
+	}
+}
@@ -1,6 +1,14 @@
 package collector
 
-import "MarketSentinel/internal/model"
+import (
+	"errors"
+
+	"MarketSentinel/internal/model"
+)
+
+// ErrNotSupported is returned for data a fetcher cannot provide, such as
+// intraday bars from a source serving only daily bars.
+var ErrNotSupported = errors.New("not supported")
 
 // Fetcher defines the interface for fetching market data.
 type Fetcher interface {
@@ -9,3 +17,50 @@ type Fetcher interface {
 	FetchCurrentPrice(symbol string) (float64, error)
 	Name() string
 }
+
+// IntradayFetcher is implemented by fetchers serving intraday bars.
+type IntradayFetcher interface {
+	// FetchIntradayBars returns the latest bars bars of interval, such as
+	// "60m", oldest first.
+	FetchIntradayBars(symbol, interval string, bars int) ([]model.OHLCV, error)
+}
+
+// FetchIntradayBars fetches intraday bars from f, or returns ErrNotSupported
+// when f does not implement IntradayFetcher.
+func FetchIntradayBars(f Fetcher, symbol, interval string, bars int) ([]model.OHLCV, error) {
+	if inf, ok := f.(IntradayFetcher); ok {
+		return inf.FetchIntradayBars(symbol, interval, bars)
+	}
+	return nil, ErrNotSupported
+}
+
+// Calendar describes how many daily bars a market produces, for the windows
+// that are defined in calendar time.
+type Calendar struct {
+	SessionsPerYear  int // daily bars in 52 weeks
+	SessionsPerMonth int // daily bars in 30 days
+}
+
+var (
+	// EquityCalendar fits exchanges trading five days a week.
+	EquityCalendar = Calendar{SessionsPerYear: 252, SessionsPerMonth: 22}
+	// CryptoCalendar fits markets trading every day.
+	CryptoCalendar = Calendar{SessionsPerYear: 365, SessionsPerMonth: 30}
+	// AShareCalendar fits the Shanghai and Shenzhen exchanges, whose long
+	// holidays leave about 242 sessions a year.
+	AShareCalendar = Calendar{SessionsPerYear: 242, SessionsPerMonth: 20}
+)
+
+// calendarFetcher is implemented by fetchers whose market does not follow
+// EquityCalendar.
+type calendarFetcher interface {
+	Calendar() Calendar
+}
+
+// calendarOf returns f's calendar, EquityCalendar by default.
+func calendarOf(f Fetcher) Calendar {
+	if cf, ok := f.(calendarFetcher); ok {
+		return cf.Calendar()
+	}
+	return EquityCalendar
+}
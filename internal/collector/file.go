@@ -0,0 +1,250 @@
+package collector
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"MarketSentinel/internal/calculator"
+	"MarketSentinel/internal/model"
+)
+
+// FileFetcher implements Fetcher over bars stored on disk as CSV or JSON, for
+// offline runs and backtests. The format follows the file extension:
+//
+//	.csv   header date,open,high,low,close[,volume], in any order and case
+//	.json  array of {"date","open","high","low","close","volume"} objects
+//
+// Dates are 2006-01-02 or RFC 3339. "{symbol}" in a path is replaced by the
+// requested symbol. Without WeeklyPath, weekly bars are aggregated from the
+// daily bars.
+type FileFetcher struct {
+	DailyPath  string
+	WeeklyPath string
+
+	mu    sync.Mutex
+	asOf  time.Time                // zero means no cutoff
+	cache map[string][]model.OHLCV // resolved path -> parsed bars, oldest first
+}
+
+// NewFileFetcher creates a fetcher reading daily bars from dailyPath and,
+// optionally, weekly bars from weeklyPath.
+func NewFileFetcher(dailyPath, weeklyPath string) *FileFetcher {
+	return &FileFetcher{DailyPath: dailyPath, WeeklyPath: weeklyPath, cache: make(map[string][]model.OHLCV)}
+}
+
+func (f *FileFetcher) Name() string { return "file" }
+
+// SetAsOf hides every bar after t, so the fetcher sees the market as it was at
+// t. A zero t removes the cutoff. A weekly file's last bar before t may still
+// include sessions after t; leave WeeklyPath empty when that matters.
+func (f *FileFetcher) SetAsOf(t time.Time) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.asOf = t
+}
+
+func (f *FileFetcher) FetchDailyBars(symbol string, days int) ([]model.OHLCV, error) {
+	bars, err := f.load(f.DailyPath, symbol)
+	if err != nil {
+		return nil, err
+	}
+	if len(bars) > days {
+		bars = bars[len(bars)-days:]
+	}
+	return bars, nil
+}
+
+func (f *FileFetcher) FetchWeeklyBars(symbol string, weeks int) ([]model.OHLCV, error) {
+	var bars []model.OHLCV
+	if f.WeeklyPath != "" {
+		var err error
+		if bars, err = f.load(f.WeeklyPath, symbol); err != nil {
+			return nil, err
+		}
+	} else {
+		daily, err := f.load(f.DailyPath, symbol)
+		if err != nil {
+			return nil, err
+		}
+		bars = calculator.AggregateWeekly(daily)
+	}
+	if len(bars) > weeks {
+		bars = bars[len(bars)-weeks:]
+	}
+	return bars, nil
+}
+
+// FetchCurrentPrice returns the close of the last daily bar up to the cutoff.
+func (f *FileFetcher) FetchCurrentPrice(symbol string) (float64, error) {
+	bars, err := f.load(f.DailyPath, symbol)
+	if err != nil {
+		return 0, err
+	}
+	return bars[len(bars)-1].Close, nil
+}
+
+// load returns the bars of path for symbol up to the cutoff, parsing the file
+// on first use. It never returns an empty slice without an error. Callers must
+// treat the returned slice as read-only.
+func (f *FileFetcher) load(path, symbol string) ([]model.OHLCV, error) {
+	path = strings.ReplaceAll(path, "{symbol}", symbol)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	bars, ok := f.cache[path]
+	if !ok {
+		var err error
+		if bars, err = readBarFile(path); err != nil {
+			return nil, fmt.Errorf("file %s: %w", path, err)
+		}
+		f.cache[path] = bars
+	}
+	if !f.asOf.IsZero() {
+		n := sort.Search(len(bars), func(i int) bool { return bars[i].Time.After(f.asOf) })
+		bars = bars[:n]
+	}
+	if len(bars) == 0 {
+		if !f.asOf.IsZero() {
+			return nil, fmt.Errorf("file %s: no bars on or before %s", path, f.asOf.Format("2006-01-02"))
+		}
+		return nil, fmt.Errorf("file %s: no bars", path)
+	}
+	return bars, nil
+}
+
+// readBarFile parses a CSV or JSON bar file and sorts it by time.
+func readBarFile(path string) ([]model.OHLCV, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var bars []model.OHLCV
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".csv":
+		bars, err = parseBarCSV(data)
+	case ".json":
+		bars, err = parseBarJSON(data)
+	default:
+		return nil, fmt.Errorf("unsupported extension %q, want .csv or .json", ext)
+	}
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(bars, func(i, j int) bool { return bars[i].Time.Before(bars[j].Time) })
+	return bars, nil
+}
+
+func parseBarCSV(data []byte) ([]model.OHLCV, error) {
+	r := csv.NewReader(bytes.NewReader(data))
+	r.FieldsPerRecord = -1
+	records, err := r.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("empty file")
+	}
+
+	col := map[string]int{}
+	for i, name := range records[0] {
+		col[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, name := range []string{"date", "open", "high", "low", "close"} {
+		if _, ok := col[name]; !ok {
+			return nil, fmt.Errorf("missing column %q", name)
+		}
+	}
+	field := func(rec []string, name string) (string, bool) {
+		i, ok := col[name]
+		if !ok || i >= len(rec) {
+			return "", false
+		}
+		return strings.TrimSpace(rec[i]), true
+	}
+
+	bars := make([]model.OHLCV, 0, len(records)-1)
+	for n, rec := range records[1:] {
+		line := n + 2
+		s, ok := field(rec, "date")
+		if !ok {
+			return nil, fmt.Errorf("line %d: missing date", line)
+		}
+		t, err := parseBarDate(s)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		bar := model.OHLCV{Time: t}
+		for _, c := range []struct {
+			name     string
+			dst      *float64
+			optional bool
+		}{
+			{"open", &bar.Open, false},
+			{"high", &bar.High, false},
+			{"low", &bar.Low, false},
+			{"close", &bar.Close, false},
+			{"volume", &bar.Volume, true},
+		} {
+			s, ok := field(rec, c.name)
+			if !ok || s == "" {
+				if c.optional {
+					continue
+				}
+				return nil, fmt.Errorf("line %d: missing %s", line, c.name)
+			}
+			if *c.dst, err = strconv.ParseFloat(s, 64); err != nil {
+				return nil, fmt.Errorf("line %d: %s %q is not a number", line, c.name, s)
+			}
+		}
+		bars = append(bars, bar)
+	}
+	return bars, nil
+}
+
+func parseBarJSON(data []byte) ([]model.OHLCV, error) {
+	var rows []struct {
+		Date   string   `json:"date"`
+		Open   *float64 `json:"open"`
+		High   *float64 `json:"high"`
+		Low    *float64 `json:"low"`
+		Close  *float64 `json:"close"`
+		Volume float64  `json:"volume"`
+	}
+	if err := json.Unmarshal(data, &rows); err != nil {
+		return nil, err
+	}
+	bars := make([]model.OHLCV, 0, len(rows))
+	for i, row := range rows {
+		t, err := parseBarDate(row.Date)
+		if err != nil {
+			return nil, fmt.Errorf("bar %d: %w", i, err)
+		}
+		if row.Open == nil || row.High == nil || row.Low == nil || row.Close == nil {
+			return nil, fmt.Errorf("bar %d: missing open/high/low/close", i)
+		}
+		bars = append(bars, model.OHLCV{
+			Time: t, Open: *row.Open, High: *row.High, Low: *row.Low, Close: *row.Close, Volume: row.Volume,
+		})
+	}
+	return bars, nil
+}
+
+func parseBarDate(s string) (time.Time, error) {
+	if t, err := time.Parse("2006-01-02", s); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("date %q is neither 2006-01-02 nor RFC 3339", s)
+}
@@ -0,0 +1,123 @@
+package collector
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeBarFile writes content to name under dir and returns its path.
+func writeBarFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// dailyCSV has ten sessions, 2024-01-02..2024-01-15, close 100 + index, in
+// reverse order to check sorting.
+const dailyCSV = `Date,Open,High,Low,Close,Volume
+2024-01-15,108,111,107,109,1000
+2024-01-12,107,110,106,108,1000
+2024-01-11,106,109,105,107,1000
+2024-01-10,105,108,104,106,1000
+2024-01-09,104,107,103,105,1000
+2024-01-08,103,106,102,104,1000
+2024-01-05,102,105,101,103,1000
+2024-01-04,101,104,100,102,1000
+2024-01-03,100,103,99,101,1000
+2024-01-02,99,102,98,100,
+`
+
+func TestFileFetcher_AsOfTruncates(t *testing.T) {
+	dir := t.TempDir()
+	writeBarFile(t, dir, "SPX500_daily.csv", dailyCSV)
+	f := NewFileFetcher(filepath.Join(dir, "{symbol}_daily.csv"), "")
+
+	all, err := f.FetchDailyBars("SPX500", 300)
+	if err != nil {
+		t.Fatalf("FetchDailyBars: %v", err)
+	}
+	if len(all) != 10 || all[0].Close != 100 || all[0].Volume != 0 || all[9].Close != 109 {
+		t.Fatalf("unexpected bars: first %+v, last %+v (%d)", all[0], all[len(all)-1], len(all))
+	}
+
+	f.SetAsOf(time.Date(2024, 1, 9, 0, 0, 0, 0, time.UTC))
+	daily, err := f.FetchDailyBars("SPX500", 300)
+	if err != nil {
+		t.Fatalf("FetchDailyBars as of: %v", err)
+	}
+	if len(daily) != 6 || !daily[5].Time.Equal(time.Date(2024, 1, 9, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("expected 6 bars ending 2024-01-09, got %d ending %s", len(daily), daily[len(daily)-1].Time)
+	}
+	if short, _ := f.FetchDailyBars("SPX500", 2); len(short) != 2 || short[1].Close != 105 {
+		t.Errorf("expected last 2 bars before cutoff, got %+v", short)
+	}
+	price, err := f.FetchCurrentPrice("SPX500")
+	if err != nil || price != 105 {
+		t.Errorf("price = %v (%v), want 105 from 2024-01-09", price, err)
+	}
+	weekly, err := f.FetchWeeklyBars("SPX500", 60)
+	if err != nil {
+		t.Fatalf("FetchWeeklyBars: %v", err)
+	}
+	if len(weekly) != 2 || weekly[1].Close != 105 || weekly[1].High != 107 {
+		t.Errorf("weekly bars should be aggregated from the truncated daily bars: %+v", weekly)
+	}
+
+	f.SetAsOf(time.Date(2023, 12, 31, 0, 0, 0, 0, time.UTC))
+	if _, err := f.FetchCurrentPrice("SPX500"); err == nil || !strings.Contains(err.Error(), "no bars on or before 2023-12-31") {
+		t.Errorf("expected no-bars error before the first session, got %v", err)
+	}
+}
+
+func TestFileFetcher_JSONWeekly(t *testing.T) {
+	dir := t.TempDir()
+	daily := writeBarFile(t, dir, "daily.csv", dailyCSV)
+	weekly := writeBarFile(t, dir, "weekly.json", `[
+		{"date": "2024-01-08T00:00:00Z", "open": 103, "high": 111, "low": 102, "close": 109, "volume": 5000},
+		{"date": "2024-01-01", "open": 99, "high": 105, "low": 98, "close": 103}
+	]`)
+	f := NewFileFetcher(daily, weekly)
+
+	bars, err := f.FetchWeeklyBars("SPX500", 60)
+	if err != nil {
+		t.Fatalf("FetchWeeklyBars: %v", err)
+	}
+	if len(bars) != 2 || bars[0].Close != 103 || bars[1].Volume != 5000 {
+		t.Errorf("unexpected weekly bars: %+v", bars)
+	}
+}
+
+func TestFileFetcher_Errors(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		name, file, content, want string
+	}{
+		{"missing file", "", "", "no such file"},
+		{"extension", "bars.txt", "x", `unsupported extension ".txt"`},
+		{"missing column", "nocol.csv", "date,open,high,low\n2024-01-02,1,2,0\n", `missing column "close"`},
+		{"short row", "short.csv", "date,open,high,low,close\n2024-01-02,1,2\n", "line 2: missing low"},
+		{"bad number", "num.csv", "date,open,high,low,close\n2024-01-02,1,2,0,x\n", `line 2: close "x" is not a number`},
+		{"bad date", "date.csv", "date,open,high,low,close\n01/02/2024,1,2,0,1\n", `line 2: date "01/02/2024"`},
+		{"header only", "empty.csv", "date,open,high,low,close\n", "no bars"},
+		{"bad json", "bad.json", `{"date":`, "unexpected end of JSON"},
+		{"json missing field", "nofield.json", `[{"date":"2024-01-02","open":1,"high":2,"low":0}]`, "bar 0: missing open/high/low/close"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(dir, "absent.csv")
+			if tt.file != "" {
+				path = writeBarFile(t, dir, tt.file, tt.content)
+			}
+			_, err := NewFileFetcher(path, "").FetchDailyBars("SPX500", 10)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,147 @@
+package collector
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"MarketSentinel/internal/model"
+)
+
+// MacroFetcher fetches macroeconomic observation series, which unlike prices
+// have a single value per date.
+type MacroFetcher interface {
+	// FetchSeries returns the observations of series id dated within the
+	// last days calendar days, oldest first, skipping missing values.
+	FetchSeries(id string, days int) ([]model.MacroPoint, error)
+	Name() string
+}
+
+// defaultFREDBaseURL is the FRED API host.
+const defaultFREDBaseURL = "https://api.stlouisfed.org"
+
+// FREDFetcher implements MacroFetcher using the St. Louis Fed's FRED API,
+// which needs a free API key.
+type FREDFetcher struct {
+	Client  *http.Client
+	BaseURL string // API host, overridable for tests
+	APIKey  string
+	Now     func() time.Time // clock for the observation window, time.Now if nil
+}
+
+// NewFREDFetcher creates a new FRED fetcher with optional proxy support.
+func NewFREDFetcher(apiKey string, proxy *url.URL) *FREDFetcher {
+	transport := &http.Transport{}
+	if proxy != nil {
+		transport.Proxy = http.ProxyURL(proxy)
+	}
+	return &FREDFetcher{
+		Client: &http.Client{
+			Timeout:   30 * time.Second,
+			Transport: transport,
+		},
+		BaseURL: defaultFREDBaseURL,
+		APIKey:  apiKey,
+	}
+}
+
+func (f *FREDFetcher) Name() string { return "fred" }
+
+func (f *FREDFetcher) FetchSeries(id string, days int) ([]model.MacroPoint, error) {
+	now := time.Now
+	if f.Now != nil {
+		now = f.Now
+	}
+	q := url.Values{
+		"series_id":         {id},
+		"api_key":           {f.APIKey},
+		"file_type":         {"json"},
+		"sort_order":        {"asc"},
+		"observation_start": {now().AddDate(0, 0, -days).Format("2006-01-02")},
+	}
+	resp, err := f.Client.Get(f.BaseURL + "/fred/series/observations?" + q.Encode())
+	if err != nil {
+		return nil, fmt.Errorf("fred fetch: %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("fred read body: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		// Errors look like {"error_code":400,"error_message":"Bad Request. ..."}.
+		var apiErr struct {
+			Message string `json:"error_message"`
+		}
+		if json.Unmarshal(body, &apiErr) == nil && apiErr.Message != "" {
+			return nil, fmt.Errorf("fred %s: status %d: %s", id, resp.StatusCode, apiErr.Message)
+		}
+		return nil, fmt.Errorf("fred %s: status %d, body: %s", id, resp.StatusCode, string(body))
+	}
+	points, err := parseFREDObservations(body)
+	if err != nil {
+		return nil, fmt.Errorf("fred %s: %w", id, err)
+	}
+	return points, nil
+}
+
+// parseFREDObservations decodes an observations response. Values are decimal
+// strings; "." marks a date without a value, such as a bond market holiday.
+func parseFREDObservations(body []byte) ([]model.MacroPoint, error) {
+	var resp struct {
+		Observations []struct {
+			Date  string `json:"date"`
+			Value string `json:"value"`
+		} `json:"observations"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, fmt.Errorf("decode: %w", err)
+	}
+	points := make([]model.MacroPoint, 0, len(resp.Observations))
+	for i, o := range resp.Observations {
+		if o.Value == "." {
+			continue
+		}
+		t, err := time.Parse("2006-01-02", o.Date)
+		if err != nil {
+			return nil, fmt.Errorf("observation %d: date %q", i, o.Date)
+		}
+		v, err := strconv.ParseFloat(o.Value, 64)
+		if err != nil {
+			return nil, fmt.Errorf("observation %d: value %q", i, o.Value)
+		}
+		points = append(points, model.MacroPoint{Date: t, Value: v})
+	}
+	if len(points) == 0 {
+		return nil, fmt.Errorf("no observations returned")
+	}
+	return points, nil
+}
+
+// MacroLatest returns the newest value of points, or 0 for none.
+func MacroLatest(points []model.MacroPoint) float64 {
+	if len(points) == 0 {
+		return 0
+	}
+	return points[len(points)-1].Value
+}
+
+// MacroAverage returns the average of the newest n values of points, or of
+// all of them when there are fewer; 0 for none.
+func MacroAverage(points []model.MacroPoint, n int) float64 {
+	if len(points) > n {
+		points = points[len(points)-n:]
+	}
+	if len(points) == 0 {
+		return 0
+	}
+	sum := 0.0
+	for _, p := range points {
+		sum += p.Value
+	}
+	return sum / float64(len(points))
+}
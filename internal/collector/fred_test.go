@@ -0,0 +1,96 @@
+package collector
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fredServer answers DGS10 with a response shaped like the live API,
+// including a "." holiday, and rejects other series the way FRED does.
+func fredServer(t *testing.T, query *string) *FREDFetcher {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		*query = r.URL.RawQuery
+		if r.URL.Path != "/fred/series/observations" || q.Get("series_id") != "DGS10" {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"error_code":400,"error_message":"Bad Request.  The series does not exist."}`)
+			return
+		}
+		fmt.Fprint(w, `{"realtime_start":"2024-01-20","realtime_end":"2024-01-20","observation_start":"2023-12-06",
+			"units":"lin","output_type":1,"file_type":"json","order_by":"observation_date","sort_order":"asc","count":5,"offset":0,"limit":100000,
+			"observations":[
+			{"realtime_start":"2024-01-20","realtime_end":"2024-01-20","date":"2024-01-12","value":"3.94"},
+			{"realtime_start":"2024-01-20","realtime_end":"2024-01-20","date":"2024-01-15","value":"."},
+			{"realtime_start":"2024-01-20","realtime_end":"2024-01-20","date":"2024-01-16","value":"4.06"},
+			{"realtime_start":"2024-01-20","realtime_end":"2024-01-20","date":"2024-01-17","value":"4.10"},
+			{"realtime_start":"2024-01-20","realtime_end":"2024-01-20","date":"2024-01-18","value":"4.14"}]}`)
+	}))
+	t.Cleanup(srv.Close)
+
+	f := NewFREDFetcher("secret", nil)
+	f.BaseURL = srv.URL
+	f.Now = func() time.Time { return time.Date(2024, 1, 20, 12, 0, 0, 0, time.UTC) }
+	return f
+}
+
+func TestFREDFetcher_FetchSeries(t *testing.T) {
+	var query string
+	f := fredServer(t, &query)
+
+	points, err := f.FetchSeries("DGS10", 45)
+	if err != nil {
+		t.Fatalf("FetchSeries: %v", err)
+	}
+	for _, want := range []string{"api_key=secret", "file_type=json", "observation_start=2023-12-06"} {
+		if !strings.Contains(query, want) {
+			t.Errorf("query %q lacks %s", query, want)
+		}
+	}
+	if len(points) != 4 || !points[1].Date.Equal(time.Date(2024, 1, 16, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("holiday not skipped: %+v", points)
+	}
+	if got := MacroLatest(points); got != 4.14 {
+		t.Errorf("latest = %v, want 4.14", got)
+	}
+	if got := MacroAverage(points, 2); math.Abs(got-4.12) > 1e-9 {
+		t.Errorf("average of last 2 = %v, want 4.12", got)
+	}
+	if got := MacroAverage(points, 20); got != (3.94+4.06+4.10+4.14)/4 {
+		t.Errorf("average of a short series = %v, want the mean of all", got)
+	}
+
+	if _, err := f.FetchSeries("NOPE", 45); err == nil || !strings.Contains(err.Error(), "fred NOPE: status 400: Bad Request.  The series does not exist.") {
+		t.Errorf("expected the API error message, got %v", err)
+	}
+}
+
+func TestCollect_Yield(t *testing.T) {
+	var query string
+	c := NewCollector(&MockFetcher{Price: 5000}, "SPX500")
+	ind, err := c.Collect()
+	if err != nil || ind.Yield10Y != 0 {
+		t.Fatalf("without a macro source: %v, yield %v", err, ind.Yield10Y)
+	}
+
+	c.Macro, c.YieldSeries = fredServer(t, &query), "DGS10"
+	if ind, err = c.Collect(); err != nil {
+		t.Fatal(err)
+	}
+	if ind.Yield10Y != 4.14 || ind.Yield10Y20d != (3.94+4.06+4.10+4.14)/4 {
+		t.Errorf("yield %v/%v", ind.Yield10Y, ind.Yield10Y20d)
+	}
+
+	c.YieldSeries = "NOPE"
+	if ind, err = c.Collect(); err != nil {
+		t.Fatalf("a failing yield series must not fail the collection: %v", err)
+	}
+	if ind.Yield10Y != 0 {
+		t.Errorf("yield = %v after a failed fetch, want zero", ind.Yield10Y)
+	}
+}
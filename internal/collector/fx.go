@@ -0,0 +1,27 @@
+package collector
+
+import "fmt"
+
+// FXFetcher quotes an exchange rate through a price Fetcher. With Yahoo the
+// symbol CNY=X quotes yuan per US dollar.
+type FXFetcher struct {
+	Quotes Fetcher
+	Symbol string
+}
+
+// NewFXFetcher creates an FXFetcher quoting symbol through quotes.
+func NewFXFetcher(quotes Fetcher, symbol string) *FXFetcher {
+	return &FXFetcher{Quotes: quotes, Symbol: symbol}
+}
+
+// Rate returns the current rate, rejecting non-positive quotes.
+func (f *FXFetcher) Rate() (float64, error) {
+	rate, err := f.Quotes.FetchCurrentPrice(f.Symbol)
+	if err != nil {
+		return 0, fmt.Errorf("fetch %s rate: %w", f.Symbol, err)
+	}
+	if rate <= 0 {
+		return 0, fmt.Errorf("fetch %s rate: invalid rate %v", f.Symbol, rate)
+	}
+	return rate, nil
+}
@@ -0,0 +1,173 @@
+package collector
+
+import (
+	"fmt"
+	"time"
+)
+
+// Exchanges with a built-in trading calendar.
+const (
+	ExchangeNYSE   = "nyse"   // US equities; holidays computed from NYSE rules
+	ExchangeSSE    = "sse"    // Shanghai and Shenzhen; holidays must be configured
+	ExchangeCrypto = "crypto" // open every day
+)
+
+// TradingCalendar reports the days an exchange is open. A day is judged in
+// the exchange's own time zone, so 22:00 in Shanghai is still the same New
+// York session date.
+type TradingCalendar struct {
+	Exchange string
+	Location *time.Location
+	EveryDay bool // open on weekends
+
+	rules    func(year int) []time.Time // computed holidays of a year
+	holidays map[string]bool            // extra closed dates, 2006-01-02
+}
+
+// NewTradingCalendar returns the calendar of exchange, closed in addition on
+// each of holidays (2006-01-02 dates). A-share holidays are announced yearly
+// and have no rules, so they come from holidays only.
+func NewTradingCalendar(exchange string, holidays []string) (*TradingCalendar, error) {
+	c := &TradingCalendar{Exchange: exchange, holidays: make(map[string]bool, len(holidays))}
+	zone := "UTC"
+	switch exchange {
+	case ExchangeNYSE:
+		zone, c.rules = "America/New_York", nyseHolidays
+		for _, d := range nyseSpecialClosures {
+			c.holidays[d] = true
+		}
+	case ExchangeSSE:
+		zone = "Asia/Shanghai"
+	case ExchangeCrypto:
+		c.EveryDay = true
+	default:
+		return nil, fmt.Errorf("unknown exchange %q, want %s, %s or %s", exchange, ExchangeNYSE, ExchangeSSE, ExchangeCrypto)
+	}
+	loc, err := time.LoadLocation(zone)
+	if err != nil {
+		return nil, fmt.Errorf("load %s time zone: %w", exchange, err)
+	}
+	c.Location = loc
+	for _, d := range holidays {
+		if _, err := time.Parse("2006-01-02", d); err != nil {
+			return nil, fmt.Errorf("holiday %q is not a 2006-01-02 date", d)
+		}
+		c.holidays[d] = true
+	}
+	return c, nil
+}
+
+// exchangeFor guesses the exchange of a market from its session calendar.
+func exchangeFor(cal Calendar) string {
+	switch cal {
+	case CryptoCalendar:
+		return ExchangeCrypto
+	case AShareCalendar:
+		return ExchangeSSE
+	default:
+		return ExchangeNYSE
+	}
+}
+
+// DefaultExchange returns the exchange f's market most likely trades on.
+func DefaultExchange(f Fetcher) string { return exchangeFor(calendarOf(f)) }
+
+// IsTradingDay reports whether the exchange is open on t's date in its time zone.
+func (c *TradingCalendar) IsTradingDay(t time.Time) bool {
+	t = t.In(c.Location)
+	if c.EveryDay {
+		return !c.holidays[t.Format("2006-01-02")]
+	}
+	if t.Weekday() == time.Saturday || t.Weekday() == time.Sunday {
+		return false
+	}
+	if c.holidays[t.Format("2006-01-02")] {
+		return false
+	}
+	if c.rules != nil {
+		for _, h := range c.rules(t.Year()) {
+			if h.Month() == t.Month() && h.Day() == t.Day() {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// nyseSpecialClosures are one-off NYSE closures no rule predicts, such as
+// national days of mourning.
+var nyseSpecialClosures = []string{
+	"2012-10-29", "2012-10-30", // Hurricane Sandy
+	"2018-12-05", // President George H. W. Bush
+	"2025-01-09", // President Jimmy Carter
+}
+
+// nyseHolidays returns the NYSE full-day holidays of year that fall on
+// weekdays, following NYSE Rule 7.2: a Sunday holiday is observed on Monday
+// and a Saturday one on Friday, except New Year's Day, which is then skipped
+// since the Friday belongs to the previous year's final session.
+func nyseHolidays(year int) []time.Time {
+	date := func(m time.Month, d int) time.Time { return time.Date(year, m, d, 0, 0, 0, 0, time.UTC) }
+	observed := func(t time.Time) time.Time {
+		switch t.Weekday() {
+		case time.Saturday:
+			return t.AddDate(0, 0, -1)
+		case time.Sunday:
+			return t.AddDate(0, 0, 1)
+		}
+		return t
+	}
+	// nth returns the nth weekday wd of month m; n < 0 counts from the end.
+	nth := func(m time.Month, wd time.Weekday, n int) time.Time {
+		if n < 0 {
+			t := date(m+1, 1).AddDate(0, 0, -1)
+			for t.Weekday() != wd {
+				t = t.AddDate(0, 0, -1)
+			}
+			return t
+		}
+		t := date(m, 1)
+		for t.Weekday() != wd {
+			t = t.AddDate(0, 0, 1)
+		}
+		return t.AddDate(0, 0, 7*(n-1))
+	}
+
+	var days []time.Time
+	if ny := date(time.January, 1); ny.Weekday() != time.Saturday {
+		days = append(days, observed(ny))
+	}
+	days = append(days,
+		nth(time.January, time.Monday, 3),  // Martin Luther King Jr. Day
+		nth(time.February, time.Monday, 3), // Washington's Birthday
+		easter(year).AddDate(0, 0, -2),     // Good Friday
+		nth(time.May, time.Monday, -1),     // Memorial Day
+	)
+	if year >= 2022 {
+		days = append(days, observed(date(time.June, 19))) // Juneteenth
+	}
+	days = append(days,
+		observed(date(time.July, 4)),
+		nth(time.September, time.Monday, 1),  // Labor Day
+		nth(time.November, time.Thursday, 4), // Thanksgiving
+		observed(date(time.December, 25)),
+	)
+	return days
+}
+
+// easter returns Easter Sunday of year in the Gregorian calendar, using the
+// anonymous Gregorian (Meeus/Jones/Butcher) algorithm.
+func easter(year int) time.Time {
+	a := year % 19
+	b, c := year/100, year%100
+	d, e := b/4, b%4
+	f := (b + 8) / 25
+	g := (b - f + 1) / 3
+	h := (19*a + b - d - g + 15) % 30
+	i, k := c/4, c%4
+	l := (32 + 2*e + 2*i - h - k) % 7
+	m := (a + 11*h + 22*l) / 451
+	month := (h + l - 7*m + 114) / 31
+	day := (h+l-7*m+114)%31 + 1
+	return time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,75 @@
+package collector
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNYSEHolidays(t *testing.T) {
+	cal, err := NewTradingCalendar(ExchangeNYSE, nil)
+	if err != nil {
+		t.Skipf("no time zone data: %v", err)
+	}
+	closed := []string{
+		"2026-01-01", "2026-01-19", "2026-02-16", "2026-04-03", "2026-05-25",
+		"2026-06-19", "2026-07-03", "2026-09-07", "2026-11-26", "2026-12-25",
+		"2021-12-24", // Christmas on a Saturday, observed Friday
+		"2023-01-02", // New Year's Day on a Sunday, observed Monday
+		"2024-03-29", // Good Friday
+		"2025-01-09", // special closure
+		"2026-10-17", // Saturday
+	}
+	open := []string{
+		"2021-12-31", // New Year's Day 2022 fell on a Saturday: not observed
+		"2021-06-18", // before Juneteenth became a holiday
+		"2026-04-06", "2026-10-15", "2026-11-27",
+	}
+	for _, d := range closed {
+		if day, _ := time.ParseInLocation("2006-01-02", d, cal.Location); cal.IsTradingDay(day.Add(12 * time.Hour)) {
+			t.Errorf("%s should be closed", d)
+		}
+	}
+	for _, d := range open {
+		if day, _ := time.ParseInLocation("2006-01-02", d, cal.Location); !cal.IsTradingDay(day.Add(12 * time.Hour)) {
+			t.Errorf("%s should be open", d)
+		}
+	}
+
+	// 01:00 UTC on the 26th is still Christmas evening in New York.
+	if cal.IsTradingDay(time.Date(2025, 12, 26, 1, 0, 0, 0, time.UTC)) {
+		t.Error("date not taken in the exchange's time zone")
+	}
+}
+
+func TestTradingCalendar_Exchanges(t *testing.T) {
+	sat := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+	crypto, err := NewTradingCalendar(ExchangeCrypto, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !crypto.IsTradingDay(sat) || !crypto.IsTradingDay(time.Date(2025, 12, 25, 12, 0, 0, 0, time.UTC)) {
+		t.Error("crypto should trade every day")
+	}
+
+	sse, err := NewTradingCalendar(ExchangeSSE, []string{"2026-10-01", "2026-10-02"})
+	if err != nil {
+		t.Skipf("no time zone data: %v", err)
+	}
+	if sse.IsTradingDay(time.Date(2026, 10, 1, 10, 0, 0, 0, sse.Location)) || sse.IsTradingDay(sat) {
+		t.Error("configured holiday or weekend counted as open")
+	}
+	if !sse.IsTradingDay(time.Date(2026, 10, 8, 10, 0, 0, 0, sse.Location)) {
+		t.Error("ordinary weekday counted as closed")
+	}
+
+	if _, err := NewTradingCalendar("lse", nil); err == nil {
+		t.Error("unknown exchange accepted")
+	}
+	if _, err := NewTradingCalendar(ExchangeCrypto, []string{"10/01/2026"}); err == nil {
+		t.Error("malformed holiday accepted")
+	}
+	if DefaultExchange(NewBinanceFetcher(nil, nil)) != ExchangeCrypto || DefaultExchange(NewEastMoneyFetcher(nil, nil)) != ExchangeSSE ||
+		DefaultExchange(&MockFetcher{}) != ExchangeNYSE {
+		t.Error("unexpected default exchanges")
+	}
+}
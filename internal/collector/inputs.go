@@ -0,0 +1,167 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"MarketSentinel/internal/model"
+)
+
+// Input names one fetch of the collector's inputs.
+type Input string
+
+const (
+	InputDaily  Input = "daily fetch"
+	InputWeekly Input = "weekly fetch"
+	InputPrice  Input = "quote"
+	InputAux    Input = "aux fetch"
+	InputYield  Input = "yield fetch"
+	InputFX     Input = "fx quote"
+)
+
+// InputFetch records how the fetch of one input went.
+type InputFetch struct {
+	Input    Input
+	Duration time.Duration
+	TimedOut bool // ctx was done before the fetch returned
+	Cached   bool // the input came from the cache instead
+}
+
+// FetchInputs fetches every input concurrently and waits for them until ctx
+// is done. An input still being fetched then is taken from the cache, or
+// fails naming the input when the cache has none. The first fetch error is
+// returned at once without waiting for the others, which still complete and
+// refresh the cache. The fetches are reported in a fixed order, up to the
+// one that failed. With WeeklyFromDaily the weekly bars are derived from the
+// daily ones instead of fetched.
+//
+// The auxiliary symbol, yield and FX rate are fetched only when set and
+// never fail the call: a timeout without cache or an error leaves them empty.
+func (c *Collector) FetchInputs(ctx context.Context) (Inputs, []InputFetch, error) {
+	g := newFetchGroup(ctx)
+	defer g.fail(nil)
+	cached := c.Cached()
+	daily := startFetch(g, InputDaily, c.FetchDaily, true)
+	var weekly *pendingFetch[[]model.OHLCV]
+	if !c.WeeklyFromDaily {
+		weekly = startFetch(g, InputWeekly, c.FetchWeekly, true)
+	}
+	price := startFetch(g, InputPrice, c.FetchPrice, true)
+	var aux *pendingFetch[[]model.OHLCV]
+	if c.AuxSymbol != "" {
+		aux = startFetch(g, InputAux, c.FetchAux, false)
+	}
+	var yield *pendingFetch[[]model.MacroPoint]
+	if c.Macro != nil {
+		yield = startFetch(g, InputYield, c.FetchYield, false)
+	}
+	var fx *pendingFetch[float64]
+	if c.FX != nil {
+		fx = startFetch(g, InputFX, c.FetchFX, false)
+	}
+
+	var in Inputs
+	var err error
+	if in.Daily, err = awaitFetch(g, daily, cached.Daily, len(cached.Daily) > 0); err != nil {
+		return in, g.fetches, err
+	}
+	if weekly == nil {
+		in.Weekly = c.DeriveWeekly(in.Daily, time.Now())
+	} else if in.Weekly, err = awaitFetch(g, weekly, cached.Weekly, len(cached.Weekly) > 0); err != nil {
+		return in, g.fetches, err
+	}
+	if in.Price, err = awaitFetch(g, price, cached.Price, cached.Price > 0); err != nil {
+		return in, g.fetches, err
+	}
+	if aux != nil {
+		if in.Aux, err = awaitFetch(g, aux, cached.Aux, len(cached.Aux) > 0); err != nil {
+			log.Printf("[WARN] %v, continuing without it", err)
+		}
+	}
+	if yield != nil {
+		if in.Yield, err = awaitFetch(g, yield, cached.Yield, len(cached.Yield) > 0); err != nil {
+			log.Printf("[WARN] %v, continuing without it", err)
+		}
+	}
+	if fx != nil {
+		if in.FXRate, err = awaitFetch(g, fx, cached.FXRate, cached.FXRate > 0); err != nil {
+			log.Printf("[WARN] %v, continuing without it", err)
+		}
+	}
+	return in, g.fetches, nil
+}
+
+// fetchGroup is the fetches of one FetchInputs call.
+type fetchGroup struct {
+	deadline context.Context         // the caller's context
+	ctx      context.Context         // also done on the first fetch error
+	fail     context.CancelCauseFunc // ends ctx with that error
+	fetches  []InputFetch
+}
+
+func newFetchGroup(ctx context.Context) *fetchGroup {
+	g := &fetchGroup{deadline: ctx}
+	g.ctx, g.fail = context.WithCancelCause(ctx)
+	return g
+}
+
+// pendingFetch is the fetch of one input running in the background.
+type pendingFetch[T any] struct {
+	input    Input
+	required bool
+	start    time.Time
+	done     chan fetchResult[T]
+}
+
+type fetchResult[T any] struct {
+	v    T
+	err  error
+	took time.Duration
+}
+
+// startFetch runs fn in the background. The error of a required input ends
+// g.ctx only once the result is ready, so awaitFetch reports an input's own
+// error as such.
+func startFetch[T any](g *fetchGroup, input Input, fn func() (T, error), required bool) *pendingFetch[T] {
+	p := &pendingFetch[T]{input: input, required: required, start: time.Now(), done: make(chan fetchResult[T], 1)}
+	go func() {
+		v, err := fn()
+		p.done <- fetchResult[T]{v, err, time.Since(p.start)}
+		if err != nil && required {
+			g.fail(err)
+		}
+	}()
+	return p
+}
+
+// awaitFetch waits for p and records how it went. When the deadline passes
+// first, cached is used if haveCache, and an optional input is left empty
+// otherwise; when another fetch failed first, its error is returned.
+func awaitFetch[T any](g *fetchGroup, p *pendingFetch[T], cached T, haveCache bool) (T, error) {
+	var res fetchResult[T]
+	select {
+	case res = <-p.done:
+	case <-g.ctx.Done():
+		select {
+		case res = <-p.done:
+		default:
+			var zero T
+			if g.deadline.Err() == nil {
+				return zero, context.Cause(g.ctx)
+			}
+			took := time.Since(p.start)
+			g.fetches = append(g.fetches, InputFetch{Input: p.input, Duration: took, TimedOut: true, Cached: haveCache})
+			if !haveCache && p.required {
+				return zero, fmt.Errorf("%s timed out after %s with no cached data", p.input, took.Round(time.Second))
+			}
+			if !haveCache {
+				return zero, nil
+			}
+			return cached, nil
+		}
+	}
+	g.fetches = append(g.fetches, InputFetch{Input: p.input, Duration: res.took})
+	return res.v, res.err
+}
@@ -0,0 +1,88 @@
+package collector
+
+import (
+	"errors"
+	"fmt"
+	"log"
+
+	"MarketSentinel/internal/calculator"
+	"MarketSentinel/internal/model"
+)
+
+// Intraday bars CollectDaily fetches: two US sessions of hourly bars.
+const (
+	intradayInterval = "60m"
+	intradayBars     = 16
+)
+
+// CollectDaily is Collect with the daily RSI brought up to date intraday:
+// today's hourly bars are merged into a partial daily bar, which replaces the
+// daily series' bar of the same date or is appended to it, and the daily RSI
+// is computed again. Sessions are matched by UTC date.
+//
+// It returns an error wrapping ErrNotSupported, before fetching anything else,
+// when the fetcher has no intraday bars; callers then fall back to Collect.
+// Any other intraday failure is logged and leaves the end-of-day RSI.
+func (c *Collector) CollectDaily() (*model.MarketIndicators, error) {
+	intraday, err := FetchIntradayBars(c.Fetcher, c.Symbol, intradayInterval, intradayBars)
+	if errors.Is(err, ErrNotSupported) {
+		return nil, fmt.Errorf("fetch intraday bars: %w", err)
+	}
+	ind, series, cerr := c.CollectSeries()
+	if cerr != nil {
+		return nil, cerr
+	}
+	if err != nil {
+		log.Printf("[WARN] fetch intraday bars: %v, using the end-of-day daily RSI", err)
+		return ind, nil
+	}
+	daily, ok := withSession(series.DailyBars, intraday)
+	if !ok {
+		return ind, nil
+	}
+	if rsi, err := calculator.CalculateRSI(daily, rsiPeriod); err == nil && len(daily) > rsiPeriod {
+		log.Printf("[INFO] daily RSI %.1f updated intraday to %.1f", ind.DailyRSI, rsi)
+		ind.DailyRSI = rsi
+	}
+	return ind, nil
+}
+
+// withSession returns daily with the last session of intraday merged into one
+// bar in place of daily's bar of that date, or appended after it. It reports
+// false when intraday is empty or older than daily's last bar.
+func withSession(daily, intraday []model.OHLCV) ([]model.OHLCV, bool) {
+	if len(intraday) == 0 {
+		return daily, false
+	}
+	day := sessionDate(intraday[len(intraday)-1])
+	first := len(intraday) - 1
+	for first > 0 && sessionDate(intraday[first-1]) == day {
+		first--
+	}
+	bar := intraday[first]
+	for _, b := range intraday[first+1:] {
+		bar.High = max(bar.High, b.High)
+		bar.Low = min(bar.Low, b.Low)
+		bar.Close = b.Close
+		bar.Volume += b.Volume
+	}
+
+	n := len(daily)
+	switch {
+	case n == 0:
+		return []model.OHLCV{bar}, true
+	case sessionDate(daily[n-1]) == day:
+		out := append([]model.OHLCV(nil), daily[:n-1]...)
+		return append(out, bar), true
+	case daily[n-1].Time.Before(bar.Time):
+		out := append([]model.OHLCV(nil), daily...)
+		return append(out, bar), true
+	default:
+		return daily, false
+	}
+}
+
+// sessionDate is the UTC date of b, as 2006-01-02.
+func sessionDate(b model.OHLCV) string {
+	return b.Time.UTC().Format("2006-01-02")
+}
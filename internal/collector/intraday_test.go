@@ -0,0 +1,70 @@
+package collector
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"MarketSentinel/internal/calculator"
+	"MarketSentinel/internal/model"
+)
+
+// hourlyBars returns n hourly bars from 14:30 UTC on day, closing at closes.
+func hourlyBars(day time.Time, closes ...float64) []model.OHLCV {
+	bars := make([]model.OHLCV, len(closes))
+	open := time.Date(day.Year(), day.Month(), day.Day(), 14, 30, 0, 0, time.UTC)
+	for i, c := range closes {
+		bars[i] = model.OHLCV{Time: open.Add(time.Duration(i) * time.Hour), Open: c, High: c + 1, Low: c - 1, Close: c, Volume: 10}
+	}
+	return bars
+}
+
+func TestWithSession(t *testing.T) {
+	day := time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC)
+	daily := []model.OHLCV{
+		{Time: day.AddDate(0, 0, -1).Add(14*time.Hour + 30*time.Minute), Close: 100},
+		{Time: day.Add(14*time.Hour + 30*time.Minute), Close: 101},
+	}
+	intraday := append(hourlyBars(day.AddDate(0, 0, -1), 90, 91), hourlyBars(day, 102, 105, 103)...)
+
+	got, ok := withSession(daily, intraday)
+	if !ok || len(got) != 2 {
+		t.Fatalf("same-date session: ok=%v, %d bars, want the last bar replaced", ok, len(got))
+	}
+	if b := got[1]; b.Open != 102 || b.High != 106 || b.Low != 101 || b.Close != 103 || b.Volume != 30 {
+		t.Errorf("merged session bar = %+v", b)
+	}
+	if daily[1].Close != 101 {
+		t.Error("withSession modified the daily series")
+	}
+
+	got, ok = withSession(daily[:1], intraday)
+	if !ok || len(got) != 2 || got[1].Close != 103 {
+		t.Errorf("new session: ok=%v, %d bars, want it appended", ok, len(got))
+	}
+
+	if _, ok := withSession(daily, hourlyBars(day.AddDate(0, 0, -2), 80)); ok {
+		t.Error("a session older than the daily series was merged")
+	}
+}
+
+func TestCollectDaily(t *testing.T) {
+	daily := generateMockBars(5000, 300)
+	m := &MockFetcher{Price: 5000, DailyData: daily, IntradayData: hourlyBars(time.Now(), 4900, 4700, 4500)}
+	c := NewCollector(m, "SPX500")
+
+	ind, err := c.CollectDaily()
+	if err != nil {
+		t.Fatal(err)
+	}
+	merged, _ := withSession(daily, m.IntradayData)
+	want, _ := calculator.CalculateRSI(merged, rsiPeriod)
+	if len(merged) != 301 || ind.DailyRSI != want || want >= 30 {
+		t.Errorf("daily RSI = %.1f, want %.1f from today's selloff appended", ind.DailyRSI, want)
+	}
+
+	m.IntradayData = nil
+	if _, err := c.CollectDaily(); !errors.Is(err, ErrNotSupported) {
+		t.Errorf("without intraday bars: err = %v, want ErrNotSupported", err)
+	}
+}
@@ -0,0 +1,74 @@
+package collector
+
+import (
+	"math"
+	"testing"
+
+	"MarketSentinel/internal/model"
+	"MarketSentinel/internal/strategy"
+)
+
+// factorWeights returns the weight of each factor and the reason each
+// skipped one was skipped, by factor ID.
+func factorWeights(sig *model.TradeSignal) (weights map[string]float64, skipped map[string]string) {
+	weights, skipped = map[string]float64{}, map[string]string{}
+	for _, f := range sig.Factors {
+		weights[f.ID] = f.Weight
+		if f.Missing != "" {
+			skipped[f.ID] = f.Missing
+		}
+	}
+	return weights, skipped
+}
+
+func TestMissing_NoMA200(t *testing.T) {
+	c := NewCollector(&MockFetcher{Price: 5000}, "SPX500")
+	ind := c.Compute(Inputs{Daily: generateMockBars(5000, 150), Weekly: generateMockBars(5000, 60), Price: 5000})
+	if ind.MissingReason(model.IndicatorMA200) == "" {
+		t.Fatal("MA200 from 150 daily bars should be missing")
+	}
+	if ind.DegradedReason(model.IndicatorMA200) == "" {
+		t.Error("a missing MA200 should also be degraded")
+	}
+
+	sig := strategy.Evaluate(ind)
+	weights, skipped := factorWeights(sig)
+	if _, ok := skipped[model.FactorMA200]; !ok || len(skipped) != 1 {
+		t.Fatalf("skipped %v, want only the MA200 factor", skipped)
+	}
+	// The skipped factor has no weight; the other five, 0.75 of it, are
+	// scaled up to 1.
+	if weights[model.FactorMA200] != 0 {
+		t.Errorf("skipped MA200 factor weighs %v, want 0", weights[model.FactorMA200])
+	}
+	sum := 0.0
+	for _, w := range weights {
+		sum += w
+	}
+	if math.Abs(sum-1) > 1e-9 || math.Abs(weights[model.FactorWeeklyRSI]-0.25/0.75) > 1e-9 {
+		t.Errorf("weights %v, want the remaining five scaled to sum to 1", weights)
+	}
+}
+
+func TestMissing_NoWeeklyBars(t *testing.T) {
+	c := NewCollector(&MockFetcher{Price: 5000}, "SPX500")
+	ind := c.Compute(Inputs{Daily: generateMockBars(5000, 300), Price: 5000})
+	for _, key := range []string{model.IndicatorMA20w, model.IndicatorMA50w, model.IndicatorWeeklyRSI} {
+		if ind.MissingReason(key) == "" {
+			t.Errorf("%s should be missing without weekly bars", key)
+		}
+	}
+
+	sig := strategy.Evaluate(ind)
+	weights, skipped := factorWeights(sig)
+	if len(skipped) != 2 || skipped[model.FactorWeeklyRSI] == "" || skipped[model.FactorTrend] == "" {
+		t.Fatalf("skipped %v, want the weekly RSI and trend factors", skipped)
+	}
+	total := 0.0
+	for _, f := range sig.Factors {
+		total += f.Weighted
+	}
+	if math.Abs(total-sig.TotalScore) > 1e-9 || math.Abs(weights[model.FactorMA200]-0.35/0.70) > 1e-9 {
+		t.Errorf("weights %v, total %v; want MA200, daily RSI, position and drawdown scaled from 0.70 to 1", weights, sig.TotalScore)
+	}
+}
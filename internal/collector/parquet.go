@@ -0,0 +1,299 @@
+package collector
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"MarketSentinel/internal/calculator"
+	"MarketSentinel/internal/model"
+
+	"github.com/parquet-go/parquet-go"
+	"github.com/parquet-go/parquet-go/format"
+)
+
+// ParquetColumns maps OHLCV fields to column names in the parquet schema.
+type ParquetColumns struct {
+	Date   string
+	Open   string
+	High   string
+	Low    string
+	Close  string
+	Volume string
+}
+
+// ParquetFetcher implements Fetcher over local parquet exports of daily bars.
+// Path is either a single file or a directory partitioned by symbol, laid out as
+// <dir>/<symbol>.parquet or <dir>/symbol=<symbol>/*.parquet.
+type ParquetFetcher struct {
+	Path    string
+	Columns ParquetColumns
+
+	mu    sync.Mutex
+	cache map[string][]model.OHLCV // symbol -> parsed daily bars
+}
+
+// NewParquetFetcher creates a fetcher; empty column names fall back to
+// date/open/high/low/close/volume.
+func NewParquetFetcher(path string, cols ParquetColumns) *ParquetFetcher {
+	defaults := ParquetColumns{"date", "open", "high", "low", "close", "volume"}
+	if cols.Date == "" {
+		cols.Date = defaults.Date
+	}
+	if cols.Open == "" {
+		cols.Open = defaults.Open
+	}
+	if cols.High == "" {
+		cols.High = defaults.High
+	}
+	if cols.Low == "" {
+		cols.Low = defaults.Low
+	}
+	if cols.Close == "" {
+		cols.Close = defaults.Close
+	}
+	if cols.Volume == "" {
+		cols.Volume = defaults.Volume
+	}
+	return &ParquetFetcher{Path: path, Columns: cols, cache: make(map[string][]model.OHLCV)}
+}
+
+func (f *ParquetFetcher) Name() string { return "parquet" }
+
+func (f *ParquetFetcher) FetchDailyBars(symbol string, days int) ([]model.OHLCV, error) {
+	bars, err := f.load(symbol)
+	if err != nil {
+		return nil, err
+	}
+	if len(bars) > days {
+		bars = bars[len(bars)-days:]
+	}
+	return bars, nil
+}
+
+func (f *ParquetFetcher) FetchWeeklyBars(symbol string, weeks int) ([]model.OHLCV, error) {
+	daily, err := f.load(symbol)
+	if err != nil {
+		return nil, err
+	}
+	bars := calculator.AggregateWeekly(daily)
+	if len(bars) > weeks {
+		bars = bars[len(bars)-weeks:]
+	}
+	return bars, nil
+}
+
+func (f *ParquetFetcher) FetchCurrentPrice(symbol string) (float64, error) {
+	bars, err := f.load(symbol)
+	if err != nil {
+		return 0, err
+	}
+	if len(bars) == 0 {
+		return 0, fmt.Errorf("parquet: no bars for %s", symbol)
+	}
+	return bars[len(bars)-1].Close, nil
+}
+
+// load returns the memoized bars for symbol, parsing its files on first use.
+// Callers must treat the returned slice as read-only.
+func (f *ParquetFetcher) load(symbol string) ([]model.OHLCV, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if bars, ok := f.cache[symbol]; ok {
+		return bars, nil
+	}
+
+	files, err := f.filesFor(symbol)
+	if err != nil {
+		return nil, err
+	}
+	var bars []model.OHLCV
+	for _, path := range files {
+		fileBars, dropped, err := f.readFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("parquet %s: %w", path, err)
+		}
+		if dropped > 0 {
+			log.Printf("[WARN] parquet %s: dropped %d rows with a null open, high, low or close", path, dropped)
+		}
+		bars = append(bars, fileBars...)
+	}
+	sort.Slice(bars, func(i, j int) bool { return bars[i].Time.Before(bars[j].Time) })
+
+	f.cache[symbol] = bars
+	return bars, nil
+}
+
+// filesFor resolves the parquet files holding symbol's bars.
+func (f *ParquetFetcher) filesFor(symbol string) ([]string, error) {
+	info, err := os.Stat(f.Path)
+	if err != nil {
+		return nil, fmt.Errorf("parquet: %w", err)
+	}
+	if !info.IsDir() {
+		return []string{f.Path}, nil
+	}
+
+	single := filepath.Join(f.Path, symbol+".parquet")
+	if _, err := os.Stat(single); err == nil {
+		return []string{single}, nil
+	}
+	files, err := filepath.Glob(filepath.Join(f.Path, "symbol="+symbol, "*.parquet"))
+	if err != nil {
+		return nil, fmt.Errorf("parquet: %w", err)
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("parquet: no files for symbol %s under %s", symbol, f.Path)
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// readFile parses the bars of the parquet file at path. Rows with a null
+// open, high, low or close are skipped rather than read as a zero price, and
+// counted in dropped; a null volume reads as zero.
+func (f *ParquetFetcher) readFile(path string) (bars []model.OHLCV, dropped int, err error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return nil, 0, err
+	}
+	pf, err := parquet.OpenFile(file, info.Size())
+	if err != nil {
+		return nil, 0, err
+	}
+
+	schema := pf.Schema()
+	lookup := func(name string) (parquet.LeafColumn, error) {
+		col, ok := schema.Lookup(name)
+		if !ok {
+			return col, fmt.Errorf("column %q not found", name)
+		}
+		return col, nil
+	}
+	dateCol, err := lookup(f.Columns.Date)
+	if err != nil {
+		return nil, 0, err
+	}
+	var numCols [5]parquet.LeafColumn
+	for i, name := range []string{f.Columns.Open, f.Columns.High, f.Columns.Low, f.Columns.Close, f.Columns.Volume} {
+		if numCols[i], err = lookup(name); err != nil {
+			return nil, 0, err
+		}
+	}
+
+	bars = make([]model.OHLCV, 0, pf.NumRows())
+	rowBuf := make([]parquet.Row, 256)
+	for _, rg := range pf.RowGroups() {
+		rows := rg.Rows()
+		for {
+			n, readErr := rows.ReadRows(rowBuf)
+			for _, row := range rowBuf[:n] {
+				t, err := parquetTime(rowValue(row, dateCol.ColumnIndex), dateCol.Node)
+				if err != nil {
+					rows.Close()
+					return nil, 0, err
+				}
+				var ohlc [4]parquet.Value
+				for i := range ohlc {
+					ohlc[i] = rowValue(row, numCols[i].ColumnIndex)
+				}
+				if ohlc[0].IsNull() || ohlc[1].IsNull() || ohlc[2].IsNull() || ohlc[3].IsNull() {
+					dropped++
+					continue
+				}
+				bars = append(bars, model.OHLCV{
+					Time:   t,
+					Open:   parquetFloat(ohlc[0]),
+					High:   parquetFloat(ohlc[1]),
+					Low:    parquetFloat(ohlc[2]),
+					Close:  parquetFloat(ohlc[3]),
+					Volume: parquetFloat(rowValue(row, numCols[4].ColumnIndex)),
+				})
+			}
+			if readErr == io.EOF {
+				break
+			}
+			if readErr != nil {
+				rows.Close()
+				return nil, 0, readErr
+			}
+		}
+		rows.Close()
+	}
+	return bars, dropped, nil
+}
+
+// rowValue returns the value of a flat (non-repeated) column, or a null value.
+func rowValue(row parquet.Row, column int) parquet.Value {
+	if column < len(row) && row[column].Column() == column {
+		return row[column]
+	}
+	for _, v := range row {
+		if v.Column() == column {
+			return v
+		}
+	}
+	return parquet.Value{}
+}
+
+// parquetTime decodes DATE, TIMESTAMP, plain int64 (unix seconds) and string date columns.
+func parquetTime(v parquet.Value, node parquet.Node) (time.Time, error) {
+	if v.IsNull() {
+		return time.Time{}, fmt.Errorf("null date value")
+	}
+	if lt := node.Type().LogicalType(); lt != nil {
+		switch t := lt.Value.(type) {
+		case *format.DateType:
+			return time.Unix(int64(v.Int32())*86400, 0).UTC(), nil
+		case *format.TimestampType:
+			if t.Unit.Value != nil {
+				return time.Unix(0, v.Int64()*int64(t.Unit.Value.Duration())).UTC(), nil
+			}
+		}
+	}
+	switch v.Kind() {
+	case parquet.Int32:
+		return time.Unix(int64(v.Int32())*86400, 0).UTC(), nil
+	case parquet.Int64:
+		return time.Unix(v.Int64(), 0).UTC(), nil
+	case parquet.ByteArray:
+		s := strings.TrimSpace(string(v.ByteArray()))
+		for _, layout := range []string{"2006-01-02", time.RFC3339, "2006-01-02 15:04:05"} {
+			if t, err := time.Parse(layout, s); err == nil {
+				return t, nil
+			}
+		}
+		return time.Time{}, fmt.Errorf("unparseable date %q", s)
+	}
+	return time.Time{}, fmt.Errorf("unsupported date column kind %s", v.Kind())
+}
+
+func parquetFloat(v parquet.Value) float64 {
+	if v.IsNull() {
+		return 0
+	}
+	switch v.Kind() {
+	case parquet.Int32:
+		return float64(v.Int32())
+	case parquet.Int64:
+		return float64(v.Int64())
+	case parquet.Float:
+		return float64(v.Float())
+	case parquet.Double:
+		return v.Double()
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,96 @@
+package collector
+
+import (
+	"testing"
+	"time"
+)
+
+// testdata/SPX500.parquet holds 20 weekday bars starting Mon 2024-01-01 with
+// closes 101..120, written out of order under non-default column names.
+var fixtureColumns = ParquetColumns{Date: "trade_date", Open: "o", High: "h", Low: "l", Close: "c", Volume: "v"}
+
+func TestParquetFetcher_DailyBars(t *testing.T) {
+	f := NewParquetFetcher("testdata", fixtureColumns)
+
+	bars, err := f.FetchDailyBars("SPX500", 300)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(bars) != 20 {
+		t.Fatalf("expected 20 bars, got %d", len(bars))
+	}
+	for i := 1; i < len(bars); i++ {
+		if !bars[i].Time.After(bars[i-1].Time) {
+			t.Fatalf("bars not sorted at %d: %v <= %v", i, bars[i].Time, bars[i-1].Time)
+		}
+	}
+	if want := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC); !bars[0].Time.Equal(want) {
+		t.Errorf("first bar time = %v, want %v", bars[0].Time, want)
+	}
+	if bars[0].Close != 101 || bars[0].Volume != 1000 {
+		t.Errorf("unexpected first bar: %+v", bars[0])
+	}
+
+	tail, _ := f.FetchDailyBars("SPX500", 5)
+	if len(tail) != 5 || tail[4].Close != 120 {
+		t.Errorf("expected last 5 bars ending at 120, got %+v", tail)
+	}
+}
+
+func TestParquetFetcher_WeeklyAndPrice(t *testing.T) {
+	f := NewParquetFetcher("testdata/SPX500.parquet", fixtureColumns)
+
+	weekly, err := f.FetchWeeklyBars("SPX500", 60)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(weekly) != 4 {
+		t.Fatalf("expected 4 weekly bars, got %d", len(weekly))
+	}
+	if weekly[0].Open != 100 || weekly[0].Close != 105 || weekly[0].Volume != 5000 {
+		t.Errorf("unexpected first week: %+v", weekly[0])
+	}
+
+	price, err := f.FetchCurrentPrice("SPX500")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if price != 120 {
+		t.Errorf("expected last close 120, got %.2f", price)
+	}
+}
+
+func TestParquetFetcher_Errors(t *testing.T) {
+	if _, err := NewParquetFetcher("testdata", fixtureColumns).FetchDailyBars("NDX", 10); err == nil {
+		t.Error("expected error for missing symbol partition")
+	}
+	if _, err := NewParquetFetcher("testdata", ParquetColumns{}).FetchDailyBars("SPX500", 10); err == nil {
+		t.Error("expected error for unmapped columns")
+	}
+}
+
+// testdata/nulls/SPX500.parquet holds 5 weekday bars from Mon 2024-01-01
+// with closes 101..105 in nullable columns: the third close is null, the
+// fourth volume too.
+func TestParquetFetcher_NullPrices(t *testing.T) {
+	f := NewParquetFetcher("testdata/nulls", fixtureColumns)
+	bars, dropped, err := f.readFile("testdata/nulls/SPX500.parquet")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(bars) != 4 || dropped != 1 {
+		t.Fatalf("got %d bars and %d dropped, want 4 and 1", len(bars), dropped)
+	}
+	for _, b := range bars {
+		if b.Close == 103 || b.Close == 0 {
+			t.Errorf("bar with a null close kept: %+v", b)
+		}
+	}
+	if bars[2].Close != 104 || bars[2].Volume != 0 {
+		t.Errorf("bar with a null volume = %+v, want close 104 and volume 0", bars[2])
+	}
+
+	if daily, err := f.FetchDailyBars("SPX500", 10); err != nil || len(daily) != 4 {
+		t.Errorf("daily bars: %d, err %v", len(daily), err)
+	}
+}
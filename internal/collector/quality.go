@@ -0,0 +1,73 @@
+package collector
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"MarketSentinel/internal/model"
+)
+
+// QualityPolicy bounds how old and how patchy the daily series may be before
+// the indicators computed from it are flagged.
+type QualityPolicy struct {
+	// MaxStaleness is the largest accepted age of the newest daily bar; 0
+	// disables the check.
+	MaxStaleness time.Duration
+	// MaxGapSessions is the most sessions that may be missing between two
+	// consecutive daily bars; 0 disables the check.
+	MaxGapSessions int
+	// Reject makes Collect fail instead of attaching a warning.
+	Reject bool
+}
+
+// DefaultQualityPolicy tolerates a long weekend plus a holiday and a short
+// exchange closure, and only warns.
+var DefaultQualityPolicy = QualityPolicy{MaxStaleness: 5 * 24 * time.Hour, MaxGapSessions: 3}
+
+// DataQualityError is returned by Collect when the policy rejects the data.
+type DataQualityError struct {
+	Problems []string
+}
+
+func (e *DataQualityError) Error() string {
+	return "data quality: " + strings.Join(e.Problems, "; ")
+}
+
+// checkQuality lists what is wrong with daily, oldest first, as of now.
+func checkQuality(daily []model.OHLCV, p QualityPolicy, cal Calendar, now time.Time) []string {
+	var problems []string
+	if n := len(daily); n > 0 && p.MaxStaleness > 0 {
+		last := daily[n-1].Time
+		if age := now.Sub(last); age > p.MaxStaleness {
+			problems = append(problems, fmt.Sprintf("last daily bar %s is %d days old",
+				last.Format("2006-01-02"), int(age/(24*time.Hour))))
+		}
+	}
+	if p.MaxGapSessions > 0 {
+		for i := 1; i < len(daily); i++ {
+			prev, cur := daily[i-1].Time, daily[i].Time
+			if missing := missingSessions(prev, cur, cal); missing > p.MaxGapSessions {
+				problems = append(problems, fmt.Sprintf("%d sessions missing between %s and %s",
+					missing, prev.Format("2006-01-02"), cur.Format("2006-01-02")))
+			}
+		}
+	}
+	return problems
+}
+
+// missingSessions counts the days strictly between a and b on which cal's
+// market would trade: every day for a seven-day calendar, weekdays otherwise.
+// Holidays count as missing, which MaxGapSessions has to allow for.
+func missingSessions(a, b time.Time, cal Calendar) int {
+	everyDay := cal.SessionsPerYear > EquityCalendar.SessionsPerYear
+	start := time.Date(a.Year(), a.Month(), a.Day(), 0, 0, 0, 0, time.UTC)
+	end := time.Date(b.Year(), b.Month(), b.Day(), 0, 0, 0, 0, time.UTC)
+	n := 0
+	for d := start.AddDate(0, 0, 1); d.Before(end); d = d.AddDate(0, 0, 1) {
+		if everyDay || (d.Weekday() != time.Saturday && d.Weekday() != time.Sunday) {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,100 @@
+package collector
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"MarketSentinel/internal/model"
+)
+
+// weekdayBars returns n weekday bars ending on end, closing at 100.
+func weekdayBars(end time.Time, n int) []model.OHLCV {
+	bars := make([]model.OHLCV, 0, n)
+	for d := end; len(bars) < n; d = d.AddDate(0, 0, -1) {
+		if d.Weekday() == time.Saturday || d.Weekday() == time.Sunday {
+			continue
+		}
+		bars = append([]model.OHLCV{{Time: d, Open: 100, High: 101, Low: 99, Close: 100}}, bars...)
+	}
+	return bars
+}
+
+// lastWeekday is the most recent weekday before now.
+func lastWeekday() time.Time {
+	d := time.Now().AddDate(0, 0, -1)
+	for d.Weekday() == time.Saturday || d.Weekday() == time.Sunday {
+		d = d.AddDate(0, 0, -1)
+	}
+	return d
+}
+
+func TestCollect_GapInWindow(t *testing.T) {
+	daily := weekdayBars(lastWeekday(), 300)
+	// Cut a 10-day hole (8 sessions) out of the middle of the series.
+	holeStart := daily[150].Time
+	var holed []model.OHLCV
+	for _, b := range daily {
+		if b.Time.After(holeStart) && b.Time.Before(holeStart.AddDate(0, 0, 11)) {
+			continue
+		}
+		holed = append(holed, b)
+	}
+	c := NewCollector(&MockFetcher{Price: 100, DailyData: holed}, "SPX500")
+
+	ind, err := c.Collect()
+	if err != nil {
+		t.Fatalf("warn-only policy should not fail: %v", err)
+	}
+	if !strings.Contains(ind.DataQualityWarning, "sessions missing between "+holeStart.Format("2006-01-02")) {
+		t.Errorf("expected a gap warning after %s, got %q", holeStart.Format("2006-01-02"), ind.DataQualityWarning)
+	}
+
+	c.Quality.Reject = true
+	var dq *DataQualityError
+	if _, err := c.Collect(); !errors.As(err, &dq) || len(dq.Problems) != 1 {
+		t.Errorf("expected one rejected problem, got %v", err)
+	}
+
+	// The intact series passes.
+	c.Fetcher = &MockFetcher{Price: 100, DailyData: daily}
+	if ind, err := c.Collect(); err != nil || ind.DataQualityWarning != "" {
+		t.Errorf("clean series flagged: %v %q", err, ind.DataQualityWarning)
+	}
+}
+
+func TestCollect_StaleSeries(t *testing.T) {
+	end := time.Now().AddDate(0, 0, -14)
+	c := NewCollector(&MockFetcher{Price: 100, DailyData: weekdayBars(end, 300)}, "SPX500")
+
+	ind, err := c.Collect()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(ind.DataQualityWarning, "is 14 days old") {
+		t.Errorf("expected a staleness warning, got %q", ind.DataQualityWarning)
+	}
+	if ind.DegradedReason(model.IndicatorDailyRSI) == "" {
+		t.Error("stale daily data should degrade the daily RSI")
+	}
+
+	c.Quality.Reject = true
+	if _, err := c.Collect(); err == nil || !strings.Contains(err.Error(), "data quality: last daily bar "+end.Format("2006-01-02")) {
+		t.Errorf("expected the stale series to be rejected, got %v", err)
+	}
+}
+
+func TestMissingSessions(t *testing.T) {
+	fri := time.Date(2024, 1, 5, 21, 0, 0, 0, time.UTC)
+	mon := time.Date(2024, 1, 8, 21, 0, 0, 0, time.UTC)
+	if n := missingSessions(fri, mon, EquityCalendar); n != 0 {
+		t.Errorf("weekend counted as %d missing sessions", n)
+	}
+	if n := missingSessions(fri, mon, CryptoCalendar); n != 2 {
+		t.Errorf("crypto weekend = %d missing sessions, want 2", n)
+	}
+	if n := missingSessions(fri, mon.AddDate(0, 0, 7), EquityCalendar); n != 5 {
+		t.Errorf("skipped week = %d missing sessions, want 5", n)
+	}
+}
@@ -0,0 +1,82 @@
+package collector
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"math/rand"
+	"net/http"
+	"time"
+)
+
+// RetryPolicy bounds how an HTTP request is retried. Transport errors, 5xx
+// and 429 responses are retried; any other status is returned at once.
+type RetryPolicy struct {
+	MaxAttempts int           // total attempts including the first; below 1 means 1
+	BaseDelay   time.Duration // wait before the second attempt, doubled after each retry
+}
+
+// DefaultRetryPolicy makes three attempts, waiting about 2s and then 4s.
+var DefaultRetryPolicy = RetryPolicy{MaxAttempts: 3, BaseDelay: 2 * time.Second}
+
+// retryableStatus reports whether a response status may succeed on retry.
+func retryableStatus(code int) bool {
+	return code == http.StatusTooManyRequests || code >= 500
+}
+
+// backoff returns the wait before attempt n (n >= 2): BaseDelay doubled per
+// earlier retry, jittered to between half and one and a half times that.
+func (p RetryPolicy) backoff(n int) time.Duration {
+	d := p.BaseDelay << (n - 2)
+	if d <= 0 {
+		return 0
+	}
+	return d/2 + time.Duration(rand.Int63n(int64(d)))
+}
+
+// do sends the request built by newReq until it gets a response that is not
+// worth retrying or attempts run out. It returns the status and body of the
+// last response, which callers check themselves; err is set only when the
+// last attempt failed in transport. source prefixes log lines and errors.
+func (p RetryPolicy) do(client *http.Client, source string, newReq func() (*http.Request, error)) (status int, body []byte, err error) {
+	attempts := max(p.MaxAttempts, 1)
+	for n := 1; ; n++ {
+		var req *http.Request
+		if req, err = newReq(); err != nil {
+			return 0, nil, err
+		}
+		status, body, err = send(client, req)
+		var reason string
+		switch {
+		case err != nil:
+			reason = fmt.Sprintf("%s: %v", source, err)
+		case retryableStatus(status):
+			reason = fmt.Sprintf("%s: status %d", source, status)
+		default:
+			return status, body, nil
+		}
+		if n >= attempts {
+			if err != nil {
+				return 0, nil, fmt.Errorf("%s after %d attempts", reason, n)
+			}
+			return status, body, nil
+		}
+		wait := p.backoff(n + 1)
+		log.Printf("[INFO] %s, retrying in %v (attempt %d/%d)", reason, wait.Round(time.Millisecond), n+1, attempts)
+		time.Sleep(wait)
+	}
+}
+
+// send performs one request and reads the whole body.
+func send(client *http.Client, req *http.Request) (int, []byte, error) {
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return 0, nil, fmt.Errorf("read body: %w", err)
+	}
+	return resp.StatusCode, body, nil
+}
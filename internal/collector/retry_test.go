@@ -0,0 +1,177 @@
+package collector
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fastRetry keeps retry tests quick.
+var fastRetry = RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond}
+
+// scriptedServer answers with statuses in order, then 200 with body.
+func scriptedServer(t *testing.T, body string, statuses ...int) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := int(hits.Add(1))
+		if n <= len(statuses) {
+			w.WriteHeader(statuses[n-1])
+			fmt.Fprintf(w, "attempt %d failed", n)
+			return
+		}
+		fmt.Fprint(w, body)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &hits
+}
+
+func TestRetry_FailsTwiceThenSucceeds(t *testing.T) {
+	srv, hits := scriptedServer(t, `{"price": 5012.5}`, http.StatusServiceUnavailable, http.StatusTooManyRequests)
+	f := NewVsTraderFetcher(srv.URL, "key", nil)
+	f.Retry = fastRetry
+
+	price, err := f.FetchCurrentPrice("SPX500")
+	if err != nil {
+		t.Fatalf("FetchCurrentPrice: %v", err)
+	}
+	if price != 5012.5 || hits.Load() != 3 {
+		t.Errorf("price %v after %d attempts, want 5012.5 after 3", price, hits.Load())
+	}
+}
+
+func TestRetry_YahooRetriesServerErrors(t *testing.T) {
+	body := `{"chart":{"result":[{"timestamp":[1704205800],"indicators":{"quote":[{"open":[1],"high":[2],"low":[0.5],"close":[1.5],"volume":[10]}]}}],"error":null}}`
+	srv, hits := scriptedServer(t, body, http.StatusBadGateway, http.StatusInternalServerError)
+	f := NewYahooFetcher(nil, nil)
+	f.BaseURL = srv.URL
+	f.Retry = fastRetry
+
+	bars, err := f.FetchDailyBars("SPX500", 1)
+	if err != nil {
+		t.Fatalf("FetchDailyBars: %v", err)
+	}
+	if len(bars) != 1 || hits.Load() != 3 {
+		t.Errorf("got %d bars after %d attempts, want 1 after 3", len(bars), hits.Load())
+	}
+}
+
+func TestRetry_ClientErrorNotRetried(t *testing.T) {
+	srv, hits := scriptedServer(t, "[]", http.StatusNotFound)
+	f := NewVsTraderFetcher(srv.URL, "", nil)
+	f.Retry = fastRetry
+
+	_, err := f.FetchDailyBars("SPX500", 10)
+	if err == nil || !strings.Contains(err.Error(), "status 404") {
+		t.Errorf("expected 404 error, got %v", err)
+	}
+	if hits.Load() != 1 {
+		t.Errorf("404 was attempted %d times, want 1", hits.Load())
+	}
+}
+
+func TestRetry_GivesUp(t *testing.T) {
+	srv, hits := scriptedServer(t, "[]", 500, 500, 500)
+	f := NewVsTraderFetcher(srv.URL, "", nil)
+	f.Retry = RetryPolicy{MaxAttempts: 2, BaseDelay: time.Millisecond}
+
+	_, err := f.FetchDailyBars("SPX500", 10)
+	if err == nil || !strings.Contains(err.Error(), "status 500, body: attempt 2 failed") {
+		t.Errorf("expected last 500 response in error, got %v", err)
+	}
+	if hits.Load() != 2 {
+		t.Errorf("attempted %d times, want 2", hits.Load())
+	}
+
+	// Transport errors are retried too and report the attempt count.
+	srv.Close()
+	if _, err := f.FetchDailyBars("SPX500", 10); err == nil || !strings.Contains(err.Error(), "after 2 attempts") {
+		t.Errorf("expected transport error after 2 attempts, got %v", err)
+	}
+}
+
+func TestRetryPolicy_BackoffDoublesWithJitter(t *testing.T) {
+	p := RetryPolicy{MaxAttempts: 4, BaseDelay: 100 * time.Millisecond}
+	for n, base := range map[int]time.Duration{2: 100 * time.Millisecond, 3: 200 * time.Millisecond, 4: 400 * time.Millisecond} {
+		for i := 0; i < 20; i++ {
+			if d := p.backoff(n); d < base/2 || d >= base*3/2 {
+				t.Fatalf("backoff(%d) = %v, want within [%v, %v)", n, d, base/2, base*3/2)
+			}
+		}
+	}
+}
+
+// crumbServer serves a chart only to requests carrying the consent cookie and
+// its crumb, answering others with status limited.
+func crumbServer(t *testing.T, limited int, alwaysLimited bool) (*YahooFetcher, *atomic.Int32, *atomic.Int32) {
+	t.Helper()
+	body := `{"chart":{"result":[{"timestamp":[1704205800],"indicators":{"quote":[{"open":[1],"high":[2],"low":[0.5],"close":[1.5],"volume":[10]}]}}],"error":null}}`
+	var charts, crumbs atomic.Int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("/consent", func(w http.ResponseWriter, r *http.Request) {
+		http.SetCookie(w, &http.Cookie{Name: "A3", Value: "session"})
+		http.NotFound(w, r)
+	})
+	mux.HandleFunc("/v1/test/getcrumb", func(w http.ResponseWriter, r *http.Request) {
+		crumbs.Add(1)
+		if c, err := r.Cookie("A3"); err != nil || c.Value != "session" {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		fmt.Fprint(w, "cr/umb.1")
+	})
+	mux.HandleFunc("/v8/finance/chart/", func(w http.ResponseWriter, r *http.Request) {
+		charts.Add(1)
+		c, err := r.Cookie("A3")
+		if alwaysLimited || err != nil || c.Value != "session" || r.URL.Query().Get("crumb") != "cr/umb.1" {
+			w.WriteHeader(limited)
+			fmt.Fprint(w, "Too Many Requests")
+			return
+		}
+		fmt.Fprint(w, body)
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	f := NewYahooFetcher(nil, nil)
+	f.BaseURL, f.CookieURL = srv.URL, srv.URL+"/consent"
+	f.Retry = RetryPolicy{MaxAttempts: 1}
+	return f, &charts, &crumbs
+}
+
+func TestYahoo_RateLimitRefreshesCrumb(t *testing.T) {
+	f, charts, crumbs := crumbServer(t, http.StatusTooManyRequests, false)
+
+	bars, err := f.FetchDailyBars("SPX500", 1)
+	if err != nil {
+		t.Fatalf("FetchDailyBars: %v", err)
+	}
+	if len(bars) != 1 || charts.Load() != 2 || crumbs.Load() != 1 {
+		t.Errorf("got %d bars after %d chart and %d crumb requests, want 1 after 2 and 1", len(bars), charts.Load(), crumbs.Load())
+	}
+
+	// The crumb is cached: the next call succeeds at once.
+	if _, err := f.FetchDailyBars("SPX500", 1); err != nil {
+		t.Fatal(err)
+	}
+	if charts.Load() != 3 || crumbs.Load() != 1 {
+		t.Errorf("second call made %d chart and %d crumb requests in total, want 3 and 1", charts.Load(), crumbs.Load())
+	}
+}
+
+func TestYahoo_PersistentRateLimit(t *testing.T) {
+	f, charts, _ := crumbServer(t, 999, true)
+
+	_, err := f.FetchDailyBars("SPX500", 1)
+	if !errors.Is(err, ErrYahooRateLimited) || !strings.Contains(err.Error(), "status 999") {
+		t.Fatalf("got %v, want a yahoo rate-limit error naming status 999", err)
+	}
+	if charts.Load() != 2 {
+		t.Errorf("made %d chart requests, want the original and one retry", charts.Load())
+	}
+}
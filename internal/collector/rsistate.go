@@ -0,0 +1,51 @@
+package collector
+
+import (
+	"time"
+
+	"MarketSentinel/internal/calculator"
+	"MarketSentinel/internal/model"
+)
+
+// rsiTracker keeps a collector's RSI smoothing between runs, so each run
+// only feeds in the bars added since the last one instead of smoothing the
+// whole fetched series again.
+//
+// The state covers every bar but the newest, which may still be forming (a
+// session in progress, the current week); the newest is applied to a copy.
+// The state is carried forward when the series continues the bar it ends at,
+// and started again otherwise. Since it was started on an older window than
+// the one fetched now, its value can differ from CalculateRSI over that
+// window, by far less than the RSI's displayed precision once Wilder's
+// smoothing has run a few hundred bars.
+type rsiTracker struct {
+	state   calculator.RSIState
+	through time.Time // time of the last bar in state; zero before the first run
+	close   float64   // close of that bar
+}
+
+// rsi returns the rsiPeriod RSI at the end of bars, advancing the state.
+func (t *rsiTracker) rsi(bars []model.OHLCV) (float64, error) {
+	n := len(bars)
+	if n < rsiPeriod+2 {
+		return calculator.CalculateRSI(bars, rsiPeriod)
+	}
+	switch {
+	case t.endsAt(bars[n-2]):
+	case t.endsAt(bars[n-3]):
+		t.state.Update(bars[n-2].Close)
+	default:
+		if _, err := t.state.Init(bars[:n-1], rsiPeriod); err != nil {
+			return 0, err
+		}
+	}
+	t.through, t.close = bars[n-2].Time, bars[n-2].Close
+
+	next := t.state // a copy: the newest bar may change by the next run
+	return next.Update(bars[n-1].Close), nil
+}
+
+// endsAt reports whether the state ends at b.
+func (t *rsiTracker) endsAt(b model.OHLCV) bool {
+	return !t.through.IsZero() && t.through.Equal(b.Time) && t.close == b.Close
+}
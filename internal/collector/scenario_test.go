@@ -0,0 +1,54 @@
+package collector
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"MarketSentinel/internal/strategy"
+)
+
+func TestScenarios_EvaluateToExpectedTier(t *testing.T) {
+	for name, want := range map[string]string{
+		"2020-crash":    "极限重仓",
+		"blow-off-top":  "轻仓观望",
+		"sideways-chop": "正常定投",
+	} {
+		t.Run(name, func(t *testing.T) {
+			ind, err := NewCollector(&MockFetcher{Scenario: name}, "SPX500").Collect()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ind.DataQualityWarning != "" {
+				t.Errorf("replayed scenario flagged: %s", ind.DataQualityWarning)
+			}
+			sig := strategy.Evaluate(ind)
+			if sig.Tier.Label != want {
+				t.Errorf("tier = %s (score %+.3f), want %s", sig.Tier.Label, sig.TotalScore, want)
+			}
+		})
+	}
+}
+
+func TestLoadScenario(t *testing.T) {
+	if got := Scenarios(); len(got) < 3 {
+		t.Errorf("bundled scenarios = %q, want at least 3", got)
+	}
+
+	s, err := LoadScenario("2020-crash")
+	if err != nil {
+		t.Fatal(err)
+	}
+	last := s.Daily[len(s.Daily)-1].Time
+	if age := time.Since(last); age < 0 || age > 3*24*time.Hour || last.Weekday() == time.Saturday || last.Weekday() == time.Sunday {
+		t.Errorf("last daily bar re-dated to %s, want the latest weekday", last.Format("Mon 2006-01-02"))
+	}
+	if w := s.Weekly[len(s.Weekly)-1].Time; !weekStart(w).Equal(weekStart(last)) {
+		t.Errorf("last weekly bar %s is not in the week of the last daily bar %s", w.Format("2006-01-02"), last.Format("2006-01-02"))
+	}
+
+	_, err = (&MockFetcher{Scenario: "2008-crash"}).FetchCurrentPrice("SPX500")
+	if err == nil || !strings.Contains(err.Error(), "sideways-chop") {
+		t.Errorf("unknown scenario should list the bundled ones, got %v", err)
+	}
+}
@@ -0,0 +1,176 @@
+package collector
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"MarketSentinel/internal/calculator"
+	"MarketSentinel/internal/model"
+)
+
+// defaultStooqBaseURL is the public CSV download host.
+const defaultStooqBaseURL = "https://stooq.com"
+
+// StooqFetcher implements Fetcher using Stooq's daily CSV download, which needs
+// no API key. Weekly bars are aggregated from the daily history and the current
+// price is the last daily close.
+type StooqFetcher struct {
+	Client    *http.Client
+	BaseURL   string            // download host, overridable for tests
+	SymbolMap map[string]string // maps internal symbol to Stooq ticker
+}
+
+// NewStooqFetcher creates a new Stooq fetcher with optional proxy support.
+// symbolMap entries override the built-in ticker aliases.
+func NewStooqFetcher(proxy *url.URL, symbolMap map[string]string) *StooqFetcher {
+	transport := &http.Transport{}
+	if proxy != nil {
+		transport.Proxy = http.ProxyURL(proxy)
+	}
+	return &StooqFetcher{
+		Client: &http.Client{
+			Timeout:   30 * time.Second,
+			Transport: transport,
+		},
+		BaseURL: defaultStooqBaseURL,
+		SymbolMap: MergeSymbolMap(map[string]string{
+			"SPX500":    "^spx",
+			"SPX":       "^spx",
+			"SP500":     "^spx",
+			"NDX":       "^ndx",
+			"NASDAQ100": "^ndx",
+		}, symbolMap),
+	}
+}
+
+func (f *StooqFetcher) Name() string { return "stooq" }
+
+func (f *StooqFetcher) stooqSymbol(symbol string) string {
+	if mapped, ok := f.SymbolMap[symbol]; ok {
+		return mapped
+	}
+	return strings.ToLower(symbol)
+}
+
+func (f *StooqFetcher) FetchDailyBars(symbol string, days int) ([]model.OHLCV, error) {
+	bars, err := f.fetchDaily(symbol)
+	if err != nil {
+		return nil, err
+	}
+	if len(bars) > days {
+		bars = bars[len(bars)-days:]
+	}
+	return bars, nil
+}
+
+func (f *StooqFetcher) FetchWeeklyBars(symbol string, weeks int) ([]model.OHLCV, error) {
+	daily, err := f.fetchDaily(symbol)
+	if err != nil {
+		return nil, err
+	}
+	bars := calculator.AggregateWeekly(daily)
+	if len(bars) > weeks {
+		bars = bars[len(bars)-weeks:]
+	}
+	return bars, nil
+}
+
+func (f *StooqFetcher) FetchCurrentPrice(symbol string) (float64, error) {
+	bars, err := f.fetchDaily(symbol)
+	if err != nil {
+		return 0, err
+	}
+	return bars[len(bars)-1].Close, nil
+}
+
+// fetchDaily downloads the full daily history, oldest first. It never returns
+// an empty slice without an error.
+func (f *StooqFetcher) fetchDaily(symbol string) ([]model.OHLCV, error) {
+	ticker := f.stooqSymbol(symbol)
+	q := url.Values{"s": {ticker}, "i": {"d"}}
+	resp, err := f.Client.Get(f.BaseURL + "/q/d/l/?" + q.Encode())
+	if err != nil {
+		return nil, fmt.Errorf("stooq fetch: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("stooq read body: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("stooq: status %d, body: %s", resp.StatusCode, string(body))
+	}
+	bars, err := parseStooqCSV(body)
+	if err != nil {
+		return nil, fmt.Errorf("stooq %s: %w", ticker, err)
+	}
+	return bars, nil
+}
+
+// parseStooqCSV decodes "Date,Open,High,Low,Close[,Volume]" rows. Stooq answers
+// unknown tickers and exhausted quotas with a plain-text line instead of CSV,
+// which is reported as the error.
+func parseStooqCSV(body []byte) ([]model.OHLCV, error) {
+	body = bytes.TrimSpace(body)
+	if len(body) == 0 {
+		return nil, fmt.Errorf("empty response")
+	}
+	r := csv.NewReader(bytes.NewReader(body))
+	r.FieldsPerRecord = -1
+	records, err := r.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("decode csv: %w", err)
+	}
+
+	header := records[0]
+	if len(header) < 5 || header[0] != "Date" {
+		return nil, fmt.Errorf("unexpected response: %q", firstLine(body))
+	}
+	hasVolume := len(header) >= 6 && header[5] == "Volume"
+	if len(records) < 2 {
+		return nil, fmt.Errorf("no data rows")
+	}
+
+	bars := make([]model.OHLCV, 0, len(records)-1)
+	for i, rec := range records[1:] {
+		line := i + 2
+		if len(rec) < 5 {
+			return nil, fmt.Errorf("line %d: %d fields, want at least 5", line, len(rec))
+		}
+		t, err := time.Parse("2006-01-02", rec[0])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: date %q", line, rec[0])
+		}
+		var v [4]float64
+		for j := range v {
+			if v[j], err = strconv.ParseFloat(rec[j+1], 64); err != nil {
+				return nil, fmt.Errorf("line %d: field %s %q", line, header[j+1], rec[j+1])
+			}
+		}
+		bar := model.OHLCV{Time: t, Open: v[0], High: v[1], Low: v[2], Close: v[3]}
+		if hasVolume && len(rec) > 5 && rec[5] != "" {
+			if bar.Volume, err = strconv.ParseFloat(rec[5], 64); err != nil {
+				return nil, fmt.Errorf("line %d: volume %q", line, rec[5])
+			}
+		}
+		bars = append(bars, bar)
+	}
+	sort.Slice(bars, func(i, j int) bool { return bars[i].Time.Before(bars[j].Time) })
+	return bars, nil
+}
+
+func firstLine(b []byte) string {
+	if i := bytes.IndexByte(b, '\n'); i >= 0 {
+		b = b[:i]
+	}
+	return strings.TrimSpace(string(b))
+}
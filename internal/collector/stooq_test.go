@@ -0,0 +1,121 @@
+package collector
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testdata/stooq holds daily CSV downloads named <ticker>_<interval>.csv:
+//
+//	^spx_d  15 sessions from 2024-01-02 to 2024-01-22, close 4700 + 10/session
+//
+// Other tickers get the inline bodies in stooqBodies.
+var stooqBodies = map[string]string{
+	"nodata": "No data",
+	"empty":  "",
+	"header": "Date,Open,High,Low,Close,Volume\n",
+	"short":  "Date,Open,High,Low,Close,Volume\n2024-01-02,1,2\n",
+	"badnum": "Date,Open,High,Low,Close\n2024-01-02,1,2,0.5,n/a\n",
+	"novol":  "Date,Open,High,Low,Close\n2024-01-03,2,3,1,2.5\n2024-01-02,1,2,0.5,1.5\n",
+}
+
+func stooqFixtureServer(t *testing.T) *StooqFetcher {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if r.URL.Path != "/q/d/l/" || q.Get("i") != "d" {
+			http.NotFound(w, r)
+			return
+		}
+		if body, ok := stooqBodies[q.Get("s")]; ok {
+			w.Write([]byte(body))
+			return
+		}
+		data, err := os.ReadFile(filepath.Join("testdata", "stooq", q.Get("s")+"_d.csv"))
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write(data)
+	}))
+	t.Cleanup(srv.Close)
+
+	f := NewStooqFetcher(nil, nil)
+	f.BaseURL = srv.URL
+	return f
+}
+
+func TestStooqFetcher_FixtureReplay(t *testing.T) {
+	f := stooqFixtureServer(t)
+
+	daily, err := f.FetchDailyBars("SPX500", 10)
+	if err != nil {
+		t.Fatalf("FetchDailyBars: %v", err)
+	}
+	if len(daily) != 10 {
+		t.Fatalf("expected 10 daily bars, got %d", len(daily))
+	}
+	last := daily[len(daily)-1]
+	if !last.Time.Equal(time.Date(2024, 1, 22, 0, 0, 0, 0, time.UTC)) || last.Close != 4840 || last.Volume != 2000000014 {
+		t.Errorf("unexpected last bar: %+v", last)
+	}
+
+	weekly, err := f.FetchWeeklyBars("SPX500", 60)
+	if err != nil {
+		t.Fatalf("FetchWeeklyBars: %v", err)
+	}
+	if len(weekly) != 4 {
+		t.Fatalf("expected 4 weekly bars, got %d", len(weekly))
+	}
+	// Week of 2024-01-08: sessions 4..8, closes 4740..4780.
+	w := weekly[1]
+	if w.Open != 4735 || w.Close != 4780 || w.High != 4788 || w.Low != 4728 {
+		t.Errorf("unexpected second week: %+v", w)
+	}
+
+	price, err := f.FetchCurrentPrice("SPX500")
+	if err != nil {
+		t.Fatalf("FetchCurrentPrice: %v", err)
+	}
+	if price != 4840 {
+		t.Errorf("price = %v, want last close 4840", price)
+	}
+}
+
+func TestStooqFetcher_NoVolumeColumnSorted(t *testing.T) {
+	f := stooqFixtureServer(t)
+	bars, err := f.FetchDailyBars("novol", 10)
+	if err != nil {
+		t.Fatalf("FetchDailyBars: %v", err)
+	}
+	if len(bars) != 2 || bars[0].Close != 1.5 || bars[1].Close != 2.5 || bars[1].Volume != 0 {
+		t.Errorf("unexpected bars: %+v", bars)
+	}
+}
+
+func TestStooqFetcher_Errors(t *testing.T) {
+	f := stooqFixtureServer(t)
+	tests := []struct {
+		symbol, want string
+	}{
+		{"nodata", `unexpected response: "No data"`},
+		{"empty", "empty response"},
+		{"header", "no data rows"},
+		{"short", "line 2: 3 fields"},
+		{"badnum", `line 2: field Close "n/a"`},
+		{"missing", "status 404"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.symbol, func(t *testing.T) {
+			_, err := f.FetchCurrentPrice(tt.symbol)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,15 @@
+package collector
+
+// MergeSymbolMap returns a new map holding defaults overlaid with overrides,
+// for fetchers that translate internal symbols to provider tickers. Symbols in
+// neither map are left to the fetcher, which passes them through.
+func MergeSymbolMap(defaults, overrides map[string]string) map[string]string {
+	merged := make(map[string]string, len(defaults)+len(overrides))
+	for k, v := range defaults {
+		merged[k] = v
+	}
+	for k, v := range overrides {
+		merged[k] = v
+	}
+	return merged
+}
@@ -0,0 +1,89 @@
+package collector
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"strings"
+
+	"MarketSentinel/internal/model"
+)
+
+// DefaultVerifyTolerance is the relative difference between sources tolerated
+// when no tolerance is set.
+const DefaultVerifyTolerance = 0.005
+
+// NewVerifiedCollector creates a Collector whose results are cross-checked
+// against verifier: when the current price or latest daily close differs by
+// more than tolerance (a fraction, 0.005 = 0.5%), MarketIndicators.SourceWarning
+// is set. A nil verifier behaves like NewCollector.
+func NewVerifiedCollector(fetcher, verifier Fetcher, symbol string, tolerance float64) *Collector {
+	c := NewCollector(fetcher, symbol)
+	c.Verifier = verifier
+	c.Tolerance = tolerance
+	return c
+}
+
+// verifyDailyBars is how many recent daily bars are requested from the
+// verifier to find the primary's latest session.
+const verifyDailyBars = 5
+
+// Verify compares in with the verifier's current price and its close for the
+// session of in's latest daily bar, returning a description of every
+// difference above the tolerance. It returns "" without a verifier, when the
+// sources agree, or when the verifier is unavailable, which is only logged.
+func (c *Collector) Verify(in Inputs) string {
+	if c.Verifier == nil {
+		return ""
+	}
+	tol := c.Tolerance
+	if tol <= 0 {
+		tol = DefaultVerifyTolerance
+	}
+	name := c.Verifier.Name()
+
+	var problems []string
+	price, err := c.Verifier.FetchCurrentPrice(c.Symbol)
+	if err != nil {
+		log.Printf("[WARN] verification source %s unavailable: %v", name, err)
+		return ""
+	}
+	if d := relDiff(in.Price, price); d > tol {
+		problems = append(problems, fmt.Sprintf("price %.2f differs from %s %.2f by %.2f%%", in.Price, name, price, d*100))
+	}
+
+	if n := len(in.Daily); n > 0 {
+		last := in.Daily[n-1]
+		bars, err := c.Verifier.FetchDailyBars(c.Symbol, verifyDailyBars)
+		if err != nil {
+			log.Printf("[WARN] verification source %s daily bars unavailable: %v", name, err)
+		}
+		for _, b := range bars {
+			if barDate(b) != barDate(last) {
+				continue
+			}
+			if d := relDiff(last.Close, b.Close); d > tol {
+				problems = append(problems, fmt.Sprintf("close %s %.2f differs from %s %.2f by %.2f%%",
+					barDate(last), last.Close, name, b.Close, d*100))
+			}
+			break
+		}
+	}
+	return strings.Join(problems, "; ")
+}
+
+// relDiff is |a-b| relative to b; 0 when b is not positive.
+func relDiff(a, b float64) float64 {
+	if b <= 0 {
+		return 0
+	}
+	return math.Abs(a-b) / b
+}
+
+// verifyInto runs Verify and records a divergence on ind.
+func (c *Collector) verifyInto(ind *model.MarketIndicators, in Inputs) {
+	if w := c.Verify(in); w != "" {
+		ind.SourceWarning = w
+		log.Printf("[WARN] source divergence: %s", w)
+	}
+}
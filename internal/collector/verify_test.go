@@ -0,0 +1,48 @@
+package collector
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCollect_VerifiedAgainstSecondary(t *testing.T) {
+	daily := weekdayBars(lastWeekday(), 300)
+	shifted := weekdayBars(lastWeekday(), 5)
+	for i := range shifted {
+		shifted[i].Close = 102
+	}
+
+	tests := []struct {
+		name     string
+		verifier Fetcher
+		want     []string // substrings of the warning; none means no warning
+	}{
+		{"agreeing", &MockFetcher{Price: 5010, DailyData: weekdayBars(lastWeekday(), 5)}, nil},
+		{"diverging", &MockFetcher{Price: 5100, DailyData: shifted}, []string{
+			"price 5000.00 differs from mock 5100.00 by 1.96%",
+			"close " + barDate(daily[299]) + " 100.00 differs from mock 102.00 by 1.96%",
+		}},
+		{"unavailable", &failingQuoteFetcher{}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			primary := &MockFetcher{Price: 5000, DailyData: daily}
+			c := NewVerifiedCollector(primary, tt.verifier, "SPX500", 0.005)
+			ind, err := c.Collect()
+			if err != nil {
+				t.Fatalf("Collect: %v", err)
+			}
+			if len(tt.want) == 0 && ind.SourceWarning != "" {
+				t.Errorf("unexpected warning %q", ind.SourceWarning)
+			}
+			for _, w := range tt.want {
+				if !strings.Contains(ind.SourceWarning, w) {
+					t.Errorf("warning %q does not mention %q", ind.SourceWarning, w)
+				}
+			}
+			if ind.CurrentPrice != 5000 {
+				t.Errorf("price = %.2f, want the primary's 5000", ind.CurrentPrice)
+			}
+		})
+	}
+}
@@ -1,31 +1,35 @@
 package collector
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
-	"io"
 	"net/http"
 	"net/url"
 	"sort"
 	"time"
 
+	"MarketSentinel/internal/calculator"
 	"MarketSentinel/internal/model"
 )
 
+// vsMaxPages bounds the requests fetchBars makes for one series.
+const vsMaxPages = 20
+
 // VsTraderFetcher implements Fetcher using the vstrader REST API.
 type VsTraderFetcher struct {
 	BaseURL string
 	APIKey  string
 	Client  *http.Client
+	Retry   RetryPolicy
 }
 
-// NewVsTraderFetcher creates a new fetcher with optional proxy support.
-func NewVsTraderFetcher(baseURL, apiKey, proxyURL string) *VsTraderFetcher {
+// NewVsTraderFetcher creates a new fetcher with optional proxy support;
+// proxy may be an http(s):// or socks5:// URL, nil for none.
+func NewVsTraderFetcher(baseURL, apiKey string, proxy *url.URL) *VsTraderFetcher {
 	transport := &http.Transport{}
-	if proxyURL != "" {
-		if u, err := url.Parse(proxyURL); err == nil {
-			transport.Proxy = http.ProxyURL(u)
-		}
+	if proxy != nil {
+		transport.Proxy = http.ProxyURL(proxy)
 	}
 	return &VsTraderFetcher{
 		BaseURL: baseURL,
@@ -34,6 +38,7 @@ func NewVsTraderFetcher(baseURL, apiKey, proxyURL string) *VsTraderFetcher {
 			Timeout:   30 * time.Second,
 			Transport: transport,
 		},
+		Retry: DefaultRetryPolicy,
 	}
 }
 
@@ -50,126 +55,131 @@ type vsBar struct {
 }
 
 func (f *VsTraderFetcher) FetchDailyBars(symbol string, days int) ([]model.OHLCV, error) {
-	endpoint := fmt.Sprintf("%s/api/v1/bars/daily?symbol=%s&limit=%d", f.BaseURL, symbol, days)
-	return f.fetchBars(endpoint)
+	endpoint := fmt.Sprintf("%s/api/v1/bars/daily?symbol=%s", f.BaseURL, symbol)
+	return f.fetchBars(endpoint, days)
 }
 
 func (f *VsTraderFetcher) FetchWeeklyBars(symbol string, weeks int) ([]model.OHLCV, error) {
 	// Try weekly endpoint first; if API only provides daily, aggregate internally.
-	endpoint := fmt.Sprintf("%s/api/v1/bars/weekly?symbol=%s&limit=%d", f.BaseURL, symbol, weeks)
-	bars, err := f.fetchBars(endpoint)
+	endpoint := fmt.Sprintf("%s/api/v1/bars/weekly?symbol=%s", f.BaseURL, symbol)
+	bars, err := f.fetchBars(endpoint, weeks)
 	if err != nil {
 		// Fallback: fetch enough daily bars and aggregate to weekly
 		dailyBars, dailyErr := f.FetchDailyBars(symbol, weeks*7)
 		if dailyErr != nil {
 			return nil, fmt.Errorf("weekly fetch failed: %w; daily fallback also failed: %w", err, dailyErr)
 		}
-		return aggregateDailyToWeekly(dailyBars), nil
+		return calculator.AggregateWeekly(dailyBars), nil
 	}
 	return bars, nil
 }
 
 func (f *VsTraderFetcher) FetchCurrentPrice(symbol string) (float64, error) {
 	endpoint := fmt.Sprintf("%s/api/v1/quote?symbol=%s", f.BaseURL, symbol)
-	req, err := http.NewRequest("GET", endpoint, nil)
-	if err != nil {
-		return 0, err
-	}
-	if f.APIKey != "" {
-		req.Header.Set("Authorization", "Bearer "+f.APIKey)
-	}
-	resp, err := f.Client.Do(req)
+	status, body, err := f.get(endpoint)
 	if err != nil {
 		return 0, fmt.Errorf("fetch current price: %w", err)
 	}
-	defer resp.Body.Close()
-	if resp.StatusCode != http.StatusOK {
-		return 0, fmt.Errorf("fetch current price: status %d", resp.StatusCode)
+	if status != http.StatusOK {
+		return 0, fmt.Errorf("fetch current price: status %d", status)
 	}
 	var result struct {
 		Price float64 `json:"price"`
 	}
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+	if err := json.Unmarshal(body, &result); err != nil {
 		return 0, fmt.Errorf("decode price: %w", err)
 	}
 	return result.Price, nil
 }
 
-func (f *VsTraderFetcher) fetchBars(endpoint string) ([]model.OHLCV, error) {
-	req, err := http.NewRequest("GET", endpoint, nil)
-	if err != nil {
-		return nil, err
-	}
-	if f.APIKey != "" {
-		req.Header.Set("Authorization", "Bearer "+f.APIKey)
-	}
-	resp, err := f.Client.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("fetch bars: %w", err)
-	}
-	defer resp.Body.Close()
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("fetch bars: status %d, body: %s", resp.StatusCode, string(body))
-	}
-	var vsBars []vsBar
-	if err := json.NewDecoder(resp.Body).Decode(&vsBars); err != nil {
-		return nil, fmt.Errorf("decode bars: %w", err)
-	}
-	bars := make([]model.OHLCV, len(vsBars))
-	for i, vb := range vsBars {
-		bars[i] = model.OHLCV{
-			Time:   time.Unix(vb.Timestamp, 0),
-			Open:   vb.Open,
-			High:   vb.High,
-			Low:    vb.Low,
-			Close:  vb.Close,
-			Volume: vb.Volume,
+// get performs an authenticated GET with f.Retry.
+func (f *VsTraderFetcher) get(endpoint string) (int, []byte, error) {
+	return f.Retry.do(f.Client, "vstrader", func() (*http.Request, error) {
+		req, err := http.NewRequest("GET", endpoint, nil)
+		if err != nil {
+			return nil, err
+		}
+		if f.APIKey != "" {
+			req.Header.Set("Authorization", "Bearer "+f.APIKey)
+		}
+		return req, nil
+	})
+}
+
+// fetchBars fetches up to limit bars from endpoint, oldest first, following
+// pagination when the server caps its responses. A response is either a bare
+// array of bars or {"bars": [...], "next": cursor}. A cursor is passed back as
+// cursor=; a bare array shorter than asked is followed by a request for the
+// bars before its oldest one. Paging stops at limit bars, an empty cursor, or
+// a page adding no new timestamps. Overlapping bars are dropped.
+func (f *VsTraderFetcher) fetchBars(endpoint string, limit int) ([]model.OHLCV, error) {
+	seen := make(map[int64]bool)
+	var bars []model.OHLCV
+	page := fmt.Sprintf("%s&limit=%d", endpoint, limit)
+	for n := 0; n < vsMaxPages; n++ {
+		vsBars, next, err := f.fetchPage(page)
+		if err != nil {
+			return nil, err
+		}
+		oldest, added := int64(0), 0
+		for _, vb := range vsBars {
+			if oldest == 0 || vb.Timestamp < oldest {
+				oldest = vb.Timestamp
+			}
+			if seen[vb.Timestamp] {
+				continue
+			}
+			seen[vb.Timestamp] = true
+			added++
+			bars = append(bars, model.OHLCV{
+				Time:   time.Unix(vb.Timestamp, 0),
+				Open:   vb.Open,
+				High:   vb.High,
+				Low:    vb.Low,
+				Close:  vb.Close,
+				Volume: vb.Volume,
+			})
+		}
+		remaining := limit - len(bars)
+		if remaining <= 0 || added == 0 || (next != nil && *next == "") {
+			break
+		}
+		if next != nil {
+			page = fmt.Sprintf("%s&limit=%d&cursor=%s", endpoint, remaining, url.QueryEscape(*next))
+		} else {
+			page = fmt.Sprintf("%s&limit=%d&before=%d", endpoint, remaining, oldest)
 		}
 	}
 	// Ensure chronological order
 	sort.Slice(bars, func(i, j int) bool { return bars[i].Time.Before(bars[j].Time) })
+	if len(bars) > limit {
+		bars = bars[len(bars)-limit:]
+	}
 	return bars, nil
 }
 
-// aggregateDailyToWeekly converts daily bars into weekly bars (Mon-Fri).
-func aggregateDailyToWeekly(daily []model.OHLCV) []model.OHLCV {
-	if len(daily) == 0 {
-		return nil
+// fetchPage fetches one page of bars. next is the cursor of a paged response,
+// nil for a bare array.
+func (f *VsTraderFetcher) fetchPage(endpoint string) (bars []vsBar, next *string, err error) {
+	status, body, err := f.get(endpoint)
+	if err != nil {
+		return nil, nil, fmt.Errorf("fetch bars: %w", err)
 	}
-	var weekly []model.OHLCV
-	var week model.OHLCV
-	var weekStarted bool
-
-	for _, d := range daily {
-		year, isoWeek := d.Time.ISOWeek()
-		weekKey := year*100 + isoWeek
-
-		if !weekStarted {
-			week = model.OHLCV{Time: d.Time, Open: d.Open, High: d.High, Low: d.Low, Close: d.Close, Volume: d.Volume}
-			weekStarted = true
-			continue
+	if status != http.StatusOK {
+		return nil, nil, fmt.Errorf("fetch bars: status %d, body: %s", status, string(body))
+	}
+	if trimmed := bytes.TrimSpace(body); len(trimmed) > 0 && trimmed[0] == '{' {
+		var paged struct {
+			Bars []vsBar `json:"bars"`
+			Next string  `json:"next"`
 		}
-
-		cy, cw := week.Time.ISOWeek()
-		currentKey := cy*100 + cw
-
-		if weekKey != currentKey {
-			weekly = append(weekly, week)
-			week = model.OHLCV{Time: d.Time, Open: d.Open, High: d.High, Low: d.Low, Close: d.Close, Volume: d.Volume}
-		} else {
-			if d.High > week.High {
-				week.High = d.High
-			}
-			if d.Low < week.Low {
-				week.Low = d.Low
-			}
-			week.Close = d.Close
-			week.Volume += d.Volume
+		if err := json.Unmarshal(body, &paged); err != nil {
+			return nil, nil, fmt.Errorf("decode bars: %w", err)
 		}
+		return paged.Bars, &paged.Next, nil
 	}
-	if weekStarted {
-		weekly = append(weekly, week)
+	if err := json.Unmarshal(body, &bars); err != nil {
+		return nil, nil, fmt.Errorf("decode bars: %w", err)
 	}
-	return weekly
+	return bars, nil, nil
 }
@@ -0,0 +1,122 @@
+package collector
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// vsPageCap is the most bars the test server returns per response.
+const vsPageCap = 100
+
+// vsTestBar is bar i of the test series: one per day from 2024-01-01, close
+// 1000 + i.
+func vsTestBar(i int) vsBar {
+	c := 1000 + float64(i)
+	return vsBar{
+		Timestamp: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC).AddDate(0, 0, i).Unix(),
+		Open:      c, High: c + 1, Low: c - 1, Close: c, Volume: 1000,
+	}
+}
+
+// vsPagingServer serves the newest of total daily bars, at most vsPageCap at a
+// time. With cursors it answers {"bars", "next"} in fixed pages of vsPageCap,
+// each repeating the oldest bar of the previous one; otherwise it answers a
+// bare array of up to limit bars, newest first, honoring before=.
+func vsPagingServer(t *testing.T, total int, cursors bool) (*VsTraderFetcher, *int32) {
+	t.Helper()
+	var requests int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		q := r.URL.Query()
+
+		if cursors {
+			// Pages run newest to oldest; the cursor is the index of the
+			// oldest bar on the previous page, which is served again.
+			end := total
+			if c := q.Get("cursor"); c != "" {
+				end, _ = strconv.Atoi(c)
+				end++
+			}
+			start := max(0, end-vsPageCap)
+			page := struct {
+				Bars []vsBar `json:"bars"`
+				Next string  `json:"next"`
+			}{}
+			for i := start; i < end; i++ {
+				page.Bars = append(page.Bars, vsTestBar(i))
+			}
+			if start > 0 {
+				page.Next = strconv.Itoa(start)
+			}
+			json.NewEncoder(w).Encode(page)
+			return
+		}
+
+		limit, _ := strconv.Atoi(q.Get("limit"))
+		limit = min(limit, vsPageCap)
+		bars := []vsBar{}
+		before := int64(1 << 62)
+		if b := q.Get("before"); b != "" {
+			before, _ = strconv.ParseInt(b, 10, 64)
+		}
+		for i := total - 1; i >= 0 && len(bars) < limit; i-- {
+			if vb := vsTestBar(i); vb.Timestamp < before {
+				bars = append(bars, vb)
+			}
+		}
+		json.NewEncoder(w).Encode(bars)
+	}))
+	t.Cleanup(srv.Close)
+
+	f := NewVsTraderFetcher(srv.URL, "", nil)
+	f.Retry = RetryPolicy{MaxAttempts: 1}
+	return f, &requests
+}
+
+func TestVsTraderFetcher_FollowsPages(t *testing.T) {
+	for _, cursors := range []bool{false, true} {
+		name := "before"
+		if cursors {
+			name = "cursor"
+		}
+		t.Run(name, func(t *testing.T) {
+			f, requests := vsPagingServer(t, 400, cursors)
+			bars, err := f.FetchDailyBars("SPX500", 250)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(bars) != 250 {
+				t.Fatalf("got %d bars, want 250", len(bars))
+			}
+			for i := 1; i < len(bars); i++ {
+				if !bars[i].Time.After(bars[i-1].Time) {
+					t.Fatalf("bar %d at %s does not follow %s", i, bars[i].Time, bars[i-1].Time)
+				}
+			}
+			if first, last := bars[0].Close, bars[len(bars)-1].Close; first != 1150 || last != 1399 {
+				t.Errorf("closes run %v..%v, want the newest 250 bars 1150..1399", first, last)
+			}
+			if n := atomic.LoadInt32(requests); n != 3 {
+				t.Errorf("made %d requests, want 3 pages", n)
+			}
+		})
+	}
+}
+
+func TestVsTraderFetcher_FewerBarsThanRequested(t *testing.T) {
+	for _, cursors := range []bool{false, true} {
+		f, _ := vsPagingServer(t, 40, cursors)
+		bars, err := f.FetchDailyBars("SPX500", 300)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(bars) != 40 || bars[0].Close != 1000 || bars[39].Close != 1039 {
+			t.Errorf("cursors=%v: got %d bars, want all 40 in order", cursors, len(bars))
+		}
+	}
+}
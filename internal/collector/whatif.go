@@ -0,0 +1,48 @@
+package collector
+
+import (
+	"errors"
+	"time"
+
+	"MarketSentinel/internal/model"
+)
+
+// ErrNothingCached is returned by WhatIf before the first successful fetch of
+// the daily bars and the current price.
+var ErrNothingCached = errors.New("no bars fetched yet")
+
+// WhatIf computes the indicators the last fetched series would give with the
+// current price at price: the last daily bar, and the last weekly bar unless
+// they are derived from the daily bars, close at price, so the RSIs move with
+// it, and every indicator depending on the price is computed again. Nothing
+// is fetched, and the RSI state of Compute is left as it was since only the
+// newest bar changes.
+func (c *Collector) WhatIf(price float64) (*model.MarketIndicators, error) {
+	in := c.Cached()
+	if len(in.Daily) == 0 || in.Price <= 0 {
+		return nil, ErrNothingCached
+	}
+	in.Price = price
+	in.Daily = withClose(in.Daily, price)
+	if c.WeeklyFromDaily {
+		in.Weekly = c.DeriveWeekly(in.Daily, time.Now())
+	} else {
+		in.Weekly = withClose(in.Weekly, price)
+	}
+	return c.Compute(in), nil
+}
+
+// withClose returns a copy of bars whose last bar closes at price, its high
+// and low widened to include it.
+func withClose(bars []model.OHLCV, price float64) []model.OHLCV {
+	n := len(bars)
+	if n == 0 {
+		return bars
+	}
+	out := append([]model.OHLCV(nil), bars...)
+	last := &out[n-1]
+	last.Close = price
+	last.High = max(last.High, price)
+	last.Low = min(last.Low, price)
+	return out
+}
@@ -0,0 +1,40 @@
+package collector
+
+import (
+	"errors"
+	"testing"
+
+	"MarketSentinel/internal/calculator"
+)
+
+func TestWhatIf(t *testing.T) {
+	daily := generateMockBars(5000, 300)
+	c := NewCollector(&MockFetcher{Price: 5000, DailyData: daily}, "SPX500")
+	if _, err := c.WhatIf(4500); !errors.Is(err, ErrNothingCached) {
+		t.Fatalf("before any fetch: err = %v, want ErrNothingCached", err)
+	}
+	before, err := c.Collect()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ind, err := c.WhatIf(4500)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ind.CurrentPrice != 4500 || ind.Position52w >= before.Position52w || ind.DailyRSI >= before.DailyRSI {
+		t.Errorf("what-if at 4500: price %.0f, position %.3f (was %.3f), daily RSI %.1f (was %.1f)",
+			ind.CurrentPrice, ind.Position52w, before.Position52w, ind.DailyRSI, before.DailyRSI)
+	}
+	want, _ := calculator.CalculateRSI(withClose(daily, 4500), rsiPeriod)
+	if ind.DailyRSI-want > 0.01 || want-ind.DailyRSI > 0.01 {
+		t.Errorf("daily RSI = %.2f, want %.2f with the last bar closing at 4500", ind.DailyRSI, want)
+	}
+
+	if in := c.Cached(); in.Price != 5000 || in.Daily[len(in.Daily)-1].Close != daily[len(daily)-1].Close {
+		t.Error("WhatIf modified the cached series")
+	}
+	if after, _ := c.Collect(); after.DailyRSI != before.DailyRSI {
+		t.Errorf("daily RSI after WhatIf = %.2f, want the unchanged %.2f", after.DailyRSI, before.DailyRSI)
+	}
+}
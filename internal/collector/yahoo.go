@@ -2,41 +2,76 @@ package collector
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
 	"log"
 	"net/http"
 	"net/url"
 	"sort"
+	"strings"
+	"sync"
 	"time"
 
 	"MarketSentinel/internal/model"
 )
 
+// defaultYahooBaseURL is the public chart API host.
+const defaultYahooBaseURL = "https://query1.finance.yahoo.com"
+
+// defaultYahooCookieURL hands out the consent cookie the crumb endpoint needs.
+const defaultYahooCookieURL = "https://fc.yahoo.com"
+
+// ErrYahooRateLimited is returned when Yahoo keeps answering 429 or 999 even
+// after the request is retried with a fresh cookie and crumb.
+var ErrYahooRateLimited = errors.New("yahoo rate limit")
+
 // YahooFetcher implements Fetcher using Yahoo Finance public API.
 type YahooFetcher struct {
 	Client    *http.Client
+	BaseURL   string            // API host, overridable for tests
 	SymbolMap map[string]string // maps internal symbol to Yahoo ticker
+	Retry     RetryPolicy
+	// Adjusted replaces closes with split and dividend adjusted closes and
+	// scales open, high and low by the same factor. Series without adjusted
+	// closes, such as indices, keep raw prices.
+	Adjusted bool
+	// CookieURL serves the consent cookie used to obtain a crumb,
+	// overridable for tests.
+	CookieURL string
+
+	mu   sync.Mutex
+	auth *yahooAuth // cookie and crumb from the last refresh, nil before
 }
 
-// NewYahooFetcher creates a new Yahoo Finance fetcher.
-func NewYahooFetcher(proxyURL string) *YahooFetcher {
+// yahooAuth is a consent cookie and the crumb issued for it.
+type yahooAuth struct {
+	cookie string
+	crumb  string
+}
+
+// NewYahooFetcher creates a new Yahoo Finance fetcher. proxy may be an
+// http(s):// or socks5:// URL, nil for none. symbolMap entries override the
+// built-in ticker aliases.
+func NewYahooFetcher(proxy *url.URL, symbolMap map[string]string) *YahooFetcher {
 	transport := &http.Transport{}
-	if proxyURL != "" {
-		if u, err := url.Parse(proxyURL); err == nil {
-			transport.Proxy = http.ProxyURL(u)
-		}
+	if proxy != nil {
+		transport.Proxy = http.ProxyURL(proxy)
 	}
 	return &YahooFetcher{
 		Client: &http.Client{
 			Timeout:   30 * time.Second,
 			Transport: transport,
 		},
-		SymbolMap: map[string]string{
-			"SPX500": "^GSPC",
-			"SPX":    "^GSPC",
-			"SP500":  "^GSPC",
-		},
+		BaseURL:   defaultYahooBaseURL,
+		CookieURL: defaultYahooCookieURL,
+		Retry:     DefaultRetryPolicy,
+		SymbolMap: MergeSymbolMap(map[string]string{
+			"SPX500":    "^GSPC",
+			"SPX":       "^GSPC",
+			"SP500":     "^GSPC",
+			"NDX":       "^NDX",
+			"NASDAQ100": "^NDX",
+		}, symbolMap),
 	}
 }
 
@@ -62,6 +97,9 @@ type yahooChart struct {
 					Close  []interface{} `json:"close"`
 					Volume []interface{} `json:"volume"`
 				} `json:"quote"`
+				AdjClose []struct {
+					AdjClose []interface{} `json:"adjclose"`
+				} `json:"adjclose"`
 			} `json:"indicators"`
 		} `json:"result"`
 		Error *struct {
@@ -86,86 +124,174 @@ func toFloat(v interface{}) float64 {
 }
 
 func (f *YahooFetcher) fetchChart(symbol, interval, rng string) ([]model.OHLCV, error) {
-	u := fmt.Sprintf("https://query1.finance.yahoo.com/v8/finance/chart/%s?interval=%s&range=%s",
-		url.PathEscape(f.yahooSymbol(symbol)), interval, rng)
-
-	var lastErr error
-	for attempt := 0; attempt < 3; attempt++ {
-		if attempt > 0 {
-			wait := time.Duration(attempt*5) * time.Second
-			log.Printf("[INFO] yahoo: retrying in %v (attempt %d/3)", wait, attempt+1)
-			time.Sleep(wait)
+	u := fmt.Sprintf("%s/v8/finance/chart/%s?interval=%s&range=%s",
+		f.BaseURL, url.PathEscape(f.yahooSymbol(symbol)), interval, rng)
+
+	f.mu.Lock()
+	auth := f.auth
+	f.mu.Unlock()
+	status, body, err := f.getChart(u, auth)
+	if err != nil {
+		return nil, err
+	}
+	if rateLimited(status) {
+		// Yahoo throttles clients without a session; get one and try once more.
+		log.Printf("[WARN] yahoo: status %d, retrying with a fresh cookie and crumb", status)
+		if auth, err = f.refreshAuth(); err != nil {
+			return nil, fmt.Errorf("%w: status %d, crumb refresh failed: %v", ErrYahooRateLimited, status, err)
+		}
+		if status, body, err = f.getChart(u, auth); err != nil {
+			return nil, err
 		}
+		if rateLimited(status) {
+			return nil, fmt.Errorf("%w: status %d even with a fresh crumb", ErrYahooRateLimited, status)
+		}
+	}
+	if status != http.StatusOK {
+		return nil, fmt.Errorf("yahoo: status %d, body: %s", status, string(body))
+	}
 
+	bars, skipped, err := parseChart(body, f.Adjusted)
+	if err != nil {
+		return nil, err
+	}
+	if skipped > 0 {
+		log.Printf("[INFO] yahoo: skipped %d empty points for %s %s/%s", skipped, symbol, interval, rng)
+	}
+	return bars, nil
+}
+
+// rateLimited reports whether status is Yahoo refusing to serve the client:
+// 429, or 999 with its "unable to process request" page.
+func rateLimited(status int) bool {
+	return status == http.StatusTooManyRequests || status == 999
+}
+
+// getChart requests the chart URL u under the retry policy, attaching the
+// cookie and crumb of auth when set.
+func (f *YahooFetcher) getChart(u string, auth *yahooAuth) (int, []byte, error) {
+	if auth != nil {
+		u += "&crumb=" + url.QueryEscape(auth.crumb)
+	}
+	return f.Retry.do(f.Client, "yahoo", func() (*http.Request, error) {
 		req, err := http.NewRequest("GET", u, nil)
 		if err != nil {
 			return nil, err
 		}
 		req.Header.Set("User-Agent", "Mozilla/5.0")
-
-		resp, err := f.Client.Do(req)
-		if err != nil {
-			lastErr = fmt.Errorf("yahoo fetch: %w", err)
-			continue
+		if auth != nil {
+			req.Header.Set("Cookie", auth.cookie)
 		}
+		return req, nil
+	})
+}
 
-		body, err := io.ReadAll(resp.Body)
-		resp.Body.Close()
-		if err != nil {
-			lastErr = fmt.Errorf("yahoo read body: %w", err)
-			continue
-		}
+// refreshAuth fetches a consent cookie from CookieURL and a crumb for it, and
+// caches both for later requests.
+func (f *YahooFetcher) refreshAuth() (*yahooAuth, error) {
+	req, err := http.NewRequest("GET", f.CookieURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", "Mozilla/5.0")
+	resp, err := f.Client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("cookie: %w", err)
+	}
+	resp.Body.Close() // the page itself is usually a 404; only the cookie matters
+	var cookies []string
+	for _, c := range resp.Cookies() {
+		cookies = append(cookies, c.Name+"="+c.Value)
+	}
+	if len(cookies) == 0 {
+		return nil, errors.New("cookie: none set")
+	}
+	auth := &yahooAuth{cookie: strings.Join(cookies, "; ")}
 
-		if resp.StatusCode == 429 {
-			lastErr = fmt.Errorf("yahoo: rate limited (429)")
-			continue
-		}
-		if resp.StatusCode != http.StatusOK {
-			return nil, fmt.Errorf("yahoo: status %d, body: %s", resp.StatusCode, string(body))
-		}
+	if req, err = http.NewRequest("GET", f.BaseURL+"/v1/test/getcrumb", nil); err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", "Mozilla/5.0")
+	req.Header.Set("Cookie", auth.cookie)
+	status, body, err := send(f.Client, req)
+	if err != nil {
+		return nil, fmt.Errorf("crumb: %w", err)
+	}
+	auth.crumb = strings.TrimSpace(string(body))
+	if status != http.StatusOK || auth.crumb == "" || strings.ContainsAny(auth.crumb, "<{ ") {
+		return nil, fmt.Errorf("crumb: status %d", status)
+	}
 
-		var chart yahooChart
-		if err := json.Unmarshal(body, &chart); err != nil {
-			return nil, fmt.Errorf("yahoo decode: %w", err)
-		}
-		if chart.Chart.Error != nil {
-			return nil, fmt.Errorf("yahoo api error: %s", chart.Chart.Error.Description)
+	f.mu.Lock()
+	f.auth = auth
+	f.mu.Unlock()
+	return auth, nil
+}
+
+// parseChart decodes a chart response into bars sorted by time. Points whose
+// open, high, low and close are all null or zero are skipped and counted; a
+// missing or short volume series yields zero volume. With adjusted, each bar
+// with an adjusted close is rescaled to it; the others keep raw prices.
+func parseChart(body []byte, adjusted bool) (bars []model.OHLCV, skipped int, err error) {
+	var chart yahooChart
+	if err := json.Unmarshal(body, &chart); err != nil {
+		return nil, 0, fmt.Errorf("yahoo decode: %w", err)
+	}
+	if chart.Chart.Error != nil {
+		return nil, 0, fmt.Errorf("yahoo api error: %s", chart.Chart.Error.Description)
+	}
+	if len(chart.Chart.Result) == 0 || len(chart.Chart.Result[0].Timestamp) == 0 {
+		return nil, 0, fmt.Errorf("yahoo: no data returned")
+	}
+
+	result := chart.Chart.Result[0]
+	if len(result.Indicators.Quote) == 0 {
+		return nil, 0, fmt.Errorf("yahoo: no quote data returned")
+	}
+	quote := result.Indicators.Quote[0]
+	var adjClose []interface{}
+	if adjusted && len(result.Indicators.AdjClose) > 0 {
+		adjClose = result.Indicators.AdjClose[0].AdjClose
+	}
+	bars = make([]model.OHLCV, 0, len(result.Timestamp))
+
+	for i, ts := range result.Timestamp {
+		o := toFloat(at(quote.Open, i))
+		h := toFloat(at(quote.High, i))
+		l := toFloat(at(quote.Low, i))
+		c := toFloat(at(quote.Close, i))
+		if o == 0 && h == 0 && l == 0 && c == 0 {
+			skipped++
+			continue
 		}
-		if len(chart.Chart.Result) == 0 || len(chart.Chart.Result[0].Timestamp) == 0 {
-			return nil, fmt.Errorf("yahoo: no data returned")
+		if adj := toFloat(at(adjClose, i)); adj > 0 && c > 0 {
+			k := adj / c
+			o, h, l, c = o*k, h*k, l*k, adj
 		}
+		bars = append(bars, model.OHLCV{
+			Time:   time.Unix(ts, 0),
+			Open:   o,
+			High:   h,
+			Low:    l,
+			Close:  c,
+			Volume: toFloat(at(quote.Volume, i)),
+		})
+	}
 
-		result := chart.Chart.Result[0]
-		quote := result.Indicators.Quote[0]
-		bars := make([]model.OHLCV, 0, len(result.Timestamp))
-
-		for i, ts := range result.Timestamp {
-			o := toFloat(quote.Open[i])
-			h := toFloat(quote.High[i])
-			l := toFloat(quote.Low[i])
-			c := toFloat(quote.Close[i])
-			if o == 0 && h == 0 && l == 0 && c == 0 {
-				continue
-			}
-			bars = append(bars, model.OHLCV{
-				Time:   time.Unix(ts, 0),
-				Open:   o,
-				High:   h,
-				Low:    l,
-				Close:  c,
-				Volume: toFloat(quote.Volume[i]),
-			})
-		}
+	sort.Slice(bars, func(i, j int) bool { return bars[i].Time.Before(bars[j].Time) })
+	return bars, skipped, nil
+}
 
-		sort.Slice(bars, func(i, j int) bool { return bars[i].Time.Before(bars[j].Time) })
-		return bars, nil
+// at returns values[i], or nil when the series is shorter than the timestamps.
+func at(values []interface{}, i int) interface{} {
+	if i < len(values) {
+		return values[i]
 	}
-	return nil, lastErr
+	return nil
 }
 
 func (f *YahooFetcher) FetchDailyBars(symbol string, days int) ([]model.OHLCV, error) {
-	// Yahoo range: max "2y" for daily interval
-	rng := "2y"
+	rng := "5y"
 	if days <= 30 {
 		rng = "1mo"
 	} else if days <= 90 {
@@ -174,6 +300,8 @@ func (f *YahooFetcher) FetchDailyBars(symbol string, days int) ([]model.OHLCV, e
 		rng = "6mo"
 	} else if days <= 365 {
 		rng = "1y"
+	} else if days <= 500 {
+		rng = "2y"
 	}
 	bars, err := f.fetchChart(symbol, "1d", rng)
 	if err != nil {
@@ -187,11 +315,13 @@ func (f *YahooFetcher) FetchDailyBars(symbol string, days int) ([]model.OHLCV, e
 }
 
 func (f *YahooFetcher) FetchWeeklyBars(symbol string, weeks int) ([]model.OHLCV, error) {
-	rng := "2y"
+	rng := "5y"
 	if weeks <= 26 {
 		rng = "6mo"
 	} else if weeks <= 52 {
 		rng = "1y"
+	} else if weeks <= 104 {
+		rng = "2y"
 	}
 	bars, err := f.fetchChart(symbol, "1wk", rng)
 	if err != nil {
@@ -203,6 +333,24 @@ func (f *YahooFetcher) FetchWeeklyBars(symbol string, weeks int) ([]model.OHLCV,
 	return bars, nil
 }
 
+// FetchIntradayBars fetches bars of interval, one of Yahoo's intraday
+// intervals such as 60m, from the past month; 1m bars only reach back five
+// days.
+func (f *YahooFetcher) FetchIntradayBars(symbol, interval string, bars int) ([]model.OHLCV, error) {
+	rng := "1mo"
+	if interval == "1m" {
+		rng = "5d"
+	}
+	out, err := f.fetchChart(symbol, interval, rng)
+	if err != nil {
+		return nil, err
+	}
+	if len(out) > bars {
+		out = out[len(out)-bars:]
+	}
+	return out, nil
+}
+
 func (f *YahooFetcher) FetchCurrentPrice(symbol string) (float64, error) {
 	bars, err := f.fetchChart(symbol, "1d", "1d")
 	if err != nil {
@@ -0,0 +1,231 @@
+package collector
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"MarketSentinel/internal/calculator"
+)
+
+// testdata/yahoo holds chart responses shaped like the live API, named
+// <ticker>_<interval>_<range>.json with any leading "^" dropped:
+//
+//	GSPC_1d_1mo     22 sessions from 2024-01-02, one all-null point
+//	GSPC_1d_2y      504 sessions from 2022-01-03, two all-null points
+//	GSPC_1wk_2y     105 weeks from 2022-01-03, no volume series
+//	GSPC_60m_1mo    hourly bars of 2024-01-30 and 31, seven a session, close
+//	                4900 + 1/bar
+//	ERR_1d_1mo      error object, null result
+//	EMPTY_1d_1mo    empty result array
+//	NOQUOTE_1d_1mo  timestamps but an empty quote array
+//	BAD_1d_1mo      truncated JSON
+//	SPLIT_1d_1y     260 sessions from 2023-01-03 of a stock with a 4:1 split
+//	                on 2023-07-05, with adjusted closes
+func yahooFixtureServer(t *testing.T) *YahooFetcher {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		symbol := strings.TrimPrefix(r.URL.Path, "/v8/finance/chart/^")
+		symbol = strings.TrimPrefix(symbol, "/v8/finance/chart/")
+		q := r.URL.Query()
+		name := symbol + "_" + q.Get("interval") + "_" + q.Get("range") + ".json"
+		data, err := os.ReadFile(filepath.Join("testdata", "yahoo", name))
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(data)
+	}))
+	t.Cleanup(srv.Close)
+
+	f := NewYahooFetcher(nil, nil)
+	f.BaseURL = srv.URL
+	return f
+}
+
+func TestYahooFetcher_FixtureReplay(t *testing.T) {
+	f := yahooFixtureServer(t)
+	session := func(y int, m time.Month, d int) time.Time { return time.Date(y, m, d, 14, 30, 0, 0, time.UTC) }
+
+	tests := []struct {
+		symbol, interval, rng string
+		wantBars              int
+		wantSkipped           int
+		wantFirst, wantLast   time.Time
+		wantVolume            bool
+	}{
+		{"SPX500", "1d", "1mo", 21, 1, session(2024, 1, 2), session(2024, 1, 31), true},
+		{"SPX500", "1d", "2y", 502, 2, session(2022, 1, 3), session(2023, 12, 7), true},
+		{"SPX500", "1wk", "2y", 105, 0, time.Date(2022, 1, 3, 5, 0, 0, 0, time.UTC), time.Date(2024, 1, 1, 5, 0, 0, 0, time.UTC), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.symbol+"_"+tt.interval+"_"+tt.rng, func(t *testing.T) {
+			bars, err := f.fetchChart(tt.symbol, tt.interval, tt.rng)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(bars) != tt.wantBars {
+				t.Fatalf("got %d bars, want %d", len(bars), tt.wantBars)
+			}
+			if !bars[0].Time.Equal(tt.wantFirst) || !bars[len(bars)-1].Time.Equal(tt.wantLast) {
+				t.Errorf("range %v..%v, want %v..%v", bars[0].Time.UTC(), bars[len(bars)-1].Time.UTC(), tt.wantFirst, tt.wantLast)
+			}
+			if got := bars[0].Volume > 0; got != tt.wantVolume {
+				t.Errorf("volume present = %v, want %v", got, tt.wantVolume)
+			}
+
+			body, err := os.ReadFile(filepath.Join("testdata", "yahoo", "GSPC_"+tt.interval+"_"+tt.rng+".json"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, skipped, _ := parseChart(body, false); skipped != tt.wantSkipped {
+				t.Errorf("skipped %d points, want %d", skipped, tt.wantSkipped)
+			}
+		})
+	}
+}
+
+func TestYahooFetcher_FixtureErrors(t *testing.T) {
+	f := yahooFixtureServer(t)
+
+	tests := []struct {
+		symbol  string
+		wantErr string
+	}{
+		{"ERR", "yahoo api error: No data found"},
+		{"EMPTY", "yahoo: no data returned"},
+		{"NOQUOTE", "yahoo: no quote data returned"},
+		{"MISSING", "yahoo: status 404"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.symbol, func(t *testing.T) {
+			_, err := f.fetchChart(tt.symbol, "1d", "1mo")
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("got error %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+
+	t.Run("BAD", func(t *testing.T) {
+		_, err := f.fetchChart("BAD", "1d", "1mo")
+		var syntaxErr *json.SyntaxError
+		if err == nil || !strings.HasPrefix(err.Error(), "yahoo decode:") || !errors.As(err, &syntaxErr) {
+			t.Errorf("expected wrapped decode error, got %v", err)
+		}
+	})
+}
+
+func TestYahooFetcher_DailyBarsTrim(t *testing.T) {
+	f := yahooFixtureServer(t)
+	bars, err := f.FetchDailyBars("SPX500", 400)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(bars) != 400 || bars[399].Close != 4700+503*1.5 {
+		t.Errorf("expected last 400 of the 2y series, got %d bars ending %.2f", len(bars), bars[len(bars)-1].Close)
+	}
+}
+
+func TestYahooFetcher_HistoryRange(t *testing.T) {
+	var ranges []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ranges = append(ranges, r.URL.Query().Get("interval")+"/"+r.URL.Query().Get("range"))
+		http.NotFound(w, r)
+	}))
+	defer srv.Close()
+	f := NewYahooFetcher(nil, nil)
+	f.BaseURL = srv.URL
+	f.Retry = RetryPolicy{MaxAttempts: 1}
+
+	// The percentile windows need more than Yahoo's two-year ranges hold.
+	f.FetchDailyBars("SPX500", 400)
+	f.FetchDailyBars("SPX500", dailyBarsFor(EquityCalendar))
+	f.FetchWeeklyBars("SPX500", 104)
+	f.FetchWeeklyBars("SPX500", weeklyBarsFetched)
+	want := []string{"1d/2y", "1d/5y", "1wk/2y", "1wk/5y"}
+	if strings.Join(ranges, " ") != strings.Join(want, " ") {
+		t.Errorf("ranges %v, want %v", ranges, want)
+	}
+}
+
+func TestYahooFetcher_IntradayBars(t *testing.T) {
+	f := yahooFixtureServer(t)
+	bars, err := f.FetchIntradayBars("SPX500", "60m", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(bars) != 10 || bars[9].Close != 4913 || bars[9].Time.UTC().Hour() != 20 {
+		t.Errorf("expected the last 10 hourly bars, got %d ending %.2f at %s", len(bars), bars[len(bars)-1].Close, bars[len(bars)-1].Time.UTC())
+	}
+}
+
+func TestYahooFetcher_AdjustedAcrossSplit(t *testing.T) {
+	f := yahooFixtureServer(t)
+
+	raw, err := f.FetchDailyBars("SPLIT", 300)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Adjusted = true
+	adj, err := f.FetchDailyBars("SPLIT", 300)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(adj) != 260 || len(raw) != 260 {
+		t.Fatalf("got %d adjusted and %d raw bars, want 260", len(adj), len(raw))
+	}
+
+	// The raw series drops by three quarters on the split; the adjusted one
+	// moves like any other day.
+	for i := 1; i < len(adj); i++ {
+		if move := adj[i].Close/adj[i-1].Close - 1; move > 0.01 || move < -0.01 {
+			t.Fatalf("adjusted close jumps %.1f%% on %s", move*100, adj[i].Time.Format("2006-01-02"))
+		}
+	}
+	if b := adj[10]; b.High < b.Close || b.Low > b.Close || math.Abs(b.Open-raw[10].Open/4) > 1e-9 {
+		t.Errorf("open/high/low not scaled with the close: %+v from %+v", b, raw[10])
+	}
+
+	last := adj[len(adj)-1].Close
+	ma, err := calculator.CalculateMA200(adj)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ma > last || ma < last*0.9 {
+		t.Errorf("adjusted MA200 %.2f should sit just below the last close %.2f", ma, last)
+	}
+	if rawMA, _ := calculator.CalculateMA200(raw); rawMA < last*1.5 {
+		t.Errorf("raw MA200 %.2f should be distorted by the split", rawMA)
+	}
+
+	// Indices have no adjusted closes and keep raw prices.
+	gspc, err := f.FetchDailyBars("SPX500", 400)
+	if err != nil || gspc[399].Close != 4700+503*1.5 {
+		t.Errorf("index series changed under Adjusted: %v", err)
+	}
+}
+
+func TestNewYahooFetcher_SymbolMapOverride(t *testing.T) {
+	f := NewYahooFetcher(nil, map[string]string{"SPX500": "SPY", "CSI300": "000300.SS"})
+	for symbol, want := range map[string]string{
+		"SPX500": "SPY",       // config wins over the built-in ^GSPC
+		"SPX":    "^GSPC",     // other defaults kept
+		"CSI300": "000300.SS", // new alias
+		"^N225":  "^N225",     // unknown symbols pass through
+	} {
+		if got := f.yahooSymbol(symbol); got != want {
+			t.Errorf("yahooSymbol(%q) = %q, want %q", symbol, got, want)
+		}
+	}
+	if NewYahooFetcher(nil, nil).yahooSymbol("SPX500") != "^GSPC" {
+		t.Error("building with an override changed the defaults of other fetchers")
+	}
+}
@@ -1,8 +1,15 @@
 package config
 
 import (
+	"errors"
 	"fmt"
+	"maps"
+	"math"
 	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
 
 	"gopkg.in/yaml.v3"
 )
@@ -12,30 +19,299 @@ type Config struct {
 	Telegram struct {
 		BotToken string `yaml:"bot_token"`
 		ChatID   string `yaml:"chat_id"`
+		// ThreadReplies sends weekly follow-ups as replies to the main report.
+		ThreadReplies bool `yaml:"thread_replies"`
+		// CompactNeutralBand sends the one-paragraph weekly report when
+		// |score| is within it and nothing needs attention; 0 always sends
+		// the detailed report.
+		CompactNeutralBand float64 `yaml:"compact_neutral_band"`
+		// MessageThreadID posts into a forum topic; 0 uses the main chat.
+		MessageThreadID int64 `yaml:"message_thread_id"`
+		// ChatStateFile keeps the new chat id after the group is upgraded to a
+		// supergroup, so the switch survives restarts.
+		ChatStateFile string `yaml:"chat_state_file"`
 	} `yaml:"telegram"`
 	DataSource struct {
-		BaseURL string `yaml:"base_url"`
-		APIKey  string `yaml:"api_key"`
-		Symbol  string `yaml:"symbol"`
+		// Provider selects the fetcher: yahoo, vstrader, parquet, binance, stooq,
+		// eastmoney, file or mock. Empty picks parquet when parquet_path is set,
+		// vstrader when base_url is set, else yahoo.
+		Provider string `yaml:"provider"`
+		BaseURL  string `yaml:"base_url"`
+		APIKey   string `yaml:"api_key"`
+		Symbol   string `yaml:"symbol"`
+		// AuxSymbol is a volatility index such as ^VIX reported alongside
+		// the indicators and scored by the volatility factor; empty
+		// disables both.
+		AuxSymbol string `yaml:"aux_symbol"`
+		// FXSymbol is a Yahoo exchange rate quoting yuan per unit of the
+		// symbol's currency, such as CNY=X for USD; the weekly report then
+		// shows amounts in both. Empty disables it.
+		FXSymbol string `yaml:"fx_symbol"`
+		// MockScenario is the scenario replayed by provider mock: a bundled
+		// name such as 2020-crash, or the path of a .json scenario file.
+		MockScenario string `yaml:"mock_scenario"`
+		// FRED fetches the 10-year yield from the St. Louis Fed; an empty
+		// API key disables it.
+		FRED struct {
+			APIKey      string `yaml:"api_key"`
+			YieldSeries string `yaml:"yield_series"` // default DGS10
+		} `yaml:"fred"`
+		// SymbolMap maps internal symbols to the provider's tickers, e.g.
+		// CSI300: 000300.SS, over the fetcher's built-in aliases.
+		SymbolMap map[string]string `yaml:"symbol_map"`
+		// Symbols lists every symbol evaluated weekly. The first moves the
+		// fund and replaces Symbol; the rest are reported for reference,
+		// unless fund.allocation gives them a share of the budget.
+		Symbols []string `yaml:"symbols"`
+		// MaxRetries is how many times a failed HTTP request to Yahoo or
+		// vstrader is retried; 0 disables retries.
+		MaxRetries int `yaml:"max_retries"`
+		// UseAdjusted makes Yahoo bars split and dividend adjusted; series
+		// without adjusted closes, such as indices, stay raw.
+		UseAdjusted bool `yaml:"use_adjusted"`
+		// Quality flags stale or gap-ridden daily data in the weekly report.
+		Quality struct {
+			// MaxStaleDays is the largest accepted age of the newest daily
+			// bar; 0 disables the check.
+			MaxStaleDays int `yaml:"max_stale_days"`
+			// MaxGapSessions is the most trading sessions that may be
+			// missing between two daily bars; 0 disables the check.
+			MaxGapSessions int `yaml:"max_gap_sessions"`
+			// Reject fails the run instead of warning.
+			Reject bool `yaml:"reject"`
+		} `yaml:"quality"`
+		// BarCache keeps fetched bars in the SQLite database, so each run
+		// only requests the bars added since the last one.
+		BarCache struct {
+			Enabled bool `yaml:"enabled"`
+			// TTLMinutes is how long the newest cached bar is served
+			// before it is refreshed.
+			TTLMinutes int `yaml:"ttl_minutes"`
+		} `yaml:"bar_cache"`
+		// Calendar decides which days the daily check runs on.
+		Calendar struct {
+			// Exchange is nyse, sse or crypto; empty picks it from the
+			// provider: binance trades every day, eastmoney is sse.
+			Exchange string `yaml:"exchange"`
+			// Holidays are extra closed dates, 2006-01-02. A-share
+			// holidays are announced yearly and must be listed here.
+			Holidays []string `yaml:"holidays"`
+		} `yaml:"calendar"`
+		// Secondary is a verification source: the current price and latest
+		// daily close are cross-checked against it and a divergence is
+		// flagged and alerted. An empty provider disables it.
+		Secondary struct {
+			Provider string `yaml:"provider"` // yahoo, vstrader, stooq, binance or eastmoney
+			BaseURL  string `yaml:"base_url"`
+			APIKey   string `yaml:"api_key"`
+			// TolerancePct is the largest accepted difference in percent.
+			TolerancePct float64 `yaml:"tolerance_pct"`
+		} `yaml:"secondary"`
+		// ParquetPath points at a local parquet file or symbol-partitioned directory.
+		ParquetPath    string `yaml:"parquet_path"`
+		ParquetColumns struct {
+			Date   string `yaml:"date"`
+			Open   string `yaml:"open"`
+			High   string `yaml:"high"`
+			Low    string `yaml:"low"`
+			Close  string `yaml:"close"`
+			Volume string `yaml:"volume"`
+		} `yaml:"parquet_columns"`
+		// File configures the file provider, reading CSV or JSON bars from disk.
+		File struct {
+			DailyPath  string `yaml:"daily_path"`  // "{symbol}" is replaced by the symbol
+			WeeklyPath string `yaml:"weekly_path"` // empty aggregates the daily bars
+			AsOf       string `yaml:"as_of"`       // 2006-01-02; hides bars after that day
+		} `yaml:"file"`
 	} `yaml:"data_source"`
 	Schedule struct {
 		WeeklyCron  string `yaml:"weekly_cron"`
 		DailyCron   string `yaml:"daily_cron"`
 		MonthlyCron string `yaml:"monthly_cron"`
+		RecapCron   string `yaml:"recap_cron"` // optional end-of-week recap, empty disables
+		// JitterSeconds bounds a random start offset for data-fetching tasks, 0 disables.
+		JitterSeconds int `yaml:"jitter_seconds"`
+		// WeeklyBudgetSeconds bounds data collection in the weekly run; stages
+		// still running then fall back to cached data.
+		WeeklyBudgetSeconds int `yaml:"weekly_budget_seconds"`
 	} `yaml:"schedule"`
 	Fund struct {
 		MonthlyBudget float64 `yaml:"monthly_budget"`
 		StateFile     string  `yaml:"state_file"`
+		// StateBackend keeps the fund state in StateFile ("json", the
+		// default) or in the database.sqlite_path database ("sqlite"), which
+		// imports an existing StateFile on first use.
+		StateBackend string `yaml:"state_backend"`
+		// RegularRatio is the share of the monthly budget sent to the regular
+		// pool, the reserve pool getting the rest. A change only applies to
+		// later replenishments, not to the balances already in the state file.
+		RegularRatio float64 `yaml:"regular_ratio"`
+		// WeeksPerMonth divides the regular share into the weekly base N of a
+		// fresh fund.
+		WeeksPerMonth float64 `yaml:"weeks_per_month"`
+		AdaptiveSplit struct {
+			Enabled         bool    `yaml:"enabled"`
+			MinReserveShare float64 `yaml:"min_reserve_share"`
+			MaxReserveShare float64 `yaml:"max_reserve_share"`
+		} `yaml:"adaptive_split"`
+		// MaxWeeklyDeployMultiple caps weekly + bottom-fish deployment per ISO week
+		// at this multiple of the weekly base N. Zero disables the ceiling.
+		MaxWeeklyDeployMultiple float64 `yaml:"max_weekly_deploy_multiple"`
+		// ReserveFloorMultiple keeps this multiple of N in the reserve pool
+		// against weekly and bottom-fish deployments. Zero disables the floor.
+		ReserveFloorMultiple float64 `yaml:"reserve_floor_multiple"`
+		// MaxQuarterlyReserveMultiple caps the reserve deployed per calendar
+		// quarter at this multiple of N. Zero disables the cap.
+		MaxQuarterlyReserveMultiple float64 `yaml:"max_quarterly_reserve_multiple"`
+		// PauseReplenish stops the monthly replenish too while investing is
+		// paused with /pause; by default the budget keeps being credited.
+		PauseReplenish bool `yaml:"pause_replenish"`
+		// RegularCapMonths caps the regular pool at this many months of its
+		// share of the budget, the monthly replenish carrying the surplus
+		// over to the reserve pool. Zero disables the cap.
+		RegularCapMonths float64 `yaml:"regular_cap_months"`
+		// Rounding rounds weekly and bottom-fish amounts to multiples of
+		// Increment by Mode (nearest, down or up) before the pools are
+		// debited. A zero increment disables it.
+		Rounding struct {
+			Mode      string  `yaml:"mode"`
+			Increment float64 `yaml:"increment"`
+		} `yaml:"rounding"`
+		// Allocation divides the monthly budget between symbols of
+		// data_source.symbols, e.g. SPX500: 0.6 and NDX100: 0.4, each
+		// investing from pools of its own. The shares must include the first
+		// symbol and sum to 1; empty puts the whole budget on the first.
+		Allocation map[string]float64 `yaml:"allocation"`
+		// LowParticipationNudgeWeeks adds a weekly-report reminder after this many
+		// consecutive weeks below 1.0×. Zero disables it.
+		LowParticipationNudgeWeeks int `yaml:"low_participation_nudge_weeks"`
+		// BottomFishLevelMultipliers scale the bottom-fish amount of level 1,
+		// 2, ... on top of the score-based multiplier.
+		BottomFishLevelMultipliers []float64 `yaml:"bottom_fish_level_multipliers"`
 	} `yaml:"fund"`
+	Strategy struct {
+		Seasonal struct {
+			Enabled bool    `yaml:"enabled"`
+			Weight  float64 `yaml:"weight"` // at most 0.05
+			// Tilts overrides the built-in raw score for a month (1-12), capped to ±0.3.
+			Tilts map[int]float64 `yaml:"tilts"`
+		} `yaml:"seasonal"`
+		// MACD scores turns of the weekly MACD histogram with this weight on
+		// top of the core factors; zero leaves it out.
+		MACD struct {
+			Weight float64 `yaml:"weight"`
+		} `yaml:"macd"`
+		// MA50w scores the deviation from the 50-week MA with this weight on
+		// top of the core factors; zero leaves it out.
+		MA50w struct {
+			Weight float64 `yaml:"weight"`
+		} `yaml:"ma50w"`
+		// Smoothing maps the tier from Alpha times this week's score plus
+		// 1-Alpha times the average of the previous two, damping noise
+		// around a tier boundary.
+		Smoothing struct {
+			Enabled bool    `yaml:"enabled"`
+			Alpha   float64 `yaml:"alpha"`
+		} `yaml:"smoothing"`
+		// Cooldown keeps 极限重仓 and 重仓买入 from being issued again within
+		// Weeks weekly runs unless the score clears the tier's minimum by
+		// Margin; otherwise the tier is stepped down one. Zero weeks disables it.
+		Cooldown struct {
+			Weeks  int     `yaml:"weeks"`
+			Margin float64 `yaml:"margin"`
+		} `yaml:"cooldown"`
+		// MinConfidence steps 极限重仓 and 重仓买入 down one tier when the
+		// factors agree with the score less than this, from 0 to 1; zero
+		// disables it.
+		MinConfidence float64 `yaml:"min_confidence"`
+		// BearBoost raises the regular multiplier by Multiplier, instead of
+		// drawing on the reserve pool, once a bear market has scored above
+		// 1.0 for Weeks weekly runs in a row. Zero weeks disables it.
+		BearBoost struct {
+			Weeks      int     `yaml:"weeks"`
+			Multiplier float64 `yaml:"multiplier"`
+		} `yaml:"bear_boost"`
+		// Thresholds are the RSI levels of the daily bottom-fish trigger and
+		// the take-profit warning, in the weekly report and the daily check.
+		Thresholds struct {
+			BottomFishRSI float64 `yaml:"bottom_fish_rsi"` // daily RSI below this bottom-fishes
+			TakeProfitRSI float64 `yaml:"take_profit_rsi"` // daily or weekly RSI above this warns
+			// DeeperBottomFishRSI are lower daily RSIs, descending, starting
+			// bottom-fish levels 2, 3, ...; each fires at most once a week.
+			DeeperBottomFishRSI []float64 `yaml:"deeper_bottom_fish_rsi"`
+			// A sell recommendation of SellPercent of the position is made
+			// when the score is at or below SellScore (negative) and the
+			// daily or weekly RSI is above SellRSI.
+			SellScore   float64 `yaml:"sell_score"`
+			SellRSI     float64 `yaml:"sell_rsi"`
+			SellPercent float64 `yaml:"sell_percent"`
+		} `yaml:"thresholds"`
+		// DisabledFactors leaves factors out of the score by registry key,
+		// e.g. daily_rsi; the remaining core weights are scaled up to 1.
+		DisabledFactors []string `yaml:"disabled_factors"`
+		// Weights overrides core factor weights by registry key before they
+		// are scaled to 1, e.g. drawdown: 0 for the original five factors.
+		Weights map[string]float64 `yaml:"weights"`
+	} `yaml:"strategy"`
+	Indicators struct {
+		// WeeklyFromDaily builds weekly bars from the daily bars instead of
+		// fetching them, so the weekly RSI does not change with how a source
+		// buckets partial weeks.
+		WeeklyFromDaily bool `yaml:"weekly_from_daily"`
+		// PercentileMinHistory is the fewest past values the weekly RSI and
+		// MA200 deviation are ranked against before their percentiles are
+		// reported; 0 uses the collector's default of 52.
+		PercentileMinHistory int `yaml:"percentile_min_history"`
+	} `yaml:"indicators"`
 	Database struct {
 		SQLitePath string `yaml:"sqlite_path"`
+		// PeriodDedup handles monthly/quarterly events recorded twice in one
+		// period: "update" (default), "skip" or "off".
+		PeriodDedup string `yaml:"period_dedup"`
+		// BarsArchive keeps the raw bars behind each weekly evaluation so a
+		// signal can be reproduced later.
+		BarsArchive struct {
+			Enabled bool `yaml:"enabled"`
+			// Keep is how many of the most recent evaluations keep their bars.
+			Keep int `yaml:"keep"`
+		} `yaml:"bars_archive"`
 	} `yaml:"database"`
-	Proxy string `yaml:"proxy"`
+	Metrics struct {
+		Enabled       bool   `yaml:"enabled"`
+		SampleCron    string `yaml:"sample_cron"`
+		RetentionDays int    `yaml:"retention_days"` // 0 keeps samples forever
+	} `yaml:"metrics"`
+	// GapAlert is the optional pre-market gap check; an empty Cron disables it.
+	GapAlert struct {
+		Cron         string  `yaml:"cron"`
+		Symbol       string  `yaml:"symbol"`        // fetched through Yahoo, e.g. ES=F
+		ThresholdPct float64 `yaml:"threshold_pct"` // alert when |gap| reaches this
+	} `yaml:"gap_alert"`
+	Proxy ProxyConfig `yaml:"proxy"`
+	// DataDir holds the fund state, SQLite database and chat state unless
+	// their paths are set explicitly.
+	DataDir string `yaml:"data_dir"`
+
+	envErrs []error // unparseable environment overrides, reported by Validate
 }
 
-// Load reads config from a YAML file, then applies environment variable overrides.
+// Defaults matching collector.DefaultRetryPolicy and
+// collector.DefaultQualityPolicy.
+const (
+	defaultMaxRetries     = 2
+	defaultMaxStaleDays   = 5
+	defaultMaxGapSessions = 3
+)
+
+// Load reads config from a YAML file, then applies environment variable
+// overrides. A missing file is not an error, so the bot can run from the
+// environment alone.
 func Load(path string) (*Config, error) {
 	cfg := &Config{}
+	// Set before parsing, since an explicit 0 is meaningful.
+	cfg.DataSource.MaxRetries = defaultMaxRetries
+	cfg.DataSource.Quality.MaxStaleDays = defaultMaxStaleDays
+	cfg.DataSource.Quality.MaxGapSessions = defaultMaxGapSessions
 
 	data, err := os.ReadFile(path)
 	if err != nil && !os.IsNotExist(err) {
@@ -54,32 +330,61 @@ func Load(path string) (*Config, error) {
 	if v := os.Getenv("TELEGRAM_CHAT_ID"); v != "" {
 		cfg.Telegram.ChatID = v
 	}
+	if v := os.Getenv("DATA_PROVIDER"); v != "" {
+		cfg.DataSource.Provider = v
+	}
 	if v := os.Getenv("VSTRADER_BASE_URL"); v != "" {
 		cfg.DataSource.BaseURL = v
 	}
 	if v := os.Getenv("VSTRADER_API_KEY"); v != "" {
 		cfg.DataSource.APIKey = v
 	}
+	if v := os.Getenv("PARQUET_PATH"); v != "" {
+		cfg.DataSource.ParquetPath = v
+	}
+	if v := os.Getenv("MOCK_SCENARIO"); v != "" {
+		cfg.DataSource.MockScenario = v
+	}
+	if v := os.Getenv("FRED_API_KEY"); v != "" {
+		cfg.DataSource.FRED.APIKey = v
+	}
 	if v := os.Getenv("HTTPS_PROXY"); v != "" {
-		cfg.Proxy = v
+		cfg.Proxy.URL = v
 	}
 	if v := os.Getenv("MONTHLY_BUDGET"); v != "" {
 		var budget float64
 		if _, err := fmt.Sscanf(v, "%f", &budget); err == nil {
 			cfg.Fund.MonthlyBudget = budget
+		} else {
+			cfg.envErrs = append(cfg.envErrs, fmt.Errorf("MONTHLY_BUDGET=%q is not a number", v))
 		}
 	}
 	if v := os.Getenv("CRON_WEEKLY"); v != "" {
 		cfg.Schedule.WeeklyCron = v
 	}
+	if v := os.Getenv("CRON_RECAP"); v != "" {
+		cfg.Schedule.RecapCron = v
+	}
+	if v := os.Getenv("DATA_DIR"); v != "" {
+		cfg.DataDir = v
+	}
 	if v := os.Getenv("SQLITE_PATH"); v != "" {
 		cfg.Database.SQLitePath = v
 	}
+	if v := os.Getenv("METRICS_ENABLED"); v != "" {
+		cfg.Metrics.Enabled = v == "true"
+	}
 
 	// Defaults
+	if len(cfg.DataSource.Symbols) > 0 {
+		cfg.DataSource.Symbol = cfg.DataSource.Symbols[0]
+	}
 	if cfg.DataSource.Symbol == "" {
 		cfg.DataSource.Symbol = "SPX500"
 	}
+	if len(cfg.DataSource.Symbols) == 0 {
+		cfg.DataSource.Symbols = []string{cfg.DataSource.Symbol}
+	}
 	if cfg.Schedule.WeeklyCron == "" {
 		cfg.Schedule.WeeklyCron = "0 0 8 * * 1"
 	}
@@ -89,29 +394,320 @@ func Load(path string) (*Config, error) {
 	if cfg.Schedule.MonthlyCron == "" {
 		cfg.Schedule.MonthlyCron = "0 0 9 1 * *"
 	}
+	if cfg.DataSource.BarCache.TTLMinutes == 0 {
+		cfg.DataSource.BarCache.TTLMinutes = 15
+	}
+	if cfg.DataSource.FRED.YieldSeries == "" {
+		cfg.DataSource.FRED.YieldSeries = "DGS10"
+	}
+	if cfg.DataSource.Secondary.TolerancePct == 0 {
+		cfg.DataSource.Secondary.TolerancePct = 0.5
+	}
+	if cfg.Schedule.WeeklyBudgetSeconds == 0 {
+		cfg.Schedule.WeeklyBudgetSeconds = 120
+	}
 	if cfg.Fund.MonthlyBudget == 0 {
 		cfg.Fund.MonthlyBudget = 10000
 	}
+	if cfg.DataDir == "" {
+		cfg.DataDir = "data"
+	}
+	if cfg.Telegram.ChatStateFile == "" {
+		cfg.Telegram.ChatStateFile = filepath.Join(cfg.DataDir, "telegram_chat.json")
+	}
 	if cfg.Fund.StateFile == "" {
-		cfg.Fund.StateFile = "data/fund_state.json"
+		cfg.Fund.StateFile = filepath.Join(cfg.DataDir, "fund_state.json")
+	}
+	if cfg.Fund.RegularRatio == 0 {
+		cfg.Fund.RegularRatio = 0.70
+	}
+	if cfg.Fund.WeeksPerMonth == 0 {
+		cfg.Fund.WeeksPerMonth = 4.33
+	}
+	if cfg.Fund.AdaptiveSplit.MinReserveShare == 0 {
+		cfg.Fund.AdaptiveSplit.MinReserveShare = 0.20
+	}
+	if cfg.Fund.AdaptiveSplit.MaxReserveShare == 0 {
+		cfg.Fund.AdaptiveSplit.MaxReserveShare = 0.40
+	}
+	if cfg.Strategy.Thresholds.BottomFishRSI == 0 {
+		cfg.Strategy.Thresholds.BottomFishRSI = 30
+	}
+	if cfg.Strategy.Thresholds.DeeperBottomFishRSI == nil {
+		cfg.Strategy.Thresholds.DeeperBottomFishRSI = []float64{25, 20}
+	}
+	if cfg.Fund.BottomFishLevelMultipliers == nil {
+		cfg.Fund.BottomFishLevelMultipliers = []float64{1.0, 1.5, 2.0}
+	}
+	if cfg.Strategy.Thresholds.SellScore == 0 {
+		cfg.Strategy.Thresholds.SellScore = -1.2
+	}
+	if cfg.Strategy.Thresholds.SellRSI == 0 {
+		cfg.Strategy.Thresholds.SellRSI = 85
+	}
+	if cfg.Strategy.Thresholds.SellPercent == 0 {
+		cfg.Strategy.Thresholds.SellPercent = 10
+	}
+	if cfg.Strategy.Thresholds.TakeProfitRSI == 0 {
+		cfg.Strategy.Thresholds.TakeProfitRSI = 85
+	}
+	if cfg.Strategy.Smoothing.Alpha == 0 {
+		cfg.Strategy.Smoothing.Alpha = 0.5
+	}
+	if cfg.Strategy.Seasonal.Weight == 0 {
+		cfg.Strategy.Seasonal.Weight = 0.05
 	}
 	if cfg.Database.SQLitePath == "" {
-		cfg.Database.SQLitePath = "data/market_sentinel.db"
+		cfg.Database.SQLitePath = filepath.Join(cfg.DataDir, "market_sentinel.db")
+	}
+	if cfg.Database.BarsArchive.Keep == 0 {
+		cfg.Database.BarsArchive.Keep = 12
+	}
+	if cfg.GapAlert.Symbol == "" {
+		cfg.GapAlert.Symbol = "ES=F"
+	}
+	if cfg.GapAlert.ThresholdPct == 0 {
+		cfg.GapAlert.ThresholdPct = 3
+	}
+	if cfg.Metrics.SampleCron == "" {
+		cfg.Metrics.SampleCron = "0 0 * * * *"
 	}
 
 	return cfg, nil
 }
 
-// Validate checks that all required fields are set.
+// Validate checks that all required fields are set and every value is in
+// range. All problems are reported together, one per line.
 func (c *Config) Validate() error {
+	errs := append([]error(nil), c.envErrs...)
+	fail := func(format string, args ...any) {
+		errs = append(errs, fmt.Errorf(format, args...))
+	}
+
 	if c.Telegram.BotToken == "" {
-		return fmt.Errorf("telegram.bot_token is required")
+		fail("telegram.bot_token is required (or TELEGRAM_BOT_TOKEN)")
 	}
 	if c.Telegram.ChatID == "" {
-		return fmt.Errorf("telegram.chat_id is required")
+		fail("telegram.chat_id is required (or TELEGRAM_CHAT_ID)")
+	}
+	switch c.DataSource.Provider {
+	case "", "yahoo", "binance", "stooq", "eastmoney":
+	case "file":
+		if c.DataSource.File.DailyPath == "" {
+			fail("data_source.file.daily_path is required for provider file")
+		}
+	case "vstrader":
+		if c.DataSource.BaseURL == "" {
+			fail("data_source.base_url is required for provider vstrader (or VSTRADER_BASE_URL)")
+		}
+	case "parquet":
+		if c.DataSource.ParquetPath == "" {
+			fail("data_source.parquet_path is required for provider parquet (or PARQUET_PATH)")
+		}
+	case "mock":
+		if c.DataSource.MockScenario == "" {
+			fail("data_source.mock_scenario is required for provider mock (or MOCK_SCENARIO)")
+		}
+	default:
+		fail("data_source.provider must be yahoo, vstrader, parquet, binance, stooq, eastmoney, file or mock")
+	}
+	if s := c.DataSource.File.AsOf; s != "" {
+		if _, err := time.Parse("2006-01-02", s); err != nil {
+			fail("data_source.file.as_of must be a 2006-01-02 date, got %q", s)
+		}
+	}
+	seen := make(map[string]bool, len(c.DataSource.Symbols))
+	for _, sym := range c.DataSource.Symbols {
+		if sym == "" || seen[sym] {
+			fail("data_source.symbols must not contain empty or duplicate entries, got %q", c.DataSource.Symbols)
+			break
+		}
+		seen[sym] = true
+	}
+	for _, sym := range slices.Sorted(maps.Keys(c.DataSource.SymbolMap)) {
+		if strings.TrimSpace(c.DataSource.SymbolMap[sym]) == "" {
+			fail("data_source.symbol_map.%s must not be empty", sym)
+		}
+	}
+	if c.DataSource.MaxRetries < 0 {
+		fail("data_source.max_retries must not be negative")
+	}
+	if q := c.DataSource.Quality; q.MaxStaleDays < 0 || q.MaxGapSessions < 0 {
+		fail("data_source.quality.max_stale_days and max_gap_sessions must not be negative")
+	}
+	if c.DataSource.BarCache.TTLMinutes < 0 {
+		fail("data_source.bar_cache.ttl_minutes must not be negative")
+	}
+	switch c.DataSource.Calendar.Exchange {
+	case "", "nyse", "sse", "crypto":
+	default:
+		fail("data_source.calendar.exchange must be nyse, sse or crypto")
+	}
+	for _, d := range c.DataSource.Calendar.Holidays {
+		if _, err := time.Parse("2006-01-02", d); err != nil {
+			fail("data_source.calendar.holidays: %q is not a 2006-01-02 date", d)
+		}
+	}
+	switch sec := c.DataSource.Secondary; sec.Provider {
+	case "", "yahoo", "stooq", "binance", "eastmoney":
+	case "vstrader":
+		if sec.BaseURL == "" {
+			fail("data_source.secondary.base_url is required for provider vstrader")
+		}
+	default:
+		fail("data_source.secondary.provider must be yahoo, vstrader, stooq, binance or eastmoney")
+	}
+	if c.DataSource.Secondary.TolerancePct < 0 {
+		fail("data_source.secondary.tolerance_pct must not be negative")
 	}
 	if c.Fund.MonthlyBudget <= 0 {
-		return fmt.Errorf("fund.monthly_budget must be positive")
+		fail("fund.monthly_budget must be positive")
+	}
+	if c.Fund.MaxWeeklyDeployMultiple < 0 {
+		fail("fund.max_weekly_deploy_multiple must not be negative")
+	}
+	if c.Fund.ReserveFloorMultiple < 0 {
+		fail("fund.reserve_floor_multiple must not be negative")
+	}
+	if c.Fund.MaxQuarterlyReserveMultiple < 0 {
+		fail("fund.max_quarterly_reserve_multiple must not be negative")
+	}
+	switch c.Fund.StateBackend {
+	case "", "json", "sqlite":
+	default:
+		fail("fund.state_backend must be json or sqlite")
+	}
+	if m := c.Fund.RegularCapMonths; m != 0 && !(m >= 1) {
+		fail("fund.regular_cap_months must be 0 or at least 1")
+	}
+	switch c.Fund.Rounding.Mode {
+	case "", "nearest", "down", "up":
+	default:
+		fail("fund.rounding.mode must be nearest, down or up")
+	}
+	if c.Fund.Rounding.Increment < 0 {
+		fail("fund.rounding.increment must not be negative")
+	}
+	if alloc := c.Fund.Allocation; len(alloc) > 0 {
+		sum := 0.0
+		for _, sym := range slices.Sorted(maps.Keys(alloc)) {
+			if !slices.Contains(c.DataSource.Symbols, sym) {
+				fail("fund.allocation.%s is not in data_source.symbols", sym)
+			}
+			if !(alloc[sym] > 0) {
+				fail("fund.allocation.%s must be positive", sym)
+			}
+			sum += alloc[sym]
+		}
+		if _, ok := alloc[c.DataSource.Symbol]; !ok {
+			fail("fund.allocation must include the primary symbol %s", c.DataSource.Symbol)
+		}
+		if math.Abs(sum-1) > 1e-6 {
+			fail("fund.allocation shares must sum to 1, got %.6g", sum)
+		}
+	}
+	if c.Fund.LowParticipationNudgeWeeks < 0 {
+		fail("fund.low_participation_nudge_weeks must not be negative")
+	}
+	if sz := c.Strategy.Seasonal; sz.Enabled {
+		if sz.Weight < 0 || sz.Weight > 0.05 {
+			fail("strategy.seasonal.weight must be between 0 and 0.05")
+		}
+		for m := range sz.Tilts {
+			if m < 1 || m > 12 {
+				fail("strategy.seasonal.tilts: invalid month %d", m)
+			}
+		}
+	}
+	if th := c.Strategy.Thresholds; th.BottomFishRSI <= 0 || th.TakeProfitRSI >= 100 || th.BottomFishRSI >= th.TakeProfitRSI {
+		fail("strategy.thresholds: need 0 < bottom_fish_rsi < take_profit_rsi < 100")
+	}
+	if th := c.Strategy.Thresholds; th.SellScore >= 0 || th.SellScore < -2 || th.SellRSI <= 0 || th.SellRSI >= 100 ||
+		th.SellPercent <= 0 || th.SellPercent > 50 {
+		fail("strategy.thresholds: need -2 <= sell_score < 0, 0 < sell_rsi < 100 and 0 < sell_percent <= 50")
+	}
+	prev := c.Strategy.Thresholds.BottomFishRSI
+	for _, rsi := range c.Strategy.Thresholds.DeeperBottomFishRSI {
+		if rsi <= 0 || rsi >= prev {
+			fail("strategy.thresholds.deeper_bottom_fish_rsi must descend below bottom_fish_rsi and stay positive")
+			break
+		}
+		prev = rsi
+	}
+	for _, mult := range c.Fund.BottomFishLevelMultipliers {
+		if mult <= 0 {
+			fail("fund.bottom_fish_level_multipliers must be positive")
+			break
+		}
+	}
+	if sm := c.Strategy.Smoothing; sm.Enabled && (sm.Alpha <= 0 || sm.Alpha > 1) {
+		fail("strategy.smoothing.alpha must be in (0, 1]")
+	}
+	if cd := c.Strategy.Cooldown; cd.Weeks < 0 || cd.Margin < 0 {
+		fail("strategy.cooldown.weeks and margin must not be negative")
+	}
+	if m := c.Strategy.MinConfidence; m < 0 || m >= 1 {
+		fail("strategy.min_confidence must be in [0, 1), got %v", m)
+	}
+	if bb := c.Strategy.BearBoost; bb.Weeks < 0 || (bb.Weeks > 0 && (bb.Multiplier < 1 || bb.Multiplier > 3)) {
+		fail("strategy.bear_boost.weeks must not be negative and multiplier must be in [1, 3]")
+	}
+	if c.Strategy.MA50w.Weight < 0 {
+		fail("strategy.ma50w.weight must not be negative")
+	}
+	if c.Strategy.MACD.Weight < 0 {
+		fail("strategy.macd.weight must not be negative")
+	}
+	for name, w := range c.Strategy.Weights {
+		if w < 0 {
+			fail("strategy.weights.%s must not be negative", name)
+		}
+	}
+	switch c.Database.PeriodDedup {
+	case "", "update", "skip", "off":
+	default:
+		fail("database.period_dedup must be update, skip or off")
+	}
+	if c.Indicators.PercentileMinHistory < 0 {
+		fail("indicators.percentile_min_history must not be negative")
+	}
+	if c.Database.BarsArchive.Keep < 0 {
+		fail("database.bars_archive.keep must not be negative")
+	}
+	if c.Telegram.CompactNeutralBand < 0 {
+		fail("telegram.compact_neutral_band must not be negative")
+	}
+	if c.Schedule.JitterSeconds < 0 {
+		fail("schedule.jitter_seconds must not be negative")
+	}
+	if c.Schedule.WeeklyBudgetSeconds < 0 {
+		fail("schedule.weekly_budget_seconds must not be negative")
+	}
+	if c.GapAlert.ThresholdPct < 0 {
+		fail("gap_alert.threshold_pct must not be negative")
+	}
+	if c.Metrics.RetentionDays < 0 {
+		fail("metrics.retention_days must not be negative")
+	}
+	for _, p := range []struct{ key, url string }{
+		{"proxy", c.Proxy.URL}, {"proxy.telegram", c.Proxy.Telegram}, {"proxy.data_source", c.Proxy.DataSource},
+	} {
+		if _, err := ParseProxyURL(p.url); err != nil {
+			fail("%s %q: %v", p.key, p.url, err)
+		}
+	}
+	if r := c.Fund.RegularRatio; !(r > 0 && r < 1) {
+		fail("fund.regular_ratio %v must be between 0 and 1 exclusive", r)
+	}
+	if !(c.Fund.WeeksPerMonth > 0) {
+		fail("fund.weeks_per_month %v must be positive", c.Fund.WeeksPerMonth)
+	}
+	if as := c.Fund.AdaptiveSplit; as.Enabled {
+		// The static reserve share is the complement of the regular ratio.
+		reserve := 1 - c.Fund.RegularRatio
+		if as.MinReserveShare < 0 || as.MaxReserveShare > 1 || as.MinReserveShare > reserve || as.MaxReserveShare < reserve {
+			fail("fund.adaptive_split shares must satisfy 0 <= min <= %.2f (1 - fund.regular_ratio) <= max <= 1", reserve)
+		}
 	}
-	return nil
+	return errors.Join(errs...)
 }
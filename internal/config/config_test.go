@@ -0,0 +1,233 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoad_DataDirDerivesPaths(t *testing.T) {
+	t.Setenv("DATA_DIR", "/var/lib/sentinel")
+	t.Setenv("SQLITE_PATH", "")
+	cfg, err := Load(filepath.Join(t.TempDir(), "missing.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, got := range map[string]string{
+		"fund.state_file":          cfg.Fund.StateFile,
+		"database.sqlite_path":     cfg.Database.SQLitePath,
+		"telegram.chat_state_file": cfg.Telegram.ChatStateFile,
+	} {
+		if filepath.Dir(got) != "/var/lib/sentinel" {
+			t.Errorf("%s = %q, want it under DATA_DIR", name, got)
+		}
+	}
+}
+
+func TestValidate_AggregatesProblems(t *testing.T) {
+	t.Setenv("TELEGRAM_BOT_TOKEN", "")
+	t.Setenv("TELEGRAM_CHAT_ID", "")
+	t.Setenv("MONTHLY_BUDGET", "lots")
+	cfg, err := Load(filepath.Join(t.TempDir(), "missing.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.Schedule.JitterSeconds = -1
+
+	err = cfg.Validate()
+	if err == nil {
+		t.Fatal("expected validation to fail")
+	}
+	for _, want := range []string{"MONTHLY_BUDGET", "telegram.bot_token", "telegram.chat_id", "schedule.jitter_seconds"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error does not mention %s:\n%v", want, err)
+		}
+	}
+	if n := strings.Count(err.Error(), "\n") + 1; n != 4 {
+		t.Errorf("got %d problems, want 4:\n%v", n, err)
+	}
+}
+
+func TestLoad_MaxRetries(t *testing.T) {
+	dir := t.TempDir()
+	cfg, err := Load(filepath.Join(dir, "missing.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.DataSource.MaxRetries != 2 {
+		t.Errorf("default max_retries = %d, want 2", cfg.DataSource.MaxRetries)
+	}
+
+	path := filepath.Join(dir, "config.yaml")
+	if err := os.WriteFile(path, []byte("data_source:\n  max_retries: 0\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if cfg, err = Load(path); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.DataSource.MaxRetries != 0 {
+		t.Errorf("explicit max_retries 0 became %d", cfg.DataSource.MaxRetries)
+	}
+}
+
+func TestLoad_Symbols(t *testing.T) {
+	dir := t.TempDir()
+	cfg, err := Load(filepath.Join(dir, "missing.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.DataSource.Symbol != "SPX500" || len(cfg.DataSource.Symbols) != 1 || cfg.DataSource.Symbols[0] != "SPX500" {
+		t.Errorf("single-symbol default: symbol %q, symbols %q", cfg.DataSource.Symbol, cfg.DataSource.Symbols)
+	}
+
+	path := filepath.Join(dir, "config.yaml")
+	if err := os.WriteFile(path, []byte("data_source:\n  symbol: SPX500\n  symbols: [NDX, SPX500]\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if cfg, err = Load(path); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.DataSource.Symbol != "NDX" {
+		t.Errorf("symbol = %q, want the first of symbols", cfg.DataSource.Symbol)
+	}
+
+	cfg.Telegram.BotToken, cfg.Telegram.ChatID = "t", "c"
+	cfg.DataSource.Symbols = []string{"NDX", "NDX"}
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "duplicate") {
+		t.Errorf("expected duplicate symbols to be rejected, got %v", err)
+	}
+}
+
+func TestValidate_SymbolMap(t *testing.T) {
+	cfg, err := Load(filepath.Join(t.TempDir(), "missing.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.Telegram.BotToken, cfg.Telegram.ChatID = "t", "c"
+	cfg.DataSource.SymbolMap = map[string]string{"CSI300": "000300.SS", "GOLD": " "}
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "data_source.symbol_map.GOLD must not be empty") {
+		t.Errorf("expected the empty GOLD mapping to be rejected, got %v", err)
+	}
+}
+
+func TestLoad_Proxy(t *testing.T) {
+	t.Setenv("HTTPS_PROXY", "")
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	load := func(yaml string) *Config {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(yaml), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		cfg, err := Load(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cfg.Telegram.BotToken, cfg.Telegram.ChatID = "t", "c"
+		return cfg
+	}
+
+	// The old single string applies to both components.
+	cfg := load("proxy: http://10.0.0.1:3128\n")
+	if cfg.Proxy.ForTelegram() != "http://10.0.0.1:3128" || cfg.Proxy.ForDataSource() != "http://10.0.0.1:3128" {
+		t.Errorf("string proxy not shared: %+v", cfg.Proxy)
+	}
+
+	cfg = load("proxy:\n  url: http://10.0.0.1:3128\n  telegram: socks5://127.0.0.1:1080\n")
+	if cfg.Proxy.ForTelegram() != "socks5://127.0.0.1:1080" || cfg.Proxy.ForDataSource() != "http://10.0.0.1:3128" {
+		t.Errorf("per-component proxy: telegram %q, data source %q", cfg.Proxy.ForTelegram(), cfg.Proxy.ForDataSource())
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("valid proxies rejected: %v", err)
+	}
+
+	cfg = load("proxy:\n  telegram: ftp://127.0.0.1:21\n  data_source: \"http://[::1\"\n")
+	err := cfg.Validate()
+	if err == nil || !strings.Contains(err.Error(), `proxy.telegram "ftp://127.0.0.1:21": scheme must be`) ||
+		!strings.Contains(err.Error(), "proxy.data_source") {
+		t.Errorf("expected both invalid proxies reported, got %v", err)
+	}
+}
+
+func TestLoad_RSIThresholds(t *testing.T) {
+	dir := t.TempDir()
+	cfg, err := Load(filepath.Join(dir, "missing.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if th := cfg.Strategy.Thresholds; th.BottomFishRSI != 30 || th.TakeProfitRSI != 85 {
+		t.Errorf("default thresholds %+v, want 30/85", th)
+	}
+
+	path := filepath.Join(dir, "config.yaml")
+	if err := os.WriteFile(path, []byte("strategy:\n  thresholds:\n    bottom_fish_rsi: 90\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if cfg, err = Load(path); err != nil {
+		t.Fatal(err)
+	}
+	cfg.Telegram.BotToken, cfg.Telegram.ChatID = "t", "c"
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "strategy.thresholds") {
+		t.Errorf("expected bottom_fish_rsi above take_profit_rsi to be rejected, got %v", err)
+	}
+}
+
+func TestLoad_FundSplit(t *testing.T) {
+	dir := t.TempDir()
+	cfg, err := Load(filepath.Join(dir, "missing.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Fund.RegularRatio != 0.70 || cfg.Fund.WeeksPerMonth != 4.33 {
+		t.Errorf("default split %v over %v weeks, want 0.70 over 4.33", cfg.Fund.RegularRatio, cfg.Fund.WeeksPerMonth)
+	}
+
+	for yaml, want := range map[string]string{
+		"fund:\n  regular_ratio: 1\n":                                         "fund.regular_ratio 1 must be between 0 and 1",
+		"fund:\n  regular_ratio: -0.2\n":                                      "fund.regular_ratio -0.2 must be between 0 and 1",
+		"fund:\n  weeks_per_month: -4\n":                                      "fund.weeks_per_month -4 must be positive",
+		"fund:\n  regular_ratio: 0.5\n  adaptive_split:\n    enabled: true\n": "min <= 0.50 (1 - fund.regular_ratio) <= max",
+	} {
+		path := filepath.Join(dir, "config.yaml")
+		if err := os.WriteFile(path, []byte(yaml), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if cfg, err = Load(path); err != nil {
+			t.Fatal(err)
+		}
+		cfg.Telegram.BotToken, cfg.Telegram.ChatID = "t", "c"
+		if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%q: err = %v, want %q", yaml, err, want)
+		}
+	}
+}
+
+func TestValidate_FundAllocation(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	const symbols = "data_source:\n  symbols: [SPX500, NDX100, GOLD]\n"
+	for yaml, want := range map[string]string{
+		"fund:\n  allocation: {SPX500: 0.6, NDX100: 0.4}\n":            "",
+		"fund:\n  allocation: {SPX500: 0.6, NDX100: 0.3}\n":            "fund.allocation shares must sum to 1, got 0.9",
+		"fund:\n  allocation: {NDX100: 1}\n":                           "fund.allocation must include the primary symbol SPX500",
+		"fund:\n  allocation: {SPX500: 0.6, QQQ: 0.4}\n":               "fund.allocation.QQQ is not in data_source.symbols",
+		"fund:\n  allocation: {SPX500: 1.2, NDX100: -0.2}\n":           "fund.allocation.NDX100 must be positive",
+		"fund:\n  allocation: {SPX500: 0.5, NDX100: 0.3, GOLD: 0.2}\n": "",
+	} {
+		if err := os.WriteFile(path, []byte(symbols+yaml), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		cfg, err := Load(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cfg.Telegram.BotToken, cfg.Telegram.ChatID = "t", "c"
+		err = cfg.Validate()
+		if want == "" && err != nil {
+			t.Errorf("%q: %v", yaml, err)
+		} else if want != "" && (err == nil || !strings.Contains(err.Error(), want)) {
+			t.Errorf("%q: err = %v, want %q", yaml, err, want)
+		}
+	}
+}
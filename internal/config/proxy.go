@@ -0,0 +1,72 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ProxyConfig routes outbound HTTP through proxies. In YAML it is either a
+// single URL used for everything:
+//
+//	proxy: socks5://127.0.0.1:1080
+//
+// or a mapping with per-component URLs falling back to url:
+//
+//	proxy:
+//	  telegram: socks5://127.0.0.1:1080
+//	  data_source: ""
+//
+// http, https, socks5 and socks5h URLs are supported.
+type ProxyConfig struct {
+	URL        string `yaml:"url"`
+	Telegram   string `yaml:"telegram"`
+	DataSource string `yaml:"data_source"`
+}
+
+// UnmarshalYAML accepts the scalar form as URL.
+func (p *ProxyConfig) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		return node.Decode(&p.URL)
+	}
+	type plain ProxyConfig
+	return node.Decode((*plain)(p))
+}
+
+// ForTelegram returns the proxy for the Telegram API, or "" for none.
+func (p ProxyConfig) ForTelegram() string {
+	if p.Telegram != "" {
+		return p.Telegram
+	}
+	return p.URL
+}
+
+// ForDataSource returns the proxy for market data fetchers, or "" for none.
+func (p ProxyConfig) ForDataSource() string {
+	if p.DataSource != "" {
+		return p.DataSource
+	}
+	return p.URL
+}
+
+// ParseProxyURL parses raw as a proxy URL, returning nil for empty, or why
+// it is not a usable one.
+func ParseProxyURL(raw string) (*url.URL, error) {
+	if raw == "" {
+		return nil, nil
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, err
+	}
+	switch u.Scheme {
+	case "http", "https", "socks5", "socks5h":
+	default:
+		return nil, fmt.Errorf("scheme must be http, https, socks5 or socks5h, got %q", u.Scheme)
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("missing host")
+	}
+	return u, nil
+}
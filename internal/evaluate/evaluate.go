@@ -0,0 +1,58 @@
+// Package evaluate computes a week's indicators and signal purely from bars,
+// joining the collector's indicators to the strategy, which cannot import it.
+package evaluate
+
+import (
+	"errors"
+	"time"
+
+	"MarketSentinel/internal/collector"
+	"MarketSentinel/internal/model"
+	"MarketSentinel/internal/strategy"
+)
+
+// Config is what Series needs besides the bars.
+type Config struct {
+	Params strategy.Params
+	// Collector holds the indicator settings: the quality policy, the
+	// calendar of its Fetcher and the percentile history. Nothing is fetched
+	// through it and its RSI state is not used; nil is a collector with the
+	// defaults for equities.
+	Collector *collector.Collector
+}
+
+// Series computes the indicators of daily and weekly, oldest first, at
+// currentPrice as of asOf, and the weekly signal cfg.Params give them. It
+// reads neither the clock nor any state kept between runs, so re-running a
+// past week gives what was computed then unless the rules changed. The
+// signal is Params.EvaluateAt's alone: smoothing, cooldown, confidence and
+// the bear boost need the weeks before and are left to the caller. It fails
+// when cfg.Params are invalid or the quality policy rejects daily.
+func Series(daily, weekly []model.OHLCV, currentPrice float64, asOf time.Time, cfg Config) (*model.TradeSignal, *model.MarketIndicators, error) {
+	if err := cfg.Params.Validate(); err != nil {
+		return nil, nil, err
+	}
+	ind, err := Indicators(collector.Inputs{Daily: daily, Weekly: weekly, Price: currentPrice}, asOf, cfg.Collector)
+	if err != nil {
+		return nil, nil, err
+	}
+	return cfg.Params.EvaluateAt(ind, asOf), ind, nil
+}
+
+// Indicators is the indicator half of Series, for callers scoring the same
+// indicators with several Params, as a backtest sweep does: the indicators
+// of in as of asOf under the settings of col, nil for the defaults. The
+// collector's live Compute shares its computation, differing only in the
+// clock and in carrying the RSI smoothing over between runs.
+func Indicators(in collector.Inputs, asOf time.Time, col *collector.Collector) (*model.MarketIndicators, error) {
+	if asOf.IsZero() {
+		return nil, errors.New("evaluate: no as-of time")
+	}
+	if col == nil {
+		col = collector.NewCollector(nil, "")
+	}
+	if err := col.ValidateAt(in, asOf); err != nil {
+		return nil, err
+	}
+	return col.ComputeAt(in, asOf), nil
+}
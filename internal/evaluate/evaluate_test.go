@@ -0,0 +1,151 @@
+package evaluate
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"MarketSentinel/internal/calculator"
+	"MarketSentinel/internal/collector"
+	"MarketSentinel/internal/model"
+	"MarketSentinel/internal/strategy"
+)
+
+// fixtureBars are four years of weekday bars from Mon 2021-01-04: a slow
+// uptrend with a long cycle and a short one on top, ending in a pullback.
+func fixtureBars() []model.OHLCV {
+	var bars []model.OHLCV
+	i := 0
+	for d := time.Date(2021, 1, 4, 0, 0, 0, 0, time.UTC); d.Year() < 2025; d = d.AddDate(0, 0, 1) {
+		if d.Weekday() == time.Saturday || d.Weekday() == time.Sunday {
+			continue
+		}
+		x := float64(i)
+		c := 100 + 0.05*x + 15*math.Sin(x/60) + 3*math.Sin(x/7)
+		bars = append(bars, model.OHLCV{Time: d, Open: c - 0.5, High: c + 1, Low: c - 1, Close: c, Volume: 1e6 + 1e4*math.Cos(x/5)})
+		i++
+	}
+	return bars
+}
+
+// fixtureAsOf is the evening of the fixture's last session, a Tuesday.
+var fixtureAsOf = time.Date(2024, 12, 31, 18, 0, 0, 0, time.UTC)
+
+func fixtureSeries(t *testing.T, cfg Config) (*model.TradeSignal, *model.MarketIndicators) {
+	t.Helper()
+	daily := fixtureBars()
+	sig, ind, err := Series(daily, calculator.AggregateWeekly(daily), daily[len(daily)-1].Close, fixtureAsOf, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return sig, ind
+}
+
+// TestSeries_Golden pins the indicators and signal of the fixture under the
+// default settings, so any change to the scoring shows up here. Regenerate
+// with UPDATE_GOLDEN=1 only when the scoring is meant to change.
+func TestSeries_Golden(t *testing.T) {
+	sig, ind := fixtureSeries(t, Config{Params: strategy.CurrentParams()})
+
+	var b strings.Builder
+	for _, f := range []struct {
+		name string
+		v    float64
+	}{
+		{"price", ind.CurrentPrice}, {"ma200", ind.MA200}, {"ma20w", ind.MA20w}, {"ma50w", ind.MA50w},
+		{"weekly_rsi", ind.WeeklyRSI}, {"daily_rsi", ind.DailyRSI}, {"high52w", ind.High52w},
+		{"low52w", ind.Low52w}, {"position52w", ind.Position52w}, {"drawdown", ind.DrawdownFromHigh},
+		{"max_drawdown_1y", ind.MaxDrawdown1y}, {"roc3m", ind.ROC3m}, {"roc6m", ind.ROC6m},
+		{"atr14", ind.ATR14}, {"vol30d", ind.Vol30d}, {"weekly_rsi_pct", ind.WeeklyRSIPercentile},
+		{"ma200_dev_pct", ind.MA200DevPercentile},
+	} {
+		fmt.Fprintf(&b, "%s %.4f\n", f.name, f.v)
+	}
+	for _, f := range sig.Factors {
+		fmt.Fprintf(&b, "factor %s raw=%.4f weight=%.4f weighted=%.4f missing=%q\n", f.ID, f.RawScore, f.Weight, f.Weighted, f.Missing)
+	}
+	fmt.Fprintf(&b, "total %.4f\ntier %s\nregime %s\nconfidence %.4f\n", sig.TotalScore, sig.Tier.Label, sig.Regime, sig.Confidence)
+	for _, w := range sig.Warnings {
+		fmt.Fprintf(&b, "warning %s\n", w.Kind)
+	}
+	fmt.Fprintf(&b, "version %s\n", sig.StrategyVersion)
+	got := b.String()
+
+	golden := filepath.Join("testdata", "series.golden")
+	if os.Getenv("UPDATE_GOLDEN") != "" {
+		if err := os.WriteFile(golden, []byte(got), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != string(want) {
+		t.Errorf("fixture evaluation changed:\n--- got ---\n%s\n--- want ---\n%s", got, want)
+	}
+}
+
+func TestSeries_NoClock(t *testing.T) {
+	// A staleness limit measured against the clock would flag the fixture,
+	// years old by now; against asOf it is fresh.
+	col := collector.NewCollector(nil, "TEST")
+	col.Quality = collector.QualityPolicy{MaxStaleness: 4 * 24 * time.Hour, Reject: true}
+	cfg := Config{Params: strategy.CurrentParams(), Collector: col}
+
+	sig1, ind1 := fixtureSeries(t, cfg)
+	sig2, ind2 := fixtureSeries(t, cfg)
+	if ind1.DataQualityWarning != "" || ind1.DegradedReason(model.IndicatorPrice) != "" {
+		t.Errorf("fixture flagged stale as of its own close: %q", ind1.DataQualityWarning)
+	}
+	if sig1.TotalScore != sig2.TotalScore || ind1.WeeklyRSI != ind2.WeeklyRSI || ind1.DailyRSI != ind2.DailyRSI {
+		t.Errorf("repeated evaluation differs: %.6f vs %.6f", sig1.TotalScore, sig2.TotalScore)
+	}
+
+	daily := fixtureBars()
+	_, _, err := Series(daily, calculator.AggregateWeekly(daily), daily[len(daily)-1].Close, fixtureAsOf.AddDate(0, 0, 30), cfg)
+	if _, ok := err.(*collector.DataQualityError); !ok {
+		t.Errorf("a month after the last bar: err = %v, want a *DataQualityError", err)
+	}
+}
+
+func TestSeries_Errors(t *testing.T) {
+	daily := fixtureBars()
+	if _, _, err := Series(daily, nil, 100, time.Time{}, Config{}); err == nil {
+		t.Error("no as-of time: want an error")
+	}
+	if _, _, err := Series(daily, nil, 100, fixtureAsOf, Config{Params: strategy.Params{SmoothingAlpha: 2}}); err == nil {
+		t.Error("invalid params: want an error")
+	}
+}
+
+// TestIndicators_MatchesCompute checks the collector's live computation
+// agrees with the pure one on a fresh collector, whose RSI state starts on
+// the same bars.
+func TestIndicators_MatchesCompute(t *testing.T) {
+	daily := fixtureBars()
+	in := collector.Inputs{Daily: daily, Weekly: calculator.AggregateWeekly(daily), Price: daily[len(daily)-1].Close}
+	col := collector.NewCollector(&collector.MockFetcher{}, "TEST")
+	col.Quality = collector.QualityPolicy{}
+
+	pure, err := Indicators(in, fixtureAsOf, col)
+	if err != nil {
+		t.Fatal(err)
+	}
+	live := col.Compute(in)
+	for _, f := range []struct {
+		name       string
+		pure, live float64
+	}{
+		{"MA200", pure.MA200, live.MA200}, {"weekly RSI", pure.WeeklyRSI, live.WeeklyRSI},
+		{"daily RSI", pure.DailyRSI, live.DailyRSI}, {"52-week position", pure.Position52w, live.Position52w},
+	} {
+		if math.Abs(f.pure-f.live) > 1e-9 {
+			t.Errorf("%s = %.9f, live Compute gave %.9f", f.name, f.pure, f.live)
+		}
+	}
+}
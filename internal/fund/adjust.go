@@ -0,0 +1,78 @@
+package fund
+
+import (
+	"fmt"
+	"log"
+	"math"
+
+	"MarketSentinel/internal/model"
+)
+
+// Pools a manual adjustment applies to.
+const (
+	PoolRegular = "regular"
+	PoolReserve = "reserve"
+)
+
+// Adjust deposits delta into pool, or withdraws it when negative, outside the
+// monthly budget: a bonus put aside or cash taken out. note is logged with
+// it. It fails for a zero or non-finite delta, an unknown pool, a withdrawal
+// of more than the pool holds, a locked fund, or when the state cannot be
+// saved, leaving the state as it was.
+func (m *Manager) Adjust(pool string, delta float64, note string) (before, after model.FundState, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	before = snapshot(m.state)
+	if err := m.lockedErr(); err != nil {
+		return before, before, err
+	}
+	if err := adjustPool(m.state, pool, delta); err != nil {
+		return before, before, err
+	}
+	m.checkInvariants()
+	if err := m.save(); err != nil {
+		restore(m.state, before)
+		return before, before, fmt.Errorf("保存资金状态失败: %w", err)
+	}
+	log.Printf("[INFO] manual adjustment of the %s pool by %+.2f: %s", pool, delta, note)
+	return before, snapshot(m.state), nil
+}
+
+// PreviewAdjust returns the current state and the state Adjust would leave,
+// without changing anything. It fails where Adjust would.
+func (m *Manager) PreviewAdjust(pool string, delta float64) (before, after model.FundState, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	before = snapshot(m.state)
+	if err := m.lockedErr(); err != nil {
+		return before, before, err
+	}
+	after = snapshot(m.state)
+	if err := adjustPool(&after, pool, delta); err != nil {
+		return before, before, err
+	}
+	return before, after, nil
+}
+
+// adjustPool adds delta to pool of st, see Adjust.
+func adjustPool(st *model.FundState, pool string, delta float64) error {
+	if delta == 0 || math.IsNaN(delta) || math.IsInf(delta, 0) {
+		return fmt.Errorf("调整金额 %v 无效", delta)
+	}
+	var balance *float64
+	switch pool {
+	case PoolRegular:
+		balance = &st.RegularBalance
+	case PoolReserve:
+		balance = &st.ReserveBalance
+	default:
+		return fmt.Errorf("未知的资金池 %q，可选 %s 或 %s", pool, PoolRegular, PoolReserve)
+	}
+	if *balance+delta < 0 {
+		return fmt.Errorf("取出 %s 超过余额 %s", model.FormatAmount(-delta), model.FormatAmount(*balance))
+	}
+	*balance += delta
+	return nil
+}
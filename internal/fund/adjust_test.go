@@ -0,0 +1,78 @@
+package fund
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"MarketSentinel/internal/model"
+)
+
+func TestAdjust(t *testing.T) {
+	m := newTestManager(t, Policy{})
+
+	before, after, err := m.Adjust(PoolReserve, 5000, "年终奖")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if after.ReserveBalance != before.ReserveBalance+5000 || after.RegularBalance != before.RegularBalance {
+		t.Errorf("deposit: %+v -> %+v", before, after)
+	}
+
+	// Withdrawing the whole balance empties the pool.
+	if _, after, err = m.Adjust(PoolRegular, -10000, ""); err != nil {
+		t.Fatal(err)
+	}
+	if after.RegularBalance != 0 {
+		t.Errorf("regular balance %.2f after withdrawing all of it", after.RegularBalance)
+	}
+
+	loaded, err := m.store.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.ReserveBalance != 15000 || loaded.RegularBalance != 0 {
+		t.Errorf("saved pools %.2f/%.2f", loaded.RegularBalance, loaded.ReserveBalance)
+	}
+}
+
+func TestPreviewAdjust(t *testing.T) {
+	m := newTestManager(t, Policy{})
+	before, after, err := m.PreviewAdjust(PoolRegular, -2000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if after.RegularBalance != before.RegularBalance-2000 || after.ReserveBalance != before.ReserveBalance {
+		t.Errorf("preview: %+v -> %+v", before, after)
+	}
+	if got := m.GetState(); got.RegularBalance != before.RegularBalance {
+		t.Errorf("preview changed the pool to %.2f", got.RegularBalance)
+	}
+	if _, _, err := m.PreviewAdjust(PoolRegular, -10000.01); err == nil {
+		t.Error("preview of an overdraft accepted")
+	}
+}
+
+func TestAdjust_Rejects(t *testing.T) {
+	m := newTestManager(t, Policy{})
+	before := m.GetState()
+	for _, c := range []struct {
+		pool  string
+		delta float64
+	}{
+		{PoolReserve, 0}, {PoolReserve, math.NaN()}, {PoolReserve, math.Inf(1)},
+		{"cash", 100}, {PoolRegular, -10000.01},
+	} {
+		if _, _, err := m.Adjust(c.pool, c.delta, ""); err == nil {
+			t.Errorf("%v to %q accepted", c.delta, c.pool)
+		}
+	}
+	if after := m.GetState(); after.RegularBalance != before.RegularBalance || after.ReserveBalance != before.ReserveBalance {
+		t.Errorf("rejected adjustments changed the pools: %+v", after)
+	}
+
+	m.MutateStateForTest(func(st *model.FundState) { st.ReserveBalance = -500 })
+	if _, _, err := m.Adjust(PoolReserve, 1000, ""); !errors.Is(err, ErrLocked) {
+		t.Errorf("deposit while locked: err = %v, want ErrLocked", err)
+	}
+}
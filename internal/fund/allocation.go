@@ -0,0 +1,133 @@
+package fund
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+
+	"MarketSentinel/internal/model"
+)
+
+// For returns the manager investing symbol's share of an allocated fund: m's
+// primary manager for the primary symbol, and nil for a symbol the
+// allocation does not include, or any symbol when the fund is not allocated.
+// The managers of one fund share its lock, policy and saved state: the weekly
+// and bottom-fish investments, holdings and adjustments of each apply to its
+// own sub-state, while ReplenishMonth, QuarterlyRebalance, ResetWeeklyFlags,
+// UpdateBudget, Reconcile and pausing cover every symbol whichever manager
+// they are called on.
+func (m *Manager) For(symbol string) *Manager {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if v, ok := m.views[symbol]; ok {
+		return v
+	}
+	st, ok := m.root.Symbols[symbol]
+	if !ok {
+		return nil
+	}
+	v := &Manager{ledger: m.ledger, state: st}
+	m.views[symbol] = v
+	return v
+}
+
+// Symbols lists the symbols the budget is allocated to, primary first and the
+// others sorted; it is empty when the fund is not allocated.
+func (m *Manager) Symbols() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if len(m.root.Symbols) == 0 {
+		return nil
+	}
+	return append([]string{m.root.Symbol}, slices.Sorted(maps.Keys(m.root.Symbols))...)
+}
+
+// allocate divides the fund's total budget between the symbols of the
+// policy's Allocation, adding an empty sub-state for a symbol new to it. A
+// symbol whose budget changed gets its weekly base N recomputed; balances are
+// not touched. It fails when the state tracks a primary or sub-state the
+// allocation does not, which would strand its balances. Callers must hold
+// m.mu.
+func (l *ledger) allocate() error {
+	if len(l.policy.Allocation) == 0 {
+		if len(l.root.Symbols) > 0 {
+			return fmt.Errorf("fund state is allocated across %d symbols but no allocation is configured", len(l.root.Symbols)+1)
+		}
+		return nil
+	}
+	if l.policy.share(l.policy.Symbol) <= 0 {
+		return fmt.Errorf("fund allocation has no share for the primary symbol %s", l.policy.Symbol)
+	}
+	if l.root.Symbol != "" && l.root.Symbol != l.policy.Symbol {
+		return fmt.Errorf("fund state's primary symbol is %s, not %s", l.root.Symbol, l.policy.Symbol)
+	}
+	for sym := range l.root.Symbols {
+		if _, ok := l.policy.Allocation[sym]; !ok || sym == l.policy.Symbol {
+			return fmt.Errorf("fund state tracks %s, which the allocation does not include", sym)
+		}
+	}
+
+	total := l.root.TotalBudget()
+	l.root.Symbol = l.policy.Symbol
+	for sym, share := range l.policy.Allocation {
+		st := l.root
+		if sym != l.policy.Symbol {
+			if st = l.root.Symbols[sym]; st == nil {
+				// Credited from the next replenish on, like the fund it joins.
+				st = &model.FundState{
+					Symbol:               sym,
+					LastReplenishMonth:   l.root.LastReplenishMonth,
+					LastRebalanceQuarter: l.root.LastRebalanceQuarter,
+				}
+				if l.root.Symbols == nil {
+					l.root.Symbols = make(map[string]*model.FundState)
+				}
+				l.root.Symbols[sym] = st
+			}
+		}
+		if budget := total * share; !approxEqual(budget, st.MonthlyBudget) {
+			st.MonthlyBudget = budget
+			st.WeeklyBaseN = l.policy.weeklyBase(budget)
+		}
+	}
+	return nil
+}
+
+// states returns root and its sub-states, root first and the others by symbol.
+func states(root *model.FundState) []*model.FundState {
+	all := []*model.FundState{root}
+	for _, sym := range slices.Sorted(maps.Keys(root.Symbols)) {
+		all = append(all, root.Symbols[sym])
+	}
+	return all
+}
+
+// snapshot is a copy of st sharing nothing the manager mutates in place, so
+// it stays as it was while st changes.
+func snapshot(st *model.FundState) model.FundState {
+	c := *st
+	c.RecentScores = slices.Clone(st.RecentScores)
+	if st.Symbols != nil {
+		c.Symbols = make(map[string]*model.FundState, len(st.Symbols))
+		for sym, sub := range st.Symbols {
+			s := snapshot(sub)
+			c.Symbols[sym] = &s
+		}
+	}
+	return c
+}
+
+// restore puts st back to before, a snapshot of it, in place: the sub-states
+// the managers returned by For point at stay the same.
+func restore(st *model.FundState, before model.FundState) {
+	subs := st.Symbols
+	for sym, sub := range before.Symbols {
+		if cur, ok := subs[sym]; ok {
+			*cur = *sub
+		}
+	}
+	before.Symbols = subs
+	*st = before
+}
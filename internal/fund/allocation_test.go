@@ -0,0 +1,142 @@
+package fund
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"MarketSentinel/internal/model"
+)
+
+var testAllocation = Policy{Symbol: "SPX500", Allocation: map[string]float64{"SPX500": 0.6, "NDX100": 0.4}}
+
+func TestAllocation_Fresh(t *testing.T) {
+	m, err := NewManager(NewFileStore(filepath.Join(t.TempDir(), "state.json")), 10000, testAllocation)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := m.Symbols(); !slices.Equal(got, []string{"SPX500", "NDX100"}) {
+		t.Errorf("symbols %v", got)
+	}
+	if m.For("DJI") != nil {
+		t.Error("manager for a symbol outside the allocation")
+	}
+	if m.For("SPX500") != m || m.For("NDX100") != m.For("NDX100") {
+		t.Error("For does not return one manager per symbol")
+	}
+
+	st := m.GetState()
+	ndx := m.For("NDX100").GetState()
+	if !approx(st.MonthlyBudget, 6000) || !approx(ndx.MonthlyBudget, 4000) || !approx(st.TotalBudget(), 10000) {
+		t.Errorf("budgets %.2f/%.2f", st.MonthlyBudget, ndx.MonthlyBudget)
+	}
+	if !approx(ndx.RegularBalance+ndx.ReserveBalance, 4000) || ndx.WeeklyBaseN >= st.WeeklyBaseN {
+		t.Errorf("NDX100 sub-state %+v", ndx)
+	}
+
+	// The copy returned shares nothing with the fund.
+	st.Symbols["NDX100"].ReserveBalance = 0
+	if m.For("NDX100").GetState().ReserveBalance == 0 {
+		t.Error("GetState shares the sub-states")
+	}
+}
+
+func TestAllocation_MigratesFlatState(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	flat := model.FundState{MonthlyBudget: 10000, WeeklyBaseN: 1750, RegularBalance: 8000, ReserveBalance: 5000, LastReplenishMonth: "2025-03"}
+	data, err := json.Marshal(flat)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	m, err := NewManager(NewFileStore(path), 10000, testAllocation)
+	if err != nil {
+		t.Fatal(err)
+	}
+	st := m.GetState()
+	if st.Symbol != "SPX500" || st.RegularBalance != 8000 || st.ReserveBalance != 5000 {
+		t.Errorf("primary kept %+v", st)
+	}
+	if !approx(st.MonthlyBudget, 6000) || st.WeeklyBaseN == 1750 {
+		t.Errorf("primary budget %.2f, N %.2f", st.MonthlyBudget, st.WeeklyBaseN)
+	}
+	ndx := st.Symbols["NDX100"]
+	if ndx == nil || ndx.RegularBalance != 0 || ndx.ReserveBalance != 0 || !approx(ndx.MonthlyBudget, 4000) || ndx.LastReplenishMonth != "2025-03" {
+		t.Errorf("NDX100 sub-state %+v", ndx)
+	}
+
+	// Reloading with the allocation is a no-op; without it, or with a
+	// different primary, the sub-state would be stranded.
+	if _, err := NewManager(NewFileStore(path), 10000, testAllocation); err != nil {
+		t.Errorf("reload: %v", err)
+	}
+	if _, err := NewManager(NewFileStore(path), 10000, Policy{Symbol: "SPX500"}); err == nil {
+		t.Error("allocated state loaded without an allocation")
+	}
+	if _, err := NewManager(NewFileStore(path), 10000, Policy{Symbol: "NDX100", Allocation: testAllocation.Allocation}); err == nil {
+		t.Error("allocated state loaded with another primary")
+	}
+	if _, err := NewManager(NewFileStore(path), 10000, Policy{Symbol: "SPX500", Allocation: map[string]float64{"SPX500": 1}}); err == nil {
+		t.Error("allocated state loaded without one of its symbols")
+	}
+}
+
+func TestAllocation_IndependentPools(t *testing.T) {
+	m, err := NewManager(NewFileStore(filepath.Join(t.TempDir(), "state.json")), 10000, testAllocation)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ndx := m.For("NDX100")
+	spxBefore, ndxBefore := m.GetState(), ndx.GetState()
+
+	final, _ := ndx.CalculateWeeklyInvestment(tierSignal(1.0, 0))
+	if !approx(final, ndxBefore.WeeklyBaseN) {
+		t.Errorf("NDX100 invested %.2f, want its N %.2f", final, ndxBefore.WeeklyBaseN)
+	}
+	if got := ndx.GetState(); !approx(got.RegularBalance, ndxBefore.RegularBalance-final) || !ndx.InvestedThisWeek() {
+		t.Errorf("NDX100 after investing %+v", got)
+	}
+	if got := m.GetState(); got.RegularBalance != spxBefore.RegularBalance || m.InvestedThisWeek() {
+		t.Errorf("primary changed by NDX100's investment: %+v", got)
+	}
+	if got := m.GetState().Symbols["NDX100"]; !approx(got.WeekDeployed, final) {
+		t.Errorf("primary's state does not include the investment: %+v", got)
+	}
+
+	m.ResetWeeklyFlags()
+	if got := ndx.GetState(); got.WeekDeployed != 0 {
+		t.Errorf("weekly flags not reset for NDX100: %+v", got)
+	}
+}
+
+func TestAllocation_ReplenishAndBudget(t *testing.T) {
+	m, err := NewManager(NewFileStore(filepath.Join(t.TempDir(), "state.json")), 10000, testAllocation)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ndx := m.For("NDX100")
+	before := ndx.GetState()
+
+	split := ndx.ReplenishMonth(nextMonth())
+	if len(split.Parts) != 2 || !approx(split.RegularAdded+split.ReserveAdded, 10000) {
+		t.Fatalf("split %+v", split)
+	}
+	if p := split.Parts[1]; p.Symbol != "NDX100" || !approx(p.RegularAdded+p.ReserveAdded, 4000) {
+		t.Errorf("NDX100 part %+v", p)
+	}
+	if after := ndx.GetState(); !approx(after.ReserveBalance+after.RegularBalance, before.ReserveBalance+before.RegularBalance+4000) {
+		t.Errorf("NDX100 pools %+v -> %+v", before, after)
+	}
+	if s := m.ReplenishMonth(nextMonth()); !s.Skipped {
+		t.Errorf("second replenish %+v", s)
+	}
+
+	if _, after, err := m.UpdateBudget(20000); err != nil || !approx(after.MonthlyBudget, 12000) || !approx(after.Symbols["NDX100"].MonthlyBudget, 8000) {
+		t.Errorf("update budget: err %v, %+v", err, after)
+	}
+}
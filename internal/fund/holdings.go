@@ -0,0 +1,138 @@
+package fund
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"maps"
+	"math"
+
+	"MarketSentinel/internal/model"
+)
+
+// RecordPurchase adds a confirmed fill to the holding of symbol: amountSpent
+// in currency bought amountSpent/price units, so a partial fill is recorded
+// as the amount actually spent. It fails for a non-positive amount or price.
+// The pools are not touched, as the weekly investment already debited them,
+// so purchases are recorded while the fund is locked too.
+func (m *Manager) RecordPurchase(symbol string, amountSpent, price float64) error {
+	if !(amountSpent > 0) || math.IsInf(amountSpent, 0) {
+		return fmt.Errorf("成交金额 %v 无效，需大于 0", amountSpent)
+	}
+	if !(price > 0) || math.IsInf(price, 0) {
+		return fmt.Errorf("成交价格 %v 无效，需大于 0", price)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	h := m.state.Holdings[symbol]
+	h.Units += amountSpent / price
+	h.TotalInvested += amountSpent
+	h.AvgCost = h.TotalInvested / h.Units
+	// A new map, so states returned by GetState keep their holdings.
+	holdings := maps.Clone(m.state.Holdings)
+	if holdings == nil {
+		holdings = make(map[string]model.Holding)
+	}
+	holdings[symbol] = h
+	m.state.Holdings = holdings
+
+	if err := m.save(); errors.Is(err, ErrStateConflict) {
+		return fmt.Errorf("保存资金状态失败: %w", err)
+	} else if err != nil {
+		log.Printf("[ERROR] failed to save fund state after purchase: %v", err)
+	}
+	return nil
+}
+
+// Destinations of the proceeds of a sale.
+const (
+	SaleToRegular  = "regular"  // credited to the regular pool
+	SaleToReserve  = "reserve"  // credited to the reserve pool
+	SaleToExternal = "external" // taken out of the fund
+)
+
+// unitsTolerance absorbs float rounding when a sale empties a holding.
+const unitsTolerance = 1e-9
+
+// RecordSale removes units of symbol sold at price from its holding and
+// returns the realized P&L against the average cost. The cost of the units
+// sold leaves TotalInvested, so the average cost of the rest is unchanged.
+// The proceeds are credited to the pool named by destination, or leave the
+// fund for SaleToExternal. It fails for non-positive units or price, an
+// unknown destination, more units than held, or a pool credit while the
+// fund is locked.
+func (m *Manager) RecordSale(symbol string, units, price float64, destination string) (realized float64, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if realized, err = m.sell(m.state, symbol, units, price, destination); err != nil {
+		return 0, err
+	}
+	m.checkInvariants()
+
+	if err := m.save(); errors.Is(err, ErrStateConflict) {
+		return 0, fmt.Errorf("保存资金状态失败: %w", err)
+	} else if err != nil {
+		log.Printf("[ERROR] failed to save fund state after sale: %v", err)
+	}
+	return realized, nil
+}
+
+// PreviewSale returns the current state and the state RecordSale would
+// leave, without changing anything. It fails where RecordSale would.
+func (m *Manager) PreviewSale(symbol string, units, price float64, destination string) (before, after model.FundState, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	before = snapshot(m.state)
+	after = snapshot(m.state)
+	if _, err := m.sell(&after, symbol, units, price, destination); err != nil {
+		return before, before, err
+	}
+	return before, after, nil
+}
+
+// sell applies a sale to st, see RecordSale.
+func (m *Manager) sell(st *model.FundState, symbol string, units, price float64, destination string) (realized float64, err error) {
+	if !(units > 0) || math.IsInf(units, 0) {
+		return 0, fmt.Errorf("卖出份额 %v 无效，需大于 0", units)
+	}
+	if !(price > 0) || math.IsInf(price, 0) {
+		return 0, fmt.Errorf("成交价格 %v 无效，需大于 0", price)
+	}
+	switch destination {
+	case SaleToRegular, SaleToReserve, SaleToExternal:
+	default:
+		return 0, fmt.Errorf("未知的资金去向 %q，可选 %s、%s 或 %s", destination, SaleToRegular, SaleToReserve, SaleToExternal)
+	}
+	if destination != SaleToExternal {
+		if err := m.lockedErr(); err != nil {
+			return 0, err
+		}
+	}
+	h := st.Holdings[symbol]
+	if units > h.Units+unitsTolerance {
+		return 0, fmt.Errorf("卖出 %.4f 份超过持有的 %.4f 份", units, h.Units)
+	}
+
+	proceeds := units * price
+	realized = units * (price - h.AvgCost)
+	holdings := maps.Clone(st.Holdings)
+	if h.Units-units <= unitsTolerance {
+		delete(holdings, symbol)
+	} else {
+		h.Units -= units
+		h.TotalInvested = h.Units * h.AvgCost
+		holdings[symbol] = h
+	}
+	st.Holdings = holdings
+	switch destination {
+	case SaleToRegular:
+		st.RegularBalance += proceeds
+	case SaleToReserve:
+		st.ReserveBalance += proceeds
+	}
+	return realized, nil
+}
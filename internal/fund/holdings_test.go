@@ -0,0 +1,163 @@
+package fund
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"MarketSentinel/internal/model"
+)
+
+func TestRecordPurchase(t *testing.T) {
+	m := newTestManager(t, Policy{})
+	before := m.GetState()
+
+	if err := m.RecordPurchase("SPX500", 1000, 5000); err != nil {
+		t.Fatal(err)
+	}
+	// A partial fill: only 300 of the week's amount went through.
+	if err := m.RecordPurchase("SPX500", 300, 4000); err != nil {
+		t.Fatal(err)
+	}
+
+	h := m.GetState().Holdings["SPX500"]
+	if !approx(h.Units, 0.2+0.075) || !approx(h.TotalInvested, 1300) || !approx(h.AvgCost, 1300/0.275) {
+		t.Errorf("holding = %+v", h)
+	}
+	if got := h.UnrealizedPnL(5000); !approx(got, 0.275*5000-1300) {
+		t.Errorf("P&L at 5000 = %.2f", got)
+	}
+	st := m.GetState()
+	if st.RegularBalance != before.RegularBalance || st.ReserveBalance != before.ReserveBalance {
+		t.Error("purchase changed the pools")
+	}
+	if len(before.Holdings) != 0 {
+		t.Errorf("earlier state copy sees the purchase: %+v", before.Holdings)
+	}
+
+	// Persisted with the state.
+	loaded, err := m.store.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.Holdings["SPX500"] != h {
+		t.Errorf("loaded holding %+v, want %+v", loaded.Holdings["SPX500"], h)
+	}
+}
+
+func TestRecordPurchase_Rejects(t *testing.T) {
+	m := newTestManager(t, Policy{})
+	for _, c := range []struct{ amount, price float64 }{
+		{1000, 0}, {1000, -5}, {1000, math.NaN()}, {0, 5000}, {-100, 5000}, {math.Inf(1), 5000},
+	} {
+		if err := m.RecordPurchase("SPX500", c.amount, c.price); err == nil {
+			t.Errorf("amount %v at %v accepted", c.amount, c.price)
+		}
+	}
+	if h := m.GetState().Holdings; len(h) != 0 {
+		t.Errorf("rejected fills recorded: %+v", h)
+	}
+}
+
+func TestRecordSale(t *testing.T) {
+	m := newTestManager(t, Policy{})
+	if err := m.RecordPurchase("SPX500", 1000, 5000); err != nil {
+		t.Fatal(err)
+	}
+	before := m.GetState()
+
+	realized, err := m.RecordSale("SPX500", 0.05, 6000, SaleToReserve)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !approx(realized, 0.05*1000) {
+		t.Errorf("realized = %.2f, want 50", realized)
+	}
+	st := m.GetState()
+	h := st.Holdings["SPX500"]
+	if !approx(h.Units, 0.15) || !approx(h.AvgCost, 5000) || !approx(h.TotalInvested, 750) {
+		t.Errorf("holding after partial sale = %+v", h)
+	}
+	if !approx(st.ReserveBalance, before.ReserveBalance+300) || st.RegularBalance != before.RegularBalance {
+		t.Errorf("pools %.2f/%.2f, want the 300 proceeds in the reserve", st.RegularBalance, st.ReserveBalance)
+	}
+	if before.Holdings["SPX500"].Units != 0.2 {
+		t.Errorf("earlier state copy sees the sale: %+v", before.Holdings)
+	}
+
+	// Selling the rest at a loss empties the holding; the proceeds leave the fund.
+	realized, err = m.RecordSale("SPX500", 0.15, 4000, SaleToExternal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !approx(realized, -150) {
+		t.Errorf("realized = %.2f, want -150", realized)
+	}
+	if _, ok := m.GetState().Holdings["SPX500"]; ok {
+		t.Error("sold-out holding kept")
+	}
+	if m.GetState().ReserveBalance != st.ReserveBalance {
+		t.Error("external sale credited a pool")
+	}
+
+	loaded, err := m.store.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(loaded.Holdings) != 0 || !approx(loaded.ReserveBalance, st.ReserveBalance) {
+		t.Errorf("loaded state %+v", loaded)
+	}
+}
+
+func TestPreviewSale(t *testing.T) {
+	m := newTestManager(t, Policy{})
+	if err := m.RecordPurchase("SPX500", 1000, 5000); err != nil {
+		t.Fatal(err)
+	}
+	before, after, err := m.PreviewSale("SPX500", 0.05, 6000, SaleToRegular)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !approx(after.RegularBalance, before.RegularBalance+300) || !approx(after.Holdings["SPX500"].Units, 0.15) {
+		t.Errorf("preview: %+v -> %+v", before, after)
+	}
+	if got := m.GetState(); got.RegularBalance != before.RegularBalance || !approx(got.Holdings["SPX500"].Units, 0.2) {
+		t.Errorf("preview changed the state: %+v", got)
+	}
+	if _, _, err := m.PreviewSale("SPX500", 0.3, 6000, SaleToRegular); err == nil {
+		t.Error("preview of an oversized sale accepted")
+	}
+}
+
+func TestRecordSale_Rejects(t *testing.T) {
+	m := newTestManager(t, Policy{})
+	if err := m.RecordPurchase("SPX500", 1000, 5000); err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range []struct {
+		units, price float64
+		destination  string
+	}{
+		{0.3, 5000, SaleToExternal}, {0, 5000, SaleToExternal}, {0.1, 0, SaleToExternal},
+		{0.1, math.NaN(), SaleToExternal}, {0.1, 5000, "cash"},
+	} {
+		if _, err := m.RecordSale("SPX500", c.units, c.price, c.destination); err == nil {
+			t.Errorf("%v units at %v to %q accepted", c.units, c.price, c.destination)
+		}
+	}
+	if _, err := m.RecordSale("QQQ", 0.1, 5000, SaleToExternal); err == nil {
+		t.Error("sale of a symbol not held accepted")
+	}
+	if h := m.GetState().Holdings["SPX500"]; !approx(h.Units, 0.2) {
+		t.Errorf("rejected sales changed the holding: %+v", h)
+	}
+
+	// A locked fund takes no proceeds into its pools, but may pay them out.
+	m.MutateStateForTest(func(st *model.FundState) { st.ReserveBalance = -500 })
+	if _, err := m.RecordSale("SPX500", 0.1, 5000, SaleToRegular); !errors.Is(err, ErrLocked) {
+		t.Errorf("pool credit while locked: err = %v, want ErrLocked", err)
+	}
+	if _, err := m.RecordSale("SPX500", 0.1, 5000, SaleToExternal); err != nil {
+		t.Errorf("external sale while locked: %v", err)
+	}
+}
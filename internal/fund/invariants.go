@@ -0,0 +1,208 @@
+package fund
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"strings"
+
+	"MarketSentinel/internal/model"
+)
+
+// ErrLocked is returned while fund mutations are refused after an invariant
+// violation. Read-only calls keep working until Reconcile succeeds.
+var ErrLocked = errors.New("资金账本已锁定，等待 /reconcile")
+
+// scoreWindow is the number of recent weekly scores kept in the state.
+const scoreWindow = 12
+
+// invariantTolerance absorbs float rounding in the deploy ceiling check.
+const invariantTolerance = 1e-6
+
+// ViolationHandler receives the failed invariants and a copy of the state. It
+// is called with the manager lock held and must not call back into the Manager.
+type ViolationHandler func(violation error, st model.FundState)
+
+// SetViolationHandler registers fn to be told when the fund locks. If the
+// state already failed its check when loaded, fn is called immediately.
+func (m *Manager) SetViolationHandler(fn ViolationHandler) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.onViolation = fn
+	if fn != nil && m.violation != nil {
+		fn(m.violation, snapshot(m.root))
+	}
+}
+
+// Locked returns ErrLocked wrapping the violation that locked the fund, or nil.
+func (m *Manager) Locked() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.lockedErr()
+}
+
+// lockedErr is Locked for callers holding m.mu.
+func (m *Manager) lockedErr() error {
+	if m.violation == nil {
+		return nil
+	}
+	return fmt.Errorf("%w: %v", ErrLocked, m.violation)
+}
+
+// invariants reports every rule st breaks under the current policy, or nil.
+func (m *Manager) invariants(st *model.FundState) error {
+	var broken []string
+	if st.RegularBalance < 0 {
+		broken = append(broken, fmt.Sprintf("常规池余额为负 (%s)", model.FormatAmountExact(st.RegularBalance)))
+	}
+	if st.ReserveBalance < 0 {
+		broken = append(broken, fmt.Sprintf("储备池余额为负 (%s)", model.FormatAmountExact(st.ReserveBalance)))
+	}
+	if st.WeeklyBaseN <= 0 {
+		broken = append(broken, fmt.Sprintf("周基准N非正 (%s)", model.FormatAmountExact(st.WeeklyBaseN)))
+	}
+	if len(st.RecentScores) > scoreWindow {
+		broken = append(broken, fmt.Sprintf("近期评分 %d 条，超过窗口 %d", len(st.RecentScores), scoreWindow))
+	}
+	if limit := m.policy.MaxWeeklyDeployMultiple * st.WeeklyBaseN; m.policy.MaxWeeklyDeployMultiple > 0 && st.WeekDeployed > limit+invariantTolerance {
+		broken = append(broken, fmt.Sprintf("本周已投入 %s 超过上限 %s", model.FormatAmountExact(st.WeekDeployed), model.FormatAmountExact(limit)))
+	}
+	if len(broken) == 0 {
+		return nil
+	}
+	return errors.New(strings.Join(broken, "; "))
+}
+
+// fundInvariants is invariants over root and every sub-state, the rules a
+// sub-state breaks prefixed with its symbol.
+func (m *Manager) fundInvariants(root *model.FundState) error {
+	var broken []string
+	for _, st := range states(root) {
+		err := m.invariants(st)
+		switch {
+		case err == nil:
+			continue
+		case st != root:
+			broken = append(broken, st.Symbol+": "+err.Error())
+		default:
+			broken = append(broken, err.Error())
+		}
+	}
+	if len(broken) == 0 {
+		return nil
+	}
+	return errors.New(strings.Join(broken, "; "))
+}
+
+// checkInvariants runs after every mutation. On the first violation it logs
+// the full state, alerts the handler and locks further mutations. Callers must
+// hold m.mu.
+func (m *Manager) checkInvariants() {
+	if m.violation != nil {
+		return
+	}
+	err := m.fundInvariants(m.root)
+	if err == nil {
+		return
+	}
+	log.Printf("[CRITICAL] fund invariant violated: %v", err)
+	m.lock(err)
+}
+
+// lock refuses mutations until Reconcile for violation, logging the full
+// state and alerting the handler. Callers must hold m.mu.
+func (m *Manager) lock(violation error) {
+	m.violation = violation
+	st := snapshot(m.root)
+	log.Printf("[CRITICAL] fund mutations locked until /reconcile: %v; state: %+v", violation, st)
+	if m.onViolation != nil {
+		m.onViolation(violation, st)
+	}
+}
+
+// refuseIfLocked logs and reports whether op must be skipped. Callers must hold m.mu.
+func (m *Manager) refuseIfLocked(op string) bool {
+	if m.violation == nil {
+		return false
+	}
+	log.Printf("[WARN] %s refused: %v", op, m.lockedErr())
+	return true
+}
+
+// PreviewReconcile returns the current state and the state Reconcile would
+// leave, without changing anything.
+func (m *Manager) PreviewReconcile() (before, after model.FundState) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	before = snapshot(m.root)
+	after = snapshot(m.root)
+	for _, st := range states(&after) {
+		m.repair(st)
+	}
+	return before, after
+}
+
+// Reconcile repairs the state of every symbol (negative balances to zero,
+// WeeklyBaseN from the monthly budget, scores trimmed to the window,
+// week-to-date deployment capped) and releases the lock if every invariant
+// then holds. A fund locked by a save conflict first reloads the state the
+// other process saved.
+func (m *Manager) Reconcile() (before, after model.FundState, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	before = snapshot(m.root)
+	if errors.Is(m.violation, ErrStateConflict) {
+		loaded, err := m.store.Load()
+		if err != nil {
+			return before, before, fmt.Errorf("重新读取资金状态失败: %w", err)
+		}
+		restore(m.root, *loaded)
+		m.saved = snapshot(m.root)
+	}
+	for _, st := range states(m.root) {
+		m.repair(st)
+	}
+	if err := m.save(); errors.Is(err, ErrStateConflict) {
+		return before, snapshot(m.root), fmt.Errorf("保存资金状态失败: %w", err)
+	} else if err != nil {
+		log.Printf("[ERROR] failed to save fund state after reconcile: %v", err)
+	}
+	after = snapshot(m.root)
+
+	if err := m.fundInvariants(m.root); err != nil {
+		m.violation = err
+		return before, after, fmt.Errorf("对账后仍不一致: %v", err)
+	}
+	if m.violation != nil {
+		log.Printf("[INFO] fund reconciled, mutations unlocked (was: %v)", m.violation)
+	}
+	m.violation = nil
+	return before, after, nil
+}
+
+// repair fixes the fields covered by invariants in place. Callers must hold m.mu.
+func (m *Manager) repair(st *model.FundState) {
+	st.RegularBalance = math.Max(0, st.RegularBalance)
+	st.ReserveBalance = math.Max(0, st.ReserveBalance)
+	if st.WeeklyBaseN <= 0 {
+		st.WeeklyBaseN = m.policy.weeklyBase(st.MonthlyBudget)
+	}
+	if len(st.RecentScores) > scoreWindow {
+		st.RecentScores = st.RecentScores[len(st.RecentScores)-scoreWindow:]
+	}
+	if m.policy.MaxWeeklyDeployMultiple > 0 {
+		st.WeekDeployed = math.Min(st.WeekDeployed, m.policy.MaxWeeklyDeployMultiple*st.WeeklyBaseN)
+	}
+}
+
+// MutateStateForTest applies fn to the live state as a mutation, including the
+// invariant check. It exists so tests can corrupt the state deliberately.
+func (m *Manager) MutateStateForTest(fn func(st *model.FundState)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	fn(m.state)
+	m.checkInvariants()
+}
@@ -0,0 +1,92 @@
+package fund
+
+import (
+	"errors"
+	"testing"
+
+	"MarketSentinel/internal/model"
+)
+
+func TestInvariantLockout(t *testing.T) {
+	m := newTestManager(t, Policy{})
+	var alerts []error
+	m.SetViolationHandler(func(violation error, _ model.FundState) { alerts = append(alerts, violation) })
+
+	m.MutateStateForTest(func(st *model.FundState) { st.ReserveBalance = -500 })
+	if err := m.Locked(); !errors.Is(err, ErrLocked) {
+		t.Fatalf("expected fund locked, got %v", err)
+	}
+	if len(alerts) != 1 {
+		t.Fatalf("expected one alert, got %d", len(alerts))
+	}
+
+	before := m.GetState()
+	if final, reserve := m.CalculateWeeklyInvestment(tierSignal(1.0, 0.5)); final != 0 || reserve != 0 {
+		t.Errorf("weekly investment ran while locked: %.0f/%.0f", final, reserve)
+	}
+	if _, triggered, _ := m.CalculateBottomFishInvestment(1.5, 1); triggered {
+		t.Error("bottom-fish ran while locked")
+	}
+	m.MonthlyReplenish()
+	m.QuarterlyRebalance()
+	if after := m.GetState(); after.RegularBalance != before.RegularBalance || after.ReserveBalance != before.ReserveBalance {
+		t.Errorf("balances changed while locked: %+v -> %+v", before, after)
+	}
+	if _, _, preview := m.PreviewWeeklyInvestment(tierSignal(1.0, 0)); preview.RegularBalance != before.RegularBalance-1000 {
+		t.Errorf("preview should keep working while locked, got regular %.0f", preview.RegularBalance)
+	}
+	if len(alerts) != 1 {
+		t.Errorf("expected no repeat alerts while locked, got %d", len(alerts))
+	}
+
+	if _, after, err := m.Reconcile(); err != nil || after.ReserveBalance != 0 {
+		t.Fatalf("reconcile: err=%v reserve=%.0f", err, after.ReserveBalance)
+	}
+	if err := m.Locked(); err != nil {
+		t.Fatalf("expected lock released, got %v", err)
+	}
+	if final, _ := m.CalculateWeeklyInvestment(tierSignal(1.0, 0)); final != 1000 {
+		t.Errorf("expected weekly investment after reconcile, got %.0f", final)
+	}
+}
+
+func TestInvariants(t *testing.T) {
+	tests := []struct {
+		name    string
+		corrupt func(st *model.FundState)
+	}{
+		{"negative regular", func(st *model.FundState) { st.RegularBalance = -1 }},
+		{"zero base", func(st *model.FundState) { st.WeeklyBaseN = 0 }},
+		{"too many scores", func(st *model.FundState) { st.RecentScores = make([]float64, scoreWindow+1) }},
+		{"over weekly ceiling", func(st *model.FundState) { st.WeekDeployed = 2500 }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newTestManager(t, Policy{MaxWeeklyDeployMultiple: 2})
+			m.MutateStateForTest(tt.corrupt)
+			if m.Locked() == nil {
+				t.Fatal("expected lockout")
+			}
+			if _, _, err := m.Reconcile(); err != nil {
+				t.Fatalf("reconcile: %v", err)
+			}
+			if m.Locked() != nil {
+				t.Error("expected lock released")
+			}
+		})
+	}
+}
+
+func TestReconcile_StaysLockedWhenUnrepairable(t *testing.T) {
+	m := newTestManager(t, Policy{})
+	m.MutateStateForTest(func(st *model.FundState) {
+		st.WeeklyBaseN = 0
+		st.MonthlyBudget = 0
+	})
+	if _, _, err := m.Reconcile(); err == nil {
+		t.Fatal("expected reconcile to fail without a monthly budget")
+	}
+	if !errors.Is(m.Locked(), ErrLocked) {
+		t.Error("expected fund to stay locked")
+	}
+}
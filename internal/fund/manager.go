@@ -1,97 +1,293 @@
 package fund
 
 import (
+	"errors"
+	"fmt"
 	"log"
+	"maps"
+	"math"
+	"strings"
 	"sync"
+	"time"
 
 	"MarketSentinel/internal/model"
 )
 
-// Manager handles dual-pool fund operations with concurrency safety.
+// Manager handles dual-pool fund operations with concurrency safety. Every
+// mutation is followed by an invariant check; a violation, like a save that
+// conflicts with another process, locks the fund against further mutations
+// until Reconcile. With the budget allocated across
+// symbols each has its own Manager, see For.
 type Manager struct {
-	mu       sync.Mutex
-	state    *model.FundState
-	filePath string
+	*ledger
+	state *model.FundState // the sub-state this manager invests from
 }
 
-// NewManager creates a Manager, loading or initializing state from disk.
-func NewManager(filePath string, monthlyBudget float64) (*Manager, error) {
-	state, err := LoadState(filePath)
+// ledger is what the managers of one fund's symbols share.
+type ledger struct {
+	mu     sync.Mutex
+	root   *model.FundState // the saved state, the primary symbol's sub-state
+	store  StateStore       // nil for in-memory managers
+	policy Policy
+	now    func() time.Time
+
+	violation   error // set when an invariant fails or a save conflicts; mutations are refused until Reconcile
+	onViolation ViolationHandler
+	saved       model.FundState // the state last loaded or saved, restored on a save conflict
+
+	views map[string]*Manager // managers by symbol, see For
+}
+
+// newManager creates the primary manager over root.
+func newManager(l *ledger) *Manager {
+	m := &Manager{ledger: l, state: l.root}
+	l.views = make(map[string]*Manager)
+	if l.root.Symbol != "" {
+		l.views[l.root.Symbol] = m
+	}
+	return m
+}
+
+// NewManager creates a Manager with policy, loading or initializing state
+// from store. A loaded state keeps its balances and weekly base whatever the
+// policy's split, which only applies to later replenishments. With an
+// allocation the primary symbol's manager is returned; a loaded flat state
+// becomes the primary's sub-state, and a symbol new to the allocation starts
+// with empty pools, its budget share credited from the next replenish.
+func NewManager(store StateStore, monthlyBudget float64, policy Policy) (*Manager, error) {
+	state, err := store.Load()
 	if err != nil {
 		return nil, err
 	}
 
-	// Initialize if fresh state
+	l := &ledger{root: state, store: store, policy: policy, now: time.Now, saved: snapshot(state)}
+	// Initialize if fresh state; the first month's budget is credited now.
 	if state.MonthlyBudget == 0 {
-		weeklyBase := monthlyBudget * 0.70 / 4.33
-		state.MonthlyBudget = monthlyBudget
-		state.WeeklyBaseN = weeklyBase
-		state.RegularBalance = monthlyBudget * 0.70
-		state.ReserveBalance = monthlyBudget * 0.30
+		*state = InitialState(monthlyBudget, policy)
+		for _, st := range states(state) {
+			st.LastReplenishMonth = MonthKey(l.now())
+		}
 	}
-
-	m := &Manager{state: state, filePath: filePath}
+	if err := l.allocate(); err != nil {
+		return nil, err
+	}
+	m := newManager(l)
+	m.checkInvariants()
 	if err := m.save(); err != nil {
 		return nil, err
 	}
 	return m, nil
 }
 
-// GetState returns a copy of the current fund state.
+// InitialState is a fresh fund: the first month's budget split between the
+// pools by policy, and the weekly base N derived from it, for each symbol of
+// the policy's allocation by its share.
+func InitialState(monthlyBudget float64, policy Policy) model.FundState {
+	st := initialPools(monthlyBudget*policy.share(policy.Symbol), policy)
+	if len(policy.Allocation) == 0 {
+		return st
+	}
+	st.Symbol = policy.Symbol
+	st.Symbols = make(map[string]*model.FundState)
+	for sym, share := range policy.Allocation {
+		if sym != policy.Symbol {
+			sub := initialPools(monthlyBudget*share, policy)
+			sub.Symbol = sym
+			st.Symbols[sym] = &sub
+		}
+	}
+	return st
+}
+
+// initialPools is one fresh sub-state with budget credited.
+func initialPools(budget float64, policy Policy) model.FundState {
+	reserve := budget * policy.reserveShare()
+	return model.FundState{
+		MonthlyBudget:  budget,
+		WeeklyBaseN:    policy.weeklyBase(budget),
+		RegularBalance: budget - reserve,
+		ReserveBalance: reserve,
+	}
+}
+
+// NewMemoryManager creates a Manager over a copy of state that is never
+// written to disk, with now as its clock. Backtests use it to replay the fund
+// rules on simulated dates.
+func NewMemoryManager(state model.FundState, now func() time.Time) *Manager {
+	state = snapshot(&state)
+	state.Holdings = maps.Clone(state.Holdings)
+	m := newManager(&ledger{root: &state, now: now})
+	m.checkInvariants()
+	return m
+}
+
+// SetPolicy replaces the optional fund rules.
+func (m *Manager) SetPolicy(p Policy) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.policy = p
+}
+
+// GetState returns a copy of the current fund state; the primary's includes
+// the other symbols' sub-states.
 func (m *Manager) GetState() model.FundState {
 	m.mu.Lock()
 	defer m.mu.Unlock()
-	return *m.state
+	return snapshot(m.state)
 }
 
 // CalculateWeeklyInvestment computes the weekly investment amount based on the signal tier.
+//
+// Limits apply in this order: the tier sets the desired regular and reserve
+// amounts, each is capped to its pool balance, the reserve floor and the
+// quarterly reserve cap trim the reserve portion, and finally the weekly
+// deploy ceiling trims the reserve portion first, then the regular portion.
+// When a reserve guard binds, signal.ReserveNote explains the reduction; when
+// the ceiling binds, signal.CapNote does. The total is then rounded by the
+// policy's Rounding, the difference taken from or added to the regular
+// portion so the reserve is spared, and the remainder stays in the pools;
+// signal.RoundingNote gives the amount before rounding.
 func (m *Manager) CalculateWeeklyInvestment(signal *model.TradeSignal) (finalAmount, reserveUsed float64) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	baseN := m.state.WeeklyBaseN
+	if m.refuseIfLocked("weekly investment") {
+		return 0, 0
+	}
+	finalAmount, reserveUsed = m.applyWeekly(m.state, signal)
+	m.state.LastWeeklyInvestmentISOWeek = isoWeekKey(m.now())
+	m.checkInvariants()
+	if err := m.save(); errors.Is(err, ErrStateConflict) {
+		return 0, 0
+	} else if err != nil {
+		log.Printf("[ERROR] failed to save fund state: %v", err)
+	}
+	return finalAmount, reserveUsed
+}
+
+// InvestedThisWeek reports whether CalculateWeeklyInvestment already ran in
+// the current ISO week.
+func (m *Manager) InvestedThisWeek() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.state.LastWeeklyInvestmentISOWeek == isoWeekKey(m.now())
+}
+
+// PreviewWeeklyInvestment computes what CalculateWeeklyInvestment would do
+// without changing or saving the state, and returns the state it would leave.
+func (m *Manager) PreviewWeeklyInvestment(signal *model.TradeSignal) (finalAmount, reserveUsed float64, after model.FundState) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	after = snapshot(m.state)
+	finalAmount, reserveUsed = m.applyWeekly(&after, signal)
+	return finalAmount, reserveUsed, after
+}
+
+// applyWeekly debits st for the weekly investment and tracks the score.
+// Callers must hold m.mu.
+func (m *Manager) applyWeekly(st *model.FundState, signal *model.TradeSignal) (finalAmount, reserveUsed float64) {
+	baseN := st.WeeklyBaseN
 	regularAmount := baseN * signal.Tier.Multiplier
 	reserveAmount := baseN * signal.Tier.UseReserve
 
 	// Cap to available balances
-	if regularAmount > m.state.RegularBalance {
-		regularAmount = m.state.RegularBalance
+	if regularAmount > st.RegularBalance {
+		regularAmount = st.RegularBalance
 	}
-	if reserveAmount > m.state.ReserveBalance {
-		reserveAmount = m.state.ReserveBalance
+	if reserveAmount > st.ReserveBalance {
+		reserveAmount = st.ReserveBalance
 	}
 
-	m.state.RegularBalance -= regularAmount
-	m.state.ReserveBalance -= reserveAmount
+	// Cap the reserve to what the reserve floor and quarterly cap allow
+	if allowed, guard := m.reserveAllowance(st); reserveAmount > allowed {
+		signal.ReserveNote = fmt.Sprintf("触及%s，储备金动用由 %s 降至 %s",
+			guard, model.FormatAmount(reserveAmount), model.FormatAmount(allowed))
+		reserveAmount = allowed
+	}
+
+	// Cap to the remaining weekly deploy allowance, reserve first
+	if remaining, limited := m.remainingWeeklyAllowance(st); limited && regularAmount+reserveAmount > remaining {
+		wanted := regularAmount + reserveAmount
+		reserveAmount = math.Max(0, math.Min(reserveAmount, remaining-regularAmount))
+		regularAmount = math.Min(regularAmount, remaining)
+		signal.CapNote = fmt.Sprintf("触及每周投入上限 %.1fN，投入由 %s 降至 %s",
+			m.policy.MaxWeeklyDeployMultiple, model.FormatAmount(wanted), model.FormatAmount(regularAmount+reserveAmount))
+	}
+
+	// Round to whole lots; rounding up draws on the regular pool alone
+	if raw := regularAmount + reserveAmount; m.policy.Rounding.Increment > 0 && raw > 0 {
+		limit := raw + st.RegularBalance - regularAmount
+		if remaining, limited := m.remainingWeeklyAllowance(st); limited {
+			limit = math.Min(limit, remaining)
+		}
+		rounded := m.policy.Rounding.round(raw, limit)
+		if rounded >= raw {
+			regularAmount += rounded - raw
+		} else {
+			cut := raw - rounded
+			fromRegular := math.Min(cut, regularAmount)
+			regularAmount -= fromRegular
+			reserveAmount = math.Max(0, reserveAmount-(cut-fromRegular))
+		}
+		signal.RawAmount, signal.RoundingNote = raw, roundingNote(raw, rounded, m.policy.Rounding.Increment, "投入")
+	}
+
+	st.RegularBalance -= regularAmount
+	st.ReserveBalance -= reserveAmount
+	st.WeekDeployed += regularAmount + reserveAmount
+	st.QuarterReserveDeployed += reserveAmount
 
 	// Track score
-	m.state.RecentScores = append(m.state.RecentScores, signal.TotalScore)
-	if len(m.state.RecentScores) > 12 {
-		m.state.RecentScores = m.state.RecentScores[len(m.state.RecentScores)-12:]
+	st.RecentScores = append(st.RecentScores, signal.TotalScore)
+	if len(st.RecentScores) > scoreWindow {
+		st.RecentScores = st.RecentScores[len(st.RecentScores)-scoreWindow:]
 	}
 
 	// Track consecutive high-score weeks
 	if signal.TotalScore > 1.0 {
-		m.state.ConsecutiveHighScoreWeeks++
+		st.ConsecutiveHighScoreWeeks++
 	} else {
-		m.state.ConsecutiveHighScoreWeeks = 0
+		st.ConsecutiveHighScoreWeeks = 0
 	}
 
-	if err := m.save(); err != nil {
-		log.Printf("[ERROR] failed to save fund state: %v", err)
+	// Track consecutive low-participation weeks and the budget left uninvested
+	if signal.Tier.Multiplier < 1.0 {
+		st.LowParticipationWeeks++
+		st.LowParticipationShortfall += math.Max(0, baseN-(regularAmount+reserveAmount))
+	} else {
+		st.LowParticipationWeeks = 0
+		st.LowParticipationShortfall = 0
 	}
 
 	return regularAmount + reserveAmount, reserveAmount
 }
 
-// CalculateBottomFishInvestment handles intra-week RSI<30 bottom-fishing.
-// Only triggers once per week, funded from reserve pool.
-func (m *Manager) CalculateBottomFishInvestment(totalScore float64) (amount float64, triggered bool) {
+// ParticipationNudgeDue reports whether st has stayed below 1.0× long enough to
+// warrant a reminder under the current policy.
+func (m *Manager) ParticipationNudgeDue(st *model.FundState) bool {
 	m.mu.Lock()
 	defer m.mu.Unlock()
+	return m.policy.NudgeAfterWeeks > 0 && st.LowParticipationWeeks >= m.policy.NudgeAfterWeeks
+}
 
-	if m.state.BottomFishUsedThisWeek {
-		return 0, false
+// CalculateBottomFishInvestment handles intra-week bottom-fishing on a low daily
+// RSI reaching level, counted from 1 with deeper levels for lower RSI. Each
+// level triggers at most once per week, and only when deeper than any already
+// triggered, funded from reserve pool. The amount is capped to the reserve
+// balance, to what the reserve floor and quarterly reserve cap allow, and then
+// to the remaining weekly deploy allowance, and rounded by the policy's
+// Rounding within those limits; capNote explains a change, and if nothing is
+// left it does not trigger.
+func (m *Manager) CalculateBottomFishInvestment(totalScore float64, level int) (amount float64, triggered bool, capNote string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.refuseIfLocked("bottom-fish investment") {
+		return 0, false, ErrLocked.Error()
+	}
+	if level <= m.state.BottomFishLevel {
+		return 0, false, ""
 	}
 
 	baseN := m.state.WeeklyBaseN
@@ -107,76 +303,437 @@ func (m *Manager) CalculateBottomFishInvestment(totalScore float64) (amount floa
 		multiplier = 0.75
 	}
 
-	amount = baseN * multiplier
-	if amount > m.state.ReserveBalance {
-		amount = m.state.ReserveBalance
+	// limit is the most the reserve, its guards and the ceiling can fund.
+	limit := m.state.ReserveBalance
+	amount = math.Min(baseN*multiplier*m.policy.levelMultiplier(level), limit)
+
+	var notes []string
+	allowed, guard := m.reserveAllowance(m.state)
+	if amount > allowed {
+		notes = append(notes, fmt.Sprintf("触及%s，抄底由 %s 降至 %s",
+			guard, model.FormatAmount(amount), model.FormatAmount(allowed)))
+		amount = allowed
+	}
+	limit = math.Min(limit, allowed)
+	if remaining, limited := m.remainingWeeklyAllowance(m.state); limited {
+		if amount > remaining {
+			notes = append(notes, fmt.Sprintf("触及每周投入上限 %.1fN，抄底由 %s 降至 %s",
+				m.policy.MaxWeeklyDeployMultiple, model.FormatAmount(amount), model.FormatAmount(remaining)))
+			amount = remaining
+		}
+		limit = math.Min(limit, remaining)
+	}
+	if r := m.policy.Rounding; r.Increment > 0 && amount > 0 {
+		raw := amount
+		amount = r.round(raw, limit)
+		if amount == 0 || !approxEqual(amount, raw) {
+			notes = append(notes, roundingNote(raw, amount, r.Increment, "抄底"))
+		}
+	}
+	capNote = strings.Join(notes, "；")
+	if len(notes) > 0 && amount <= 0 {
+		return 0, false, capNote
 	}
 
 	m.state.ReserveBalance -= amount
-	m.state.BottomFishUsedThisWeek = true
-
-	if err := m.save(); err != nil {
+	m.state.WeekDeployed += amount
+	m.state.QuarterReserveDeployed += amount
+	m.state.BottomFishLevel = level
+	m.checkInvariants()
+
+	if err := m.save(); errors.Is(err, ErrStateConflict) {
+		return 0, false, ErrLocked.Error()
+	} else if err != nil {
 		log.Printf("[ERROR] failed to save fund state: %v", err)
 	}
 
-	return amount, true
+	return amount, true, capNote
 }
 
-// MonthlyReplenish refills both pools from the monthly budget.
-func (m *Manager) MonthlyReplenish() {
+// BottomFishCapacity is what a bottom-fish could still deploy this week.
+type BottomFishCapacity struct {
+	Level     int     // deepest level already triggered this week, 0 for none
+	Locked    bool    // fund mutations are refused until /reconcile
+	Reserve   float64 // reserve pool balance, the bottom-fish funding source
+	Remaining float64 // weekly deploy allowance left; only set when Limited
+	Limited   bool    // a weekly deploy ceiling is configured
+}
+
+// BottomFishCapacity reports the bottom-fish headroom without changing anything.
+func (m *Manager) BottomFishCapacity() BottomFishCapacity {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	m.state.RegularBalance += m.state.MonthlyBudget * 0.70
-	m.state.ReserveBalance += m.state.MonthlyBudget * 0.30
+	st := *m.state
+	c := BottomFishCapacity{
+		Level:   st.BottomFishLevel,
+		Locked:  m.violation != nil,
+		Reserve: st.ReserveBalance,
+	}
+	c.Remaining, c.Limited = m.remainingWeeklyAllowance(&st)
+	return c
+}
+
+// remainingWeeklyAllowance rolls st's week-to-date deployment over on a new ISO
+// week and returns how much may still be deployed this week. limited is false
+// when no ceiling is configured. Callers must hold m.mu.
+func (m *Manager) remainingWeeklyAllowance(st *model.FundState) (remaining float64, limited bool) {
+	if week := isoWeekKey(m.now()); week != st.DeployWeek {
+		st.DeployWeek = week
+		st.WeekDeployed = 0
+	}
+	if m.policy.MaxWeeklyDeployMultiple <= 0 {
+		return 0, false
+	}
+	return math.Max(0, m.policy.MaxWeeklyDeployMultiple*st.WeeklyBaseN-st.WeekDeployed), true
+}
+
+// roundingNote explains how rounding to increment changed raw to rounded, the
+// amount of what, e.g. "按 ¥100 取整，投入由 ¥1,154 调整为 ¥1,100"; it is
+// empty when nothing changed.
+func roundingNote(raw, rounded, increment float64, what string) string {
+	switch {
+	case rounded == 0:
+		return fmt.Sprintf("%s金额 %s 不足最小单位 %s，本次跳过", what, model.FormatAmount(raw), model.FormatAmount(increment))
+	case approxEqual(rounded, raw):
+		return ""
+	}
+	return fmt.Sprintf("按 %s 取整，%s由 %s 调整为 %s", model.FormatAmount(increment), what, model.FormatAmount(raw), model.FormatAmount(rounded))
+}
 
+// approxEqual reports whether two amounts differ by less than a cent.
+func approxEqual(a, b float64) bool { return math.Abs(a-b) < 0.005 }
+
+// reserveAllowance rolls st's quarter-to-date reserve deployment over on a
+// new calendar quarter and returns how much of the reserve pool the reserve
+// floor and the quarterly reserve cap leave deployable, with the guard that
+// binds for the notes. allowed is +Inf when neither is configured. Callers
+// must hold m.mu.
+func (m *Manager) reserveAllowance(st *model.FundState) (allowed float64, guard string) {
+	if quarter := QuarterKey(m.now()); quarter != st.ReserveQuarter {
+		st.ReserveQuarter = quarter
+		st.QuarterReserveDeployed = 0
+	}
+	allowed = math.Inf(1)
+	if floor := m.policy.ReserveFloorMultiple; floor > 0 {
+		allowed = math.Max(0, st.ReserveBalance-floor*st.WeeklyBaseN)
+		guard = fmt.Sprintf("储备池保底 %.1fN", floor)
+	}
+	if limit := m.policy.MaxQuarterlyReserveMultiple; limit > 0 {
+		if left := math.Max(0, limit*st.WeeklyBaseN-st.QuarterReserveDeployed); left < allowed {
+			allowed = left
+			guard = fmt.Sprintf("本季度储备金动用上限 %.1fN", limit)
+		}
+	}
+	return allowed, guard
+}
+
+// maxBudgetGrowth bounds how many times the current budget UpdateBudget accepts.
+const maxBudgetGrowth = 100
+
+// UpdateBudget sets the monthly budget and recomputes the weekly base N from
+// it by the policy's split; an allocated budget is divided between the
+// symbols by their shares, and the states returned are the primary's. The
+// balances are not touched: the new budget applies from the next replenish.
+// It fails for a non-positive budget, one more than 100 times the current, a
+// locked fund, or when the state cannot be saved, leaving the state as it was.
+func (m *Manager) UpdateBudget(newBudget float64) (before, after model.FundState, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	before = snapshot(m.root)
+	if err := m.lockedErr(); err != nil {
+		return before, before, err
+	}
+	if err := m.setBudget(m.root, newBudget); err != nil {
+		return before, before, err
+	}
+	m.checkInvariants()
 	if err := m.save(); err != nil {
+		restore(m.root, before)
+		return before, before, fmt.Errorf("保存资金状态失败: %w", err)
+	}
+	return before, snapshot(m.root), nil
+}
+
+// PreviewBudget returns the current state and the state UpdateBudget would
+// leave, without changing anything. It fails where UpdateBudget would.
+func (m *Manager) PreviewBudget(newBudget float64) (before, after model.FundState, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	before = snapshot(m.root)
+	if err := m.lockedErr(); err != nil {
+		return before, before, err
+	}
+	after = snapshot(m.root)
+	if err := m.setBudget(&after, newBudget); err != nil {
+		return before, before, err
+	}
+	return before, after, nil
+}
+
+// setBudget applies newBudget to root and every symbol under it, see
+// UpdateBudget.
+func (m *Manager) setBudget(root *model.FundState, newBudget float64) error {
+	if !(newBudget > 0) || math.IsInf(newBudget, 0) {
+		return fmt.Errorf("月度预算 %v 无效，需大于 0", newBudget)
+	}
+	if old := root.TotalBudget(); old > 0 && newBudget > maxBudgetGrowth*old {
+		return fmt.Errorf("月度预算 %s 超过当前 %s 的 %d 倍，请确认金额", model.FormatAmount(newBudget), model.FormatAmount(old), maxBudgetGrowth)
+	}
+	for _, st := range states(root) {
+		st.MonthlyBudget = newBudget * m.policy.share(st.Symbol)
+		st.WeeklyBaseN = m.policy.weeklyBase(st.MonthlyBudget)
+		if m.policy.MaxWeeklyDeployMultiple > 0 {
+			// A lower N shrinks this week's ceiling below what may be deployed already.
+			st.WeekDeployed = math.Min(st.WeekDeployed, m.policy.MaxWeeklyDeployMultiple*st.WeeklyBaseN)
+		}
+	}
+	return nil
+}
+
+// MonthlyReplenish refills both pools from the monthly budget for the current
+// month, see ReplenishMonth.
+func (m *Manager) MonthlyReplenish() model.ReplenishSplit {
+	return m.ReplenishMonth(MonthKey(m.now()))
+}
+
+// ReplenishMonth refills both pools from the monthly budget for month
+// ("2006-01"). With the adaptive split enabled, the reserve share follows the
+// trailing average score. A month up to the last one replenished is skipped,
+// so a repeated or late trigger never credits the budget twice. While
+// investing is paused under Policy.PauseReplenish the month passes with
+// nothing added. An allocated budget is credited to every symbol, each split
+// by its own scores, and the split returned totals the Parts.
+func (m *Manager) ReplenishMonth(month string) model.ReplenishSplit {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.refuseIfLocked("monthly replenish") {
+		return model.ReplenishSplit{Month: month, Reason: ErrLocked.Error()}
+	}
+	if last := m.root.LastReplenishMonth; month <= last {
+		log.Printf("[INFO] monthly replenish for %s skipped, already replenished through %s", month, last)
+		return model.ReplenishSplit{Month: month, Skipped: true, Reason: fmt.Sprintf("%s 已补充，跳过", month)}
+	}
+	all := states(m.root)
+	if m.policy.PauseReplenish && m.pausedLocked() {
+		log.Printf("[INFO] monthly replenish for %s skipped, investing is paused", month)
+		for _, st := range all {
+			st.LastReplenishMonth = month
+		}
+		if err := m.save(); errors.Is(err, ErrStateConflict) {
+			return model.ReplenishSplit{Month: month, Reason: ErrLocked.Error()}
+		} else if err != nil {
+			log.Printf("[ERROR] failed to save fund state after paused replenish: %v", err)
+		}
+		return model.ReplenishSplit{Month: month, Paused: true, Reason: "暂停投入中，未补充"}
+	}
+
+	var split model.ReplenishSplit
+	if len(all) == 1 {
+		split = m.replenish(m.root, month)
+	} else {
+		split = model.ReplenishSplit{Month: month}
+		for _, st := range all {
+			part := m.replenish(st, month)
+			split.RegularAdded += part.RegularAdded
+			split.ReserveAdded += part.ReserveAdded
+			split.CarriedOver += part.CarriedOver
+			split.Parts = append(split.Parts, part)
+		}
+		if added := split.RegularAdded + split.ReserveAdded; added > 0 {
+			split.ReserveShare = split.ReserveAdded / added
+		}
+		split.AvgScore, split.Reason = split.Parts[0].AvgScore, "按标的分配"
+	}
+	m.checkInvariants()
+
+	if err := m.save(); errors.Is(err, ErrStateConflict) {
+		return model.ReplenishSplit{Month: month, Reason: ErrLocked.Error()}
+	} else if err != nil {
 		log.Printf("[ERROR] failed to save fund state after monthly replenish: %v", err)
 	}
+	return split
+}
+
+// replenish credits st's budget for month, split by the policy and st's
+// scores, then carries the regular pool's surplus over its cap to the
+// reserve pool. Callers must hold m.mu.
+func (m *Manager) replenish(st *model.FundState, month string) model.ReplenishSplit {
+	budget := st.MonthlyBudget
+	stats := computeScoreStats(st.RecentScores, adaptiveSplitWeeks)
+	split := model.ReplenishSplit{Month: month, Symbol: st.Symbol, ReserveShare: m.policy.reserveShare(), AvgScore: stats.Avg, Reason: "固定比例"}
+
+	if m.policy.AdaptiveSplit && stats.Count == 0 {
+		split.Reason = "无历史评分，使用固定比例"
+	} else if m.policy.AdaptiveSplit {
+		split.ReserveShare = m.policy.reserveShareFor(stats.Avg)
+		switch {
+		case stats.Avg > 0.5:
+			split.Reason = fmt.Sprintf("近%d周均分 %s 偏低估，提高储备比例", stats.Count, model.FormatScore(stats.Avg))
+		case stats.Avg < -0.5:
+			split.Reason = fmt.Sprintf("近%d周均分 %s 偏高估，降低储备比例", stats.Count, model.FormatScore(stats.Avg))
+		default:
+			split.Reason = fmt.Sprintf("近%d周均分 %s 处于中性区间，使用固定比例", stats.Count, model.FormatScore(stats.Avg))
+		}
+	}
+
+	split.ReserveAdded = budget * split.ReserveShare
+	split.RegularAdded = budget - split.ReserveAdded
+	st.RegularBalance += split.RegularAdded
+	st.ReserveBalance += split.ReserveAdded
+	if limit := m.policy.regularCap(budget); limit > 0 && st.RegularBalance > limit {
+		split.CarriedOver = st.RegularBalance - limit
+		split.CarryNote = fmt.Sprintf("常规池 %s 超过 %g 个月份额上限 %s，%s 转入储备池",
+			model.FormatAmount(st.RegularBalance), m.policy.RegularCapMonths, model.FormatAmount(limit), model.FormatAmount(split.CarriedOver))
+		st.RegularBalance = limit
+		st.ReserveBalance += split.CarriedOver
+	}
+	st.LastReplenishMonth = month
+	// Dated by the month credited, so a caught-up month reads as its own.
+	if t, err := time.ParseInLocation("2006-01", month, m.now().Location()); err == nil {
+		st.LastReplenishAt = t
+	}
+	return split
+}
+
+// MissedMonths returns the months after the last one replenished through
+// through ("2006-01"), oldest first. It is empty for a state that has never
+// recorded a replenish, whose history is unknown.
+func (m *Manager) MissedMonths(through string) []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	last, err := time.Parse("2006-01", m.root.LastReplenishMonth)
+	if err != nil {
+		return nil
+	}
+	var months []string
+	for t := last.AddDate(0, 1, 0); MonthKey(t) <= through; t = t.AddDate(0, 1, 0) {
+		months = append(months, MonthKey(t))
+	}
+	return months
+}
+
+// MonthKey identifies the calendar month containing t, e.g. "2025-03".
+func MonthKey(t time.Time) string {
+	return t.Format("2006-01")
 }
 
-// QuarterlyRebalance adjusts the reserve pool:
+// QuarterKey identifies the calendar quarter containing t, e.g. "2025-Q1".
+func QuarterKey(t time.Time) string {
+	return fmt.Sprintf("%d-Q%d", t.Year(), (int(t.Month())+2)/3)
+}
+
+// QuarterlyRebalance adjusts the reserve pool once per calendar quarter:
 // - If reserve > 6N, transfer excess back to regular pool
 // - If consecutive 4+ weeks score > 1.0 and reserve < 3N, emergency top-up
-func (m *Manager) QuarterlyRebalance() string {
+//
+// The transfer stops at the regular pool's cap when Policy.RegularCapMonths
+// sets one, so it does not undo the monthly carry-over.
+// An allocated fund is rebalanced in one pass, each symbol against its own
+// N, and msg lists them by symbol. done is false when nothing ran, because
+// the fund is locked or the quarter was already rebalanced.
+func (m *Manager) QuarterlyRebalance() (msg string, done bool) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	baseN := m.state.WeeklyBaseN
-	var msg string
-
-	if m.state.ReserveBalance > 6*baseN {
-		excess := m.state.ReserveBalance - 6*baseN
-		m.state.ReserveBalance -= excess
-		m.state.RegularBalance += excess
-		msg = "储备池超额，已转回常规池"
-	} else if m.state.ConsecutiveHighScoreWeeks >= 4 && m.state.ReserveBalance < 3*baseN {
-		topUp := 3*baseN - m.state.ReserveBalance
-		m.state.ReserveBalance += topUp
-		msg = "连续高分+储备不足，紧急补充储备池"
-	} else {
-		msg = "季度再平衡：无需调整"
+	if m.refuseIfLocked("quarterly rebalance") {
+		return ErrLocked.Error(), false
+	}
+	quarter := QuarterKey(m.now())
+	if quarter <= m.root.LastRebalanceQuarter {
+		log.Printf("[INFO] quarterly rebalance for %s skipped, already done", quarter)
+		return fmt.Sprintf("%s 已再平衡，跳过", quarter), false
 	}
 
-	if err := m.save(); err != nil {
+	all := states(m.root)
+	msgs := make([]string, 0, len(all))
+	for _, st := range all {
+		msg := rebalance(st, m.policy.regularCap(st.MonthlyBudget))
+		if len(all) > 1 {
+			msg = st.Symbol + " " + msg
+		}
+		msgs = append(msgs, msg)
+		st.LastRebalanceQuarter = quarter
+		st.LastRebalanceAt = m.now()
+	}
+	m.checkInvariants()
+
+	if err := m.save(); errors.Is(err, ErrStateConflict) {
+		return ErrLocked.Error(), false
+	} else if err != nil {
 		log.Printf("[ERROR] failed to save fund state after quarterly rebalance: %v", err)
 	}
 
-	return msg
+	return strings.Join(msgs, "；"), true
 }
 
-// ResetWeeklyFlags resets per-week flags (called every Monday).
+// rebalance applies the quarterly rule to st and describes what it did.
+// regularCap bounds the regular pool the excess reserve is moved to; zero
+// means no bound.
+func rebalance(st *model.FundState, regularCap float64) string {
+	baseN := st.WeeklyBaseN
+	switch {
+	case st.ReserveBalance > 6*baseN:
+		excess := st.ReserveBalance - 6*baseN
+		if regularCap > 0 && st.RegularBalance+excess > regularCap {
+			excess = math.Max(0, regularCap-st.RegularBalance)
+			if excess == 0 {
+				return "储备池超额，常规池已达上限，未转回"
+			}
+			st.ReserveBalance -= excess
+			st.RegularBalance += excess
+			return fmt.Sprintf("储备池超额，%s 转回常规池至上限", model.FormatAmount(excess))
+		}
+		st.ReserveBalance -= excess
+		st.RegularBalance += excess
+		return "储备池超额，已转回常规池"
+	case st.ConsecutiveHighScoreWeeks >= 4 && st.ReserveBalance < 3*baseN:
+		topUp := 3*baseN - st.ReserveBalance
+		st.ReserveBalance += topUp
+		return "连续高分+储备不足，紧急补充储备池"
+	}
+	return "季度再平衡：无需调整"
+}
+
+// ResetWeeklyFlags resets per-week flags of every symbol (called every Monday).
 func (m *Manager) ResetWeeklyFlags() {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	m.state.BottomFishUsedThisWeek = false
+	if m.refuseIfLocked("weekly flag reset") {
+		return
+	}
+	for _, st := range states(m.root) {
+		st.BottomFishLevel = 0
+		st.WeekDeployed = 0
+		st.DeployWeek = isoWeekKey(m.now())
+	}
+	m.checkInvariants()
 
 	if err := m.save(); err != nil {
 		log.Printf("[ERROR] failed to save fund state after weekly reset: %v", err)
 	}
 }
 
+// save writes the state to the store; in-memory managers have none. When
+// another process saved the state meanwhile, writing over it would lose its
+// changes: the state goes back to the one last saved and the fund locks as
+// on an invariant violation. Callers must hold m.mu.
 func (m *Manager) save() error {
-	return SaveState(m.filePath, m.state)
+	if m.store == nil {
+		return nil
+	}
+	err := m.store.Save(m.root)
+	switch {
+	case errors.Is(err, ErrStateConflict):
+		restore(m.root, snapshot(&m.saved))
+		m.lock(fmt.Errorf("资金状态已被其他进程修改，本次变动已撤销: %w", err))
+	case err == nil:
+		m.saved = snapshot(m.root)
+	}
+	return err
 }
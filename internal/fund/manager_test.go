@@ -0,0 +1,553 @@
+package fund
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"MarketSentinel/internal/model"
+)
+
+func newTestManager(t *testing.T, policy Policy) *Manager {
+	t.Helper()
+	m, err := NewManager(NewFileStore(filepath.Join(t.TempDir(), "state.json")), 10000, Policy{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.SetPolicy(policy)
+	m.state.WeeklyBaseN = 1000
+	m.state.RegularBalance = 10000
+	m.state.ReserveBalance = 10000
+	return m
+}
+
+func tierSignal(multiplier, useReserve float64) *model.TradeSignal {
+	return &model.TradeSignal{Tier: model.InvestmentTier{Multiplier: multiplier, UseReserve: useReserve}}
+}
+
+func approx(a, b float64) bool { return math.Abs(a-b) < 1e-6 }
+
+// nextMonth is the month after the current one, which a fresh fund has not
+// been replenished for.
+func nextMonth() string {
+	return MonthKey(time.Now().AddDate(0, 1, 0))
+}
+
+func TestWeeklyDeployCeiling(t *testing.T) {
+	tests := []struct {
+		name            string
+		maxMultiple     float64
+		reserveBalance  float64
+		multiplier      float64
+		useReserve      float64
+		wantFinal       float64
+		wantReserveUsed float64
+		wantCapped      bool
+	}{
+		{"no ceiling", 0, 10000, 1.0, 1.5, 2500, 1500, false},
+		{"under ceiling", 2, 10000, 1.0, 0.5, 1500, 500, false},
+		{"reserve trimmed first", 2, 10000, 1.0, 1.5, 2000, 1000, true},
+		{"ceiling below regular", 0.5, 10000, 1.0, 1.0, 500, 0, true},
+		{"pool cap before ceiling", 2, 300, 1.0, 1.5, 1300, 300, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newTestManager(t, Policy{MaxWeeklyDeployMultiple: tt.maxMultiple})
+			m.state.ReserveBalance = tt.reserveBalance
+
+			sig := tierSignal(tt.multiplier, tt.useReserve)
+			final, reserve := m.CalculateWeeklyInvestment(sig)
+			if !approx(final, tt.wantFinal) || !approx(reserve, tt.wantReserveUsed) {
+				t.Errorf("got final=%.0f reserve=%.0f, want %.0f/%.0f", final, reserve, tt.wantFinal, tt.wantReserveUsed)
+			}
+			if (sig.CapNote != "") != tt.wantCapped {
+				t.Errorf("capped=%v, want %v (note %q)", sig.CapNote != "", tt.wantCapped, sig.CapNote)
+			}
+			if !approx(m.GetState().WeekDeployed, tt.wantFinal) {
+				t.Errorf("week deployed %.0f, want %.0f", m.GetState().WeekDeployed, tt.wantFinal)
+			}
+		})
+	}
+}
+
+func TestBottomFishDeployCeiling(t *testing.T) {
+	tests := []struct {
+		name          string
+		maxMultiple   float64
+		weekly        *model.TradeSignal // weekly investment made first, nil for none
+		score         float64
+		wantAmount    float64
+		wantTriggered bool
+		wantNote      bool
+	}{
+		{"no ceiling", 0, tierSignal(1.0, 1.5), 1.5, 1500, true, false},
+		{"fits under ceiling", 2, tierSignal(1.0, 0), 0.5, 1000, true, false},
+		{"trimmed to remaining", 2, tierSignal(1.0, 0.5), 1.5, 500, true, true},
+		{"ceiling exhausted", 2, tierSignal(1.0, 1.5), 1.5, 0, false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newTestManager(t, Policy{MaxWeeklyDeployMultiple: tt.maxMultiple})
+			if tt.weekly != nil {
+				m.CalculateWeeklyInvestment(tt.weekly)
+			}
+			reserveBefore := m.GetState().ReserveBalance
+
+			amount, triggered, note := m.CalculateBottomFishInvestment(tt.score, 1)
+			if !approx(amount, tt.wantAmount) || triggered != tt.wantTriggered || (note != "") != tt.wantNote {
+				t.Errorf("got amount=%.0f triggered=%v note=%q, want %.0f/%v/note=%v",
+					amount, triggered, note, tt.wantAmount, tt.wantTriggered, tt.wantNote)
+			}
+			after := m.GetState()
+			if !approx(reserveBefore-after.ReserveBalance, tt.wantAmount) {
+				t.Errorf("reserve debited %.0f, want %.0f", reserveBefore-after.ReserveBalance, tt.wantAmount)
+			}
+			if (after.BottomFishLevel == 1) != tt.wantTriggered {
+				t.Errorf("bottom-fish level %d, want triggered %v", after.BottomFishLevel, tt.wantTriggered)
+			}
+		})
+	}
+}
+
+func TestBottomFishLevels_EscalateWithinWeek(t *testing.T) {
+	m := newTestManager(t, Policy{BottomFishLevelMultipliers: []float64{1, 1.5, 2}})
+	steps := []struct {
+		level         int
+		wantAmount    float64
+		wantTriggered bool
+	}{
+		{1, 1000, true}, // RSI 29
+		{1, 0, false},   // RSI 28 again: level 1 used
+		{3, 2000, true}, // RSI 18 skips straight to level 3
+		{2, 0, false},   // RSI 23: shallower than level 3
+		{3, 0, false},   // RSI 17: level 3 used
+	}
+	for i, st := range steps {
+		amount, triggered, _ := m.CalculateBottomFishInvestment(0.5, st.level)
+		if !approx(amount, st.wantAmount) || triggered != st.wantTriggered {
+			t.Errorf("step %d level %d: got %.0f/%v, want %.0f/%v", i, st.level, amount, triggered, st.wantAmount, st.wantTriggered)
+		}
+	}
+	if got := m.GetState(); got.BottomFishLevel != 3 || !approx(got.ReserveBalance, 7000) {
+		t.Errorf("level %d, reserve %.0f after the week, want 3 and 7000", got.BottomFishLevel, got.ReserveBalance)
+	}
+
+	m.ResetWeeklyFlags()
+	if got := m.GetState().BottomFishLevel; got != 0 {
+		t.Fatalf("level %d after the Monday reset, want 0", got)
+	}
+	if amount, triggered, _ := m.CalculateBottomFishInvestment(0.5, 2); !triggered || !approx(amount, 1500) {
+		t.Errorf("level 2 in a new week: got %.0f/%v, want 1500", amount, triggered)
+	}
+}
+
+func TestLoadState_LegacyBottomFishFlag(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	if err := os.WriteFile(path, []byte(`{"monthly_budget": 10000, "bottom_fish_used_this_week": true}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	st, err := LoadState(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st.BottomFishLevel != 1 {
+		t.Errorf("level %d from the legacy flag, want 1", st.BottomFishLevel)
+	}
+}
+
+func TestWeeklyDeployCeiling_ResetsOnNewISOWeek(t *testing.T) {
+	m := newTestManager(t, Policy{MaxWeeklyDeployMultiple: 2})
+	now := time.Date(2025, 3, 7, 12, 0, 0, 0, time.UTC) // Friday
+	m.now = func() time.Time { return now }
+
+	m.CalculateWeeklyInvestment(tierSignal(1.0, 1.0))
+	if _, triggered, _ := m.CalculateBottomFishInvestment(1.5, 1); triggered {
+		t.Fatal("expected ceiling to block bottom-fish in the same week")
+	}
+
+	now = now.AddDate(0, 0, 3) // Monday of the next ISO week
+	final, _ := m.CalculateWeeklyInvestment(tierSignal(1.0, 1.0))
+	if !approx(final, 2000) {
+		t.Errorf("expected full allowance in new week, got %.0f", final)
+	}
+}
+
+func TestReserveFloor(t *testing.T) {
+	tests := []struct {
+		name            string
+		floor           float64
+		useReserve      float64
+		wantReserveUsed float64
+		wantNote        bool
+	}{
+		{"no floor", 0, 1.5, 1500, false},
+		{"above the floor", 5, 1.5, 1500, false},
+		{"floor binds", 9, 1.5, 1000, true},
+		{"at the floor", 10, 1.5, 0, true},
+		{"balance below the floor", 12, 0.5, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newTestManager(t, Policy{ReserveFloorMultiple: tt.floor})
+			sig := tierSignal(1.0, tt.useReserve)
+			final, reserve := m.CalculateWeeklyInvestment(sig)
+			if !approx(reserve, tt.wantReserveUsed) || !approx(final, 1000+tt.wantReserveUsed) {
+				t.Errorf("got final=%.0f reserve=%.0f, want reserve %.0f", final, reserve, tt.wantReserveUsed)
+			}
+			if (sig.ReserveNote != "") != tt.wantNote || tt.wantNote && !strings.Contains(sig.ReserveNote, "储备池保底") {
+				t.Errorf("note %q, want one %v", sig.ReserveNote, tt.wantNote)
+			}
+			if got := m.GetState().ReserveBalance; !approx(got, 10000-tt.wantReserveUsed) {
+				t.Errorf("reserve balance %.0f", got)
+			}
+		})
+	}
+
+	// A bottom-fish draws the reserve down to the floor, and no further.
+	m := newTestManager(t, Policy{ReserveFloorMultiple: 9.5})
+	amount, triggered, note := m.CalculateBottomFishInvestment(1.5, 1)
+	if !triggered || !approx(amount, 500) || !strings.Contains(note, "储备池保底 9.5N") {
+		t.Errorf("got %.0f/%v note %q, want 500 trimmed by the floor", amount, triggered, note)
+	}
+	if amount, triggered, note = m.CalculateBottomFishInvestment(1.5, 2); triggered || amount != 0 || note == "" {
+		t.Errorf("at the floor: got %.0f/%v note %q, want no trigger with a note", amount, triggered, note)
+	}
+	if got := m.GetState(); !approx(got.ReserveBalance, 9500) || got.BottomFishLevel != 1 {
+		t.Errorf("reserve %.0f, level %d after the floor blocked level 2", got.ReserveBalance, got.BottomFishLevel)
+	}
+}
+
+func TestQuarterlyReserveCap(t *testing.T) {
+	m := newTestManager(t, Policy{MaxQuarterlyReserveMultiple: 4})
+	now := time.Date(2025, 2, 3, 8, 0, 0, 0, time.UTC) // Monday
+	m.now = func() time.Time { return now }
+
+	for i, want := range []float64{1500, 1500, 1000, 0} {
+		sig := tierSignal(1.0, 1.5)
+		if _, reserve := m.CalculateWeeklyInvestment(sig); !approx(reserve, want) {
+			t.Errorf("week %d: reserve used %.0f, want %.0f", i+1, reserve, want)
+		}
+		if (sig.ReserveNote != "") != (want < 1500) {
+			t.Errorf("week %d: note %q", i+1, sig.ReserveNote)
+		}
+		now = now.AddDate(0, 0, 7)
+	}
+	if got := m.GetState(); !approx(got.QuarterReserveDeployed, 4000) || got.ReserveQuarter != "2025-Q1" {
+		t.Errorf("quarter deployed %.0f in %q, want 4000 in 2025-Q1", got.QuarterReserveDeployed, got.ReserveQuarter)
+	}
+	if _, triggered, note := m.CalculateBottomFishInvestment(1.5, 1); triggered || !strings.Contains(note, "本季度储备金动用上限 4.0N") {
+		t.Errorf("bottom-fish past the cap: triggered %v, note %q", triggered, note)
+	}
+
+	// A new quarter starts with the whole allowance.
+	now = time.Date(2025, 4, 7, 8, 0, 0, 0, time.UTC)
+	if _, reserve := m.CalculateWeeklyInvestment(tierSignal(1.0, 1.5)); !approx(reserve, 1500) {
+		t.Errorf("new quarter: reserve used %.0f, want 1500", reserve)
+	}
+	if amount, triggered, _ := m.CalculateBottomFishInvestment(1.5, 1); !triggered || !approx(amount, 1500) {
+		t.Errorf("new quarter bottom-fish: got %.0f/%v, want 1500", amount, triggered)
+	}
+	if amount, triggered, note := m.CalculateBottomFishInvestment(1.5, 2); !triggered || !approx(amount, 1000) || note == "" {
+		t.Errorf("bottom-fish reaching the cap: got %.0f/%v note %q, want 1000 with a note", amount, triggered, note)
+	}
+}
+
+func TestRounding(t *testing.T) {
+	tests := []struct {
+		name            string
+		rounding        Rounding
+		regularBalance  float64
+		multiplier      float64
+		useReserve      float64
+		wantFinal       float64
+		wantReserveUsed float64
+	}{
+		{"off", Rounding{}, 10000, 1.154, 0, 1154, 0},
+		{"nearest", Rounding{Increment: 100}, 10000, 1.154, 0, 1200, 0},
+		{"down", Rounding{Mode: RoundDown, Increment: 100}, 10000, 1.154, 0, 1100, 0},
+		{"up", Rounding{Mode: RoundUp, Increment: 100}, 10000, 1.104, 0, 1200, 0},
+		{"exact", Rounding{Mode: RoundUp, Increment: 100}, 10000, 1.1, 0, 1100, 0},
+		{"up past the pool rounds down", Rounding{Mode: RoundUp, Increment: 100}, 1154, 1.154, 0, 1100, 0},
+		{"down spares the reserve", Rounding{Mode: RoundDown, Increment: 1000}, 10000, 1.0, 0.54, 1000, 540},
+		{"up draws on the regular pool", Rounding{Mode: RoundUp, Increment: 1000}, 10000, 1.0, 0.54, 2000, 540},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newTestManager(t, Policy{Rounding: tt.rounding})
+			m.state.RegularBalance = tt.regularBalance
+			sig := tierSignal(tt.multiplier, tt.useReserve)
+			final, reserve := m.CalculateWeeklyInvestment(sig)
+			if !approx(final, tt.wantFinal) || !approx(reserve, tt.wantReserveUsed) {
+				t.Errorf("got final=%.2f reserve=%.2f, want %.0f/%.0f", final, reserve, tt.wantFinal, tt.wantReserveUsed)
+			}
+			// The remainder stays in the pools.
+			st := m.GetState()
+			if !approx(st.RegularBalance+st.ReserveBalance, tt.regularBalance+10000-tt.wantFinal) {
+				t.Errorf("pools %.2f/%.2f after investing %.0f", st.RegularBalance, st.ReserveBalance, tt.wantFinal)
+			}
+			raw := 1000 * (tt.multiplier + tt.useReserve)
+			if changed := !approx(raw, tt.wantFinal); (sig.RoundingNote != "") != changed {
+				t.Errorf("note %q, want one %v", sig.RoundingNote, changed)
+			}
+		})
+	}
+}
+
+func TestRounding_BelowOneIncrementSkips(t *testing.T) {
+	m := newTestManager(t, Policy{Rounding: Rounding{Mode: RoundDown, Increment: 100}})
+	before := m.GetState()
+	sig := tierSignal(0.054, 0)
+	if final, reserve := m.CalculateWeeklyInvestment(sig); final != 0 || reserve != 0 {
+		t.Errorf("got %.2f/%.2f, want nothing invested", final, reserve)
+	}
+	if !strings.Contains(sig.RoundingNote, "不足最小单位 ¥100，本次跳过") || !approx(sig.RawAmount, 54) {
+		t.Errorf("note %q, raw %.2f", sig.RoundingNote, sig.RawAmount)
+	}
+	if st := m.GetState(); st.RegularBalance != before.RegularBalance || st.WeekDeployed != 0 {
+		t.Errorf("skipped investment debited the pool: %.2f -> %.2f", before.RegularBalance, st.RegularBalance)
+	}
+
+	// A bottom-fish below one increment does not trigger.
+	m.SetPolicy(Policy{Rounding: Rounding{Mode: RoundDown, Increment: 2000}})
+	amount, triggered, note := m.CalculateBottomFishInvestment(1.5, 1)
+	if triggered || amount != 0 || !strings.Contains(note, "抄底金额 ¥1,500 不足最小单位 ¥2,000") {
+		t.Errorf("got %.0f/%v note %q, want no trigger with a note", amount, triggered, note)
+	}
+	if st := m.GetState(); st.ReserveBalance != before.ReserveBalance || st.BottomFishLevel != 0 {
+		t.Errorf("skipped bottom-fish changed the fund: %+v", st)
+	}
+
+	m.SetPolicy(Policy{Rounding: Rounding{Mode: RoundUp, Increment: 400}})
+	if amount, triggered, note = m.CalculateBottomFishInvestment(1.5, 1); !triggered || amount != 1600 || note == "" {
+		t.Errorf("rounded up: got %.0f/%v note %q, want 1600 with a note", amount, triggered, note)
+	}
+}
+
+func TestLowParticipationTracking(t *testing.T) {
+	m := newTestManager(t, Policy{NudgeAfterWeeks: 3})
+
+	for week := 1; week <= 3; week++ {
+		m.CalculateWeeklyInvestment(tierSignal(0.15, 0))
+		st := m.GetState()
+		if st.LowParticipationWeeks != week || !approx(st.LowParticipationShortfall, float64(week)*850) {
+			t.Fatalf("week %d: got %d weeks, shortfall %.0f", week, st.LowParticipationWeeks, st.LowParticipationShortfall)
+		}
+		if due := m.ParticipationNudgeDue(&st); due != (week >= 3) {
+			t.Errorf("week %d: nudge due = %v", week, due)
+		}
+	}
+
+	m.CalculateWeeklyInvestment(tierSignal(1.0, 0))
+	if st := m.GetState(); st.LowParticipationWeeks != 0 || st.LowParticipationShortfall != 0 {
+		t.Errorf("expected reset at 1.0x, got %d weeks, shortfall %.0f", st.LowParticipationWeeks, st.LowParticipationShortfall)
+	}
+}
+
+func TestMemoryManager_NeverWritesAndUsesItsClock(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+	day := time.Date(2020, 3, 20, 0, 0, 0, 0, time.UTC)
+	start := InitialState(10000, Policy{})
+	m := NewMemoryManager(start, func() time.Time { return day })
+
+	m.ResetWeeklyFlags()
+	amount, _ := m.CalculateWeeklyInvestment(tierSignal(1.0, 0))
+	if !approx(amount, start.WeeklyBaseN) {
+		t.Errorf("invested %.2f, want N = %.2f", amount, start.WeeklyBaseN)
+	}
+	st := m.GetState()
+	if st.DeployWeek != "2020-W12" {
+		t.Errorf("deploy week %q, want the simulated 2020-W12", st.DeployWeek)
+	}
+	if !approx(st.RegularBalance, start.RegularBalance-amount) {
+		t.Errorf("regular balance %.2f, want %.2f", st.RegularBalance, start.RegularBalance-amount)
+	}
+	if len(start.RecentScores) != 0 {
+		t.Error("the starting state was modified")
+	}
+	if entries, _ := filepath.Glob(filepath.Join(dir, "*")); len(entries) != 0 {
+		t.Errorf("in-memory manager wrote %v", entries)
+	}
+}
+
+func TestUpdateBudget(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	m, err := NewManager(NewFileStore(path), 10000, Policy{MaxWeeklyDeployMultiple: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.state.WeekDeployed = 3000
+
+	before, after, err := m.UpdateBudget(5000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if after.MonthlyBudget != 5000 || math.Abs(after.WeeklyBaseN-5000*0.7/4.33) > 1e-9 {
+		t.Errorf("after = budget %.0f, N %.2f", after.MonthlyBudget, after.WeeklyBaseN)
+	}
+	if after.RegularBalance != before.RegularBalance || after.ReserveBalance != before.ReserveBalance {
+		t.Errorf("balances changed: %+v -> %+v", before, after)
+	}
+	// The lower N shrinks this week's ceiling below what was deployed;
+	// nothing more goes out this week, and the fund stays unlocked.
+	if err := m.Locked(); err != nil {
+		t.Errorf("budget cut locked the fund: %v", err)
+	}
+	if math.Abs(after.WeekDeployed-2*after.WeeklyBaseN) > 1e-9 {
+		t.Errorf("week deployed %.2f, want the new ceiling %.2f", after.WeekDeployed, 2*after.WeeklyBaseN)
+	}
+
+	loaded, err := LoadState(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.MonthlyBudget != 5000 || loaded.WeeklyBaseN != after.WeeklyBaseN {
+		t.Errorf("saved budget %.0f, N %.2f", loaded.MonthlyBudget, loaded.WeeklyBaseN)
+	}
+	if _, err := os.Stat(path + ".tmp"); !os.IsNotExist(err) {
+		t.Errorf("temporary file left behind: %v", err)
+	}
+
+	if split := m.ReplenishMonth(nextMonth()); split.RegularAdded+split.ReserveAdded != 5000 {
+		t.Errorf("replenished %.0f, want the new budget", split.RegularAdded+split.ReserveAdded)
+	}
+}
+
+func TestUpdateBudget_Rejects(t *testing.T) {
+	m := newTestManager(t, Policy{})
+	before := m.GetState()
+	for _, budget := range []float64{0, -15000, math.NaN(), math.Inf(1), 100*before.MonthlyBudget + 1} {
+		if _, _, err := m.UpdateBudget(budget); err == nil {
+			t.Errorf("budget %v accepted", budget)
+		}
+	}
+	if after := m.GetState(); after.MonthlyBudget != before.MonthlyBudget || after.WeeklyBaseN != before.WeeklyBaseN {
+		t.Errorf("rejected budgets changed the state: %+v", after)
+	}
+	if _, _, err := m.UpdateBudget(100 * before.MonthlyBudget); err != nil {
+		t.Errorf("100x the budget rejected: %v", err)
+	}
+}
+
+func TestReplenishMonth_OncePerMonth(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	m, err := NewManager(NewFileStore(path), 10000, Policy{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2025, 1, 15, 9, 0, 0, 0, time.UTC)
+	m.now = func() time.Time { return now }
+	m.state.LastReplenishMonth = "2024-11"
+
+	// Down over December and into January.
+	if got := m.MissedMonths("2025-01"); strings.Join(got, ",") != "2024-12,2025-01" {
+		t.Errorf("missed months %v, want 2024-12 and 2025-01", got)
+	}
+	before := m.GetState()
+	for _, month := range m.MissedMonths("2025-01") {
+		if split := m.ReplenishMonth(month); split.Skipped || split.Month != month {
+			t.Errorf("catch-up of %s: %+v", month, split)
+		}
+	}
+	if st := m.GetState(); !approx(st.RegularBalance+st.ReserveBalance, before.RegularBalance+before.ReserveBalance+20000) {
+		t.Errorf("pools %.0f/%.0f after two months caught up", st.RegularBalance, st.ReserveBalance)
+	}
+
+	// The cron run and a restart in the same month add nothing more, nor does
+	// an earlier month.
+	credited := m.GetState()
+	for _, split := range []model.ReplenishSplit{m.MonthlyReplenish(), m.MonthlyReplenish(), m.ReplenishMonth("2024-12")} {
+		if !split.Skipped || split.RegularAdded != 0 || split.ReserveAdded != 0 {
+			t.Errorf("repeated replenish: %+v", split)
+		}
+	}
+	if st := m.GetState(); st.RegularBalance != credited.RegularBalance || st.ReserveBalance != credited.ReserveBalance {
+		t.Errorf("repeated replenish changed the pools: %+v", st)
+	}
+	if got := m.MissedMonths("2025-01"); len(got) != 0 {
+		t.Errorf("missed months after catching up: %v", got)
+	}
+
+	loaded, err := LoadState(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.LastReplenishMonth != "2025-01" {
+		t.Errorf("saved last replenish month %q", loaded.LastReplenishMonth)
+	}
+}
+
+func TestReplenishMonth_FreshAndLegacyState(t *testing.T) {
+	m, err := NewManager(NewFileStore(filepath.Join(t.TempDir(), "state.json")), 10000, Policy{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The first month's budget is in the initial balances.
+	if split := m.MonthlyReplenish(); !split.Skipped {
+		t.Errorf("fresh fund credited its first month twice: %+v", split)
+	}
+
+	// A state saved before months were tracked has no known gap.
+	m.state.LastReplenishMonth = ""
+	if got := m.MissedMonths("2030-01"); len(got) != 0 {
+		t.Errorf("legacy state missed months %v", got)
+	}
+	if split := m.MonthlyReplenish(); split.Skipped {
+		t.Errorf("legacy state not replenished: %+v", split)
+	}
+}
+
+func TestQuarterlyRebalance_OncePerQuarter(t *testing.T) {
+	now := time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC)
+	m := NewMemoryManager(InitialState(10000, Policy{}), func() time.Time { return now })
+
+	if _, done := m.QuarterlyRebalance(); !done {
+		t.Fatal("first rebalance of the quarter skipped")
+	}
+	now = now.AddDate(0, 2, 0)
+	if msg, done := m.QuarterlyRebalance(); done || !strings.Contains(msg, "2025-Q1 已再平衡") {
+		t.Errorf("second rebalance in the quarter: %q, done %v", msg, done)
+	}
+	now = now.AddDate(0, 1, 0)
+	if _, done := m.QuarterlyRebalance(); !done {
+		t.Error("next quarter's rebalance skipped")
+	}
+	if got := QuarterKey(time.Date(2025, 12, 31, 0, 0, 0, 0, time.UTC)); got != "2025-Q4" {
+		t.Errorf("QuarterKey = %q", got)
+	}
+}
+
+func TestRegularCap(t *testing.T) {
+	// Two months of the 7000 regular share: 14000.
+	m := newTestManager(t, Policy{RegularCapMonths: 2})
+	now := time.Now()
+	m.now = func() time.Time { return now }
+
+	split := m.ReplenishMonth(nextMonth())
+	if !approx(split.CarriedOver, 3000) || !strings.Contains(split.CarryNote, "转入储备池") {
+		t.Errorf("split %+v", split)
+	}
+	if st := m.GetState(); !approx(st.RegularBalance, 14000) || !approx(st.ReserveBalance, 16000) {
+		t.Errorf("pools %.2f/%.2f after the carry-over", st.RegularBalance, st.ReserveBalance)
+	}
+
+	// The reserve over 6N is not moved back past the cap.
+	if msg, _ := m.QuarterlyRebalance(); !strings.Contains(msg, "未转回") {
+		t.Errorf("rebalance at the cap: %q", msg)
+	}
+	m.MutateStateForTest(func(st *model.FundState) { st.RegularBalance = 10000 })
+	now = now.AddDate(0, 3, 0)
+	m.QuarterlyRebalance()
+	if st := m.GetState(); !approx(st.RegularBalance, 14000) || !approx(st.ReserveBalance, 12000) {
+		t.Errorf("pools %.2f/%.2f after rebalancing up to the cap", st.RegularBalance, st.ReserveBalance)
+	}
+
+	// Off by default.
+	m = newTestManager(t, Policy{})
+	if split := m.ReplenishMonth(nextMonth()); split.CarriedOver != 0 || m.GetState().RegularBalance != 17000 {
+		t.Errorf("uncapped split %+v", split)
+	}
+}
@@ -0,0 +1,88 @@
+package fund
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"MarketSentinel/internal/model"
+)
+
+// Pause stops investing until Resume, or until until when it is not zero:
+// weekly and bottom-fish investments are reported but not made, and with
+// Policy.PauseReplenish the monthly budget is not credited either, for every
+// symbol of an allocated fund. Pausing a paused fund moves its end to until.
+// It fails for an until already past, or when the state cannot be saved,
+// leaving the state as it was.
+func (m *Manager) Pause(until time.Time) (before, after model.FundState, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	before = snapshot(m.root)
+	if !until.IsZero() && !until.After(m.now()) {
+		return before, before, fmt.Errorf("暂停截止时间 %s 已过", until.Format("2006-01-02"))
+	}
+	m.root.Paused, m.root.PausedUntil = true, until
+	if err := m.save(); err != nil {
+		restore(m.root, before)
+		return before, before, fmt.Errorf("保存资金状态失败: %w", err)
+	}
+	return before, snapshot(m.root), nil
+}
+
+// Resume lifts a pause. It fails when investing is not paused, or when the
+// state cannot be saved, leaving the state as it was.
+func (m *Manager) Resume() (before, after model.FundState, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	before = snapshot(m.root)
+	if !m.root.Paused {
+		return before, before, errors.New("当前未暂停投入")
+	}
+	m.root.Paused, m.root.PausedUntil = false, time.Time{}
+	if err := m.save(); err != nil {
+		restore(m.root, before)
+		return before, before, fmt.Errorf("保存资金状态失败: %w", err)
+	}
+	return before, snapshot(m.root), nil
+}
+
+// IsPaused reports whether investing is paused now; a pause whose
+// PausedUntil has passed no longer counts, see ResumeIfExpired.
+func (m *Manager) IsPaused() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.pausedLocked()
+}
+
+// ResumeIfExpired lifts a pause whose PausedUntil has passed, and reports
+// whether it did along with the states around it.
+func (m *Manager) ResumeIfExpired() (before, after model.FundState, resumed bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	before = snapshot(m.root)
+	if !m.root.Paused || m.pausedLocked() {
+		return before, before, false
+	}
+	m.root.Paused, m.root.PausedUntil = false, time.Time{}
+	if err := m.save(); err != nil {
+		restore(m.root, before)
+		return before, before, false
+	}
+	return before, snapshot(m.root), true
+}
+
+// pausedLocked is IsPaused for callers holding m.mu.
+func (m *Manager) pausedLocked() bool {
+	return m.root.Paused && (m.root.PausedUntil.IsZero() || m.now().Before(m.root.PausedUntil))
+}
+
+// PauseReplenish reports whether the monthly replenish stops too while
+// investing is paused.
+func (m *Manager) PauseReplenish() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.policy.PauseReplenish
+}
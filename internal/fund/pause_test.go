@@ -0,0 +1,103 @@
+package fund
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPauseResume(t *testing.T) {
+	m := newTestManager(t, Policy{})
+	now := time.Date(2025, 3, 3, 8, 0, 0, 0, time.UTC)
+	m.now = func() time.Time { return now }
+
+	if _, _, err := m.Resume(); err == nil {
+		t.Error("resume of a fund not paused accepted")
+	}
+	if _, _, err := m.Pause(now.Add(-time.Hour)); err == nil {
+		t.Error("pause until the past accepted")
+	}
+
+	_, after, err := m.Pause(time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !after.Paused || !m.IsPaused() {
+		t.Fatal("not paused")
+	}
+	if _, _, resumed := m.ResumeIfExpired(); resumed {
+		t.Error("a pause without an end expired")
+	}
+	loaded, err := m.store.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !loaded.Paused {
+		t.Error("pause not saved")
+	}
+
+	if _, after, err = m.Resume(); err != nil || after.Paused || m.IsPaused() {
+		t.Errorf("resume: err %v, paused %v", err, after.Paused)
+	}
+}
+
+func TestPause_ExpiresAtUntil(t *testing.T) {
+	m := newTestManager(t, Policy{})
+	now := time.Date(2025, 3, 3, 8, 0, 0, 0, time.UTC)
+	m.now = func() time.Time { return now }
+
+	until := now.AddDate(0, 0, 14)
+	if _, _, err := m.Pause(until); err != nil {
+		t.Fatal(err)
+	}
+	now = until.Add(-time.Minute)
+	if !m.IsPaused() {
+		t.Error("not paused a minute before the end")
+	}
+	if _, _, resumed := m.ResumeIfExpired(); resumed {
+		t.Error("resumed before the end")
+	}
+
+	now = until
+	if m.IsPaused() {
+		t.Error("still paused at the end")
+	}
+	before, after, resumed := m.ResumeIfExpired()
+	if !resumed || !before.Paused || after.Paused || !after.PausedUntil.IsZero() {
+		t.Errorf("resumed %v: %+v -> %+v", resumed, before, after)
+	}
+	if _, _, resumed = m.ResumeIfExpired(); resumed {
+		t.Error("resumed twice")
+	}
+}
+
+func TestPause_Replenish(t *testing.T) {
+	// By default the budget keeps being credited while paused.
+	m := newTestManager(t, Policy{})
+	if _, _, err := m.Pause(time.Time{}); err != nil {
+		t.Fatal(err)
+	}
+	if split := m.ReplenishMonth(nextMonth()); split.Paused || split.RegularAdded+split.ReserveAdded != 10000 {
+		t.Errorf("replenish while paused: %+v", split)
+	}
+
+	m = newTestManager(t, Policy{PauseReplenish: true})
+	if _, _, err := m.Pause(time.Time{}); err != nil {
+		t.Fatal(err)
+	}
+	before := m.GetState()
+	split := m.ReplenishMonth(nextMonth())
+	if !split.Paused || split.RegularAdded+split.ReserveAdded != 0 {
+		t.Errorf("replenish with PauseReplenish: %+v", split)
+	}
+	after := m.GetState()
+	if after.RegularBalance != before.RegularBalance || after.ReserveBalance != before.ReserveBalance {
+		t.Errorf("pools changed while paused: %+v -> %+v", before, after)
+	}
+	// The paused month is passed over, not caught up after resuming.
+	if _, _, err := m.Resume(); err != nil {
+		t.Fatal(err)
+	}
+	if missed := m.MissedMonths(nextMonth()); len(missed) != 0 {
+		t.Errorf("missed months %v after resuming", missed)
+	}
+}
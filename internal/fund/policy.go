@@ -0,0 +1,200 @@
+package fund
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"MarketSentinel/internal/calculator"
+)
+
+// Default pool split of the monthly budget, and the weeks per month the
+// regular share is spread over.
+const (
+	defaultRegularShare  = 0.70
+	defaultWeeksPerMonth = 4.33
+)
+
+// Policy holds optional fund rules layered on top of the static dual-pool model.
+// The zero value reproduces the original static behaviour.
+type Policy struct {
+	// RegularShare is the fraction of the monthly budget sent to the regular
+	// pool, the reserve pool getting the rest; zero means 70%.
+	RegularShare float64
+	// WeeksPerMonth divides the regular share of the budget into the weekly
+	// base N of a fresh fund; zero means 4.33.
+	WeeksPerMonth float64
+
+	// AdaptiveSplit scales the reserve share of each monthly replenish with the
+	// trailing average score, between MinReserveShare and MaxReserveShare.
+	AdaptiveSplit   bool
+	MinReserveShare float64
+	MaxReserveShare float64
+
+	// MaxWeeklyDeployMultiple caps total deployment (weekly + bottom-fish) within
+	// one ISO week at this multiple of WeeklyBaseN. Zero means no ceiling.
+	MaxWeeklyDeployMultiple float64
+
+	// ReserveFloorMultiple keeps this multiple of WeeklyBaseN in the reserve
+	// pool: weekly and bottom-fish deployments draw it down to the floor at
+	// most. Zero means no floor.
+	ReserveFloorMultiple float64
+	// MaxQuarterlyReserveMultiple caps the reserve deployed (weekly +
+	// bottom-fish) within one calendar quarter at this multiple of
+	// WeeklyBaseN. Zero means no cap.
+	MaxQuarterlyReserveMultiple float64
+
+	// NudgeAfterWeeks is how many consecutive weeks below 1.0× trigger a reminder
+	// in the weekly report. Zero disables the reminder.
+	NudgeAfterWeeks int
+
+	// PauseReplenish stops the monthly replenish too while investing is
+	// paused; the months paused are skipped, not caught up on resume.
+	PauseReplenish bool
+
+	// RegularCapMonths caps the regular pool at this many months of its
+	// share of the budget: the monthly replenish carries the surplus over to
+	// the reserve pool, and the quarterly rebalance moves no more of the
+	// reserve back than fits under the cap. Zero means no cap.
+	RegularCapMonths float64
+
+	// Rounding rounds weekly and bottom-fish amounts to whole lots before
+	// the pools are debited; the zero value leaves them as computed.
+	Rounding Rounding
+
+	// BottomFishLevelMultipliers scale the bottom-fish amount of level 1, 2,
+	// ... on top of the score-based multiplier; levels past the end use 1.
+	BottomFishLevelMultipliers []float64
+
+	// Symbol is the primary symbol, whose sub-state is the top level of the
+	// fund state. Allocation divides the monthly budget between it and other
+	// symbols by share, the shares summing to 1, each symbol investing from
+	// pools of its own; empty means the whole budget tracks the primary.
+	Symbol     string
+	Allocation map[string]float64
+}
+
+// Modes of a Rounding.
+const (
+	RoundNearest = "nearest"
+	RoundDown    = "down"
+	RoundUp      = "up"
+)
+
+// Rounding rounds an investment amount to a multiple of Increment, e.g. 100
+// for a broker taking orders in hundreds, by Mode; an empty Mode is
+// RoundNearest. A zero Increment disables rounding.
+type Rounding struct {
+	Mode      string
+	Increment float64
+}
+
+// round rounds amount to a multiple of the increment, rounding down instead
+// when rounding up would exceed limit, the most that can be funded.
+func (r Rounding) round(amount, limit float64) float64 {
+	// The tolerance keeps 1100 from rounding to 1200 up or 1000 down when
+	// float arithmetic left it a hair off.
+	lots := amount / r.Increment
+	var n float64
+	switch r.Mode {
+	case RoundDown:
+		n = math.Floor(lots + 1e-9)
+	case RoundUp:
+		n = math.Ceil(lots - 1e-9)
+	default:
+		n = math.Round(lots)
+	}
+	if n*r.Increment > limit+1e-9 {
+		n = math.Floor(lots + 1e-9)
+	}
+	return n * r.Increment
+}
+
+// regularShare is the configured regular share of the budget.
+func (p Policy) regularShare() float64 {
+	if p.RegularShare <= 0 {
+		return defaultRegularShare
+	}
+	return p.RegularShare
+}
+
+// reserveShare is the static reserve share of the budget.
+func (p Policy) reserveShare() float64 {
+	// Rounded so 1-0.7 is 0.3 and a budget splits into whole amounts.
+	return math.Round((1-p.regularShare())*1e9) / 1e9
+}
+
+// regularCap is the most the regular pool of a monthlyBudget holds after a
+// replenish, zero when it is not capped.
+func (p Policy) regularCap(monthlyBudget float64) float64 {
+	return p.RegularCapMonths * monthlyBudget * p.regularShare()
+}
+
+// share is the fraction of the monthly budget allocated to symbol, 1 when
+// the budget is not allocated.
+func (p Policy) share(symbol string) float64 {
+	if len(p.Allocation) == 0 {
+		return 1
+	}
+	return p.Allocation[symbol]
+}
+
+// weeklyBase is the weekly base N of monthlyBudget.
+func (p Policy) weeklyBase(monthlyBudget float64) float64 {
+	weeks := p.WeeksPerMonth
+	if weeks <= 0 {
+		weeks = defaultWeeksPerMonth
+	}
+	return monthlyBudget * p.regularShare() / weeks
+}
+
+// levelMultiplier is the multiplier of bottom-fish level, counted from 1.
+func (p Policy) levelMultiplier(level int) float64 {
+	if level < 1 || level > len(p.BottomFishLevelMultipliers) {
+		return 1
+	}
+	return p.BottomFishLevelMultipliers[level-1]
+}
+
+// adaptiveSplitWeeks is the trailing window of scores used by the adaptive split.
+const adaptiveSplitWeeks = 8
+
+// ScoreStats summarizes a trailing window of weekly scores.
+type ScoreStats struct {
+	Count  int
+	Avg    float64
+	StdDev float64
+}
+
+// computeScoreStats summarizes the trailing weeks of scores with
+// calculator.ScoreStats, as the monthly report does.
+func computeScoreStats(scores []float64, weeks int) ScoreStats {
+	if len(scores) > weeks {
+		scores = scores[len(scores)-weeks:]
+	}
+	avg, stddev, _ := calculator.ScoreStats(scores)
+	return ScoreStats{Count: len(scores), Avg: avg, StdDev: stddev}
+}
+
+// reserveShareFor maps a trailing average score to the reserve share of the budget.
+// Between -0.5 and +0.5 the static share applies; beyond that the share moves
+// linearly towards the bound, reaching it at ±1.5.
+func (p Policy) reserveShareFor(avg float64) float64 {
+	static := p.reserveShare()
+	switch {
+	case avg > 0.5:
+		t := math.Min((avg-0.5)/1.0, 1)
+		return static + t*(p.MaxReserveShare-static)
+	case avg < -0.5:
+		t := math.Min((-0.5-avg)/1.0, 1)
+		return static - t*(static-p.MinReserveShare)
+	default:
+		return static
+	}
+}
+
+// isoWeekKey identifies the ISO week containing t.
+func isoWeekKey(t time.Time) string {
+	y, w := t.ISOWeek()
+	return fmt.Sprintf("%d-W%02d", y, w)
+}
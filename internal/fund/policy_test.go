@@ -0,0 +1,97 @@
+package fund
+
+import (
+	"math"
+	"path/filepath"
+	"testing"
+)
+
+func TestReserveShareFor(t *testing.T) {
+	p := Policy{AdaptiveSplit: true, MinReserveShare: 0.20, MaxReserveShare: 0.40}
+	tests := []struct {
+		avg  float64
+		want float64
+	}{
+		{0, 0.30},
+		{0.5, 0.30},
+		{-0.5, 0.30},
+		{1.0, 0.35},
+		{1.5, 0.40},
+		{3.0, 0.40},
+		{-1.0, 0.25},
+		{-2.0, 0.20},
+	}
+	for _, tt := range tests {
+		if got := p.reserveShareFor(tt.avg); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("avg %+.1f: expected share %.2f, got %.4f", tt.avg, tt.want, got)
+		}
+	}
+}
+
+func TestMonthlyReplenish_StaticByDefault(t *testing.T) {
+	m, err := NewManager(NewFileStore(filepath.Join(t.TempDir(), "state.json")), 10000, Policy{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.state.RecentScores = []float64{1.5, 1.5, 1.5}
+
+	split := m.ReplenishMonth(nextMonth())
+	if split.RegularAdded != 7000 || split.ReserveAdded != 3000 {
+		t.Errorf("expected static 7000/3000, got %.0f/%.0f", split.RegularAdded, split.ReserveAdded)
+	}
+}
+
+func TestMonthlyReplenish_Adaptive(t *testing.T) {
+	m, err := NewManager(NewFileStore(filepath.Join(t.TempDir(), "state.json")), 10000, Policy{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.SetPolicy(Policy{AdaptiveSplit: true, MinReserveShare: 0.20, MaxReserveShare: 0.40})
+	before := m.GetState()
+
+	// Only the trailing 8 weeks count: the early -2 scores are ignored.
+	m.state.RecentScores = []float64{-2, -2, 1.5, 1.5, 1.5, 1.5, 1.5, 1.5, 1.5, 1.5}
+	split := m.ReplenishMonth(nextMonth())
+	if math.Abs(split.ReserveAdded-4000) > 1e-6 || math.Abs(split.RegularAdded-6000) > 1e-6 {
+		t.Errorf("expected 6000/4000 split, got %.0f/%.0f", split.RegularAdded, split.ReserveAdded)
+	}
+	after := m.GetState()
+	if math.Abs(after.ReserveBalance-before.ReserveBalance-4000) > 1e-6 {
+		t.Errorf("reserve pool not credited with split amount")
+	}
+}
+
+func TestConfiguredSplit(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	m, err := NewManager(NewFileStore(path), 10000, Policy{RegularShare: 0.8, WeeksPerMonth: 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	st := m.GetState()
+	if math.Abs(st.RegularBalance-8000) > 1e-9 || math.Abs(st.ReserveBalance-2000) > 1e-9 || math.Abs(st.WeeklyBaseN-2000) > 1e-9 {
+		t.Errorf("fresh state %.0f/%.0f, N %.0f; want 8000/2000, N 2000", st.RegularBalance, st.ReserveBalance, st.WeeklyBaseN)
+	}
+
+	// Reopened with another split, the saved balances and N stay; only the
+	// next replenish follows the new split.
+	m, err = NewManager(NewFileStore(path), 10000, Policy{RegularShare: 0.6})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reopened := m.GetState(); reopened.RegularBalance != st.RegularBalance || reopened.ReserveBalance != st.ReserveBalance || reopened.WeeklyBaseN != st.WeeklyBaseN {
+		t.Errorf("reopened state %+v, want the saved %+v", reopened, st)
+	}
+	split := m.ReplenishMonth(nextMonth())
+	if math.Abs(split.RegularAdded-6000) > 1e-9 || math.Abs(split.ReserveAdded-4000) > 1e-9 || math.Abs(split.ReserveShare-0.4) > 1e-9 {
+		t.Errorf("replenish %.0f/%.0f (reserve share %.2f), want 6000/4000", split.RegularAdded, split.ReserveAdded, split.ReserveShare)
+	}
+
+	// The adaptive split moves around the configured share.
+	p := Policy{RegularShare: 0.6, AdaptiveSplit: true, MinReserveShare: 0.2, MaxReserveShare: 0.6}
+	if got := p.reserveShareFor(0); math.Abs(got-0.4) > 1e-9 {
+		t.Errorf("neutral share %.2f, want 0.40", got)
+	}
+	if got := p.reserveShareFor(1); math.Abs(got-0.5) > 1e-9 {
+		t.Errorf("share at +1.0 %.2f, want 0.50", got)
+	}
+}
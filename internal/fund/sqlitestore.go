@@ -0,0 +1,161 @@
+package fund
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"MarketSentinel/internal/model"
+
+	_ "modernc.org/sqlite"
+)
+
+// ErrStateConflict is returned by SQLiteStore.Save when another process
+// saved the state since this store last loaded or saved it.
+var ErrStateConflict = errors.New("fund state was changed by another process")
+
+// SQLiteStore keeps the fund state as JSON in a single-row table of a SQLite
+// database, usually the recorder's. Each save bumps a version and fails with
+// ErrStateConflict unless the row still has the version this store last
+// read or wrote, so two processes sharing the database cannot overwrite each
+// other's state unnoticed.
+type SQLiteStore struct {
+	db *sql.DB
+
+	mu      sync.Mutex
+	version int64 // of the row last loaded or saved, 0 before the first save
+}
+
+// OpenSQLiteStore opens (or creates) the fund_state table of the SQLite
+// database at dbPath.
+func OpenSQLiteStore(dbPath string) (*SQLiteStore, error) {
+	if err := os.MkdirAll(filepath.Dir(dbPath), 0o755); err != nil {
+		return nil, fmt.Errorf("create database dir: %w", err)
+	}
+	db, err := sql.Open("sqlite", dbPath)
+	if err != nil {
+		return nil, fmt.Errorf("open sqlite: %w", err)
+	}
+	// The recorder writes to the same database; wait for its transactions
+	// rather than failing a save.
+	for _, pragma := range []string{"PRAGMA journal_mode=WAL", "PRAGMA busy_timeout=5000"} {
+		if _, err := db.Exec(pragma); err != nil {
+			db.Close()
+			return nil, fmt.Errorf("%s: %w", pragma, err)
+		}
+	}
+	if _, err := db.Exec(`CREATE TABLE IF NOT EXISTS fund_state (
+		id         INTEGER PRIMARY KEY CHECK (id = 1),
+		version    INTEGER NOT NULL,
+		state      TEXT NOT NULL,
+		updated_at INTEGER NOT NULL
+	)`); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("create fund_state: %w", err)
+	}
+	return &SQLiteStore{db: db}, nil
+}
+
+// Load returns the saved state, or a zero state when none was saved yet, and
+// remembers its version for the next Save.
+func (s *SQLiteStore) Load() (*model.FundState, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var (
+		version int64
+		data    string
+	)
+	err := s.db.QueryRow(`SELECT version, state FROM fund_state WHERE id = 1`).Scan(&version, &data)
+	if errors.Is(err, sql.ErrNoRows) {
+		s.version = 0
+		return &model.FundState{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("load fund state: %w", err)
+	}
+	var state model.FundState
+	if err := json.Unmarshal([]byte(data), &state); err != nil {
+		return nil, fmt.Errorf("decode fund state: %w", err)
+	}
+	s.version = version
+	return &state, nil
+}
+
+// Save replaces the saved state with state. It fails with ErrStateConflict,
+// saving nothing, when the row changed since the last Load or Save.
+func (s *SQLiteStore) Save(state *model.FundState) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	state.UpdatedAt = time.Now()
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	var res sql.Result
+	if s.version == 0 {
+		res, err = s.db.Exec(`INSERT OR IGNORE INTO fund_state (id, version, state, updated_at) VALUES (1, 1, ?, ?)`,
+			string(data), state.UpdatedAt.Unix())
+	} else {
+		res, err = s.db.Exec(`UPDATE fund_state SET version = version + 1, state = ?, updated_at = ? WHERE id = 1 AND version = ?`,
+			string(data), state.UpdatedAt.Unix(), s.version)
+	}
+	if err != nil {
+		return fmt.Errorf("save fund state: %w", err)
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return fmt.Errorf("save fund state: %w", err)
+	} else if n == 0 {
+		return ErrStateConflict
+	}
+	s.version++
+	return nil
+}
+
+// ImportFile moves the state of the JSON file at path into the store, once:
+// it does nothing when the store already holds a state or there is no file,
+// and renames an imported file to path.imported so it is not mistaken for
+// the live state. It reports whether a file was imported.
+func (s *SQLiteStore) ImportFile(path string) (bool, error) {
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	if _, err := s.Load(); err != nil {
+		return false, err
+	}
+	if s.saved() {
+		log.Printf("[WARN] fund state already in sqlite, ignoring %s", path)
+		return false, nil
+	}
+	state, err := LoadState(path)
+	if err != nil {
+		return false, fmt.Errorf("read %s: %w", path, err)
+	}
+	if err := s.Save(state); err != nil {
+		return false, err
+	}
+	if err := os.Rename(path, path+".imported"); err != nil {
+		return true, fmt.Errorf("rename imported %s: %w", path, err)
+	}
+	log.Printf("[INFO] imported fund state from %s into sqlite", path)
+	return true, nil
+}
+
+// saved reports whether the last Load found a saved state.
+func (s *SQLiteStore) saved() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.version != 0
+}
+
+// Close closes the database.
+func (s *SQLiteStore) Close() error {
+	return s.db.Close()
+}
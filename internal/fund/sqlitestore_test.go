@@ -0,0 +1,186 @@
+package fund
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"MarketSentinel/internal/model"
+)
+
+func openTestStore(t *testing.T, dbPath string) *SQLiteStore {
+	t.Helper()
+	s, err := OpenSQLiteStore(dbPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+func TestSQLiteStore_SaveAndConflict(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "test.db")
+	store := openTestStore(t, dbPath)
+	m, err := NewManager(store, 10000, Policy{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A second process loads the state before the first saves again.
+	other := openTestStore(t, dbPath)
+	if _, err := other.Load(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, _, err := m.Adjust(PoolReserve, 500, ""); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := openTestStore(t, dbPath).Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := m.GetState(); loaded.ReserveBalance != want.ReserveBalance || loaded.MonthlyBudget != 10000 {
+		t.Errorf("loaded %+v, want %+v", loaded, want)
+	}
+
+	if err := other.Save(loaded); !errors.Is(err, ErrStateConflict) {
+		t.Errorf("stale save: err = %v, want ErrStateConflict", err)
+	}
+	// A store that never loaded must not create the row over another's.
+	if err := openTestStore(t, dbPath).Save(loaded); !errors.Is(err, ErrStateConflict) {
+		t.Errorf("blind save: err = %v, want ErrStateConflict", err)
+	}
+}
+
+func TestSQLiteStore_ConflictLocks(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "test.db")
+	m, err := NewManager(openTestStore(t, dbPath), 10000, Policy{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var alerts []error
+	m.SetViolationHandler(func(violation error, _ model.FundState) { alerts = append(alerts, violation) })
+	before := m.GetState()
+
+	// Another process saves a deposit the manager has not seen.
+	other := openTestStore(t, dbPath)
+	theirs, err := other.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	theirs.ReserveBalance += 5000
+	if err := other.Save(theirs); err != nil {
+		t.Fatal(err)
+	}
+
+	if final, reserve := m.CalculateWeeklyInvestment(tierSignal(1.0, 0)); final != 0 || reserve != 0 {
+		t.Errorf("conflicting investment reported %.2f (reserve %.2f)", final, reserve)
+	}
+	if got := m.GetState(); got.RegularBalance != before.RegularBalance || got.LastWeeklyInvestmentISOWeek != before.LastWeeklyInvestmentISOWeek {
+		t.Errorf("state not restored: %+v", got)
+	}
+	if err := m.Locked(); !errors.Is(err, ErrLocked) {
+		t.Errorf("Locked() = %v, want ErrLocked", err)
+	}
+	if len(alerts) != 1 || !errors.Is(alerts[0], ErrStateConflict) {
+		t.Errorf("alerts %v, want one state conflict", alerts)
+	}
+	if _, _, err := m.Adjust(PoolRegular, 100, ""); !errors.Is(err, ErrLocked) {
+		t.Errorf("adjust after the conflict: err = %v, want ErrLocked", err)
+	}
+	saved, err := openTestStore(t, dbPath).Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if saved.ReserveBalance != theirs.ReserveBalance {
+		t.Errorf("the other process's reserve %.2f overwritten with %.2f", theirs.ReserveBalance, saved.ReserveBalance)
+	}
+
+	// Reconcile picks up the other process's state and unlocks.
+	if _, after, err := m.Reconcile(); err != nil || after.ReserveBalance != theirs.ReserveBalance {
+		t.Fatalf("reconcile: %v, reserve %.2f", err, after.ReserveBalance)
+	}
+	if final, _ := m.CalculateWeeklyInvestment(tierSignal(1.0, 0)); final == 0 {
+		t.Error("no investment after reconciling")
+	}
+}
+
+func TestSQLiteStore_ImportFile(t *testing.T) {
+	dir := t.TempDir()
+	jsonPath := filepath.Join(dir, "fund_state.json")
+	fm, err := NewManager(NewFileStore(jsonPath), 10000, Policy{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := fm.GetState()
+
+	store := openTestStore(t, filepath.Join(dir, "test.db"))
+	if imported, err := store.ImportFile(jsonPath); err != nil || !imported {
+		t.Fatalf("import: %v, imported %v", err, imported)
+	}
+	if _, err := os.Stat(jsonPath + ".imported"); err != nil {
+		t.Errorf("imported file not renamed: %v", err)
+	}
+	m, err := NewManager(store, 20000, Policy{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := m.GetState(); got.MonthlyBudget != want.MonthlyBudget || got.RegularBalance != want.RegularBalance {
+		t.Errorf("imported state %+v, want %+v", got, want)
+	}
+
+	// Once imported, a file put back is ignored.
+	if err := SaveState(jsonPath, &want); err != nil {
+		t.Fatal(err)
+	}
+	if imported, err := store.ImportFile(jsonPath); err != nil || imported {
+		t.Errorf("second import: %v, imported %v", err, imported)
+	}
+	if imported, err := store.ImportFile(filepath.Join(dir, "missing.json")); err != nil || imported {
+		t.Errorf("missing file: %v, imported %v", err, imported)
+	}
+}
+
+func TestSQLiteStore_Concurrent(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "test.db")
+	m, err := NewManager(openTestStore(t, dbPath), 10000, Policy{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	before := m.GetState()
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		invested float64
+	)
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range 20 {
+				final, _ := m.CalculateWeeklyInvestment(tierSignal(0.01, 0))
+				mu.Lock()
+				invested += final
+				mu.Unlock()
+				if i%5 == 0 {
+					m.ResetWeeklyFlags()
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	after := m.GetState()
+	if !approx(after.RegularBalance, before.RegularBalance-invested) {
+		t.Errorf("regular %.4f, want %.4f less %.4f invested", after.RegularBalance, before.RegularBalance, invested)
+	}
+	loaded, err := openTestStore(t, dbPath).Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.RegularBalance != after.RegularBalance || len(loaded.RecentScores) != len(after.RecentScores) {
+		t.Errorf("saved %+v, want %+v", loaded, after)
+	}
+}
@@ -2,7 +2,9 @@ package fund
 
 import (
 	"encoding/json"
+	"fmt"
 	"os"
+	"path/filepath"
 	"time"
 
 	"MarketSentinel/internal/model"
@@ -21,15 +23,31 @@ func LoadState(filePath string) (*model.FundState, error) {
 	if err := json.Unmarshal(data, &state); err != nil {
 		return nil, err
 	}
+	// Files written before bottom-fish levels carry a single weekly flag.
+	var legacy struct {
+		BottomFishUsed bool `json:"bottom_fish_used_this_week"`
+	}
+	if err := json.Unmarshal(data, &legacy); err == nil && legacy.BottomFishUsed && state.BottomFishLevel == 0 {
+		state.BottomFishLevel = 1
+	}
 	return &state, nil
 }
 
-// SaveState writes the fund state to a JSON file.
+// SaveState writes the fund state to a JSON file, creating its directory if
+// needed. The file is replaced in one step, so a crash mid-write leaves the
+// previous state rather than a truncated one.
 func SaveState(filePath string, state *model.FundState) error {
+	if err := os.MkdirAll(filepath.Dir(filePath), 0o755); err != nil {
+		return fmt.Errorf("create fund state dir: %w", err)
+	}
 	state.UpdatedAt = time.Now()
 	data, err := json.MarshalIndent(state, "", "  ")
 	if err != nil {
 		return err
 	}
-	return os.WriteFile(filePath, data, 0644)
+	tmp := filePath + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, filePath)
 }
@@ -0,0 +1,27 @@
+package fund
+
+import "MarketSentinel/internal/model"
+
+// StateStore persists the fund state of a Manager.
+type StateStore interface {
+	// Load returns the saved state, or a zero state when none was saved yet.
+	Load() (*model.FundState, error)
+	// Save replaces the saved state with state.
+	Save(state *model.FundState) error
+}
+
+// FileStore keeps the fund state in a JSON file, see LoadState and SaveState.
+type FileStore struct {
+	Path string
+}
+
+// NewFileStore returns the store of the JSON file at path.
+func NewFileStore(path string) *FileStore {
+	return &FileStore{Path: path}
+}
+
+// Load reads the file, see LoadState.
+func (s *FileStore) Load() (*model.FundState, error) { return LoadState(s.Path) }
+
+// Save replaces the file, see SaveState.
+func (s *FileStore) Save(state *model.FundState) error { return SaveState(s.Path, state) }
@@ -0,0 +1,165 @@
+package ledger
+
+import (
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"MarketSentinel/internal/recorder"
+)
+
+// DateLayout is the date format of both CSV files.
+const DateLayout = "2006-01-02"
+
+// ExportHeader is the first row of an exported ledger. One row per fund_history
+// event, oldest first:
+//
+//	date            event day, local time (2006-01-02)
+//	time            event time of day (15:04:05)
+//	event_type      WEEKLY, BOTTOM_FISH, MONTHLY, QUARTERLY or RECONCILE
+//	invested        money that left the pools (simulated buy), 0 for top-ups and transfers
+//	amount          amount as recorded with the event
+//	regular_before  regular pool before the event, then the same for after and reserve
+//	note            free-text note
+//	symbol          symbol whose pools these are in a fund allocated across symbols, else empty
+var ExportHeader = []string{
+	"date", "time", "event_type", "invested", "amount",
+	"regular_before", "regular_after", "reserve_before", "reserve_after", "note", "symbol",
+}
+
+// TradeColumns are the required columns of an imported trades file, in any
+// order. Extra columns are ignored.
+//
+//	date    execution day (2006-01-02)
+//	amount  money spent, > 0
+//	price   execution price, > 0
+//	units   units bought, > 0
+var TradeColumns = []string{"date", "amount", "price", "units"}
+
+// WriteExport writes events as CSV with ExportHeader.
+func WriteExport(w io.Writer, events []recorder.FundEvent) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(ExportHeader); err != nil {
+		return err
+	}
+	for i := range events {
+		e := &events[i]
+		if err := cw.Write([]string{
+			e.OccurredAt.Format(DateLayout),
+			e.OccurredAt.Format("15:04:05"),
+			e.EventType,
+			money(e.Invested()),
+			money(e.Amount),
+			money(e.RegularBefore), money(e.RegularAfter),
+			money(e.ReserveBefore), money(e.ReserveAfter),
+			e.Note,
+			e.Symbol,
+		}); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func money(v float64) string { return strconv.FormatFloat(v, 'f', 2, 64) }
+
+// Reject is a trades row that failed validation.
+type Reject struct {
+	Line   int // 1-based line in the file, header included
+	Reason string
+}
+
+// ReadTrades parses a trades file. Rows that fail validation are returned as
+// rejects rather than failing the whole file; only an unreadable file or a
+// header missing a required column is an error. Dates are parsed in loc.
+func ReadTrades(r io.Reader, loc *time.Location) ([]recorder.Trade, []Reject, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+
+	header, err := cr.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, nil, errors.New("trades file is empty")
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("read header: %w", err)
+	}
+	col := make(map[string]int, len(header))
+	for i, name := range header {
+		col[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, name := range TradeColumns {
+		if _, ok := col[name]; !ok {
+			return nil, nil, fmt.Errorf("header missing column %q (want %s)", name, strings.Join(TradeColumns, ","))
+		}
+	}
+
+	var trades []recorder.Trade
+	var rejects []Reject
+	for line := 2; ; line++ {
+		row, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			rejects = append(rejects, Reject{Line: line, Reason: err.Error()})
+			continue
+		}
+		t, err := parseTrade(row, col, loc)
+		if err != nil {
+			rejects = append(rejects, Reject{Line: line, Reason: err.Error()})
+			continue
+		}
+		trades = append(trades, t)
+	}
+	return trades, rejects, nil
+}
+
+func parseTrade(row []string, col map[string]int, loc *time.Location) (recorder.Trade, error) {
+	field := func(name string) string {
+		if i := col[name]; i < len(row) {
+			return strings.TrimSpace(row[i])
+		}
+		return ""
+	}
+
+	var t recorder.Trade
+	date, err := time.ParseInLocation(DateLayout, field("date"), loc)
+	if err != nil {
+		return t, fmt.Errorf("date %q: want %s", field("date"), DateLayout)
+	}
+	t.Date = date
+	for _, f := range []struct {
+		name string
+		dst  *float64
+	}{{"amount", &t.Amount}, {"price", &t.Price}, {"units", &t.Units}} {
+		v, err := strconv.ParseFloat(field(f.name), 64)
+		if err != nil || v <= 0 {
+			return t, fmt.Errorf("%s %q: want a positive number", f.name, field(f.name))
+		}
+		*f.dst = v
+	}
+	t.Hash = RowHash(t)
+	return t, nil
+}
+
+// RowHash identifies a trade by its normalized date, amount, price and units,
+// so re-importing the same statement row is a no-op. Two genuinely identical
+// trades on one day collapse into one.
+func RowHash(t recorder.Trade) string {
+	key := strings.Join([]string{
+		t.Date.Format(DateLayout),
+		strconv.FormatFloat(t.Amount, 'f', -1, 64),
+		strconv.FormatFloat(t.Price, 'f', -1, 64),
+		strconv.FormatFloat(t.Units, 'f', -1, 64),
+	}, "|")
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,63 @@
+package ledger
+
+import (
+	"bytes"
+	"encoding/csv"
+	"strings"
+	"testing"
+	"time"
+
+	"MarketSentinel/internal/recorder"
+)
+
+func TestReadTrades(t *testing.T) {
+	in := `Units,Date,Price,Amount,Broker
+2,2025-03-03,5000,10000,x
+1.5,2025-03-10,4900,7350,x
+1,03/17/2025,4800,4800,x
+0,2025-03-24,4800,0,x
+1,2025-03-31,abc,4800,x
+`
+	trades, rejects, err := ReadTrades(strings.NewReader(in), time.UTC)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(trades) != 2 || trades[1].Units != 1.5 || !trades[1].Date.Equal(time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("unexpected trades: %+v", trades)
+	}
+	if len(rejects) != 3 || rejects[0].Line != 4 || rejects[1].Line != 5 || rejects[2].Line != 6 {
+		t.Errorf("unexpected rejects: %+v", rejects)
+	}
+
+	// Equivalent formatting of the same row hashes the same.
+	again, _, _ := ReadTrades(strings.NewReader("date,amount,price,units\n2025-03-03,10000.00,5000,2.0\n"), time.UTC)
+	if len(again) != 1 || again[0].Hash != trades[0].Hash {
+		t.Errorf("expected stable hash, got %q vs %q", again[0].Hash, trades[0].Hash)
+	}
+}
+
+func TestReadTrades_MissingColumn(t *testing.T) {
+	if _, _, err := ReadTrades(strings.NewReader("date,amount,units\n"), time.UTC); err == nil || !strings.Contains(err.Error(), `"price"`) {
+		t.Errorf("expected missing price column error, got %v", err)
+	}
+}
+
+func TestWriteExport(t *testing.T) {
+	at := time.Date(2025, 3, 3, 9, 0, 0, 0, time.Local)
+	var buf bytes.Buffer
+	err := WriteExport(&buf, []recorder.FundEvent{{
+		EventType: "WEEKLY", RegularBefore: 5000, RegularAfter: 4000,
+		ReserveBefore: 2000, ReserveAfter: 1500, Amount: 1500, Note: "周定投", Symbol: "NDX100", OccurredAt: at,
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"2025-03-03", "09:00:00", "WEEKLY", "1500.00", "1500.00", "5000.00", "4000.00", "2000.00", "1500.00", "周定投", "NDX100"}
+	if len(rows) != 2 || strings.Join(rows[0], ",") != strings.Join(ExportHeader, ",") || strings.Join(rows[1], ",") != strings.Join(want, ",") {
+		t.Errorf("unexpected export:\n%v", rows)
+	}
+}
@@ -0,0 +1,173 @@
+package metrics
+
+import (
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Labels are the label pairs of one series.
+type Labels map[string]string
+
+// Sample is one series value at collection time, named Prometheus-style.
+type Sample struct {
+	Name   string
+	Labels Labels
+	Value  float64
+}
+
+// Quantiles reported for every summary over the window since the last Collect.
+var Quantiles = []float64{0.5, 0.95}
+
+// Registry is a small in-process store of counters and summaries. A nil
+// *Registry is valid and records nothing, so metrics can be disabled without
+// guarding every call site.
+type Registry struct {
+	mu        sync.Mutex
+	counters  map[string]*counter
+	summaries map[string]*summary
+}
+
+type counter struct {
+	name   string
+	labels Labels
+	value  float64
+}
+
+type summary struct {
+	name   string
+	labels Labels
+	count  float64
+	sum    float64
+	window []float64 // observations since the last Collect
+}
+
+// New creates an empty registry.
+func New() *Registry {
+	return &Registry{
+		counters:  make(map[string]*counter),
+		summaries: make(map[string]*summary),
+	}
+}
+
+// Inc increments the counter name{labels} by one.
+func (r *Registry) Inc(name string, labels Labels) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := SeriesKey(name, labels)
+	c, ok := r.counters[key]
+	if !ok {
+		c = &counter{name: name, labels: labels}
+		r.counters[key] = c
+	}
+	c.value++
+}
+
+// Observe adds v to the summary name{labels}.
+func (r *Registry) Observe(name string, labels Labels, v float64) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := SeriesKey(name, labels)
+	s, ok := r.summaries[key]
+	if !ok {
+		s = &summary{name: name, labels: labels}
+		r.summaries[key] = s
+	}
+	s.count++
+	s.sum += v
+	s.window = append(s.window, v)
+}
+
+// Since observes the seconds elapsed since start, typically via defer.
+func (r *Registry) Since(name string, labels Labels, start time.Time) {
+	if r == nil {
+		return
+	}
+	r.Observe(name, labels, time.Since(start).Seconds())
+}
+
+// Collect returns every series. Counters and summary _count/_sum are cumulative
+// since process start; quantiles cover observations since the previous Collect,
+// and are omitted for summaries with no new observations.
+func (r *Registry) Collect() []Sample {
+	if r == nil {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var out []Sample
+	for _, c := range r.counters {
+		out = append(out, Sample{Name: c.name, Labels: c.labels, Value: c.value})
+	}
+	for _, s := range r.summaries {
+		out = append(out,
+			Sample{Name: s.name + "_count", Labels: s.labels, Value: s.count},
+			Sample{Name: s.name + "_sum", Labels: s.labels, Value: s.sum},
+		)
+		if len(s.window) == 0 {
+			continue
+		}
+		sort.Float64s(s.window)
+		for _, q := range Quantiles {
+			labels := Labels{"quantile": strconv.FormatFloat(q, 'g', -1, 64)}
+			for k, v := range s.labels {
+				labels[k] = v
+			}
+			out = append(out, Sample{Name: s.name, Labels: labels, Value: quantile(s.window, q)})
+		}
+		s.window = s.window[:0]
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return SeriesKey(out[i].Name, out[i].Labels) < SeriesKey(out[j].Name, out[j].Labels)
+	})
+	return out
+}
+
+// quantile returns the nearest-rank q-quantile of sorted values.
+func quantile(sorted []float64, q float64) float64 {
+	idx := int(math.Ceil(q*float64(len(sorted)))) - 1
+	if idx < 0 {
+		idx = 0
+	}
+	return sorted[idx]
+}
+
+// SeriesKey renders name{k="v",...} with sorted label names, as in the
+// Prometheus text exposition format.
+func SeriesKey(name string, labels Labels) string {
+	if len(labels) == 0 {
+		return name
+	}
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	b.WriteString(name)
+	b.WriteByte('{')
+	for i, k := range keys {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(k)
+		b.WriteString(`="`)
+		b.WriteString(labels[k])
+		b.WriteByte('"')
+	}
+	b.WriteByte('}')
+	return b.String()
+}
@@ -0,0 +1,48 @@
+package metrics
+
+import "testing"
+
+func TestRegistry_Collect(t *testing.T) {
+	r := New()
+	r.Inc("failures_total", Labels{"type": "weekly_report"})
+	r.Inc("failures_total", Labels{"type": "weekly_report"})
+	for _, v := range []float64{4, 1, 3, 2} {
+		r.Observe("latency_seconds", nil, v)
+	}
+
+	got := make(map[string]float64)
+	for _, s := range r.Collect() {
+		got[SeriesKey(s.Name, s.Labels)] = s.Value
+	}
+	want := map[string]float64{
+		`failures_total{type="weekly_report"}`: 2,
+		"latency_seconds_count":                4,
+		"latency_seconds_sum":                  10,
+		`latency_seconds{quantile="0.5"}`:      2,
+		`latency_seconds{quantile="0.95"}`:     4,
+	}
+	if len(got) != len(want) {
+		t.Fatalf("expected %d series, got %v", len(want), got)
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("%s = %v, want %v", k, got[k], v)
+		}
+	}
+
+	// Quantiles only cover the window since the previous collection.
+	for _, s := range r.Collect() {
+		if s.Labels["quantile"] != "" {
+			t.Errorf("unexpected quantile in empty window: %+v", s)
+		}
+	}
+}
+
+func TestRegistry_NilIsNoop(t *testing.T) {
+	var r *Registry
+	r.Inc("x", nil)
+	r.Observe("y", nil, 1)
+	if got := r.Collect(); got != nil {
+		t.Errorf("expected no samples from nil registry, got %v", got)
+	}
+}
@@ -8,10 +8,107 @@ type FundState struct {
 	WeeklyBaseN               float64   `json:"weekly_base_n"`
 	RegularBalance            float64   `json:"regular_balance"`
 	ReserveBalance            float64   `json:"reserve_balance"`
-	BottomFishUsedThisWeek    bool      `json:"bottom_fish_used_this_week"`
+	BottomFishLevel           int       `json:"bottom_fish_level"` // deepest bottom-fish level triggered this week, 0 for none; shallower levels count as used
 	ConsecutiveHighScoreWeeks int       `json:"consecutive_high_score_weeks"`
 	RecentScores              []float64 `json:"recent_scores"`
+	WeekDeployed              float64   `json:"week_deployed"`               // amount invested so far in DeployWeek
+	DeployWeek                string    `json:"deploy_week"`                 // ISO week key, e.g. "2025-W07"
+	LowParticipationWeeks     int       `json:"low_participation_weeks"`     // consecutive weeks with multiplier < 1.0
+	LowParticipationShortfall float64   `json:"low_participation_shortfall"` // N not invested over those weeks
 	LastReplenishAt           time.Time `json:"last_replenish_at"`
 	LastRebalanceAt           time.Time `json:"last_rebalance_at"`
-	UpdatedAt                 time.Time `json:"updated_at"`
+	// LastReplenishMonth ("2006-01") and LastRebalanceQuarter ("2006-Q1")
+	// are the periods already credited and rebalanced, so neither runs twice.
+	LastReplenishMonth   string `json:"last_replenish_month,omitempty"`
+	LastRebalanceQuarter string `json:"last_rebalance_quarter,omitempty"`
+	// LastWeeklyInvestmentISOWeek is the ISO week ("2006-W01") of the last
+	// weekly investment, so a restart does not debit the pools twice.
+	LastWeeklyInvestmentISOWeek string `json:"last_weekly_investment_iso_week,omitempty"`
+	// QuarterReserveDeployed is the reserve deployed so far in ReserveQuarter
+	// ("2006-Q1"), against fund.Policy.MaxQuarterlyReserveMultiple.
+	QuarterReserveDeployed float64 `json:"quarter_reserve_deployed,omitempty"`
+	ReserveQuarter         string  `json:"reserve_quarter,omitempty"`
+	// Paused stops investing until PausedUntil, or until resumed when it
+	// is zero; see fund.Manager.Pause.
+	Paused      bool      `json:"paused,omitempty"`
+	PausedUntil time.Time `json:"paused_until,omitempty"`
+	// Holdings are the confirmed purchases by symbol, see
+	// fund.Manager.RecordPurchase.
+	Holdings map[string]Holding `json:"holdings,omitempty"`
+	// Symbol is the instrument whose share of the budget this state tracks,
+	// empty for a fund that is not allocated. With an allocation the top
+	// level is the primary symbol, and Symbols holds the sub-states of the
+	// others by symbol; a flat state from before allocations is the
+	// primary's. See fund.Manager.For.
+	Symbol    string                `json:"symbol,omitempty"`
+	Symbols   map[string]*FundState `json:"symbols,omitempty"`
+	UpdatedAt time.Time             `json:"updated_at"`
+}
+
+// TotalBudget is the monthly budget across the primary and the other
+// allocated symbols.
+func (s FundState) TotalBudget() float64 {
+	total := s.MonthlyBudget
+	for _, sub := range s.Symbols {
+		total += sub.MonthlyBudget
+	}
+	return total
+}
+
+// Holding is the position in one symbol built from confirmed purchases.
+type Holding struct {
+	Units         float64 `json:"units"`
+	AvgCost       float64 `json:"avg_cost"` // TotalInvested per unit
+	TotalInvested float64 `json:"total_invested"`
+}
+
+// Value is the holding at price.
+func (h Holding) Value(price float64) float64 { return h.Units * price }
+
+// UnrealizedPnL is the gain of the holding at price over what it cost.
+func (h Holding) UnrealizedPnL(price float64) float64 { return h.Value(price) - h.TotalInvested }
+
+// ReplenishSplit describes how one monthly budget was divided between the pools.
+type ReplenishSplit struct {
+	Month        string // "2006-01"
+	RegularAdded float64
+	ReserveAdded float64
+	ReserveShare float64 // fraction of the budget sent to the reserve pool
+	AvgScore     float64 // trailing average score the split was based on
+	Reason       string
+	// Skipped is set when Month was already replenished and nothing was added.
+	Skipped bool
+	// Paused is set when investing was paused and Month passed without
+	// anything added.
+	Paused bool
+	// CarriedOver is the regular pool surplus moved to the reserve pool
+	// after crediting, CarryNote saying why; zero when the pool stayed under
+	// its cap.
+	CarriedOver float64
+	CarryNote   string
+	// Symbol names the sub-state a part was credited to; Parts has one per
+	// symbol, primary first, when the budget is allocated across several,
+	// and the fields above are then their totals.
+	Symbol string
+	Parts  []ReplenishSplit
+}
+
+// Performance is how a holding has done at a price: its unrealized P&L, the
+// simple return on what is invested, the money-weighted annual return of the
+// purchases and sales, and the P&L the sales realized this year. Invested and
+// the fields depending on it are zero once everything was sold.
+type Performance struct {
+	Symbol        string
+	Price         float64 // zero when no price could be fetched
+	Invested      float64
+	Value         float64 // at Price
+	UnrealizedPnL float64
+	ROI           float64 // UnrealizedPnL over Invested, as a fraction
+	// XIRR is the annualized internal rate of return of the recorded
+	// purchases and sales against Value; NaN when there are none or it has
+	// no solution.
+	XIRR float64
+	// RealizedYTD is the realized P&L of the SalesYTD sales this year.
+	RealizedYTD float64
+	SalesYTD    int
 }
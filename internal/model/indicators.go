@@ -1,5 +1,19 @@
 package model
 
+// Indicator keys used in MarketIndicators.Degraded.
+const (
+	IndicatorPrice       = "price"
+	IndicatorMA200       = "ma200"
+	IndicatorMA20w       = "ma20w"
+	IndicatorMA50w       = "ma50w"
+	IndicatorWeeklyRSI   = "weekly_rsi"
+	IndicatorDailyRSI    = "daily_rsi"
+	IndicatorRange52w    = "range_52w"
+	IndicatorRange30d    = "range_30d"
+	IndicatorPosition52w = "position_52w"
+	IndicatorVIX         = "vix"
+)
+
 // MarketIndicators holds all computed technical indicators.
 type MarketIndicators struct {
 	CurrentPrice float64
@@ -13,4 +27,128 @@ type MarketIndicators struct {
 	High30d      float64
 	Low30d       float64
 	Position52w  float64 // 0.0 ~ 1.0
+	// ZScoreMA200 is how many standard deviations the price lies from the
+	// mean of the last 200 daily closes; zero when unavailable.
+	ZScoreMA200 float64
+	// WeeklyRSIPercentile and MA200DevPercentile rank the weekly RSI and
+	// the deviation from MA200 against their own values over up to three
+	// years, as percentages strictly between 0 and 100; zero when the
+	// history is too short.
+	WeeklyRSIPercentile float64
+	MA200DevPercentile  float64
+	// DrawdownFromHigh is how far the price is below High52w and
+	// MaxDrawdown1y the deepest peak-to-trough decline of daily closes over
+	// 52 weeks, both as fractions such as -0.12; zero at a new high.
+	DrawdownFromHigh float64
+	MaxDrawdown1y    float64
+	// ROC3m and ROC6m are the percentage price changes of the daily closes
+	// over 3 and 6 months, e.g. -8.5; zero when history is too short.
+	ROC3m float64
+	ROC6m float64
+	// MA200Slope and MA20wSlope are the percentage changes of MA200 over
+	// the last 20 sessions and of MA20w over the last 4 weeks; zero when
+	// there are too few bars.
+	MA200Slope float64
+	MA20wSlope float64
+	// DaysBelowMA200 is how many of the latest daily closes lie below their
+	// MA200, and DaysAboveMA200Before how many closes right before those lie
+	// above it; WeeksMA20wBelowMA50w is how many of the latest weekly bars
+	// have MA20w below MA50w. All are zero when there are too few bars.
+	DaysBelowMA200       int
+	DaysAboveMA200Before int
+	WeeksMA20wBelowMA50w int
+	// Low52wAge is how many of the latest daily bars, counting from the one
+	// setting Low52w, have passed: 1 when the latest bar set it, zero when
+	// unknown.
+	Low52wAge int
+	// MACD, MACDSignal and MACDHist are the weekly 12/26/9 MACD line, signal
+	// line and histogram, and MACDHistPrev the histogram a week earlier;
+	// zero when there are too few weekly bars.
+	MACD         float64
+	MACDSignal   float64
+	MACDHist     float64
+	MACDHistPrev float64
+	// ATR14 is the 14-day average true range in price units and Vol30d the
+	// annualized volatility of daily returns over 30 days, 0.2 being 20%;
+	// zero when there are too few daily bars.
+	ATR14  float64
+	Vol30d float64
+	// VolumeRatio20 is the latest daily volume over its average of the 20
+	// days before, and OBVSlope20 the direction of on-balance volume over
+	// those 20 days: 1 rising, -1 falling, 0 flat. Both are zero when the
+	// source reports no volume.
+	VolumeRatio20 float64
+	OBVSlope20    int
+	// VIX and VIX20d are the latest close and 20-day average of the
+	// auxiliary volatility symbol; zero when it is not configured or could
+	// not be fetched.
+	VIX    float64
+	VIX20d float64
+	// Yield10Y and Yield10Y20d are the latest 10-year Treasury yield in
+	// percent and the average of its last 20 observations; zero when no
+	// macro source is configured or it could not be fetched.
+	Yield10Y    float64
+	Yield10Y20d float64
+	// FXRate is yuan per unit of the symbol's quote currency, e.g. USD/CNY;
+	// zero when no FX symbol is configured or it could not be fetched, in
+	// which case amounts are shown in yuan only.
+	FXRate float64
+
+	// Degraded maps an indicator key to the reason it fell back to a default value.
+	Degraded map[string]string
+	// Missing maps an indicator key to the reason it could not be computed
+	// at all, e.g. MA200 from fewer than 200 daily bars. Its field holds a
+	// placeholder that must not be read as data, and factors depending on
+	// it are skipped. Missing indicators are also Degraded.
+	Missing map[string]string
+	// DataQualityWarning describes stale or gap-ridden input data; empty when
+	// the data passed the collector's checks.
+	DataQualityWarning string
+	// SourceWarning describes a divergence between the primary data source
+	// and the verification source; empty when they agree or no verification
+	// source is configured.
+	SourceWarning string
+}
+
+// MarkDegraded flags an indicator as computed from a fallback rather than fresh data.
+func (m *MarketIndicators) MarkDegraded(key, reason string) {
+	if m.Degraded == nil {
+		m.Degraded = make(map[string]string)
+	}
+	m.Degraded[key] = reason
+}
+
+// DegradedReason returns the reasons for any of keys that are degraded, joined by "; ".
+// An empty string means all of keys are fresh.
+func (m *MarketIndicators) DegradedReason(keys ...string) string {
+	return reasons(m.Degraded, keys)
+}
+
+// MarkMissing flags an indicator as not computed, and so also degraded.
+func (m *MarketIndicators) MarkMissing(key, reason string) {
+	m.MarkDegraded(key, reason)
+	if m.Missing == nil {
+		m.Missing = make(map[string]string)
+	}
+	m.Missing[key] = reason
+}
+
+// MissingReason returns the reasons for any of keys that are missing, joined
+// by "; ". An empty string means all of keys were computed.
+func (m *MarketIndicators) MissingReason(keys ...string) string {
+	return reasons(m.Missing, keys)
+}
+
+// reasons joins the reasons in byKey for any of keys as "key: reason".
+func reasons(byKey map[string]string, keys []string) string {
+	var reason string
+	for _, k := range keys {
+		if r, ok := byKey[k]; ok {
+			if reason != "" {
+				reason += "; "
+			}
+			reason += k + ": " + r
+		}
+	}
+	return reason
 }
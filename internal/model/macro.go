@@ -0,0 +1,10 @@
+package model
+
+import "time"
+
+// MacroPoint is one observation of a macroeconomic series such as the
+// 10-year Treasury yield.
+type MacroPoint struct {
+	Date  time.Time
+	Value float64
+}
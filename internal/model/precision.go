@@ -0,0 +1,143 @@
+package model
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Precision policy shared by messages and records. Values are rounded with
+// the Round helpers before they are written, and displayed with the Format
+// helpers, so a stored row and the message about it always agree.
+const (
+	ScoreDecimals  = 3 // composite, factor and boundary scores
+	WeightDecimals = 2 // factor weights, tier multipliers, pool shares
+	AmountDecimals = 2 // stored amounts; displayed as whole yuan
+	RSIDecimals    = 1
+	ReturnDecimals = 4 // returns as fractions; displayed as percent with two decimals
+)
+
+func roundTo(v float64, decimals int) float64 {
+	p := math.Pow10(decimals)
+	r := math.Round(v*p) / p
+	if r == 0 {
+		return 0 // drop the sign of -0
+	}
+	return r
+}
+
+// RoundScore rounds a score to ScoreDecimals.
+func RoundScore(v float64) float64 { return roundTo(v, ScoreDecimals) }
+
+// RoundWeight rounds a weight, multiplier or share to WeightDecimals.
+func RoundWeight(v float64) float64 { return roundTo(v, WeightDecimals) }
+
+// RoundAmount rounds an amount of money to AmountDecimals.
+func RoundAmount(v float64) float64 { return roundTo(v, AmountDecimals) }
+
+// RoundRSI rounds an RSI value to RSIDecimals.
+func RoundRSI(v float64) float64 { return roundTo(v, RSIDecimals) }
+
+// RoundReturn rounds a return, e.g. an ROI or XIRR, to ReturnDecimals.
+func RoundReturn(v float64) float64 { return roundTo(v, ReturnDecimals) }
+
+// FormatScore formats a signed score, e.g. "+1.025".
+func FormatScore(v float64) string {
+	return signed(strconv.FormatFloat(RoundScore(v), 'f', ScoreDecimals, 64))
+}
+
+// FormatScoreDistance formats an unsigned distance between scores, e.g. "0.175".
+func FormatScoreDistance(v float64) string {
+	return strconv.FormatFloat(RoundScore(v), 'f', ScoreDecimals, 64)
+}
+
+// FormatWeight formats a weight or multiplier, e.g. "0.35".
+func FormatWeight(v float64) string {
+	return strconv.FormatFloat(RoundWeight(v), 'f', WeightDecimals, 64)
+}
+
+// FormatRSI formats an RSI value, e.g. "52.3".
+func FormatRSI(v float64) string {
+	return strconv.FormatFloat(RoundRSI(v), 'f', RSIDecimals, 64)
+}
+
+// FormatRawScore formats a factor's raw score with only the digits it has,
+// e.g. "+2" or "+0.5".
+func FormatRawScore(v float64) string {
+	return signed(strconv.FormatFloat(RoundWeight(v), 'f', -1, 64))
+}
+
+// FormatAmount formats an amount as whole yuan with thousands separators,
+// e.g. "¥12,345" or "-¥80". The stored value is rounded first, so the result
+// matches what a reader of the record would round to.
+func FormatAmount(v float64) string {
+	y := math.Round(RoundAmount(v))
+	switch {
+	case y == 0:
+		return "¥0"
+	case y < 0:
+		return "-¥" + groupThousands(-y)
+	}
+	return "¥" + groupThousands(y)
+}
+
+// FormatUSD formats an amount as whole US dollars, e.g. "$1,702", for amounts
+// converted from yuan for display only.
+func FormatUSD(v float64) string {
+	y := math.Round(v)
+	switch {
+	case y == 0:
+		return "$0"
+	case y < 0:
+		return "-$" + groupThousands(-y)
+	}
+	return "$" + groupThousands(y)
+}
+
+// FormatAmountExact formats an amount at stored precision, e.g. "¥12,345.67",
+// for audit views where whole yuan would hide a discrepancy.
+func FormatAmountExact(v float64) string {
+	r := RoundAmount(v)
+	sign := ""
+	if r < 0 {
+		sign, r = "-", -r
+	}
+	whole := math.Floor(r)
+	cents := math.Round((r - whole) * 100)
+	if cents == 100 {
+		whole, cents = whole+1, 0
+	}
+	return fmt.Sprintf("%s¥%s.%02.0f", sign, groupThousands(whole), cents)
+}
+
+// FormatAmountDelta formats a signed change in yuan, e.g. "+¥1,200" or "-¥80".
+func FormatAmountDelta(v float64) string {
+	return signed(FormatAmount(v))
+}
+
+func signed(s string) string {
+	if strings.HasPrefix(s, "-") {
+		return s
+	}
+	return "+" + s
+}
+
+func groupThousands(y float64) string {
+	s := fmt.Sprintf("%.0f", y)
+	if len(s) <= 3 {
+		return s
+	}
+	var b strings.Builder
+	lead := len(s) % 3
+	if lead > 0 {
+		b.WriteString(s[:lead])
+	}
+	for i := lead; i < len(s); i += 3 {
+		if b.Len() > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(s[i : i+3])
+	}
+	return b.String()
+}
@@ -0,0 +1,43 @@
+package model
+
+import "testing"
+
+func TestFormatHelpers(t *testing.T) {
+	tests := []struct {
+		got, want string
+	}{
+		{FormatScore(1.0249999), "+1.025"},
+		{FormatScore(-0.0004), "+0.000"},
+		{FormatScore(-0.5), "-0.500"},
+		{FormatScoreDistance(0.17500001), "0.175"},
+		{FormatWeight(0.349999), "0.35"},
+		{FormatRSI(51.94), "51.9"},
+		{FormatRawScore(2), "+2"},
+		{FormatRawScore(1.5), "+1.5"},
+		{FormatRawScore(-0.333), "-0.33"},
+		{FormatAmount(1939.6), "¥1,940"},
+		{FormatAmount(999.4), "¥999"},
+		{FormatAmount(1234567), "¥1,234,567"},
+		{FormatAmount(-80.2), "-¥80"},
+		{FormatAmount(-0.3), "¥0"},
+		{FormatAmountDelta(1200), "+¥1,200"},
+		{FormatAmountDelta(-12.6), "-¥13"},
+		{FormatAmountExact(12345.675), "¥12,345.68"},
+		{FormatAmountExact(-0.999), "-¥1.00"},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("got %q, want %q", tt.got, tt.want)
+		}
+	}
+}
+
+// TestFormatAmount_MatchesStoredRounding pins that the displayed yuan is
+// derived from the stored two-decimal value, not the raw float.
+func TestFormatAmount_MatchesStoredRounding(t *testing.T) {
+	raw := 1616.4999999
+	stored := RoundAmount(raw) // 1616.50
+	if FormatAmount(raw) != FormatAmount(stored) {
+		t.Errorf("raw %s != stored %s", FormatAmount(raw), FormatAmount(stored))
+	}
+}
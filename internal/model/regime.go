@@ -0,0 +1,25 @@
+package model
+
+// Regime is the broad state of the market a signal was issued in, see
+// strategy.ClassifyRegime.
+type Regime string
+
+const (
+	RegimeUnknown Regime = "" // MA200 could not be computed
+	RegimeBear    Regime = "BEAR"
+	RegimeRange   Regime = "RANGE"
+	RegimeBull    Regime = "BULL"
+)
+
+// Label returns the Chinese name of r shown in reports, e.g. "熊市".
+func (r Regime) Label() string {
+	switch r {
+	case RegimeBear:
+		return "熊市"
+	case RegimeRange:
+		return "震荡"
+	case RegimeBull:
+		return "牛市"
+	}
+	return "未知"
+}
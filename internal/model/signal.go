@@ -12,13 +12,31 @@ const (
 	TriggerManual    TriggerType = "MANUAL"
 )
 
+// Registry keys of the core strategy factors, see FactorScore.ID.
+const (
+	FactorMA200       = "ma200"
+	FactorWeeklyRSI   = "weekly_rsi"
+	FactorDailyRSI    = "daily_rsi"
+	FactorPosition52w = "position_52w"
+	FactorTrend       = "trend"
+	FactorVolatility  = "volatility"
+	FactorDrawdown    = "drawdown"
+)
+
+// Registry key of the optional MA50w deviation factor, recorded in its own
+// snapshot column.
+const FactorMA50w = "ma50w"
+
 // FactorScore represents a single factor's scoring result.
 type FactorScore struct {
+	ID         string // registry key of the factor, e.g. FactorMA200
 	Name       string
 	RawScore   float64
 	Weight     float64
 	Weighted   float64
 	Commentary string
+	Extra      bool // optional registered factor, shown with one decimal
+	Missing    string // why the factor was skipped for missing indicators; empty when scored
 }
 
 // InvestmentTier maps a total score range to an action.
@@ -32,10 +50,47 @@ type InvestmentTier struct {
 type TradeSignal struct {
 	Factors     []FactorScore
 	TotalScore  float64
+	Confidence  float64 // 0-1, how firmly the factors agree with TotalScore
 	Tier        InvestmentTier
 	BaseAmount  float64
 	FinalAmount float64
 	ReserveUsed float64
 	TriggerType TriggerType
-	WarningMsg  string
+	Warnings    []Warning // one report line each; see strategy.EvaluateWarnings
+	CapNote     string // set when the weekly deployment ceiling reduced the amount
+	ReserveNote string // set when the reserve floor or quarterly reserve cap reduced the reserve used
+	RawAmount    float64 // FinalAmount before lot-size rounding, set when rounding applies
+	RoundingNote string  // set when lot-size rounding changed the amount or skipped the investment
+	CooldownNote string // set when the extreme-tier cooldown stepped the tier down
+	ConfidenceNote string // set when low confidence stepped an extreme tier down
+	BoostNote   string // set when the bear-market boost raised the regular multiplier
+	Sell        *SellRecommendation // set when the score and RSI call for trimming the position
+	Regime      Regime // market state the signal was issued in
+	StrategyVersion string // rule set the signal was produced by, see strategy.Version
+	Boundary    BoundaryInfo
+	// Smoothed is set when Tier and Boundary were mapped from SmoothedScore, a
+	// blend of TotalScore with the previous weekly scores, rather than from
+	// TotalScore; RawTierLabel is then the tier TotalScore alone maps to.
+	Smoothed      bool
+	SmoothedScore float64
+	RawTierLabel  string
+}
+
+// BoundaryInfo places a score within the tier table. A tier is entered at its
+// minimum score, so a score exactly on a boundary already belongs to the tier
+// above it and DistDown is 0.
+type BoundaryInfo struct {
+	Tier      InvestmentTier
+	UpLabel   string  // next tier up, "" in the top tier
+	DistUp    float64 // score gain that reaches UpLabel; 0 when UpLabel is ""
+	DownLabel string  // next tier down, "" in the bottom tier
+	DistDown  float64 // any score loss beyond this drops into DownLabel; 0 when DownLabel is ""
+}
+
+// SellRecommendation suggests selling part of the position on a very negative
+// score combined with a high RSI.
+type SellRecommendation struct {
+	Percent float64 // share of the position to sell, in percent
+	Amount  float64 // currency value of Percent; zero until positions are tracked
+	Reason  string
 }
@@ -0,0 +1,21 @@
+package model
+
+// WarningKind identifies the condition a Warning reports.
+type WarningKind string
+
+const (
+	// WarningTakeProfit: the daily or weekly RSI is above the take-profit threshold.
+	WarningTakeProfit WarningKind = "take_profit"
+	// WarningMA200Break: the daily close fell below MA200 after a long run above it.
+	WarningMA200Break WarningKind = "ma200_break"
+	// WarningDeathCross: MA20w crossed below MA50w.
+	WarningDeathCross WarningKind = "death_cross"
+	// WarningNew52wLow: a new 52-week low was set.
+	WarningNew52wLow WarningKind = "new_52w_low"
+)
+
+// Warning is a condition a signal warns about, rendered as one report line.
+type Warning struct {
+	Kind WarningKind
+	Text string // e.g. "⚠️ RSI > 85 止盈预警：建议考虑部分止盈"
+}
@@ -2,17 +2,84 @@ package notifier
 
 import (
 	"fmt"
+	"maps"
+	"math"
+	"slices"
 	"strings"
 	"time"
 
+	"MarketSentinel/internal/calculator"
+	"MarketSentinel/internal/fund"
 	"MarketSentinel/internal/model"
+	"MarketSentinel/internal/pipeline"
+	"MarketSentinel/internal/recorder"
+	"MarketSentinel/internal/reporting"
 )
 
-// FormatWeeklyReport formats the weekly trade signal into a Telegram message.
-func FormatWeeklyReport(ind *model.MarketIndicators, signal *model.TradeSignal) string {
+// FormatWeeklyReport formats a weekly evaluation, including the resulting fund
+// status, into a Telegram message.
+func FormatWeeklyReport(res *pipeline.WeeklyResult) string {
+	report := FormatWeeklySignal(res) + "\n" + FormatFundStatus(&res.StateAfter)
+	if v := FormatStrategyVersion(res.Signal); v != "" {
+		report += "\n" + v
+	}
+	return report
+}
+
+// FormatStrategyVersion renders the rule set a signal was produced by as a
+// line in small print for the end of a report, empty when it is not known.
+func FormatStrategyVersion(signal *model.TradeSignal) string {
+	if signal.StrategyVersion == "" {
+		return ""
+	}
+	return fmt.Sprintf("<i>策略版本 %s</i>\n", signal.StrategyVersion)
+}
+
+// weeklyTitle is the first line of a weekly report; a requested preview is
+// titled as one, so it cannot be taken for an executed run.
+func weeklyTitle(res *pipeline.WeeklyResult) string {
+	if res.DryRun && !res.FundLocked && !res.Paused && !res.AlreadyInvested {
+		return fmt.Sprintf("🔍 <b>MarketSentinel 周报预览</b> | %s\n", time.Now().Format("2006-01-02"))
+	}
+	return fmt.Sprintf("📊 <b>MarketSentinel 周报</b> | %s\n", time.Now().Format("2006-01-02"))
+}
+
+// PauseBanner heads the reports made while investing is paused in st.
+func PauseBanner(st model.FundState) string {
+	until := ""
+	if !st.PausedUntil.IsZero() {
+		until = "（至 " + st.PausedUntil.Format("2006-01-02") + "）"
+	}
+	return "⏸ 已暂停投入" + until + "：未改动资金池，未记录历史，发送 /resume 恢复"
+}
+
+// FormatWeeklySignal formats the indicators, factor table and suggested action
+// of a weekly evaluation, without the fund status.
+func FormatWeeklySignal(res *pipeline.WeeklyResult) string {
+	ind, signal := res.Indicators, res.Signal
 	var b strings.Builder
 
-	b.WriteString(fmt.Sprintf("📊 <b>MarketSentinel 周报</b> | %s\n\n", time.Now().Format("2006-01-02")))
+	b.WriteString(weeklyTitle(res))
+	if signal.Regime != model.RegimeUnknown {
+		b.WriteString(fmt.Sprintf("当前市场状态: %s\n", signal.Regime.Label()))
+	}
+	b.WriteString("\n")
+	switch {
+	case res.FundLocked:
+		b.WriteString("⛔ 资金账本已锁定，等待 /reconcile：本周仅预览，未改动资金池，未记录历史\n\n")
+	case res.Paused:
+		b.WriteString(PauseBanner(res.StateBefore) + "\n\n")
+	case res.AlreadyInvested:
+		b.WriteString("ℹ️ 本周已执行，仅展示：未改动资金池，未记录历史（如需再次执行发送 /weekly 强制）\n\n")
+	case res.DryRun:
+		b.WriteString("🔍 预览模式：未改动资金池，未记录历史\n\n")
+	}
+	if w := ind.DataQualityWarning; w != "" {
+		b.WriteString(fmt.Sprintf("⚠️ <b>数据质量异常，本周建议仅供参考</b>\n%s\n\n", w))
+	}
+	if w := ind.SourceWarning; w != "" {
+		b.WriteString(fmt.Sprintf("⚠️ <b>数据源不一致，请核对价格</b>\n%s\n\n", w))
+	}
 
 	// Price and MAs
 	b.WriteString(fmt.Sprintf("当前价格: %.2f\n", ind.CurrentPrice))
@@ -20,63 +87,636 @@ func FormatWeeklyReport(ind *model.MarketIndicators, signal *model.TradeSignal)
 	if ind.MA200 > 0 {
 		ma200Dev = (ind.CurrentPrice - ind.MA200) / ind.MA200 * 100
 	}
-	b.WriteString(fmt.Sprintf("MA200: %.2f (偏离 %+.1f%%)\n", ind.MA200, ma200Dev))
-	b.WriteString(fmt.Sprintf("MA20周: %.2f | MA50周: %.2f\n\n", ind.MA20w, ind.MA50w))
+	if reason := ind.MissingReason(model.IndicatorMA200); reason != "" {
+		b.WriteString(fmt.Sprintf("MA200: 不可用 (%s)\n", reason))
+	} else {
+		b.WriteString(fmt.Sprintf("MA200: %.2f (偏离 %+.1f%%)\n", ind.MA200, ma200Dev))
+	}
+	b.WriteString(fmt.Sprintf("MA20周: %.2f | MA50周: %.2f\n", ind.MA20w, ind.MA50w))
+	b.WriteString(fmt.Sprintf("距52周高点: %.1f%% | 近1年最大回撤: %.1f%%\n", ind.DrawdownFromHigh*100, ind.MaxDrawdown1y*100))
+	if ind.ROC3m != 0 || ind.ROC6m != 0 {
+		b.WriteString(fmt.Sprintf("动量: 3个月 %+.1f%% | 6个月 %+.1f%%\n", ind.ROC3m, ind.ROC6m))
+	}
+	if ind.ATR14 > 0 && ind.CurrentPrice > 0 {
+		b.WriteString(fmt.Sprintf("ATR14: %.2f (%.1f%%) | 30日波动率: %.1f%%\n",
+			ind.ATR14, ind.ATR14/ind.CurrentPrice*100, ind.Vol30d*100))
+	}
+	if ind.VolumeRatio20 > 0 {
+		b.WriteString(fmt.Sprintf("成交量: 20日量比 %.2f | OBV趋势 %s\n", ind.VolumeRatio20, obvArrow(ind.OBVSlope20)))
+	}
+	if ind.VIX > 0 {
+		b.WriteString(fmt.Sprintf("VIX: %.2f", ind.VIX))
+		if ind.VIX20d > 0 {
+			b.WriteString(fmt.Sprintf(" (20日均 %.2f)", ind.VIX20d))
+		}
+		b.WriteString("\n")
+	}
+	if ind.Yield10Y > 0 {
+		b.WriteString(fmt.Sprintf("10年期美债: %.2f%% (20日均 %.2f%%)\n", ind.Yield10Y, ind.Yield10Y20d))
+	}
+	b.WriteString("\n")
 
 	// Factor details
 	b.WriteString("📈 <b>因子评分明细:</b>\n")
+	skipped := 0
 	for _, f := range signal.Factors {
-		b.WriteString(fmt.Sprintf("  %s(%s): %+.0f (×%.2f) = %+.3f\n",
-			f.Name, f.Commentary, f.RawScore, f.Weight, f.Weighted))
+		if f.Missing != "" {
+			b.WriteString(fmt.Sprintf("  %s: 跳过 (%s)\n", f.Name, f.Missing))
+			skipped++
+			continue
+		}
+		b.WriteString(fmt.Sprintf("  %s(%s): %s (×%s) = %s\n",
+			f.Name, f.Commentary, model.FormatRawScore(f.RawScore), model.FormatWeight(f.Weight), model.FormatScore(f.Weighted)))
+	}
+	if skipped > 0 {
+		b.WriteString(fmt.Sprintf("  ⚠️ %d个因子因数据不足跳过，其余权重已按比例放大\n", skipped))
 	}
 	b.WriteString("  ─────────────────\n")
-	b.WriteString(fmt.Sprintf("  综合评分: %+.3f\n\n", signal.TotalScore))
+	b.WriteString(fmt.Sprintf("  综合评分: %s\n", model.FormatScore(signal.TotalScore)))
+	if signal.Smoothed {
+		b.WriteString(fmt.Sprintf("  平滑评分: %s", model.FormatScore(signal.SmoothedScore)))
+		if signal.RawTierLabel != signal.Tier.Label {
+			b.WriteString(fmt.Sprintf(" (未平滑为 %s)", signal.RawTierLabel))
+		}
+		b.WriteString("\n")
+	}
+	b.WriteString(fmt.Sprintf("  信心度: %.0f%%\n", signal.Confidence*100))
+	if line := formatBoundary(signal.Boundary); line != "" {
+		b.WriteString("  " + line + "\n")
+	}
+	b.WriteString("\n")
 
 	// Action
-	b.WriteString(fmt.Sprintf("💰 <b>本周操作:</b> %s %.2fx\n", signal.Tier.Label, signal.Tier.Multiplier))
-	b.WriteString(fmt.Sprintf("   投入金额: ¥%.0f (基准¥%.0f)\n", signal.FinalAmount, signal.BaseAmount))
+	b.WriteString(fmt.Sprintf("💰 <b>本周操作:</b> %s %sx\n", signal.Tier.Label, model.FormatWeight(signal.Tier.Multiplier)))
+	b.WriteString(fmt.Sprintf("   投入金额: %s%s (基准%s)\n", model.FormatAmount(signal.FinalAmount),
+		formatFX(signal.FinalAmount, ind.FXRate), model.FormatAmount(signal.BaseAmount)))
 	if signal.ReserveUsed > 0 {
-		b.WriteString(fmt.Sprintf("   储备金动用: ¥%.0f\n", signal.ReserveUsed))
+		b.WriteString(fmt.Sprintf("   储备金动用: %s%s\n", model.FormatAmount(signal.ReserveUsed), formatFX(signal.ReserveUsed, ind.FXRate)))
+	}
+	if signal.CooldownNote != "" {
+		b.WriteString(fmt.Sprintf("   ⏳ %s\n", signal.CooldownNote))
+	}
+	if signal.ConfidenceNote != "" {
+		b.WriteString(fmt.Sprintf("   ⚖️ %s\n", signal.ConfidenceNote))
+	}
+	if signal.BoostNote != "" {
+		b.WriteString(fmt.Sprintf("   🐻 %s\n", signal.BoostNote))
+	}
+	if signal.ReserveNote != "" {
+		b.WriteString(fmt.Sprintf("   🛡️ %s\n", signal.ReserveNote))
+	}
+	if signal.RoundingNote != "" {
+		b.WriteString(fmt.Sprintf("   🔢 %s\n", signal.RoundingNote))
+	}
+	if signal.CapNote != "" {
+		b.WriteString(fmt.Sprintf("   ⛔ %s\n", signal.CapNote))
 	}
 
 	// Warning
-	if signal.WarningMsg != "" {
-		b.WriteString(fmt.Sprintf("\n%s\n", signal.WarningMsg))
+	if len(signal.Warnings) > 0 {
+		b.WriteString("\n")
+		for _, w := range signal.Warnings {
+			b.WriteString(w.Text + "\n")
+		}
+	}
+	if signal.Sell != nil {
+		b.WriteString(FormatSellRecommendation(signal.Sell) + "\n")
+	}
+
+	if res.ParticipationNudge {
+		b.WriteString(fmt.Sprintf("\n💤 已连续 %d 周低于 1.0x 投入，累计少投 %s\n",
+			res.StateAfter.LowParticipationWeeks, model.FormatAmount(res.StateAfter.LowParticipationShortfall)))
+		b.WriteString("建议复查策略配置，或手动将部分常规池资金转入储备池\n")
+	}
+
+	if len(res.Others) > 0 {
+		if slices.ContainsFunc(res.Others, func(o pipeline.SymbolResult) bool { return o.Allocated }) {
+			b.WriteString("\n📋 <b>其他标的</b> (按预算分配，各自独立资金池)\n")
+		} else {
+			b.WriteString(fmt.Sprintf("\n📋 <b>其他标的</b> (仅供参考，资金池按 %s 分配)\n", res.Symbol))
+		}
+		b.WriteString(formatOthers(res.Others))
+	}
+
+	return b.String()
+}
+
+// FormatSellRecommendation renders a sell recommendation, e.g.
+// "📉 建议减仓: 持仓的 12% (评分 -1.400 ≤ -1.200 且 RSI 88.0 > 85)".
+func FormatSellRecommendation(r *model.SellRecommendation) string {
+	s := fmt.Sprintf("📉 建议减仓: 持仓的 %.0f%%", r.Percent)
+	if r.Amount > 0 {
+		s += fmt.Sprintf(" (约 %s)", model.FormatAmount(r.Amount))
+	}
+	return s + fmt.Sprintf(" (%s)", r.Reason)
+}
+
+// FormatWhatIf formats a simulated evaluation at a hypothetical price: the
+// score, tier and amounts the weekly run would give, marked as a simulation.
+func FormatWhatIf(r *pipeline.WhatIfResult) string {
+	ind, signal := r.Indicators, r.Signal
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("🧪 <b>假设模拟</b> | 价格 %.2f (当前 %.2f，%+.1f%%)\n", ind.CurrentPrice, r.Current,
+		(ind.CurrentPrice/r.Current-1)*100))
+	b.WriteString("仅为模拟：未改动资金池，未记录历史\n\n")
+
+	ma200Dev := 0.0
+	if ind.MA200 > 0 {
+		ma200Dev = (ind.CurrentPrice - ind.MA200) / ind.MA200 * 100
+	}
+	b.WriteString(fmt.Sprintf("RSI 周/日: %s/%s | 距MA200 %+.1f%% | 52周位置 %.0f%%\n",
+		model.FormatRSI(ind.WeeklyRSI), model.FormatRSI(ind.DailyRSI), ma200Dev, ind.Position52w*100))
+	b.WriteString(fmt.Sprintf("综合评分: %s", model.FormatScore(signal.TotalScore)))
+	if signal.Smoothed {
+		b.WriteString(fmt.Sprintf(" (平滑 %s)", model.FormatScore(signal.SmoothedScore)))
+	}
+	b.WriteString(fmt.Sprintf(" | 信心度 %.0f%%", signal.Confidence*100))
+	b.WriteString("\n\n")
+
+	b.WriteString(fmt.Sprintf("💰 <b>将会操作:</b> %s %sx\n", signal.Tier.Label, model.FormatWeight(signal.Tier.Multiplier)))
+	b.WriteString(fmt.Sprintf("   投入金额: %s%s (基准%s)\n", model.FormatAmount(signal.FinalAmount),
+		formatFX(signal.FinalAmount, ind.FXRate), model.FormatAmount(signal.BaseAmount)))
+	if signal.ReserveUsed > 0 {
+		b.WriteString(fmt.Sprintf("   储备金动用: %s%s\n", model.FormatAmount(signal.ReserveUsed), formatFX(signal.ReserveUsed, ind.FXRate)))
+	}
+	if signal.CooldownNote != "" {
+		b.WriteString(fmt.Sprintf("   ⏳ %s\n", signal.CooldownNote))
+	}
+	if signal.ConfidenceNote != "" {
+		b.WriteString(fmt.Sprintf("   ⚖️ %s\n", signal.ConfidenceNote))
 	}
+	if signal.BoostNote != "" {
+		b.WriteString(fmt.Sprintf("   🐻 %s\n", signal.BoostNote))
+	}
+	if signal.ReserveNote != "" {
+		b.WriteString(fmt.Sprintf("   🛡️ %s\n", signal.ReserveNote))
+	}
+	if signal.RoundingNote != "" {
+		b.WriteString(fmt.Sprintf("   🔢 %s\n", signal.RoundingNote))
+	}
+	if signal.CapNote != "" {
+		b.WriteString(fmt.Sprintf("   ⛔ %s\n", signal.CapNote))
+	}
+	if len(signal.Warnings) > 0 {
+		b.WriteString("\n")
+		for _, w := range signal.Warnings {
+			b.WriteString(w.Text + "\n")
+		}
+	}
+	if signal.Sell != nil {
+		b.WriteString(FormatSellRecommendation(signal.Sell) + "\n")
+	}
+	return b.String()
+}
 
+// formatOthers lists the evaluation of each additional symbol, e.g.
+// "  NDX: 评分 +0.120 → 正常定投 1.00x；RSI 54.0/51.0；距MA200 +3.1%", with
+// the amount invested and its notes for a symbol allocated a share of the
+// budget, e.g. "→ 正常定投 1.00x 投入 ¥650".
+func formatOthers(others []pipeline.SymbolResult) string {
+	var b strings.Builder
+	for _, o := range others {
+		if o.Err != nil {
+			b.WriteString(fmt.Sprintf("  %s: ❌ 采集失败: %v\n", o.Symbol, o.Err))
+			continue
+		}
+		ind, sig := o.Indicators, o.Signal
+		ma200Dev := 0.0
+		if ind.MA200 > 0 {
+			ma200Dev = (ind.CurrentPrice - ind.MA200) / ind.MA200 * 100
+		}
+		var amount string
+		if o.Allocated {
+			amount = " 投入 " + model.FormatAmount(sig.FinalAmount)
+			if sig.ReserveUsed > 0 {
+				amount += "（含储备金 " + model.FormatAmount(sig.ReserveUsed) + "）"
+			}
+		}
+		b.WriteString(fmt.Sprintf("  %s: 评分 %s → %s %sx%s；RSI %s/%s；距MA200 %+.1f%%\n",
+			o.Symbol, model.FormatScore(sig.TotalScore), sig.Tier.Label, model.FormatWeight(sig.Tier.Multiplier), amount,
+			model.FormatRSI(ind.WeeklyRSI), model.FormatRSI(ind.DailyRSI), ma200Dev))
+		for _, note := range []string{sig.ReserveNote, sig.RoundingNote, sig.CapNote} {
+			if note != "" {
+				b.WriteString(fmt.Sprintf("    %s\n", note))
+			}
+		}
+		if ind.DataQualityWarning != "" {
+			b.WriteString(fmt.Sprintf("    ⚠️ %s\n", ind.DataQualityWarning))
+		}
+	}
 	return b.String()
 }
 
-// FormatFundStatus formats the current fund state for display.
+// formatBoundary describes how far the score is from the neighbouring tiers,
+// e.g. "再涨0.175分进入 重仓买入；再跌0.225分进入 正常定投".
+func formatBoundary(b model.BoundaryInfo) string {
+	var parts []string
+	if b.UpLabel != "" {
+		parts = append(parts, fmt.Sprintf("再涨%s分进入 %s", model.FormatScoreDistance(b.DistUp), b.UpLabel))
+	}
+	if b.DownLabel != "" {
+		parts = append(parts, fmt.Sprintf("再跌%s分进入 %s", model.FormatScoreDistance(b.DistDown), b.DownLabel))
+	}
+	return strings.Join(parts, "；")
+}
+
+// obvArrow renders an OBV slope sign as 上升, 下降 or 持平.
+func obvArrow(slope int) string {
+	switch {
+	case slope > 0:
+		return "上升"
+	case slope < 0:
+		return "下降"
+	}
+	return "持平"
+}
+
+// FormatFundStatus formats the current fund state for display; a fund
+// allocated across symbols is shown by symbol, primary first.
 func FormatFundStatus(state *model.FundState) string {
 	var b strings.Builder
 	b.WriteString("📦 <b>资金池状态</b>\n\n")
-	b.WriteString(fmt.Sprintf("月度预算: ¥%.0f\n", state.MonthlyBudget))
-	b.WriteString(fmt.Sprintf("周基准N: ¥%.0f\n", state.WeeklyBaseN))
-	b.WriteString(fmt.Sprintf("常规池: ¥%.0f\n", state.RegularBalance))
-	b.WriteString(fmt.Sprintf("储备池: ¥%.0f\n", state.ReserveBalance))
-	b.WriteString(fmt.Sprintf("本周已抄底: %v\n", state.BottomFishUsedThisWeek))
-	b.WriteString(fmt.Sprintf("连续高分周数: %d\n", state.ConsecutiveHighScoreWeeks))
+	if state.Paused && state.PausedUntil.IsZero() {
+		b.WriteString("投入状态: ⏸ 已暂停，发送 /resume 恢复\n")
+	} else if state.Paused {
+		b.WriteString(fmt.Sprintf("投入状态: ⏸ 已暂停至 %s\n", state.PausedUntil.Format("2006-01-02")))
+	}
+	total := state.TotalBudget()
+	b.WriteString(fmt.Sprintf("月度预算: %s\n", model.FormatAmount(total)))
+	if len(state.Symbols) == 0 {
+		writePools(&b, state)
+	} else {
+		for _, st := range append([]*model.FundState{state}, symbolStates(state)...) {
+			b.WriteString(fmt.Sprintf("\n<b>%s</b> (预算 %s，%.0f%%)\n", st.Symbol, model.FormatAmount(st.MonthlyBudget), st.MonthlyBudget/total*100))
+			writePools(&b, st)
+		}
+	}
 	b.WriteString(fmt.Sprintf("更新时间: %s\n", state.UpdatedAt.Format("2006-01-02 15:04")))
 	return b.String()
 }
 
-// FormatMonthlySummary formats a monthly summary report.
-func FormatMonthlySummary(state *model.FundState) string {
+// symbolStates returns the sub-states of the other symbols of an allocated
+// fund, by symbol.
+func symbolStates(state *model.FundState) []*model.FundState {
+	subs := make([]*model.FundState, 0, len(state.Symbols))
+	for _, sym := range slices.Sorted(maps.Keys(state.Symbols)) {
+		subs = append(subs, state.Symbols[sym])
+	}
+	return subs
+}
+
+// writePools writes the weekly base, pools and weekly flags of st.
+func writePools(b *strings.Builder, st *model.FundState) {
+	b.WriteString(fmt.Sprintf("周基准N: %s\n", model.FormatAmount(st.WeeklyBaseN)))
+	b.WriteString(fmt.Sprintf("常规池: %s\n", model.FormatAmount(st.RegularBalance)))
+	b.WriteString(fmt.Sprintf("储备池: %s\n", model.FormatAmount(st.ReserveBalance)))
+	if st.BottomFishLevel > 0 {
+		b.WriteString(fmt.Sprintf("本周已抄底: 第%d档\n", st.BottomFishLevel))
+	} else {
+		b.WriteString("本周已抄底: 否\n")
+	}
+	b.WriteString(fmt.Sprintf("连续高分周数: %d\n", st.ConsecutiveHighScoreWeeks))
+	if st.LowParticipationWeeks > 0 {
+		b.WriteString(fmt.Sprintf("连续低投入周数: %d (累计少投 %s)\n", st.LowParticipationWeeks, model.FormatAmount(st.LowParticipationShortfall)))
+	}
+}
+
+// FormatHoldings formats the confirmed holdings by symbol with their value
+// and unrealized P&L at prices; a symbol without a positive price shows
+// neither. It returns "" when there are none.
+func FormatHoldings(holdings map[string]model.Holding, prices map[string]float64) string {
+	if len(holdings) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString("💼 <b>持仓</b>\n")
+	for _, sym := range slices.Sorted(maps.Keys(holdings)) {
+		h := holdings[sym]
+		b.WriteString(fmt.Sprintf("\n%s: %.4f 份 | 均价 %.2f | 投入 %s\n", sym, h.Units, h.AvgCost, model.FormatAmount(h.TotalInvested)))
+		price := prices[sym]
+		if price <= 0 {
+			b.WriteString("现价未知，无法计算盈亏\n")
+			continue
+		}
+		pnl := h.UnrealizedPnL(price)
+		b.WriteString(fmt.Sprintf("现价 %.2f | 市值 %s | 浮动盈亏 %s (%+.2f%%)\n",
+			price, model.FormatAmount(h.Value(price)), model.FormatAmountDelta(pnl), pnl/h.TotalInvested*100))
+	}
+	return b.String()
+}
+
+// FormatMonthlySummary formats a monthly summary report, with a performance
+// section for perf, nil before the first purchase.
+func FormatMonthlySummary(state *model.FundState, perf *model.Performance) string {
 	var b strings.Builder
 	b.WriteString(fmt.Sprintf("📅 <b>月度汇总</b> | %s\n\n", time.Now().Format("2006-01")))
-	b.WriteString(fmt.Sprintf("常规池余额: ¥%.0f\n", state.RegularBalance))
-	b.WriteString(fmt.Sprintf("储备池余额: ¥%.0f\n", state.ReserveBalance))
+	if len(state.Symbols) == 0 {
+		b.WriteString(fmt.Sprintf("常规池余额: %s\n", model.FormatAmount(state.RegularBalance)))
+		b.WriteString(fmt.Sprintf("储备池余额: %s\n", model.FormatAmount(state.ReserveBalance)))
+	} else {
+		for _, st := range append([]*model.FundState{state}, symbolStates(state)...) {
+			b.WriteString(fmt.Sprintf("%s: 常规池 %s | 储备池 %s\n", st.Symbol, model.FormatAmount(st.RegularBalance), model.FormatAmount(st.ReserveBalance)))
+		}
+	}
 
-	if len(state.RecentScores) > 0 {
-		sum := 0.0
-		for _, s := range state.RecentScores {
-			sum += s
+	if n := len(state.RecentScores); n > 0 {
+		avg, stddev, sharpe := calculator.ScoreStats(state.RecentScores)
+		b.WriteString(fmt.Sprintf("近期平均评分: %s (%d周)\n", model.FormatScore(avg), n))
+		if n >= 2 {
+			b.WriteString(fmt.Sprintf("评分波动: %.2f | 均值/波动: %+.2f\n", stddev, sharpe))
 		}
-		avg := sum / float64(len(state.RecentScores))
-		b.WriteString(fmt.Sprintf("近期平均评分: %+.3f (%d周)\n", avg, len(state.RecentScores)))
 	}
+	if state.LowParticipationWeeks > 0 {
+		b.WriteString(fmt.Sprintf("连续低投入周数: %d (累计少投 %s)\n", state.LowParticipationWeeks, model.FormatAmount(state.LowParticipationShortfall)))
+	}
+	b.WriteString("\n" + FormatPerformance(perf))
 
 	b.WriteString("\n已完成月度资金补充 ✅")
 	return b.String()
 }
+
+// FormatPerformance formats how the holding has done and what sales realized
+// this year. Before the first purchase, with nothing held, or without a price,
+// it says so instead of showing a zero return.
+func FormatPerformance(perf *model.Performance) string {
+	var b strings.Builder
+	b.WriteString("📈 <b>投资表现</b>\n")
+	switch {
+	case perf == nil:
+		b.WriteString("暂无成交记录，使用 /bought 记录实际成交后显示收益\n")
+		return b.String()
+	case perf.Invested <= 0:
+		b.WriteString("当前无持仓\n")
+		if !math.IsNaN(perf.XIRR) {
+			b.WriteString(fmt.Sprintf("年化收益 (XIRR): %+.2f%%\n", perf.XIRR*100))
+		}
+	case perf.Price <= 0:
+		b.WriteString(fmt.Sprintf("累计投入: %s\n现价获取失败，暂无法计算收益\n", model.FormatAmount(perf.Invested)))
+	default:
+		b.WriteString(fmt.Sprintf("累计投入: %s | 市值: %s (现价 %.2f)\n",
+			model.FormatAmount(perf.Invested), model.FormatAmount(perf.Value), perf.Price))
+		b.WriteString(fmt.Sprintf("浮动盈亏: %s | 收益率: %+.2f%%\n", model.FormatAmountDelta(perf.UnrealizedPnL), perf.ROI*100))
+		if math.IsNaN(perf.XIRR) {
+			b.WriteString("年化收益 (XIRR): 无法计算\n")
+		} else {
+			b.WriteString(fmt.Sprintf("年化收益 (XIRR): %+.2f%%\n", perf.XIRR*100))
+		}
+	}
+	if perf.SalesYTD > 0 {
+		b.WriteString(fmt.Sprintf("本年已实现盈亏: %s (%d 笔卖出)\n", model.FormatAmountDelta(perf.RealizedYTD), perf.SalesYTD))
+	}
+	return b.String()
+}
+
+// FormatReplenishSplit describes how the monthly budget was divided between
+// the pools, and for an allocated fund between the symbols.
+func FormatReplenishSplit(split *model.ReplenishSplit) string {
+	s := fmt.Sprintf("本月分配: 常规池 %s (%.0f%%) | 储备池 %s (%.0f%%)\n依据: %s",
+		model.FormatAmountDelta(split.RegularAdded), (1-model.RoundWeight(split.ReserveShare))*100,
+		model.FormatAmountDelta(split.ReserveAdded), model.RoundWeight(split.ReserveShare)*100, split.Reason)
+	if split.CarryNote != "" {
+		s += "\n结转: " + split.CarryNote
+	}
+	for _, part := range split.Parts {
+		s += fmt.Sprintf("\n  %s: 常规池 %s | 储备池 %s（%s）",
+			part.Symbol, model.FormatAmountDelta(part.RegularAdded), model.FormatAmountDelta(part.ReserveAdded), part.Reason)
+		if part.CarryNote != "" {
+			s += "\n    结转: " + part.CarryNote
+		}
+	}
+	return s
+}
+
+// FormatWeeklyRecap formats the end-of-week comparison of the weekly suggestion
+// against the market. hits/total describe the rolling hit-rate window.
+func FormatWeeklyRecap(recap *recorder.WeeklyRecap, tierLabel string, hits, total int) string {
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("🗓 <b>周回顾</b> | %s 当周\n\n", recap.WeekStart.Format("2006-01-02")))
+	b.WriteString(fmt.Sprintf("周一建议: %s (评分 %s, 价格 %.2f)\n", tierLabel, model.FormatScore(recap.TotalScore), recap.SuggestedPrice))
+
+	weekMove := 0.0
+	if recap.WeekOpen > 0 {
+		weekMove = (recap.WeekClose - recap.WeekOpen) / recap.WeekOpen * 100
+	}
+	b.WriteString(fmt.Sprintf("本周走势: %.2f → %.2f (%+.2f%%)\n", recap.WeekOpen, recap.WeekClose, weekMove))
+
+	if recap.BottomFished {
+		b.WriteString("抄底触发: 是 🎣\n")
+	} else {
+		b.WriteString("抄底触发: 否\n")
+	}
+
+	mark := "❌"
+	if recap.Hit {
+		mark = "✅"
+	}
+	b.WriteString(fmt.Sprintf("方向判断: %s\n", mark))
+	if total > 0 {
+		b.WriteString(fmt.Sprintf("近%d周命中率: %d/%d (%.0f%%)\n", total, hits, total, float64(hits)/float64(total)*100))
+	}
+	return b.String()
+}
+
+// GapAlert is the content of a pre-market gap alert.
+type GapAlert struct {
+	Symbol    string
+	PrevClose float64
+	Quote     float64
+	GapPct    float64
+	Snapshot  *recorder.WeeklySnapshotSummary // latest weekly report, nil if none
+	Capacity  fund.BottomFishCapacity
+}
+
+// FormatGapAlert formats the informational pre-market gap alert.
+func FormatGapAlert(a GapAlert) string {
+	var b strings.Builder
+	icon := "📈"
+	if a.GapPct < 0 {
+		icon = "📉"
+	}
+	b.WriteString(fmt.Sprintf("%s <b>盘前跳空</b> | %s\n\n", icon, a.Symbol))
+	b.WriteString(fmt.Sprintf("昨收: %.2f → 盘前: %.2f (%+.2f%%)\n", a.PrevClose, a.Quote, a.GapPct))
+	if a.Snapshot != nil {
+		b.WriteString(fmt.Sprintf("最近周报: %s 评分 %s → %s\n",
+			a.Snapshot.Timestamp.Format("01-02"), model.FormatScore(a.Snapshot.TotalScore), a.Snapshot.TierLabel))
+	}
+
+	c := a.Capacity
+	avail := "可用"
+	if c.Level > 0 {
+		avail = fmt.Sprintf("本周已触发第%d档，仅更深档位可用", c.Level)
+	}
+	switch {
+	case c.Locked:
+		b.WriteString("抄底额度: 资金账本已锁定，等待 /reconcile\n")
+	case c.Limited:
+		b.WriteString(fmt.Sprintf("抄底额度: %s (储备池 %s，本周剩余投入上限 %s)\n", avail, model.FormatAmount(c.Reserve), model.FormatAmount(c.Remaining)))
+	default:
+		b.WriteString(fmt.Sprintf("抄底额度: %s (储备池 %s)\n", avail, model.FormatAmount(c.Reserve)))
+	}
+	b.WriteString("\n仅供参考，资金池未变动")
+	return b.String()
+}
+
+// formatFX renders a yuan amount converted at rate, e.g. " (≈$221)", or ""
+// without a rate.
+func formatFX(amount, rate float64) string {
+	if rate <= 0 {
+		return ""
+	}
+	return fmt.Sprintf(" (≈%s)", model.FormatUSD(amount/rate))
+}
+
+// FormatSourceAlert formats the alert sent when the primary data source
+// disagrees with the verification source.
+func FormatSourceAlert(symbol, warning string) string {
+	return fmt.Sprintf("⚠️ <b>数据源不一致</b> | %s\n\n%s\n\n本周建议基于主数据源计算，执行前请核对行情", symbol, warning)
+}
+
+// FormatDailySourceAlert formats the alert sent when the daily check finds
+// the data sources disagreeing; the bottom-fish trigger is skipped that day.
+func FormatDailySourceAlert(symbol, warning string) string {
+	return fmt.Sprintf("⚠️ <b>数据源不一致</b> | %s\n\n%s\n\n今日抄底检查已跳过，请核对行情", symbol, warning)
+}
+
+// statsBarWidth is the length of the histogram bar for the most frequent tier.
+const statsBarWidth = 12
+
+// FormatTierStats formats the tier distribution over the last `months` months.
+func FormatTierStats(st reporting.TierStats, months int) string {
+	if st.Weeks == 0 {
+		return fmt.Sprintf("📊 近%d个月暂无周报历史记录", months)
+	}
+
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("📊 <b>档位分布</b> | 近%d个月 (%d周)\n\n", months, st.Weeks))
+
+	maxCount := 0
+	for _, t := range st.Tiers {
+		if t.Count > maxCount {
+			maxCount = t.Count
+		}
+	}
+	for _, t := range st.Tiers {
+		width := t.Count * statsBarWidth / maxCount
+		if width == 0 {
+			width = 1
+		}
+		b.WriteString(fmt.Sprintf("%s %s %d\n", t.Label, strings.Repeat("▇", width), t.Count))
+	}
+
+	b.WriteString(fmt.Sprintf("\n平均评分: %s | 中位数: %s\n", model.FormatScore(st.AvgScore), model.FormatScore(st.MedianScore)))
+	b.WriteString(fmt.Sprintf("动用储备周数: %d\n", st.ReserveWeeks))
+	b.WriteString(fmt.Sprintf("建议投入合计: %s (恒定N: %s)\n", model.FormatAmount(st.SuggestedTotal), model.FormatAmount(st.ConstantNTotal)))
+	return b.String()
+}
+
+// FormatOpsSummary formats the 24h operational footer appended to the weekly report.
+func FormatOpsSummary(sum reporting.OpsSummary) string {
+	var b strings.Builder
+	b.WriteString("🛠 <b>运行状况</b> | 近24小时\n")
+	if sum.Collections > 0 {
+		b.WriteString(fmt.Sprintf("数据采集: %d次 | p50 %.1fs | p95 %.1fs\n", sum.Collections, sum.CollectP50, sum.CollectP95))
+	} else {
+		b.WriteString("数据采集: 暂无采样\n")
+	}
+	b.WriteString(fmt.Sprintf("采集失败: %d | 推送失败: %d\n", sum.CollectFailures, sum.SendFailures))
+	return b.String()
+}
+
+// stageLabels are the footer names of weekly run stages.
+var stageLabels = map[pipeline.Stage]string{
+	pipeline.StageDaily:  "日线",
+	pipeline.StageWeekly: "周线",
+	pipeline.StageQuote:  "报价",
+	pipeline.StageAux:    "波动率",
+	pipeline.StageYield:  "收益率",
+	pipeline.StageFX:     "汇率",
+	pipeline.StageVerify: "校验",
+	pipeline.StageRender: "渲染",
+	pipeline.StageSend:   "推送",
+}
+
+// FormatStageTimings formats the per-stage timing line of a weekly run, e.g.
+// "⏱ 日线 1.2s · 周线 超时(缓存) · 报价 0.3s · 渲染 0.0s".
+func FormatStageTimings(stages pipeline.StageReport) string {
+	parts := make([]string, len(stages))
+	for i, st := range stages {
+		label := stageLabels[st.Stage]
+		switch {
+		case st.TimedOut && st.Cached:
+			parts[i] = label + " 超时(缓存)"
+		case st.TimedOut:
+			parts[i] = label + " 超时"
+		default:
+			parts[i] = fmt.Sprintf("%s %.1fs", label, st.Duration.Seconds())
+		}
+	}
+	return "⏱ " + strings.Join(parts, " · ")
+}
+
+// FormatFundViolation formats the critical alert sent when the fund state
+// fails its invariant check and mutations are locked.
+func FormatFundViolation(violation error, state *model.FundState) string {
+	var b strings.Builder
+	b.WriteString("🚨 <b>资金账本校验失败</b>\n\n")
+	b.WriteString(fmt.Sprintf("违规: %v\n\n", violation))
+	b.WriteString(FormatFundStatus(state))
+	b.WriteString(fmt.Sprintf("本周已投入: %s | 近期评分: %d条\n", model.FormatAmountExact(state.WeekDeployed), len(state.RecentScores)))
+	b.WriteString("\n资金变动已暂停，数据采集和查询照常。核对后发送 /reconcile 解锁")
+	return b.String()
+}
+
+// FormatReconcilePreview shows what /reconcile would change, old → new.
+func FormatReconcilePreview(locked error, before, after *model.FundState) string {
+	var b strings.Builder
+	b.WriteString("🧾 <b>资金对账</b>\n\n")
+	if locked != nil {
+		b.WriteString(fmt.Sprintf("%v\n\n", locked))
+	} else {
+		b.WriteString("账本当前未锁定\n\n")
+	}
+	b.WriteString(fmt.Sprintf("常规池: %s → %s\n", model.FormatAmountExact(before.RegularBalance), model.FormatAmountExact(after.RegularBalance)))
+	b.WriteString(fmt.Sprintf("储备池: %s → %s\n", model.FormatAmountExact(before.ReserveBalance), model.FormatAmountExact(after.ReserveBalance)))
+	b.WriteString(fmt.Sprintf("周基准N: %s → %s\n", model.FormatAmountExact(before.WeeklyBaseN), model.FormatAmountExact(after.WeeklyBaseN)))
+	b.WriteString(fmt.Sprintf("本周已投入: %s → %s\n", model.FormatAmountExact(before.WeekDeployed), model.FormatAmountExact(after.WeekDeployed)))
+	b.WriteString(fmt.Sprintf("近期评分: %d条 → %d条", len(before.RecentScores), len(after.RecentScores)))
+	return b.String()
+}
+
+// FormatFundChangePreview shows what a /deposit, /withdraw or /setbudget
+// titled title would change, old → new; the budget lines only when it changes.
+func FormatFundChangePreview(title string, before, after *model.FundState) string {
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("🧾 <b>%s</b>\n\n", title))
+	b.WriteString(fmt.Sprintf("常规池: %s → %s\n", model.FormatAmountExact(before.RegularBalance), model.FormatAmountExact(after.RegularBalance)))
+	b.WriteString(fmt.Sprintf("储备池: %s → %s", model.FormatAmountExact(before.ReserveBalance), model.FormatAmountExact(after.ReserveBalance)))
+	if before.TotalBudget() != after.TotalBudget() {
+		b.WriteString(fmt.Sprintf("\n月度预算: %s → %s", model.FormatAmountExact(before.TotalBudget()), model.FormatAmountExact(after.TotalBudget())))
+		b.WriteString(fmt.Sprintf("\n周基准N: %s → %s", model.FormatAmountExact(before.WeeklyBaseN), model.FormatAmountExact(after.WeeklyBaseN)))
+	}
+	return b.String()
+}
+
+// FormatReconcileReport compares simulated and actual investment month by month.
+func FormatReconcileReport(months []reporting.MonthDrift, window int) string {
+	if len(months) == 0 {
+		return fmt.Sprintf("🧾 近%d个月没有模拟投入或导入的成交记录", window)
+	}
+
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("🧾 <b>模拟 vs 实际</b> | 近%d个月\n\n", window))
+	var simulated, actual float64
+	for _, m := range months {
+		b.WriteString(fmt.Sprintf("%s: 模拟 %s | 实际 %s (%d笔) | 偏差 %s\n",
+			m.Month.Format("2006-01"), model.FormatAmount(m.Simulated), model.FormatAmount(m.Actual), m.Trades, model.FormatAmountDelta(m.Drift())))
+		simulated += m.Simulated
+		actual += m.Actual
+	}
+	b.WriteString(fmt.Sprintf("\n合计: 模拟 %s | 实际 %s | 偏差 %s", model.FormatAmount(simulated), model.FormatAmount(actual), model.FormatAmountDelta(actual-simulated)))
+	if simulated > 0 {
+		b.WriteString(fmt.Sprintf(" (%+.1f%%)", (actual-simulated)/simulated*100))
+	}
+	b.WriteString("\n")
+	return b.String()
+}
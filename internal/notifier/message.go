@@ -0,0 +1,61 @@
+package notifier
+
+import "context"
+
+// MessageType classifies an outbound message so downstream features
+// (delivery records, quiet hours, dedup) can treat kinds differently.
+type MessageType string
+
+const (
+	MsgWeeklyReport MessageType = "weekly_report"
+	MsgBottomFish   MessageType = "bottom_fish"
+	MsgTakeProfit   MessageType = "take_profit"
+	MsgMonthly      MessageType = "monthly"
+	MsgQuarterly    MessageType = "quarterly"
+	MsgError        MessageType = "error"
+	MsgObservation  MessageType = "observation"
+	MsgGapAlert     MessageType = "gap_alert"
+	MsgSourceAlert  MessageType = "source_alert"
+)
+
+// Priority ranks how urgent a message is.
+type Priority int
+
+const (
+	PriorityLow Priority = iota
+	PriorityNormal
+	PriorityHigh
+)
+
+func (p Priority) String() string {
+	switch p {
+	case PriorityLow:
+		return "low"
+	case PriorityHigh:
+		return "high"
+	default:
+		return "normal"
+	}
+}
+
+// Message is a typed outbound notification.
+type Message struct {
+	Type     MessageType
+	Priority Priority
+	Text     string
+	Meta     map[string]string
+	ReplyTo  int64 // id of the message this one replies to, 0 for none
+}
+
+// NewMessage builds a message with no metadata.
+func NewMessage(typ MessageType, priority Priority, text string) Message {
+	return Message{Type: typ, Priority: priority, Text: text}
+}
+
+// Notifier delivers outbound messages. Sends return the delivered message id
+// so follow-ups can reply to it.
+type Notifier interface {
+	SendMessage(ctx context.Context, msg Message, maxRetries int) (int64, error)
+	SendPhoto(ctx context.Context, msg Message, photo []byte) (int64, error)
+	SendWithKeyboard(text string, rows [][]string) error
+}
@@ -0,0 +1,99 @@
+package notifier
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// chat returns the chat id messages are currently sent to.
+func (t *TelegramNotifier) chat() string {
+	t.chatMu.RLock()
+	defer t.chatMu.RUnlock()
+	return t.ChatID
+}
+
+// migratedChatID returns the new chat id from a "group chat was upgraded to a
+// supergroup chat" error, or "" for any other error.
+func migratedChatID(err error) string {
+	var apiErr *apiError
+	if !errors.As(err, &apiErr) || apiErr.Status != http.StatusBadRequest {
+		return ""
+	}
+	var body struct {
+		Parameters struct {
+			MigrateToChatID int64 `json:"migrate_to_chat_id"`
+		} `json:"parameters"`
+	}
+	if json.Unmarshal([]byte(apiErr.Body), &body) != nil || body.Parameters.MigrateToChatID == 0 {
+		return ""
+	}
+	return strconv.FormatInt(body.Parameters.MigrateToChatID, 10)
+}
+
+// migrateChat switches sending from oldID to newID, then runs OnChatMigrated
+// and announces the change in the new chat. It does nothing if the chat is no
+// longer oldID, so concurrent senders hitting the same error migrate once.
+func (t *TelegramNotifier) migrateChat(oldID, newID string) {
+	t.chatMu.Lock()
+	if t.ChatID != oldID || oldID == newID {
+		t.chatMu.Unlock()
+		return
+	}
+	t.ChatID = newID
+	t.chatMu.Unlock()
+
+	log.Printf("[WARN] telegram chat %s was upgraded to supergroup %s, switched chat id", oldID, newID)
+	if t.OnChatMigrated != nil {
+		t.OnChatMigrated(oldID, newID)
+	}
+	notice := fmt.Sprintf("ℹ️ 群组已升级为超级群组，推送已切换到新的聊天ID %s（原 %s）\n建议同步更新配置中的 telegram.chat_id", newID, oldID)
+	if _, err := t.send(Message{Text: notice}); err != nil {
+		log.Printf("[ERROR] send chat migration notice: %v", err)
+	}
+}
+
+// ChatMigration is the persisted result of a supergroup migration.
+type ChatMigration struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// SaveChatMigration writes the migration to path so it survives restarts.
+func SaveChatMigration(path string, m ChatMigration) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("create chat state dir: %w", err)
+	}
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+// ResolveChatID returns the migrated chat id recorded at path when it was
+// migrated from configured, or configured otherwise. A missing file is not an
+// error; a migration from a different chat id is ignored, so changing the
+// configured chat id still takes effect.
+func ResolveChatID(path, configured string) (string, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return configured, nil
+	}
+	if err != nil {
+		return configured, fmt.Errorf("read chat state: %w", err)
+	}
+	var m ChatMigration
+	if err := json.Unmarshal(data, &m); err != nil {
+		return configured, fmt.Errorf("parse chat state: %w", err)
+	}
+	if m.From == configured && m.To != "" {
+		return m.To, nil
+	}
+	return configured, nil
+}
@@ -0,0 +1,135 @@
+package notifier
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+const migrateErrorBody = `{"ok":false,"error_code":400,"description":"Bad Request: group chat was upgraded to a supergroup chat","parameters":{"migrate_to_chat_id":-1001234567890}}`
+
+// fakeBotAPI answers sendMessage for newChat and rejects oldChat with the
+// supergroup migration error. It records the chat and text of every request.
+type fakeBotAPI struct {
+	mu    sync.Mutex
+	chats []string
+	texts []string
+}
+
+func (f *fakeBotAPI) serve(t *testing.T, oldChat string) *TelegramNotifier {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload struct {
+			ChatID string `json:"chat_id"`
+			Text   string `json:"text"`
+		}
+		json.NewDecoder(r.Body).Decode(&payload)
+		f.mu.Lock()
+		f.chats = append(f.chats, payload.ChatID)
+		f.texts = append(f.texts, payload.Text)
+		n := len(f.chats)
+		f.mu.Unlock()
+
+		if payload.ChatID == oldChat {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(migrateErrorBody))
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"ok": true, "result": map[string]int{"message_id": n}})
+	}))
+	t.Cleanup(srv.Close)
+
+	tn := NewTelegramNotifier("token", oldChat, nil)
+	tn.APIBase = srv.URL
+	return tn
+}
+
+func TestSendMessage_FollowsChatMigration(t *testing.T) {
+	api := &fakeBotAPI{}
+	tn := api.serve(t, "-42")
+	statePath := filepath.Join(t.TempDir(), "telegram_chat.json")
+	var migrated []string
+	tn.OnChatMigrated = func(oldID, newID string) {
+		migrated = append(migrated, oldID+"->"+newID)
+		SaveChatMigration(statePath, ChatMigration{From: oldID, To: newID})
+	}
+
+	id, err := tn.SendMessage(context.Background(), NewMessage(MsgWeeklyReport, PriorityNormal, "周报"), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tn.chat() != "-1001234567890" || len(migrated) != 1 || migrated[0] != "-42->-1001234567890" {
+		t.Fatalf("expected one migration to the supergroup, chat=%s migrated=%v", tn.chat(), migrated)
+	}
+	// old chat rejected, migration notice, then the original message resent
+	if len(api.chats) != 3 || api.chats[1] != "-1001234567890" || api.texts[2] != "周报" || id != 3 {
+		t.Errorf("unexpected requests: chats=%v texts=%v id=%d", api.chats, api.texts, id)
+	}
+
+	if got, err := ResolveChatID(statePath, "-42"); err != nil || got != "-1001234567890" {
+		t.Errorf("expected persisted migration, got %q (err %v)", got, err)
+	}
+	if got, _ := ResolveChatID(statePath, "-7"); got != "-7" {
+		t.Errorf("migration of another chat must not apply, got %q", got)
+	}
+}
+
+func TestMigratedChatID(t *testing.T) {
+	if got := migratedChatID(&apiError{Status: 400, Body: migrateErrorBody}); got != "-1001234567890" {
+		t.Errorf("got %q", got)
+	}
+	if got := migratedChatID(&apiError{Status: 400, Body: `{"ok":false,"description":"Bad Request: chat not found"}`}); got != "" {
+		t.Errorf("expected no migration for other errors, got %q", got)
+	}
+}
+
+func TestHandleUpdate_MigrationServiceMessage(t *testing.T) {
+	api := &fakeBotAPI{}
+	tn := api.serve(t, "-42")
+
+	var update telegramUpdate
+	if err := json.Unmarshal([]byte(`{"update_id":1,"message":{"chat":{"id":-42},"migrate_to_chat_id":-1001234567890}}`), &update); err != nil {
+		t.Fatal(err)
+	}
+	tn.handleUpdate(update, func(int64, string) string { t.Fatal("service message passed to handler"); return "" })
+	if tn.chat() != "-1001234567890" {
+		t.Fatalf("expected chat switched, got %s", tn.chat())
+	}
+
+	// Commands from the new supergroup are answered there.
+	var cmd telegramUpdate
+	json.Unmarshal([]byte(`{"update_id":2,"message":{"chat":{"id":-1001234567890},"from":{"id":7},"text":"/fund"}}`), &cmd)
+	tn.handleUpdate(cmd, func(userID int64, cmd string) string { return "ok" })
+	if last := api.chats[len(api.chats)-1]; last != "-1001234567890" || api.texts[len(api.texts)-1] != "ok" {
+		t.Errorf("expected reply in new chat, got %v %v", api.chats, api.texts)
+	}
+}
+
+func TestHandleUpdate_ChatOnly(t *testing.T) {
+	api := &fakeBotAPI{}
+	tn := api.serve(t, "")
+	tn.ChatID = "-42"
+	tn.ChatOnly = func(cmd string) bool { return strings.HasPrefix(cmd, "/setbudget") }
+
+	var handled []string
+	handler := func(_ int64, cmd string) string { handled = append(handled, cmd); return "ok" }
+	for _, u := range []string{
+		`{"update_id":1,"message":{"chat":{"id":99},"from":{"id":7},"text":"/setbudget 15000"}}`,
+		`{"update_id":2,"message":{"chat":{"id":99},"from":{"id":7},"text":"/fund"}}`,
+		`{"update_id":3,"message":{"chat":{"id":-42},"from":{"id":7},"text":"/setbudget 15000"}}`,
+	} {
+		var update telegramUpdate
+		if err := json.Unmarshal([]byte(u), &update); err != nil {
+			t.Fatal(err)
+		}
+		tn.handleUpdate(update, handler)
+	}
+	if want := []string{"/fund", "/setbudget 15000"}; strings.Join(handled, "|") != strings.Join(want, "|") {
+		t.Errorf("handled %q, want %q", handled, want)
+	}
+}
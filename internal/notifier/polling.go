@@ -7,18 +7,29 @@ import (
 	"io"
 	"log"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 )
 
 // CommandHandler is called when a user command is received.
-type CommandHandler func(command string) string
+// userID identifies the sender so multi-step commands can be bound to one user.
+type CommandHandler func(userID int64, command string) string
 
 // telegramUpdate represents a Telegram update from long polling.
 type telegramUpdate struct {
 	UpdateID int `json:"update_id"`
 	Message  *struct {
 		Text string `json:"text"`
+		From *struct {
+			ID int64 `json:"id"`
+		} `json:"from"`
+		Chat struct {
+			ID int64 `json:"id"`
+		} `json:"chat"`
+		// MigrateToChatID is set on the service message posted in a group
+		// that was upgraded to a supergroup.
+		MigrateToChatID int64 `json:"migrate_to_chat_id"`
 	} `json:"message"`
 }
 
@@ -35,7 +46,7 @@ func (t *TelegramNotifier) StartPolling(ctx context.Context, handler CommandHand
 		default:
 		}
 
-		apiURL := fmt.Sprintf("https://api.telegram.org/bot%s/getUpdates?offset=%d&timeout=30", t.BotToken, offset)
+		apiURL := fmt.Sprintf("%s/bot%s/getUpdates?offset=%d&timeout=30", t.APIBase, t.BotToken, offset)
 		req, err := http.NewRequestWithContext(ctx, "GET", apiURL, nil)
 		if err != nil {
 			log.Printf("[ERROR] create polling request: %v", err)
@@ -71,17 +82,40 @@ func (t *TelegramNotifier) StartPolling(ctx context.Context, handler CommandHand
 
 		for _, update := range result.Result {
 			offset = update.UpdateID + 1
-			if update.Message == nil || update.Message.Text == "" {
-				continue
-			}
-			text := strings.TrimSpace(update.Message.Text)
-			log.Printf("[INFO] received command: %s", text)
-			reply := handler(text)
-			if reply != "" {
-				if err := t.Send(reply); err != nil {
-					log.Printf("[ERROR] send reply: %v", err)
-				}
-			}
+			t.handleUpdate(update, handler)
+		}
+	}
+}
+
+// handleUpdate follows a chat migration announced in the update, or passes a
+// text command to handler and sends its reply to the current chat. Commands
+// ChatOnly reports are dropped unless they come from the current chat.
+func (t *TelegramNotifier) handleUpdate(update telegramUpdate, handler CommandHandler) {
+	msg := update.Message
+	if msg == nil {
+		return
+	}
+	if msg.MigrateToChatID != 0 {
+		t.migrateChat(strconv.FormatInt(msg.Chat.ID, 10), strconv.FormatInt(msg.MigrateToChatID, 10))
+		return
+	}
+	if msg.Text == "" {
+		return
+	}
+	text := strings.TrimSpace(msg.Text)
+	var userID int64
+	if msg.From != nil {
+		userID = msg.From.ID
+	}
+	log.Printf("[INFO] received command from %d: %s", userID, text)
+	if t.ChatOnly != nil && t.ChatOnly(text) && strconv.FormatInt(msg.Chat.ID, 10) != t.chat() {
+		log.Printf("[WARN] ignored command from chat %d, only accepted from the configured chat: %s", msg.Chat.ID, text)
+		return
+	}
+	reply := handler(userID, text)
+	if reply != "" {
+		if err := t.Send(reply); err != nil {
+			log.Printf("[ERROR] send reply: %v", err)
 		}
 	}
 }
@@ -4,32 +4,51 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
+	"mime/multipart"
 	"net/http"
 	"net/url"
+	"sync"
 	"time"
 )
 
+// maxCaptionLen is Telegram's limit for photo captions.
+const maxCaptionLen = 1024
+
+// defaultAPIBase is the Bot API endpoint.
+const defaultAPIBase = "https://api.telegram.org"
+
 // TelegramNotifier sends messages via the Telegram Bot API.
 type TelegramNotifier struct {
 	BotToken string
-	ChatID   string
+	ChatID   string // read through chat() once sending has started; may change on migration
+	ThreadID int64  // forum topic to post into, 0 for the main chat
 	Client   *http.Client
+	APIBase  string
+	// OnChatMigrated is called after the group was upgraded to a supergroup
+	// and ChatID switched to the new id, e.g. to persist it.
+	OnChatMigrated func(oldID, newID string)
+	// ChatOnly reports the commands accepted only from the configured chat;
+	// from any other chat they are ignored. Nil accepts every command.
+	ChatOnly func(command string) bool
+
+	chatMu sync.RWMutex
 }
 
-// NewTelegramNotifier creates a notifier with optional proxy support.
-func NewTelegramNotifier(botToken, chatID, proxyURL string) *TelegramNotifier {
+// NewTelegramNotifier creates a notifier with optional proxy support;
+// proxy may be an http(s):// or socks5:// URL, nil for none.
+func NewTelegramNotifier(botToken, chatID string, proxy *url.URL) *TelegramNotifier {
 	transport := &http.Transport{}
-	if proxyURL != "" {
-		if u, err := url.Parse(proxyURL); err == nil {
-			transport.Proxy = http.ProxyURL(u)
-		}
+	if proxy != nil {
+		transport.Proxy = http.ProxyURL(proxy)
 	}
 	return &TelegramNotifier{
 		BotToken: botToken,
 		ChatID:   chatID,
+		APIBase:  defaultAPIBase,
 		Client: &http.Client{
 			Timeout:   30 * time.Second,
 			Transport: transport,
@@ -39,44 +58,231 @@ func NewTelegramNotifier(botToken, chatID, proxyURL string) *TelegramNotifier {
 
 // Send sends a message to the configured chat.
 func (t *TelegramNotifier) Send(text string) error {
-	apiURL := fmt.Sprintf("https://api.telegram.org/bot%s/sendMessage", t.BotToken)
-	payload := map[string]string{
-		"chat_id":    t.ChatID,
+	_, err := t.send(Message{Text: text})
+	return err
+}
+
+// send delivers msg as HTML text and returns the sent message id.
+func (t *TelegramNotifier) send(msg Message) (int64, error) {
+	payload := map[string]interface{}{
+		"chat_id":    t.chat(),
+		"text":       msg.Text,
+		"parse_mode": "HTML",
+	}
+	return t.sendThreaded(payload, msg.ReplyTo)
+}
+
+// threadParams returns the reply/topic parameters for a message replying to
+// parent (0 for none).
+func (t *TelegramNotifier) threadParams(parent int64) map[string]interface{} {
+	params := make(map[string]interface{})
+	if parent != 0 {
+		params["reply_to_message_id"] = parent
+		params["allow_sending_without_reply"] = true
+	}
+	if t.ThreadID != 0 {
+		params["message_thread_id"] = t.ThreadID
+	}
+	return params
+}
+
+// sendThreaded adds thread parameters to payload and sends it. If Telegram
+// rejects the request with thread parameters present, it is retried once as an
+// independent message.
+func (t *TelegramNotifier) sendThreaded(payload map[string]interface{}, parent int64) (int64, error) {
+	params := t.threadParams(parent)
+	for k, v := range params {
+		payload[k] = v
+	}
+	id, err := t.sendMessage(payload)
+	var apiErr *apiError
+	if err == nil || len(params) == 0 || !errors.As(err, &apiErr) || apiErr.Status != http.StatusBadRequest {
+		return id, err
+	}
+	log.Printf("[INFO] telegram rejected thread parameters, sending unthreaded: %v", err)
+	for k := range params {
+		delete(payload, k)
+	}
+	return t.sendMessage(payload)
+}
+
+// apiError is a non-200 response from the Bot API.
+type apiError struct {
+	Status int
+	Body   string
+}
+
+func (e *apiError) Error() string {
+	return fmt.Sprintf("telegram API error: status %d, body: %s", e.Status, e.Body)
+}
+
+// sentMessage is the part of a Bot API send response we read.
+type sentMessage struct {
+	Result struct {
+		MessageID int64 `json:"message_id"`
+	} `json:"result"`
+}
+
+// readSent returns the message id from a send response, or an apiError.
+func readSent(resp *http.Response) (int64, error) {
+	respBody, err := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return 0, &apiError{Status: resp.StatusCode, Body: string(respBody)}
+	}
+	if err != nil {
+		return 0, fmt.Errorf("read response: %w", err)
+	}
+	var sent sentMessage
+	if err := json.Unmarshal(respBody, &sent); err != nil {
+		return 0, fmt.Errorf("decode response: %w", err)
+	}
+	return sent.Result.MessageID, nil
+}
+
+// SendWithKeyboard sends a message with a one-time reply keyboard. Each inner
+// slice is one row of button labels; pressing a button sends its label as text.
+func (t *TelegramNotifier) SendWithKeyboard(text string, rows [][]string) error {
+	keyboard := make([][]map[string]string, len(rows))
+	for i, row := range rows {
+		for _, label := range row {
+			keyboard[i] = append(keyboard[i], map[string]string{"text": label})
+		}
+	}
+	_, err := t.sendThreaded(map[string]interface{}{
+		"chat_id":    t.chat(),
 		"text":       text,
 		"parse_mode": "HTML",
+		"reply_markup": map[string]interface{}{
+			"keyboard":          keyboard,
+			"one_time_keyboard": true,
+			"resize_keyboard":   true,
+		},
+	}, 0)
+	return err
+}
+
+// sendMessage posts payload to sendMessage. If the chat was upgraded to a
+// supergroup, it switches to the new chat and resends once.
+func (t *TelegramNotifier) sendMessage(payload map[string]interface{}) (int64, error) {
+	id, err := t.postMessage(payload)
+	if newID := migratedChatID(err); newID != "" {
+		t.migrateChat(fmt.Sprint(payload["chat_id"]), newID)
+		payload["chat_id"] = newID
+		return t.postMessage(payload)
 	}
+	return id, err
+}
+
+func (t *TelegramNotifier) postMessage(payload map[string]interface{}) (int64, error) {
+	apiURL := fmt.Sprintf("%s/bot%s/sendMessage", t.APIBase, t.BotToken)
 	body, err := json.Marshal(payload)
 	if err != nil {
-		return fmt.Errorf("marshal payload: %w", err)
+		return 0, fmt.Errorf("marshal payload: %w", err)
 	}
 	resp, err := t.Client.Post(apiURL, "application/json", bytes.NewReader(body))
 	if err != nil {
-		return fmt.Errorf("send message: %w", err)
+		return 0, fmt.Errorf("send message: %w", err)
 	}
 	defer resp.Body.Close()
-	if resp.StatusCode != http.StatusOK {
-		respBody, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("telegram API error: status %d, body: %s", resp.StatusCode, string(respBody))
+	return readSent(resp)
+}
+
+// SendPhoto sends a PNG with msg.Text as the caption and returns the photo's
+// message id. Captions over Telegram's limit are sent as a follow-up text
+// message replying to the photo instead.
+func (t *TelegramNotifier) SendPhoto(ctx context.Context, msg Message, photo []byte) (int64, error) {
+	caption := msg.Text
+	if len([]rune(caption)) > maxCaptionLen {
+		caption = ""
+	}
+
+	fields := map[string]string{"chat_id": t.chat(), "caption": caption, "parse_mode": "HTML"}
+	params := t.threadParams(msg.ReplyTo)
+	id, err := t.postPhoto(ctx, fields, params, photo)
+	if newID := migratedChatID(err); newID != "" {
+		t.migrateChat(fields["chat_id"], newID)
+		fields["chat_id"] = newID
+		id, err = t.postPhoto(ctx, fields, params, photo)
+	}
+	var apiErr *apiError
+	if err != nil && len(params) > 0 && errors.As(err, &apiErr) && apiErr.Status == http.StatusBadRequest {
+		log.Printf("[INFO] telegram rejected thread parameters, sending photo unthreaded: %v", err)
+		id, err = t.postPhoto(ctx, fields, nil, photo)
 	}
-	return nil
+	if err != nil {
+		return 0, err
+	}
+
+	if caption == "" && msg.Text != "" {
+		if _, err := t.send(Message{Text: msg.Text, ReplyTo: id}); err != nil {
+			return id, err
+		}
+	}
+	return id, nil
 }
 
-// SendWithRetry sends a message with exponential backoff retry.
-func (t *TelegramNotifier) SendWithRetry(ctx context.Context, text string, maxRetries int) error {
+func (t *TelegramNotifier) postPhoto(ctx context.Context, fields map[string]string, params map[string]interface{}, photo []byte) (int64, error) {
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	for k, v := range fields {
+		if err := w.WriteField(k, v); err != nil {
+			return 0, fmt.Errorf("write field %s: %w", k, err)
+		}
+	}
+	for k, v := range params {
+		if err := w.WriteField(k, fmt.Sprint(v)); err != nil {
+			return 0, fmt.Errorf("write field %s: %w", k, err)
+		}
+	}
+	part, err := w.CreateFormFile("photo", "chart.png")
+	if err != nil {
+		return 0, fmt.Errorf("create photo part: %w", err)
+	}
+	if _, err := part.Write(photo); err != nil {
+		return 0, fmt.Errorf("write photo: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return 0, fmt.Errorf("close multipart: %w", err)
+	}
+
+	apiURL := fmt.Sprintf("%s/bot%s/sendPhoto", t.APIBase, t.BotToken)
+	req, err := http.NewRequestWithContext(ctx, "POST", apiURL, &body)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", w.FormDataContentType())
+	resp, err := t.Client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("send photo: %w", err)
+	}
+	defer resp.Body.Close()
+	return readSent(resp)
+}
+
+// SendMessage delivers a typed message with exponential backoff retry and
+// returns the sent message id.
+func (t *TelegramNotifier) SendMessage(ctx context.Context, msg Message, maxRetries int) (int64, error) {
 	var lastErr error
 	for i := 0; i <= maxRetries; i++ {
-		if err := t.Send(text); err != nil {
-			lastErr = err
-			backoff := time.Duration(1<<uint(i)) * time.Second
-			log.Printf("[WARN] Telegram send failed (attempt %d/%d): %v, retrying in %v", i+1, maxRetries+1, err, backoff)
-			select {
-			case <-ctx.Done():
-				return ctx.Err()
-			case <-time.After(backoff):
-				continue
-			}
+		id, err := t.send(msg)
+		if err == nil {
+			return id, nil
+		}
+		lastErr = err
+		backoff := time.Duration(1<<uint(i)) * time.Second
+		log.Printf("[WARN] Telegram send failed (attempt %d/%d): %v, retrying in %v", i+1, maxRetries+1, err, backoff)
+		select {
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		case <-time.After(backoff):
 		}
-		return nil
 	}
-	return fmt.Errorf("all %d retries exhausted: %w", maxRetries+1, lastErr)
+	return 0, fmt.Errorf("all %d retries exhausted: %w", maxRetries+1, lastErr)
+}
+
+// SendWithRetry sends a plain-text message with exponential backoff retry.
+// Kept as a shim for callers that have no message type.
+func (t *TelegramNotifier) SendWithRetry(ctx context.Context, text string, maxRetries int) error {
+	_, err := t.SendMessage(ctx, Message{Text: text}, maxRetries)
+	return err
 }
@@ -0,0 +1,107 @@
+package notifier
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"MarketSentinel/internal/model"
+	"MarketSentinel/internal/pipeline"
+)
+
+// WeeklyStyle is the layout of the weekly report.
+type WeeklyStyle int
+
+const (
+	// WeeklyDetailed is the full report: indicators, factor table, action
+	// and fund status.
+	WeeklyDetailed WeeklyStyle = iota
+	// WeeklyCompact is a single paragraph for uneventful weeks.
+	WeeklyCompact
+)
+
+func (s WeeklyStyle) String() string {
+	if s == WeeklyCompact {
+		return "compact"
+	}
+	return "detailed"
+}
+
+// StylePolicy decides when a week is uneventful enough for the compact report.
+type StylePolicy struct {
+	// NeutralBand is the largest |score| reported compactly; 0 disables the
+	// compact report.
+	NeutralBand float64
+}
+
+// SelectWeeklyStyle picks the compact report when the score lies within the
+// neutral band and nothing in res needs attention; anything else gets the
+// detailed report.
+func SelectWeeklyStyle(res *pipeline.WeeklyResult, p StylePolicy) WeeklyStyle {
+	if p.NeutralBand <= 0 || math.Abs(res.Signal.TotalScore) > p.NeutralBand {
+		return WeeklyDetailed
+	}
+	if needsAttention(res) {
+		return WeeklyDetailed
+	}
+	return WeeklyCompact
+}
+
+// needsAttention reports whether res carries anything the compact report
+// would hide: warnings, a sell recommendation, caps, reserve guards, reserve
+// use, an investment skipped by rounding, a tier changed by smoothing, the
+// cooldown or low confidence, nudges, a locked fund, degraded indicators or
+// data, or a stage that ran out of time.
+func needsAttention(res *pipeline.WeeklyResult) bool {
+	sig := res.Signal
+	return len(sig.Warnings) > 0 || sig.CapNote != "" || sig.ReserveNote != "" || sig.ReserveUsed > 0 ||
+		(sig.RoundingNote != "" && sig.FinalAmount == 0) ||
+		(sig.Smoothed && sig.RawTierLabel != sig.Tier.Label) || sig.CooldownNote != "" || sig.ConfidenceNote != "" || sig.BoostNote != "" ||
+		sig.Sell != nil ||
+		res.ParticipationNudge || res.FundLocked ||
+		len(res.Indicators.Degraded) > 0 || res.Indicators.DataQualityWarning != "" ||
+		res.Indicators.SourceWarning != "" ||
+		res.Stages.TimedOut() != ""
+}
+
+// FormatWeeklyCompact formats a weekly evaluation as one paragraph, e.g.
+// "评分 +0.120 → 正常定投 ¥1,600；RSI 54.2/51.0；距MA200 +3.1%". RSI is weekly/daily.
+func FormatWeeklyCompact(res *pipeline.WeeklyResult) string {
+	ind, signal := res.Indicators, res.Signal
+	var b strings.Builder
+
+	b.WriteString(weeklyTitle(res))
+	if signal.Regime != model.RegimeUnknown {
+		b.WriteString(fmt.Sprintf("当前市场状态: %s\n", signal.Regime.Label()))
+	}
+	switch {
+	case res.Paused:
+		b.WriteString(PauseBanner(res.StateBefore) + "\n")
+	case res.AlreadyInvested:
+		b.WriteString("ℹ️ 本周已执行，仅展示：未改动资金池，未记录历史\n")
+	case res.DryRun:
+		b.WriteString("🔍 预览模式：未改动资金池，未记录历史\n")
+	}
+	ma200Dev := 0.0
+	if ind.MA200 > 0 {
+		ma200Dev = (ind.CurrentPrice - ind.MA200) / ind.MA200 * 100
+	}
+	if len(res.Others) > 0 {
+		b.WriteString(res.Symbol + ": ")
+	}
+	score := model.FormatScore(signal.TotalScore)
+	if signal.Smoothed {
+		score += "（平滑 " + model.FormatScore(signal.SmoothedScore) + "）"
+	}
+	amount := model.FormatAmount(signal.FinalAmount) + formatFX(signal.FinalAmount, ind.FXRate)
+	if signal.RoundingNote != "" {
+		amount += "（取整前 " + model.FormatAmount(signal.RawAmount) + "）"
+	}
+	b.WriteString(fmt.Sprintf("评分 %s → %s %s；RSI %s/%s；距MA200 %+.1f%%\n",
+		score, signal.Tier.Label, amount,
+		model.FormatRSI(ind.WeeklyRSI), model.FormatRSI(ind.DailyRSI), ma200Dev))
+	if len(res.Others) > 0 {
+		b.WriteString(formatOthers(res.Others))
+	}
+	return b.String()
+}
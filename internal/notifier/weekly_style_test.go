@@ -0,0 +1,298 @@
+package notifier
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"MarketSentinel/internal/model"
+	"MarketSentinel/internal/pipeline"
+)
+
+func TestSelectWeeklyStyle(t *testing.T) {
+	neutral := StylePolicy{NeutralBand: 0.2}
+	tests := []struct {
+		name   string
+		policy StylePolicy
+		score  float64
+		modify func(res *pipeline.WeeklyResult)
+		want   WeeklyStyle
+	}{
+		{"quiet week", neutral, 0.12, nil, WeeklyCompact},
+		{"negative within band", neutral, -0.2, nil, WeeklyCompact},
+		{"above band", neutral, 0.21, nil, WeeklyDetailed},
+		{"below band", neutral, -0.5, nil, WeeklyDetailed},
+		{"compact disabled", StylePolicy{}, 0, nil, WeeklyDetailed},
+		{"warning", neutral, 0.1, func(r *pipeline.WeeklyResult) {
+			r.Signal.Warnings = []model.Warning{{Kind: model.WarningDeathCross, Text: "⚠️ 死叉"}}
+		}, WeeklyDetailed},
+		{"bear boost", neutral, 0.1, func(r *pipeline.WeeklyResult) { r.Signal.BoostNote = "熊市加码" }, WeeklyDetailed},
+		{"capped", neutral, 0.1, func(r *pipeline.WeeklyResult) { r.Signal.CapNote = "触及每周投入上限" }, WeeklyDetailed},
+		{"reserve guarded", neutral, 0.1, func(r *pipeline.WeeklyResult) { r.Signal.ReserveNote = "触及储备池保底 1.0N" }, WeeklyDetailed},
+		{"reserve used", neutral, 0.1, func(r *pipeline.WeeklyResult) { r.Signal.ReserveUsed = 300 }, WeeklyDetailed},
+		{"participation nudge", neutral, 0.1, func(r *pipeline.WeeklyResult) { r.ParticipationNudge = true }, WeeklyDetailed},
+		{"fund locked", neutral, 0.1, func(r *pipeline.WeeklyResult) { r.FundLocked = true }, WeeklyDetailed},
+		{"degraded indicator", neutral, 0.1, func(r *pipeline.WeeklyResult) {
+			r.Indicators.MarkDegraded(model.IndicatorDailyRSI, "stale")
+		}, WeeklyDetailed},
+		{"data quality", neutral, 0.1, func(r *pipeline.WeeklyResult) {
+			r.Indicators.DataQualityWarning = "last daily bar 2024-03-01 is 14 days old"
+		}, WeeklyDetailed},
+		{"stage timed out", neutral, 0.1, func(r *pipeline.WeeklyResult) {
+			r.Stages = pipeline.StageReport{{Stage: pipeline.StageQuote, TimedOut: true, Cached: true}}
+		}, WeeklyDetailed},
+		{"smoothing kept the tier", neutral, 0.1, func(r *pipeline.WeeklyResult) {
+			r.Signal.Smoothed, r.Signal.RawTierLabel = true, r.Signal.Tier.Label
+		}, WeeklyCompact},
+		{"smoothing changed the tier", neutral, 0.1, func(r *pipeline.WeeklyResult) {
+			r.Signal.Smoothed, r.Signal.RawTierLabel = true, "正常定投"
+		}, WeeklyDetailed},
+		{"sell recommendation", neutral, -0.1, func(r *pipeline.WeeklyResult) {
+			r.Signal.Sell = &model.SellRecommendation{Percent: 10}
+		}, WeeklyDetailed},
+		{"low confidence demotion", neutral, 0.1, func(r *pipeline.WeeklyResult) {
+			r.Signal.ConfidenceNote = "信心度 30% 低于 40%"
+		}, WeeklyDetailed},
+		{"cooldown", neutral, 0.1, func(r *pipeline.WeeklyResult) { r.Signal.CooldownNote = "冷却期" }, WeeklyDetailed},
+		{"dry run stays compact", neutral, 0.1, func(r *pipeline.WeeklyResult) { r.DryRun = true }, WeeklyCompact},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := &pipeline.WeeklyResult{
+				Indicators: &model.MarketIndicators{},
+				Signal:     &model.TradeSignal{TotalScore: tt.score},
+			}
+			if tt.modify != nil {
+				tt.modify(res)
+			}
+			if got := SelectWeeklyStyle(res, tt.policy); got != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatWeeklyCompact(t *testing.T) {
+	res := &pipeline.WeeklyResult{
+		Indicators: &model.MarketIndicators{CurrentPrice: 5155, MA200: 5000, WeeklyRSI: 54, DailyRSI: 51},
+		Signal: &model.TradeSignal{
+			TotalScore:  0.12,
+			Tier:        model.InvestmentTier{Label: "正常定投", Multiplier: 1},
+			FinalAmount: 1600,
+		},
+	}
+	got := FormatWeeklyCompact(res)
+	if want := "评分 +0.120 → 正常定投 ¥1,600；RSI 54.0/51.0；距MA200 +3.1%\n"; !strings.HasSuffix(got, want) {
+		t.Errorf("got %q, want suffix %q", got, want)
+	}
+	if strings.Contains(got, "因子评分明细") || strings.Contains(got, "资金池状态") {
+		t.Errorf("compact report includes detailed sections: %q", got)
+	}
+}
+
+func TestFormatWeekly_Rounding(t *testing.T) {
+	res := &pipeline.WeeklyResult{
+		Indicators: &model.MarketIndicators{CurrentPrice: 5155, MA200: 5000, WeeklyRSI: 54, DailyRSI: 51},
+		Signal: &model.TradeSignal{
+			TotalScore:   0.12,
+			Tier:         model.InvestmentTier{Label: "正常定投", Multiplier: 1},
+			FinalAmount:  1100,
+			RawAmount:    1154,
+			RoundingNote: "按 ¥100 取整，投入由 ¥1,154 调整为 ¥1,100",
+		},
+	}
+	if got := FormatWeeklyCompact(res); !strings.Contains(got, "正常定投 ¥1,100（取整前 ¥1,154）；") {
+		t.Errorf("compact report lacks the raw amount: %q", got)
+	}
+	if got := FormatWeeklySignal(res); !strings.Contains(got, "🔢 按 ¥100 取整，投入由 ¥1,154 调整为 ¥1,100") {
+		t.Errorf("detailed report lacks the rounding: %q", got)
+	}
+	if SelectWeeklyStyle(res, StylePolicy{NeutralBand: 0.2}) != WeeklyCompact {
+		t.Error("a rounded amount needs no detailed report")
+	}
+	res.Signal.FinalAmount = 0
+	if SelectWeeklyStyle(res, StylePolicy{NeutralBand: 0.2}) != WeeklyDetailed {
+		t.Error("an investment skipped by rounding needs the detailed report")
+	}
+}
+
+func TestFormatWeeklyCompact_OtherSymbols(t *testing.T) {
+	res := &pipeline.WeeklyResult{
+		Symbol:     "SPX500",
+		Indicators: &model.MarketIndicators{CurrentPrice: 5155, MA200: 5000, WeeklyRSI: 54, DailyRSI: 51},
+		Signal: &model.TradeSignal{
+			TotalScore:  0.12,
+			Tier:        model.InvestmentTier{Label: "正常定投", Multiplier: 1},
+			FinalAmount: 1600,
+		},
+		Others: []pipeline.SymbolResult{
+			{
+				Symbol:     "NDX",
+				Indicators: &model.MarketIndicators{CurrentPrice: 18540, MA200: 18000, WeeklyRSI: 61, DailyRSI: 58.25},
+				Signal:     &model.TradeSignal{TotalScore: -0.3, Tier: model.InvestmentTier{Label: "减少定投", Multiplier: 0.5}},
+			},
+			{Symbol: "BAD", Err: errors.New("no data")},
+		},
+	}
+	got := FormatWeeklyCompact(res)
+	want := "SPX500: 评分 +0.120 → 正常定投 ¥1,600；RSI 54.0/51.0；距MA200 +3.1%\n" +
+		"  NDX: 评分 -0.300 → 减少定投 0.50x；RSI 61.0/58.3；距MA200 +3.0%\n" +
+		"  BAD: ❌ 采集失败: no data\n"
+	if !strings.HasSuffix(got, want) {
+		t.Errorf("got %q, want suffix %q", got, want)
+	}
+}
+
+func TestFormatWeekly_FXRate(t *testing.T) {
+	res := &pipeline.WeeklyResult{
+		Indicators: &model.MarketIndicators{CurrentPrice: 5155, MA200: 5000, WeeklyRSI: 54, DailyRSI: 51, FXRate: 7.25},
+		Signal: &model.TradeSignal{
+			TotalScore:  0.12,
+			Tier:        model.InvestmentTier{Label: "正常定投", Multiplier: 1},
+			FinalAmount: 1600,
+			BaseAmount:  1600,
+		},
+	}
+	if got, want := FormatWeeklyCompact(res), "正常定投 ¥1,600 (≈$221)；"; !strings.Contains(got, want) {
+		t.Errorf("compact report %q lacks %q", got, want)
+	}
+	if got, want := FormatWeeklySignal(res), "投入金额: ¥1,600 (≈$221) (基准¥1,600)"; !strings.Contains(got, want) {
+		t.Errorf("detailed report lacks %q:\n%s", want, got)
+	}
+
+	// Without a rate the report stays in yuan.
+	res.Indicators.FXRate = 0
+	if got := FormatWeeklySignal(res); strings.Contains(got, "$") {
+		t.Errorf("report without a rate shows dollars:\n%s", got)
+	}
+}
+
+func TestFormatWeeklySignal_SkippedFactors(t *testing.T) {
+	ind := &model.MarketIndicators{CurrentPrice: 5155, MA200: 5155, WeeklyRSI: 54, DailyRSI: 51}
+	ind.MarkMissing(model.IndicatorMA200, "not enough data for MA200")
+	res := &pipeline.WeeklyResult{
+		Indicators: ind,
+		Signal: &model.TradeSignal{
+			Factors: []model.FactorScore{
+				{ID: model.FactorMA200, Name: "MA200偏离度", Commentary: "不可用", Missing: "ma200: not enough data for MA200"},
+				{ID: model.FactorDailyRSI, Name: "日线RSI", Weight: 1, Commentary: "RSI=51.0"},
+			},
+			Tier: model.InvestmentTier{Label: "正常定投", Multiplier: 1},
+		},
+	}
+	got := FormatWeeklySignal(res)
+	for _, want := range []string{
+		"MA200: 不可用 (ma200: not enough data for MA200)",
+		"MA200偏离度: 跳过 (ma200: not enough data for MA200)",
+		"1个因子因数据不足跳过",
+		"日线RSI(RSI=51.0)",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("report lacks %q:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "偏离 +0.0%") {
+		t.Errorf("report shows the placeholder MA200 as a deviation:\n%s", got)
+	}
+}
+
+func TestFormatWeekly_Smoothed(t *testing.T) {
+	res := &pipeline.WeeklyResult{
+		Indicators: &model.MarketIndicators{CurrentPrice: 5155, MA200: 5000, WeeklyRSI: 54, DailyRSI: 51},
+		Signal: &model.TradeSignal{
+			TotalScore: 0.78, Smoothed: true, SmoothedScore: 0.815, RawTierLabel: "正常定投",
+			Tier:        model.InvestmentTier{Label: "加仓买入", Multiplier: 1, UseReserve: 0.5},
+			FinalAmount: 2400,
+		},
+	}
+	if got := FormatWeeklySignal(res); !strings.Contains(got, "综合评分: +0.780\n  平滑评分: +0.815 (未平滑为 正常定投)") {
+		t.Errorf("detailed report lacks the smoothed score:\n%s", got)
+	}
+	if got := FormatWeeklyCompact(res); !strings.Contains(got, "评分 +0.780（平滑 +0.815） → 加仓买入") {
+		t.Errorf("compact report lacks the smoothed score: %q", got)
+	}
+}
+
+func TestFormatWeekly_Regime(t *testing.T) {
+	res := &pipeline.WeeklyResult{
+		Indicators: &model.MarketIndicators{CurrentPrice: 5500, MA200: 5000},
+		Signal:     &model.TradeSignal{Regime: model.RegimeBull, Tier: model.InvestmentTier{Label: "正常定投"}},
+	}
+	for _, got := range []string{FormatWeeklySignal(res), FormatWeeklyCompact(res)} {
+		if lines := strings.SplitN(got, "\n", 3); len(lines) < 2 || lines[1] != "当前市场状态: 牛市" {
+			t.Errorf("report does not open with the regime:\n%s", got)
+		}
+	}
+
+	res.Signal.Regime = model.RegimeUnknown
+	if got := FormatWeeklySignal(res); strings.Contains(got, "当前市场状态") {
+		t.Errorf("unknown regime shown:\n%s", got)
+	}
+}
+
+func TestFormatWeeklySignal_Confidence(t *testing.T) {
+	res := &pipeline.WeeklyResult{
+		Indicators: &model.MarketIndicators{CurrentPrice: 5500, MA200: 5000},
+		Signal: &model.TradeSignal{
+			TotalScore: 1.3, Confidence: 0.32,
+			Tier:           model.InvestmentTier{Label: "加仓买入", Multiplier: 1, UseReserve: 0.5},
+			ConfidenceNote: "信心度 32% 低于 40%，因子分歧，由 重仓买入 降为 加仓买入",
+		},
+	}
+	got := FormatWeeklySignal(res)
+	for _, want := range []string{"  信心度: 32%\n", "⚖️ 信心度 32% 低于 40%"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("report lacks %q:\n%s", want, got)
+		}
+	}
+}
+
+func TestFormatWeeklySignal_SellRecommendation(t *testing.T) {
+	res := &pipeline.WeeklyResult{
+		Indicators: &model.MarketIndicators{CurrentPrice: 6600, MA200: 5000},
+		Signal: &model.TradeSignal{
+			TotalScore: -1.4,
+			Tier:       model.InvestmentTier{Label: "轻仓观望", Multiplier: 0.25},
+			Sell:       &model.SellRecommendation{Percent: 12.5, Reason: "评分 -1.400 ≤ -1.200 且 RSI 88.0 > 85"},
+		},
+	}
+	if got := FormatWeeklySignal(res); !strings.Contains(got, "📉 建议减仓: 持仓的 12% (评分 -1.400 ≤ -1.200 且 RSI 88.0 > 85)") {
+		t.Errorf("report lacks the recommendation:\n%s", got)
+	}
+
+	res.Signal.Sell.Amount = 3000
+	if got := FormatSellRecommendation(res.Signal.Sell); !strings.Contains(got, "持仓的 12% (约 ¥3,000)") {
+		t.Errorf("recommendation lacks the amount: %q", got)
+	}
+}
+
+func TestFormatWeeklySignal_Cooldown(t *testing.T) {
+	note := "冷却期：近4周已发出 极限重仓，评分未超出门槛 0.100 分，降为 重仓买入"
+	res := &pipeline.WeeklyResult{
+		Indicators: &model.MarketIndicators{CurrentPrice: 5155, MA200: 5000},
+		Signal: &model.TradeSignal{
+			TotalScore:   1.52,
+			Tier:         model.InvestmentTier{Label: "重仓买入", Multiplier: 1, UseReserve: 1},
+			CooldownNote: note,
+		},
+	}
+	if got := FormatWeeklySignal(res); !strings.Contains(got, "本周操作:</b> 重仓买入") || !strings.Contains(got, "⏳ "+note) {
+		t.Errorf("report lacks the cooldown note:\n%s", got)
+	}
+}
+
+func TestFormatWeekly_WarningLines(t *testing.T) {
+	res := &pipeline.WeeklyResult{
+		Indicators: &model.MarketIndicators{CurrentPrice: 4500, MA200: 5000},
+		Signal: &model.TradeSignal{
+			Tier: model.InvestmentTier{Label: "正常定投", Multiplier: 1},
+			Warnings: []model.Warning{
+				{Kind: model.WarningMA200Break, Text: "⚠️ 跌破 MA200：站上 45 个交易日后收于年线下方"},
+				{Kind: model.WarningDeathCross, Text: "⚠️ 死叉：MA20w 下穿 MA50w"},
+			},
+		},
+	}
+	if got := FormatWeeklySignal(res); !strings.Contains(got, "\n⚠️ 跌破 MA200：站上 45 个交易日后收于年线下方\n⚠️ 死叉：MA20w 下穿 MA50w\n") {
+		t.Errorf("warnings not on separate lines:\n%s", got)
+	}
+}
@@ -0,0 +1,159 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"MarketSentinel/internal/collector"
+	"MarketSentinel/internal/model"
+	"MarketSentinel/internal/reporting"
+)
+
+// Stage names one step of the weekly run.
+type Stage string
+
+const (
+	StageDaily  = Stage(collector.InputDaily)
+	StageWeekly = Stage(collector.InputWeekly)
+	StageQuote  = Stage(collector.InputPrice)
+	StageAux    = Stage(collector.InputAux)
+	StageYield  = Stage(collector.InputYield)
+	StageFX     = Stage(collector.InputFX)
+	StageVerify = Stage("verify")
+	StageRender = Stage("render")
+	StageSend   = Stage("send")
+)
+
+// DefaultWeeklyBudget bounds the weekly run when no budget is configured.
+const DefaultWeeklyBudget = 120 * time.Second
+
+// StageTiming records how one stage of a weekly run went.
+type StageTiming struct {
+	Stage    Stage
+	Duration time.Duration
+	TimedOut bool // the budget ran out before the stage finished
+	Cached   bool // the stage's data came from the collector cache instead
+}
+
+// StageReport is the ordered timing of every stage that ran.
+type StageReport []StageTiming
+
+// TimedOut returns the first stage that ran out of budget, or "".
+func (r StageReport) TimedOut() Stage {
+	for _, st := range r {
+		if st.TimedOut {
+			return st.Stage
+		}
+	}
+	return ""
+}
+
+// String renders the report on one line for logs, e.g.
+// "daily fetch=1.2s weekly fetch=timeout(cached) quote=timeout(cached)".
+func (r StageReport) String() string {
+	parts := make([]string, len(r))
+	for i, st := range r {
+		switch {
+		case st.TimedOut && st.Cached:
+			parts[i] = fmt.Sprintf("%s=timeout(cached)", st.Stage)
+		case st.TimedOut:
+			parts[i] = fmt.Sprintf("%s=timeout", st.Stage)
+		default:
+			parts[i] = fmt.Sprintf("%s=%.1fs", st.Stage, st.Duration.Seconds())
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// errStageTimeout marks a stage abandoned because the budget ran out.
+var errStageTimeout = errors.New("stage timed out")
+
+// runStage runs fn and waits for it until ctx is done. A timed-out fn keeps
+// running in the background; its result is discarded.
+func runStage[T any](ctx context.Context, report *StageReport, stage Stage, fn func() (T, error)) (T, error) {
+	type result struct {
+		v   T
+		err error
+	}
+	start := time.Now()
+	done := make(chan result, 1)
+	go func() {
+		v, err := fn()
+		done <- result{v, err}
+	}()
+
+	select {
+	case res := <-done:
+		*report = append(*report, StageTiming{Stage: stage, Duration: time.Since(start)})
+		return res.v, res.err
+	case <-ctx.Done():
+		*report = append(*report, StageTiming{Stage: stage, Duration: time.Since(start), TimedOut: true})
+		var zero T
+		return zero, errStageTimeout
+	}
+}
+
+// collectStaged fetches the collector's inputs under ctx's deadline, each
+// reported as its own stage (see collector.FetchInputs). The indicators fed
+// by a stage that fell back to cached data are marked degraded. Fetch errors
+// fail the run as in Collect. With a Verifier the result is cross-checked in
+// a last stage, skipped when the budget runs out. The series returned holds
+// the inputs actually used, cached ones included.
+func (p *Pipeline) collectStaged(ctx context.Context, report *StageReport) (*model.MarketIndicators, *model.PriceSeries, error) {
+	start := time.Now()
+	defer p.Metrics.Since(reporting.MetricCollectDuration, nil, start)
+
+	in, fetches, err := p.Collector.FetchInputs(ctx)
+	var fallbacks []Stage
+	for _, f := range fetches {
+		stage := Stage(f.Input)
+		*report = append(*report, StageTiming{Stage: stage, Duration: f.Duration, TimedOut: f.TimedOut, Cached: f.Cached})
+		if f.Cached {
+			fallbacks = append(fallbacks, stage)
+		}
+	}
+	if err == nil {
+		err = p.Collector.Validate(in)
+	}
+	if err != nil {
+		p.Metrics.Inc(reporting.MetricCollectFailures, nil)
+		return nil, nil, err
+	}
+
+	ind := p.Collector.Compute(in)
+
+	// Verification is advisory like the auxiliary symbol: a timeout skips it.
+	if p.Collector.Verifier != nil {
+		warning, err := runStage(ctx, report, StageVerify, func() (string, error) {
+			return p.Collector.Verify(in), nil
+		})
+		if err != nil {
+			log.Printf("[WARN] source verification %v, skipped", err)
+		} else if warning != "" {
+			ind.SourceWarning = warning
+			log.Printf("[WARN] source divergence: %s", warning)
+		}
+	}
+	for _, stage := range fallbacks {
+		reason := fmt.Sprintf("%s timed out, using cached data", stage)
+		for _, key := range stageIndicators[stage] {
+			ind.MarkDegraded(key, reason)
+		}
+	}
+	if len(fallbacks) > 0 {
+		log.Printf("[WARN] weekly collection over budget, cached data used for: %v", fallbacks)
+	}
+	return ind, in.Series(p.Collector.Symbol), nil
+}
+
+// stageIndicators lists the indicators computed from each fetch stage.
+var stageIndicators = map[Stage][]string{
+	StageDaily: {model.IndicatorMA200, model.IndicatorDailyRSI, model.IndicatorRange52w,
+		model.IndicatorRange30d, model.IndicatorPosition52w},
+	StageWeekly: {model.IndicatorMA20w, model.IndicatorMA50w, model.IndicatorWeeklyRSI},
+	StageQuote:  {model.IndicatorPrice, model.IndicatorPosition52w},
+}
@@ -0,0 +1,395 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"time"
+
+	"MarketSentinel/internal/collector"
+	"MarketSentinel/internal/fund"
+	"MarketSentinel/internal/metrics"
+	"MarketSentinel/internal/model"
+	"MarketSentinel/internal/recorder"
+	"MarketSentinel/internal/reporting"
+	"MarketSentinel/internal/strategy"
+)
+
+// Pipeline runs the evaluation use cases shared by the scheduler, bot commands
+// and any other front end, without formatting or sending anything.
+type Pipeline struct {
+	Collector *collector.Collector
+	Fund      *fund.Manager
+	Recorder  recorder.Recorder
+	Metrics   *metrics.Registry // optional
+	// Budget bounds data collection in RunWeeklyEvaluation; zero means
+	// DefaultWeeklyBudget.
+	Budget time.Duration
+	// Symbols are evaluated alongside Collector.Symbol in each weekly run and
+	// reported for reference; only Collector.Symbol moves the fund, unless
+	// its budget is allocated to others too (see fund.Manager.For).
+	Symbols []string
+	// ArchiveBars keeps the raw bars of this many most recent weekly
+	// evaluations in the recorder; zero disables archiving.
+	ArchiveBars int
+}
+
+// New creates a Pipeline.
+func New(col *collector.Collector, fm *fund.Manager, rec recorder.Recorder) *Pipeline {
+	return &Pipeline{Collector: col, Fund: fm, Recorder: rec}
+}
+
+// WeeklyOptions controls a weekly evaluation run.
+type WeeklyOptions struct {
+	// DryRun computes the allocation without debiting the fund or recording history.
+	DryRun bool
+	// Force debits the fund even when this ISO week's investment was already
+	// made; otherwise such a run falls back to a dry run. It does not
+	// override a pause.
+	Force bool
+	// At is the logical evaluation time recorded with the snapshot and fund
+	// event, such as the scheduled slot; zero means now.
+	At time.Time
+}
+
+// WeeklyResult is everything produced by one weekly evaluation.
+type WeeklyResult struct {
+	Indicators  *model.MarketIndicators
+	Signal      *model.TradeSignal // FinalAmount/ReserveUsed/BaseAmount filled in
+	StateBefore model.FundState
+	StateAfter  model.FundState
+	DryRun      bool
+	// FundLocked is set when the fund refused mutations after an invariant
+	// violation; the run then falls back to a dry run.
+	FundLocked bool
+	// AlreadyInvested is set when this week's investment was already made
+	// and the run fell back to a dry run instead of debiting the fund again.
+	AlreadyInvested bool
+	// Paused is set when investing is paused; the run then falls back to a
+	// dry run.
+	Paused bool
+	// ParticipationNudge is set when StateAfter has been below 1.0× for the
+	// configured number of weeks.
+	ParticipationNudge bool
+	// Stages times each collection stage; front ends append render and send.
+	Stages StageReport
+	// Symbol is the symbol the fund was allocated for.
+	Symbol string
+	// Others holds the evaluation of each of Pipeline.Symbols, in order.
+	Others []SymbolResult
+	// Series holds the raw bars and quote Indicators were computed from.
+	Series *model.PriceSeries
+}
+
+// SymbolResult is the evaluation of an additional symbol. Signal carries the
+// score and tier only, for reference, unless Allocated.
+type SymbolResult struct {
+	Symbol     string
+	Indicators *model.MarketIndicators // nil when Err is set
+	Signal     *model.TradeSignal      // nil when Err is set
+	Err        error
+	// Allocated is set when the fund gives Symbol a share of the budget:
+	// Signal then has its amounts filled in as for the primary, invested
+	// from the symbol's own pools or previewed with the run, and
+	// StateBefore/StateAfter are its sub-state.
+	Allocated   bool
+	StateBefore model.FundState
+	StateAfter  model.FundState
+}
+
+// Collect runs the collector and records its latency and failures.
+func (p *Pipeline) Collect() (*model.MarketIndicators, error) {
+	start := time.Now()
+	ind, err := p.Collector.Collect()
+	p.Metrics.Since(reporting.MetricCollectDuration, nil, start)
+	if err != nil {
+		p.Metrics.Inc(reporting.MetricCollectFailures, nil)
+	}
+	return ind, err
+}
+
+// CollectDaily is Collect with the daily RSI updated intraday, see
+// collector.Collector.CollectDaily. An error wrapping
+// collector.ErrNotSupported is returned as is and not counted as a failure.
+func (p *Pipeline) CollectDaily() (*model.MarketIndicators, error) {
+	start := time.Now()
+	ind, err := p.Collector.CollectDaily()
+	if errors.Is(err, collector.ErrNotSupported) {
+		return nil, err
+	}
+	p.Metrics.Since(reporting.MetricCollectDuration, nil, start)
+	if err != nil {
+		p.Metrics.Inc(reporting.MetricCollectFailures, nil)
+	}
+	return ind, err
+}
+
+// RunWeeklyEvaluation collects indicators, evaluates the strategy, allocates the
+// weekly investment and records the snapshot and fund event. Collection runs
+// under p.Budget; a fetch still running when it expires is replaced by cached
+// data (see collectStaged). Collection errors are returned unwrapped; recording
+// errors are logged and do not fail the run.
+func (p *Pipeline) RunWeeklyEvaluation(ctx context.Context, opts WeeklyOptions) (*WeeklyResult, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	budget := p.Budget
+	if budget <= 0 {
+		budget = DefaultWeeklyBudget
+	}
+	collectCtx, cancel := context.WithTimeout(ctx, budget)
+	defer cancel()
+
+	var stages StageReport
+	ind, series, err := p.collectStaged(collectCtx, &stages)
+	if err != nil {
+		return nil, err
+	}
+
+	signal := strategy.Evaluate(ind)
+	signal.TriggerType = model.TriggerWeekly
+
+	res := &WeeklyResult{Indicators: ind, Signal: signal, DryRun: opts.DryRun, Stages: stages, Symbol: p.Collector.Symbol, Series: series}
+	res.Others = p.evaluateOthers(ctx)
+	res.StateBefore = p.Fund.GetState()
+	p.adjust(res.Symbol, signal, &res.StateBefore)
+	kinds := p.dedupWarnings(signal)
+	if err := p.Fund.Locked(); err != nil && !opts.DryRun {
+		log.Printf("[WARN] weekly evaluation running as dry run: %v", err)
+		res.DryRun, res.FundLocked = true, true
+	}
+	if !res.DryRun && p.Fund.IsPaused() {
+		log.Printf("[INFO] investing paused, weekly evaluation running as dry run")
+		res.DryRun, res.Paused = true, true
+	}
+	if !res.DryRun && !opts.Force && p.Fund.InvestedThisWeek() {
+		log.Printf("[INFO] weekly investment already made this week, running as dry run")
+		res.DryRun, res.AlreadyInvested = true, true
+	}
+
+	if res.DryRun {
+		signal.FinalAmount, signal.ReserveUsed, res.StateAfter = p.Fund.PreviewWeeklyInvestment(signal)
+		p.allocateOthers(res)
+		for _, o := range res.Others {
+			if o.Allocated {
+				res.StateAfter.Symbols[o.Symbol] = &o.StateAfter
+			}
+		}
+		res.ParticipationNudge = p.Fund.ParticipationNudgeDue(&res.StateAfter)
+		return res, nil
+	}
+
+	signal.FinalAmount, signal.ReserveUsed = p.Fund.CalculateWeeklyInvestment(signal)
+	p.allocateOthers(res)
+	res.StateAfter = p.Fund.GetState()
+	if err := p.Recorder.SyncWarnings(res.Symbol, kinds, opts.At); err != nil {
+		log.Printf("[ERROR] record warnings: %v", err)
+	}
+	res.ParticipationNudge = p.Fund.ParticipationNudgeDue(&res.StateAfter)
+
+	snap := &recorder.WeeklySnapshot{
+		Symbol:     res.Symbol,
+		Indicators: ind,
+		Signal:     signal,
+		FundState:  &res.StateAfter,
+		OccurredAt: opts.At,
+	}
+	if err := p.Recorder.RecordWeekly(snap); err != nil {
+		log.Printf("[ERROR] record weekly: %v", err)
+	} else if p.ArchiveBars > 0 && snap.ID != 0 {
+		if err := p.Recorder.RecordPriceSeries(snap.ID, series, p.ArchiveBars); err != nil {
+			log.Printf("[ERROR] archive weekly bars: %v", err)
+		}
+	}
+	for _, o := range res.Others {
+		if o.Err != nil {
+			continue
+		}
+		state := &res.StateAfter
+		if o.Allocated {
+			state = &o.StateAfter
+		}
+		if err := p.Recorder.RecordWeekly(&recorder.WeeklySnapshot{
+			Symbol:     o.Symbol,
+			Indicators: o.Indicators,
+			Signal:     o.Signal,
+			FundState:  state,
+			OccurredAt: opts.At,
+		}); err != nil {
+			log.Printf("[ERROR] record weekly %s: %v", o.Symbol, err)
+		}
+	}
+	p.recordWeeklyEvent(signal, &res.StateBefore, &res.StateAfter, opts.At)
+	for _, o := range res.Others {
+		if o.Allocated {
+			p.recordWeeklyEvent(o.Signal, &o.StateBefore, &o.StateAfter, opts.At)
+		}
+	}
+	return res, nil
+}
+
+// adjust applies the score smoothing, cooldown, confidence and bear boost to
+// the signal of symbol against its fund state before, and sets its base
+// amount.
+func (p *Pipeline) adjust(symbol string, signal *model.TradeSignal, before *model.FundState) {
+	strategy.Smooth(signal, before.RecentScores)
+	p.applyCooldown(symbol, signal)
+	strategy.ApplyConfidence(signal)
+	strategy.ApplyBearBoost(signal, before.ConsecutiveHighScoreWeeks)
+	signal.BaseAmount = before.WeeklyBaseN
+}
+
+// allocateOthers allocates the weekly investment of each of res.Others the
+// fund gives a share of the budget, from that symbol's own pools, after the
+// primary's: previewed when res is a dry run, invested otherwise.
+func (p *Pipeline) allocateOthers(res *WeeklyResult) {
+	for i := range res.Others {
+		o := &res.Others[i]
+		fm := p.Fund.For(o.Symbol)
+		if o.Err != nil || fm == nil {
+			continue
+		}
+		o.Allocated = true
+		o.StateBefore = fm.GetState()
+		p.adjust(o.Symbol, o.Signal, &o.StateBefore)
+		if res.DryRun {
+			o.Signal.FinalAmount, o.Signal.ReserveUsed, o.StateAfter = fm.PreviewWeeklyInvestment(o.Signal)
+			continue
+		}
+		o.Signal.FinalAmount, o.Signal.ReserveUsed = fm.CalculateWeeklyInvestment(o.Signal)
+		o.StateAfter = fm.GetState()
+	}
+}
+
+// recordWeeklyEvent records the WEEKLY fund event of signal, which took the
+// pools of a symbol from before to after.
+func (p *Pipeline) recordWeeklyEvent(signal *model.TradeSignal, before, after *model.FundState, at time.Time) {
+	note := "周定投"
+	if signal.ReserveNote != "" {
+		note += "；" + signal.ReserveNote
+	}
+	if err := p.Recorder.RecordFundEvent(&recorder.FundEvent{
+		EventType:     "WEEKLY",
+		RegularBefore: before.RegularBalance,
+		RegularAfter:  after.RegularBalance,
+		ReserveBefore: before.ReserveBalance,
+		ReserveAfter:  after.ReserveBalance,
+		Amount:        signal.FinalAmount + signal.ReserveUsed,
+		Note:          note,
+		Symbol:        after.Symbol,
+		OccurredAt:    at,
+	}); err != nil {
+		log.Printf("[ERROR] record fund event: %v", err)
+	}
+}
+
+// applyCooldown applies the extreme-tier cooldown to signal against the tiers
+// recorded for symbol. When they cannot be read the cooldown is skipped.
+func (p *Pipeline) applyCooldown(symbol string, signal *model.TradeSignal) {
+	weeks := strategy.CooldownWeeks()
+	if weeks == 0 {
+		return
+	}
+	tiers, err := p.Recorder.RecentWeeklyTiers(symbol, weeks)
+	if err != nil {
+		log.Printf("[WARN] read recent tiers: %v, skipping the cooldown", err)
+		return
+	}
+	strategy.ApplyCooldown(signal, tiers)
+}
+
+// dedupWarnings drops from signal the warnings already reported for
+// p.Collector.Symbol and still active, and returns the kinds of all of them
+// for the recorder. When the active warnings cannot be read all are kept.
+func (p *Pipeline) dedupWarnings(signal *model.TradeSignal) []model.WarningKind {
+	kinds := make([]model.WarningKind, 0, len(signal.Warnings))
+	for _, w := range signal.Warnings {
+		kinds = append(kinds, w.Kind)
+	}
+	active, err := p.Recorder.ActiveWarnings(p.Collector.Symbol)
+	if err != nil {
+		log.Printf("[WARN] read active warnings: %v, reporting all", err)
+		return kinds
+	}
+	signal.Warnings = slices.DeleteFunc(signal.Warnings, func(w model.Warning) bool {
+		return slices.Contains(active, w.Kind)
+	})
+	return kinds
+}
+
+// evaluateOthers collects and evaluates each of p.Symbols. A failing symbol
+// is reported in its result and does not fail the run.
+func (p *Pipeline) evaluateOthers(ctx context.Context) []SymbolResult {
+	var others []SymbolResult
+	for _, sym := range p.Symbols {
+		if sym == p.Collector.Symbol {
+			continue
+		}
+		r := SymbolResult{Symbol: sym}
+		if r.Err = ctx.Err(); r.Err == nil {
+			r.Indicators, r.Err = p.Collector.For(sym).Collect()
+		}
+		if r.Err != nil {
+			log.Printf("[WARN] weekly evaluation of %s failed: %v", sym, r.Err)
+		} else {
+			r.Signal = strategy.Evaluate(r.Indicators)
+			r.Signal.TriggerType = model.TriggerWeekly
+		}
+		others = append(others, r)
+	}
+	return others
+}
+
+// MaxWhatIfRatio bounds the hypothetical price of WhatIf to this multiple of
+// the current price.
+const MaxWhatIfRatio = 10
+
+// PriceRangeError is returned by WhatIf for a hypothetical price that is not
+// positive or exceeds MaxWhatIfRatio times the current price.
+type PriceRangeError struct {
+	Price   float64
+	Current float64
+}
+
+func (e *PriceRangeError) Error() string {
+	return fmt.Sprintf("hypothetical price %.2f outside (0, %d × %.2f]", e.Price, MaxWhatIfRatio, e.Current)
+}
+
+// WhatIfResult is a weekly evaluation simulated at a hypothetical price.
+type WhatIfResult struct {
+	*WeeklyResult
+	// Current is the last fetched price the hypothetical one replaced.
+	Current float64
+}
+
+// WhatIf evaluates the week as if the current price were price, from the
+// last fetched bars (see collector.Collector.WhatIf), collecting first when
+// nothing has been fetched yet. The allocation is previewed as in a dry run:
+// the fund is not debited and nothing is recorded. A price out of range
+// returns a *PriceRangeError.
+func (p *Pipeline) WhatIf(price float64) (*WhatIfResult, error) {
+	current := p.Collector.Cached().Price
+	if current <= 0 {
+		ind, err := p.Collect()
+		if err != nil {
+			return nil, err
+		}
+		current = ind.CurrentPrice
+	}
+	if price <= 0 || price > current*MaxWhatIfRatio {
+		return nil, &PriceRangeError{Price: price, Current: current}
+	}
+	ind, err := p.Collector.WhatIf(price)
+	if err != nil {
+		return nil, err
+	}
+
+	signal := strategy.Evaluate(ind)
+	res := &WeeklyResult{Indicators: ind, Signal: signal, DryRun: true, Symbol: p.Collector.Symbol}
+	res.StateBefore = p.Fund.GetState()
+	p.adjust(res.Symbol, signal, &res.StateBefore)
+	signal.FinalAmount, signal.ReserveUsed, res.StateAfter = p.Fund.PreviewWeeklyInvestment(signal)
+	return &WhatIfResult{WeeklyResult: res, Current: current}, nil
+}
@@ -0,0 +1,469 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"MarketSentinel/internal/collector"
+	"MarketSentinel/internal/fund"
+	"MarketSentinel/internal/model"
+	"MarketSentinel/internal/recorder"
+	"MarketSentinel/internal/strategy"
+)
+
+func newTestPipeline(t *testing.T, f collector.Fetcher) *Pipeline {
+	t.Helper()
+	fm, err := fund.NewManager(fund.NewFileStore(filepath.Join(t.TempDir(), "fund.json")), 10000, fund.Policy{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return New(collector.NewCollector(f, "SPX500"), fm, recorder.NewNoopRecorder())
+}
+
+func TestRunWeeklyEvaluation_StageTimings(t *testing.T) {
+	p := newTestPipeline(t, &collector.MockFetcher{Price: 5000})
+	res, err := p.RunWeeklyEvaluation(context.Background(), WeeklyOptions{DryRun: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []Stage
+	for _, st := range res.Stages {
+		got = append(got, st.Stage)
+	}
+	if want := []Stage{StageDaily, StageWeekly, StageQuote}; len(got) != 3 || got[0] != want[0] || got[1] != want[1] || got[2] != want[2] {
+		t.Errorf("stages = %v, want %v", got, want)
+	}
+	if s := res.Stages.TimedOut(); s != "" {
+		t.Errorf("unexpected timeout in %s", s)
+	}
+}
+
+func TestRunWeeklyEvaluation_StagesRunConcurrently(t *testing.T) {
+	const delay = 200 * time.Millisecond
+	p := newTestPipeline(t, &collector.MockFetcher{Price: 5000, DailyDelay: delay, WeeklyDelay: delay, PriceDelay: delay})
+	p.Budget = 2 * delay
+	start := time.Now()
+	res, err := p.RunWeeklyEvaluation(context.Background(), WeeklyOptions{DryRun: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed >= 2*delay {
+		t.Errorf("run took %s, want the fetches to overlap", elapsed)
+	}
+	if s := res.Stages.TimedOut(); s != "" {
+		t.Errorf("unexpected timeout in %s", s)
+	}
+}
+
+func TestRunWeeklyEvaluation_BudgetFallsBackToCache(t *testing.T) {
+	p := newTestPipeline(t, &collector.MockFetcher{Price: 5000})
+	if _, err := p.RunWeeklyEvaluation(context.Background(), WeeklyOptions{DryRun: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	// The weekly fetch and the quote now outlive the budget.
+	p.Collector.Fetcher = &collector.MockFetcher{Price: 5100, WeeklyDelay: time.Second, PriceDelay: time.Second}
+	p.Budget = 50 * time.Millisecond
+	start := time.Now()
+	res, err := p.RunWeeklyEvaluation(context.Background(), WeeklyOptions{DryRun: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("run took %s, expected it to stop waiting at the budget", elapsed)
+	}
+	if s := res.Stages.TimedOut(); s != StageWeekly {
+		t.Errorf("first timed-out stage = %q, want %q", s, StageWeekly)
+	}
+	for _, st := range res.Stages {
+		if st.TimedOut && !st.Cached {
+			t.Errorf("%s timed out without using the cache", st.Stage)
+		}
+	}
+	if res.Indicators.CurrentPrice != 5000 {
+		t.Errorf("price = %.0f, want the cached 5000", res.Indicators.CurrentPrice)
+	}
+	for _, key := range []string{model.IndicatorMA20w, model.IndicatorWeeklyRSI, model.IndicatorPrice} {
+		if !strings.Contains(res.Indicators.DegradedReason(key), "timed out") {
+			t.Errorf("%s not marked degraded: %q", key, res.Indicators.DegradedReason(key))
+		}
+	}
+	if r := res.Indicators.DegradedReason(model.IndicatorMA200); r != "" {
+		t.Errorf("MA200 came from a fresh daily fetch but is degraded: %q", r)
+	}
+	if !strings.Contains(res.Stages.String(), "weekly fetch=timeout(cached)") {
+		t.Errorf("report %q does not name the timed-out stage", res.Stages)
+	}
+}
+
+func TestRunWeeklyEvaluation_BudgetWithoutCacheFails(t *testing.T) {
+	p := newTestPipeline(t, &collector.MockFetcher{Price: 5000, DailyDelay: time.Second})
+	p.Budget = 50 * time.Millisecond
+	_, err := p.RunWeeklyEvaluation(context.Background(), WeeklyOptions{DryRun: true})
+	if err == nil || !strings.Contains(err.Error(), "daily fetch timed out") {
+		t.Fatalf("got %v, want a daily fetch timeout error", err)
+	}
+}
+
+// symbolFetcher serves MockFetcher data at a price per symbol and fails for
+// symbols it does not know.
+type symbolFetcher struct {
+	prices map[string]float64
+}
+
+func (f *symbolFetcher) mock(symbol string) (*collector.MockFetcher, error) {
+	p, ok := f.prices[symbol]
+	if !ok {
+		return nil, fmt.Errorf("unknown symbol %s", symbol)
+	}
+	return &collector.MockFetcher{Price: p}, nil
+}
+
+func (f *symbolFetcher) Name() string { return "symbols" }
+
+func (f *symbolFetcher) FetchDailyBars(symbol string, days int) ([]model.OHLCV, error) {
+	m, err := f.mock(symbol)
+	if err != nil {
+		return nil, err
+	}
+	return m.FetchDailyBars(symbol, days)
+}
+
+func (f *symbolFetcher) FetchWeeklyBars(symbol string, weeks int) ([]model.OHLCV, error) {
+	m, err := f.mock(symbol)
+	if err != nil {
+		return nil, err
+	}
+	return m.FetchWeeklyBars(symbol, weeks)
+}
+
+func (f *symbolFetcher) FetchCurrentPrice(symbol string) (float64, error) {
+	m, err := f.mock(symbol)
+	if err != nil {
+		return 0, err
+	}
+	return m.FetchCurrentPrice(symbol)
+}
+
+func TestRunWeeklyEvaluation_OtherSymbols(t *testing.T) {
+	p := newTestPipeline(t, &symbolFetcher{prices: map[string]float64{"SPX500": 5000, "NDX": 18000}})
+	rec, err := recorder.NewSQLiteRecorder(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rec.Close()
+	p.Recorder = rec
+	p.Symbols = []string{"NDX", "BAD"}
+
+	res, err := p.RunWeeklyEvaluation(context.Background(), WeeklyOptions{})
+	if err != nil {
+		t.Fatalf("a failing extra symbol must not fail the run: %v", err)
+	}
+	if res.Symbol != "SPX500" || res.Indicators.CurrentPrice != 5000 {
+		t.Errorf("primary result is %s at %.0f", res.Symbol, res.Indicators.CurrentPrice)
+	}
+	if len(res.Others) != 2 {
+		t.Fatalf("got %d other results, want 2", len(res.Others))
+	}
+	if ndx := res.Others[0]; ndx.Symbol != "NDX" || ndx.Err != nil || ndx.Indicators.CurrentPrice != 18000 || ndx.Signal == nil {
+		t.Errorf("unexpected NDX result: %+v", ndx)
+	}
+	if bad := res.Others[1]; bad.Err == nil || !strings.Contains(bad.Err.Error(), "unknown symbol BAD") {
+		t.Errorf("expected BAD to carry its error, got %v", bad.Err)
+	}
+
+	since := time.Now().Add(-time.Hour)
+	for sym, price := range map[string]float64{"SPX500": 5000, "NDX": 18000} {
+		snaps, err := rec.WeeklySnapshotsSince(sym, since)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(snaps) != 1 || snaps[0].CurrentPrice != price {
+			t.Errorf("%s snapshots = %+v, want one at %.0f", sym, snaps, price)
+		}
+	}
+	if snaps, _ := rec.WeeklySnapshotsSince("BAD", since); len(snaps) != 0 {
+		t.Errorf("failed symbol recorded: %+v", snaps)
+	}
+}
+
+func TestRunWeeklyEvaluation_Allocated(t *testing.T) {
+	policy := fund.Policy{Symbol: "SPX500", Allocation: map[string]float64{"SPX500": 0.6, "NDX": 0.4}}
+	fm, err := fund.NewManager(fund.NewFileStore(filepath.Join(t.TempDir(), "fund.json")), 10000, policy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec, err := recorder.NewSQLiteRecorder(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rec.Close()
+	p := New(collector.NewCollector(&symbolFetcher{prices: map[string]float64{"SPX500": 5000, "NDX": 18000}}, "SPX500"), fm, rec)
+	p.Symbols = []string{"NDX"}
+	ndxBefore := fm.For("NDX").GetState()
+
+	dry, err := p.RunWeeklyEvaluation(context.Background(), WeeklyOptions{DryRun: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if o := dry.Others[0]; !o.Allocated || o.Signal.FinalAmount <= 0 || dry.StateAfter.Symbols["NDX"].RegularBalance >= ndxBefore.RegularBalance {
+		t.Fatalf("dry run NDX: allocated %v, amount %.2f", o.Allocated, o.Signal.FinalAmount)
+	}
+	if st := fm.For("NDX").GetState(); st.RegularBalance != ndxBefore.RegularBalance {
+		t.Errorf("dry run debited NDX: %+v", st)
+	}
+
+	from := time.Now().Add(-time.Hour)
+	res, err := p.RunWeeklyEvaluation(context.Background(), WeeklyOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ndx := res.Others[0]
+	if got := fm.For("NDX").GetState(); math.Abs(got.RegularBalance+got.ReserveBalance-(ndxBefore.RegularBalance+ndxBefore.ReserveBalance-ndx.Signal.FinalAmount)) > 0.01 {
+		t.Errorf("NDX pools %+v after investing %.2f", got, ndx.Signal.FinalAmount)
+	}
+	if got := res.StateAfter.Symbols["NDX"]; got.RegularBalance != ndx.StateAfter.RegularBalance {
+		t.Errorf("primary's state after lacks NDX's investment: %+v", got)
+	}
+
+	events, err := rec.FundEventsBetween(from, time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var symbols []string
+	for _, e := range events {
+		if e.EventType == "WEEKLY" {
+			symbols = append(symbols, e.Symbol)
+		}
+	}
+	slices.Sort(symbols)
+	if !slices.Equal(symbols, []string{"NDX", "SPX500"}) {
+		t.Errorf("weekly fund events for %v, want NDX and SPX500", symbols)
+	}
+}
+
+func TestRunWeeklyEvaluation_AuxSymbolOptional(t *testing.T) {
+	p := newTestPipeline(t, &symbolFetcher{prices: map[string]float64{"SPX500": 5000, "^VIX": 15}})
+	p.Collector.AuxSymbol = "^VIX"
+	res, err := p.RunWeeklyEvaluation(context.Background(), WeeklyOptions{DryRun: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Indicators.VIX == 0 || res.Indicators.VIX20d == 0 {
+		t.Errorf("VIX not collected: %.2f/%.2f", res.Indicators.VIX, res.Indicators.VIX20d)
+	}
+	if last := res.Stages[len(res.Stages)-1]; last.Stage != StageAux {
+		t.Errorf("last stage = %s, want %s", last.Stage, StageAux)
+	}
+
+	p.Collector.AuxSymbol = "^MISSING"
+	if res, err = p.RunWeeklyEvaluation(context.Background(), WeeklyOptions{DryRun: true}); err != nil {
+		t.Fatalf("a failing aux symbol must not fail the run: %v", err)
+	}
+	if res.Indicators.VIX != 0 || res.Indicators.VIX20d != 0 {
+		t.Errorf("VIX = %.2f/%.2f after a failed fetch, want zero", res.Indicators.VIX, res.Indicators.VIX20d)
+	}
+}
+
+func TestRunWeeklyEvaluation_ArchivesBars(t *testing.T) {
+	p := newTestPipeline(t, &collector.MockFetcher{Price: 5000})
+	rec, err := recorder.NewSQLiteRecorder(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rec.Close()
+	p.Recorder = rec
+	p.ArchiveBars = 1
+
+	var res *WeeklyResult
+	for range 2 {
+		// Forced, so the second run debits and records like the next week's.
+		if res, err = p.RunWeeklyEvaluation(context.Background(), WeeklyOptions{Force: true}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if res.Series == nil || res.Series.CurrentPrice != 5000 || len(res.Series.DailyBars) == 0 {
+		t.Fatalf("result series not populated: %+v", res.Series)
+	}
+	if s, err := rec.ArchivedPriceSeries(1); err != nil || s != nil {
+		t.Errorf("first run's bars kept beyond ArchiveBars: %v", err)
+	}
+	s, err := rec.ArchivedPriceSeries(2)
+	if err != nil || s == nil {
+		t.Fatalf("second run's bars not archived: %v", err)
+	}
+	if len(s.DailyBars) != len(res.Series.DailyBars) || len(s.WeeklyBars) != len(res.Series.WeeklyBars) {
+		t.Errorf("archived %d/%d bars, want %d/%d", len(s.DailyBars), len(s.WeeklyBars),
+			len(res.Series.DailyBars), len(res.Series.WeeklyBars))
+	}
+}
+
+func TestRunWeeklyEvaluation_Smoothing(t *testing.T) {
+	strategy.SetSmoothing(0.5)
+	t.Cleanup(func() { strategy.SetSmoothing(0) })
+	p := newTestPipeline(t, &collector.MockFetcher{Price: 5000})
+
+	first, err := p.RunWeeklyEvaluation(context.Background(), WeeklyOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.Signal.Smoothed {
+		t.Errorf("first week smoothed without earlier scores")
+	}
+
+	second, err := p.RunWeeklyEvaluation(context.Background(), WeeklyOptions{Force: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !second.Signal.Smoothed {
+		t.Fatal("second week not smoothed")
+	}
+	want := 0.5*second.Signal.TotalScore + 0.5*first.Signal.TotalScore
+	if math.Abs(second.Signal.SmoothedScore-want) > 1e-9 {
+		t.Errorf("smoothed score %.4f, want %.4f", second.Signal.SmoothedScore, want)
+	}
+	if got := second.StateAfter.RecentScores; got[len(got)-1] != second.Signal.TotalScore {
+		t.Errorf("recorded score %.4f, want the raw %.4f", got[len(got)-1], second.Signal.TotalScore)
+	}
+}
+
+func TestWhatIf(t *testing.T) {
+	p := newTestPipeline(t, &collector.MockFetcher{Price: 5000})
+	before := p.Fund.GetState()
+
+	// Nothing fetched yet: WhatIf collects first.
+	low, err := p.WhatIf(4500)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if low.Current != 5000 || low.Indicators.CurrentPrice != 4500 || !low.DryRun {
+		t.Errorf("got current %.0f, price %.0f, dry run %v", low.Current, low.Indicators.CurrentPrice, low.DryRun)
+	}
+	// The mock's daily closes have risen well above its quote; a price back
+	// inside their 52-week range scores lower.
+	high, err := p.WhatIf(7000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if low.Signal.TotalScore <= high.Signal.TotalScore {
+		t.Errorf("score at 4500 %.3f not above the score at 7000 %.3f", low.Signal.TotalScore, high.Signal.TotalScore)
+	}
+	if low.Signal.FinalAmount <= 0 || low.Signal.BaseAmount != before.WeeklyBaseN {
+		t.Errorf("amounts not previewed: %+v", low.Signal)
+	}
+	if after := p.Fund.GetState(); after.RegularBalance != before.RegularBalance || after.ReserveBalance != before.ReserveBalance {
+		t.Errorf("WhatIf moved the fund: %+v -> %+v", before, after)
+	}
+
+	for _, price := range []float64{0, -1, 50001} {
+		var rangeErr *PriceRangeError
+		if _, err := p.WhatIf(price); !errors.As(err, &rangeErr) || rangeErr.Current != 5000 {
+			t.Errorf("WhatIf(%v): err = %v, want a PriceRangeError", price, err)
+		}
+	}
+	if _, err := p.WhatIf(50000); err != nil {
+		t.Errorf("WhatIf at exactly %dx: %v", MaxWhatIfRatio, err)
+	}
+}
+
+// warningRecorder keeps the active warnings in memory.
+type warningRecorder struct {
+	*recorder.NoopRecorder
+	active []model.WarningKind
+	syncs  int
+}
+
+func (r *warningRecorder) ActiveWarnings(string) ([]model.WarningKind, error) { return r.active, nil }
+
+func (r *warningRecorder) SyncWarnings(_ string, kinds []model.WarningKind, _ time.Time) error {
+	r.active = kinds
+	r.syncs++
+	return nil
+}
+
+func TestRunWeeklyEvaluation_WarningsOnce(t *testing.T) {
+	strategy.SetRSIThresholds(strategy.RSIThresholds{BottomFish: 10, TakeProfit: 20})
+	t.Cleanup(func() { strategy.SetRSIThresholds(strategy.DefaultRSIThresholds) })
+	p := newTestPipeline(t, &collector.MockFetcher{Price: 5000})
+	rec := &warningRecorder{NoopRecorder: recorder.NewNoopRecorder()}
+	p.Recorder = rec
+
+	hasTakeProfit := func(s *model.TradeSignal) bool {
+		return slices.ContainsFunc(s.Warnings, func(w model.Warning) bool { return w.Kind == model.WarningTakeProfit })
+	}
+
+	dry, err := p.RunWeeklyEvaluation(context.Background(), WeeklyOptions{DryRun: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !hasTakeProfit(dry.Signal) || rec.syncs != 0 {
+		t.Fatalf("dry run: warnings %+v, %d syncs", dry.Signal.Warnings, rec.syncs)
+	}
+
+	first, err := p.RunWeeklyEvaluation(context.Background(), WeeklyOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !hasTakeProfit(first.Signal) {
+		t.Fatalf("first run lacks the take-profit warning: %+v", first.Signal.Warnings)
+	}
+	second, err := p.RunWeeklyEvaluation(context.Background(), WeeklyOptions{Force: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hasTakeProfit(second.Signal) {
+		t.Errorf("second run repeated the active warning: %+v", second.Signal.Warnings)
+	}
+	if !slices.Contains(rec.active, model.WarningTakeProfit) {
+		t.Errorf("active warnings %v lost the take-profit warning", rec.active)
+	}
+}
+
+func TestRunWeeklyEvaluation_OncePerWeek(t *testing.T) {
+	now := time.Date(2025, 3, 3, 8, 0, 0, 0, time.UTC) // a Monday
+	fm := fund.NewMemoryManager(fund.InitialState(10000, fund.Policy{}), func() time.Time { return now })
+	p := New(collector.NewCollector(&collector.MockFetcher{Price: 5000}, "SPX500"), fm, recorder.NewNoopRecorder())
+	run := func(opts WeeklyOptions) *WeeklyResult {
+		t.Helper()
+		res, err := p.RunWeeklyEvaluation(context.Background(), opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return res
+	}
+
+	first := run(WeeklyOptions{At: now})
+	if first.DryRun || first.Signal.FinalAmount <= 0 {
+		t.Fatalf("first run: dry %v, invested %.2f", first.DryRun, first.Signal.FinalAmount)
+	}
+	invested := fm.GetState()
+
+	// RUN_ON_START after the cron run the same week: shown, not debited.
+	now = now.Add(3 * time.Hour)
+	again := run(WeeklyOptions{At: now})
+	if !again.DryRun || !again.AlreadyInvested || again.Signal.FinalAmount != first.Signal.FinalAmount {
+		t.Errorf("repeated run: dry %v, already invested %v, amount %.2f", again.DryRun, again.AlreadyInvested, again.Signal.FinalAmount)
+	}
+	if st := fm.GetState(); st.RegularBalance != invested.RegularBalance || len(st.RecentScores) != len(invested.RecentScores) {
+		t.Errorf("repeated run changed the fund: %+v", st)
+	}
+
+	// The next Monday invests again.
+	now = time.Date(2025, 3, 10, 8, 0, 0, 0, time.UTC)
+	fm.ResetWeeklyFlags()
+	if next := run(WeeklyOptions{At: now}); next.DryRun || fm.GetState().RegularBalance >= invested.RegularBalance {
+		t.Errorf("next week's run: dry %v, regular %.2f", next.DryRun, fm.GetState().RegularBalance)
+	}
+	// Forced, a second run in the week debits again.
+	before := fm.GetState()
+	if forced := run(WeeklyOptions{At: now, Force: true}); forced.DryRun || fm.GetState().RegularBalance >= before.RegularBalance {
+		t.Errorf("forced run: dry %v, regular %.2f", forced.DryRun, fm.GetState().RegularBalance)
+	}
+}
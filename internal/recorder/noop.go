@@ -1,5 +1,11 @@
 package recorder
 
+import (
+	"time"
+
+	"MarketSentinel/internal/model"
+)
+
 // NoopRecorder is a no-op implementation used when SQLite is not configured.
 type NoopRecorder struct{}
 
@@ -10,4 +16,36 @@ func (n *NoopRecorder) RecordDailyCheck(_ *DailyCheckEvent) error { return nil }
 func (n *NoopRecorder) RecordFundEvent(_ *FundEvent) error       { return nil }
 func (n *NoopRecorder) RecordMonthly(_ *MonthlyEvent) error      { return nil }
 func (n *NoopRecorder) RecordQuarterly(_ *QuarterlyEvent) error  { return nil }
+func (n *NoopRecorder) RecordCommandAudit(_ *CommandAuditEvent) error { return nil }
+func (n *NoopRecorder) RecordWeeklyRecap(_ *WeeklyRecap) error { return nil }
+func (n *NoopRecorder) RecordDelivery(_ *DeliveryEvent) error      { return nil }
+func (n *NoopRecorder) RecordTaskRun(_ *TaskRun) error            { return nil }
+func (n *NoopRecorder) RecordMetricSamples(_ []MetricSample) error { return nil }
+func (n *NoopRecorder) RecordPurchase(_ *Purchase) error { return nil }
+func (n *NoopRecorder) RecordSale(_ *Sale) error         { return nil }
+func (n *NoopRecorder) ImportTrades(_ []Trade) (int, error)        { return 0, nil }
+func (n *NoopRecorder) StoreBars(_, _ string, _ []model.OHLCV, _ time.Time) error { return nil }
+func (n *NoopRecorder) RecordPriceSeries(_ int64, _ *model.PriceSeries, _ int) error { return nil }
+func (n *NoopRecorder) SyncWarnings(_ string, _ []model.WarningKind, _ time.Time) error { return nil }
+func (n *NoopRecorder) FirstWeeklySnapshotSince(_ string, _ time.Time) (*WeeklySnapshotSummary, error) {
+	return nil, nil
+}
+func (n *NoopRecorder) WeeklySnapshotsSince(_ string, _ time.Time) ([]WeeklySnapshotSummary, error) {
+	return nil, nil
+}
+func (n *NoopRecorder) DailyChecksSince(_ time.Time) ([]DailyCheckEvent, error) { return nil, nil }
+func (n *NoopRecorder) FundHistorySince(_ time.Time) ([]FundHistoryPoint, error) { return nil, nil }
+func (n *NoopRecorder) FundEventsBetween(_, _ time.Time) ([]FundEvent, error)     { return nil, nil }
+func (n *NoopRecorder) TradesBetween(_, _ time.Time) ([]Trade, error)             { return nil, nil }
+func (n *NoopRecorder) PurchasesSince(_ string, _ time.Time) ([]Purchase, error) { return nil, nil }
+func (n *NoopRecorder) SalesSince(_ string, _ time.Time) ([]Sale, error)         { return nil, nil }
+func (n *NoopRecorder) RecentWeeklyTiers(_ string, _ int) ([]string, error)  { return nil, nil }
+func (n *NoopRecorder) ActiveWarnings(_ string) ([]model.WarningKind, error) { return nil, nil }
+func (n *NoopRecorder) RecentWeeklyRecaps(_ int) ([]WeeklyRecap, error)       { return nil, nil }
+func (n *NoopRecorder) MetricSamplesSince(_ time.Time) ([]MetricSample, error)   { return nil, nil }
+func (n *NoopRecorder) LoadBars(_, _ string) ([]model.OHLCV, time.Time, error) {
+	return nil, time.Time{}, nil
+}
+func (n *NoopRecorder) ArchivedPriceSeries(_ int64) (*model.PriceSeries, error) { return nil, nil }
+func (n *NoopRecorder) PruneMetricSamples(_ time.Time) (int64, error)            { return 0, nil }
 func (n *NoopRecorder) Close() error                             { return nil }
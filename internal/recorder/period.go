@@ -0,0 +1,42 @@
+package recorder
+
+import (
+	"fmt"
+	"time"
+)
+
+// DedupPolicy decides what happens when a monthly or quarterly event is
+// recorded again within a period that already has one.
+type DedupPolicy string
+
+const (
+	DedupUpdate DedupPolicy = "update" // overwrite the existing row
+	DedupSkip   DedupPolicy = "skip"   // keep the existing row and log
+	DedupOff    DedupPolicy = "off"    // always insert
+)
+
+// ParseDedupPolicy validates a configured policy; empty means DedupUpdate.
+func ParseDedupPolicy(s string) (DedupPolicy, error) {
+	switch p := DedupPolicy(s); p {
+	case "":
+		return DedupUpdate, nil
+	case DedupUpdate, DedupSkip, DedupOff:
+		return p, nil
+	default:
+		return "", fmt.Errorf("unknown dedup policy %q (want update, skip or off)", s)
+	}
+}
+
+// monthBounds returns the calendar month containing t, in t's location, matching
+// the monthly replenish cron which runs in local time.
+func monthBounds(t time.Time) (start, end time.Time) {
+	start = time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+	return start, start.AddDate(0, 1, 0)
+}
+
+// quarterBounds returns the calendar quarter (Jan/Apr/Jul/Oct) containing t.
+func quarterBounds(t time.Time) (start, end time.Time) {
+	first := time.Month((int(t.Month())-1)/3*3 + 1)
+	start = time.Date(t.Year(), first, 1, 0, 0, 0, 0, t.Location())
+	return start, start.AddDate(0, 3, 0)
+}
@@ -1,33 +1,53 @@
 package recorder
 
-import "MarketSentinel/internal/model"
+import (
+	"math"
+	"time"
+
+	"MarketSentinel/internal/model"
+)
 
 // WeeklySnapshot holds all data for a weekly evaluation record.
 type WeeklySnapshot struct {
+	Symbol      string
 	Indicators  *model.MarketIndicators
 	Signal      *model.TradeSignal
 	FundState   *model.FundState
+	OccurredAt  time.Time // logical evaluation time; zero means now
+	ID          int64     // set by RecordWeekly to the stored row id
 }
 
 // DailyCheckEvent holds data for a daily RSI trigger event.
 type DailyCheckEvent struct {
+	Timestamp   time.Time // event time; zero on write means now
 	DailyRSI    float64
 	WeeklyRSI   float64
 	Price       float64
-	EventType   string // "BOTTOM_FISH" or "TAKE_PROFIT"
+	EventType   string // "BOTTOM_FISH", "TAKE_PROFIT", "SELL" or "OBSERVE"
 	Amount      float64
 	TotalScore  float64
+	Note        string // e.g. why a trigger was suppressed
+	Level       int    // bottom-fish level of a BOTTOM_FISH event, counted from 1; 0 otherwise
+	SellPercent float64 // share of the position a SELL event recommends selling, in percent
 }
 
 // FundEvent records a fund balance change.
 type FundEvent struct {
-	EventType      string // "WEEKLY", "BOTTOM_FISH", "MONTHLY", "QUARTERLY"
+	EventType      string // "WEEKLY", "BOTTOM_FISH", "MONTHLY", "QUARTERLY", "MANUAL_ADJUST", ...
 	RegularBefore  float64
 	RegularAfter   float64
 	ReserveBefore  float64
 	ReserveAfter   float64
 	Amount         float64
 	Note           string
+	Symbol         string    // symbol of the sub-state whose pools these are, see model.FundState.Symbol
+	OccurredAt     time.Time // zero means now; populated when read back
+}
+
+// Invested returns how much left the two pools in this event, or zero when
+// money was added or only moved between them.
+func (e *FundEvent) Invested() float64 {
+	return math.Max(0, (e.RegularBefore-e.RegularAfter)+(e.ReserveBefore-e.ReserveAfter))
 }
 
 // MonthlyEvent records a monthly replenishment.
@@ -37,6 +57,13 @@ type MonthlyEvent struct {
 	RegularAfter  float64
 	ReserveAfter  float64
 	AvgScore      float64
+	ScoreStdDev   float64 // sample standard deviation of the scores AvgScore averages
+	ReserveShare  float64 // actual fraction of the budget sent to the reserve pool
+	SplitReason   string
+	CarriedOver   float64 // regular pool surplus moved to the reserve pool, see fund.Policy.RegularCapMonths
+	Symbol        string
+	OccurredAt    time.Time // zero means now; also selects the dedup month
+	Performance   *model.Performance // nil before the first purchase or sale
 }
 
 // QuarterlyEvent records a quarterly rebalance.
@@ -46,6 +73,114 @@ type QuarterlyEvent struct {
 	RegularAfter  float64
 	ReserveAfter  float64
 	Note          string
+	Symbol        string
+	OccurredAt    time.Time // zero means now; also selects the dedup quarter
+}
+
+// CommandAuditEvent records one stage of a confirmable (destructive) command.
+type CommandAuditEvent struct {
+	UserID        int64
+	Command       string
+	Stage         string // "PREVIEW", "CONFIRMED", "DENIED", "EXPIRED", "FOREIGN_USER"
+	Detail        string
+	OccurredAt    time.Time // zero means now
+}
+
+// WeeklySnapshotSummary is the subset of a weekly snapshot read back for recaps.
+type WeeklySnapshotSummary struct {
+	Timestamp    time.Time
+	CurrentPrice float64
+	TotalScore   float64
+	TierLabel    string
+	BaseAmount   float64
+	FinalAmount  float64
+	ReserveUsed  float64
+}
+
+// WeeklyRecap records the end-of-week comparison of the suggestion against the market.
+type WeeklyRecap struct {
+	WeekStart      time.Time
+	SuggestedPrice float64 // price at the time of the weekly report
+	TotalScore     float64
+	WeekOpen       float64
+	WeekClose      float64
+	BottomFished   bool
+	Hit            bool // close moved in the direction the score implied
+	OccurredAt     time.Time // when the recap ran; zero means now
+}
+
+// DeliveryEvent records the outcome of one outbound notification.
+type DeliveryEvent struct {
+	MessageType string
+	Priority    string
+	Status      string // "SENT" or "FAILED"
+	Error       string
+	Length      int
+	OccurredAt  time.Time // zero means now
+}
+
+// TaskRun records how one run of a scheduled task went, stage by stage.
+type TaskRun struct {
+	Task       string // e.g. "weekly"
+	Stages     []StageRun
+	OccurredAt time.Time // logical run time; zero means now
+}
+
+// StageRun is the timing of one stage of a TaskRun.
+type StageRun struct {
+	Stage    string
+	Duration time.Duration
+	TimedOut bool // the run's budget ran out before the stage finished
+	Cached   bool // the stage's data came from the cache instead
+}
+
+// FundHistoryPoint is one fund_history row read back for charting.
+type FundHistoryPoint struct {
+	Timestamp    time.Time
+	EventType    string
+	Symbol       string
+	RegularAfter float64
+	ReserveAfter float64
+}
+
+// Trade is one executed brokerage trade imported from a statement. Hash
+// identifies the source row so repeated imports are ignored.
+type Trade struct {
+	Date   time.Time
+	Amount float64
+	Price  float64
+	Units  float64
+	Hash   string
+}
+
+// Purchase is one fill confirmed by the user: Amount spent on Units of
+// Symbol at Price.
+type Purchase struct {
+	Symbol     string
+	Amount     float64
+	Price      float64
+	Units      float64
+	OccurredAt time.Time // zero means now
+}
+
+// Sale is one sale confirmed by the user: Units of Symbol sold at Price for
+// Proceeds, realizing RealizedPnL against their average cost.
+type Sale struct {
+	Symbol      string
+	Units       float64
+	Price       float64
+	Proceeds    float64
+	RealizedPnL float64
+	Destination string    // pool credited with Proceeds, or "external"
+	OccurredAt  time.Time // zero means now
+}
+
+// MetricSample is one sampled series from the in-process metrics registry.
+type MetricSample struct {
+	Timestamp time.Time // sampling time; zero on write means now
+	Name      string
+	Labels    map[string]string
+	Value     float64
 }
 
 // Recorder persists historical data for analysis.
@@ -55,5 +190,65 @@ type Recorder interface {
 	RecordFundEvent(evt *FundEvent) error
 	RecordMonthly(evt *MonthlyEvent) error
 	RecordQuarterly(evt *QuarterlyEvent) error
+	RecordCommandAudit(evt *CommandAuditEvent) error
+	RecordWeeklyRecap(recap *WeeklyRecap) error
+	RecordDelivery(evt *DeliveryEvent) error
+	// RecordTaskRun stores the stages of run, one row each.
+	RecordTaskRun(run *TaskRun) error
+	RecordMetricSamples(samples []MetricSample) error
+	RecordPurchase(p *Purchase) error
+	RecordSale(s *Sale) error
+	// ImportTrades stores trades, skipping any whose Hash is already present,
+	// and returns how many were inserted.
+	ImportTrades(trades []Trade) (int, error)
+	// StoreBars caches bars (oldest first) of symbol and interval, replacing
+	// every cached bar dated on or after the first of them.
+	StoreBars(symbol, interval string, bars []model.OHLCV, fetchedAt time.Time) error
+	// RecordPriceSeries archives the bars a weekly snapshot was computed from
+	// and prunes the archive to the keep most recent snapshots.
+	RecordPriceSeries(snapshotID int64, series *model.PriceSeries, keep int) error
+	// SyncWarnings records the warnings of symbol active at at: kinds not
+	// yet active start an occurrence, and active ones absent from kinds end.
+	SyncWarnings(symbol string, kinds []model.WarningKind, at time.Time) error
+
+	// FirstWeeklySnapshotSince returns the earliest snapshot of symbol at or
+	// after since, or nil if none. Snapshots recorded before symbols were
+	// stored match any symbol.
+	FirstWeeklySnapshotSince(symbol string, since time.Time) (*WeeklySnapshotSummary, error)
+	// WeeklySnapshotsSince returns all snapshots of symbol at or after since,
+	// oldest first, matching unlabelled snapshots like FirstWeeklySnapshotSince.
+	WeeklySnapshotsSince(symbol string, since time.Time) ([]WeeklySnapshotSummary, error)
+	// DailyChecksSince returns daily check events at or after since, oldest first.
+	DailyChecksSince(since time.Time) ([]DailyCheckEvent, error)
+	// FundHistorySince returns fund balance changes at or after since, oldest first.
+	FundHistorySince(since time.Time) ([]FundHistoryPoint, error)
+	// FundEventsBetween returns fund balance changes in [from, to), oldest first.
+	FundEventsBetween(from, to time.Time) ([]FundEvent, error)
+	// TradesBetween returns imported trades dated in [from, to), oldest first.
+	TradesBetween(from, to time.Time) ([]Trade, error)
+	// PurchasesSince returns the purchases of symbol at or after since,
+	// oldest first.
+	PurchasesSince(symbol string, since time.Time) ([]Purchase, error)
+	// SalesSince returns the sales of symbol at or after since, oldest first.
+	SalesSince(symbol string, since time.Time) ([]Sale, error)
+	// RecentWeeklyTiers returns the tier labels of up to n most recent
+	// snapshots of symbol, newest first, matching unlabelled snapshots like
+	// FirstWeeklySnapshotSince.
+	RecentWeeklyTiers(symbol string, n int) ([]string, error)
+	// ActiveWarnings returns the kinds of the warnings of symbol whose
+	// occurrence has not ended, so each is reported once.
+	ActiveWarnings(symbol string) ([]model.WarningKind, error)
+	// RecentWeeklyRecaps returns up to n most recent recaps, newest first.
+	RecentWeeklyRecaps(n int) ([]WeeklyRecap, error)
+	// MetricSamplesSince returns metric samples at or after since, oldest first.
+	MetricSamplesSince(since time.Time) ([]MetricSample, error)
+	// LoadBars returns the cached bars of symbol and interval, oldest first,
+	// and when the newest of them was fetched.
+	LoadBars(symbol, interval string) ([]model.OHLCV, time.Time, error)
+	// ArchivedPriceSeries returns the bars archived for a weekly snapshot,
+	// or nil if none are kept.
+	ArchivedPriceSeries(snapshotID int64) (*model.PriceSeries, error)
+	// PruneMetricSamples deletes metric samples older than before and returns the count removed.
+	PruneMetricSamples(before time.Time) (int64, error)
 	Close() error
 }
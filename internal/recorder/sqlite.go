@@ -2,22 +2,34 @@ package recorder
 
 import (
 	"database/sql"
+	"encoding/json"
 	"fmt"
 	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
 	"sync"
 	"time"
 
+	"MarketSentinel/internal/model"
+
 	_ "modernc.org/sqlite"
 )
 
 // SQLiteRecorder persists historical data to a SQLite database.
 type SQLiteRecorder struct {
-	db *sql.DB
-	mu sync.Mutex
+	db    *sql.DB
+	mu    sync.Mutex
+	dedup DedupPolicy
+	now   func() time.Time
 }
 
 // NewSQLiteRecorder opens (or creates) the SQLite database and runs migrations.
 func NewSQLiteRecorder(dbPath string) (*SQLiteRecorder, error) {
+	if err := os.MkdirAll(filepath.Dir(dbPath), 0o755); err != nil {
+		return nil, fmt.Errorf("create database dir: %w", err)
+	}
 	db, err := sql.Open("sqlite", dbPath)
 	if err != nil {
 		return nil, fmt.Errorf("open sqlite: %w", err)
@@ -29,7 +41,7 @@ func NewSQLiteRecorder(dbPath string) (*SQLiteRecorder, error) {
 		return nil, fmt.Errorf("set WAL mode: %w", err)
 	}
 
-	r := &SQLiteRecorder{db: db}
+	r := &SQLiteRecorder{db: db, dedup: DedupUpdate, now: time.Now}
 	if err := r.migrate(); err != nil {
 		db.Close()
 		return nil, fmt.Errorf("migrate: %w", err)
@@ -116,6 +128,127 @@ func (r *SQLiteRecorder) migrate() error {
 			note          TEXT
 		)`,
 		`CREATE INDEX IF NOT EXISTS idx_quarterly_ts ON quarterly_events(timestamp)`,
+
+		`CREATE TABLE IF NOT EXISTS command_audit (
+			id        INTEGER PRIMARY KEY AUTOINCREMENT,
+			timestamp INTEGER NOT NULL,
+			user_id   INTEGER,
+			command   TEXT,
+			stage     TEXT,
+			detail    TEXT
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_audit_ts ON command_audit(timestamp)`,
+
+		`CREATE TABLE IF NOT EXISTS weekly_recaps (
+			week_start      INTEGER PRIMARY KEY,
+			timestamp       INTEGER NOT NULL,
+			suggested_price REAL,
+			total_score     REAL,
+			week_open       REAL,
+			week_close      REAL,
+			bottom_fished   INTEGER,
+			hit             INTEGER
+		)`,
+
+		`CREATE TABLE IF NOT EXISTS deliveries (
+			id           INTEGER PRIMARY KEY AUTOINCREMENT,
+			timestamp    INTEGER NOT NULL,
+			message_type TEXT,
+			priority     TEXT,
+			status       TEXT,
+			error        TEXT,
+			length       INTEGER
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_deliveries_ts ON deliveries(timestamp)`,
+
+		`CREATE TABLE IF NOT EXISTS task_runs (
+			id          INTEGER PRIMARY KEY AUTOINCREMENT,
+			timestamp   INTEGER NOT NULL,
+			task        TEXT NOT NULL,
+			stage       TEXT NOT NULL,
+			duration_ms INTEGER,
+			timed_out   INTEGER,
+			cached      INTEGER
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_task_runs_ts ON task_runs(timestamp)`,
+
+		`CREATE TABLE IF NOT EXISTS metrics_samples (
+			id        INTEGER PRIMARY KEY AUTOINCREMENT,
+			timestamp INTEGER NOT NULL,
+			name      TEXT,
+			value     REAL,
+			labels    TEXT
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_metrics_ts ON metrics_samples(timestamp)`,
+
+		`CREATE TABLE IF NOT EXISTS trades (
+			id          INTEGER PRIMARY KEY AUTOINCREMENT,
+			date        INTEGER NOT NULL,
+			amount      REAL,
+			price       REAL,
+			units       REAL,
+			hash        TEXT NOT NULL UNIQUE,
+			imported_at INTEGER NOT NULL
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_trades_date ON trades(date)`,
+
+		`CREATE TABLE IF NOT EXISTS purchases (
+			id        INTEGER PRIMARY KEY AUTOINCREMENT,
+			timestamp INTEGER NOT NULL,
+			symbol    TEXT NOT NULL,
+			amount    REAL,
+			price     REAL,
+			units     REAL
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_purchases_ts ON purchases(timestamp)`,
+
+		`CREATE TABLE IF NOT EXISTS sales (
+			id           INTEGER PRIMARY KEY AUTOINCREMENT,
+			timestamp    INTEGER NOT NULL,
+			symbol       TEXT NOT NULL,
+			units        REAL,
+			price        REAL,
+			proceeds     REAL,
+			realized_pnl REAL,
+			destination  TEXT
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_sales_ts ON sales(timestamp)`,
+
+		`CREATE TABLE IF NOT EXISTS bars_cache (
+			symbol     TEXT NOT NULL,
+			interval   TEXT NOT NULL,
+			date       TEXT NOT NULL,
+			time       INTEGER NOT NULL,
+			open       REAL,
+			high       REAL,
+			low        REAL,
+			close      REAL,
+			volume     REAL,
+			fetched_at INTEGER NOT NULL,
+			PRIMARY KEY (symbol, interval, date)
+		)`,
+
+		`CREATE TABLE IF NOT EXISTS bars_archive (
+			snapshot_id INTEGER NOT NULL,
+			symbol      TEXT NOT NULL,
+			interval    TEXT NOT NULL,
+			time        INTEGER NOT NULL,
+			open        REAL,
+			high        REAL,
+			low         REAL,
+			close       REAL,
+			volume      REAL,
+			PRIMARY KEY (snapshot_id, interval, time)
+		)`,
+
+		`CREATE TABLE IF NOT EXISTS signal_warnings (
+			id         INTEGER PRIMARY KEY AUTOINCREMENT,
+			symbol     TEXT NOT NULL,
+			kind       TEXT NOT NULL,
+			started_at INTEGER NOT NULL,
+			ended_at   INTEGER
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_warnings_active ON signal_warnings(symbol, ended_at)`,
 	}
 
 	for _, s := range stmts {
@@ -123,51 +256,187 @@ func (r *SQLiteRecorder) migrate() error {
 			return fmt.Errorf("exec %q: %w", s[:40], err)
 		}
 	}
+
+	// Columns added after the initial schema; existing databases get them via ALTER TABLE.
+	columns := []struct{ table, column, decl string }{
+		{"daily_checks", "note", "TEXT"},
+		{"daily_checks", "level", "INTEGER"},
+		{"daily_checks", "sell_pct", "REAL"},
+		{"monthly_events", "reserve_share", "REAL"},
+		{"monthly_events", "split_reason", "TEXT"},
+		{"monthly_events", "symbol", "TEXT"},
+		{"monthly_events", "score_stddev", "REAL"},
+		{"monthly_events", "price", "REAL"},
+		{"monthly_events", "invested", "REAL"},
+		{"monthly_events", "market_value", "REAL"},
+		{"monthly_events", "unrealized_pnl", "REAL"},
+		{"monthly_events", "roi", "REAL"},
+		{"monthly_events", "xirr", "REAL"},
+		{"monthly_events", "realized_ytd", "REAL"},
+		{"monthly_events", "carried_over", "REAL"},
+		{"quarterly_events", "symbol", "TEXT"},
+		{"fund_history", "symbol", "TEXT"},
+		{"weekly_snapshots", "boundary_dist_up", "REAL"},
+		{"weekly_snapshots", "boundary_dist_down", "REAL"},
+		{"weekly_snapshots", "symbol", "TEXT"},
+		{"weekly_snapshots", "fx_rate", "REAL"},
+		{"weekly_snapshots", "factor6_score", "REAL"},
+		{"weekly_snapshots", "smoothed_score", "REAL"},
+		{"weekly_snapshots", "cooldown_demoted", "INTEGER"},
+		{"weekly_snapshots", "sell_pct", "REAL"},
+		{"weekly_snapshots", "regime", "TEXT"},
+		{"weekly_snapshots", "confidence", "REAL"},
+		{"weekly_snapshots", "ma50w_score", "REAL"},
+		{"weekly_snapshots", "strategy_version", "TEXT"},
+	}
+	for _, c := range columns {
+		if err := r.ensureColumn(c.table, c.column, c.decl); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
+// ensureColumn adds column to table if it is not already present.
+func (r *SQLiteRecorder) ensureColumn(table, column, decl string) error {
+	rows, err := r.db.Query(fmt.Sprintf("PRAGMA table_info(%s)", table))
+	if err != nil {
+		return fmt.Errorf("table_info %s: %w", table, err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var (
+			cid     int
+			name    string
+			typ     string
+			notNull int
+			dflt    sql.NullString
+			pk      int
+		)
+		if err := rows.Scan(&cid, &name, &typ, &notNull, &dflt, &pk); err != nil {
+			return err
+		}
+		if name == column {
+			return nil
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	if _, err := r.db.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, column, decl)); err != nil {
+		return fmt.Errorf("add column %s.%s: %w", table, column, err)
+	}
+	return nil
+}
+
+// at returns t, or the recorder clock's current time when t is zero.
+func (r *SQLiteRecorder) at(t time.Time) time.Time {
+	if t.IsZero() {
+		return r.now()
+	}
+	return t
+}
+
 func (r *SQLiteRecorder) RecordWeekly(snap *WeeklySnapshot) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	now := time.Now().Unix()
+	now := r.at(snap.OccurredAt).Unix()
 	ind := snap.Indicators
 	sig := snap.Signal
 	fs := snap.FundState
 
-	// Extract per-factor weighted scores (up to 5).
-	factors := make([]float64, 5)
-	for i := 0; i < len(sig.Factors) && i < 5; i++ {
-		factors[i] = sig.Factors[i].Weighted
+	// Distances to the neighbouring tiers; NULL past the open-ended extremes.
+	var distUp, distDown sql.NullFloat64
+	if sig.Boundary.UpLabel != "" {
+		distUp = sql.NullFloat64{Float64: model.RoundScore(sig.Boundary.DistUp), Valid: true}
+	}
+	if sig.Boundary.DownLabel != "" {
+		distDown = sql.NullFloat64{Float64: model.RoundScore(sig.Boundary.DistDown), Valid: true}
+	}
+
+	// NULL when the run had no FX rate.
+	var fxRate sql.NullFloat64
+	if ind.FXRate > 0 {
+		fxRate = sql.NullFloat64{Float64: ind.FXRate, Valid: true}
+	}
+
+	// NULL when the tier was mapped from the raw total score.
+	var smoothed sql.NullFloat64
+	if sig.Smoothed {
+		smoothed = sql.NullFloat64{Float64: model.RoundScore(sig.SmoothedScore), Valid: true}
+	}
+
+	// NULL when no sell recommendation was made.
+	var sellPct sql.NullFloat64
+	if sig.Sell != nil {
+		sellPct = sql.NullFloat64{Float64: model.RoundWeight(sig.Sell.Percent), Valid: true}
 	}
 
-	_, err := r.db.Exec(`INSERT INTO weekly_snapshots
+	// NULL when MA200 was missing and the regime unknown.
+	var regime sql.NullString
+	if sig.Regime != model.RegimeUnknown {
+		regime = sql.NullString{String: string(sig.Regime), Valid: true}
+	}
+
+	// Extract the core factors' weighted scores by column; a disabled
+	// factor records 0. The optional MA50w factor records NULL when it is
+	// not registered or was skipped.
+	factors := make([]float64, len(factorColumns))
+	var ma50w sql.NullFloat64
+	for _, f := range sig.Factors {
+		for i, id := range factorColumns {
+			if f.ID == id {
+				factors[i] = model.RoundScore(f.Weighted)
+			}
+		}
+		if f.ID == model.FactorMA50w && f.Missing == "" {
+			ma50w = sql.NullFloat64{Float64: model.RoundScore(f.Weighted), Valid: true}
+		}
+	}
+
+	res, err := r.db.Exec(`INSERT INTO weekly_snapshots
 		(timestamp, current_price, ma200, ma20w, ma50w, weekly_rsi, daily_rsi,
 		 high_52w, low_52w, position_52w,
 		 factor1_score, factor2_score, factor3_score, factor4_score, factor5_score,
 		 total_score, tier_label, tier_multiplier, tier_reserve,
 		 base_amount, final_amount, reserve_used,
-		 regular_balance, reserve_balance)
-		VALUES (?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?)`,
+		 regular_balance, reserve_balance, boundary_dist_up, boundary_dist_down, symbol, fx_rate,
+		 factor6_score, smoothed_score, cooldown_demoted, sell_pct, regime, confidence, ma50w_score,
+		 strategy_version)
+		VALUES (?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?)`,
 		now, ind.CurrentPrice, ind.MA200, ind.MA20w, ind.MA50w,
-		ind.WeeklyRSI, ind.DailyRSI, ind.High52w, ind.Low52w, ind.Position52w,
+		model.RoundRSI(ind.WeeklyRSI), model.RoundRSI(ind.DailyRSI), ind.High52w, ind.Low52w, ind.Position52w,
 		factors[0], factors[1], factors[2], factors[3], factors[4],
-		sig.TotalScore, sig.Tier.Label, sig.Tier.Multiplier, sig.Tier.UseReserve,
-		sig.BaseAmount, sig.FinalAmount, sig.ReserveUsed,
-		fs.RegularBalance, fs.ReserveBalance,
+		model.RoundScore(sig.TotalScore), sig.Tier.Label, model.RoundWeight(sig.Tier.Multiplier), sig.Tier.UseReserve,
+		model.RoundAmount(sig.BaseAmount), model.RoundAmount(sig.FinalAmount), model.RoundAmount(sig.ReserveUsed),
+		model.RoundAmount(fs.RegularBalance), model.RoundAmount(fs.ReserveBalance), distUp, distDown, snap.Symbol, fxRate,
+		factors[5], smoothed, sig.CooldownNote != "", sellPct, regime, model.RoundWeight(sig.Confidence), ma50w,
+		sig.StrategyVersion,
 	)
+	if err != nil {
+		return err
+	}
+	snap.ID, err = res.LastInsertId()
 	return err
 }
 
+// factorColumns are the factors recorded in factor1_score to factor6_score.
+var factorColumns = []string{
+	model.FactorMA200, model.FactorWeeklyRSI, model.FactorDailyRSI, model.FactorPosition52w, model.FactorTrend,
+	model.FactorVolatility,
+}
+
 func (r *SQLiteRecorder) RecordDailyCheck(evt *DailyCheckEvent) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
 	_, err := r.db.Exec(`INSERT INTO daily_checks
-		(timestamp, daily_rsi, weekly_rsi, price, event_type, amount, total_score)
-		VALUES (?,?,?,?,?,?,?)`,
-		time.Now().Unix(), evt.DailyRSI, evt.WeeklyRSI, evt.Price,
-		evt.EventType, evt.Amount, evt.TotalScore,
+		(timestamp, daily_rsi, weekly_rsi, price, event_type, amount, total_score, note, level, sell_pct)
+		VALUES (?,?,?,?,?,?,?,?,?,?)`,
+		r.at(evt.Timestamp).Unix(), model.RoundRSI(evt.DailyRSI), model.RoundRSI(evt.WeeklyRSI), evt.Price,
+		evt.EventType, model.RoundAmount(evt.Amount), model.RoundScore(evt.TotalScore), evt.Note, evt.Level,
+		model.RoundWeight(evt.SellPercent),
 	)
 	return err
 }
@@ -177,42 +446,779 @@ func (r *SQLiteRecorder) RecordFundEvent(evt *FundEvent) error {
 	defer r.mu.Unlock()
 
 	_, err := r.db.Exec(`INSERT INTO fund_history
-		(timestamp, event_type, regular_before, regular_after, reserve_before, reserve_after, amount, note)
-		VALUES (?,?,?,?,?,?,?,?)`,
-		time.Now().Unix(), evt.EventType,
-		evt.RegularBefore, evt.RegularAfter,
-		evt.ReserveBefore, evt.ReserveAfter,
-		evt.Amount, evt.Note,
+		(timestamp, event_type, regular_before, regular_after, reserve_before, reserve_after, amount, note, symbol)
+		VALUES (?,?,?,?,?,?,?,?,?)`,
+		r.at(evt.OccurredAt).Unix(), evt.EventType,
+		model.RoundAmount(evt.RegularBefore), model.RoundAmount(evt.RegularAfter),
+		model.RoundAmount(evt.ReserveBefore), model.RoundAmount(evt.ReserveAfter),
+		model.RoundAmount(evt.Amount), evt.Note, evt.Symbol,
 	)
 	return err
 }
 
+// SetDedupPolicy sets how monthly and quarterly events repeated within the
+// same period are handled. The default is DedupUpdate.
+func (r *SQLiteRecorder) SetDedupPolicy(p DedupPolicy) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.dedup = p
+}
+
+// existingInPeriod returns the id of the latest row in table for symbol within
+// [start, end), or 0 if there is none or dedup is off. Rows written before the
+// symbol column existed match any symbol. Callers must hold r.mu.
+func (r *SQLiteRecorder) existingInPeriod(table, symbol string, start, end time.Time) (int64, error) {
+	if r.dedup == DedupOff {
+		return 0, nil
+	}
+	var id int64
+	err := r.db.QueryRow(fmt.Sprintf(`SELECT id FROM %s
+		WHERE timestamp >= ? AND timestamp < ? AND (symbol = ? OR symbol IS NULL)
+		ORDER BY timestamp DESC LIMIT 1`, table),
+		start.Unix(), end.Unix(), symbol,
+	).Scan(&id)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	return id, err
+}
+
+// RecordMonthly records a replenish, deduplicated per calendar month and symbol.
 func (r *SQLiteRecorder) RecordMonthly(evt *MonthlyEvent) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	_, err := r.db.Exec(`INSERT INTO monthly_events
-		(timestamp, regular_added, reserve_added, regular_after, reserve_after, avg_score)
-		VALUES (?,?,?,?,?,?)`,
-		time.Now().Unix(), evt.RegularAdded, evt.ReserveAdded,
-		evt.RegularAfter, evt.ReserveAfter, evt.AvgScore,
+	now := r.at(evt.OccurredAt)
+	start, end := monthBounds(now)
+	id, err := r.existingInPeriod("monthly_events", evt.Symbol, start, end)
+	if err != nil {
+		return fmt.Errorf("find monthly event: %w", err)
+	}
+	if id != 0 && r.dedup == DedupSkip {
+		log.Printf("[INFO] monthly event for %s already recorded, skipping", start.Format("2006-01"))
+		return nil
+	}
+	args := append([]any{now.Unix(), model.RoundAmount(evt.RegularAdded), model.RoundAmount(evt.ReserveAdded),
+		model.RoundAmount(evt.RegularAfter), model.RoundAmount(evt.ReserveAfter),
+		model.RoundScore(evt.AvgScore), model.RoundWeight(evt.ReserveShare), evt.SplitReason, evt.Symbol,
+		model.RoundScore(evt.ScoreStdDev)}, performanceColumns(evt.Performance)...)
+	args = append(args, model.RoundAmount(evt.CarriedOver))
+	if id != 0 {
+		_, err = r.db.Exec(`UPDATE monthly_events SET
+			timestamp = ?, regular_added = ?, reserve_added = ?, regular_after = ?, reserve_after = ?,
+			avg_score = ?, reserve_share = ?, split_reason = ?, symbol = ?, score_stddev = ?,
+			price = ?, invested = ?, market_value = ?, unrealized_pnl = ?, roi = ?, xirr = ?,
+			realized_ytd = ?, carried_over = ?
+			WHERE id = ?`,
+			append(args, id)...,
+		)
+		return err
+	}
+
+	_, err = r.db.Exec(`INSERT INTO monthly_events
+		(timestamp, regular_added, reserve_added, regular_after, reserve_after, avg_score, reserve_share, split_reason, symbol, score_stddev,
+		price, invested, market_value, unrealized_pnl, roi, xirr, realized_ytd, carried_over)
+		VALUES (?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?)`,
+		args...,
 	)
 	return err
 }
 
+// performanceColumns are the values of the price to realized_ytd columns of
+// monthly_events for p. All are NULL before the first purchase, invested
+// when nothing is held, those depending on the price when there is none, and
+// xirr when it has no solution.
+func performanceColumns(p *model.Performance) []any {
+	if p == nil {
+		return []any{nil, nil, nil, nil, nil, nil, nil}
+	}
+	cols := []any{nil, nil, nil, nil, nil, nil, model.RoundAmount(p.RealizedYTD)}
+	if p.Invested > 0 {
+		cols[1] = model.RoundAmount(p.Invested)
+	}
+	if p.Price > 0 {
+		cols[0], cols[2], cols[3] = p.Price, model.RoundAmount(p.Value), model.RoundAmount(p.UnrealizedPnL)
+		cols[4] = model.RoundReturn(p.ROI)
+	}
+	if !math.IsNaN(p.XIRR) {
+		cols[5] = model.RoundReturn(p.XIRR)
+	}
+	return cols
+}
+
+// RecordQuarterly records a rebalance, deduplicated per calendar quarter and symbol.
 func (r *SQLiteRecorder) RecordQuarterly(evt *QuarterlyEvent) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	_, err := r.db.Exec(`INSERT INTO quarterly_events
-		(timestamp, action, amount, regular_after, reserve_after, note)
+	now := r.at(evt.OccurredAt)
+	start, end := quarterBounds(now)
+	id, err := r.existingInPeriod("quarterly_events", evt.Symbol, start, end)
+	if err != nil {
+		return fmt.Errorf("find quarterly event: %w", err)
+	}
+	if id != 0 && r.dedup == DedupSkip {
+		log.Printf("[INFO] quarterly event for %d-Q%d already recorded, skipping", start.Year(), (int(start.Month())+2)/3)
+		return nil
+	}
+	if id != 0 {
+		_, err = r.db.Exec(`UPDATE quarterly_events SET
+			timestamp = ?, action = ?, amount = ?, regular_after = ?, reserve_after = ?, note = ?, symbol = ?
+			WHERE id = ?`,
+			now.Unix(), evt.Action, model.RoundAmount(evt.Amount),
+			model.RoundAmount(evt.RegularAfter), model.RoundAmount(evt.ReserveAfter), evt.Note, evt.Symbol,
+			id,
+		)
+		return err
+	}
+
+	_, err = r.db.Exec(`INSERT INTO quarterly_events
+		(timestamp, action, amount, regular_after, reserve_after, note, symbol)
+		VALUES (?,?,?,?,?,?,?)`,
+		now.Unix(), evt.Action, model.RoundAmount(evt.Amount),
+		model.RoundAmount(evt.RegularAfter), model.RoundAmount(evt.ReserveAfter), evt.Note, evt.Symbol,
+	)
+	return err
+}
+
+func (r *SQLiteRecorder) RecordCommandAudit(evt *CommandAuditEvent) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	_, err := r.db.Exec(`INSERT INTO command_audit
+		(timestamp, user_id, command, stage, detail)
+		VALUES (?,?,?,?,?)`,
+		r.at(evt.OccurredAt).Unix(), evt.UserID, evt.Command, evt.Stage, evt.Detail,
+	)
+	return err
+}
+
+// RecordWeeklyRecap upserts the recap for its week so re-runs don't skew the hit-rate.
+func (r *SQLiteRecorder) RecordWeeklyRecap(recap *WeeklyRecap) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	_, err := r.db.Exec(`INSERT OR REPLACE INTO weekly_recaps
+		(week_start, timestamp, suggested_price, total_score, week_open, week_close, bottom_fished, hit)
+		VALUES (?,?,?,?,?,?,?,?)`,
+		recap.WeekStart.Unix(), r.at(recap.OccurredAt).Unix(), recap.SuggestedPrice, model.RoundScore(recap.TotalScore),
+		recap.WeekOpen, recap.WeekClose, recap.BottomFished, recap.Hit,
+	)
+	return err
+}
+
+func (r *SQLiteRecorder) RecordDelivery(evt *DeliveryEvent) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	_, err := r.db.Exec(`INSERT INTO deliveries
+		(timestamp, message_type, priority, status, error, length)
 		VALUES (?,?,?,?,?,?)`,
-		time.Now().Unix(), evt.Action, evt.Amount,
-		evt.RegularAfter, evt.ReserveAfter, evt.Note,
+		r.at(evt.OccurredAt).Unix(), evt.MessageType, evt.Priority, evt.Status, evt.Error, evt.Length,
 	)
 	return err
 }
 
+// RecordTaskRun writes the stages of run in a single transaction, all under
+// the run's timestamp.
+func (r *SQLiteRecorder) RecordTaskRun(run *TaskRun) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	ts := r.at(run.OccurredAt).Unix()
+	for _, st := range run.Stages {
+		if _, err := tx.Exec(`INSERT INTO task_runs
+			(timestamp, task, stage, duration_ms, timed_out, cached)
+			VALUES (?,?,?,?,?,?)`,
+			ts, run.Task, st.Stage, st.Duration.Milliseconds(), st.TimedOut, st.Cached,
+		); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// RecordMetricSamples writes one sampling pass in a single transaction.
+func (r *SQLiteRecorder) RecordMetricSamples(samples []MetricSample) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	now := r.now() // one timestamp per pass unless a sample carries its own
+	for _, m := range samples {
+		ts := m.Timestamp
+		if ts.IsZero() {
+			ts = now
+		}
+		labels, err := json.Marshal(m.Labels)
+		if err != nil {
+			return fmt.Errorf("marshal labels for %s: %w", m.Name, err)
+		}
+		if _, err := tx.Exec(`INSERT INTO metrics_samples (timestamp, name, value, labels) VALUES (?,?,?,?)`,
+			ts.Unix(), m.Name, m.Value, string(labels),
+		); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// ImportTrades inserts trades in a single transaction, ignoring hashes already stored.
+func (r *SQLiteRecorder) ImportTrades(trades []Trade) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	now := r.now().Unix()
+	inserted := 0
+	for _, t := range trades {
+		res, err := tx.Exec(`INSERT OR IGNORE INTO trades (date, amount, price, units, hash, imported_at) VALUES (?,?,?,?,?,?)`,
+			t.Date.Unix(), model.RoundAmount(t.Amount), t.Price, t.Units, t.Hash, now,
+		)
+		if err != nil {
+			return 0, err
+		}
+		if n, _ := res.RowsAffected(); n > 0 {
+			inserted++
+		}
+	}
+	return inserted, tx.Commit()
+}
+
+func (r *SQLiteRecorder) RecordPurchase(p *Purchase) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	_, err := r.db.Exec(`INSERT INTO purchases (timestamp, symbol, amount, price, units) VALUES (?,?,?,?,?)`,
+		r.at(p.OccurredAt).Unix(), p.Symbol, model.RoundAmount(p.Amount), p.Price, p.Units,
+	)
+	return err
+}
+
+func (r *SQLiteRecorder) RecordSale(s *Sale) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	_, err := r.db.Exec(`INSERT INTO sales (timestamp, symbol, units, price, proceeds, realized_pnl, destination) VALUES (?,?,?,?,?,?,?)`,
+		r.at(s.OccurredAt).Unix(), s.Symbol, s.Units, s.Price, model.RoundAmount(s.Proceeds), model.RoundAmount(s.RealizedPnL), s.Destination,
+	)
+	return err
+}
+
+// SyncWarnings opens an occurrence for each kind not already active and ends
+// the active ones missing from kinds, in a single transaction.
+func (r *SQLiteRecorder) SyncWarnings(symbol string, kinds []model.WarningKind, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	active, err := activeWarnings(tx, symbol)
+	if err != nil {
+		return err
+	}
+	ts := r.at(at).Unix()
+	for _, k := range kinds {
+		if slices.Contains(active, k) {
+			continue
+		}
+		if _, err := tx.Exec(`INSERT INTO signal_warnings (symbol, kind, started_at) VALUES (?,?,?)`, symbol, string(k), ts); err != nil {
+			return err
+		}
+	}
+	for _, k := range active {
+		if slices.Contains(kinds, k) {
+			continue
+		}
+		if _, err := tx.Exec(`UPDATE signal_warnings SET ended_at = ? WHERE symbol = ? AND kind = ? AND ended_at IS NULL`, ts, symbol, string(k)); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// StoreBars replaces the cached bars dated on or after the first of bars in one
+// transaction.
+func (r *SQLiteRecorder) StoreBars(symbol, interval string, bars []model.OHLCV, fetchedAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if len(bars) == 0 {
+		return nil
+	}
+	if _, err := tx.Exec(`DELETE FROM bars_cache WHERE symbol = ? AND interval = ? AND date >= ?`,
+		symbol, interval, bars[0].Time.UTC().Format("2006-01-02")); err != nil {
+		return err
+	}
+	for _, b := range bars {
+		if _, err := tx.Exec(`INSERT OR REPLACE INTO bars_cache
+			(symbol, interval, date, time, open, high, low, close, volume, fetched_at)
+			VALUES (?,?,?,?,?,?,?,?,?,?)`,
+			symbol, interval, b.Time.UTC().Format("2006-01-02"), b.Time.Unix(),
+			b.Open, b.High, b.Low, b.Close, b.Volume, fetchedAt.Unix(),
+		); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// Intervals of archived bars; the current price lives in weekly_snapshots.
+const (
+	archiveDaily  = "1d"
+	archiveWeekly = "1wk"
+)
+
+// RecordPriceSeries stores series under snapshotID in a single transaction,
+// then deletes the bars of all but the keep most recent snapshots.
+func (r *SQLiteRecorder) RecordPriceSeries(snapshotID int64, series *model.PriceSeries, keep int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for interval, bars := range map[string][]model.OHLCV{archiveDaily: series.DailyBars, archiveWeekly: series.WeeklyBars} {
+		for _, b := range bars {
+			if _, err := tx.Exec(`INSERT OR REPLACE INTO bars_archive
+				(snapshot_id, symbol, interval, time, open, high, low, close, volume)
+				VALUES (?,?,?,?,?,?,?,?,?)`,
+				snapshotID, series.Symbol, interval, b.Time.Unix(), b.Open, b.High, b.Low, b.Close, b.Volume,
+			); err != nil {
+				return err
+			}
+		}
+	}
+	if _, err := tx.Exec(`DELETE FROM bars_archive WHERE snapshot_id NOT IN
+		(SELECT DISTINCT snapshot_id FROM bars_archive ORDER BY snapshot_id DESC LIMIT ?)`, keep); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *SQLiteRecorder) ArchivedPriceSeries(snapshotID int64) (*model.PriceSeries, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	rows, err := r.db.Query(`SELECT symbol, interval, time, open, high, low, close, volume FROM bars_archive
+		WHERE snapshot_id = ? ORDER BY interval, time`, snapshotID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var series *model.PriceSeries
+	for rows.Next() {
+		var symbol, interval string
+		var ts int64
+		var b model.OHLCV
+		if err := rows.Scan(&symbol, &interval, &ts, &b.Open, &b.High, &b.Low, &b.Close, &b.Volume); err != nil {
+			return nil, err
+		}
+		if series == nil {
+			series = &model.PriceSeries{Symbol: symbol}
+		}
+		b.Time = time.Unix(ts, 0).UTC()
+		if interval == archiveWeekly {
+			series.WeeklyBars = append(series.WeeklyBars, b)
+		} else {
+			series.DailyBars = append(series.DailyBars, b)
+		}
+	}
+	return series, rows.Err()
+}
+
+func (r *SQLiteRecorder) LoadBars(symbol, interval string) ([]model.OHLCV, time.Time, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	rows, err := r.db.Query(`SELECT time, open, high, low, close, volume, fetched_at FROM bars_cache
+		WHERE symbol = ? AND interval = ? ORDER BY date`, symbol, interval)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	defer rows.Close()
+
+	var bars []model.OHLCV
+	var fetched int64
+	for rows.Next() {
+		var b model.OHLCV
+		var ts int64
+		if err := rows.Scan(&ts, &b.Open, &b.High, &b.Low, &b.Close, &b.Volume, &fetched); err != nil {
+			return nil, time.Time{}, err
+		}
+		b.Time = time.Unix(ts, 0)
+		bars = append(bars, b)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, time.Time{}, err
+	}
+	if len(bars) == 0 {
+		return nil, time.Time{}, nil
+	}
+	return bars, time.Unix(fetched, 0), nil
+}
+
+const weeklySummaryColumns = `timestamp, current_price, total_score, tier_label, base_amount, final_amount, reserve_used`
+
+func scanWeeklySummary(row interface{ Scan(...any) error }) (*WeeklySnapshotSummary, error) {
+	var ts int64
+	var snap WeeklySnapshotSummary
+	if err := row.Scan(&ts, &snap.CurrentPrice, &snap.TotalScore, &snap.TierLabel,
+		&snap.BaseAmount, &snap.FinalAmount, &snap.ReserveUsed); err != nil {
+		return nil, err
+	}
+	snap.Timestamp = time.Unix(ts, 0)
+	return &snap, nil
+}
+
+func (r *SQLiteRecorder) FirstWeeklySnapshotSince(symbol string, since time.Time) (*WeeklySnapshotSummary, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	snap, err := scanWeeklySummary(r.db.QueryRow(`SELECT `+weeklySummaryColumns+`
+		FROM weekly_snapshots WHERE timestamp >= ? AND (symbol = ? OR symbol IS NULL)
+		ORDER BY timestamp ASC LIMIT 1`,
+		since.Unix(), symbol,
+	))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return snap, err
+}
+
+func (r *SQLiteRecorder) WeeklySnapshotsSince(symbol string, since time.Time) ([]WeeklySnapshotSummary, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	rows, err := r.db.Query(`SELECT `+weeklySummaryColumns+`
+		FROM weekly_snapshots WHERE timestamp >= ? AND (symbol = ? OR symbol IS NULL)
+		ORDER BY timestamp ASC`,
+		since.Unix(), symbol,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var snaps []WeeklySnapshotSummary
+	for rows.Next() {
+		snap, err := scanWeeklySummary(rows)
+		if err != nil {
+			return nil, err
+		}
+		snaps = append(snaps, *snap)
+	}
+	return snaps, rows.Err()
+}
+
+func (r *SQLiteRecorder) DailyChecksSince(since time.Time) ([]DailyCheckEvent, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	rows, err := r.db.Query(`SELECT timestamp, daily_rsi, weekly_rsi, price, event_type, amount, total_score, COALESCE(note, ''), COALESCE(level, 0), COALESCE(sell_pct, 0)
+		FROM daily_checks WHERE timestamp >= ? ORDER BY timestamp ASC`,
+		since.Unix(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []DailyCheckEvent
+	for rows.Next() {
+		var ts int64
+		var evt DailyCheckEvent
+		if err := rows.Scan(&ts, &evt.DailyRSI, &evt.WeeklyRSI, &evt.Price,
+			&evt.EventType, &evt.Amount, &evt.TotalScore, &evt.Note, &evt.Level, &evt.SellPercent); err != nil {
+			return nil, err
+		}
+		evt.Timestamp = time.Unix(ts, 0)
+		events = append(events, evt)
+	}
+	return events, rows.Err()
+}
+
+func (r *SQLiteRecorder) FundHistorySince(since time.Time) ([]FundHistoryPoint, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	rows, err := r.db.Query(`SELECT timestamp, event_type, COALESCE(symbol, ''), regular_after, reserve_after
+		FROM fund_history WHERE timestamp >= ? ORDER BY timestamp ASC, id ASC`,
+		since.Unix(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var points []FundHistoryPoint
+	for rows.Next() {
+		var ts int64
+		var p FundHistoryPoint
+		if err := rows.Scan(&ts, &p.EventType, &p.Symbol, &p.RegularAfter, &p.ReserveAfter); err != nil {
+			return nil, err
+		}
+		p.Timestamp = time.Unix(ts, 0)
+		points = append(points, p)
+	}
+	return points, rows.Err()
+}
+
+func (r *SQLiteRecorder) FundEventsBetween(from, to time.Time) ([]FundEvent, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	rows, err := r.db.Query(`SELECT timestamp, event_type, regular_before, regular_after,
+		reserve_before, reserve_after, amount, COALESCE(note, ''), COALESCE(symbol, '')
+		FROM fund_history WHERE timestamp >= ? AND timestamp < ? ORDER BY timestamp ASC, id ASC`,
+		from.Unix(), to.Unix(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []FundEvent
+	for rows.Next() {
+		var ts int64
+		var evt FundEvent
+		if err := rows.Scan(&ts, &evt.EventType, &evt.RegularBefore, &evt.RegularAfter,
+			&evt.ReserveBefore, &evt.ReserveAfter, &evt.Amount, &evt.Note, &evt.Symbol); err != nil {
+			return nil, err
+		}
+		evt.OccurredAt = time.Unix(ts, 0)
+		events = append(events, evt)
+	}
+	return events, rows.Err()
+}
+
+func (r *SQLiteRecorder) TradesBetween(from, to time.Time) ([]Trade, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	rows, err := r.db.Query(`SELECT date, amount, price, units, hash
+		FROM trades WHERE date >= ? AND date < ? ORDER BY date ASC, id ASC`,
+		from.Unix(), to.Unix(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var trades []Trade
+	for rows.Next() {
+		var ts int64
+		var t Trade
+		if err := rows.Scan(&ts, &t.Amount, &t.Price, &t.Units, &t.Hash); err != nil {
+			return nil, err
+		}
+		t.Date = time.Unix(ts, 0)
+		trades = append(trades, t)
+	}
+	return trades, rows.Err()
+}
+
+func (r *SQLiteRecorder) PurchasesSince(symbol string, since time.Time) ([]Purchase, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	rows, err := r.db.Query(`SELECT timestamp, symbol, amount, price, units
+		FROM purchases WHERE symbol = ? AND timestamp >= ? ORDER BY timestamp ASC, id ASC`,
+		symbol, since.Unix(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var purchases []Purchase
+	for rows.Next() {
+		var ts int64
+		var p Purchase
+		if err := rows.Scan(&ts, &p.Symbol, &p.Amount, &p.Price, &p.Units); err != nil {
+			return nil, err
+		}
+		p.OccurredAt = time.Unix(ts, 0)
+		purchases = append(purchases, p)
+	}
+	return purchases, rows.Err()
+}
+
+func (r *SQLiteRecorder) SalesSince(symbol string, since time.Time) ([]Sale, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	rows, err := r.db.Query(`SELECT timestamp, symbol, units, price, proceeds, realized_pnl, destination
+		FROM sales WHERE symbol = ? AND timestamp >= ? ORDER BY timestamp ASC, id ASC`,
+		symbol, since.Unix(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var sales []Sale
+	for rows.Next() {
+		var ts int64
+		var s Sale
+		if err := rows.Scan(&ts, &s.Symbol, &s.Units, &s.Price, &s.Proceeds, &s.RealizedPnL, &s.Destination); err != nil {
+			return nil, err
+		}
+		s.OccurredAt = time.Unix(ts, 0)
+		sales = append(sales, s)
+	}
+	return sales, rows.Err()
+}
+
+func (r *SQLiteRecorder) RecentWeeklyTiers(symbol string, n int) ([]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	rows, err := r.db.Query(`SELECT COALESCE(tier_label, '') FROM weekly_snapshots
+		WHERE symbol = ? OR symbol IS NULL ORDER BY timestamp DESC, id DESC LIMIT ?`, symbol, n)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tiers []string
+	for rows.Next() {
+		var label string
+		if err := rows.Scan(&label); err != nil {
+			return nil, err
+		}
+		tiers = append(tiers, label)
+	}
+	return tiers, rows.Err()
+}
+
+func (r *SQLiteRecorder) ActiveWarnings(symbol string) ([]model.WarningKind, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return activeWarnings(r.db, symbol)
+}
+
+// activeWarnings reads the kinds of the open warning occurrences of symbol
+// through q, a database or a transaction.
+func activeWarnings(q interface {
+	Query(query string, args ...any) (*sql.Rows, error)
+}, symbol string) ([]model.WarningKind, error) {
+	rows, err := q.Query(`SELECT DISTINCT kind FROM signal_warnings
+		WHERE symbol = ? AND ended_at IS NULL ORDER BY kind`, symbol)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var kinds []model.WarningKind
+	for rows.Next() {
+		var kind string
+		if err := rows.Scan(&kind); err != nil {
+			return nil, err
+		}
+		kinds = append(kinds, model.WarningKind(kind))
+	}
+	return kinds, rows.Err()
+}
+
+func (r *SQLiteRecorder) RecentWeeklyRecaps(n int) ([]WeeklyRecap, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	rows, err := r.db.Query(`SELECT week_start, suggested_price, total_score, week_open, week_close, bottom_fished, hit
+		FROM weekly_recaps ORDER BY week_start DESC LIMIT ?`, n)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var recaps []WeeklyRecap
+	for rows.Next() {
+		var ws int64
+		var recap WeeklyRecap
+		if err := rows.Scan(&ws, &recap.SuggestedPrice, &recap.TotalScore,
+			&recap.WeekOpen, &recap.WeekClose, &recap.BottomFished, &recap.Hit); err != nil {
+			return nil, err
+		}
+		recap.WeekStart = time.Unix(ws, 0)
+		recaps = append(recaps, recap)
+	}
+	return recaps, rows.Err()
+}
+
+func (r *SQLiteRecorder) MetricSamplesSince(since time.Time) ([]MetricSample, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	rows, err := r.db.Query(`SELECT timestamp, name, value, COALESCE(labels, '')
+		FROM metrics_samples WHERE timestamp >= ? ORDER BY timestamp ASC, id ASC`,
+		since.Unix(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var samples []MetricSample
+	for rows.Next() {
+		var ts int64
+		var labels string
+		var m MetricSample
+		if err := rows.Scan(&ts, &m.Name, &m.Value, &labels); err != nil {
+			return nil, err
+		}
+		if labels != "" && labels != "null" {
+			if err := json.Unmarshal([]byte(labels), &m.Labels); err != nil {
+				return nil, fmt.Errorf("unmarshal labels for %s: %w", m.Name, err)
+			}
+		}
+		m.Timestamp = time.Unix(ts, 0)
+		samples = append(samples, m)
+	}
+	return samples, rows.Err()
+}
+
+func (r *SQLiteRecorder) PruneMetricSamples(before time.Time) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	res, err := r.db.Exec(`DELETE FROM metrics_samples WHERE timestamp < ?`, before.Unix())
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
 func (r *SQLiteRecorder) Close() error {
 	log.Println("[INFO] closing sqlite recorder")
 	return r.db.Close()
@@ -0,0 +1,738 @@
+package recorder
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"MarketSentinel/internal/model"
+)
+
+func newTestRecorder(t *testing.T, policy DedupPolicy, now *time.Time) *SQLiteRecorder {
+	t.Helper()
+	r, err := NewSQLiteRecorder(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { r.Close() })
+	r.SetDedupPolicy(policy)
+	r.now = func() time.Time { return *now }
+	return r
+}
+
+func countRows(t *testing.T, r *SQLiteRecorder, table string) (n int, lastAmount float64) {
+	t.Helper()
+	col := map[string]string{"monthly_events": "regular_added", "quarterly_events": "amount"}[table]
+	if err := r.db.QueryRow(`SELECT COUNT(*) FROM ` + table).Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+	if n > 0 {
+		if err := r.db.QueryRow(`SELECT ` + col + ` FROM ` + table + ` ORDER BY timestamp DESC, id DESC LIMIT 1`).Scan(&lastAmount); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return n, lastAmount
+}
+
+func TestRecordMonthly_Dedup(t *testing.T) {
+	tests := []struct {
+		policy     DedupPolicy
+		wantRows   int
+		wantAmount float64
+	}{
+		{DedupUpdate, 1, 2},
+		{DedupSkip, 1, 1},
+		{DedupOff, 2, 2},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.policy), func(t *testing.T) {
+			now := time.Date(2025, 3, 1, 9, 0, 0, 0, time.Local)
+			r := newTestRecorder(t, tt.policy, &now)
+
+			// Re-run later in the same month, e.g. after restoring the state file.
+			r.RecordMonthly(&MonthlyEvent{RegularAdded: 1, Symbol: "SPX500"})
+			now = now.AddDate(0, 0, 20)
+			if err := r.RecordMonthly(&MonthlyEvent{RegularAdded: 2, Symbol: "SPX500"}); err != nil {
+				t.Fatal(err)
+			}
+			if n, amt := countRows(t, r, "monthly_events"); n != tt.wantRows || amt != tt.wantAmount {
+				t.Errorf("got %d rows, last amount %.0f; want %d, %.0f", n, amt, tt.wantRows, tt.wantAmount)
+			}
+
+			// A new month always inserts.
+			now = time.Date(2025, 4, 1, 9, 0, 0, 0, time.Local)
+			r.RecordMonthly(&MonthlyEvent{RegularAdded: 3, Symbol: "SPX500"})
+			if n, _ := countRows(t, r, "monthly_events"); n != tt.wantRows+1 {
+				t.Errorf("expected a new row for April, got %d rows", n)
+			}
+		})
+	}
+}
+
+func TestRecordMonthly_DedupPerSymbol(t *testing.T) {
+	now := time.Date(2025, 3, 1, 9, 0, 0, 0, time.Local)
+	r := newTestRecorder(t, DedupUpdate, &now)
+	r.RecordMonthly(&MonthlyEvent{RegularAdded: 1, Symbol: "SPX500"})
+	r.RecordMonthly(&MonthlyEvent{RegularAdded: 1, Symbol: "NDX"})
+	if n, _ := countRows(t, r, "monthly_events"); n != 2 {
+		t.Errorf("expected one row per symbol, got %d", n)
+	}
+}
+
+func TestRecordMonthly_ScoreStdDev(t *testing.T) {
+	now := time.Date(2025, 3, 1, 9, 0, 0, 0, time.Local)
+	r := newTestRecorder(t, DedupUpdate, &now)
+	r.RecordMonthly(&MonthlyEvent{AvgScore: 0.4, ScoreStdDev: 0.3, Symbol: "SPX500"})
+	r.RecordMonthly(&MonthlyEvent{AvgScore: -0.2, ScoreStdDev: 1.25, Symbol: "SPX500"})
+	var avg, stddev float64
+	if err := r.db.QueryRow(`SELECT avg_score, score_stddev FROM monthly_events`).Scan(&avg, &stddev); err != nil {
+		t.Fatal(err)
+	}
+	if avg != -0.2 || stddev != 1.25 {
+		t.Errorf("stored avg %v, stddev %v; want the update's -0.2 and 1.25", avg, stddev)
+	}
+}
+
+func TestRecordQuarterly_Dedup(t *testing.T) {
+	now := time.Date(2025, 4, 1, 9, 0, 0, 0, time.Local)
+	r := newTestRecorder(t, DedupUpdate, &now)
+
+	r.RecordQuarterly(&QuarterlyEvent{Action: "NO_ACTION", Amount: 0, Symbol: "SPX500"})
+	now = time.Date(2025, 6, 30, 23, 0, 0, 0, time.Local)
+	r.RecordQuarterly(&QuarterlyEvent{Action: "TRANSFER_EXCESS", Amount: 500, Symbol: "SPX500"})
+	if n, amt := countRows(t, r, "quarterly_events"); n != 1 || amt != 500 {
+		t.Errorf("expected single updated Q2 row, got %d rows, amount %.0f", n, amt)
+	}
+
+	now = time.Date(2025, 7, 1, 9, 0, 0, 0, time.Local)
+	r.RecordQuarterly(&QuarterlyEvent{Action: "NO_ACTION", Symbol: "SPX500"})
+	if n, _ := countRows(t, r, "quarterly_events"); n != 2 {
+		t.Errorf("expected a new row for Q3, got %d", n)
+	}
+}
+
+func TestPeriodBounds(t *testing.T) {
+	ts := time.Date(2025, 8, 15, 12, 0, 0, 0, time.UTC)
+	if start, end := monthBounds(ts); !start.Equal(time.Date(2025, 8, 1, 0, 0, 0, 0, time.UTC)) || !end.Equal(time.Date(2025, 9, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("month bounds %v..%v", start, end)
+	}
+	if start, end := quarterBounds(ts); !start.Equal(time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC)) || !end.Equal(time.Date(2025, 10, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("quarter bounds %v..%v", start, end)
+	}
+}
+
+func TestRecord_OccurredAt(t *testing.T) {
+	now := time.Date(2025, 3, 5, 9, 0, 0, 0, time.Local)
+	r := newTestRecorder(t, DedupUpdate, &now)
+
+	// A Monday slot caught up on Wednesday keeps the Monday timestamp.
+	slot := time.Date(2025, 3, 3, 9, 0, 0, 0, time.Local)
+	if err := r.RecordFundEvent(&FundEvent{EventType: "WEEKLY", OccurredAt: slot}); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.RecordFundEvent(&FundEvent{EventType: "MONTHLY"}); err != nil {
+		t.Fatal(err)
+	}
+	history, err := r.FundHistorySince(time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 2 || !history[0].Timestamp.Equal(slot) || !history[1].Timestamp.Equal(now) {
+		t.Fatalf("unexpected timestamps: %+v", history)
+	}
+
+	// The event time, not the recorder clock, selects the dedup period.
+	r.RecordMonthly(&MonthlyEvent{RegularAdded: 1, Symbol: "SPX500", OccurredAt: time.Date(2025, 2, 1, 9, 0, 0, 0, time.Local)})
+	r.RecordMonthly(&MonthlyEvent{RegularAdded: 2, Symbol: "SPX500"})
+	if n, _ := countRows(t, r, "monthly_events"); n != 2 {
+		t.Errorf("expected February and March rows, got %d", n)
+	}
+}
+
+func TestRecordFundEvent_Symbol(t *testing.T) {
+	now := time.Date(2025, 3, 3, 9, 0, 0, 0, time.Local)
+	r := newTestRecorder(t, DedupUpdate, &now)
+	for _, sym := range []string{"SPX500", "NDX100"} {
+		if err := r.RecordFundEvent(&FundEvent{EventType: "WEEKLY", Symbol: sym, RegularBefore: 1000}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	events, err := r.FundEventsBetween(now, now.Add(time.Second))
+	if err != nil || len(events) != 2 || events[0].Symbol != "SPX500" || events[1].Symbol != "NDX100" {
+		t.Fatalf("events %+v (err %v)", events, err)
+	}
+	history, err := r.FundHistorySince(now)
+	if err != nil || len(history) != 2 || history[1].Symbol != "NDX100" {
+		t.Errorf("history %+v (err %v)", history, err)
+	}
+}
+
+func TestImportTrades_Idempotent(t *testing.T) {
+	now := time.Date(2025, 4, 1, 9, 0, 0, 0, time.Local)
+	r := newTestRecorder(t, DedupUpdate, &now)
+	trades := []Trade{
+		{Date: time.Date(2025, 3, 3, 0, 0, 0, 0, time.Local), Amount: 1000, Price: 5000, Units: 0.2, Hash: "a"},
+		{Date: time.Date(2025, 3, 10, 0, 0, 0, 0, time.Local), Amount: 1500, Price: 5000, Units: 0.3, Hash: "b"},
+	}
+	if n, err := r.ImportTrades(trades); err != nil || n != 2 {
+		t.Fatalf("first import: n=%d err=%v", n, err)
+	}
+	if n, err := r.ImportTrades(append(trades, Trade{Date: trades[0].Date, Amount: 1, Price: 1, Units: 1, Hash: "c"})); err != nil || n != 1 {
+		t.Fatalf("second import: n=%d err=%v", n, err)
+	}
+	got, err := r.TradesBetween(time.Date(2025, 3, 1, 0, 0, 0, 0, time.Local), now)
+	if err != nil || len(got) != 3 || got[2].Hash != "b" {
+		t.Errorf("unexpected trades: %+v (err %v)", got, err)
+	}
+}
+
+func TestRecordPurchase(t *testing.T) {
+	now := time.Date(2025, 4, 1, 9, 0, 0, 0, time.Local)
+	r := newTestRecorder(t, DedupUpdate, &now)
+	if err := r.RecordPurchase(&Purchase{Symbol: "SPX500", Amount: 1000.004, Price: 5000, Units: 0.2}); err != nil {
+		t.Fatal(err)
+	}
+
+	var ts int64
+	var symbol string
+	var amount, price, units float64
+	if err := r.db.QueryRow(`SELECT timestamp, symbol, amount, price, units FROM purchases`).Scan(&ts, &symbol, &amount, &price, &units); err != nil {
+		t.Fatal(err)
+	}
+	if ts != now.Unix() || symbol != "SPX500" || amount != 1000 || price != 5000 || units != 0.2 {
+		t.Errorf("stored %d %s %v %v %v", ts, symbol, amount, price, units)
+	}
+}
+
+func TestRecordSale(t *testing.T) {
+	now := time.Date(2025, 4, 1, 9, 0, 0, 0, time.Local)
+	r := newTestRecorder(t, DedupUpdate, &now)
+	for _, s := range []*Sale{
+		{Symbol: "SPX500", Units: 0.1, Price: 6000, Proceeds: 600.004, RealizedPnL: 100, Destination: "reserve",
+			OccurredAt: now.AddDate(0, -2, 0)},
+		{Symbol: "SPX500", Units: 0.05, Price: 5500, Proceeds: 275, RealizedPnL: 25, Destination: "external"},
+		{Symbol: "QQQ", Units: 1, Price: 400, Proceeds: 400, Destination: "external"},
+	} {
+		if err := r.RecordSale(s); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	sales, err := r.SalesSince("SPX500", time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sales) != 2 || sales[0].Proceeds != 600 || sales[0].Destination != "reserve" || !sales[1].OccurredAt.Equal(now) {
+		t.Errorf("sales = %+v", sales)
+	}
+	if recent, _ := r.SalesSince("SPX500", now.AddDate(0, -1, 0)); len(recent) != 1 || recent[0].RealizedPnL != 25 {
+		t.Errorf("sales in the last month = %+v", recent)
+	}
+}
+
+func TestRecordTaskRun(t *testing.T) {
+	now := time.Date(2025, 3, 3, 9, 0, 0, 0, time.Local)
+	r := newTestRecorder(t, DedupOff, &now)
+	run := &TaskRun{Task: "weekly", OccurredAt: now.Add(-time.Minute), Stages: []StageRun{
+		{Stage: "daily fetch", Duration: 1200 * time.Millisecond},
+		{Stage: "weekly fetch", Duration: 5 * time.Second, TimedOut: true, Cached: true},
+		{Stage: "send", Duration: 300 * time.Millisecond},
+	}}
+	if err := r.RecordTaskRun(run); err != nil {
+		t.Fatal(err)
+	}
+
+	rows, err := r.db.Query(`SELECT timestamp, task, stage, duration_ms, timed_out, cached FROM task_runs ORDER BY id`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	var got []string
+	for rows.Next() {
+		var ts, ms int64
+		var task, stage string
+		var timedOut, cached bool
+		if err := rows.Scan(&ts, &task, &stage, &ms, &timedOut, &cached); err != nil {
+			t.Fatal(err)
+		}
+		if ts != run.OccurredAt.Unix() || task != "weekly" {
+			t.Errorf("row at %d for %q", ts, task)
+		}
+		got = append(got, fmt.Sprintf("%s=%d/%v/%v", stage, ms, timedOut, cached))
+	}
+	if want := "daily fetch=1200/false/false weekly fetch=5000/true/true send=300/false/false"; strings.Join(got, " ") != want {
+		t.Errorf("task_runs = %v, want %s", got, want)
+	}
+}
+
+func TestRecordMonthly_Performance(t *testing.T) {
+	now := time.Date(2025, 3, 1, 9, 0, 0, 0, time.Local)
+	r := newTestRecorder(t, DedupOff, &now)
+	for _, p := range []*model.Performance{
+		nil,
+		{Invested: 2000, XIRR: math.NaN()},
+		{Price: 5000, Invested: 2000, Value: 2250, UnrealizedPnL: 250, ROI: 0.125, XIRR: 0.17, RealizedYTD: 80, SalesYTD: 1},
+		{RealizedYTD: -40.004, SalesYTD: 2, XIRR: 0.05}, // sold out
+	} {
+		if err := r.RecordMonthly(&MonthlyEvent{Symbol: "SPX500", Performance: p}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	rows, err := r.db.Query(`SELECT price, invested, market_value, unrealized_pnl, roi, xirr, realized_ytd FROM monthly_events ORDER BY id`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	var got []string
+	for rows.Next() {
+		var cols [7]sql.NullFloat64
+		if err := rows.Scan(&cols[0], &cols[1], &cols[2], &cols[3], &cols[4], &cols[5], &cols[6]); err != nil {
+			t.Fatal(err)
+		}
+		var parts []string
+		for _, c := range cols {
+			if c.Valid {
+				parts = append(parts, fmt.Sprint(c.Float64))
+			} else {
+				parts = append(parts, "NULL")
+			}
+		}
+		got = append(got, strings.Join(parts, " "))
+	}
+	want := []string{
+		"NULL NULL NULL NULL NULL NULL NULL",
+		"NULL 2000 NULL NULL NULL NULL 0",
+		"5000 2000 2250 250 0.125 0.17 80",
+		"NULL NULL NULL NULL NULL 0.05 -40",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got rows\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestRecordWeekly_BoundaryDistances(t *testing.T) {
+	now := time.Date(2025, 3, 3, 9, 0, 0, 0, time.Local)
+	r := newTestRecorder(t, DedupUpdate, &now)
+
+	for _, b := range []model.BoundaryInfo{
+		{UpLabel: "重仓买入", DistUp: 0.175, DownLabel: "正常定投", DistDown: 0.225},
+		{DownLabel: "重仓买入", DistDown: 1.5}, // top tier, nothing above
+	} {
+		if err := r.RecordWeekly(&WeeklySnapshot{
+			Indicators: &model.MarketIndicators{},
+			Signal:     &model.TradeSignal{Boundary: b},
+			FundState:  &model.FundState{},
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	rows, err := r.db.Query(`SELECT boundary_dist_up, boundary_dist_down FROM weekly_snapshots ORDER BY id`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	var got []string
+	for rows.Next() {
+		var up, down sql.NullFloat64
+		if err := rows.Scan(&up, &down); err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, fmt.Sprintf("%v/%.3f %v/%.3f", up.Valid, up.Float64, down.Valid, down.Float64))
+	}
+	want := []string{"true/0.175 true/0.225", "false/0.000 true/1.500"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestBarsCache_ReplacesFromFirstBar(t *testing.T) {
+	now := time.Date(2024, 3, 5, 12, 0, 0, 0, time.UTC)
+	r := newTestRecorder(t, DedupUpdate, &now)
+	day := func(d int, close float64) model.OHLCV {
+		return model.OHLCV{Time: time.Date(2024, 3, d, 14, 30, 0, 0, time.UTC), Close: close}
+	}
+
+	if bars, fetched, err := r.LoadBars("SPX500", "1d"); err != nil || bars != nil || !fetched.IsZero() {
+		t.Fatalf("empty cache: %v %v %v", bars, fetched, err)
+	}
+	if err := r.StoreBars("SPX500", "1d", []model.OHLCV{day(1, 100), day(4, 101), day(5, 102)}, now); err != nil {
+		t.Fatal(err)
+	}
+	// A refetch from 03-04 replaces 03-04 and drops the 03-05 bar upstream no
+	// longer reports.
+	later := now.Add(time.Hour)
+	if err := r.StoreBars("SPX500", "1d", []model.OHLCV{day(4, 101.5)}, later); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.StoreBars("SPX500", "1w", []model.OHLCV{day(4, 999)}, later); err != nil {
+		t.Fatal(err)
+	}
+
+	bars, fetched, err := r.LoadBars("SPX500", "1d")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(bars) != 2 || bars[0].Close != 100 || bars[1].Close != 101.5 || !bars[1].Time.Equal(day(4, 0).Time) {
+		t.Errorf("unexpected bars: %+v", bars)
+	}
+	if !fetched.Equal(later) {
+		t.Errorf("fetched = %v, want %v", fetched, later)
+	}
+}
+
+func TestRecordPriceSeries_KeepsRecentSnapshots(t *testing.T) {
+	now := time.Date(2024, 3, 4, 8, 0, 0, 0, time.UTC)
+	r := newTestRecorder(t, DedupUpdate, &now)
+
+	var ids []int64
+	for week := 0; week < 3; week++ {
+		snap := &WeeklySnapshot{
+			Symbol:     "SPX500",
+			Indicators: &model.MarketIndicators{},
+			Signal:     &model.TradeSignal{},
+			FundState:  &model.FundState{},
+		}
+		if err := r.RecordWeekly(snap); err != nil {
+			t.Fatal(err)
+		}
+		close := float64(100 + week)
+		series := &model.PriceSeries{
+			Symbol:     "SPX500",
+			DailyBars:  []model.OHLCV{{Time: now.AddDate(0, 0, -1), Close: close - 1}, {Time: now, Close: close}},
+			WeeklyBars: []model.OHLCV{{Time: now, Close: close}},
+		}
+		if err := r.RecordPriceSeries(snap.ID, series, 2); err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, snap.ID)
+		now = now.AddDate(0, 0, 7)
+	}
+
+	if ids[0] == 0 || ids[0] == ids[1] {
+		t.Fatalf("snapshot ids not assigned: %v", ids)
+	}
+	if s, err := r.ArchivedPriceSeries(ids[0]); err != nil || s != nil {
+		t.Errorf("oldest snapshot's bars not pruned: %+v %v", s, err)
+	}
+	s, err := r.ArchivedPriceSeries(ids[2])
+	if err != nil || s == nil {
+		t.Fatalf("latest snapshot's bars missing: %v", err)
+	}
+	if s.Symbol != "SPX500" || len(s.DailyBars) != 2 || len(s.WeeklyBars) != 1 ||
+		s.DailyBars[0].Close != 101 || s.DailyBars[1].Close != 102 || s.WeeklyBars[0].Close != 102 {
+		t.Errorf("unexpected archived series: %+v", s)
+	}
+}
+
+func TestRecordWeekly_FXRate(t *testing.T) {
+	now := time.Date(2024, 3, 4, 8, 0, 0, 0, time.UTC)
+	r := newTestRecorder(t, DedupUpdate, &now)
+	for _, rate := range []float64{7.2, 0} {
+		if err := r.RecordWeekly(&WeeklySnapshot{
+			Indicators: &model.MarketIndicators{FXRate: rate},
+			Signal:     &model.TradeSignal{},
+			FundState:  &model.FundState{},
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	rows, err := r.db.Query(`SELECT fx_rate FROM weekly_snapshots ORDER BY id`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	var got []sql.NullFloat64
+	for rows.Next() {
+		var v sql.NullFloat64
+		if err := rows.Scan(&v); err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, v)
+	}
+	if len(got) != 2 || got[0].Float64 != 7.2 || got[1].Valid {
+		t.Errorf("fx_rate = %+v, want 7.2 then NULL", got)
+	}
+}
+
+func TestRecordWeekly_VolatilityColumn(t *testing.T) {
+	now := time.Date(2024, 3, 4, 8, 0, 0, 0, time.UTC)
+	r := newTestRecorder(t, DedupUpdate, &now)
+	if err := r.RecordWeekly(&WeeklySnapshot{
+		Indicators: &model.MarketIndicators{},
+		Signal: &model.TradeSignal{Factors: []model.FactorScore{
+			{ID: model.FactorTrend, Weighted: -0.14},
+			{ID: model.FactorVolatility, Weighted: 0.18},
+		}},
+		FundState: &model.FundState{},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	var trend, vol float64
+	if err := r.db.QueryRow(`SELECT factor5_score, factor6_score FROM weekly_snapshots`).Scan(&trend, &vol); err != nil {
+		t.Fatal(err)
+	}
+	if trend != -0.14 || vol != 0.18 {
+		t.Errorf("factor5_score, factor6_score = %v, %v, want -0.14, 0.18", trend, vol)
+	}
+}
+
+func TestRecordWeekly_Regime(t *testing.T) {
+	now := time.Date(2024, 3, 4, 8, 0, 0, 0, time.UTC)
+	r := newTestRecorder(t, DedupOff, &now)
+	for _, regime := range []model.Regime{model.RegimeBear, model.RegimeUnknown} {
+		if err := r.RecordWeekly(&WeeklySnapshot{
+			Indicators: &model.MarketIndicators{},
+			Signal:     &model.TradeSignal{Regime: regime},
+			FundState:  &model.FundState{},
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	rows, err := r.db.Query(`SELECT regime FROM weekly_snapshots ORDER BY id`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	var got []sql.NullString
+	for rows.Next() {
+		var v sql.NullString
+		if err := rows.Scan(&v); err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, v)
+	}
+	if len(got) != 2 || got[0].String != "BEAR" || got[1].Valid {
+		t.Errorf("regime = %+v, want BEAR then NULL", got)
+	}
+}
+
+func TestRecordWeekly_Confidence(t *testing.T) {
+	now := time.Date(2024, 3, 4, 8, 0, 0, 0, time.UTC)
+	r := newTestRecorder(t, DedupUpdate, &now)
+	if err := r.RecordWeekly(&WeeklySnapshot{
+		Indicators: &model.MarketIndicators{},
+		Signal:     &model.TradeSignal{TotalScore: 1.3, Confidence: 0.7841},
+		FundState:  &model.FundState{},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	var conf float64
+	if err := r.db.QueryRow(`SELECT confidence FROM weekly_snapshots`).Scan(&conf); err != nil || conf != 0.78 {
+		t.Errorf("confidence = %v (%v), want 0.78", conf, err)
+	}
+}
+
+func TestRecordWeekly_MA50wColumn(t *testing.T) {
+	now := time.Date(2024, 3, 4, 8, 0, 0, 0, time.UTC)
+	r := newTestRecorder(t, DedupOff, &now)
+	for _, factors := range [][]model.FactorScore{
+		{{ID: model.FactorMA50w, Weighted: 0.15, Extra: true}},
+		{{ID: model.FactorMA50w, Missing: "MA50w not computed", Extra: true}},
+		nil,
+	} {
+		if err := r.RecordWeekly(&WeeklySnapshot{
+			Indicators: &model.MarketIndicators{},
+			Signal:     &model.TradeSignal{Factors: factors},
+			FundState:  &model.FundState{},
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	rows, err := r.db.Query(`SELECT ma50w_score FROM weekly_snapshots ORDER BY id`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	var got []sql.NullFloat64
+	for rows.Next() {
+		var v sql.NullFloat64
+		if err := rows.Scan(&v); err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, v)
+	}
+	if len(got) != 3 || got[0].Float64 != 0.15 || got[1].Valid || got[2].Valid {
+		t.Errorf("ma50w_score = %+v, want 0.15 then NULL twice", got)
+	}
+}
+
+func TestRecordWeekly_StrategyVersion(t *testing.T) {
+	now := time.Date(2024, 3, 4, 8, 0, 0, 0, time.UTC)
+	r := newTestRecorder(t, DedupUpdate, &now)
+	if err := r.RecordWeekly(&WeeklySnapshot{
+		Indicators: &model.MarketIndicators{},
+		Signal:     &model.TradeSignal{StrategyVersion: "v1-23fd28a1"},
+		FundState:  &model.FundState{},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	var v string
+	if err := r.db.QueryRow(`SELECT strategy_version FROM weekly_snapshots`).Scan(&v); err != nil || v != "v1-23fd28a1" {
+		t.Errorf("strategy_version = %q (%v)", v, err)
+	}
+}
+
+func TestRecentWeeklyTiers(t *testing.T) {
+	now := time.Date(2024, 3, 4, 8, 0, 0, 0, time.UTC)
+	r := newTestRecorder(t, DedupOff, &now)
+	for i, s := range []struct {
+		symbol, label, cooldown string
+	}{
+		{"SPX500", "正常定投", ""},
+		{"SPX500", "极限重仓", ""},
+		{"NDX", "轻仓观望", ""},
+		{"SPX500", "重仓买入", "冷却期"},
+	} {
+		if err := r.RecordWeekly(&WeeklySnapshot{
+			Symbol:     s.symbol,
+			Indicators: &model.MarketIndicators{},
+			Signal:     &model.TradeSignal{Tier: model.InvestmentTier{Label: s.label}, CooldownNote: s.cooldown},
+			FundState:  &model.FundState{},
+			OccurredAt: now.AddDate(0, 0, 7*i),
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := r.RecentWeeklyTiers("SPX500", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got[0] != "重仓买入" || got[1] != "极限重仓" {
+		t.Errorf("recent tiers %v, want [重仓买入 极限重仓]", got)
+	}
+	if got, _ := r.RecentWeeklyTiers("SPX500", 5); len(got) != 3 || got[2] != "正常定投" {
+		t.Errorf("recent tiers %v, want all three of SPX500", got)
+	}
+
+	var demoted int
+	if err := r.db.QueryRow(`SELECT SUM(cooldown_demoted) FROM weekly_snapshots`).Scan(&demoted); err != nil {
+		t.Fatal(err)
+	}
+	if demoted != 1 {
+		t.Errorf("%d snapshots flagged as demoted, want 1", demoted)
+	}
+}
+
+func TestRecordSellRecommendation(t *testing.T) {
+	now := time.Date(2024, 3, 4, 8, 0, 0, 0, time.UTC)
+	r := newTestRecorder(t, DedupUpdate, &now)
+	if err := r.RecordWeekly(&WeeklySnapshot{
+		Indicators: &model.MarketIndicators{},
+		Signal:     &model.TradeSignal{TotalScore: -1.4, Sell: &model.SellRecommendation{Percent: 12.5}},
+		FundState:  &model.FundState{},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	var pct float64
+	if err := r.db.QueryRow(`SELECT sell_pct FROM weekly_snapshots`).Scan(&pct); err != nil || pct != 12.5 {
+		t.Errorf("sell_pct = %v (%v), want 12.5", pct, err)
+	}
+
+	if err := r.RecordDailyCheck(&DailyCheckEvent{EventType: "SELL", TotalScore: -1.4, SellPercent: 12.5, Note: "评分 -1.400"}); err != nil {
+		t.Fatal(err)
+	}
+	checks, err := r.DailyChecksSince(now.Add(-time.Hour))
+	if err != nil || len(checks) != 1 {
+		t.Fatalf("got %d checks (%v)", len(checks), err)
+	}
+	if c := checks[0]; c.EventType != "SELL" || c.SellPercent != 12.5 || c.Note != "评分 -1.400" {
+		t.Errorf("read back %+v", c)
+	}
+}
+
+func TestRecordWeekly_SmoothedScore(t *testing.T) {
+	now := time.Date(2024, 3, 4, 8, 0, 0, 0, time.UTC)
+	r := newTestRecorder(t, DedupOff, &now)
+	for _, sig := range []*model.TradeSignal{
+		{TotalScore: 0.78},
+		{TotalScore: 0.78, Smoothed: true, SmoothedScore: 0.815},
+	} {
+		if err := r.RecordWeekly(&WeeklySnapshot{Indicators: &model.MarketIndicators{}, Signal: sig, FundState: &model.FundState{}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	rows, err := r.db.Query(`SELECT total_score, smoothed_score FROM weekly_snapshots ORDER BY id`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	var got []sql.NullFloat64
+	for rows.Next() {
+		var total float64
+		var smoothed sql.NullFloat64
+		if err := rows.Scan(&total, &smoothed); err != nil {
+			t.Fatal(err)
+		}
+		if total != 0.78 {
+			t.Errorf("total_score %v, want the raw 0.78", total)
+		}
+		got = append(got, smoothed)
+	}
+	if len(got) != 2 || got[0].Valid || !got[1].Valid || got[1].Float64 != 0.815 {
+		t.Errorf("smoothed_score %+v, want NULL then 0.815", got)
+	}
+}
+
+func TestSyncWarnings(t *testing.T) {
+	now := time.Date(2024, 3, 4, 8, 0, 0, 0, time.UTC)
+	r := newTestRecorder(t, DedupOff, &now)
+	occurrences := func() (n int) {
+		t.Helper()
+		if err := r.db.QueryRow(`SELECT COUNT(*) FROM signal_warnings`).Scan(&n); err != nil {
+			t.Fatal(err)
+		}
+		return n
+	}
+	active := func(symbol string) []model.WarningKind {
+		t.Helper()
+		kinds, err := r.ActiveWarnings(symbol)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return kinds
+	}
+
+	if err := r.SyncWarnings("SPX500", []model.WarningKind{model.WarningDeathCross, model.WarningNew52wLow}, time.Time{}); err != nil {
+		t.Fatal(err)
+	}
+	// Repeating an active warning opens no second occurrence.
+	if err := r.SyncWarnings("SPX500", []model.WarningKind{model.WarningDeathCross, model.WarningNew52wLow}, time.Time{}); err != nil {
+		t.Fatal(err)
+	}
+	if n := occurrences(); n != 2 {
+		t.Errorf("%d occurrences, want 2", n)
+	}
+	if got := active("NDX"); len(got) != 0 {
+		t.Errorf("NDX active %v, want none", got)
+	}
+
+	// A cleared warning ends; when it returns it is a new occurrence.
+	if err := r.SyncWarnings("SPX500", []model.WarningKind{model.WarningDeathCross}, time.Time{}); err != nil {
+		t.Fatal(err)
+	}
+	if got := active("SPX500"); len(got) != 1 || got[0] != model.WarningDeathCross {
+		t.Errorf("active %v, want the death cross only", got)
+	}
+	if err := r.SyncWarnings("SPX500", []model.WarningKind{model.WarningDeathCross, model.WarningNew52wLow}, time.Time{}); err != nil {
+		t.Fatal(err)
+	}
+	if n := occurrences(); n != 3 {
+		t.Errorf("%d occurrences, want 3", n)
+	}
+	var ended int
+	if err := r.db.QueryRow(`SELECT COUNT(*) FROM signal_warnings WHERE ended_at IS NOT NULL`).Scan(&ended); err != nil || ended != 1 {
+		t.Errorf("%d ended occurrences (%v), want 1", ended, err)
+	}
+}
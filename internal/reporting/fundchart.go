@@ -0,0 +1,65 @@
+package reporting
+
+import (
+	"errors"
+
+	"MarketSentinel/internal/chart"
+	"MarketSentinel/internal/recorder"
+)
+
+// MinFundChartEvents is the minimum history length worth charting.
+const MinFundChartEvents = 4
+
+// ErrShortHistory means there isn't enough fund history to draw a useful chart.
+var ErrShortHistory = errors.New("fund history too short for chart")
+
+// FundBalanceChart turns fund_history rows into a two-line chart of the regular
+// and reserve pools, with markers at replenish and rebalance events. The rows
+// of a fund allocated across symbols are summed, each symbol at its latest
+// balances.
+func FundBalanceChart(history []recorder.FundHistoryPoint) (*chart.LineChart, error) {
+	if len(history) < MinFundChartEvents {
+		return nil, ErrShortHistory
+	}
+
+	regular := chart.Series{Name: "常规池", Color: chart.ColorBlue}
+	reserve := chart.Series{Name: "储备池", Color: chart.ColorOrange}
+	var markers []chart.Marker
+	latest := make(map[string]recorder.FundHistoryPoint)
+	for _, p := range history {
+		if p.Symbol != "" {
+			// Rows without a symbol are from before the allocation, when the
+			// whole fund was the primary symbol's.
+			delete(latest, "")
+		}
+		latest[p.Symbol] = p
+		var regularSum, reserveSum float64
+		for _, l := range latest {
+			regularSum += l.RegularAfter
+			reserveSum += l.ReserveAfter
+		}
+		regular.Points = append(regular.Points, chart.Point{Time: p.Timestamp, Value: regularSum})
+		reserve.Points = append(reserve.Points, chart.Point{Time: p.Timestamp, Value: reserveSum})
+
+		var m chart.Marker
+		switch p.EventType {
+		case "MONTHLY":
+			m = chart.Marker{Time: p.Timestamp, Label: "补充", Color: chart.ColorGreen}
+		case "QUARTERLY":
+			m = chart.Marker{Time: p.Timestamp, Label: "再平衡", Color: chart.ColorRed}
+		default:
+			continue
+		}
+		// One marker for the rows every symbol records for the same event.
+		if n := len(markers); n == 0 || markers[n-1] != m {
+			markers = append(markers, m)
+		}
+	}
+
+	return &chart.LineChart{
+		Width:   800,
+		Height:  400,
+		Series:  []chart.Series{regular, reserve},
+		Markers: markers,
+	}, nil
+}
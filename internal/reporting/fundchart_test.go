@@ -0,0 +1,89 @@
+package reporting
+
+import (
+	"bytes"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"MarketSentinel/internal/recorder"
+)
+
+func fundPoints(types ...string) []recorder.FundHistoryPoint {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	points := make([]recorder.FundHistoryPoint, len(types))
+	for i, typ := range types {
+		points[i] = recorder.FundHistoryPoint{
+			Timestamp:    start.AddDate(0, 0, 7*i),
+			EventType:    typ,
+			RegularAfter: 7000 - float64(i)*1000,
+			ReserveAfter: 3000 + float64(i)*100,
+		}
+	}
+	return points
+}
+
+func TestFundBalanceChart_ShortHistory(t *testing.T) {
+	if _, err := FundBalanceChart(fundPoints("WEEKLY", "MONTHLY", "WEEKLY")); !errors.Is(err, ErrShortHistory) {
+		t.Errorf("expected ErrShortHistory, got %v", err)
+	}
+	if _, err := FundBalanceChart(nil); !errors.Is(err, ErrShortHistory) {
+		t.Errorf("expected ErrShortHistory for empty history, got %v", err)
+	}
+}
+
+func TestFundBalanceChart_Series(t *testing.T) {
+	c, err := FundBalanceChart(fundPoints("WEEKLY", "MONTHLY", "BOTTOM_FISH", "QUARTERLY", "WEEKLY"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(c.Series) != 2 {
+		t.Fatalf("expected 2 series, got %d", len(c.Series))
+	}
+	regular, reserve := c.Series[0], c.Series[1]
+	if len(regular.Points) != 5 || len(reserve.Points) != 5 {
+		t.Fatalf("expected 5 points per series, got %d/%d", len(regular.Points), len(reserve.Points))
+	}
+	if regular.Points[1].Value != 6000 || reserve.Points[1].Value != 3100 {
+		t.Errorf("unexpected values at point 1: regular=%.0f reserve=%.0f", regular.Points[1].Value, reserve.Points[1].Value)
+	}
+	if len(c.Markers) != 2 || c.Markers[0].Label != "补充" || c.Markers[1].Label != "再平衡" {
+		t.Errorf("expected replenish and rebalance markers, got %+v", c.Markers)
+	}
+
+	png, err := c.RenderPNG()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(png, []byte("\x89PNG")) {
+		t.Error("expected PNG output")
+	}
+}
+
+func TestFundBalanceChart_SumsSymbols(t *testing.T) {
+	at := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	row := func(days int, typ, sym string, regular, reserve float64) recorder.FundHistoryPoint {
+		return recorder.FundHistoryPoint{Timestamp: at.AddDate(0, 0, days), EventType: typ, Symbol: sym, RegularAfter: regular, ReserveAfter: reserve}
+	}
+	c, err := FundBalanceChart([]recorder.FundHistoryPoint{
+		row(0, "WEEKLY", "", 7000, 3000),
+		// Allocated from here on: the flat fund became SPX500's.
+		row(31, "MONTHLY", "SPX500", 9000, 4000),
+		row(31, "MONTHLY", "NDX100", 3000, 1000),
+		row(35, "WEEKLY", "NDX100", 2500, 1000),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []float64
+	for _, p := range c.Series[0].Points {
+		got = append(got, p.Value)
+	}
+	if want := []float64{7000, 9000, 12000, 11500}; !slices.Equal(got, want) {
+		t.Errorf("regular = %v, want %v", got, want)
+	}
+	if len(c.Markers) != 1 {
+		t.Errorf("markers %+v, want one for the replenish of both symbols", c.Markers)
+	}
+}
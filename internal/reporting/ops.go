@@ -0,0 +1,92 @@
+package reporting
+
+import (
+	"sort"
+
+	"MarketSentinel/internal/metrics"
+	"MarketSentinel/internal/recorder"
+)
+
+// Metric names the scheduler records; the ops summary reads them back.
+const (
+	MetricCollectDuration = "collect_duration_seconds"
+	MetricCollectFailures = "collect_failures_total"
+	MetricNotifyFailures  = "notify_failures_total"
+	MetricTaskDuration    = "task_duration_seconds"
+)
+
+// OpsSummary condenses sampled operational metrics over a window.
+type OpsSummary struct {
+	Collections     int     // collector runs seen in the window
+	CollectP50      float64 // seconds, from the sampled per-window quantiles
+	CollectP95      float64
+	CollectFailures int
+	SendFailures    int
+}
+
+// SummarizeOps computes collect latency and failure counts from samples,
+// which must be ordered oldest first. Counters are cumulative, so counts are
+// the increase across the window with process restarts treated as resets.
+func SummarizeOps(samples []recorder.MetricSample) OpsSummary {
+	var sum OpsSummary
+	var p50s, p95s []float64
+	for _, m := range samples {
+		if m.Name != MetricCollectDuration {
+			continue
+		}
+		switch m.Labels["quantile"] {
+		case "0.5":
+			p50s = append(p50s, m.Value)
+		case "0.95":
+			p95s = append(p95s, m.Value)
+		}
+	}
+	// Each sampling window usually holds only a handful of collections, so the
+	// median of window medians and the worst window p95 are close enough.
+	if len(p50s) > 0 {
+		sort.Float64s(p50s)
+		sum.CollectP50 = p50s[len(p50s)/2]
+	}
+	for _, v := range p95s {
+		if v > sum.CollectP95 {
+			sum.CollectP95 = v
+		}
+	}
+
+	sum.Collections = int(increase(samples, MetricCollectDuration+"_count"))
+	sum.CollectFailures = int(increase(samples, MetricCollectFailures))
+	sum.SendFailures = int(increase(samples, MetricNotifyFailures))
+	return sum
+}
+
+// increase sums the growth of every series called name. Every sampling pass
+// writes all series, so a series present in the first pass of the window uses
+// it as baseline while one appearing later started from zero. A drop means the
+// process restarted and the counter began again from zero.
+func increase(samples []recorder.MetricSample, name string) float64 {
+	if len(samples) == 0 {
+		return 0
+	}
+	first := samples[0].Timestamp
+	last := make(map[string]float64)
+	total := 0.0
+	for _, m := range samples {
+		if m.Name != name {
+			continue
+		}
+		key := metrics.SeriesKey(m.Name, m.Labels)
+		prev, seen := last[key]
+		last[key] = m.Value
+		switch {
+		case !seen:
+			if m.Timestamp.After(first) {
+				total += m.Value
+			}
+		case m.Value >= prev:
+			total += m.Value - prev
+		default:
+			total += m.Value
+		}
+	}
+	return total
+}
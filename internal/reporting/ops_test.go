@@ -0,0 +1,43 @@
+package reporting
+
+import (
+	"testing"
+	"time"
+
+	"MarketSentinel/internal/recorder"
+)
+
+func TestSummarizeOps(t *testing.T) {
+	t0 := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	at := func(h int, name string, labels map[string]string, v float64) recorder.MetricSample {
+		return recorder.MetricSample{Timestamp: t0.Add(time.Duration(h) * time.Hour), Name: name, Labels: labels, Value: v}
+	}
+	p50 := map[string]string{"quantile": "0.5"}
+	p95 := map[string]string{"quantile": "0.95"}
+	weekly := map[string]string{"type": "weekly_report"}
+
+	samples := []recorder.MetricSample{
+		at(0, MetricCollectDuration+"_count", nil, 5),
+		at(0, MetricCollectFailures, nil, 1),
+		at(1, MetricCollectDuration+"_count", nil, 6),
+		at(1, MetricCollectDuration, p50, 2),
+		at(1, MetricCollectDuration, p95, 3),
+		at(1, MetricCollectFailures, nil, 2),
+		at(1, MetricNotifyFailures, weekly, 1), // new series: counts from zero
+		// Process restart: counters start over.
+		at(2, MetricCollectDuration+"_count", nil, 1),
+		at(2, MetricCollectDuration, p50, 4),
+		at(2, MetricCollectDuration, p95, 9),
+		at(2, MetricCollectFailures, nil, 0),
+	}
+
+	got := SummarizeOps(samples)
+	want := OpsSummary{Collections: 2, CollectP50: 4, CollectP95: 9, CollectFailures: 1, SendFailures: 1}
+	if got != want {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+
+	if empty := SummarizeOps(nil); empty != (OpsSummary{}) {
+		t.Errorf("expected zero summary, got %+v", empty)
+	}
+}
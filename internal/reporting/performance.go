@@ -0,0 +1,57 @@
+package reporting
+
+import (
+	"math"
+	"slices"
+	"time"
+
+	"MarketSentinel/internal/calculator"
+	"MarketSentinel/internal/model"
+	"MarketSentinel/internal/recorder"
+)
+
+// HoldingPerformance values h at price as of at: its unrealized P&L, ROI,
+// the XIRR of purchases paid out and sales received on their dates against
+// the value of the rest at at, and the P&L realized by sales in at's year.
+// It returns nil when there is neither a holding nor a sale, and leaves the
+// price-dependent fields unset when a holding has no positive price; once
+// everything was sold, price is not needed.
+func HoldingPerformance(symbol string, h model.Holding, purchases []recorder.Purchase, sales []recorder.Sale, price float64, at time.Time) *model.Performance {
+	if h.Units <= 0 && len(sales) == 0 {
+		return nil
+	}
+	p := &model.Performance{Symbol: symbol, Invested: h.TotalInvested, XIRR: math.NaN()}
+	for _, s := range sales {
+		if s.OccurredAt.Year() == at.Year() {
+			p.RealizedYTD += s.RealizedPnL
+			p.SalesYTD++
+		}
+	}
+	if h.Units > 0 {
+		if price <= 0 {
+			return p
+		}
+		p.Price = price
+		p.Value = h.Value(price)
+		p.UnrealizedPnL = h.UnrealizedPnL(price)
+		if h.TotalInvested > 0 {
+			p.ROI = p.UnrealizedPnL / h.TotalInvested
+		}
+	}
+
+	flows := make([]calculator.CashFlow, 0, len(purchases)+len(sales)+1)
+	for _, pu := range purchases {
+		flows = append(flows, calculator.CashFlow{Date: pu.OccurredAt, Amount: -pu.Amount})
+	}
+	for _, s := range sales {
+		flows = append(flows, calculator.CashFlow{Date: s.OccurredAt, Amount: s.Proceeds})
+	}
+	if len(purchases) > 0 {
+		// XIRR discounts from the first flow.
+		slices.SortStableFunc(flows, func(a, b calculator.CashFlow) int { return a.Date.Compare(b.Date) })
+		if irr, err := calculator.XIRR(append(flows, calculator.CashFlow{Date: at, Amount: p.Value})); err == nil {
+			p.XIRR = irr
+		}
+	}
+	return p
+}
@@ -0,0 +1,74 @@
+package reporting
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"MarketSentinel/internal/model"
+	"MarketSentinel/internal/recorder"
+)
+
+func TestHoldingPerformance(t *testing.T) {
+	day := func(y int, m time.Month, d int) time.Time { return time.Date(y, m, d, 9, 0, 0, 0, time.UTC) }
+	purchases := []recorder.Purchase{
+		{Amount: 1000, Price: 5000, Units: 0.2, OccurredAt: day(2024, 1, 1)},
+		{Amount: 1000, Price: 4000, Units: 0.25, OccurredAt: day(2024, 7, 1)},
+	}
+	h := model.Holding{Units: 0.45, TotalInvested: 2000, AvgCost: 2000 / 0.45}
+
+	if p := HoldingPerformance("SPX500", model.Holding{}, nil, nil, 5000, day(2025, 1, 1)); p != nil {
+		t.Errorf("empty holding: %+v, want nil", p)
+	}
+
+	p := HoldingPerformance("SPX500", h, purchases, nil, 5000, day(2025, 1, 1))
+	if math.Abs(p.Value-2250) > 1e-9 || math.Abs(p.UnrealizedPnL-250) > 1e-9 || math.Abs(p.ROI-0.125) > 1e-9 {
+		t.Errorf("value %.2f, P&L %.2f, ROI %.4f", p.Value, p.UnrealizedPnL, p.ROI)
+	}
+	// Half the money was invested for only half the year, so the annual
+	// rate is above the simple return.
+	if math.IsNaN(p.XIRR) || p.XIRR <= p.ROI {
+		t.Errorf("XIRR %.4f, want above the ROI %.4f", p.XIRR, p.ROI)
+	}
+
+	// Without a price only the invested amount is known.
+	p = HoldingPerformance("SPX500", h, purchases, nil, 0, day(2025, 1, 1))
+	if p.Invested != 2000 || p.Price != 0 || p.Value != 0 || !math.IsNaN(p.XIRR) {
+		t.Errorf("no price: %+v", p)
+	}
+
+	// A holding whose purchases were not recorded has no XIRR.
+	if p := HoldingPerformance("SPX500", h, nil, nil, 5000, day(2025, 1, 1)); !math.IsNaN(p.XIRR) || p.ROI != 0.125 {
+		t.Errorf("no purchases: %+v", p)
+	}
+}
+
+func TestHoldingPerformance_Sales(t *testing.T) {
+	day := func(y int, m time.Month, d int) time.Time { return time.Date(y, m, d, 9, 0, 0, 0, time.UTC) }
+	purchases := []recorder.Purchase{
+		{Amount: 1000, Price: 5000, Units: 0.2, OccurredAt: day(2024, 1, 1)},
+		{Amount: 1000, Price: 4000, Units: 0.25, OccurredAt: day(2024, 7, 1)},
+	}
+	sales := []recorder.Sale{
+		{Units: 0.1, Price: 5000, Proceeds: 500, RealizedPnL: 500 - 0.1*2000/0.45, OccurredAt: day(2024, 12, 1)},
+		{Units: 0.1, Price: 6000, Proceeds: 600, RealizedPnL: 600 - 0.1*2000/0.45, OccurredAt: day(2025, 3, 1)},
+	}
+	avg := 2000 / 0.45
+	h := model.Holding{Units: 0.25, TotalInvested: 0.25 * avg, AvgCost: avg}
+
+	p := HoldingPerformance("SPX500", h, purchases, sales, 6000, day(2025, 6, 1))
+	if p.SalesYTD != 1 || math.Abs(p.RealizedYTD-sales[1].RealizedPnL) > 1e-9 {
+		t.Errorf("realized this year %.2f over %d sales, want only the 2025 sale", p.RealizedYTD, p.SalesYTD)
+	}
+	// The sales are money back: the rate beats the one ignoring them.
+	without := HoldingPerformance("SPX500", h, purchases, nil, 6000, day(2025, 6, 1))
+	if math.IsNaN(p.XIRR) || p.XIRR <= without.XIRR {
+		t.Errorf("XIRR %.4f with sales, %.4f without", p.XIRR, without.XIRR)
+	}
+
+	// Everything sold: no price is needed for the realized figures and XIRR.
+	p = HoldingPerformance("SPX500", model.Holding{}, purchases, sales, 0, day(2025, 6, 1))
+	if p == nil || p.Invested != 0 || p.Value != 0 || p.SalesYTD != 1 || math.IsNaN(p.XIRR) {
+		t.Errorf("sold out: %+v", p)
+	}
+}
@@ -0,0 +1,55 @@
+package reporting
+
+import (
+	"sort"
+	"time"
+
+	"MarketSentinel/internal/recorder"
+)
+
+// MonthDrift compares what the bot simulated investing in one month with the
+// trades actually executed.
+type MonthDrift struct {
+	Month     time.Time // first day of the month
+	Simulated float64   // money that left the simulated pools
+	Actual    float64   // sum of imported trade amounts
+	Trades    int
+}
+
+// Drift is actual minus simulated investment.
+func (d MonthDrift) Drift() float64 { return d.Actual - d.Simulated }
+
+// ReconcileMonths buckets simulated fund events and actual trades by calendar
+// month in local time. Months with neither are omitted; the result is oldest
+// first.
+func ReconcileMonths(events []recorder.FundEvent, trades []recorder.Trade) []MonthDrift {
+	byMonth := make(map[string]*MonthDrift)
+	bucket := func(t time.Time) *MonthDrift {
+		t = t.Local()
+		key := t.Format("2006-01")
+		d, ok := byMonth[key]
+		if !ok {
+			d = &MonthDrift{Month: time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.Local)}
+			byMonth[key] = d
+		}
+		return d
+	}
+
+	for i := range events {
+		if v := events[i].Invested(); v > 0 {
+			bucket(events[i].OccurredAt).Simulated += v
+		}
+	}
+	for _, t := range trades {
+		d := bucket(t.Date)
+		d.Actual += t.Amount
+		d.Trades++
+	}
+
+	out := make([]MonthDrift, 0, len(byMonth))
+	for _, d := range byMonth {
+		out = append(out, *d)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Month.Before(out[j].Month) })
+	return out
+}
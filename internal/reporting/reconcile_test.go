@@ -0,0 +1,34 @@
+package reporting
+
+import (
+	"testing"
+	"time"
+
+	"MarketSentinel/internal/recorder"
+)
+
+func TestReconcileMonths(t *testing.T) {
+	day := func(m time.Month, d int) time.Time { return time.Date(2025, m, d, 9, 0, 0, 0, time.Local) }
+	events := []recorder.FundEvent{
+		{EventType: "WEEKLY", RegularBefore: 5000, RegularAfter: 4000, OccurredAt: day(3, 3)},
+		{EventType: "BOTTOM_FISH", ReserveBefore: 2000, ReserveAfter: 1500, OccurredAt: day(3, 12)},
+		{EventType: "MONTHLY", RegularBefore: 4000, RegularAfter: 9000, OccurredAt: day(4, 1)},
+		{EventType: "QUARTERLY", RegularBefore: 9000, RegularAfter: 9500, ReserveBefore: 1500, ReserveAfter: 1000, OccurredAt: day(4, 1)},
+	}
+	trades := []recorder.Trade{
+		{Date: day(2, 28), Amount: 800},
+		{Date: day(3, 4), Amount: 1000},
+		{Date: day(3, 13), Amount: 600},
+	}
+
+	got := ReconcileMonths(events, trades)
+	if len(got) != 2 {
+		t.Fatalf("expected Feb and Mar, got %+v", got)
+	}
+	if got[0].Month.Month() != time.February || got[0].Simulated != 0 || got[0].Actual != 800 {
+		t.Errorf("February: %+v", got[0])
+	}
+	if mar := got[1]; mar.Simulated != 1500 || mar.Actual != 1600 || mar.Trades != 2 || mar.Drift() != 100 {
+		t.Errorf("March: %+v", mar)
+	}
+}
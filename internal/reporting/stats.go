@@ -0,0 +1,85 @@
+package reporting
+
+import (
+	"sort"
+
+	"MarketSentinel/internal/recorder"
+	"MarketSentinel/internal/strategy"
+)
+
+// TierCount is the number of weeks that landed in one tier.
+type TierCount struct {
+	Label string
+	Count int
+}
+
+// TierStats summarizes weekly snapshots over a reporting window.
+type TierStats struct {
+	Weeks          int
+	Tiers          []TierCount // highest tier first; unknown labels last
+	AvgScore       float64
+	MedianScore    float64
+	ReserveWeeks   int     // weeks that drew on the reserve pool
+	SuggestedTotal float64 // sum of final suggested amounts
+	ConstantNTotal float64 // what a flat 1×N every week would have invested
+}
+
+// AggregateTiers computes tier distribution and score statistics for snaps.
+func AggregateTiers(snaps []recorder.WeeklySnapshotSummary) TierStats {
+	st := TierStats{Weeks: len(snaps)}
+	if len(snaps) == 0 {
+		return st
+	}
+
+	counts := make(map[string]int)
+	scores := make([]float64, 0, len(snaps))
+	sum := 0.0
+	for _, s := range snaps {
+		counts[s.TierLabel]++
+		scores = append(scores, s.TotalScore)
+		sum += s.TotalScore
+		if s.ReserveUsed > 0 {
+			st.ReserveWeeks++
+		}
+		st.SuggestedTotal += s.FinalAmount
+		st.ConstantNTotal += s.BaseAmount
+	}
+
+	for _, label := range tierOrder() {
+		if n, ok := counts[label]; ok {
+			st.Tiers = append(st.Tiers, TierCount{Label: label, Count: n})
+			delete(counts, label)
+		}
+	}
+	var unknown []string
+	for label := range counts {
+		unknown = append(unknown, label)
+	}
+	sort.Strings(unknown)
+	for _, label := range unknown {
+		st.Tiers = append(st.Tiers, TierCount{Label: label, Count: counts[label]})
+	}
+
+	st.AvgScore = sum / float64(len(scores))
+	st.MedianScore = median(scores)
+	return st
+}
+
+// tierOrder lists tier labels from the highest tier to the default tier.
+func tierOrder() []string {
+	labels := make([]string, 0, len(strategy.Tiers)+1)
+	for _, t := range strategy.Tiers {
+		labels = append(labels, t.Tier.Label)
+	}
+	return append(labels, strategy.DefaultTier.Label)
+}
+
+func median(values []float64) float64 {
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	n := len(sorted)
+	if n%2 == 1 {
+		return sorted[n/2]
+	}
+	return (sorted[n/2-1] + sorted[n/2]) / 2
+}
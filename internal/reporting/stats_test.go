@@ -0,0 +1,70 @@
+package reporting
+
+import (
+	"math"
+	"testing"
+
+	"MarketSentinel/internal/recorder"
+)
+
+func snap(label string, score, final, reserve float64) recorder.WeeklySnapshotSummary {
+	return recorder.WeeklySnapshotSummary{
+		TierLabel: label, TotalScore: score, BaseAmount: 1000,
+		FinalAmount: final, ReserveUsed: reserve,
+	}
+}
+
+func TestAggregateTiers_Empty(t *testing.T) {
+	st := AggregateTiers(nil)
+	if st.Weeks != 0 || len(st.Tiers) != 0 || st.SuggestedTotal != 0 {
+		t.Errorf("expected zero stats, got %+v", st)
+	}
+}
+
+func TestAggregateTiers_Distribution(t *testing.T) {
+	st := AggregateTiers([]recorder.WeeklySnapshotSummary{
+		snap("正常定投", 0.2, 1000, 0),
+		snap("缩减定投", -0.5, 500, 0),
+		snap("加仓买入", 0.9, 1500, 500),
+		snap("正常定投", 0.1, 1000, 0),
+	})
+
+	if st.Weeks != 4 {
+		t.Fatalf("expected 4 weeks, got %d", st.Weeks)
+	}
+	want := []TierCount{{"加仓买入", 1}, {"正常定投", 2}, {"缩减定投", 1}}
+	if len(st.Tiers) != len(want) {
+		t.Fatalf("expected tiers %v, got %v", want, st.Tiers)
+	}
+	for i := range want {
+		if st.Tiers[i] != want[i] {
+			t.Errorf("tier %d: expected %v, got %v", i, want[i], st.Tiers[i])
+		}
+	}
+	if math.Abs(st.AvgScore-0.175) > 1e-9 {
+		t.Errorf("expected avg 0.175, got %f", st.AvgScore)
+	}
+	if math.Abs(st.MedianScore-0.15) > 1e-9 {
+		t.Errorf("expected median 0.15, got %f", st.MedianScore)
+	}
+	if st.ReserveWeeks != 1 {
+		t.Errorf("expected 1 reserve week, got %d", st.ReserveWeeks)
+	}
+	if st.SuggestedTotal != 4000 || st.ConstantNTotal != 4000 {
+		t.Errorf("unexpected totals: suggested=%.0f constant=%.0f", st.SuggestedTotal, st.ConstantNTotal)
+	}
+}
+
+func TestAggregateTiers_UnknownLabelLast(t *testing.T) {
+	st := AggregateTiers([]recorder.WeeklySnapshotSummary{
+		snap("旧档位", 0, 0, 0),
+		snap("最低参与", -2, 150, 0),
+		snap("极限重仓", 2, 2500, 1500),
+	})
+	if st.Tiers[0].Label != "极限重仓" || st.Tiers[1].Label != "最低参与" || st.Tiers[2].Label != "旧档位" {
+		t.Errorf("unexpected tier order: %v", st.Tiers)
+	}
+	if st.MedianScore != 0 {
+		t.Errorf("expected median 0 for odd count, got %f", st.MedianScore)
+	}
+}
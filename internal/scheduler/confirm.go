@@ -0,0 +1,134 @@
+package scheduler
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Reply keyboard labels for the confirmation step.
+const (
+	confirmYes = "✅ 确认"
+	confirmNo  = "❌ 取消"
+)
+
+// Audit stages recorded for confirmable commands.
+const (
+	stagePreview     = "PREVIEW"
+	stageConfirmed   = "CONFIRMED"
+	stageDenied      = "DENIED"
+	stageExpired     = "EXPIRED"
+	stageForeignUser = "FOREIGN_USER"
+)
+
+// defaultConfirmTimeout is how long a preview stays valid.
+const defaultConfirmTimeout = 60 * time.Second
+
+// confirmable is a destructive command split into a side-effect-free preview
+// (old → new balance per pool) and the apply step that actually mutates state.
+type confirmable struct {
+	Command string
+	Preview func() (string, error)
+	Apply   func() (string, error)
+}
+
+// auditFunc receives every stage of a confirmable command.
+type auditFunc func(userID int64, command, stage, detail string)
+
+type pendingConfirm struct {
+	userID    int64
+	action    confirmable
+	expiresAt time.Time
+}
+
+// confirmer holds at most one pending confirmable command. Only the user who
+// issued it may confirm, and only before the timeout elapses.
+type confirmer struct {
+	mu      sync.Mutex
+	pending *pendingConfirm
+	timeout time.Duration
+	now     func() time.Time
+	audit   auditFunc
+}
+
+func newConfirmer(timeout time.Duration, audit auditFunc) *confirmer {
+	return &confirmer{timeout: timeout, now: time.Now, audit: audit}
+}
+
+// Request renders the preview and parks the action until the same user confirms.
+// A previously pending action is expired and replaced.
+func (c *confirmer) Request(userID int64, action confirmable) (string, error) {
+	preview, err := action.Preview()
+	if err != nil {
+		return "", err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.pending != nil {
+		c.audit(c.pending.userID, c.pending.action.Command, stageExpired, "被新命令替换")
+	}
+	c.pending = &pendingConfirm{
+		userID:    userID,
+		action:    action,
+		expiresAt: c.now().Add(c.timeout),
+	}
+	c.audit(userID, action.Command, stagePreview, preview)
+
+	return fmt.Sprintf("%s\n\n请在 %.0f 秒内点击「确认」执行，或「取消」放弃", preview, c.timeout.Seconds()), nil
+}
+
+// Resolve handles a confirm/deny reply. handled is false when text is not a
+// confirmation reply and should be routed as a normal command.
+func (c *confirmer) Resolve(userID int64, text string) (reply string, handled bool) {
+	if text != confirmYes && text != confirmNo {
+		return "", false
+	}
+
+	c.mu.Lock()
+	p := c.pending
+	if p == nil {
+		c.mu.Unlock()
+		return "没有待确认的操作", true
+	}
+	if c.now().After(p.expiresAt) {
+		c.pending = nil
+		c.mu.Unlock()
+		c.audit(p.userID, p.action.Command, stageExpired, "超时未确认")
+		return "⌛ 操作已超时，未做任何修改", true
+	}
+	if userID != p.userID {
+		c.mu.Unlock()
+		c.audit(userID, p.action.Command, stageForeignUser, fmt.Sprintf("发起人 %d", p.userID))
+		return "⛔ 只有发起该操作的用户可以确认", true
+	}
+	c.pending = nil
+	c.mu.Unlock()
+
+	if text == confirmNo {
+		c.audit(userID, p.action.Command, stageDenied, "")
+		return "已取消，未做任何修改", true
+	}
+
+	result, err := p.action.Apply()
+	if err != nil {
+		c.audit(userID, p.action.Command, stageConfirmed, fmt.Sprintf("执行失败: %v", err))
+		return fmt.Sprintf("❌ 执行失败: %v", err), true
+	}
+	c.audit(userID, p.action.Command, stageConfirmed, result)
+	return result, true
+}
+
+// ExpireStale drops the pending action if its timeout has elapsed, recording the expiry.
+func (c *confirmer) ExpireStale() {
+	c.mu.Lock()
+	p := c.pending
+	if p == nil || !c.now().After(p.expiresAt) {
+		c.mu.Unlock()
+		return
+	}
+	c.pending = nil
+	c.mu.Unlock()
+	c.audit(p.userID, p.action.Command, stageExpired, "超时未确认")
+}
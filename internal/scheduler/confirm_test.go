@@ -0,0 +1,133 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+)
+
+type auditEntry struct {
+	userID int64
+	stage  string
+}
+
+func newTestConfirmer(now *time.Time) (*confirmer, *[]auditEntry, *int) {
+	var log []auditEntry
+	applied := 0
+	c := newConfirmer(time.Minute, func(userID int64, _, stage, _ string) {
+		log = append(log, auditEntry{userID, stage})
+	})
+	c.now = func() time.Time { return *now }
+	return c, &log, &applied
+}
+
+func testAction(applied *int) confirmable {
+	return confirmable{
+		Command: "/adjust",
+		Preview: func() (string, error) { return "常规池: ¥100 → ¥200", nil },
+		Apply: func() (string, error) {
+			*applied++
+			return "done", nil
+		},
+	}
+}
+
+func TestConfirmer_Confirm(t *testing.T) {
+	now := time.Now()
+	c, audit, applied := newTestConfirmer(&now)
+
+	if _, err := c.Request(1, testAction(applied)); err != nil {
+		t.Fatal(err)
+	}
+	if *applied != 0 {
+		t.Fatal("apply must not run before confirmation")
+	}
+	reply, handled := c.Resolve(1, confirmYes)
+	if !handled || reply != "done" {
+		t.Fatalf("expected confirmed apply, got %q (handled=%v)", reply, handled)
+	}
+	if *applied != 1 {
+		t.Errorf("expected apply once, got %d", *applied)
+	}
+	if len(*audit) != 2 || (*audit)[0].stage != stagePreview || (*audit)[1].stage != stageConfirmed {
+		t.Errorf("unexpected audit trail: %+v", *audit)
+	}
+}
+
+func TestConfirmer_Deny(t *testing.T) {
+	now := time.Now()
+	c, audit, applied := newTestConfirmer(&now)
+
+	c.Request(1, testAction(applied))
+	if _, handled := c.Resolve(1, confirmNo); !handled {
+		t.Fatal("expected deny to be handled")
+	}
+	if *applied != 0 {
+		t.Error("apply must not run after deny")
+	}
+	if last := (*audit)[len(*audit)-1]; last.stage != stageDenied {
+		t.Errorf("expected DENIED audit, got %s", last.stage)
+	}
+	// Nothing left to confirm.
+	c.Resolve(1, confirmYes)
+	if *applied != 0 {
+		t.Error("confirm after deny must not apply")
+	}
+}
+
+func TestConfirmer_Timeout(t *testing.T) {
+	now := time.Now()
+	c, audit, applied := newTestConfirmer(&now)
+
+	c.Request(1, testAction(applied))
+	now = now.Add(2 * time.Minute)
+	c.Resolve(1, confirmYes)
+	if *applied != 0 {
+		t.Error("apply must not run after timeout")
+	}
+	if last := (*audit)[len(*audit)-1]; last.stage != stageExpired {
+		t.Errorf("expected EXPIRED audit, got %s", last.stage)
+	}
+}
+
+func TestConfirmer_ExpireStale(t *testing.T) {
+	now := time.Now()
+	c, audit, applied := newTestConfirmer(&now)
+
+	c.Request(1, testAction(applied))
+	c.ExpireStale()
+	if len(*audit) != 1 {
+		t.Fatalf("pending action expired too early: %+v", *audit)
+	}
+	now = now.Add(2 * time.Minute)
+	c.ExpireStale()
+	if last := (*audit)[len(*audit)-1]; last.stage != stageExpired {
+		t.Errorf("expected EXPIRED audit, got %s", last.stage)
+	}
+}
+
+func TestConfirmer_DifferentUser(t *testing.T) {
+	now := time.Now()
+	c, audit, applied := newTestConfirmer(&now)
+
+	c.Request(1, testAction(applied))
+	c.Resolve(2, confirmYes)
+	if *applied != 0 {
+		t.Fatal("another user must not be able to confirm")
+	}
+	if last := (*audit)[len(*audit)-1]; last.stage != stageForeignUser || last.userID != 2 {
+		t.Errorf("expected FOREIGN_USER audit for user 2, got %+v", last)
+	}
+	// The original user can still confirm.
+	c.Resolve(1, confirmYes)
+	if *applied != 1 {
+		t.Errorf("expected original user to confirm, applied=%d", *applied)
+	}
+}
+
+func TestConfirmer_IgnoresOtherText(t *testing.T) {
+	now := time.Now()
+	c, _, _ := newTestConfirmer(&now)
+	if _, handled := c.Resolve(1, "/fund"); handled {
+		t.Error("normal commands must not be handled by the confirmer")
+	}
+}
@@ -0,0 +1,87 @@
+package scheduler
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	"MarketSentinel/internal/collector"
+	"MarketSentinel/internal/metrics"
+	"MarketSentinel/internal/notifier"
+	"MarketSentinel/internal/reporting"
+)
+
+// gapCheck configures the optional pre-market gap alert.
+type gapCheck struct {
+	fetcher      collector.Fetcher
+	symbol       string  // auxiliary futures or pre-market symbol, e.g. ES=F
+	thresholdPct float64 // |gap| at or above this sends an alert
+}
+
+// gapSnapshotLookback bounds how far back the latest weekly snapshot is searched.
+const gapSnapshotLookback = 14 * 24 * time.Hour
+
+// RegisterGapCheck registers the optional early-morning gap check. It compares
+// the current quote of symbol, fetched through fetcher, with its previous close
+// and alerts when the implied open moves by thresholdPct or more. It never
+// changes the fund.
+func (s *Scheduler) RegisterGapCheck(spec string, fetcher collector.Fetcher, symbol string, thresholdPct float64) error {
+	s.gap = &gapCheck{fetcher: fetcher, symbol: symbol, thresholdPct: thresholdPct}
+	if err := s.addTask("跳空检查", spec, s.gapCheckTask, true); err != nil {
+		return fmt.Errorf("register gap check: %w", err)
+	}
+	return nil
+}
+
+// gapCheckTask is best-effort: a failed auxiliary fetch is only logged at
+// debug level and sends nothing.
+func (s *Scheduler) gapCheckTask(at time.Time) {
+	defer s.Metrics.Since(reporting.MetricTaskDuration, metrics.Labels{"task": "gap"}, time.Now())
+	g := s.gap
+
+	price, err := g.fetcher.FetchCurrentPrice(g.symbol)
+	if err != nil {
+		log.Printf("[DEBUG] gap check: fetch %s quote: %v", g.symbol, err)
+		return
+	}
+	bars, err := g.fetcher.FetchDailyBars(g.symbol, 5)
+	if err != nil {
+		log.Printf("[DEBUG] gap check: fetch %s bars: %v", g.symbol, err)
+		return
+	}
+	// The previous close is the last session that ended before today.
+	y, m, d := at.Date()
+	today := time.Date(y, m, d, 0, 0, 0, 0, at.Location())
+	prevClose := 0.0
+	for _, b := range bars {
+		if b.Time.Before(today) {
+			prevClose = b.Close
+		}
+	}
+	if prevClose <= 0 || price <= 0 {
+		log.Printf("[DEBUG] gap check: no usable %s close/quote (close %.2f, quote %.2f)", g.symbol, prevClose, price)
+		return
+	}
+
+	gapPct := (price - prevClose) / prevClose * 100
+	if math.Abs(gapPct) < g.thresholdPct {
+		log.Printf("[INFO] gap check: %s %+.2f%%, below %.1f%%", g.symbol, gapPct, g.thresholdPct)
+		return
+	}
+
+	alert := notifier.GapAlert{
+		Symbol:    g.symbol,
+		PrevClose: prevClose,
+		Quote:     price,
+		GapPct:    gapPct,
+		Capacity:  s.Fund.BottomFishCapacity(),
+	}
+	snaps, err := s.Recorder.WeeklySnapshotsSince(s.Collector.Symbol, at.Add(-gapSnapshotLookback))
+	if err != nil {
+		log.Printf("[ERROR] gap check: load weekly snapshot: %v", err)
+	} else if len(snaps) > 0 {
+		alert.Snapshot = &snaps[len(snaps)-1]
+	}
+	s.trySend(notifier.NewMessage(notifier.MsgGapAlert, notifier.PriorityNormal, notifier.FormatGapAlert(alert)))
+}
@@ -0,0 +1,125 @@
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"MarketSentinel/internal/collector"
+	"MarketSentinel/internal/fund"
+	"MarketSentinel/internal/model"
+	"MarketSentinel/internal/notifier"
+	"MarketSentinel/internal/recorder"
+)
+
+// recapRecorder serves the weekly snapshot, daily checks and past recaps a
+// recap reads, and keeps the recaps written.
+type recapRecorder struct {
+	*recorder.NoopRecorder
+	snap   *recorder.WeeklySnapshotSummary
+	checks []recorder.DailyCheckEvent
+	recent []recorder.WeeklyRecap
+	recaps []recorder.WeeklyRecap
+}
+
+func (r *recapRecorder) FirstWeeklySnapshotSince(string, time.Time) (*recorder.WeeklySnapshotSummary, error) {
+	return r.snap, nil
+}
+
+func (r *recapRecorder) DailyChecksSince(time.Time) ([]recorder.DailyCheckEvent, error) {
+	return r.checks, nil
+}
+
+func (r *recapRecorder) RecentWeeklyRecaps(int) ([]recorder.WeeklyRecap, error) { return r.recent, nil }
+
+func (r *recapRecorder) RecordWeeklyRecap(recap *recorder.WeeklyRecap) error {
+	r.recaps = append(r.recaps, *recap)
+	return nil
+}
+
+// captureTransport answers every Telegram API call with success and keeps
+// the text of the messages sent.
+type captureTransport struct {
+	texts []string
+}
+
+func (c *captureTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var payload struct {
+		Text string `json:"text"`
+	}
+	if err := json.NewDecoder(req.Body).Decode(&payload); err != nil {
+		return nil, err
+	}
+	c.texts = append(c.texts, payload.Text)
+	return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(`{"ok":true}`)), Header: make(http.Header)}, nil
+}
+
+func TestWeeklyRecapTask(t *testing.T) {
+	// Friday evening of the week from Mon 2025-03-03, opening at 5000 and
+	// closing at 5150; the bar of the Friday before is not part of it.
+	at := time.Date(2025, 3, 7, 20, 0, 0, 0, time.Local)
+	bars := []model.OHLCV{{Time: time.Date(2025, 2, 28, 0, 0, 0, 0, time.Local), Open: 4800, Close: 4900}}
+	for i, c := range []float64{5050, 5000, 5100, 5120, 5150} {
+		bars = append(bars, model.OHLCV{Time: time.Date(2025, 3, 3+i, 0, 0, 0, 0, time.Local), Open: c - 50, Close: c})
+	}
+
+	tests := []struct {
+		name         string
+		score        float64
+		checks       []recorder.DailyCheckEvent
+		wantHit      bool
+		wantFished   bool
+		wantContains []string
+	}{
+		{"buy call, price rose, dip bought", 0.8,
+			[]recorder.DailyCheckEvent{{EventType: "OBSERVE"}, {EventType: "BOTTOM_FISH"}},
+			true, true, []string{"本周走势: 5000.00 → 5150.00 (+3.00%)", "抄底触发: 是", "方向判断: ✅"}},
+		{"cautious call, price rose", -0.6,
+			[]recorder.DailyCheckEvent{{EventType: "OBSERVE"}},
+			false, false, []string{"抄底触发: 否", "方向判断: ❌"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := &recapRecorder{
+				NoopRecorder: recorder.NewNoopRecorder(),
+				snap:         &recorder.WeeklySnapshotSummary{CurrentPrice: 5020, TotalScore: tt.score, TierLabel: "标准定投"},
+				checks:       tt.checks,
+				// The rolling window: three hits in the last four weeks.
+				recent: []recorder.WeeklyRecap{{Hit: true}, {Hit: false}, {Hit: true}, {Hit: true}},
+			}
+			fm, err := fund.NewManager(fund.NewFileStore(filepath.Join(t.TempDir(), "fund_state.json")), 10000, fund.Policy{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			sent := &captureTransport{}
+			tn := notifier.NewTelegramNotifier("token", "chat", nil)
+			tn.Client.Transport = sent
+			s := NewScheduler(context.Background(), collector.NewCollector(&collector.MockFetcher{DailyData: bars}, "SPX500"), fm, tn, rec)
+			s.weeklyRecapTask(at)
+
+			if len(rec.recaps) != 1 {
+				t.Fatalf("recorded %d recaps, want 1", len(rec.recaps))
+			}
+			r := rec.recaps[0]
+			if !r.WeekStart.Equal(time.Date(2025, 3, 3, 0, 0, 0, 0, time.Local)) || r.WeekOpen != 5000 || r.WeekClose != 5150 || r.SuggestedPrice != 5020 {
+				t.Errorf("recap %+v", r)
+			}
+			if r.Hit != tt.wantHit || r.BottomFished != tt.wantFished {
+				t.Errorf("hit %v, bottom-fished %v; want %v, %v", r.Hit, r.BottomFished, tt.wantHit, tt.wantFished)
+			}
+			if len(sent.texts) != 1 {
+				t.Fatalf("sent %d messages, want 1", len(sent.texts))
+			}
+			for _, want := range append(tt.wantContains, "近4周命中率: 3/4 (75%)") {
+				if !strings.Contains(sent.texts[0], want) {
+					t.Errorf("recap message lacks %q:\n%s", want, sent.texts[0])
+				}
+			}
+		})
+	}
+}
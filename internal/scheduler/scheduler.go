@@ -2,14 +2,26 @@ package scheduler
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
+	"maps"
+	"math"
+	"math/rand"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 
+	"MarketSentinel/internal/calculator"
 	"MarketSentinel/internal/collector"
 	"MarketSentinel/internal/fund"
+	"MarketSentinel/internal/metrics"
 	"MarketSentinel/internal/model"
 	"MarketSentinel/internal/notifier"
+	"MarketSentinel/internal/pipeline"
 	"MarketSentinel/internal/recorder"
+	"MarketSentinel/internal/reporting"
 	"MarketSentinel/internal/strategy"
 
 	"github.com/robfig/cron/v3"
@@ -20,52 +32,169 @@ type Scheduler struct {
 	Cron      *cron.Cron
 	Collector *collector.Collector
 	Fund      *fund.Manager
-	Notifier  *notifier.TelegramNotifier
+	Notifier  notifier.Notifier
 	Recorder  recorder.Recorder
 	Ctx       context.Context
+	Pipeline  *pipeline.Pipeline
+	Metrics   *metrics.Registry // nil unless EnableMetrics was called
+	// Clock supplies the logical time of commands and scheduled runs.
+	Clock Clock
+	// ThreadReplies sends the weekly fund status and footer as replies to the
+	// main report instead of one combined message.
+	ThreadReplies bool
+	// WeeklyStyle selects the compact weekly report for uneventful weeks; the
+	// zero value always sends the detailed report.
+	WeeklyStyle notifier.StylePolicy
+
+	metricsRetention time.Duration
+	confirm          *confirmer
+	degradedDays     int           // consecutive daily checks with degraded trigger inputs
+	jitter           time.Duration // upper bound of the per-task start offset
+	gap              *gapCheck     // nil unless RegisterGapCheck was called
+	jobs             []job
+	monthlyID        cron.EntryID // the monthly task, 0 until RegisterAll
+}
+
+// Clock reports the current time. Tests substitute a fixed clock.
+type Clock interface {
+	Now() time.Time
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time { return time.Now() }
+
+// job is a registered cron task, kept for the /status display.
+type job struct {
+	name   string
+	id     cron.EntryID
+	offset time.Duration // random start delay fixed at registration
 }
 
 // NewScheduler creates a new Scheduler.
-func NewScheduler(ctx context.Context, col *collector.Collector, fm *fund.Manager, tn *notifier.TelegramNotifier, rec recorder.Recorder) *Scheduler {
-	return &Scheduler{
+func NewScheduler(ctx context.Context, col *collector.Collector, fm *fund.Manager, tn notifier.Notifier, rec recorder.Recorder) *Scheduler {
+	s := &Scheduler{
 		Cron:      cron.New(cron.WithSeconds()),
 		Collector: col,
 		Fund:      fm,
 		Notifier:  tn,
 		Recorder:  rec,
+		Pipeline:  pipeline.New(col, fm, rec),
 		Ctx:       ctx,
+		Clock:     systemClock{},
 	}
+	s.confirm = newConfirmer(defaultConfirmTimeout, s.recordCommandAudit)
+	fm.SetViolationHandler(s.fundViolationAlert)
+	return s
+}
+
+// SetJitter enables a random start offset of up to limit for tasks that fetch
+// market data. Offsets are drawn once per task at registration, so call this
+// before RegisterAll.
+func (s *Scheduler) SetJitter(limit time.Duration) {
+	s.jitter = limit
 }
 
 // RegisterAll registers weekly, daily, monthly, and quarterly tasks.
 func (s *Scheduler) RegisterAll(weeklyCron, dailyCron, monthlyCron string) error {
-	if _, err := s.Cron.AddFunc(weeklyCron, s.weeklyTask); err != nil {
+	if err := s.addTask("周报", weeklyCron, s.weeklyTask, true); err != nil {
 		return fmt.Errorf("register weekly task: %w", err)
 	}
-	if _, err := s.Cron.AddFunc(dailyCron, s.dailyCheck); err != nil {
+	if err := s.addTask("日线检查", dailyCron, s.dailyCheck, true); err != nil {
 		return fmt.Errorf("register daily task: %w", err)
 	}
-	if _, err := s.Cron.AddFunc(monthlyCron, s.monthlyTask); err != nil {
+	if err := s.addTask("月度补充", monthlyCron, s.monthlyTask, false); err != nil {
 		return fmt.Errorf("register monthly task: %w", err)
 	}
+	s.monthlyID = s.jobs[len(s.jobs)-1].id
 	// Quarterly: 1st of Jan, Apr, Jul, Oct
-	if _, err := s.Cron.AddFunc("0 0 9 1 1,4,7,10 *", s.quarterlyTask); err != nil {
+	if err := s.addTask("季度再平衡", "0 0 9 1 1,4,7,10 *", s.quarterlyTask, false); err != nil {
 		return fmt.Errorf("register quarterly task: %w", err)
 	}
 	// Weekly flag reset: every Monday 00:00
-	if _, err := s.Cron.AddFunc("0 0 0 * * 1", func() {
+	if err := s.addTask("周标记重置", "0 0 0 * * 1", func(time.Time) {
 		s.Fund.ResetWeeklyFlags()
 		log.Println("[INFO] weekly flags reset")
-	}); err != nil {
+	}, false); err != nil {
 		return fmt.Errorf("register weekly reset: %w", err)
 	}
 	return nil
 }
 
+// RegisterRecap registers the optional end-of-week recap task.
+func (s *Scheduler) RegisterRecap(recapCron string) error {
+	if err := s.addTask("周回顾", recapCron, s.weeklyRecapTask, true); err != nil {
+		return fmt.Errorf("register weekly recap: %w", err)
+	}
+	return nil
+}
+
+// addTask registers fn under spec. fn receives the scheduled slot as its
+// logical event time. Jittered tasks sleep a random offset, fixed for the
+// process lifetime, before running so requests don't land exactly on popular
+// cron times; the slot they receive excludes the offset.
+func (s *Scheduler) addTask(name, spec string, fn func(at time.Time), jittered bool) error {
+	var offset time.Duration
+	if jittered {
+		offset = jitterOffset(s.jitter)
+	}
+	if offset > 0 {
+		log.Printf("[INFO] %s task start offset: +%s", name, offset)
+	}
+	run := func() {
+		at := s.Clock.Now().Truncate(time.Second)
+		if offset > 0 {
+			s.runAfter(offset, func() { fn(at) })
+			return
+		}
+		fn(at)
+	}
+	id, err := s.Cron.AddFunc(spec, run)
+	if err != nil {
+		return err
+	}
+	s.jobs = append(s.jobs, job{name: name, id: id, offset: offset})
+	return nil
+}
+
+// jitterOffset returns a random whole-second offset in [0, limit].
+func jitterOffset(limit time.Duration) time.Duration {
+	if limit < time.Second {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(limit/time.Second)+1)) * time.Second
+}
+
+// runAfter waits d and then runs fn, giving up if the scheduler context ends first.
+func (s *Scheduler) runAfter(d time.Duration, fn func()) {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-s.Ctx.Done():
+		log.Println("[INFO] shutdown during task start offset, skipping run")
+	case <-t.C:
+		fn()
+	}
+}
+
+// EnableMetrics creates the in-process metrics registry and registers the job
+// that samples it into the recorder. Samples older than retention are pruned on
+// each pass; zero keeps them forever. Without this call no metrics are kept.
+func (s *Scheduler) EnableMetrics(sampleCron string, retention time.Duration) error {
+	s.Metrics = metrics.New()
+	s.Pipeline.Metrics = s.Metrics
+	s.metricsRetention = retention
+	if err := s.addTask("指标采样", sampleCron, s.sampleMetricsTask, false); err != nil {
+		return fmt.Errorf("register metrics sampling: %w", err)
+	}
+	return nil
+}
+
 // Start starts the cron scheduler.
 func (s *Scheduler) Start() {
 	s.Cron.Start()
 	log.Println("[INFO] scheduler started")
+	s.CatchUp()
 }
 
 // Stop stops the cron scheduler gracefully.
@@ -76,166 +205,1165 @@ func (s *Scheduler) Stop() {
 
 // RunWeeklyNow executes the weekly task immediately (for manual trigger / RUN_ON_START).
 func (s *Scheduler) RunWeeklyNow() {
-	s.weeklyTask()
+	s.weeklyTask(s.Clock.Now())
+}
+
+func (s *Scheduler) weeklyTask(at time.Time) {
+	s.weeklyTaskStyled(at, false, false)
 }
 
-func (s *Scheduler) weeklyTask() {
+// weeklyTaskStyled is weeklyTask with the detailed report forced on request.
+// With force set it invests even when this week's investment was made.
+func (s *Scheduler) weeklyTaskStyled(at time.Time, detail, force bool) {
 	log.Println("[INFO] running weekly task")
-	ind, err := s.Collector.Collect()
+	defer s.Metrics.Since(reporting.MetricTaskDuration, metrics.Labels{"task": "weekly"}, time.Now())
+	s.investingPaused(at)
+	s.runWeekly(pipeline.WeeklyOptions{At: at, Force: force}, true, detail)
+}
+
+// runWeekly runs the weekly evaluation and returns the report. With notify set
+// the report (or collection error) is also sent as a notification. The report
+// is compact when WeeklyStyle allows it, unless detail is set.
+func (s *Scheduler) runWeekly(opts pipeline.WeeklyOptions, notify, detail bool) string {
+	res, err := s.Pipeline.RunWeeklyEvaluation(s.Ctx, opts)
 	if err != nil {
 		log.Printf("[ERROR] weekly collect: %v", err)
-		s.trySend(fmt.Sprintf("❌ 周任务数据采集失败: %v", err))
-		return
+		text := fmt.Sprintf("❌ 周任务数据采集失败: %v", err)
+		if errors.Is(err, collector.ErrYahooRateLimited) {
+			text += "\nYahoo 限流中，稍后发送 查看本周建议 重试，或改用其他 data_source.provider"
+		}
+		if notify {
+			s.trySend(notifier.NewMessage(notifier.MsgError, notifier.PriorityHigh, text))
+		}
+		return text
+	}
+	if w := res.Indicators.SourceWarning; w != "" && notify {
+		s.trySend(notifier.NewMessage(notifier.MsgSourceAlert, notifier.PriorityHigh, notifier.FormatSourceAlert(res.Symbol, w)))
 	}
 
-	signal := strategy.Evaluate(ind)
-	signal.TriggerType = model.TriggerWeekly
-
-	state := s.Fund.GetState()
-	signal.BaseAmount = state.WeeklyBaseN
+	renderStart := time.Now()
+	style := notifier.WeeklyDetailed
+	if !detail {
+		style = notifier.SelectWeeklyStyle(res, s.WeeklyStyle)
+	}
+	var signalText, fundText string
+	if style == notifier.WeeklyCompact {
+		signalText = notifier.FormatWeeklyCompact(res)
+	} else {
+		signalText, fundText = notifier.FormatWeeklySignal(res), notifier.FormatFundStatus(&res.StateAfter)
+	}
+	res.Stages = append(res.Stages, pipeline.StageTiming{Stage: pipeline.StageRender, Duration: time.Since(renderStart)})
 
-	stateBefore := s.Fund.GetState()
-	finalAmount, reserveUsed := s.Fund.CalculateWeeklyInvestment(signal)
-	signal.FinalAmount = finalAmount
-	signal.ReserveUsed = reserveUsed
+	footer := s.opsFooter()
+	if style == notifier.WeeklyDetailed {
+		// Send is not done yet; the full report including it is logged
+		// and recorded below.
+		footer += notifier.FormatStageTimings(res.Stages) + "\n"
+	}
 
-	report := notifier.FormatWeeklyReport(ind, signal)
+	// The strategy version closes the report, or its last threaded part.
+	parts := []string{signalText, fundText, footer}
+	if version := notifier.FormatStrategyVersion(res.Signal); version != "" {
+		for i := len(parts) - 1; i >= 0; i-- {
+			if parts[i] != "" {
+				parts[i] += "\n" + version
+				break
+			}
+		}
+	}
 
-	// Append fund status
-	updatedState := s.Fund.GetState()
-	report += "\n" + notifier.FormatFundStatus(&updatedState)
+	sendStart := time.Now()
+	if notify && s.ThreadReplies {
+		s.sendThread(notifier.MsgWeeklyReport, notifier.PriorityNormal, parts...)
+	}
+	report := parts[0]
+	for _, p := range parts[1:] {
+		if p != "" {
+			report += "\n" + p
+		}
+	}
+	if notify && !s.ThreadReplies {
+		s.trySend(notifier.NewMessage(notifier.MsgWeeklyReport, notifier.PriorityNormal, report))
+	}
+	if notify {
+		res.Stages = append(res.Stages, pipeline.StageTiming{Stage: pipeline.StageSend, Duration: time.Since(sendStart)})
+	}
+	log.Printf("[INFO] weekly report (%s), stages: %s", style, res.Stages)
+	if notify {
+		s.recordTaskRun("weekly", res.Stages, opts.At)
+	}
+	return report
+}
 
-	s.trySend(report)
+// recordTaskRun records the stage timings of a run of task at at.
+func (s *Scheduler) recordTaskRun(task string, stages pipeline.StageReport, at time.Time) {
+	run := &recorder.TaskRun{Task: task, OccurredAt: at}
+	for _, st := range stages {
+		run.Stages = append(run.Stages, recorder.StageRun{
+			Stage: string(st.Stage), Duration: st.Duration, TimedOut: st.TimedOut, Cached: st.Cached,
+		})
+	}
+	if err := s.Recorder.RecordTaskRun(run); err != nil {
+		log.Printf("[ERROR] record %s task run: %v", task, err)
+	}
+}
 
-	// Record to SQLite
-	if err := s.Recorder.RecordWeekly(&recorder.WeeklySnapshot{
-		Indicators: ind,
-		Signal:     signal,
-		FundState:  &updatedState,
-	}); err != nil {
-		log.Printf("[ERROR] record weekly: %v", err)
+// sendThread sends parts as a reply chain under the first part. Empty parts are
+// skipped; if the first part fails to send the rest go out unthreaded.
+func (s *Scheduler) sendThread(typ notifier.MessageType, priority notifier.Priority, parts ...string) {
+	var parent int64
+	for i, text := range parts {
+		if text == "" {
+			continue
+		}
+		msg := notifier.NewMessage(typ, priority, text)
+		msg.ReplyTo = parent
+		id := s.trySend(msg)
+		if i == 0 {
+			parent = id
+		}
 	}
-	s.recordFundEvent("WEEKLY", &stateBefore, &updatedState, finalAmount+reserveUsed, "周定投")
 }
 
-func (s *Scheduler) dailyCheck() {
+// degradedAlertDays is how many consecutive degraded daily checks trigger an error alert.
+const degradedAlertDays = 3
+
+func (s *Scheduler) dailyCheck(at time.Time) {
+	s.CatchUp()
+	if !s.Pipeline.Collector.IsTradingDay(at) {
+		log.Printf("[INFO] daily check skipped: market closed on %s", at.Format("2006-01-02"))
+		return
+	}
 	log.Println("[INFO] running daily check")
-	ind, err := s.Collector.Collect()
+	defer s.Metrics.Since(reporting.MetricTaskDuration, metrics.Labels{"task": "daily"}, time.Now())
+	ind, err := s.Pipeline.CollectDaily()
+	if errors.Is(err, collector.ErrNotSupported) {
+		// No intraday bars from this source; use the last close.
+		ind, err = s.Pipeline.Collect()
+	}
 	if err != nil {
 		log.Printf("[ERROR] daily collect: %v", err)
 		return
 	}
+	s.evaluateDaily(ind, at)
+	s.dailyOthers(at)
+}
 
-	// Bottom-fish trigger: daily RSI < 30
-	if ind.DailyRSI < 30 {
-		signal := strategy.Evaluate(ind)
-		stateBefore := s.Fund.GetState()
-		amount, triggered := s.Fund.CalculateBottomFishInvestment(signal.TotalScore)
-		if triggered {
-			msg := fmt.Sprintf("🎣 <b>抄底触发</b> | 日线RSI=%.0f\n\n综合评分: %+.3f\n抄底金额: ¥%.0f (储备池)\n",
-				ind.DailyRSI, signal.TotalScore, amount)
-			s.trySend(msg)
-
-			stateAfter := s.Fund.GetState()
-			if err := s.Recorder.RecordDailyCheck(&recorder.DailyCheckEvent{
-				DailyRSI: ind.DailyRSI, WeeklyRSI: ind.WeeklyRSI, Price: ind.CurrentPrice,
-				EventType: "BOTTOM_FISH", Amount: amount, TotalScore: signal.TotalScore,
-			}); err != nil {
-				log.Printf("[ERROR] record daily check: %v", err)
-			}
-			s.recordFundEvent("BOTTOM_FISH", &stateBefore, &stateAfter, amount, "抄底触发")
+// dailyOthers runs the bottom-fish trigger of each other symbol the fund's
+// budget is allocated to, funded from that symbol's reserve pool. Only the
+// primary symbol is checked for take-profit and degraded data.
+func (s *Scheduler) dailyOthers(at time.Time) {
+	for _, sym := range s.Pipeline.Symbols {
+		fm := s.Fund.For(sym)
+		if sym == s.Collector.Symbol || fm == nil {
+			continue
+		}
+		col := s.Pipeline.Collector.For(sym)
+		ind, err := col.CollectDaily()
+		if errors.Is(err, collector.ErrNotSupported) {
+			ind, err = col.Collect()
+		}
+		if err != nil {
+			log.Printf("[ERROR] daily collect %s: %v", sym, err)
+			continue
 		}
+		if s.sourcesDisagree(sym, ind) {
+			continue
+		}
+		if level := strategy.Thresholds().BottomFishLevel(ind); level > 0 && ind.DegradedReason(model.IndicatorDailyRSI, model.IndicatorPrice) == "" {
+			s.bottomFish(sym, fm, ind, level, at)
+		}
+	}
+}
+
+// evaluateDaily runs the bottom-fish and take-profit triggers. A trigger is
+// suppressed when any indicator it depends on fell back to a default value,
+// and the bottom-fish one also while the data sources disagree. Events are
+// recorded at the logical time at.
+func (s *Scheduler) evaluateDaily(ind *model.MarketIndicators, at time.Time) {
+	bottomFishBlocked := ind.DegradedReason(model.IndicatorDailyRSI, model.IndicatorPrice)
+	if s.sourcesDisagree(s.Collector.Symbol, ind) && bottomFishBlocked == "" {
+		bottomFishBlocked = ind.SourceWarning
 	}
+	takeProfitBlocked := ind.DegradedReason(model.IndicatorDailyRSI, model.IndicatorWeeklyRSI, model.IndicatorPrice)
+	s.trackDegraded(ind, takeProfitBlocked, at)
+	thresholds := strategy.Thresholds()
 
-	// Take-profit warning: RSI > 85
-	if ind.DailyRSI > 85 || ind.WeeklyRSI > 85 {
-		msg := fmt.Sprintf("⚠️ <b>止盈预警</b>\n\n日线RSI: %.0f | 周线RSI: %.0f\n当前价格: %.2f\n建议考虑部分止盈",
-			ind.DailyRSI, ind.WeeklyRSI, ind.CurrentPrice)
-		s.trySend(msg)
+	// Bottom-fish trigger: daily RSI below the threshold, at the deepest
+	// level it reaches
+	if level := thresholds.BottomFishLevel(ind); level > 0 && bottomFishBlocked == "" {
+		s.bottomFish(s.Collector.Symbol, s.Fund, ind, level, at)
+	}
+
+	if takeProfitBlocked != "" {
+		return
+	}
 
+	// Take-profit warning: daily or weekly RSI above the threshold, with a
+	// sell recommendation when the score is also very negative
+	var sell *model.SellRecommendation
+	var score float64
+	if thresholds.SellPercent > 0 {
+		signal := strategy.Evaluate(ind)
+		sell, score = signal.Sell, signal.TotalScore
+	}
+	if thresholds.TakeProfitTriggered(ind) || sell != nil {
+		msg := fmt.Sprintf("⚠️ <b>止盈预警</b>\n\n日线RSI: %s | 周线RSI: %s\n当前价格: %.2f\n建议考虑部分止盈",
+			model.FormatRSI(ind.DailyRSI), model.FormatRSI(ind.WeeklyRSI), ind.CurrentPrice)
+		if sell != nil {
+			msg += "\n" + notifier.FormatSellRecommendation(sell)
+		}
+		s.trySend(notifier.NewMessage(notifier.MsgTakeProfit, notifier.PriorityHigh, msg))
+	}
+	if thresholds.TakeProfitTriggered(ind) {
 		if err := s.Recorder.RecordDailyCheck(&recorder.DailyCheckEvent{
-			DailyRSI: ind.DailyRSI, WeeklyRSI: ind.WeeklyRSI, Price: ind.CurrentPrice,
+			Timestamp: at, DailyRSI: ind.DailyRSI, WeeklyRSI: ind.WeeklyRSI, Price: ind.CurrentPrice,
 			EventType: "TAKE_PROFIT",
 		}); err != nil {
 			log.Printf("[ERROR] record daily check: %v", err)
 		}
 	}
+	if sell != nil {
+		if err := s.Recorder.RecordDailyCheck(&recorder.DailyCheckEvent{
+			Timestamp: at, DailyRSI: ind.DailyRSI, WeeklyRSI: ind.WeeklyRSI, Price: ind.CurrentPrice,
+			EventType: "SELL", Amount: sell.Amount, TotalScore: score, Note: sell.Reason, SellPercent: sell.Percent,
+		}); err != nil {
+			log.Printf("[ERROR] record daily check: %v", err)
+		}
+	}
+}
+
+// sourcesDisagree sends the source alert for symbol and reports true when the
+// verification source found ind's data diverging from the primary one.
+func (s *Scheduler) sourcesDisagree(symbol string, ind *model.MarketIndicators) bool {
+	if ind.SourceWarning == "" {
+		return false
+	}
+	log.Printf("[WARN] %s daily check: sources disagree, bottom-fish skipped", symbol)
+	s.trySend(notifier.NewMessage(notifier.MsgSourceAlert, notifier.PriorityHigh, notifier.FormatDailySourceAlert(symbol, ind.SourceWarning)))
+	return true
+}
+
+// bottomFish invests the bottom-fish of level for symbol from fm, its share of
+// the fund, and reports and records it as of at; while investing is paused it
+// is only reported. The daily check is recorded for the primary symbol alone,
+// the fund event for any.
+func (s *Scheduler) bottomFish(symbol string, fm *fund.Manager, ind *model.MarketIndicators, level int, at time.Time) {
+	var label string
+	if symbol != s.Collector.Symbol {
+		label = symbol + " "
+	}
+	if s.investingPaused(at) {
+		s.trySend(notifier.NewMessage(notifier.MsgBottomFish, notifier.PriorityNormal,
+			fmt.Sprintf("%s\n\n🎣 抄底条件触发 | %s日线RSI=%s，第%d档，本次未投入",
+				notifier.PauseBanner(s.Fund.GetState()), label, model.FormatRSI(ind.DailyRSI), level)))
+		return
+	}
+	thresholds := strategy.Thresholds()
+	signal := strategy.Evaluate(ind)
+	stateBefore := fm.GetState()
+	amount, triggered, capNote := fm.CalculateBottomFishInvestment(signal.TotalScore, level)
+	if !triggered {
+		if capNote != "" {
+			log.Printf("[INFO] %sbottom-fish skipped: %s", label, capNote)
+		}
+		return
+	}
+	msg := fmt.Sprintf("🎣 <b>抄底触发</b> | %s日线RSI=%s\n\n抄底档位: 第%d档 (RSI<%s)\n综合评分: %s\n抄底金额: %s (储备池)\n",
+		label, model.FormatRSI(ind.DailyRSI), level, strconv.FormatFloat(thresholds.BottomFishRSI(level), 'f', -1, 64),
+		model.FormatScore(signal.TotalScore), model.FormatAmount(amount))
+	if capNote != "" {
+		msg += capNote + "\n"
+	}
+	s.trySend(notifier.NewMessage(notifier.MsgBottomFish, notifier.PriorityHigh, msg))
+
+	stateAfter := fm.GetState()
+	if label == "" {
+		if err := s.Recorder.RecordDailyCheck(&recorder.DailyCheckEvent{
+			Timestamp: at, DailyRSI: ind.DailyRSI, WeeklyRSI: ind.WeeklyRSI, Price: ind.CurrentPrice,
+			EventType: "BOTTOM_FISH", Amount: amount, TotalScore: signal.TotalScore, Level: level,
+		}); err != nil {
+			log.Printf("[ERROR] record daily check: %v", err)
+		}
+	}
+	note := fmt.Sprintf("抄底触发 第%d档", level)
+	if capNote != "" {
+		note += "；" + capNote
+	}
+	s.recordFundEvent("BOTTOM_FISH", &stateBefore, &stateAfter, amount, note, at)
+}
+
+// trackDegraded records an OBSERVE row when trigger inputs are degraded and
+// alerts once the condition has lasted degradedAlertDays consecutive checks.
+func (s *Scheduler) trackDegraded(ind *model.MarketIndicators, reason string, at time.Time) {
+	if reason == "" {
+		s.degradedDays = 0
+		return
+	}
+	s.degradedDays++
+	log.Printf("[WARN] daily check on degraded data (%d day(s)), dependent triggers suppressed: %s", s.degradedDays, reason)
+
+	if err := s.Recorder.RecordDailyCheck(&recorder.DailyCheckEvent{
+		Timestamp: at, DailyRSI: ind.DailyRSI, WeeklyRSI: ind.WeeklyRSI, Price: ind.CurrentPrice,
+		EventType: "OBSERVE", Note: reason,
+	}); err != nil {
+		log.Printf("[ERROR] record daily check: %v", err)
+	}
+
+	if s.degradedDays >= degradedAlertDays {
+		s.trySend(notifier.NewMessage(notifier.MsgError, notifier.PriorityHigh,
+			fmt.Sprintf("❌ 日线检查已连续 %d 天使用降级数据，抄底/止盈判断已暂停\n原因: %s", s.degradedDays, reason)))
+	}
+}
+
+// recapWindow is the number of recent weeks included in the recap hit-rate.
+const recapWindow = 4
+
+func (s *Scheduler) weeklyRecapTask(at time.Time) {
+	log.Println("[INFO] running weekly recap")
+	defer s.Metrics.Since(reporting.MetricTaskDuration, metrics.Labels{"task": "recap"}, time.Now())
+	weekStart := startOfWeek(at)
+
+	snap, err := s.Recorder.FirstWeeklySnapshotSince(s.Collector.Symbol, weekStart)
+	if err != nil {
+		log.Printf("[ERROR] recap load snapshot: %v", err)
+		return
+	}
+	if snap == nil {
+		log.Println("[INFO] recap: no weekly snapshot this week, skipping")
+		s.trySend(notifier.NewMessage(notifier.MsgObservation, notifier.PriorityLow, "🗓 <b>周回顾</b>\n\n本周没有周报记录（可能因节假日跳过），不计入命中率"))
+		return
+	}
+
+	bars, err := s.Collector.Fetcher.FetchDailyBars(s.Collector.Symbol, 10)
+	if err != nil {
+		log.Printf("[ERROR] recap fetch bars: %v", err)
+		s.trySend(notifier.NewMessage(notifier.MsgError, notifier.PriorityNormal, fmt.Sprintf("❌ 周回顾数据采集失败: %v", err)))
+		return
+	}
+	var weekBars []model.OHLCV
+	for _, b := range bars {
+		if !b.Time.Before(weekStart) {
+			weekBars = append(weekBars, b)
+		}
+	}
+	if len(weekBars) == 0 {
+		log.Println("[INFO] recap: no bars this week, skipping")
+		return
+	}
+
+	checks, err := s.Recorder.DailyChecksSince(weekStart)
+	if err != nil {
+		log.Printf("[ERROR] recap load daily checks: %v", err)
+	}
+	bottomFished := false
+	for _, c := range checks {
+		if c.EventType == "BOTTOM_FISH" {
+			bottomFished = true
+			break
+		}
+	}
+
+	weekClose := weekBars[len(weekBars)-1].Close
+	recap := &recorder.WeeklyRecap{
+		WeekStart:      weekStart,
+		SuggestedPrice: snap.CurrentPrice,
+		TotalScore:     snap.TotalScore,
+		WeekOpen:       weekBars[0].Open,
+		WeekClose:      weekClose,
+		BottomFished:   bottomFished,
+		// A non-negative score favours buying, so the call was right if the
+		// price ended the week above the suggested price, and vice versa.
+		Hit:        (snap.TotalScore >= 0) == (weekClose > snap.CurrentPrice),
+		OccurredAt: at,
+	}
+	if err := s.Recorder.RecordWeeklyRecap(recap); err != nil {
+		log.Printf("[ERROR] record weekly recap: %v", err)
+	}
+
+	recent, err := s.Recorder.RecentWeeklyRecaps(recapWindow)
+	if err != nil {
+		log.Printf("[ERROR] recap load history: %v", err)
+	}
+	hits := 0
+	for _, r := range recent {
+		if r.Hit {
+			hits++
+		}
+	}
+
+	s.trySend(notifier.NewMessage(notifier.MsgObservation, notifier.PriorityLow, notifier.FormatWeeklyRecap(recap, snap.TierLabel, hits, len(recent))))
 }
 
-func (s *Scheduler) monthlyTask() {
+// startOfWeek returns Monday 00:00 of the week containing t, in t's location.
+func startOfWeek(t time.Time) time.Time {
+	offset := (int(t.Weekday()) + 6) % 7 // days since Monday
+	y, m, d := t.AddDate(0, 0, -offset).Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+}
+
+func (s *Scheduler) monthlyTask(at time.Time) {
 	log.Println("[INFO] running monthly task")
+	defer s.Metrics.Since(reporting.MetricTaskDuration, metrics.Labels{"task": "monthly"}, time.Now())
+	if err := s.Fund.Locked(); err != nil {
+		s.trySend(notifier.NewMessage(notifier.MsgError, notifier.PriorityHigh,
+			fmt.Sprintf("❌ 本月资金补充未执行: %v\n对账后请手动核对本月补充", err)))
+		return
+	}
+	s.investingPaused(at)
+	month := fund.MonthKey(at)
+	s.catchUpReplenish(at, fund.MonthKey(monthStart(at).AddDate(0, -1, 0)))
+	s.replenish(month, at, false)
+}
+
+// CatchUp replenishes the months whose scheduled replenish was missed while
+// the bot was down, up to the latest one due now. The daily check calls it
+// too, so a missed month is caught up by the next tick at the latest.
+func (s *Scheduler) CatchUp() {
+	now := s.Clock.Now()
+	if err := s.Fund.Locked(); err != nil {
+		log.Printf("[WARN] monthly catch-up skipped: %v", err)
+		return
+	}
+	s.catchUpReplenish(now, s.replenishDue(now))
+}
+
+// catchUpReplenish replenishes the months missed through through, each
+// recorded in its own month.
+func (s *Scheduler) catchUpReplenish(now time.Time, through string) {
+	for _, month := range s.Fund.MissedMonths(through) {
+		at, err := time.ParseInLocation("2006-01", month, now.Location())
+		if err != nil {
+			continue
+		}
+		log.Printf("[WARN] monthly replenish for %s was missed, catching up", month)
+		s.replenish(month, at, true)
+	}
+}
+
+// replenishDue is the latest month whose scheduled replenish time has passed
+// at now: the current month once the monthly task's first run in it is due,
+// the previous month before.
+func (s *Scheduler) replenishDue(now time.Time) string {
+	start := monthStart(now)
+	if s.monthlyID != 0 {
+		if first := s.Cron.Entry(s.monthlyID).Schedule.Next(start.Add(-time.Second)); first.After(now) {
+			return fund.MonthKey(start.AddDate(0, -1, 0))
+		}
+	}
+	return fund.MonthKey(now)
+}
+
+// monthStart returns the first of t's month at 00:00, in t's location.
+func monthStart(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+}
+
+// replenish credits the budget of month, reports and records it as of at;
+// late marks a catch-up of a missed month. A month already replenished is
+// only reported as skipped.
+func (s *Scheduler) replenish(month string, at time.Time, late bool) {
 	stateBefore := s.Fund.GetState()
-	s.Fund.MonthlyReplenish()
+	split := s.Fund.ReplenishMonth(month)
+	if split.Skipped {
+		s.trySend(notifier.NewMessage(notifier.MsgMonthly, notifier.PriorityLow,
+			fmt.Sprintf("ℹ️ %s 的月度资金已补充，本次未重复补充", month)))
+		return
+	}
+	if split.Paused {
+		s.trySend(notifier.NewMessage(notifier.MsgMonthly, notifier.PriorityNormal,
+			fmt.Sprintf("%s\n\n%s 的月度资金未补充", notifier.PauseBanner(s.Fund.GetState()), month)))
+		return
+	}
 	state := s.Fund.GetState()
-	report := notifier.FormatMonthlySummary(&state)
-	s.trySend(report)
-
-	budget := state.MonthlyBudget
-	regularAdded := budget * 0.7
-	reserveAdded := budget * 0.3
-	var avgScore float64
-	if len(state.RecentScores) > 0 {
-		sum := 0.0
-		for _, sc := range state.RecentScores {
-			sum += sc
-		}
-		avgScore = sum / float64(len(state.RecentScores))
-	}
-	if err := s.Recorder.RecordMonthly(&recorder.MonthlyEvent{
-		RegularAdded: regularAdded, ReserveAdded: reserveAdded,
-		RegularAfter: state.RegularBalance, ReserveAfter: state.ReserveBalance,
-		AvgScore: avgScore,
-	}); err != nil {
-		log.Printf("[ERROR] record monthly: %v", err)
+	perf := s.performance(&state, at)
+	report := notifier.FormatMonthlySummary(&state, perf) + "\n" + notifier.FormatReplenishSplit(&split)
+	if late {
+		report = fmt.Sprintf("⚠️ 补发 %s 月度资金补充（计划时间未运行）\n\n", month) + report
+	}
+	msg := notifier.NewMessage(notifier.MsgMonthly, notifier.PriorityNormal, report)
+	if png, err := s.fundChart(); err != nil {
+		log.Printf("[INFO] monthly fund chart skipped: %v", err)
+		s.trySend(msg)
+	} else {
+		s.trySendPhoto(msg, png)
+	}
+
+	// An allocated fund records each symbol's part, the primary's first.
+	parts := split.Parts
+	if len(parts) == 0 {
+		parts = []model.ReplenishSplit{split}
+	}
+	for i, part := range parts {
+		st, sym, monthlyPerf := &state, s.Collector.Symbol, perf
+		if i > 0 {
+			// Holdings are tracked for the primary symbol alone.
+			st, sym, monthlyPerf = state.Symbols[part.Symbol], part.Symbol, nil
+		}
+		avg, stddev, _ := calculator.ScoreStats(st.RecentScores)
+		if err := s.Recorder.RecordMonthly(&recorder.MonthlyEvent{
+			RegularAdded: part.RegularAdded, ReserveAdded: part.ReserveAdded,
+			RegularAfter: st.RegularBalance, ReserveAfter: st.ReserveBalance,
+			AvgScore: avg, ScoreStdDev: stddev,
+			ReserveShare: part.ReserveShare, SplitReason: part.Reason, CarriedOver: part.CarriedOver,
+			Symbol: sym, OccurredAt: at, Performance: monthlyPerf,
+		}); err != nil {
+			log.Printf("[ERROR] record monthly: %v", err)
+		}
+	}
+	note := "月度补充"
+	if late {
+		note = fmt.Sprintf("月度补充（补发 %s）", month)
+	}
+	s.recordFundEvent("MONTHLY", &stateBefore, &state, state.MonthlyBudget, note, at)
+	s.recordOtherFundEvents("MONTHLY", &stateBefore, &state, func(_, after *model.FundState) float64 {
+		return after.MonthlyBudget
+	}, note, at)
+}
+
+// performance values the holding of the primary symbol in state at the
+// current price, with the XIRR of its recorded purchases as of at; nil
+// before the first purchase.
+func (s *Scheduler) performance(state *model.FundState, at time.Time) *model.Performance {
+	sym := s.Collector.Symbol
+	sales, err := s.Recorder.SalesSince(sym, time.Time{})
+	if err != nil {
+		log.Printf("[ERROR] performance sales: %v", err)
+	}
+	h, ok := state.Holdings[sym]
+	if !ok && len(sales) == 0 {
+		return nil
+	}
+	var price float64
+	if ok {
+		if price, err = s.Collector.FetchPrice(); err != nil {
+			log.Printf("[WARN] performance: %v", err)
+		}
+	}
+	purchases, err := s.Recorder.PurchasesSince(sym, time.Time{})
+	if err != nil {
+		log.Printf("[ERROR] performance purchases: %v", err)
 	}
-	s.recordFundEvent("MONTHLY", &stateBefore, &state, budget, "月度补充")
+	return reporting.HoldingPerformance(sym, h, purchases, sales, price, at)
 }
 
-func (s *Scheduler) quarterlyTask() {
+func (s *Scheduler) quarterlyTask(at time.Time) {
 	log.Println("[INFO] running quarterly rebalance")
+	defer s.Metrics.Since(reporting.MetricTaskDuration, metrics.Labels{"task": "quarterly"}, time.Now())
+	if err := s.Fund.Locked(); err != nil {
+		s.trySend(notifier.NewMessage(notifier.MsgError, notifier.PriorityHigh,
+			fmt.Sprintf("❌ 季度再平衡未执行: %v", err)))
+		return
+	}
 	stateBefore := s.Fund.GetState()
-	result := s.Fund.QuarterlyRebalance()
+	result, done := s.Fund.QuarterlyRebalance()
+	if !done {
+		s.trySend(notifier.NewMessage(notifier.MsgQuarterly, notifier.PriorityLow, "ℹ️ "+result))
+		return
+	}
 	state := s.Fund.GetState()
 	msg := fmt.Sprintf("📊 <b>季度再平衡</b>\n\n%s\n\n%s", result, notifier.FormatFundStatus(&state))
-	s.trySend(msg)
-
-	action := "NO_ACTION"
-	var amount float64
-	if state.ReserveBalance < stateBefore.ReserveBalance {
-		action = "TRANSFER_EXCESS"
-		amount = stateBefore.ReserveBalance - state.ReserveBalance
-	} else if state.ReserveBalance > stateBefore.ReserveBalance {
-		action = "EMERGENCY_TOPUP"
-		amount = state.ReserveBalance - stateBefore.ReserveBalance
-	}
-	if err := s.Recorder.RecordQuarterly(&recorder.QuarterlyEvent{
-		Action: action, Amount: amount,
-		RegularAfter: state.RegularBalance, ReserveAfter: state.ReserveBalance,
-		Note: result,
-	}); err != nil {
-		log.Printf("[ERROR] record quarterly: %v", err)
+	s.trySend(notifier.NewMessage(notifier.MsgQuarterly, notifier.PriorityNormal, msg))
+
+	record := func(symbol string, before, after *model.FundState) {
+		action, amount := rebalanceAction(before, after)
+		if err := s.Recorder.RecordQuarterly(&recorder.QuarterlyEvent{
+			Action: action, Amount: amount,
+			RegularAfter: after.RegularBalance, ReserveAfter: after.ReserveBalance,
+			Note: result, Symbol: symbol, OccurredAt: at,
+		}); err != nil {
+			log.Printf("[ERROR] record quarterly: %v", err)
+		}
+		s.recordFundEvent("QUARTERLY", before, after, amount, "季度再平衡", at)
+	}
+	record(s.Collector.Symbol, &stateBefore, &state)
+	// An allocated fund is rebalanced in one pass; each symbol is recorded.
+	for _, sym := range slices.Sorted(maps.Keys(state.Symbols)) {
+		if before := stateBefore.Symbols[sym]; before != nil {
+			record(sym, before, state.Symbols[sym])
+		}
+	}
+}
+
+// rebalanceAction names what a quarterly rebalance did to the reserve pool
+// between before and after, and the amount moved.
+func rebalanceAction(before, after *model.FundState) (action string, amount float64) {
+	switch {
+	case after.ReserveBalance < before.ReserveBalance:
+		return "TRANSFER_EXCESS", before.ReserveBalance - after.ReserveBalance
+	case after.ReserveBalance > before.ReserveBalance:
+		return "EMERGENCY_TOPUP", after.ReserveBalance - before.ReserveBalance
 	}
-	s.recordFundEvent("QUARTERLY", &stateBefore, &state, amount, "季度再平衡")
+	return "NO_ACTION", 0
 }
 
 // HandleCommand processes a user command and returns a reply.
-func (s *Scheduler) HandleCommand(command string) string {
-	switch command {
-	case "查看本周建议", "/weekly":
-		s.weeklyTask()
-		return ""
+func (s *Scheduler) HandleCommand(userID int64, command string) string {
+	s.confirm.ExpireStale()
+	if reply, handled := s.confirm.Resolve(userID, command); handled {
+		return reply
+	}
+
+	name, args := splitCommand(command)
+	switch name {
+	case "查看本周建议", "/weekly", "预览本周建议", "/preview":
+		return s.handleWeekly(args)
 	case "查看资金状态", "/fund":
-		state := s.Fund.GetState()
-		return notifier.FormatFundStatus(&state)
+		return s.handleFund()
+	case "确认成交", "/bought":
+		return s.handleBought(args)
+	case "确认卖出", "/sold":
+		return s.handleSold(userID, args)
+	case "调整预算", "/setbudget":
+		return s.handleSetBudget(userID, args)
+	case "暂停投入", "/pause":
+		return s.handlePause(args)
+	case "恢复投入", "/resume":
+		return s.handleResume()
+	case "存入资金", "/deposit":
+		return s.handleAdjust(userID, args, 1, depositUsage)
+	case "取出资金", "/withdraw":
+		return s.handleAdjust(userID, args, -1, withdrawUsage)
 	case "查看月报", "/monthly":
 		state := s.Fund.GetState()
-		return notifier.FormatMonthlySummary(&state)
+		return notifier.FormatMonthlySummary(&state, s.performance(&state, s.Clock.Now()))
+	case "查看统计", "/stats":
+		return s.handleStats(args)
+	case "查看状态", "/status":
+		return s.handleStatus()
+	case "资金对账", "/reconcile":
+		return s.requestConfirm(userID, s.reconcileAction())
+	case "对账报告", "/reconcile-report":
+		return s.handleReconcileReport(args)
+	case "行情缓存", "/cache":
+		return s.handleCache()
+	case "假设价格", "/whatif":
+		return s.handleWhatIf(args)
+	default:
+		return "可用命令:\n• 查看本周建议 [详细] (预览，加 确认 执行，加 强制 本周再次执行)\n• /preview [详细]\n• 查看资金状态\n• 查看月报\n• /stats [月数]\n• /status\n• /reconcile\n• /reconcile-report [月数]\n• /cache\n• /whatif 价格\n• /bought 金额 价格\n• /sold 份额 价格 [regular|reserve|external]\n• /setbudget 金额\n• /deposit regular|reserve 金额 [备注]\n• /withdraw regular|reserve 金额 [备注]\n• /pause [周数]\n• /resume"
+	}
+}
+
+// handleWeekly previews this week's evaluation, or runs the weekly task for
+// real when args hold "confirm" or "force"; "force" also invests again in a
+// week already invested. "detail" asks for the detailed report.
+func (s *Scheduler) handleWeekly(args []string) string {
+	var execute, detail, force bool
+	for _, a := range args {
+		switch a {
+		case "confirm", "确认":
+			execute = true
+		case "force", "强制":
+			execute, force = true, true
+		case "detail", "详细":
+			detail = true
+		}
+	}
+	if !execute {
+		return s.runWeekly(pipeline.WeeklyOptions{DryRun: true, At: s.Clock.Now()}, false, detail)
+	}
+	s.weeklyTaskStyled(s.Clock.Now(), detail, force)
+	return ""
+}
+
+// reconcileAction repairs the fund state and releases the invariant lock.
+func (s *Scheduler) reconcileAction() confirmable {
+	return confirmable{
+		Command: "/reconcile",
+		Preview: func() (string, error) {
+			before, after := s.Fund.PreviewReconcile()
+			return notifier.FormatReconcilePreview(s.Fund.Locked(), &before, &after), nil
+		},
+		Apply: func() (string, error) {
+			before, after, err := s.Fund.Reconcile()
+			if err != nil {
+				return "", err
+			}
+			s.recordFundEvent("RECONCILE", &before, &after, 0, "资金对账", s.Clock.Now())
+			s.recordOtherFundEvents("RECONCILE", &before, &after, func(_, _ *model.FundState) float64 { return 0 }, "资金对账", s.Clock.Now())
+			return "✅ 对账完成，资金变动已恢复\n\n" + notifier.FormatFundStatus(&after), nil
+		},
+	}
+}
+
+// fundViolationAlert sends the critical alert when the fund locks. It runs
+// under the fund lock, so it only formats and sends.
+func (s *Scheduler) fundViolationAlert(violation error, st model.FundState) {
+	s.trySend(notifier.NewMessage(notifier.MsgError, notifier.PriorityHigh, notifier.FormatFundViolation(violation, &st)))
+}
+
+// ChatOnly reports whether command may only come from the configured chat,
+// as it changes the fund's settings or pools rather than reporting or
+// recording.
+func (s *Scheduler) ChatOnly(command string) bool {
+	name, _ := splitCommand(command)
+	switch name {
+	case "调整预算", "/setbudget", "存入资金", "/deposit", "取出资金", "/withdraw",
+		"暂停投入", "/pause", "恢复投入", "/resume", "确认卖出", "/sold":
+		return true
+	}
+	return false
+}
+
+// splitCommand separates the command name from its whitespace-separated arguments.
+func splitCommand(command string) (string, []string) {
+	fields := strings.Fields(command)
+	if len(fields) == 0 {
+		return "", nil
+	}
+	return fields[0], fields[1:]
+}
+
+// handleStatus lists the next run of every task, including its start offset.
+func (s *Scheduler) handleStatus() string {
+	var b strings.Builder
+	b.WriteString("⏱ <b>任务计划</b>\n\n")
+	for _, j := range s.jobs {
+		next := s.Cron.Entry(j.id).Next
+		if next.IsZero() {
+			b.WriteString(fmt.Sprintf("%s: 未启动\n", j.name))
+			continue
+		}
+		b.WriteString(fmt.Sprintf("%s: %s", j.name, next.Add(j.offset).Format("2006-01-02 15:04:05")))
+		if j.offset > 0 {
+			b.WriteString(fmt.Sprintf(" (含随机偏移 +%s)", j.offset))
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// handleCache logs and reports the bar cache counters.
+func (s *Scheduler) handleCache() string {
+	cf, ok := s.Collector.Fetcher.(interface{ CacheStats() collector.CacheStats })
+	if !ok {
+		return "行情缓存未启用"
+	}
+	st := cf.CacheStats()
+	log.Printf("[INFO] bar cache: %s", st)
+	return fmt.Sprintf("🗄 <b>行情缓存</b>\n\n命中: %d\n增量拉取: %d\n完整拉取: %d\n数据源失败时使用缓存: %d\n拉取K线: %d\n缓存读写失败: %d",
+		st.Hits, st.TailFetches, st.Misses, st.Stale, st.BarsFetched, st.StoreErrors)
+}
+
+// handleFund reports the pools and the holdings, valued at current prices.
+func (s *Scheduler) handleFund() string {
+	state := s.Fund.GetState()
+	reply := notifier.FormatFundStatus(&state)
+	if len(state.Holdings) == 0 {
+		return reply
+	}
+	prices := make(map[string]float64, len(state.Holdings))
+	for sym := range state.Holdings {
+		price, err := s.Collector.For(sym).FetchPrice()
+		if err != nil {
+			log.Printf("[WARN] %s holding valuation: %v", sym, err)
+			continue
+		}
+		prices[sym] = price
+	}
+	return reply + "\n" + notifier.FormatHoldings(state.Holdings, prices)
+}
+
+// boughtUsage is the /bought reply to missing or malformed arguments.
+const boughtUsage = "用法: /bought 金额 价格，例如 /bought 1000 5200，记录实际成交的金额和价格"
+
+// handleBought records a fill of the primary symbol: the amount actually
+// spent, which may be less than suggested, at the execution price.
+func (s *Scheduler) handleBought(args []string) string {
+	if len(args) != 2 {
+		return boughtUsage
+	}
+	var vals [2]float64
+	for i, a := range args {
+		v, err := strconv.ParseFloat(strings.ReplaceAll(a, ",", ""), 64)
+		if err != nil || math.IsNaN(v) || math.IsInf(v, 0) {
+			return fmt.Sprintf("无法识别数字 %q\n%s", a, boughtUsage)
+		}
+		vals[i] = v
+	}
+	amount, price := vals[0], vals[1]
+	sym := s.Collector.Symbol
+	if err := s.Fund.RecordPurchase(sym, amount, price); err != nil {
+		return fmt.Sprintf("❌ 未记录: %v\n%s", err, boughtUsage)
+	}
+	if err := s.Recorder.RecordPurchase(&recorder.Purchase{
+		Symbol: sym, Amount: amount, Price: price, Units: amount / price, OccurredAt: s.Clock.Now(),
+	}); err != nil {
+		log.Printf("[ERROR] record purchase: %v", err)
+	}
+	state := s.Fund.GetState()
+	h := state.Holdings[sym]
+	return fmt.Sprintf("✅ 已记录成交: %s @ %.2f，买入 %.4f 份\n\n", model.FormatAmount(amount), price, amount/price) +
+		notifier.FormatHoldings(map[string]model.Holding{sym: h}, map[string]float64{sym: price})
+}
+
+// soldUsage is the /sold reply to missing or malformed arguments.
+const soldUsage = "用法: /sold 份额 价格 [regular|reserve|external]，例如 /sold 0.1 6000 reserve，记录卖出并将所得计入常规池、储备池或转出（默认）"
+
+// handleSold records a sale of the primary symbol: the units sold at the
+// execution price, and where the proceeds went, out of the fund by default.
+// Proceeds credited to a pool change the fund, so that sale waits for a
+// confirmation, see saleAction.
+func (s *Scheduler) handleSold(userID int64, args []string) string {
+	if len(args) != 2 && len(args) != 3 {
+		return soldUsage
+	}
+	var vals [2]float64
+	for i, a := range args[:2] {
+		v, err := strconv.ParseFloat(strings.ReplaceAll(a, ",", ""), 64)
+		if err != nil || math.IsNaN(v) || math.IsInf(v, 0) {
+			return fmt.Sprintf("无法识别数字 %q\n%s", a, soldUsage)
+		}
+		vals[i] = v
+	}
+	destination := fund.SaleToExternal
+	if len(args) == 3 {
+		destination = strings.ToLower(args[2])
+	}
+	action := s.saleAction(vals[0], vals[1], destination)
+	if destination == fund.SaleToRegular || destination == fund.SaleToReserve {
+		return s.requestConfirm(userID, action)
+	}
+	reply, err := action.Apply()
+	if err != nil {
+		return fmt.Sprintf("❌ 未记录: %v\n%s", err, soldUsage)
+	}
+	return reply
+}
+
+// saleAction records units of the primary symbol sold at price, with the
+// proceeds going to destination.
+func (s *Scheduler) saleAction(units, price float64, destination string) confirmable {
+	sym := s.Collector.Symbol
+	proceeds := units * price
+	what := fmt.Sprintf("卖出 %.4f 份 @ %.2f，所得 %s %s", units, price, model.FormatAmount(proceeds), saleDestinationText(destination))
+	return confirmable{
+		Command: "/sold",
+		Preview: func() (string, error) {
+			before, after, err := s.Fund.PreviewSale(sym, units, price, destination)
+			if err != nil {
+				return "", err
+			}
+			return notifier.FormatFundChangePreview(what, &before, &after), nil
+		},
+		Apply: func() (string, error) {
+			at := s.Clock.Now()
+			before := s.Fund.GetState()
+			realized, err := s.Fund.RecordSale(sym, units, price, destination)
+			if err != nil {
+				return "", err
+			}
+			after := s.Fund.GetState()
+			s.recordFundEvent("SALE", &before, &after, proceeds, fmt.Sprintf("卖出 %.4f 份，所得%s", units, saleDestinationText(destination)), at)
+			if err := s.Recorder.RecordSale(&recorder.Sale{
+				Symbol: sym, Units: units, Price: price, Proceeds: proceeds, RealizedPnL: realized,
+				Destination: destination, OccurredAt: at,
+			}); err != nil {
+				log.Printf("[ERROR] record sale: %v", err)
+			}
+			reply := fmt.Sprintf("✅ 已记录卖出: %.4f 份 @ %.2f，所得 %s %s\n已实现盈亏: %s\n\n",
+				units, price, model.FormatAmount(proceeds), saleDestinationText(destination), model.FormatAmountDelta(realized))
+			if h, ok := after.Holdings[sym]; ok {
+				return reply + notifier.FormatHoldings(map[string]model.Holding{sym: h}, map[string]float64{sym: price}), nil
+			}
+			return reply + "已全部卖出，当前无持仓", nil
+		},
+	}
+}
+
+// saleDestinationText describes where the proceeds of a sale went.
+func saleDestinationText(destination string) string {
+	switch destination {
+	case fund.SaleToRegular:
+		return "计入常规池"
+	case fund.SaleToReserve:
+		return "计入储备池"
 	default:
-		return "可用命令:\n• 查看本周建议\n• 查看资金状态\n• 查看月报"
+		return "已转出基金"
+	}
+}
+
+// setBudgetUsage is the /setbudget reply to missing or malformed arguments.
+const setBudgetUsage = "用法: /setbudget 金额，例如 /setbudget 15000，调整每月预算并重新计算每周基数 N"
+
+// handleSetBudget asks to confirm changing the monthly budget, see
+// budgetAction.
+func (s *Scheduler) handleSetBudget(userID int64, args []string) string {
+	if len(args) != 1 {
+		return setBudgetUsage
+	}
+	budget, err := strconv.ParseFloat(strings.ReplaceAll(args[0], ",", ""), 64)
+	if err != nil || math.IsNaN(budget) || math.IsInf(budget, 0) {
+		return fmt.Sprintf("无法识别数字 %q\n%s", args[0], setBudgetUsage)
+	}
+	return s.requestConfirm(userID, s.budgetAction(budget))
+}
+
+// budgetAction changes the monthly budget, leaving the balances as they are
+// until the next replenish.
+func (s *Scheduler) budgetAction(budget float64) confirmable {
+	return confirmable{
+		Command: "/setbudget",
+		Preview: func() (string, error) {
+			before, after, err := s.Fund.PreviewBudget(budget)
+			if err != nil {
+				return "", err
+			}
+			return notifier.FormatFundChangePreview("调整预算", &before, &after), nil
+		},
+		Apply: func() (string, error) {
+			before, after, err := s.Fund.UpdateBudget(budget)
+			if err != nil {
+				return "", err
+			}
+			s.recordFundEvent("BUDGET_CHANGE", &before, &after, budget,
+				fmt.Sprintf("月度预算 %s → %s", model.FormatAmount(before.TotalBudget()), model.FormatAmount(after.TotalBudget())), s.Clock.Now())
+			baseN := fmt.Sprintf("%s → %s", model.FormatAmount(before.WeeklyBaseN), model.FormatAmount(after.WeeklyBaseN))
+			if len(after.Symbols) > 0 {
+				baseN = after.Symbol + " " + baseN
+				for _, sym := range slices.Sorted(maps.Keys(after.Symbols)) {
+					baseN += fmt.Sprintf("；%s %s → %s", sym,
+						model.FormatAmount(before.Symbols[sym].WeeklyBaseN), model.FormatAmount(after.Symbols[sym].WeeklyBaseN))
+				}
+			}
+			return fmt.Sprintf("✅ 月度预算已调整: %s → %s\n每周基数 N: %s\n现有余额不变，下次月度补充起按新预算分配",
+				model.FormatAmount(before.TotalBudget()), model.FormatAmount(after.TotalBudget()), baseN), nil
+		},
+	}
+}
+
+// pauseUsage is the /pause reply to malformed arguments.
+const pauseUsage = "用法: /pause [周数]，例如 /pause 8，暂停投入 8 周后自动恢复；不带周数则暂停至 /resume"
+
+// handlePause pauses investing, for the number of weeks in args if given.
+func (s *Scheduler) handlePause(args []string) string {
+	if len(args) > 1 {
+		return pauseUsage
+	}
+	now := s.Clock.Now()
+	var until time.Time
+	note := "暂停投入"
+	if len(args) == 1 {
+		weeks, err := strconv.Atoi(args[0])
+		if err != nil || weeks <= 0 {
+			return fmt.Sprintf("周数 %q 无效，需为正整数\n%s", args[0], pauseUsage)
+		}
+		until = now.AddDate(0, 0, 7*weeks)
+		note = fmt.Sprintf("暂停投入 %d 周，至 %s", weeks, until.Format("2006-01-02"))
+	}
+	before, after, err := s.Fund.Pause(until)
+	if err != nil {
+		return fmt.Sprintf("❌ 未暂停: %v", err)
+	}
+	s.recordFundEvent("PAUSE", &before, &after, 0, note, now)
+	reply := "⏸ 已" + note + "\n周报与每日监控照常运行，不扣款、不记录资金变动"
+	if !s.Fund.PauseReplenish() {
+		reply += "；月度资金照常补充"
+	}
+	return reply
+}
+
+// handleResume lifts a pause.
+func (s *Scheduler) handleResume() string {
+	before, after, err := s.Fund.Resume()
+	if err != nil {
+		return fmt.Sprintf("❌ 未恢复: %v", err)
+	}
+	s.recordFundEvent("RESUME", &before, &after, 0, "恢复投入", s.Clock.Now())
+	return "▶️ 已恢复投入\n\n" + notifier.FormatFundStatus(&after)
+}
+
+// investingPaused lifts a pause that has run out as of at, reporting and
+// recording it, and reports whether investing is still paused.
+func (s *Scheduler) investingPaused(at time.Time) bool {
+	if before, after, resumed := s.Fund.ResumeIfExpired(); resumed {
+		s.trySend(notifier.NewMessage(notifier.MsgObservation, notifier.PriorityNormal, "▶️ 暂停期已结束，已自动恢复投入"))
+		s.recordFundEvent("RESUME", &before, &after, 0, "暂停到期，自动恢复投入", at)
+	}
+	return s.Fund.IsPaused()
+}
+
+// depositUsage and withdrawUsage are the /deposit and /withdraw replies to
+// missing or malformed arguments.
+const (
+	depositUsage  = "用法: /deposit regular|reserve 金额 [备注]，例如 /deposit reserve 5000 年终奖，向资金池存入预算外资金"
+	withdrawUsage = "用法: /withdraw regular|reserve 金额 [备注]，例如 /withdraw regular 2000 应急，从资金池取出资金"
+)
+
+// handleAdjust asks to confirm a deposit into or, for a negative sign, a
+// withdrawal from the pool named in args of the amount after it, the rest of
+// args being the note; see adjustAction.
+func (s *Scheduler) handleAdjust(userID int64, args []string, sign float64, usage string) string {
+	if len(args) < 2 {
+		return usage
+	}
+	pool := poolName(args[0])
+	amount, err := strconv.ParseFloat(strings.ReplaceAll(args[1], ",", ""), 64)
+	if err != nil || math.IsNaN(amount) || math.IsInf(amount, 0) {
+		return fmt.Sprintf("无法识别数字 %q\n%s", args[1], usage)
+	}
+	if !(amount > 0) {
+		return fmt.Sprintf("金额 %v 无效，需大于 0\n%s", amount, usage)
+	}
+	return s.requestConfirm(userID, s.adjustAction(pool, sign*amount, strings.Join(args[2:], " ")))
+}
+
+// adjustAction deposits delta into pool, or withdraws it when negative.
+func (s *Scheduler) adjustAction(pool string, delta float64, note string) confirmable {
+	command, action := "/deposit", "存入"
+	if delta < 0 {
+		command, action = "/withdraw", "取出"
+	}
+	what := fmt.Sprintf("手动%s%s %s", action, poolText(pool), model.FormatAmount(math.Abs(delta)))
+	return confirmable{
+		Command: command,
+		Preview: func() (string, error) {
+			before, after, err := s.Fund.PreviewAdjust(pool, delta)
+			if err != nil {
+				return "", err
+			}
+			return notifier.FormatFundChangePreview(what, &before, &after), nil
+		},
+		Apply: func() (string, error) {
+			before, after, err := s.Fund.Adjust(pool, delta, note)
+			if err != nil {
+				return "", err
+			}
+			eventNote := what
+			if note != "" {
+				eventNote += "：" + note
+			}
+			s.recordFundEvent("MANUAL_ADJUST", &before, &after, delta, eventNote, s.Clock.Now())
+			return fmt.Sprintf("✅ 已%s\n\n%s", what, notifier.FormatFundStatus(&after)), nil
+		},
+	}
+}
+
+// poolName maps a pool argument, in English or Chinese, to the fund's pool
+// name; anything else is returned as given for the fund to reject.
+func poolName(arg string) string {
+	switch strings.ToLower(arg) {
+	case "常规", "常规池":
+		return fund.PoolRegular
+	case "储备", "储备池":
+		return fund.PoolReserve
+	}
+	return strings.ToLower(arg)
+}
+
+// poolText names pool for a reply.
+func poolText(pool string) string {
+	if pool == fund.PoolReserve {
+		return "储备池"
+	}
+	return "常规池"
+}
+
+// whatIfUsage is the /whatif reply to missing or malformed arguments.
+const whatIfUsage = "用法: /whatif 价格，例如 /whatif 5200，按假设的当前价格模拟本周建议"
+
+// handleWhatIf simulates the weekly evaluation at the price in args, without
+// touching the fund or the history.
+func (s *Scheduler) handleWhatIf(args []string) string {
+	if len(args) != 1 {
+		return whatIfUsage
+	}
+	price, err := strconv.ParseFloat(strings.ReplaceAll(args[0], ",", ""), 64)
+	if err != nil || math.IsNaN(price) || math.IsInf(price, 0) {
+		return fmt.Sprintf("无法识别价格 %q\n%s", args[0], whatIfUsage)
+	}
+	res, err := s.Pipeline.WhatIf(price)
+	var rangeErr *pipeline.PriceRangeError
+	switch {
+	case errors.As(err, &rangeErr):
+		return fmt.Sprintf("假设价格 %s 不合理：需大于 0 且不超过当前价格 %.2f 的 %d 倍",
+			args[0], rangeErr.Current, pipeline.MaxWhatIfRatio)
+	case err != nil:
+		log.Printf("[ERROR] whatif: %v", err)
+		return fmt.Sprintf("❌ 模拟失败: %v", err)
+	}
+	return notifier.FormatWhatIf(res)
+}
+
+// defaultStatsMonths is the /stats window when no month count is given.
+const defaultStatsMonths = 6
+
+// parseMonths reads an optional month count argument in 1-120.
+func parseMonths(args []string) (int, bool) {
+	if len(args) == 0 {
+		return defaultStatsMonths, true
+	}
+	n, err := strconv.Atoi(args[0])
+	if err != nil || n <= 0 || n > 120 {
+		return 0, false
+	}
+	return n, true
+}
+
+func (s *Scheduler) handleStats(args []string) string {
+	months, ok := parseMonths(args)
+	if !ok {
+		return "用法: /stats [月数]，月数为 1-120 的整数"
+	}
+
+	snaps, err := s.Recorder.WeeklySnapshotsSince(s.Collector.Symbol, s.Clock.Now().AddDate(0, -months, 0))
+	if err != nil {
+		log.Printf("[ERROR] stats query: %v", err)
+		return fmt.Sprintf("❌ 查询历史失败: %v", err)
+	}
+	return notifier.FormatTierStats(reporting.AggregateTiers(snaps), months)
+}
+
+// handleReconcileReport compares the simulated ledger with imported trades
+// month by month, starting from the first day of the oldest month.
+func (s *Scheduler) handleReconcileReport(args []string) string {
+	months, ok := parseMonths(args)
+	if !ok {
+		return "用法: /reconcile-report [月数]，月数为 1-120 的整数"
 	}
+
+	now := s.Clock.Now()
+	from := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location()).AddDate(0, -(months - 1), 0)
+	to := now.Add(time.Second)
+	events, err := s.Recorder.FundEventsBetween(from, to)
+	if err != nil {
+		log.Printf("[ERROR] reconcile report fund history: %v", err)
+		return fmt.Sprintf("❌ 查询历史失败: %v", err)
+	}
+	trades, err := s.Recorder.TradesBetween(from, to)
+	if err != nil {
+		log.Printf("[ERROR] reconcile report trades: %v", err)
+		return fmt.Sprintf("❌ 查询成交失败: %v", err)
+	}
+	return notifier.FormatReconcileReport(reporting.ReconcileMonths(events, trades), months)
 }
 
-func (s *Scheduler) recordFundEvent(eventType string, before, after *model.FundState, amount float64, note string) {
+// requestConfirm sends the preview of a destructive command with a confirm/cancel
+// keyboard. The change is only applied once the same user confirms in time.
+func (s *Scheduler) requestConfirm(userID int64, action confirmable) string {
+	preview, err := s.confirm.Request(userID, action)
+	if err != nil {
+		return fmt.Sprintf("❌ %v", err)
+	}
+	if err := s.Notifier.SendWithKeyboard(preview, [][]string{{confirmYes, confirmNo}}); err != nil {
+		log.Printf("[ERROR] send confirmation preview: %v", err)
+	}
+	return ""
+}
+
+func (s *Scheduler) recordCommandAudit(userID int64, command, stage, detail string) {
+	if err := s.Recorder.RecordCommandAudit(&recorder.CommandAuditEvent{
+		UserID:     userID,
+		Command:    command,
+		Stage:      stage,
+		Detail:     detail,
+		OccurredAt: s.Clock.Now(),
+	}); err != nil {
+		log.Printf("[ERROR] record command audit: %v", err)
+	}
+}
+
+func (s *Scheduler) recordFundEvent(eventType string, before, after *model.FundState, amount float64, note string, at time.Time) {
 	if err := s.Recorder.RecordFundEvent(&recorder.FundEvent{
 		EventType:     eventType,
 		RegularBefore: before.RegularBalance,
@@ -244,13 +1372,115 @@ func (s *Scheduler) recordFundEvent(eventType string, before, after *model.FundS
 		ReserveAfter:  after.ReserveBalance,
 		Amount:        amount,
 		Note:          note,
+		Symbol:        after.Symbol,
+		OccurredAt:    at,
 	}); err != nil {
 		log.Printf("[ERROR] record fund event: %v", err)
 	}
 }
 
-func (s *Scheduler) trySend(text string) {
-	if err := s.Notifier.SendWithRetry(s.Ctx, text, 3); err != nil {
-		log.Printf("[ERROR] send notification: %v", err)
+// recordOtherFundEvents records eventType for each other symbol of an
+// allocated fund whose pools a fund-wide operation changed between before and
+// after, amountOf giving the amount of each.
+func (s *Scheduler) recordOtherFundEvents(eventType string, before, after *model.FundState, amountOf func(before, after *model.FundState) float64, note string, at time.Time) {
+	for _, sym := range slices.Sorted(maps.Keys(after.Symbols)) {
+		b, a := before.Symbols[sym], after.Symbols[sym]
+		if b == nil || (b.RegularBalance == a.RegularBalance && b.ReserveBalance == a.ReserveBalance) {
+			continue
+		}
+		s.recordFundEvent(eventType, b, a, amountOf(b, a), note, at)
+	}
+}
+
+// sampleMetricsTask writes the current registry values to the recorder and
+// prunes samples past the retention window.
+func (s *Scheduler) sampleMetricsTask(at time.Time) {
+	collected := s.Metrics.Collect()
+	samples := make([]recorder.MetricSample, len(collected))
+	for i, m := range collected {
+		samples[i] = recorder.MetricSample{Timestamp: at, Name: m.Name, Labels: m.Labels, Value: m.Value}
+	}
+	if err := s.Recorder.RecordMetricSamples(samples); err != nil {
+		log.Printf("[ERROR] record metric samples: %v", err)
+	}
+	if s.metricsRetention > 0 {
+		if n, err := s.Recorder.PruneMetricSamples(at.Add(-s.metricsRetention)); err != nil {
+			log.Printf("[ERROR] prune metric samples: %v", err)
+		} else if n > 0 {
+			log.Printf("[INFO] pruned %d metric samples", n)
+		}
+	}
+}
+
+// opsWindow is the span of sampled metrics summarized in the weekly report footer.
+const opsWindow = 24 * time.Hour
+
+// opsFooter summarizes recent operational metrics, or returns "" when metrics are disabled.
+func (s *Scheduler) opsFooter() string {
+	if s.Metrics == nil {
+		return ""
+	}
+	samples, err := s.Recorder.MetricSamplesSince(s.Clock.Now().Add(-opsWindow))
+	if err != nil {
+		log.Printf("[ERROR] load metric samples: %v", err)
+		return ""
+	}
+	return notifier.FormatOpsSummary(reporting.SummarizeOps(samples))
+}
+
+// fundChartMonths is the window of fund history plotted in the monthly report.
+const fundChartMonths = 6
+
+// fundChart renders pool balances over the last fundChartMonths months.
+func (s *Scheduler) fundChart() ([]byte, error) {
+	history, err := s.Recorder.FundHistorySince(s.Clock.Now().AddDate(0, -fundChartMonths, 0))
+	if err != nil {
+		return nil, fmt.Errorf("load fund history: %w", err)
+	}
+	c, err := reporting.FundBalanceChart(history)
+	if err != nil {
+		return nil, err
+	}
+	return c.RenderPNG()
+}
+
+// trySendPhoto delivers msg as a photo caption, falling back to text if the upload fails.
+func (s *Scheduler) trySendPhoto(msg notifier.Message, png []byte) {
+	if _, err := s.Notifier.SendPhoto(s.Ctx, msg, png); err != nil {
+		log.Printf("[WARN] send %s photo failed, falling back to text: %v", msg.Type, err)
+		s.trySend(msg)
+		return
+	}
+	if err := s.Recorder.RecordDelivery(&recorder.DeliveryEvent{
+		MessageType: string(msg.Type),
+		Priority:    msg.Priority.String(),
+		Status:      "SENT",
+		Length:      len(msg.Text),
+		OccurredAt:  s.Clock.Now(),
+	}); err != nil {
+		log.Printf("[ERROR] record delivery: %v", err)
+	}
+}
+
+// trySend delivers msg, records the delivery outcome and returns the sent
+// message id, or 0 if sending failed.
+func (s *Scheduler) trySend(msg notifier.Message) int64 {
+	evt := &recorder.DeliveryEvent{
+		MessageType: string(msg.Type),
+		Priority:    msg.Priority.String(),
+		Status:      "SENT",
+		Length:      len(msg.Text),
+		OccurredAt:  s.Clock.Now(),
+	}
+	id, err := s.Notifier.SendMessage(s.Ctx, msg, 3)
+	if err != nil {
+		log.Printf("[ERROR] send %s notification: %v", msg.Type, err)
+		evt.Status = "FAILED"
+		evt.Error = err.Error()
+		s.Metrics.Inc(reporting.MetricNotifyFailures, metrics.Labels{"type": string(msg.Type)})
+	}
+	if err := s.Recorder.RecordDelivery(evt); err != nil {
+		log.Printf("[ERROR] record delivery: %v", err)
 	}
+	return id
 }
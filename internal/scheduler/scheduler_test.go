@@ -0,0 +1,1294 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"MarketSentinel/internal/collector"
+	"MarketSentinel/internal/fund"
+	"MarketSentinel/internal/model"
+	"MarketSentinel/internal/notifier"
+	"MarketSentinel/internal/recorder"
+	"MarketSentinel/internal/strategy"
+)
+
+type fakeNotifier struct {
+	sent   []notifier.Message
+	photos int
+	fail   bool
+}
+
+// SendMessage records msg and returns its 1-based position as the message id.
+func (f *fakeNotifier) SendMessage(_ context.Context, msg notifier.Message, _ int) (int64, error) {
+	f.sent = append(f.sent, msg)
+	if f.fail {
+		return 0, errors.New("send failed")
+	}
+	return int64(len(f.sent)), nil
+}
+
+func (f *fakeNotifier) SendPhoto(_ context.Context, msg notifier.Message, photo []byte) (int64, error) {
+	f.sent = append(f.sent, msg)
+	f.photos++
+	return int64(len(f.sent)), nil
+}
+
+func (f *fakeNotifier) SendWithKeyboard(text string, _ [][]string) error {
+	f.sent = append(f.sent, notifier.Message{Text: text})
+	return nil
+}
+
+func (f *fakeNotifier) types() []notifier.MessageType {
+	var out []notifier.MessageType
+	for _, m := range f.sent {
+		out = append(out, m.Type)
+	}
+	return out
+}
+
+type captureRecorder struct {
+	*recorder.NoopRecorder
+	deliveries  []recorder.DeliveryEvent
+	dailyChecks []recorder.DailyCheckEvent
+	fundHistory []recorder.FundHistoryPoint
+	weekly      []recorder.WeeklySnapshot
+	purchases   []recorder.Purchase
+	sales       []recorder.Sale
+	fundEvents  []recorder.FundEvent
+	monthly     []recorder.MonthlyEvent
+	taskRuns    []recorder.TaskRun
+}
+
+func (c *captureRecorder) RecordPurchase(p *recorder.Purchase) error {
+	c.purchases = append(c.purchases, *p)
+	return nil
+}
+
+func (c *captureRecorder) PurchasesSince(string, time.Time) ([]recorder.Purchase, error) {
+	return c.purchases, nil
+}
+
+func (c *captureRecorder) RecordSale(sale *recorder.Sale) error {
+	c.sales = append(c.sales, *sale)
+	return nil
+}
+
+func (c *captureRecorder) SalesSince(string, time.Time) ([]recorder.Sale, error) {
+	return c.sales, nil
+}
+
+func (c *captureRecorder) RecordFundEvent(evt *recorder.FundEvent) error {
+	c.fundEvents = append(c.fundEvents, *evt)
+	return nil
+}
+
+func (c *captureRecorder) RecordMonthly(evt *recorder.MonthlyEvent) error {
+	c.monthly = append(c.monthly, *evt)
+	return nil
+}
+
+func (c *captureRecorder) RecordWeekly(snap *recorder.WeeklySnapshot) error {
+	c.weekly = append(c.weekly, *snap)
+	return nil
+}
+
+func (c *captureRecorder) FundHistorySince(time.Time) ([]recorder.FundHistoryPoint, error) {
+	return c.fundHistory, nil
+}
+
+func (c *captureRecorder) RecordDailyCheck(evt *recorder.DailyCheckEvent) error {
+	c.dailyChecks = append(c.dailyChecks, *evt)
+	return nil
+}
+
+func (c *captureRecorder) RecordTaskRun(run *recorder.TaskRun) error {
+	c.taskRuns = append(c.taskRuns, *run)
+	return nil
+}
+
+func (c *captureRecorder) RecordDelivery(evt *recorder.DeliveryEvent) error {
+	c.deliveries = append(c.deliveries, *evt)
+	return nil
+}
+
+type failingFetcher struct{}
+
+func (failingFetcher) Name() string { return "failing" }
+func (failingFetcher) FetchDailyBars(string, int) ([]model.OHLCV, error) {
+	return nil, errors.New("boom")
+}
+func (failingFetcher) FetchWeeklyBars(string, int) ([]model.OHLCV, error) {
+	return nil, errors.New("boom")
+}
+func (failingFetcher) FetchCurrentPrice(string) (float64, error) { return 0, errors.New("boom") }
+
+// barsFromCloses builds daily bars ending yesterday with the given closes.
+func barsFromCloses(closes []float64) []model.OHLCV {
+	bars := make([]model.OHLCV, len(closes))
+	for i, c := range closes {
+		bars[i] = model.OHLCV{
+			Time: time.Now().AddDate(0, 0, -(len(closes) - i)),
+			Open: c, High: c * 1.01, Low: c * 0.99, Close: c, Volume: 1000,
+		}
+	}
+	return bars
+}
+
+// trendCloses returns n closes moving by step each bar.
+func trendCloses(start, step float64, n int) []float64 {
+	out := make([]float64, n)
+	for i := range out {
+		out[i] = start + step*float64(i)
+	}
+	return out
+}
+
+// choppyCloses alternates up and down to keep RSI near 50.
+func choppyCloses(base float64, n int) []float64 {
+	out := make([]float64, n)
+	for i := range out {
+		if i%2 == 0 {
+			out[i] = base
+		} else {
+			out[i] = base + 10
+		}
+	}
+	return out
+}
+
+func newTestScheduler(t *testing.T, fetcher collector.Fetcher) (*Scheduler, *fakeNotifier, *captureRecorder) {
+	t.Helper()
+	fm, err := fund.NewManager(fund.NewFileStore(filepath.Join(t.TempDir(), "fund_state.json")), 10000, fund.Policy{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	fn := &fakeNotifier{}
+	rec := &captureRecorder{NoopRecorder: recorder.NewNoopRecorder()}
+	s := NewScheduler(context.Background(), openEveryDay(t, collector.NewCollector(fetcher, "SPX500")), fm, fn, rec)
+	return s, fn, rec
+}
+
+// openEveryDay gives col a calendar without closures, so daily checks run
+// whatever day the tests do.
+func openEveryDay(t *testing.T, col *collector.Collector) *collector.Collector {
+	t.Helper()
+	cal, err := collector.NewTradingCalendar(collector.ExchangeCrypto, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	col.Trading = cal
+	return col
+}
+
+func assertTypes(t *testing.T, fn *fakeNotifier, want ...notifier.MessageType) {
+	t.Helper()
+	got := fn.types()
+	if len(got) != len(want) {
+		t.Fatalf("expected message types %v, got %v", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("message %d: expected %s, got %s", i, want[i], got[i])
+		}
+	}
+}
+
+func TestMessageTypes_Weekly(t *testing.T) {
+	s, fn, _ := newTestScheduler(t, &collector.MockFetcher{Price: 5000})
+	s.weeklyTask(time.Now())
+	assertTypes(t, fn, notifier.MsgWeeklyReport)
+}
+
+func TestMessageTypes_WeeklyCollectError(t *testing.T) {
+	s, fn, _ := newTestScheduler(t, failingFetcher{})
+	s.weeklyTask(time.Now())
+	assertTypes(t, fn, notifier.MsgError)
+	if fn.sent[0].Priority != notifier.PriorityHigh {
+		t.Errorf("expected high priority error, got %s", fn.sent[0].Priority)
+	}
+}
+
+func TestMessageTypes_BottomFish(t *testing.T) {
+	s, fn, _ := newTestScheduler(t, &collector.MockFetcher{
+		Price:      4000,
+		DailyData:  barsFromCloses(trendCloses(5000, -5, 300)),
+		WeeklyData: barsFromCloses(choppyCloses(4500, 60)),
+	})
+	s.dailyCheck(time.Now())
+	assertTypes(t, fn, notifier.MsgBottomFish)
+}
+
+func TestMessageTypes_TakeProfit(t *testing.T) {
+	s, fn, _ := newTestScheduler(t, &collector.MockFetcher{
+		Price:      6500,
+		DailyData:  barsFromCloses(trendCloses(5000, 5, 300)),
+		WeeklyData: barsFromCloses(choppyCloses(5500, 60)),
+	})
+	s.dailyCheck(time.Now())
+	assertTypes(t, fn, notifier.MsgTakeProfit)
+}
+
+func TestDailyCheck_IntradayRSI(t *testing.T) {
+	daily := barsFromCloses(choppyCloses(5000, 300))
+	fetcher := &collector.MockFetcher{
+		Price:      4500,
+		DailyData:  daily,
+		WeeklyData: barsFromCloses(choppyCloses(5000, 60)),
+	}
+	s, fn, _ := newTestScheduler(t, fetcher)
+	s.dailyCheck(time.Now())
+	assertTypes(t, fn) // no intraday bars: yesterday's RSI is near 50
+
+	// Today's selloff drives the intraday-updated RSI below 30.
+	today := time.Now().UTC().Truncate(24 * time.Hour).Add(15 * time.Hour)
+	fetcher.IntradayData = []model.OHLCV{
+		{Time: today, Open: 5000, High: 5000, Low: 4700, Close: 4700},
+		{Time: today.Add(time.Hour), Open: 4700, High: 4700, Low: 4500, Close: 4500},
+	}
+	s.dailyCheck(time.Now())
+	assertTypes(t, fn, notifier.MsgBottomFish)
+}
+
+func TestDailyCheck_SkipsMarketHoliday(t *testing.T) {
+	s, fn, _ := newTestScheduler(t, &collector.MockFetcher{
+		Price:      4000,
+		DailyData:  barsFromCloses(trendCloses(5000, -5, 300)),
+		WeeklyData: barsFromCloses(choppyCloses(4500, 60)),
+	})
+	nyse, err := collector.NewTradingCalendar(collector.ExchangeNYSE, nil)
+	if err != nil {
+		t.Skipf("no time zone data: %v", err)
+	}
+	s.Pipeline.Collector.Trading = nyse
+
+	// Christmas Day, 22:00 in Shanghai, is still 25 December in New York.
+	shanghai := time.FixedZone("CST", 8*3600)
+	s.dailyCheck(time.Date(2025, 12, 25, 22, 0, 0, 0, shanghai))
+	assertTypes(t, fn)
+
+	s.dailyCheck(time.Date(2025, 12, 26, 22, 0, 0, 0, shanghai))
+	assertTypes(t, fn, notifier.MsgBottomFish)
+}
+
+func TestMessageTypes_MonthlyQuarterly(t *testing.T) {
+	s, fn, _ := newTestScheduler(t, &collector.MockFetcher{Price: 5000})
+	s.monthlyTask(time.Now())
+	s.quarterlyTask(time.Now())
+	assertTypes(t, fn, notifier.MsgMonthly, notifier.MsgQuarterly)
+}
+
+func TestMessageTypes_RecapWithoutSnapshot(t *testing.T) {
+	s, fn, _ := newTestScheduler(t, &collector.MockFetcher{Price: 5000})
+	s.weeklyRecapTask(time.Now())
+	assertTypes(t, fn, notifier.MsgObservation)
+}
+
+func TestTrySend_RecordsDelivery(t *testing.T) {
+	s, fn, rec := newTestScheduler(t, &collector.MockFetcher{Price: 5000})
+	s.trySend(notifier.NewMessage(notifier.MsgMonthly, notifier.PriorityNormal, "hello"))
+	fn.fail = true
+	s.trySend(notifier.NewMessage(notifier.MsgError, notifier.PriorityHigh, "oops"))
+
+	if len(rec.deliveries) != 2 {
+		t.Fatalf("expected 2 delivery records, got %d", len(rec.deliveries))
+	}
+	if d := rec.deliveries[0]; d.MessageType != "monthly" || d.Status != "SENT" || d.Length != 5 {
+		t.Errorf("unexpected first delivery: %+v", d)
+	}
+	if d := rec.deliveries[1]; d.MessageType != "error" || d.Status != "FAILED" || d.Priority != "high" || d.Error == "" {
+		t.Errorf("unexpected second delivery: %+v", d)
+	}
+}
+
+func TestEvaluateDaily_DegradedSuppressesTriggers(t *testing.T) {
+	keys := []string{model.IndicatorDailyRSI, model.IndicatorWeeklyRSI, model.IndicatorPrice}
+	// Every non-empty combination of degraded trigger inputs.
+	for mask := 1; mask < 1<<len(keys); mask++ {
+		var degraded []string
+		for i, k := range keys {
+			if mask&(1<<i) != 0 {
+				degraded = append(degraded, k)
+			}
+		}
+		// Both an oversold and an overbought reading must stay silent. Bottom-fishing
+		// doesn't depend on weekly RSI, so a weekly-only degradation is covered separately.
+		for _, rsi := range []float64{20, 90} {
+			if rsi < 30 && mask == 1<<1 {
+				continue
+			}
+			s, fn, rec := newTestScheduler(t, &collector.MockFetcher{Price: 5000})
+			before := s.Fund.GetState()
+
+			ind := &model.MarketIndicators{
+				CurrentPrice: 5000, MA200: 5000, MA20w: 5000, MA50w: 5000,
+				DailyRSI: rsi, WeeklyRSI: rsi, High52w: 6000, Low52w: 4000,
+				High30d: 5200, Low30d: 4800, Position52w: 0.5,
+			}
+			for _, k := range degraded {
+				ind.MarkDegraded(k, "test")
+			}
+			s.evaluateDaily(ind, time.Now())
+
+			after := s.Fund.GetState()
+			if after.ReserveBalance != before.ReserveBalance || after.RegularBalance != before.RegularBalance ||
+				after.BottomFishLevel != 0 {
+				t.Errorf("degraded %v rsi=%.0f: fund state mutated", degraded, rsi)
+			}
+			if len(fn.sent) != 0 {
+				t.Errorf("degraded %v rsi=%.0f: unexpected messages %v", degraded, rsi, fn.types())
+			}
+			if len(rec.dailyChecks) != 1 || rec.dailyChecks[0].EventType != "OBSERVE" || rec.dailyChecks[0].Note == "" {
+				t.Errorf("degraded %v rsi=%.0f: expected one OBSERVE row, got %+v", degraded, rsi, rec.dailyChecks)
+			}
+		}
+	}
+}
+
+func TestEvaluateDaily_WeeklyDegradedStillBottomFishes(t *testing.T) {
+	s, fn, _ := newTestScheduler(t, &collector.MockFetcher{Price: 5000})
+	ind := &model.MarketIndicators{
+		CurrentPrice: 5000, MA200: 5000, MA20w: 5000, MA50w: 5000,
+		DailyRSI: 20, WeeklyRSI: 50, High52w: 6000, Low52w: 4000,
+		High30d: 5200, Low30d: 4800, Position52w: 0.5,
+	}
+	ind.MarkDegraded(model.IndicatorWeeklyRSI, "test")
+	s.evaluateDaily(ind, time.Now())
+	assertTypes(t, fn, notifier.MsgBottomFish)
+}
+
+func TestEvaluateDaily_SourceDivergence(t *testing.T) {
+	tests := []struct {
+		name string
+		rsi  float64
+		want []notifier.MessageType
+	}{
+		{"oversold: bottom-fish skipped", 20, []notifier.MessageType{notifier.MsgSourceAlert}},
+		{"overbought: take-profit still warns", 90, []notifier.MessageType{notifier.MsgSourceAlert, notifier.MsgTakeProfit}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, fn, _ := newTestScheduler(t, &collector.MockFetcher{Price: 5000})
+			before := s.Fund.GetState()
+			ind := &model.MarketIndicators{
+				CurrentPrice: 5000, MA200: 5000, MA20w: 5000, MA50w: 5000,
+				DailyRSI: tt.rsi, WeeklyRSI: tt.rsi, High52w: 6000, Low52w: 4000,
+				High30d: 5200, Low30d: 4800, Position52w: 0.5,
+				SourceWarning: "price 5000.00 vs 5100.00 (2.0%)",
+			}
+			s.evaluateDaily(ind, time.Now())
+			assertTypes(t, fn, tt.want...)
+			if !strings.Contains(fn.sent[0].Text, ind.SourceWarning) {
+				t.Errorf("source alert lacks the warning:\n%s", fn.sent[0].Text)
+			}
+			if after := s.Fund.GetState(); after.ReserveBalance != before.ReserveBalance || after.BottomFishLevel != 0 {
+				t.Errorf("fund changed while sources disagree: %+v", after)
+			}
+		})
+	}
+}
+
+func TestEvaluateDaily_CustomThresholds(t *testing.T) {
+	t.Cleanup(func() { strategy.SetRSIThresholds(strategy.DefaultRSIThresholds) })
+	ind := func(daily, weekly float64) *model.MarketIndicators {
+		return &model.MarketIndicators{
+			CurrentPrice: 5000, MA200: 5000, MA20w: 5000, MA50w: 5000,
+			DailyRSI: daily, WeeklyRSI: weekly, High52w: 6000, Low52w: 4000,
+			High30d: 5200, Low30d: 4800, Position52w: 0.5,
+		}
+	}
+	tests := []struct {
+		name          string
+		thresholds    strategy.RSIThresholds
+		daily, weekly float64
+		want          []notifier.MessageType
+	}{
+		{"default bottom-fish", strategy.DefaultRSIThresholds, 28, 50, []notifier.MessageType{notifier.MsgBottomFish}},
+		{"stricter bottom-fish", strategy.RSIThresholds{BottomFish: 25, TakeProfit: 85}, 28, 50, nil},
+		{"default take-profit quiet", strategy.DefaultRSIThresholds, 50, 82, nil},
+		{"earlier take-profit", strategy.RSIThresholds{BottomFish: 30, TakeProfit: 80}, 50, 82, []notifier.MessageType{notifier.MsgTakeProfit}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			strategy.SetRSIThresholds(tt.thresholds)
+			s, fn, _ := newTestScheduler(t, &collector.MockFetcher{Price: 5000})
+			s.evaluateDaily(ind(tt.daily, tt.weekly), time.Now())
+			assertTypes(t, fn, tt.want...)
+		})
+	}
+
+	// The weekly report warns at the same level.
+	strategy.SetRSIThresholds(strategy.RSIThresholds{BottomFish: 30, TakeProfit: 80})
+	if w := strategy.Evaluate(ind(50, 82)).Warnings; len(w) != 1 || w[0].Kind != model.WarningTakeProfit || w[0].Text != "⚠️ RSI > 80 止盈预警：建议考虑部分止盈" {
+		t.Errorf("weekly warnings %+v", w)
+	}
+}
+
+func TestEvaluateDaily_BottomFishEscalates(t *testing.T) {
+	s, fn, rec := newTestScheduler(t, &collector.MockFetcher{Price: 5000})
+	s.Fund.SetPolicy(fund.Policy{BottomFishLevelMultipliers: []float64{1, 1.5, 2}})
+	ind := func(daily float64) *model.MarketIndicators {
+		return &model.MarketIndicators{
+			CurrentPrice: 5000, MA200: 5000, MA20w: 5000, MA50w: 5000,
+			DailyRSI: daily, WeeklyRSI: 50, High52w: 6000, Low52w: 4000,
+			High30d: 5200, Low30d: 4800, Position52w: 0.5,
+		}
+	}
+	// Monday to Friday of one week: a level-1 dip, the same again, a level-2
+	// dip, a bounce into level 1 and a level-3 dip.
+	for _, rsi := range []float64{28, 27, 24, 29, 18} {
+		s.evaluateDaily(ind(rsi), time.Now())
+	}
+	assertTypes(t, fn, notifier.MsgBottomFish, notifier.MsgBottomFish, notifier.MsgBottomFish)
+	var levels []int
+	for _, c := range rec.dailyChecks {
+		levels = append(levels, c.Level)
+	}
+	if fmt.Sprint(levels) != "[1 2 3]" {
+		t.Errorf("recorded levels %v, want [1 2 3]", levels)
+	}
+	if !strings.Contains(fn.sent[2].Text, "抄底档位: 第3档 (RSI<20)") {
+		t.Errorf("level-3 alert:\n%s", fn.sent[2].Text)
+	}
+	if got := s.Fund.GetState().BottomFishLevel; got != 3 {
+		t.Errorf("fund level %d, want 3", got)
+	}
+}
+
+func TestEvaluateDaily_SellRecommendation(t *testing.T) {
+	t.Cleanup(func() { strategy.SetRSIThresholds(strategy.DefaultRSIThresholds) })
+	ind := &model.MarketIndicators{
+		CurrentPrice: 6600, MA200: 5000, MA20w: 6000, MA50w: 5800,
+		DailyRSI: 82, WeeklyRSI: 82, High52w: 6610, Low52w: 5000,
+		High30d: 6650, Low30d: 6500, Position52w: 0.99,
+	}
+	if score := strategy.Evaluate(ind).TotalScore; score > -1.2 {
+		t.Fatalf("fixture score %.3f is not below the sell gate", score)
+	}
+
+	// RSI 82 is below both default gates: nothing fires.
+	s, fn, rec := newTestScheduler(t, &collector.MockFetcher{Price: 6600})
+	s.evaluateDaily(ind, time.Now())
+	assertTypes(t, fn)
+
+	// A lower RSI gate recommends trimming without a take-profit warning.
+	th := strategy.DefaultRSIThresholds
+	th.SellRSI = 80
+	strategy.SetRSIThresholds(th)
+	s.evaluateDaily(ind, time.Now())
+	assertTypes(t, fn, notifier.MsgTakeProfit)
+	if !strings.Contains(fn.sent[0].Text, "📉 建议减仓: 持仓的 ") {
+		t.Errorf("alert lacks the recommendation:\n%s", fn.sent[0].Text)
+	}
+	if len(rec.dailyChecks) != 1 || rec.dailyChecks[0].EventType != "SELL" || rec.dailyChecks[0].SellPercent < 10 {
+		t.Errorf("expected one SELL row, got %+v", rec.dailyChecks)
+	}
+}
+
+func TestEvaluateDaily_DegradedAlertAfterThreeDays(t *testing.T) {
+	s, fn, _ := newTestScheduler(t, &collector.MockFetcher{Price: 5000})
+	degradedInd := func() *model.MarketIndicators {
+		ind := &model.MarketIndicators{CurrentPrice: 5000, DailyRSI: 50, WeeklyRSI: 50}
+		ind.MarkDegraded(model.IndicatorDailyRSI, "stale")
+		return ind
+	}
+
+	s.evaluateDaily(degradedInd(), time.Now())
+	s.evaluateDaily(degradedInd(), time.Now())
+	assertTypes(t, fn)
+	s.evaluateDaily(degradedInd(), time.Now())
+	assertTypes(t, fn, notifier.MsgError)
+
+	// A fresh day resets the streak.
+	s.evaluateDaily(&model.MarketIndicators{CurrentPrice: 5000, DailyRSI: 50, WeeklyRSI: 50}, time.Now())
+	s.evaluateDaily(degradedInd(), time.Now())
+	assertTypes(t, fn, notifier.MsgError)
+}
+
+func TestMonthlyTask_ChartFallback(t *testing.T) {
+	s, fn, rec := newTestScheduler(t, &collector.MockFetcher{Price: 5000})
+	rec.fundHistory = make([]recorder.FundHistoryPoint, 3)
+	s.monthlyTask(monthsAhead(1))
+	if fn.photos != 0 {
+		t.Error("expected text-only monthly report for short history")
+	}
+
+	for i := 0; i < 5; i++ {
+		rec.fundHistory = append(rec.fundHistory, recorder.FundHistoryPoint{
+			Timestamp: time.Now().AddDate(0, 0, -7*(5-i)), EventType: "WEEKLY",
+			RegularAfter: 7000 - float64(i)*500, ReserveAfter: 3000,
+		})
+	}
+	s.monthlyTask(monthsAhead(2))
+	if fn.photos != 1 {
+		t.Errorf("expected monthly report sent as photo, got %d photos", fn.photos)
+	}
+	assertTypes(t, fn, notifier.MsgMonthly, notifier.MsgMonthly)
+}
+
+func TestMonthlyTask_Performance(t *testing.T) {
+	s, fn, rec := newTestScheduler(t, &collector.MockFetcher{Price: 5500})
+
+	// No purchases yet: said so, not a 0% return.
+	s.monthlyTask(monthsAhead(1))
+	if text := fn.sent[0].Text; !strings.Contains(text, "暂无成交记录") || strings.Contains(text, "收益率") {
+		t.Errorf("monthly report before any purchase:\n%s", text)
+	}
+	if rec.monthly[0].Performance != nil {
+		t.Errorf("recorded performance %+v before any purchase", rec.monthly[0].Performance)
+	}
+
+	s.HandleCommand(1, "/bought 1000 5000")
+	s.monthlyTask(monthsAhead(2))
+	text := fn.sent[1].Text
+	for _, want := range []string{"累计投入: ¥1,000 | 市值: ¥1,100", "浮动盈亏: +¥100 | 收益率: +10.00%", "年化收益 (XIRR)"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("monthly report lacks %q:\n%s", want, text)
+		}
+	}
+	if p := rec.monthly[1].Performance; p == nil || p.Price != 5500 || math.Abs(p.ROI-0.1) > 1e-9 {
+		t.Errorf("recorded performance %+v", p)
+	}
+}
+
+var reportDates = regexp.MustCompile(`\d{4}-\d{2}-\d{2}( \d{2}:\d{2})?`)
+
+// reportTimings matches the stage timing line, whose durations vary by run.
+var reportTimings = regexp.MustCompile(`(?m)^⏱ .*$`)
+
+// TestWeeklyTask_ReportGolden pins the cron weekly report text. Regenerate with
+// UPDATE_GOLDEN=1 only when the report is meant to change.
+func TestWeeklyTask_ReportGolden(t *testing.T) {
+	s, fn, _ := newTestScheduler(t, &collector.MockFetcher{
+		Price:      4000,
+		DailyData:  barsFromCloses(trendCloses(5000, -1, 300)),
+		WeeklyData: barsFromCloses(choppyCloses(4900, 60)),
+	})
+	s.Fund.SetPolicy(fund.Policy{MaxWeeklyDeployMultiple: 1.2})
+	s.weeklyTask(time.Now())
+	assertTypes(t, fn, notifier.MsgWeeklyReport)
+
+	got := reportDates.ReplaceAllString(fn.sent[0].Text, "<date>")
+	got = reportTimings.ReplaceAllString(got, "⏱ <timings>")
+	golden := filepath.Join("testdata", "weekly_report.golden")
+	if os.Getenv("UPDATE_GOLDEN") != "" {
+		if err := os.WriteFile(golden, []byte(got), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != string(want) {
+		t.Errorf("weekly report changed:\n--- got ---\n%s\n--- want ---\n%s", got, want)
+	}
+}
+
+func TestHandleCommand_WeeklyPreviewIsDryRun(t *testing.T) {
+	s, fn, _ := newTestScheduler(t, &collector.MockFetcher{Price: 5000})
+	before := s.Fund.GetState()
+
+	for _, cmd := range []string{"/weekly preview", "/weekly", "/preview", "查看本周建议"} {
+		reply := s.HandleCommand(1, cmd)
+		if !strings.Contains(reply, "周报预览") || !strings.Contains(reply, "预览模式") || !strings.Contains(reply, "投入金额") {
+			t.Errorf("%s: expected preview report, got %q", cmd, reply)
+		}
+	}
+	if len(fn.sent) != 0 {
+		t.Errorf("preview must not notify, sent %v", fn.types())
+	}
+	after := s.Fund.GetState()
+	if after.RegularBalance != before.RegularBalance || len(after.RecentScores) != len(before.RecentScores) {
+		t.Errorf("preview mutated fund state: %+v -> %+v", before, after)
+	}
+
+	s.HandleCommand(1, "/weekly confirm")
+	assertTypes(t, fn, notifier.MsgWeeklyReport)
+	if strings.Contains(fn.sent[0].Text, "周报预览") {
+		t.Errorf("executed report titled as a preview:\n%s", fn.sent[0].Text)
+	}
+	if s.Fund.GetState().RegularBalance >= before.RegularBalance {
+		t.Error("/weekly confirm did not invest")
+	}
+}
+
+func TestHandleCommand_WhatIf(t *testing.T) {
+	s, fn, rec := newTestScheduler(t, &collector.MockFetcher{Price: 5000})
+	before := s.Fund.GetState()
+
+	for cmd, want := range map[string]string{
+		"/whatif":       "用法: /whatif 价格",
+		"/whatif 1 2":   "用法: /whatif 价格",
+		"/whatif abc":   "无法识别价格",
+		"/whatif NaN":   "无法识别价格",
+		"/whatif 0":     "需大于 0 且不超过当前价格 5000.00 的 10 倍",
+		"/whatif 60000": "需大于 0 且不超过当前价格 5000.00 的 10 倍",
+		"/whatif 4,750": "价格 4750.00 (当前 5000.00，-5.0%)",
+		"假设价格 4750.5":   "仅为模拟：未改动资金池，未记录历史",
+	} {
+		if reply := s.HandleCommand(1, cmd); !strings.Contains(reply, want) {
+			t.Errorf("%s: reply lacks %q:\n%s", cmd, want, reply)
+		}
+	}
+	if len(fn.sent) != 0 || len(rec.weekly) != 0 {
+		t.Errorf("simulation sent %v and recorded %d snapshots", fn.types(), len(rec.weekly))
+	}
+	if after := s.Fund.GetState(); after.RegularBalance != before.RegularBalance || len(after.RecentScores) != len(before.RecentScores) {
+		t.Errorf("simulation mutated fund state: %+v -> %+v", before, after)
+	}
+}
+
+func TestHandleCommand_Bought(t *testing.T) {
+	s, _, rec := newTestScheduler(t, &collector.MockFetcher{Price: 5500})
+
+	for cmd, want := range map[string]string{
+		"/bought":          "用法: /bought 金额 价格",
+		"/bought 1000":     "用法: /bought 金额 价格",
+		"/bought abc 5000": "无法识别数字",
+		"/bought 1000 0":   "成交价格 0 无效",
+		"/bought 1000 -5":  "成交价格 -5 无效",
+	} {
+		if reply := s.HandleCommand(1, cmd); !strings.Contains(reply, want) {
+			t.Errorf("%s: reply lacks %q:\n%s", cmd, want, reply)
+		}
+	}
+	if len(rec.purchases) != 0 || len(s.Fund.GetState().Holdings) != 0 {
+		t.Fatalf("rejected fills recorded: %+v", rec.purchases)
+	}
+
+	if reply := s.HandleCommand(1, "/bought 1,000 5000"); !strings.Contains(reply, "买入 0.2000 份") {
+		t.Errorf("unexpected reply:\n%s", reply)
+	}
+	if len(rec.purchases) != 1 || rec.purchases[0].Units != 0.2 || rec.purchases[0].Symbol != "SPX500" {
+		t.Errorf("recorded %+v", rec.purchases)
+	}
+
+	// Valued at the fetched price: 0.2 units at 5500 against 1000 invested.
+	if reply := s.HandleCommand(1, "/fund"); !strings.Contains(reply, "市值 ¥1,100 | 浮动盈亏 +¥100 (+10.00%)") {
+		t.Errorf("/fund lacks the holding:\n%s", reply)
+	}
+}
+
+func TestHandleCommand_Sold(t *testing.T) {
+	s, fn, rec := newTestScheduler(t, &collector.MockFetcher{Price: 6000})
+	s.HandleCommand(1, "/bought 1000 5000")
+	before := s.Fund.GetState()
+
+	for cmd, want := range map[string]string{
+		"/sold":                  "用法: /sold 份额 价格",
+		"/sold 0.1":              "用法: /sold 份额 价格",
+		"/sold x 6000":           "无法识别数字",
+		"/sold 0.5 6000":         "超过持有的 0.2000 份",
+		"/sold 0.1 6000 savings": "未知的资金去向",
+	} {
+		if reply := s.HandleCommand(1, cmd); !strings.Contains(reply, want) {
+			t.Errorf("%s: reply lacks %q:\n%s", cmd, want, reply)
+		}
+	}
+	if len(rec.sales) != 0 || len(rec.fundEvents) != 0 {
+		t.Fatalf("rejected sales recorded: %+v", rec.sales)
+	}
+
+	// Crediting a pool waits for the confirmation.
+	if reply := s.HandleCommand(1, "/sold 0.1 6000 reserve"); reply != "" {
+		t.Fatalf("expected the preview to be sent with the keyboard, got reply %q", reply)
+	}
+	if len(fn.sent) != 1 || !strings.Contains(fn.sent[0].Text, "所得 ¥600 计入储备池") {
+		t.Fatalf("unexpected preview %+v", fn.sent)
+	}
+	if len(rec.sales) != 0 || s.Fund.GetState().ReserveBalance != before.ReserveBalance {
+		t.Fatalf("sale applied before the confirmation: %+v", rec.sales)
+	}
+	reply := s.HandleCommand(1, confirmYes)
+	if !strings.Contains(reply, "所得 ¥600 计入储备池") || !strings.Contains(reply, "已实现盈亏: +¥100") {
+		t.Errorf("unexpected reply:\n%s", reply)
+	}
+	if after := s.Fund.GetState(); after.ReserveBalance != before.ReserveBalance+600 {
+		t.Errorf("reserve %.2f, want %.2f", after.ReserveBalance, before.ReserveBalance+600)
+	}
+	if len(rec.sales) != 1 || rec.sales[0].RealizedPnL != 100 || rec.sales[0].Destination != "reserve" {
+		t.Errorf("recorded %+v", rec.sales)
+	}
+	if len(rec.fundEvents) != 1 || rec.fundEvents[0].EventType != "SALE" || rec.fundEvents[0].Amount != 600 {
+		t.Errorf("fund events %+v", rec.fundEvents)
+	}
+
+	// Without a destination the proceeds leave the fund.
+	if reply := s.HandleCommand(1, "/sold 0.1 6000"); !strings.Contains(reply, "已转出基金") || !strings.Contains(reply, "当前无持仓") {
+		t.Errorf("unexpected reply:\n%s", reply)
+	}
+	if reply := s.HandleCommand(1, "/monthly"); !strings.Contains(reply, "本年已实现盈亏: +¥200 (2 笔卖出)") {
+		t.Errorf("/monthly lacks the realized P&L:\n%s", reply)
+	}
+	if !s.ChatOnly("/sold 0.1 6000 reserve") || !s.ChatOnly("确认卖出 0.1 6000") {
+		t.Error("sales should be restricted to the configured chat")
+	}
+}
+
+func TestHandleCommand_SetBudget(t *testing.T) {
+	s, fn, rec := newTestScheduler(t, &collector.MockFetcher{Price: 5000})
+	before := s.Fund.GetState()
+
+	for cmd, want := range map[string]string{
+		"/setbudget":         "用法: /setbudget 金额",
+		"/setbudget abc":     "无法识别数字",
+		"/setbudget -5000":   "月度预算 -5000 无效",
+		"/setbudget 1000001": "超过当前 ¥10,000 的 100 倍",
+	} {
+		if reply := s.HandleCommand(1, cmd); !strings.Contains(reply, want) {
+			t.Errorf("%s: reply lacks %q:\n%s", cmd, want, reply)
+		}
+	}
+	if len(rec.fundEvents) != 0 || s.Fund.GetState().MonthlyBudget != before.MonthlyBudget {
+		t.Fatalf("rejected budgets applied: %+v", rec.fundEvents)
+	}
+
+	s.HandleCommand(1, "/setbudget 15,000")
+	if len(fn.sent) != 1 || !strings.Contains(fn.sent[0].Text, "月度预算: ¥10,000.00 → ¥15,000.00") {
+		t.Fatalf("expected a budget preview, sent %+v", fn.sent)
+	}
+	if s.Fund.GetState().MonthlyBudget != before.MonthlyBudget {
+		t.Fatal("budget changed before the confirmation")
+	}
+	reply := s.HandleCommand(1, confirmYes)
+	if !strings.Contains(reply, "月度预算已调整: ¥10,000 → ¥15,000") || !strings.Contains(reply, "每周基数 N: ¥1,617 → ¥2,425") {
+		t.Errorf("unexpected reply:\n%s", reply)
+	}
+	after := s.Fund.GetState()
+	if after.MonthlyBudget != 15000 || after.RegularBalance != before.RegularBalance {
+		t.Errorf("state after = %+v", after)
+	}
+	if len(rec.fundEvents) != 1 || rec.fundEvents[0].EventType != "BUDGET_CHANGE" || rec.fundEvents[0].Amount != 15000 {
+		t.Errorf("fund events %+v", rec.fundEvents)
+	}
+
+	if !s.ChatOnly("/setbudget 15000") || !s.ChatOnly("调整预算 15000") || s.ChatOnly("/fund") {
+		t.Error("only the budget change should be restricted to the configured chat")
+	}
+}
+
+func TestPause_ReportsWithoutInvesting(t *testing.T) {
+	s, fn, rec := newTestScheduler(t, &collector.MockFetcher{Price: 5000})
+	if reply := s.HandleCommand(1, "/pause 0"); !strings.Contains(reply, "用法: /pause") {
+		t.Errorf("pause of 0 weeks: %q", reply)
+	}
+	if reply := s.HandleCommand(1, "/pause 2"); !strings.Contains(reply, "已暂停投入 2 周") {
+		t.Fatalf("unexpected pause reply: %q", reply)
+	}
+	before := s.Fund.GetState()
+
+	s.weeklyTask(time.Now())
+	ind := &model.MarketIndicators{
+		CurrentPrice: 5000, MA200: 5000, MA20w: 5000, MA50w: 5000,
+		DailyRSI: 20, WeeklyRSI: 50, High52w: 6000, Low52w: 4000,
+		High30d: 5200, Low30d: 4800, Position52w: 0.5,
+	}
+	s.evaluateDaily(ind, time.Now())
+	assertTypes(t, fn, notifier.MsgWeeklyReport, notifier.MsgBottomFish)
+	for _, m := range fn.sent {
+		if !strings.Contains(m.Text, "⏸ 已暂停投入（至 ") {
+			t.Errorf("report lacks the pause banner:\n%s", m.Text)
+		}
+	}
+	if strings.Contains(fn.sent[0].Text, "周报预览") {
+		t.Errorf("scheduled report titled as a preview:\n%s", fn.sent[0].Text)
+	}
+	after := s.Fund.GetState()
+	if after.RegularBalance != before.RegularBalance || after.ReserveBalance != before.ReserveBalance || after.BottomFishLevel != 0 {
+		t.Errorf("paused fund changed: %+v -> %+v", before, after)
+	}
+	if len(rec.weekly) != 0 || len(rec.dailyChecks) != 0 {
+		t.Errorf("paused runs recorded history: %d weekly, %d daily", len(rec.weekly), len(rec.dailyChecks))
+	}
+	if len(rec.fundEvents) != 1 || rec.fundEvents[0].EventType != "PAUSE" {
+		t.Errorf("fund events %+v, want the PAUSE marker alone", rec.fundEvents)
+	}
+
+	// The weekly task after the pause ran out resumes and invests.
+	s.Fund.MutateStateForTest(func(st *model.FundState) { st.PausedUntil = time.Now().Add(-time.Minute) })
+	fn.sent = nil
+	s.weeklyTask(time.Now())
+	assertTypes(t, fn, notifier.MsgObservation, notifier.MsgWeeklyReport)
+	if s.Fund.GetState().Paused || s.Fund.GetState().RegularBalance >= before.RegularBalance {
+		t.Error("expired pause did not resume investing")
+	}
+	if types := []string{rec.fundEvents[1].EventType, rec.fundEvents[2].EventType}; types[0] != "RESUME" || types[1] != "WEEKLY" {
+		t.Errorf("fund events after the pause: %v", types)
+	}
+
+	if reply := s.HandleCommand(1, "/resume"); !strings.Contains(reply, "当前未暂停投入") {
+		t.Errorf("resume when not paused: %q", reply)
+	}
+	if !s.ChatOnly("/pause") || !s.ChatOnly("恢复投入") {
+		t.Error("pause and resume should be restricted to the configured chat")
+	}
+}
+
+func TestHandleCommand_DepositWithdraw(t *testing.T) {
+	s, fn, rec := newTestScheduler(t, &collector.MockFetcher{Price: 5000})
+	before := s.Fund.GetState()
+
+	for cmd, want := range map[string]string{
+		"/deposit":                "用法: /deposit",
+		"/deposit reserve":        "用法: /deposit",
+		"/deposit reserve abc":    "无法识别数字",
+		"/deposit reserve -5000":  "金额 -5000 无效",
+		"/deposit cash 5000":      "未知的资金池",
+		"/withdraw regular 80000": "超过余额",
+	} {
+		if reply := s.HandleCommand(1, cmd); !strings.Contains(reply, want) {
+			t.Errorf("%s: reply lacks %q:\n%s", cmd, want, reply)
+		}
+	}
+	if len(rec.fundEvents) != 0 {
+		t.Fatalf("rejected adjustments recorded: %+v", rec.fundEvents)
+	}
+
+	if len(fn.sent) != 0 {
+		t.Fatalf("rejected adjustments asked for confirmation: %+v", fn.sent)
+	}
+
+	s.HandleCommand(1, "/deposit 储备 5,000 年终 奖金")
+	if len(fn.sent) != 1 || !strings.Contains(fn.sent[0].Text, "储备池: ¥3,000.00 → ¥8,000.00") {
+		t.Fatalf("expected a deposit preview, sent %+v", fn.sent)
+	}
+	reply := s.HandleCommand(1, confirmYes)
+	if !strings.Contains(reply, "已手动存入储备池 ¥5,000") || !strings.Contains(reply, "资金池状态") {
+		t.Errorf("unexpected deposit reply:\n%s", reply)
+	}
+	s.HandleCommand(1, "取出资金 regular 1000")
+	s.HandleCommand(1, confirmYes)
+	after := s.Fund.GetState()
+	if after.ReserveBalance != before.ReserveBalance+5000 || after.RegularBalance != before.RegularBalance-1000 {
+		t.Errorf("pools %.2f/%.2f after the adjustments", after.RegularBalance, after.ReserveBalance)
+	}
+	if len(rec.fundEvents) != 2 || rec.fundEvents[0].EventType != "MANUAL_ADJUST" ||
+		rec.fundEvents[0].Note != "手动存入储备池 ¥5,000：年终 奖金" || rec.fundEvents[1].Amount != -1000 {
+		t.Errorf("fund events %+v", rec.fundEvents)
+	}
+
+	if !s.ChatOnly("/deposit reserve 5000") || !s.ChatOnly("/withdraw regular 1000") || !s.ChatOnly("存入资金 储备 1") {
+		t.Error("deposits and withdrawals should be restricted to the configured chat")
+	}
+}
+
+func TestHandleCommand_WithdrawConfirm(t *testing.T) {
+	s, fn, rec := newTestScheduler(t, &collector.MockFetcher{Price: 5000})
+	before := s.Fund.GetState()
+
+	if reply := s.HandleCommand(1, "/withdraw regular 2000 应急"); reply != "" {
+		t.Fatalf("expected the preview to be sent with the keyboard, got reply %q", reply)
+	}
+	if len(fn.sent) != 1 || !strings.Contains(fn.sent[0].Text, "常规池: ¥7,000.00 → ¥5,000.00") ||
+		!strings.Contains(fn.sent[0].Text, "储备池: ¥3,000.00 → ¥3,000.00") {
+		t.Fatalf("unexpected preview %+v", fn.sent)
+	}
+	if got := s.Fund.GetState(); got.RegularBalance != before.RegularBalance || len(rec.fundEvents) != 0 {
+		t.Fatalf("withdrawal applied before the confirmation: %.2f, events %+v", got.RegularBalance, rec.fundEvents)
+	}
+
+	// Denied, nothing changes.
+	if reply := s.HandleCommand(1, confirmNo); !strings.Contains(reply, "已取消") {
+		t.Errorf("unexpected deny reply %q", reply)
+	}
+	if got := s.Fund.GetState(); got.RegularBalance != before.RegularBalance || len(rec.fundEvents) != 0 {
+		t.Errorf("denied withdrawal applied: %.2f, events %+v", got.RegularBalance, rec.fundEvents)
+	}
+	if reply := s.HandleCommand(1, confirmYes); !strings.Contains(reply, "没有待确认的操作") {
+		t.Errorf("confirm after deny: %q", reply)
+	}
+
+	// Confirmed, the pool changes once.
+	s.HandleCommand(1, "/withdraw regular 2000 应急")
+	if reply := s.HandleCommand(1, confirmYes); !strings.Contains(reply, "已手动取出常规池 ¥2,000") {
+		t.Errorf("unexpected confirm reply %q", reply)
+	}
+	if got := s.Fund.GetState(); got.RegularBalance != before.RegularBalance-2000 {
+		t.Errorf("regular %.2f after the confirmed withdrawal", got.RegularBalance)
+	}
+	if len(rec.fundEvents) != 1 || rec.fundEvents[0].Amount != -2000 || rec.fundEvents[0].Note != "手动取出常规池 ¥2,000：应急" {
+		t.Errorf("fund events %+v", rec.fundEvents)
+	}
+}
+
+func TestJitterOffset_Bounded(t *testing.T) {
+	if d := jitterOffset(0); d != 0 {
+		t.Errorf("expected no offset when disabled, got %s", d)
+	}
+	limit := 90 * time.Second
+	for i := 0; i < 1000; i++ {
+		if d := jitterOffset(limit); d < 0 || d > limit || d%time.Second != 0 {
+			t.Fatalf("offset %s outside [0, %s] or not whole seconds", d, limit)
+		}
+	}
+}
+
+func TestRunAfter_AbortsOnShutdown(t *testing.T) {
+	s, _, _ := newTestScheduler(t, &collector.MockFetcher{Price: 5000})
+	ctx, cancel := context.WithCancel(context.Background())
+	s.Ctx = ctx
+
+	ran := false
+	done := make(chan struct{})
+	go func() {
+		s.runAfter(time.Hour, func() { ran = true })
+		close(done)
+	}()
+	cancel()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("runAfter did not return promptly after shutdown")
+	}
+	if ran {
+		t.Error("task ran despite shutdown during start offset")
+	}
+}
+
+func TestHandleStatus_ShowsOffset(t *testing.T) {
+	s, _, _ := newTestScheduler(t, &collector.MockFetcher{Price: 5000})
+	s.SetJitter(time.Hour)
+	if err := s.RegisterAll("0 0 8 * * 1", "0 0 22 * * 1-5", "0 0 9 1 * *"); err != nil {
+		t.Fatal(err)
+	}
+	s.Start()
+	defer s.Stop()
+
+	reply := s.HandleCommand(1, "/status")
+	if !strings.Contains(reply, "日线检查") || !strings.Contains(reply, "月度补充") {
+		t.Errorf("expected all tasks listed, got %q", reply)
+	}
+	for _, j := range s.jobs {
+		if j.offset > 0 && !strings.Contains(reply, "+"+j.offset.String()) {
+			t.Errorf("expected %s offset %s in status, got %q", j.name, j.offset, reply)
+		}
+	}
+}
+
+func TestWeeklyTask_ThreadReplies(t *testing.T) {
+	s, fn, _ := newTestScheduler(t, &collector.MockFetcher{Price: 5000})
+	s.ThreadReplies = true
+	s.weeklyTask(time.Now())
+
+	assertTypes(t, fn, notifier.MsgWeeklyReport, notifier.MsgWeeklyReport, notifier.MsgWeeklyReport)
+	if fn.sent[0].ReplyTo != 0 || strings.Contains(fn.sent[0].Text, "资金池状态") {
+		t.Errorf("main report should be a standalone signal message: %+v", fn.sent[0])
+	}
+	if fn.sent[1].ReplyTo != 1 || !strings.Contains(fn.sent[1].Text, "资金池状态") {
+		t.Errorf("fund status should reply to the report: %+v", fn.sent[1])
+	}
+	if fn.sent[2].ReplyTo != 1 || !strings.Contains(fn.sent[2].Text, "⏱ 日线") {
+		t.Errorf("stage timings should reply to the report: %+v", fn.sent[2])
+	}
+}
+
+func TestWeeklyTask_ThreadRepliesParentFailed(t *testing.T) {
+	s, fn, _ := newTestScheduler(t, &collector.MockFetcher{Price: 5000})
+	s.ThreadReplies = true
+	fn.fail = true
+	s.weeklyTask(time.Now())
+
+	for _, m := range fn.sent {
+		if m.ReplyTo != 0 {
+			t.Errorf("expected unthreaded follow-up when the parent failed, got %+v", m)
+		}
+	}
+}
+
+func TestFundLockout_AlertAndReconcile(t *testing.T) {
+	s, fn, _ := newTestScheduler(t, &collector.MockFetcher{Price: 5000})
+	s.Fund.MutateStateForTest(func(st *model.FundState) { st.RegularBalance = -100 })
+	assertTypes(t, fn, notifier.MsgError)
+	if fn.sent[0].Priority != notifier.PriorityHigh || !strings.Contains(fn.sent[0].Text, "常规池余额为负") {
+		t.Errorf("unexpected alert: %+v", fn.sent[0])
+	}
+
+	fn.sent = nil
+	s.weeklyTask(time.Now())
+	s.monthlyTask(time.Now())
+	assertTypes(t, fn, notifier.MsgWeeklyReport, notifier.MsgError)
+	if !strings.Contains(fn.sent[0].Text, "资金账本已锁定") {
+		t.Errorf("expected locked weekly report, got %q", fn.sent[0].Text)
+	}
+	if st := s.Fund.GetState(); st.RegularBalance != -100 || len(st.RecentScores) != 0 {
+		t.Errorf("fund mutated while locked: %+v", st)
+	}
+	if reply := s.HandleCommand(1, "/fund"); !strings.Contains(reply, "资金池状态") {
+		t.Errorf("read-only command should still work, got %q", reply)
+	}
+
+	s.HandleCommand(1, "/reconcile")
+	if reply := s.HandleCommand(1, confirmYes); !strings.Contains(reply, "对账完成") {
+		t.Fatalf("expected reconcile to succeed, got %q", reply)
+	}
+	if err := s.Fund.Locked(); err != nil {
+		t.Errorf("expected lock released, got %v", err)
+	}
+}
+
+// monthsAhead is 09:00 on the first of the n-th month from now, for monthly
+// runs after the month a fresh fund was credited in.
+func monthsAhead(n int) time.Time {
+	return monthStart(time.Now()).AddDate(0, n, 0).Add(9 * time.Hour)
+}
+
+type fixedClock time.Time
+
+func (c fixedClock) Now() time.Time { return time.Time(c) }
+
+func TestWeeklyTask_OncePerWeek(t *testing.T) {
+	s, fn, _ := newTestScheduler(t, &collector.MockFetcher{Price: 5000})
+	s.weeklyTask(time.Now())
+	invested := s.Fund.GetState()
+	s.weeklyTask(time.Now())
+	assertTypes(t, fn, notifier.MsgWeeklyReport, notifier.MsgWeeklyReport)
+	if !strings.Contains(fn.sent[1].Text, "本周已执行，仅展示") {
+		t.Errorf("repeated run not labeled:\n%s", fn.sent[1].Text)
+	}
+	if st := s.Fund.GetState(); st.RegularBalance != invested.RegularBalance {
+		t.Errorf("repeated run debited %.2f", invested.RegularBalance-st.RegularBalance)
+	}
+
+	s.HandleCommand(1, "/weekly 强制")
+	if st := s.Fund.GetState(); st.RegularBalance >= invested.RegularBalance {
+		t.Error("/weekly force did not invest")
+	}
+}
+
+func TestCatchUp_MissedMonths(t *testing.T) {
+	s, fn, rec := newTestScheduler(t, &collector.MockFetcher{Price: 5000})
+	if err := s.RegisterAll("0 0 8 * * 1", "0 0 22 * * 1-5", "0 0 9 1 * *"); err != nil {
+		t.Fatal(err)
+	}
+	s.Fund.MutateStateForTest(func(st *model.FundState) { st.LastReplenishMonth = "2025-01" })
+	before := s.Fund.GetState()
+
+	// Back up on 1 March before the 09:00 run: February was missed, March
+	// is not due yet.
+	s.Clock = fixedClock(time.Date(2025, 3, 1, 8, 0, 0, 0, time.Local))
+	s.CatchUp()
+	if len(rec.monthly) != 1 || rec.monthly[0].OccurredAt.Month() != time.February {
+		t.Fatalf("caught up %+v, want February alone", rec.monthly)
+	}
+	if !strings.Contains(fn.sent[0].Text, "补发 2025-02 月度资金补充") {
+		t.Errorf("catch-up report:\n%s", fn.sent[0].Text)
+	}
+	if len(rec.fundEvents) != 1 || rec.fundEvents[0].OccurredAt.Month() != time.February {
+		t.Errorf("catch-up fund events %+v, want one in February", rec.fundEvents)
+	}
+	if at := s.Fund.GetState().LastReplenishAt; !at.Equal(time.Date(2025, 2, 1, 0, 0, 0, 0, time.Local)) {
+		t.Errorf("last replenish at %s, want the start of February", at)
+	}
+
+	// The 09:00 run credits March, then a restart and a repeated trigger
+	// the same day add nothing.
+	s.Clock = fixedClock(time.Date(2025, 3, 1, 9, 0, 0, 0, time.Local))
+	s.monthlyTask(s.Clock.Now())
+	s.Clock = fixedClock(time.Date(2025, 3, 1, 10, 0, 0, 0, time.Local))
+	s.CatchUp()
+	s.monthlyTask(s.Clock.Now())
+	if len(rec.monthly) != 2 || rec.monthly[1].OccurredAt.Month() != time.March {
+		t.Fatalf("recorded %d monthly events, want February and March", len(rec.monthly))
+	}
+	if last := fn.sent[len(fn.sent)-1]; last.Priority != notifier.PriorityLow || !strings.Contains(last.Text, "2025-03 的月度资金已补充") {
+		t.Errorf("repeated trigger: %+v", last)
+	}
+	if st := s.Fund.GetState(); st.RegularBalance+st.ReserveBalance != before.RegularBalance+before.ReserveBalance+20000 {
+		t.Errorf("pools %.0f/%.0f, want two budgets added", st.RegularBalance, st.ReserveBalance)
+	}
+
+	// Down from April to after the 1 May run: the May task catches April up first.
+	s.Clock = fixedClock(time.Date(2025, 5, 1, 9, 0, 0, 0, time.Local))
+	s.monthlyTask(s.Clock.Now())
+	if len(rec.monthly) != 4 || rec.monthly[2].OccurredAt.Month() != time.April || rec.monthly[3].OccurredAt.Month() != time.May {
+		t.Errorf("recorded %d monthly events, want April then May", len(rec.monthly))
+	}
+}
+
+func TestQuarterlyTask_OncePerQuarter(t *testing.T) {
+	s, fn, _ := newTestScheduler(t, &collector.MockFetcher{Price: 5000})
+	s.quarterlyTask(time.Now())
+	s.quarterlyTask(time.Now())
+	if len(fn.sent) != 2 || fn.sent[1].Priority != notifier.PriorityLow || !strings.Contains(fn.sent[1].Text, "已再平衡，跳过") {
+		t.Errorf("repeated quarterly run sent %+v", fn.sent)
+	}
+}
+
+func TestScheduledRun_RecordsSlotTime(t *testing.T) {
+	s, _, rec := newTestScheduler(t, &collector.MockFetcher{Price: 5000})
+	slot := time.Date(2025, 3, 3, 9, 0, 0, 0, time.Local)
+	s.Clock = fixedClock(slot.Add(400 * time.Millisecond))
+	if err := s.RegisterAll("0 0 9 * * 1", "0 0 16 * * 1-5", "0 0 9 1 * *"); err != nil {
+		t.Fatal(err)
+	}
+
+	s.Cron.Entry(s.jobs[0].id).Job.Run()
+	if len(rec.weekly) != 1 || !rec.weekly[0].OccurredAt.Equal(slot) {
+		t.Fatalf("expected snapshot at slot %v, got %+v", slot, rec.weekly)
+	}
+	if rec.deliveries[0].OccurredAt.IsZero() {
+		t.Error("expected delivery time from the scheduler clock")
+	}
+}
+
+func TestWeeklyTask_CompactUnlessDetailRequested(t *testing.T) {
+	s, fn, rec := newTestScheduler(t, &collector.MockFetcher{
+		Price:      5005,
+		DailyData:  barsFromCloses(choppyCloses(5000, 300)),
+		WeeklyData: barsFromCloses(choppyCloses(5000, 60)),
+	})
+	s.WeeklyStyle = notifier.StylePolicy{NeutralBand: 10}
+
+	reply := s.HandleCommand(1, "/weekly preview")
+	if !strings.Contains(reply, "评分") || strings.Contains(reply, "因子评分明细") || strings.Contains(reply, "⏱") {
+		t.Errorf("expected compact preview, got %q", reply)
+	}
+	reply = s.HandleCommand(1, "/weekly 预览 详细")
+	if !strings.Contains(reply, "因子评分明细") || !strings.Contains(reply, "预览模式") || !strings.Contains(reply, "⏱ 日线") {
+		t.Errorf("expected detailed preview, got %q", reply)
+	}
+	if len(rec.taskRuns) != 0 {
+		t.Errorf("previews recorded task runs: %+v", rec.taskRuns)
+	}
+
+	s.HandleCommand(1, "/weekly confirm detail")
+	assertTypes(t, fn, notifier.MsgWeeklyReport)
+	if !strings.Contains(fn.sent[0].Text, "资金池状态") {
+		t.Errorf("/weekly detail sent a compact report: %q", fn.sent[0].Text)
+	}
+	if len(rec.taskRuns) != 1 || rec.taskRuns[0].Task != "weekly" {
+		t.Fatalf("recorded task runs %+v, want the weekly run", rec.taskRuns)
+	}
+	var stages []string
+	for _, st := range rec.taskRuns[0].Stages {
+		stages = append(stages, st.Stage)
+	}
+	if got := strings.Join(stages, ","); got != "daily fetch,weekly fetch,quote,render,send" {
+		t.Errorf("recorded stages %s", got)
+	}
+}
+
+func TestGapCheck_AlertsWithoutFundChange(t *testing.T) {
+	s, fn, _ := newTestScheduler(t, &collector.MockFetcher{Price: 5000})
+	aux := &collector.MockFetcher{Price: 4800, DailyData: barsFromCloses([]float64{5010, 5000})}
+	if err := s.RegisterGapCheck("0 0 21 * * 1-5", aux, "ES=F", 3); err != nil {
+		t.Fatal(err)
+	}
+	before := s.Fund.GetState()
+
+	s.gapCheckTask(time.Now())
+	assertTypes(t, fn, notifier.MsgGapAlert)
+	text := fn.sent[0].Text
+	for _, want := range []string{"ES=F", "5000.00 → 盘前: 4800.00 (-4.00%)", "抄底额度: 可用"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("alert missing %q:\n%s", want, text)
+		}
+	}
+	if after := s.Fund.GetState(); after.ReserveBalance != before.ReserveBalance || after.BottomFishLevel != 0 {
+		t.Errorf("gap check changed the fund: %+v -> %+v", before, after)
+	}
+
+	// Below the threshold nothing is sent.
+	aux.Price = 4900
+	s.gapCheckTask(time.Now())
+	assertTypes(t, fn, notifier.MsgGapAlert)
+}
+
+func TestGapCheck_SilentOnFetchFailure(t *testing.T) {
+	s, fn, _ := newTestScheduler(t, &collector.MockFetcher{Price: 5000})
+	if err := s.RegisterGapCheck("0 0 21 * * 1-5", failingFetcher{}, "ES=F", 3); err != nil {
+		t.Fatal(err)
+	}
+	s.gapCheckTask(time.Now())
+	if len(fn.sent) != 0 {
+		t.Errorf("expected no messages on a failed auxiliary fetch, got %v", fn.types())
+	}
+}
+
+// newSQLiteScheduler is newTestScheduler recording into a real SQLite database.
+func newSQLiteScheduler(t *testing.T, fetcher collector.Fetcher) (*Scheduler, *fakeNotifier, *recorder.SQLiteRecorder) {
+	t.Helper()
+	dir := t.TempDir()
+	fm, err := fund.NewManager(fund.NewFileStore(filepath.Join(dir, "fund_state.json")), 10000, fund.Policy{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec, err := recorder.NewSQLiteRecorder(filepath.Join(dir, "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { rec.Close() })
+	fn := &fakeNotifier{}
+	s := NewScheduler(context.Background(), openEveryDay(t, collector.NewCollector(fetcher, "SPX500")), fm, fn, rec)
+	return s, fn, rec
+}
+
+// TestPrecision_WeeklyRecordMatchesMessage re-reads the weekly snapshot and
+// checks the report shows exactly the stored values.
+func TestPrecision_WeeklyRecordMatchesMessage(t *testing.T) {
+	s, fn, rec := newSQLiteScheduler(t, &collector.MockFetcher{
+		Price:      4000,
+		DailyData:  barsFromCloses(trendCloses(5000, -1, 300)),
+		WeeklyData: barsFromCloses(choppyCloses(4900, 60)),
+	})
+	s.Fund.SetPolicy(fund.Policy{MaxWeeklyDeployMultiple: 1.2})
+	s.weeklyTask(time.Now())
+	assertTypes(t, fn, notifier.MsgWeeklyReport)
+
+	snaps, err := rec.WeeklySnapshotsSince("SPX500", time.Now().Add(-time.Hour))
+	if err != nil || len(snaps) != 1 {
+		t.Fatalf("expected one snapshot, got %d (%v)", len(snaps), err)
+	}
+	snap := snaps[0]
+	if snap.FinalAmount != model.RoundAmount(snap.FinalAmount) || snap.TotalScore != model.RoundScore(snap.TotalScore) {
+		t.Errorf("stored values not rounded: %+v", snap)
+	}
+	text := fn.sent[0].Text
+	for _, want := range []string{
+		"综合评分: " + model.FormatScore(snap.TotalScore),
+		"投入金额: " + model.FormatAmount(snap.FinalAmount) + " (基准" + model.FormatAmount(snap.BaseAmount) + ")",
+		"储备金动用: " + model.FormatAmount(snap.ReserveUsed),
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("report does not show stored value %q:\n%s", want, text)
+		}
+	}
+}
+
+// TestPrecision_BottomFishRecordMatchesMessage does the same for a daily
+// bottom-fish alert.
+func TestPrecision_BottomFishRecordMatchesMessage(t *testing.T) {
+	s, fn, rec := newSQLiteScheduler(t, &collector.MockFetcher{
+		Price:      4000,
+		DailyData:  barsFromCloses(trendCloses(5000, -5, 300)),
+		WeeklyData: barsFromCloses(choppyCloses(4500, 60)),
+	})
+	s.dailyCheck(time.Now())
+	assertTypes(t, fn, notifier.MsgBottomFish)
+
+	checks, err := rec.DailyChecksSince(time.Now().Add(-time.Hour))
+	if err != nil || len(checks) != 1 {
+		t.Fatalf("expected one daily check, got %d (%v)", len(checks), err)
+	}
+	evt := checks[0]
+	text := fn.sent[0].Text
+	for _, want := range []string{
+		"日线RSI=" + model.FormatRSI(evt.DailyRSI),
+		"综合评分: " + model.FormatScore(evt.TotalScore),
+		"抄底金额: " + model.FormatAmount(evt.Amount),
+		fmt.Sprintf("抄底档位: 第%d档", evt.Level),
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("alert does not show stored value %q:\n%s", want, text)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package strategy
+
+import (
+	"fmt"
+	"math"
+
+	"MarketSentinel/internal/model"
+)
+
+// bearBoostScore is the score a week must exceed to count toward the bear
+// boost, as for model.FundState.ConsecutiveHighScoreWeeks.
+const bearBoostScore = 1.0
+
+// Bear boost settings, guarded by registryMu; zero weeks disables it.
+var (
+	bearBoostWeeks      int
+	bearBoostMultiplier = 1.0
+)
+
+// SetBearBoost makes ApplyBearBoost raise the regular multiplier by
+// multiplier, instead of drawing on the reserve pool, once a bear market has
+// scored above 1.0 for weeks weekly runs in a row. Zero weeks, the default,
+// disables it; the multiplier is at least 1.
+func SetBearBoost(weeks int, multiplier float64) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	bearBoostWeeks, bearBoostMultiplier = max(weeks, 0), math.Max(multiplier, 1)
+}
+
+// ApplyBearBoost applies the bear-market boost to signal, whose previous
+// highWeeks weekly runs scored above 1.0, as counted by
+// model.FundState.ConsecutiveHighScoreWeeks before this week. A prolonged
+// bear market keeps the score in the buying tiers for months and would run
+// the reserve pool dry; once this week makes the streak as long as set by
+// SetBearBoost, the tier's regular multiplier is raised and its reserve use
+// dropped, the reason put in BoostNote.
+func ApplyBearBoost(signal *model.TradeSignal, highWeeks int) {
+	CurrentParams().ApplyBearBoost(signal, highWeeks)
+}
+
+// ApplyBearBoost is the package-level ApplyBearBoost with the boost in p.
+func (p Params) ApplyBearBoost(signal *model.TradeSignal, highWeeks int) {
+	weeks := p.BearBoostWeeks
+	if weeks == 0 || signal.Regime != model.RegimeBear || signal.TotalScore <= bearBoostScore || highWeeks+1 < weeks {
+		return
+	}
+	tier := signal.Tier
+	tier.Multiplier *= p.BearBoostMultiplier
+	tier.UseReserve = 0
+	signal.BoostNote = fmt.Sprintf("熊市加码：评分连续%d周高于 %s，常规投入 %.2fx → %.2fx，不动用储备",
+		highWeeks+1, model.FormatScore(bearBoostScore), signal.Tier.Multiplier, tier.Multiplier)
+	signal.Tier = tier
+}
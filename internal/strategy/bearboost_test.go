@@ -0,0 +1,50 @@
+package strategy
+
+import (
+	"testing"
+
+	"MarketSentinel/internal/model"
+)
+
+func TestApplyBearBoost(t *testing.T) {
+	t.Cleanup(func() { SetBearBoost(0, 0) })
+	signal := func(score float64, regime model.Regime) *model.TradeSignal {
+		return &model.TradeSignal{TotalScore: score, Tier: mapTier(score), Regime: regime}
+	}
+
+	// Off by default.
+	s := signal(1.3, model.RegimeBear)
+	ApplyBearBoost(s, 10)
+	if s.Tier != mapTier(1.3) || s.BoostNote != "" {
+		t.Fatalf("boost applied while off: %+v", s)
+	}
+
+	SetBearBoost(4, 1.5)
+	tests := []struct {
+		name      string
+		score     float64
+		regime    model.Regime
+		highWeeks int
+		boosted   bool
+	}{
+		{"fourth week in a bear market", 1.3, model.RegimeBear, 3, true},
+		{"third week", 1.3, model.RegimeBear, 2, false},
+		{"range market", 1.3, model.RegimeRange, 10, false},
+		{"score at 1.0", 1.0, model.RegimeBear, 10, false},
+	}
+	for _, tt := range tests {
+		s := signal(tt.score, tt.regime)
+		ApplyBearBoost(s, tt.highWeeks)
+		if (s.BoostNote != "") != tt.boosted {
+			t.Errorf("%s: note %q, want boosted %v", tt.name, s.BoostNote, tt.boosted)
+			continue
+		}
+		want := mapTier(tt.score)
+		if tt.boosted {
+			want.Multiplier, want.UseReserve = want.Multiplier*1.5, 0
+		}
+		if s.Tier != want {
+			t.Errorf("%s: tier %+v, want %+v", tt.name, s.Tier, want)
+		}
+	}
+}
@@ -0,0 +1,31 @@
+package strategy
+
+import "MarketSentinel/internal/model"
+
+// TierBoundaries returns the tier for score together with how far score is
+// from the neighbouring tiers. The top tier has no tier above it and
+// DefaultTier none below it.
+func TierBoundaries(score float64) model.BoundaryInfo {
+	for i, t := range Tiers {
+		if score < t.MinScore {
+			continue
+		}
+		b := model.BoundaryInfo{Tier: t.Tier, DistDown: score - t.MinScore}
+		if i > 0 {
+			b.UpLabel = Tiers[i-1].Tier.Label
+			b.DistUp = Tiers[i-1].MinScore - score
+		}
+		if i+1 < len(Tiers) {
+			b.DownLabel = Tiers[i+1].Tier.Label
+		} else {
+			b.DownLabel = DefaultTier.Label
+		}
+		return b
+	}
+	last := Tiers[len(Tiers)-1]
+	return model.BoundaryInfo{
+		Tier:    DefaultTier,
+		UpLabel: last.Tier.Label,
+		DistUp:  last.MinScore - score,
+	}
+}
@@ -0,0 +1,60 @@
+package strategy
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"MarketSentinel/internal/model"
+)
+
+// TestTierBoundaries_Golden pins the boundary info at and 0.01 either side of
+// every tier minimum, plus scores deep in the open-ended top and bottom tiers.
+func TestTierBoundaries_Golden(t *testing.T) {
+	scores := []float64{3.0}
+	for _, tier := range Tiers {
+		scores = append(scores, tier.MinScore+0.01, tier.MinScore, tier.MinScore-0.01)
+	}
+	scores = append(scores, -3.0)
+
+	var b strings.Builder
+	for _, s := range scores {
+		b.WriteString(formatBoundary(s, TierBoundaries(s)))
+	}
+	got := b.String()
+
+	golden := filepath.Join("testdata", "tier_boundaries.golden")
+	if os.Getenv("UPDATE_GOLDEN") != "" {
+		if err := os.WriteFile(golden, []byte(got), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != string(want) {
+		t.Errorf("tier boundaries changed:\n--- got ---\n%s\n--- want ---\n%s", got, want)
+	}
+}
+
+func formatBoundary(score float64, b model.BoundaryInfo) string {
+	up, down := "-", "-"
+	if b.UpLabel != "" {
+		up = fmt.Sprintf("%s(%.3f)", b.UpLabel, b.DistUp)
+	}
+	if b.DownLabel != "" {
+		down = fmt.Sprintf("%s(%.3f)", b.DownLabel, b.DistDown)
+	}
+	return fmt.Sprintf("%+.2f %s up=%s down=%s\n", score, b.Tier.Label, up, down)
+}
+
+func TestTierBoundaries_MatchesEvaluate(t *testing.T) {
+	for _, s := range []float64{-2, -1.5, -0.8, 0, 0.8, 1.2, 1.5, 2} {
+		if got, want := TierBoundaries(s).Tier.Label, mapTier(s).Label; got != want {
+			t.Errorf("score %.1f: boundary tier %q, mapTier %q", s, got, want)
+		}
+	}
+}
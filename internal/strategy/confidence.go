@@ -0,0 +1,74 @@
+package strategy
+
+import (
+	"fmt"
+	"math"
+
+	"MarketSentinel/internal/model"
+)
+
+// maxRawScore is the largest absolute raw score of a factor.
+const maxRawScore = 2.0
+
+// minConfidence is the Confidence below which ApplyConfidence steps an
+// extreme tier down; zero disables it. Guarded by registryMu.
+var minConfidence float64
+
+// SetConfidenceDemotion makes ApplyConfidence step 极限重仓 and 重仓买入 down
+// one tier when the signal's Confidence is below min. Zero, the default,
+// disables it.
+func SetConfidenceDemotion(min float64) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	minConfidence = math.Max(0, min)
+}
+
+// Confidence rates from 0 to 1 how firmly factors back total: the weighted
+// scores with the sign of total, as a share of what all scored factors would
+// add up to at ±maxRawScore. Factors near zero or against total lower it, so
+// a total reached by a few factors while the rest disagree scores low and
+// one every factor pushes hard scores high. Skipped factors are left out; a
+// zero total has zero confidence.
+func Confidence(factors []model.FactorScore, total float64) float64 {
+	var agree, full float64
+	for _, f := range factors {
+		if f.Missing != "" {
+			continue
+		}
+		full += math.Abs(f.Weight) * maxRawScore
+		if f.Weighted*total > 0 {
+			agree += math.Abs(f.Weighted)
+		}
+	}
+	if full == 0 {
+		return 0
+	}
+	return math.Min(agree/full, 1)
+}
+
+// ApplyConfidence steps signal down one tier when it is an extreme tier whose
+// Confidence is below the minimum set by SetConfidenceDemotion, putting the
+// reason in ConfidenceNote. A tier the cooldown already stepped down is left
+// alone, so a signal drops one tier at most.
+func ApplyConfidence(signal *model.TradeSignal) {
+	CurrentParams().ApplyConfidence(signal)
+}
+
+// ApplyConfidence is the package-level ApplyConfidence with the minimum
+// confidence in p.
+func (p Params) ApplyConfidence(signal *model.TradeSignal) {
+	minConf := p.MinConfidence
+	if minConf == 0 || signal.CooldownNote != "" || signal.Confidence >= minConf {
+		return
+	}
+	for i := 0; i < extremeTiers && i+1 < len(Tiers); i++ {
+		if signal.Tier.Label != Tiers[i].Tier.Label {
+			continue
+		}
+		lower := Tiers[i+1].Tier
+		signal.ConfidenceNote = fmt.Sprintf("信心度 %.0f%% 低于 %.0f%%，因子分歧，由 %s 降为 %s",
+			signal.Confidence*100, minConf*100, signal.Tier.Label, lower.Label)
+		signal.Tier = lower
+		return
+	}
+}
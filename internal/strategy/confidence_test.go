@@ -0,0 +1,89 @@
+package strategy
+
+import (
+	"testing"
+
+	"MarketSentinel/internal/model"
+)
+
+func TestConfidence_Ordering(t *testing.T) {
+	// Every factor oversold.
+	agree := Evaluate(&model.MarketIndicators{
+		CurrentPrice: 4500, MA200: 5500, MA20w: 5000, MA50w: 5200,
+		WeeklyRSI: 22, DailyRSI: 20, High52w: 6000, Low52w: 4400,
+		High30d: 4800, Low30d: 4500, Position52w: 0.06, DrawdownFromHigh: -0.25,
+	})
+	// Far below MA200, but the RSIs and the 52-week position point the
+	// other way.
+	split := Evaluate(&model.MarketIndicators{
+		CurrentPrice: 4500, MA200: 5500, MA20w: 4400, MA50w: 4300,
+		WeeklyRSI: 68, DailyRSI: 72, High52w: 4700, Low52w: 3500,
+		High30d: 4550, Low30d: 4000, Position52w: 0.83, DrawdownFromHigh: -0.04,
+	})
+	if agree.TotalScore <= 0 || split.TotalScore <= 0 {
+		t.Fatalf("fixtures should both score positive: %.3f, %.3f", agree.TotalScore, split.TotalScore)
+	}
+	if agree.Confidence < 0.6 || split.Confidence > 0.4 || agree.Confidence <= split.Confidence {
+		t.Errorf("confidence agree %.2f, split %.2f, want agreement well above the split", agree.Confidence, split.Confidence)
+	}
+}
+
+func TestConfidence(t *testing.T) {
+	factors := []model.FactorScore{
+		{RawScore: 2, Weight: 0.5, Weighted: 1},
+		{RawScore: -1, Weight: 0.25, Weighted: -0.25},
+		{RawScore: 0, Weight: 0.25, Weighted: 0},
+		{Weight: 0.2, Missing: "no VIX"},
+	}
+	if got := Confidence(factors, 0.75); got != 0.5 {
+		t.Errorf("Confidence = %v, want 1 of the 2 attainable", got)
+	}
+	if got := Confidence(factors, 0); got != 0 {
+		t.Errorf("zero total: Confidence = %v, want 0", got)
+	}
+	if got := Confidence(nil, 1); got != 0 {
+		t.Errorf("no factors: Confidence = %v, want 0", got)
+	}
+}
+
+func TestApplyConfidence(t *testing.T) {
+	t.Cleanup(func() { SetConfidenceDemotion(0) })
+	signal := func(score, conf float64) *model.TradeSignal {
+		return &model.TradeSignal{TotalScore: score, Confidence: conf, Tier: mapTier(score)}
+	}
+
+	// Off by default.
+	s := signal(1.6, 0.1)
+	ApplyConfidence(s)
+	if s.Tier.Label != "极限重仓" || s.ConfidenceNote != "" {
+		t.Fatalf("demoted while off: %+v", s)
+	}
+
+	SetConfidenceDemotion(0.4)
+	tests := []struct {
+		name  string
+		score float64
+		conf  float64
+		want  string
+	}{
+		{"low confidence 极限重仓", 1.6, 0.3, "重仓买入"},
+		{"low confidence 重仓买入", 1.3, 0.3, "加仓买入"},
+		{"confident extreme tier", 1.6, 0.4, "极限重仓"},
+		{"other tier", 0.9, 0.1, "加仓买入"},
+	}
+	for _, tt := range tests {
+		s := signal(tt.score, tt.conf)
+		ApplyConfidence(s)
+		if s.Tier.Label != tt.want || (s.ConfidenceNote != "") != (tt.want != mapTier(tt.score).Label) {
+			t.Errorf("%s: tier %s, note %q, want %s", tt.name, s.Tier.Label, s.ConfidenceNote, tt.want)
+		}
+	}
+
+	// A tier the cooldown stepped down is not stepped down again.
+	s = signal(1.6, 0.1)
+	s.Tier, s.CooldownNote = mapTier(1.3), "冷却期"
+	ApplyConfidence(s)
+	if s.Tier.Label != "重仓买入" || s.ConfidenceNote != "" {
+		t.Errorf("demoted twice: %+v", s)
+	}
+}
@@ -0,0 +1,80 @@
+package strategy
+
+import (
+	"fmt"
+
+	"MarketSentinel/internal/model"
+)
+
+// extremeTiers is how many of the top Tiers the cooldown applies to:
+// 极限重仓 and 重仓买入, the ones drawing the reserve pool down fastest.
+const extremeTiers = 2
+
+// Cooldown settings, guarded by registryMu; zero weeks disables it.
+var (
+	cooldownWeeks  int
+	cooldownMargin float64
+)
+
+// SetCooldown keeps an extreme tier from being issued again within weeks
+// weekly runs unless the score clears the tier's minimum by margin; otherwise
+// ApplyCooldown steps it down one tier. Zero weeks, the default, disables it.
+func SetCooldown(weeks int, margin float64) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	cooldownWeeks, cooldownMargin = max(weeks, 0), margin
+}
+
+// CooldownWeeks is how many previous weekly tiers ApplyCooldown looks at;
+// zero when the cooldown is off.
+func CooldownWeeks() int {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	return cooldownWeeks
+}
+
+// ApplyCooldown steps signal down one tier when it is an extreme tier that
+// also appears among the CooldownWeeks most recent of recent, the tier labels
+// of the previous weekly runs newest first, and its score (SmoothedScore when
+// smoothed) is below the tier's minimum plus the margin. The reason is put in
+// CooldownNote.
+func ApplyCooldown(signal *model.TradeSignal, recent []string) {
+	CurrentParams().ApplyCooldown(signal, recent)
+}
+
+// ApplyCooldown is the package-level ApplyCooldown with the cooldown in p.
+func (p Params) ApplyCooldown(signal *model.TradeSignal, recent []string) {
+	weeks, margin := p.CooldownWeeks, p.CooldownMargin
+	if weeks == 0 {
+		return
+	}
+
+	for i := 0; i < extremeTiers && i+1 < len(Tiers); i++ {
+		t := Tiers[i]
+		if signal.Tier.Label != t.Tier.Label {
+			continue
+		}
+		score := signal.TotalScore
+		if signal.Smoothed {
+			score = signal.SmoothedScore
+		}
+		if score >= t.MinScore+margin || !issuedWithin(recent, t.Tier.Label, weeks) {
+			return
+		}
+		lower := Tiers[i+1].Tier
+		signal.CooldownNote = fmt.Sprintf("冷却期：近%d周已发出 %s，评分未超出门槛 %s 分，降为 %s",
+			weeks, t.Tier.Label, model.FormatScoreDistance(margin), lower.Label)
+		signal.Tier = lower
+		return
+	}
+}
+
+// issuedWithin reports whether label is among the first weeks of recent.
+func issuedWithin(recent []string, label string, weeks int) bool {
+	for _, l := range recent[:min(weeks, len(recent))] {
+		if l == label {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,51 @@
+package strategy
+
+import (
+	"testing"
+
+	"MarketSentinel/internal/model"
+)
+
+func TestApplyCooldown(t *testing.T) {
+	t.Cleanup(func() { SetCooldown(0, 0) })
+	signal := func(score float64) *model.TradeSignal {
+		return &model.TradeSignal{TotalScore: score, Tier: mapTier(score)}
+	}
+
+	// Off by default.
+	s := signal(1.52)
+	ApplyCooldown(s, []string{"极限重仓"})
+	if s.Tier.Label != "极限重仓" || s.CooldownNote != "" {
+		t.Fatalf("cooldown applied while off: %+v", s)
+	}
+
+	SetCooldown(3, 0.1)
+	tests := []struct {
+		name   string
+		score  float64
+		recent []string
+		want   string
+	}{
+		{"repeat at the threshold", 1.52, []string{"正常定投", "极限重仓"}, "重仓买入"},
+		{"repeat clearing the margin", 1.65, []string{"极限重仓"}, "极限重仓"},
+		{"issued before the window", 1.52, []string{"正常定投", "加仓买入", "重仓买入", "极限重仓"}, "极限重仓"},
+		{"no history", 1.52, nil, "极限重仓"},
+		{"second extreme tier", 1.25, []string{"重仓买入"}, "加仓买入"},
+		{"other tier", 0.85, []string{"加仓买入"}, "加仓买入"},
+	}
+	for _, tt := range tests {
+		s := signal(tt.score)
+		ApplyCooldown(s, tt.recent)
+		if s.Tier.Label != tt.want || (s.CooldownNote != "") != (tt.want != mapTier(tt.score).Label) {
+			t.Errorf("%s: tier %s, note %q, want %s", tt.name, s.Tier.Label, s.CooldownNote, tt.want)
+		}
+	}
+
+	// A smoothed signal is judged by its smoothed score.
+	s = signal(1.7)
+	s.Smoothed, s.SmoothedScore, s.Tier = true, 1.55, mapTier(1.55)
+	ApplyCooldown(s, []string{"极限重仓"})
+	if s.Tier.Label != "重仓买入" {
+		t.Errorf("smoothed 1.55 not demoted: %+v", s)
+	}
+}
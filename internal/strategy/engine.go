@@ -1,6 +1,10 @@
 package strategy
 
-import "MarketSentinel/internal/model"
+import (
+	"time"
+
+	"MarketSentinel/internal/model"
+)
 
 // Tiers defines the 7-level investment mapping.
 var Tiers = []struct {
@@ -20,47 +24,48 @@ var DefaultTier = model.InvestmentTier{Label: "最低参与", Multiplier: 0.15,
 
 // mapTier maps a total score to an InvestmentTier.
 func mapTier(totalScore float64) model.InvestmentTier {
-	for _, t := range Tiers {
-		if totalScore >= t.MinScore {
-			return t.Tier
-		}
-	}
-	return DefaultTier
+	return TierBoundaries(totalScore).Tier
 }
 
 // Evaluate computes the full trade signal from market indicators.
 func Evaluate(ind *model.MarketIndicators) *model.TradeSignal {
-	// Step a: compute factors 1, 2, 3, 5
-	f1 := scoreMA200Deviation(ind)
-	f2 := scoreWeeklyRSI(ind)
-	f3 := scoreDailyRSI(ind)
-	f5 := scoreTrendTracker(ind)
-
-	// Step b: compute otherFactorsAvg for factor 4
-	otherFactorsAvg := (f1.RawScore + f2.RawScore + f3.RawScore + f5.RawScore) / 4.0
-
-	// Step c: compute factor 4 with the avg
-	f4 := score52WeekPosition(ind, otherFactorsAvg)
+	return CurrentParams().Evaluate(ind)
+}
 
-	factors := []model.FactorScore{f1, f2, f3, f4, f5}
+// Evaluate is the package-level Evaluate with the settings in p.
+func (p Params) Evaluate(ind *model.MarketIndicators) *model.TradeSignal {
+	return p.EvaluateAt(ind, time.Time{})
+}
 
-	// Step d: weighted sum
-	totalScore := f1.Weighted + f2.Weighted + f3.Weighted + f4.Weighted + f5.Weighted
+// EvaluateAt is Evaluate of indicators computed as of asOf, which factors
+// reading the clock, such as the seasonal factor, use instead; the zero time
+// evaluates live.
+func (p Params) EvaluateAt(ind *model.MarketIndicators, asOf time.Time) *model.TradeSignal {
+	// Steps a-d: score the enabled factors, the 52-week position last, and
+	// sum their weighted scores
+	factors := scoreFactors(ind, p.Weights, asOf)
+	totalScore := 0.0
+	for _, f := range factors {
+		totalScore += f.Weighted
+	}
 
 	// Step e: map to tier
-	tier := mapTier(totalScore)
+	boundary := TierBoundaries(totalScore)
 
 	signal := &model.TradeSignal{
 		Factors:     factors,
 		TotalScore:  totalScore,
-		Tier:        tier,
+		Confidence:  Confidence(factors, totalScore),
+		Tier:        boundary.Tier,
 		TriggerType: model.TriggerWeekly,
+		Boundary:    boundary,
+		Regime:      ClassifyRegime(ind),
+		StrategyVersion: p.Version(),
 	}
 
-	// Step f: take-profit warning
-	if ind.WeeklyRSI > 85 || ind.DailyRSI > 85 {
-		signal.WarningMsg = "⚠️ RSI > 85 止盈预警：建议考虑部分止盈"
-	}
+	// Step f: warnings and sell recommendation
+	signal.Warnings = evaluateWarnings(ind, p.RSI)
+	signal.Sell = p.RSI.SellRecommendation(totalScore, ind)
 
 	return signal
 }
@@ -1,6 +1,7 @@
 package strategy
 
 import (
+	"math"
 	"testing"
 
 	"MarketSentinel/internal/model"
@@ -24,11 +25,11 @@ func TestEvaluate_NormalMarket(t *testing.T) {
 	if sig == nil {
 		t.Fatal("expected non-nil signal")
 	}
-	if len(sig.Factors) != 5 {
-		t.Fatalf("expected 5 factors, got %d", len(sig.Factors))
+	if len(sig.Factors) != 6 {
+		t.Fatalf("expected 6 factors, got %d", len(sig.Factors))
 	}
-	if sig.WarningMsg != "" {
-		t.Errorf("unexpected warning: %s", sig.WarningMsg)
+	if len(sig.Warnings) != 0 {
+		t.Errorf("unexpected warnings: %+v", sig.Warnings)
 	}
 }
 
@@ -73,7 +74,7 @@ func TestEvaluate_ExtremeOverbought(t *testing.T) {
 	if sig.TotalScore > -0.5 {
 		t.Errorf("expected negative score for overbought market, got %.3f", sig.TotalScore)
 	}
-	if sig.WarningMsg == "" {
+	if len(sig.Warnings) == 0 || sig.Warnings[0].Kind != model.WarningTakeProfit {
 		t.Error("expected take-profit warning for RSI > 85")
 	}
 }
@@ -214,3 +215,50 @@ func TestTrendTracker_BullBear(t *testing.T) {
 		t.Errorf("expected bearish trend score <= -0.5, got %.1f", f5b.RawScore)
 	}
 }
+
+func TestDrawdownFactor_DeepDrawdownUsesReserve(t *testing.T) {
+	t.Cleanup(resetFactors)
+	// 28% below the high, yet the five original factors only add up to
+	// 0.70: MA200 -12% (+1.5), weekly RSI 42 (+0.5), daily RSI 44 (+0.5),
+	// position 35% (+0.5) and a bearish trend (-0.5).
+	ind := &model.MarketIndicators{
+		CurrentPrice: 4400, MA200: 5000, MA20w: 4600, MA50w: 4800,
+		WeeklyRSI: 42, DailyRSI: 44, High52w: 6111, Low52w: 3479,
+		High30d: 4700, Low30d: 4300, Position52w: 0.35, DrawdownFromHigh: -0.28,
+	}
+
+	sig := Evaluate(ind)
+	var dd model.FactorScore
+	for _, f := range sig.Factors {
+		if f.ID == model.FactorDrawdown {
+			dd = f
+		}
+	}
+	if dd.RawScore != 2 {
+		t.Errorf("drawdown factor scored %v at -28%%, want +2", dd.RawScore)
+	}
+	if want := (0.70 + 2*0.10) / 1.10; math.Abs(sig.TotalScore-want) > 1e-9 {
+		t.Errorf("total %v, want %v", sig.TotalScore, want)
+	}
+	if sig.Tier.UseReserve == 0 {
+		t.Errorf("tier %s at %.2f does not use the reserve", sig.Tier.Label, sig.TotalScore)
+	}
+
+	// Weight 0 restores the five-factor score, which stays in 正常定投.
+	if err := SetFactorWeights(map[string]float64{model.FactorDrawdown: 0}); err != nil {
+		t.Fatal(err)
+	}
+	five := Evaluate(ind)
+	if len(five.Factors) != 5 || math.Abs(five.TotalScore-0.70) > 1e-9 || five.Tier.UseReserve != 0 {
+		t.Errorf("without drawdown: %d factors, total %v, tier %s; want 5, 0.70 and no reserve",
+			len(five.Factors), five.TotalScore, five.Tier.Label)
+	}
+}
+
+func TestScoreDrawdown(t *testing.T) {
+	for dd, want := range map[float64]float64{0: 0, -0.03: 0, -0.05: 0, -0.10: 0.5, -0.15: 1, -0.20: 1.5, -0.25: 2, -0.50: 2} {
+		if got := scoreDrawdown(&model.MarketIndicators{DrawdownFromHigh: dd}).RawScore; math.Abs(got-want) > 1e-9 {
+			t.Errorf("drawdown %v scored %v, want %v", dd, got, want)
+		}
+	}
+}
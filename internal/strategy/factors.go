@@ -42,10 +42,29 @@ func scoreMA200Deviation(ind *model.MarketIndicators) model.FactorScore {
 		RawScore:   score,
 		Weight:     0.35,
 		Weighted:   score * 0.35,
-		Commentary: fmt.Sprintf("偏离 %+.1f%%", deviation),
+		Commentary: withPercentile(ma200Commentary(deviation, ind.ZScoreMA200), ind.MA200DevPercentile),
 	}
 }
 
+// ma200Commentary describes the deviation from MA200, with its z-score when
+// known, e.g. "偏离 -16.7%, z=-2.10".
+func ma200Commentary(deviation, z float64) string {
+	if z == 0 {
+		return fmt.Sprintf("偏离 %+.1f%%", deviation)
+	}
+	return fmt.Sprintf("偏离 %+.1f%%, z=%+.2f", deviation, z)
+}
+
+// withPercentile appends the indicator's percentile rank in its own history
+// to commentary when known, e.g. "RSI=28.4, 历史分位 7%". The rank is shown
+// between 1% and 99%, since it never reaches either end.
+func withPercentile(commentary string, pct float64) string {
+	if pct == 0 {
+		return commentary
+	}
+	return fmt.Sprintf("%s, 历史分位 %.0f%%", commentary, min(max(math.Round(pct), 1), 99))
+}
+
 // scoreWeeklyRSI scores based on the weekly RSI(14).
 // Weight: 0.25
 func scoreWeeklyRSI(ind *model.MarketIndicators) model.FactorScore {
@@ -77,7 +96,7 @@ func scoreWeeklyRSI(ind *model.MarketIndicators) model.FactorScore {
 		RawScore:   score,
 		Weight:     0.25,
 		Weighted:   score * 0.25,
-		Commentary: fmt.Sprintf("RSI=%.0f", rsi),
+		Commentary: withPercentile("RSI="+model.FormatRSI(rsi), ind.WeeklyRSIPercentile),
 	}
 }
 
@@ -112,14 +131,22 @@ func scoreDailyRSI(ind *model.MarketIndicators) model.FactorScore {
 		RawScore:   score,
 		Weight:     0.15,
 		Weighted:   score * 0.15,
-		Commentary: fmt.Sprintf("RSI=%.0f", rsi),
+		Commentary: "RSI=" + model.FormatRSI(rsi),
 	}
 }
 
 // score52WeekPosition scores based on where the price sits in the 52-week range.
 // Weight: 0.10
 // Special logic: when position > 95%, requires otherFactorsAvg < -1 to give -2, otherwise caps at -1.
+// A degenerate range, high equal to low, carries no position and is skipped.
 func score52WeekPosition(ind *model.MarketIndicators, otherFactorsAvg float64) model.FactorScore {
+	if ind.High52w <= ind.Low52w {
+		reason := ind.MissingReason(model.IndicatorRange52w, model.IndicatorPosition52w)
+		if reason == "" {
+			reason = "52-week high equals low"
+		}
+		return model.FactorScore{Name: "52周位置", Commentary: "52周高低点相同，已跳过", Missing: reason}
+	}
 	pos := ind.Position52w * 100 // convert to percentage
 
 	var score float64
@@ -195,6 +222,69 @@ func scoreTrendTracker(ind *model.MarketIndicators) model.FactorScore {
 		RawScore:   score,
 		Weight:     0.15,
 		Weighted:   score * 0.15,
-		Commentary: commentary,
+		Commentary: commentary + slopeCommentary("MA200", ind.MA200Slope) + slopeCommentary("MA20周", ind.MA20wSlope),
+	}
+}
+
+// scoreDrawdown scores how far the price is below its 52-week high, rising
+// linearly from 0 at a 5% drawdown to +2 at 25% and beyond. It is never
+// negative: being near the high is left to the other factors.
+// Weight: 0.10, taken from the others by rescaling the core weights to 1.
+func scoreDrawdown(ind *model.MarketIndicators) model.FactorScore {
+	depth := -ind.DrawdownFromHigh * 100 // percent below the high
+	score := min(max((depth-5)/10, 0), 2)
+
+	return model.FactorScore{
+		Name:       "回撤深度",
+		RawScore:   score,
+		Weight:     0.10,
+		Weighted:   score * 0.10,
+		Commentary: fmt.Sprintf("距52周高点 %.1f%%", -depth),
+	}
+}
+
+// scoreVolatility scores the fear priced into the volatility index: buying
+// into a panic is rewarded and complacency trimmed.
+// Weight: 0.10, taken from the others by rescaling the core weights to 1.
+func scoreVolatility(ind *model.MarketIndicators) model.FactorScore {
+	vix := ind.VIX
+	var score float64
+	var mood string
+	switch {
+	case vix > 40:
+		score, mood = 2.0, "极度恐慌"
+	case vix > 30:
+		score, mood = 1.0, "恐慌"
+	case vix >= 13:
+		score, mood = 0, "正常"
+	default:
+		score, mood = -0.5, "过度乐观"
+	}
+
+	return model.FactorScore{
+		Name:       "波动率",
+		RawScore:   score,
+		Weight:     0.10,
+		Weighted:   score * 0.10,
+		Commentary: fmt.Sprintf("VIX=%.1f, %s", vix, mood),
+	}
+}
+
+// slopeFlat is the largest change, in percent, a moving average's slope
+// reads as flat at.
+const slopeFlat = 0.1
+
+// slopeCommentary describes the direction of a moving average's slope for
+// the trend tracker, e.g. ", MA200上行+1.2%"; empty when the slope is unknown.
+func slopeCommentary(name string, slope float64) string {
+	switch {
+	case slope == 0:
+		return ""
+	case slope > slopeFlat:
+		return fmt.Sprintf(", %s上行%+.1f%%", name, slope)
+	case slope < -slopeFlat:
+		return fmt.Sprintf(", %s下行%+.1f%%", name, slope)
+	default:
+		return fmt.Sprintf(", %s走平", name)
 	}
 }
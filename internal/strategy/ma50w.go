@@ -0,0 +1,67 @@
+package strategy
+
+import (
+	"fmt"
+
+	"MarketSentinel/internal/model"
+)
+
+// MA50wFactorName is the registry key of the MA50w deviation factor.
+const MA50wFactorName = model.FactorMA50w
+
+// NewMA50wFactor returns a factor that scores how far the price lies from the
+// 50-week MA, in the buckets of the MA200 factor widened by half again for
+// the slower average: +2 at 30% or more below it down to -2 beyond 30% above.
+func NewMA50wFactor(weight float64) Factor {
+	return ma50wFactor{weight: weight}
+}
+
+// ma50wFactor is the factor NewMA50wFactor returns.
+type ma50wFactor struct {
+	weight float64
+}
+
+func (f ma50wFactor) Name() string { return MA50wFactorName }
+
+func (f ma50wFactor) config() string { return fmt.Sprintf("weight=%v", f.weight) }
+
+func (f ma50wFactor) Score(ind *model.MarketIndicators, _ FactorContext) model.FactorScore {
+	const name = "MA50周偏离度"
+	if ind.MA50w <= 0 || ind.MissingReason(model.IndicatorMA50w) != "" {
+		return model.FactorScore{Name: name, Commentary: "不可用", Missing: "MA50w not computed"}
+	}
+	deviation := (ind.CurrentPrice - ind.MA50w) / ind.MA50w * 100
+	score := scoreMA50wDeviation(deviation)
+	return model.FactorScore{
+		Name:       name,
+		RawScore:   score,
+		Weight:     f.weight,
+		Weighted:   score * f.weight,
+		Commentary: fmt.Sprintf("偏离 %+.1f%%", deviation),
+	}
+}
+
+// scoreMA50wDeviation scores a deviation from MA50w in percent. A deviation
+// exactly on a bucket edge belongs to the bucket nearer zero from below, as
+// in scoreMA200Deviation.
+func scoreMA50wDeviation(deviation float64) float64 {
+	switch {
+	case deviation <= -30:
+		return 2.0
+	case deviation <= -15:
+		return 1.5
+	case deviation <= -7.5:
+		return 1.0
+	case deviation <= 0:
+		return 0.5
+	case deviation <= 7.5:
+		return 0
+	case deviation <= 15:
+		return -0.5
+	case deviation <= 22.5:
+		return -1.0
+	case deviation <= 30:
+		return -1.5
+	}
+	return -2.0
+}
@@ -0,0 +1,63 @@
+package strategy
+
+import (
+	"testing"
+
+	"MarketSentinel/internal/model"
+)
+
+func TestScoreMA50wDeviation_Boundaries(t *testing.T) {
+	tests := []struct {
+		deviation float64
+		want      float64
+	}{
+		{-35, 2.0}, {-30, 2.0}, {-29.9, 1.5},
+		{-15, 1.5}, {-14.9, 1.0},
+		{-7.5, 1.0}, {-7.4, 0.5},
+		{0, 0.5}, {0.1, 0},
+		{7.5, 0}, {7.6, -0.5},
+		{15, -0.5}, {15.1, -1.0},
+		{22.5, -1.0}, {22.6, -1.5},
+		{30, -1.5}, {30.1, -2.0},
+	}
+	for _, tt := range tests {
+		if got := scoreMA50wDeviation(tt.deviation); got != tt.want {
+			t.Errorf("deviation %+.1f%%: got %.1f, want %.1f", tt.deviation, got, tt.want)
+		}
+	}
+}
+
+func TestMA50wFactor_Score(t *testing.T) {
+	weight := 0.1
+	f := NewMA50wFactor(weight).Score(&model.MarketIndicators{CurrentPrice: 4250, MA50w: 5000}, FactorContext{})
+	if f.RawScore != 1.5 || f.Weighted != f.RawScore*weight || f.Commentary != "偏离 -15.0%" || f.Missing != "" {
+		t.Errorf("got %+v, want 1.5 at -15%%", f)
+	}
+
+	ind := &model.MarketIndicators{CurrentPrice: 4250, MA50w: 4250}
+	ind.MarkMissing(model.IndicatorMA50w, "only 30 weekly bars")
+	if f := NewMA50wFactor(0.1).Score(ind, FactorContext{}); f.Missing == "" || f.Weighted != 0 {
+		t.Errorf("expected missing MA50w to be skipped, got %+v", f)
+	}
+}
+
+func TestMA50wFactor_Registered(t *testing.T) {
+	ind := &model.MarketIndicators{
+		CurrentPrice: 5000, MA200: 5200, MA20w: 5100, MA50w: 6000,
+		WeeklyRSI: 30, DailyRSI: 28, High52w: 6000, Low52w: 4800,
+		High30d: 5400, Low30d: 4900, Position52w: 0.17,
+	}
+	base := Evaluate(ind)
+
+	RegisterFactor(NewMA50wFactor(0.1))
+	t.Cleanup(resetFactors)
+	got := Evaluate(ind)
+
+	last := got.Factors[len(got.Factors)-1]
+	if len(got.Factors) != len(base.Factors)+1 || last.ID != MA50wFactorName || !last.Extra {
+		t.Fatalf("expected MA50w factor appended, got %+v", got.Factors)
+	}
+	if diff := got.TotalScore - base.TotalScore; diff < 0.149 || diff > 0.151 {
+		t.Errorf("total moved by %.3f, want +0.15 for -16.7%%", diff)
+	}
+}
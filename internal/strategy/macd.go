@@ -0,0 +1,72 @@
+package strategy
+
+import (
+	"fmt"
+
+	"MarketSentinel/internal/model"
+)
+
+// MACDFactorName is the registry key of the MACD factor.
+const MACDFactorName = "macd"
+
+// MACD histogram thresholds, as a percentage of the price so they do not
+// depend on the index level. Below macdFlatPct the histogram is treated as
+// zero; from macdStrongPct it is strong.
+const (
+	macdFlatPct   = 0.1
+	macdStrongPct = 0.5
+)
+
+// NewMACDFactor returns a factor that scores turns of the weekly MACD
+// histogram: a negative histogram curling up scores +1, +2 when strongly
+// negative, and a positive one rolling over -1, -2 when strongly positive.
+// A histogram near zero or still widening scores 0.
+func NewMACDFactor(weight float64) Factor {
+	return macdFactor{weight: weight}
+}
+
+// macdFactor is the factor NewMACDFactor returns.
+type macdFactor struct {
+	weight float64
+}
+
+func (f macdFactor) Name() string { return MACDFactorName }
+
+func (f macdFactor) config() string { return fmt.Sprintf("weight=%v", f.weight) }
+
+func (f macdFactor) Score(ind *model.MarketIndicators, _ FactorContext) model.FactorScore {
+	const name = "MACD拐点"
+	if (ind.MACDHist == 0 && ind.MACDHistPrev == 0) || ind.CurrentPrice <= 0 {
+		return model.FactorScore{Name: name, Commentary: "不可用", Missing: "too few weekly bars for MACD"}
+	}
+	score, label := scoreMACDHist(ind.MACDHist/ind.CurrentPrice*100, ind.MACDHist-ind.MACDHistPrev)
+	return model.FactorScore{
+		Name:       name,
+		RawScore:   score,
+		Weight:     f.weight,
+		Weighted:   score * f.weight,
+		Commentary: fmt.Sprintf("柱 %+.2f (前 %+.2f), %s", ind.MACDHist, ind.MACDHistPrev, label),
+	}
+}
+
+// scoreMACDHist scores a histogram of pct percent of the price that changed
+// by delta since the week before.
+func scoreMACDHist(pct, delta float64) (float64, string) {
+	switch {
+	case pct > -macdFlatPct && pct < macdFlatPct:
+		return 0, "接近零轴"
+	case pct < 0 && delta > 0:
+		if pct <= -macdStrongPct {
+			return 2, "深度负值收窄"
+		}
+		return 1, "负值收窄"
+	case pct > 0 && delta < 0:
+		if pct >= macdStrongPct {
+			return -2, "高位正值回落"
+		}
+		return -1, "正值回落"
+	case pct < 0:
+		return 0, "负值扩大"
+	}
+	return 0, "正值扩大"
+}
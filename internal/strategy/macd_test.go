@@ -0,0 +1,60 @@
+package strategy
+
+import (
+	"strings"
+	"testing"
+
+	"MarketSentinel/internal/model"
+)
+
+func TestMACDFactor_Score(t *testing.T) {
+	tests := []struct {
+		name       string
+		hist, prev float64
+		want       float64
+	}{
+		{"capitulation curling up", -40, -55, 2},
+		{"negative curling up", -15, -20, 1},
+		{"negative widening", -40, -30, 0},
+		{"near zero", 3, -2, 0},
+		{"top rolling over", 35, 45, -2},
+		{"positive rolling over", 15, 20, -1},
+		{"positive widening", 35, 25, 0},
+	}
+	for _, tt := range tests {
+		ind := &model.MarketIndicators{CurrentPrice: 5000, MACDHist: tt.hist, MACDHistPrev: tt.prev}
+		f := NewMACDFactor(0.1).Score(ind, FactorContext{})
+		if f.RawScore != tt.want || f.Weighted != tt.want*0.1 || f.Missing != "" {
+			t.Errorf("%s: got %+v, want raw %.0f", tt.name, f, tt.want)
+		}
+		if !strings.Contains(f.Commentary, "柱") {
+			t.Errorf("%s: commentary %q lacks the histogram", tt.name, f.Commentary)
+		}
+	}
+
+	if f := NewMACDFactor(0.1).Score(&model.MarketIndicators{CurrentPrice: 5000}, FactorContext{}); f.Missing == "" || f.Weighted != 0 {
+		t.Errorf("expected unset MACD to be missing, got %+v", f)
+	}
+}
+
+func TestMACDFactor_Registered(t *testing.T) {
+	ind := &model.MarketIndicators{
+		CurrentPrice: 5000, MA200: 5200, MA20w: 5100, MA50w: 5150,
+		WeeklyRSI: 30, DailyRSI: 28, High52w: 6000, Low52w: 4800,
+		High30d: 5400, Low30d: 4900, Position52w: 0.17,
+		MACDHist: -40, MACDHistPrev: -55,
+	}
+	base := Evaluate(ind)
+
+	RegisterFactor(NewMACDFactor(0.1))
+	t.Cleanup(resetFactors)
+	got := Evaluate(ind)
+
+	last := got.Factors[len(got.Factors)-1]
+	if len(got.Factors) != len(base.Factors)+1 || last.ID != MACDFactorName || !last.Extra {
+		t.Fatalf("expected MACD factor appended, got %+v", got.Factors)
+	}
+	if diff := got.TotalScore - base.TotalScore; diff < 0.199 || diff > 0.201 {
+		t.Errorf("total moved by %.3f, want +0.2", diff)
+	}
+}
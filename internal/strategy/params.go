@@ -0,0 +1,72 @@
+package strategy
+
+import (
+	"fmt"
+	"maps"
+)
+
+// Params are the strategy settings that can be tuned without registering
+// factors: the core weight overrides and the RSI, smoothing, cooldown and
+// confidence and bear boost settings. The package-level setters change the ones in effect,
+// see CurrentParams; the methods of a Params value evaluate with its own, so
+// differently tuned evaluations can run side by side, as in a parameter
+// sweep. The registered factors, disabled factors and tiers are shared.
+type Params struct {
+	Weights        map[string]float64 // see SetFactorWeights
+	RSI            RSIThresholds      // see SetRSIThresholds
+	SmoothingAlpha float64            // see SetSmoothing
+	CooldownWeeks  int                // see SetCooldown
+	CooldownMargin float64
+	MinConfidence  float64 // see SetConfidenceDemotion
+	// BearBoostWeeks and BearBoostMultiplier are the bear boost, see
+	// SetBearBoost.
+	BearBoostWeeks      int
+	BearBoostMultiplier float64
+}
+
+// CurrentParams returns a copy of the settings in effect.
+func CurrentParams() Params {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	return Params{
+		Weights:        maps.Clone(weights),
+		RSI:            rsiThresholds,
+		SmoothingAlpha: smoothingAlpha,
+		CooldownWeeks:  cooldownWeeks,
+		CooldownMargin: cooldownMargin,
+		MinConfidence:  minConfidence,
+
+		BearBoostWeeks:      bearBoostWeeks,
+		BearBoostMultiplier: bearBoostMultiplier,
+	}
+}
+
+// Validate checks p as the setters would: weights of registered core factors
+// and not negative, a smoothing alpha in [0, 1], a cooldown of zero weeks or
+// more, a minimum confidence in [0, 1) and a bear boost of zero weeks or
+// more by a multiplier of at least 1.
+func (p Params) Validate() error {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	for name, v := range p.Weights {
+		if !registeredCore(name) {
+			return fmt.Errorf("no core factor %q", name)
+		}
+		if v < 0 {
+			return fmt.Errorf("negative weight %v for factor %q", v, name)
+		}
+	}
+	switch {
+	case p.SmoothingAlpha < 0 || p.SmoothingAlpha > 1:
+		return fmt.Errorf("smoothing alpha %v outside [0, 1]", p.SmoothingAlpha)
+	case p.CooldownWeeks < 0:
+		return fmt.Errorf("negative cooldown of %d weeks", p.CooldownWeeks)
+	case p.MinConfidence < 0 || p.MinConfidence >= 1:
+		return fmt.Errorf("minimum confidence %v outside [0, 1)", p.MinConfidence)
+	case p.BearBoostWeeks < 0:
+		return fmt.Errorf("negative bear boost of %d weeks", p.BearBoostWeeks)
+	case p.BearBoostWeeks > 0 && p.BearBoostMultiplier < 1:
+		return fmt.Errorf("bear boost multiplier %v below 1", p.BearBoostMultiplier)
+	}
+	return nil
+}
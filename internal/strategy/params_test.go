@@ -0,0 +1,46 @@
+package strategy
+
+import (
+	"testing"
+
+	"MarketSentinel/internal/model"
+)
+
+func TestParams_EvaluateWithoutSetters(t *testing.T) {
+	ind := &model.MarketIndicators{
+		CurrentPrice: 5000, MA200: 5200, MA20w: 5100, MA50w: 5150,
+		WeeklyRSI: 30, DailyRSI: 28, High52w: 6000, Low52w: 4800,
+		High30d: 5400, Low30d: 4900, Position52w: 0.17,
+	}
+	base := Evaluate(ind)
+	if got := CurrentParams().Evaluate(ind); got.TotalScore != base.TotalScore || got.StrategyVersion != base.StrategyVersion {
+		t.Fatalf("current params evaluate to %+v, want %+v", got, base)
+	}
+
+	p := CurrentParams()
+	p.Weights = map[string]float64{model.FactorMA200: 0}
+	tuned := p.Evaluate(ind)
+	if tuned.TotalScore == base.TotalScore || tuned.StrategyVersion == base.StrategyVersion {
+		t.Errorf("zero MA200 weight kept score %.3f and version %s", tuned.TotalScore, tuned.StrategyVersion)
+	}
+	if again := Evaluate(ind); again.TotalScore != base.TotalScore {
+		t.Errorf("tuned params leaked into Evaluate: %.3f, want %.3f", again.TotalScore, base.TotalScore)
+	}
+}
+
+func TestParams_Validate(t *testing.T) {
+	if err := CurrentParams().Validate(); err != nil {
+		t.Fatalf("current params invalid: %v", err)
+	}
+	for name, p := range map[string]Params{
+		"unknown factor":  {Weights: map[string]float64{"nope": 1}},
+		"negative weight": {Weights: map[string]float64{model.FactorMA200: -1}},
+		"alpha":           {SmoothingAlpha: 1.5},
+		"cooldown":        {CooldownWeeks: -1},
+		"confidence":      {MinConfidence: 1},
+	} {
+		if err := p.Validate(); err == nil {
+			t.Errorf("%s accepted", name)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package strategy
+
+import "MarketSentinel/internal/model"
+
+// Regime classification thresholds. A drawdown of regimeBearDrawdown from the
+// 52-week high is a bear market whatever the trend; a bull market must be
+// within regimeBullDrawdown of it. Without an MA200 slope the price must lie
+// more than regimeBand beyond MA200 to count as trending.
+const (
+	regimeBearDrawdown = -0.20
+	regimeBullDrawdown = -0.10
+	regimeBand         = 0.05
+)
+
+// ClassifyRegime classifies the market by the price against MA200, the MA200
+// slope and the drawdown from the 52-week high:
+//
+//   - 熊市: a drawdown of 20% or more, or the price below a falling MA200
+//   - 牛市: the price above a rising MA200 and within 10% of the high
+//   - 震荡: anything else, including a price exactly on MA200
+//
+// A zero MA200Slope means the slope is unavailable; the price must then be
+// more than 5% beyond MA200 to stand in for the trend. The regime is
+// RegimeUnknown when MA200 itself is missing.
+func ClassifyRegime(ind *model.MarketIndicators) model.Regime {
+	if ind.MA200 <= 0 || ind.MissingReason(model.IndicatorMA200) != "" {
+		return model.RegimeUnknown
+	}
+	if ind.DrawdownFromHigh <= regimeBearDrawdown {
+		return model.RegimeBear
+	}
+
+	dev := ind.CurrentPrice/ind.MA200 - 1
+	up, down := ind.MA200Slope > 0, ind.MA200Slope < 0
+	if ind.MA200Slope == 0 {
+		up, down = dev > regimeBand, dev < -regimeBand
+	}
+	switch {
+	case dev < 0 && down:
+		return model.RegimeBear
+	case dev > 0 && up && ind.DrawdownFromHigh > regimeBullDrawdown:
+		return model.RegimeBull
+	}
+	return model.RegimeRange
+}
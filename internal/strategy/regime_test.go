@@ -0,0 +1,42 @@
+package strategy
+
+import (
+	"testing"
+
+	"MarketSentinel/internal/model"
+)
+
+func TestClassifyRegime(t *testing.T) {
+	tests := []struct {
+		name     string
+		price    float64
+		slope    float64
+		drawdown float64
+		want     model.Regime
+	}{
+		{"above rising MA200 near the high", 5500, 1.2, -0.03, model.RegimeBull},
+		{"above rising MA200 but far off the high", 5500, 1.2, -0.12, model.RegimeRange},
+		{"below falling MA200", 4500, -0.8, -0.15, model.RegimeBear},
+		{"below rising MA200", 4900, 0.5, -0.08, model.RegimeRange},
+		{"above falling MA200", 5100, -0.5, -0.05, model.RegimeRange},
+		{"deep drawdown above rising MA200", 5100, 0.5, -0.20, model.RegimeBear},
+		{"exactly on rising MA200", 5000, 1.2, -0.02, model.RegimeRange},
+		{"exactly on falling MA200", 5000, -1.2, -0.15, model.RegimeRange},
+		{"no slope, well above MA200", 5300, 0, -0.04, model.RegimeBull},
+		{"no slope, slightly above MA200", 5200, 0, -0.04, model.RegimeRange},
+		{"no slope, well below MA200", 4700, 0, -0.15, model.RegimeBear},
+		{"no slope, slightly below MA200", 4800, 0, -0.15, model.RegimeRange},
+	}
+	for _, tt := range tests {
+		ind := &model.MarketIndicators{CurrentPrice: tt.price, MA200: 5000, MA200Slope: tt.slope, DrawdownFromHigh: tt.drawdown}
+		if got := ClassifyRegime(ind); got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+
+	missing := &model.MarketIndicators{CurrentPrice: 4000, MA200: 4000, DrawdownFromHigh: -0.3}
+	missing.MarkMissing(model.IndicatorMA200, "only 120 daily bars")
+	if got := ClassifyRegime(missing); got != model.RegimeUnknown || got.Label() != "未知" {
+		t.Errorf("missing MA200: got %q", got)
+	}
+}
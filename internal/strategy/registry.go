@@ -0,0 +1,272 @@
+package strategy
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"MarketSentinel/internal/model"
+)
+
+// Factor scores one input of the trade signal.
+type Factor interface {
+	// Name is the factor's registry key, e.g. model.FactorMA200, used to
+	// enable and disable it and recorded as FactorScore.ID.
+	Name() string
+	Score(ind *model.MarketIndicators, ctx FactorContext) model.FactorScore
+}
+
+// FactorContext is what Evaluate knows besides the indicators when it scores
+// a factor.
+type FactorContext struct {
+	// OtherFactorsAvg is the average raw score of the enabled core factors
+	// that do not depend on it, leaving out those skipped for missing
+	// indicators and the drawdown factor, which is zero at any high. It is only set for a factor registered as
+	// depending on the others, which is scored after them; zero otherwise.
+	OtherFactorsAvg float64
+	// AsOf is the time the indicators were computed as of, for factors that
+	// would read the clock; zero for a live evaluation, see EvaluateAt.
+	AsOf time.Time
+}
+
+// coreWeight is the sum of the core factors' weights. When some are disabled
+// the others are scaled up to it, so the total score keeps its -2..+2 scale.
+const coreWeight = 1.0
+
+// registration is a factor in the registry.
+type registration struct {
+	factor Factor
+	core   bool // one of the weighted factors the total is built from; false for extras
+	// dependsOnOthers scores the factor after the others, with
+	// FactorContext.OtherFactorsAvg.
+	dependsOnOthers bool
+	// notInAvg leaves the factor out of FactorContext.OtherFactorsAvg.
+	notInAvg bool
+	// applies reports whether the factor is scored for ind; when false it
+	// is left out as if disabled. nil always applies.
+	applies func(ind *model.MarketIndicators) bool
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   = coreFactors()
+	disabled   map[string]bool
+	weights    map[string]float64 // core weight overrides, see SetFactorWeights
+)
+
+// coreFactors returns the core factors in report order.
+func coreFactors() []registration {
+	return []registration{
+		{factor: factorFunc{model.FactorMA200, func(ind *model.MarketIndicators, _ FactorContext) model.FactorScore {
+			return scoreMA200Deviation(ind)
+		}, []string{model.IndicatorMA200}}, core: true},
+		{factor: factorFunc{model.FactorWeeklyRSI, func(ind *model.MarketIndicators, _ FactorContext) model.FactorScore {
+			return scoreWeeklyRSI(ind)
+		}, []string{model.IndicatorWeeklyRSI}}, core: true},
+		{factor: factorFunc{model.FactorDailyRSI, func(ind *model.MarketIndicators, _ FactorContext) model.FactorScore {
+			return scoreDailyRSI(ind)
+		}, []string{model.IndicatorDailyRSI}}, core: true},
+		// A 52-week high only scores -2 when the other factors agree.
+		{factor: factorFunc{model.FactorPosition52w, func(ind *model.MarketIndicators, ctx FactorContext) model.FactorScore {
+			return score52WeekPosition(ind, ctx.OtherFactorsAvg)
+		}, []string{model.IndicatorRange52w, model.IndicatorPosition52w}}, core: true, dependsOnOthers: true},
+		{factor: factorFunc{model.FactorTrend, func(ind *model.MarketIndicators, _ FactorContext) model.FactorScore {
+			return scoreTrendTracker(ind)
+		}, []string{model.IndicatorMA20w, model.IndicatorMA50w, model.IndicatorRange30d}}, core: true},
+		{factor: factorFunc{model.FactorDrawdown, func(ind *model.MarketIndicators, _ FactorContext) model.FactorScore {
+			return scoreDrawdown(ind)
+		}, []string{model.IndicatorRange52w}}, core: true, notInAvg: true},
+		// Only with a volatility index configured, so single-symbol setups
+		// keep the five factors above.
+		{factor: factorFunc{model.FactorVolatility, func(ind *model.MarketIndicators, _ FactorContext) model.FactorScore {
+			return scoreVolatility(ind)
+		}, []string{model.IndicatorVIX}}, core: true, applies: hasVIX},
+	}
+}
+
+// hasVIX reports whether ind has a volatility index, or one was configured
+// but could not be fetched.
+func hasVIX(ind *model.MarketIndicators) bool {
+	return ind.VIX > 0 || ind.MissingReason(model.IndicatorVIX) != ""
+}
+
+// factorFunc is a Factor scored by a function from the given indicators.
+// When any of them is missing it is not scored: its result has zero weight
+// and the reason in Missing. A function may also skip the factor itself by
+// setting Missing, keeping its own commentary.
+type factorFunc struct {
+	name   string
+	score  func(*model.MarketIndicators, FactorContext) model.FactorScore
+	inputs []string // indicator keys, see model.MarketIndicators.Missing
+}
+
+func (f factorFunc) Name() string { return f.name }
+
+func (f factorFunc) Score(ind *model.MarketIndicators, ctx FactorContext) model.FactorScore {
+	s := f.score(ind, ctx)
+	if s.Missing != "" {
+		return s
+	}
+	if reason := ind.MissingReason(f.inputs...); reason != "" {
+		return model.FactorScore{Name: s.Name, Commentary: "不可用", Missing: reason}
+	}
+	return s
+}
+
+// RegisterFactor adds an optional factor evaluated after the core factors.
+// Registered factors add to the total score but are excluded from the
+// other-factors average used by the 52-week position rule, and their weights
+// are not rescaled.
+func RegisterFactor(f Factor) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	registry = append(registry, registration{factor: f})
+}
+
+// DisableFactors leaves the named factors out of Evaluate, which scales the
+// weights of the remaining core factors up to make up for disabled ones. It
+// fails on a name no factor is registered under, changing nothing.
+func DisableFactors(names ...string) error {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	for _, name := range names {
+		if !registered(name) {
+			return fmt.Errorf("unknown factor %q", name)
+		}
+	}
+	for _, name := range names {
+		if disabled == nil {
+			disabled = make(map[string]bool)
+		}
+		disabled[name] = true
+	}
+	return nil
+}
+
+// SetFactorWeights overrides the weights of core factors by name, before the
+// core weights are scaled to add up to 1. A zero weight leaves the factor out
+// as if disabled, so weight 0 for model.FactorDrawdown restores the original
+// five factors. It fails on a name that is not a core factor or a negative
+// weight, changing nothing.
+func SetFactorWeights(w map[string]float64) error {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	for name, v := range w {
+		if !registeredCore(name) {
+			return fmt.Errorf("no core factor %q", name)
+		}
+		if v < 0 {
+			return fmt.Errorf("negative weight %v for factor %q", v, name)
+		}
+	}
+	for name, v := range w {
+		if weights == nil {
+			weights = make(map[string]float64)
+		}
+		weights[name] = v
+	}
+	return nil
+}
+
+// registeredCore reports whether a core factor is registered under name.
+// The caller holds registryMu.
+func registeredCore(name string) bool {
+	for _, r := range registry {
+		if r.core && r.factor.Name() == name {
+			return true
+		}
+	}
+	return false
+}
+
+// registered reports whether a factor is registered under name. The caller
+// holds registryMu.
+func registered(name string) bool {
+	for _, r := range registry {
+		if r.factor.Name() == name {
+			return true
+		}
+	}
+	return false
+}
+
+// resetFactors restores the core factors, all enabled; used by tests.
+func resetFactors() {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	registry = coreFactors()
+	disabled = nil
+	weights = nil
+}
+
+// scoreFactors scores every enabled factor that applies to ind, in
+// registration order, with weights overriding core weights as in
+// SetFactorWeights, in a FactorContext as of asOf. Factors depending on the
+// others are scored last; extras are marked Extra. When the core factors'
+// weights differ from coreWeight, because some are disabled, skipped with
+// zero weight or added by the volatility factor, they are scaled to it.
+func scoreFactors(ind *model.MarketIndicators, weights map[string]float64, asOf time.Time) []model.FactorScore {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	scores := make([]model.FactorScore, len(registry))
+	enabled := make([]bool, len(registry))
+	score := func(i int, ctx FactorContext) {
+		r := registry[i]
+		s := r.factor.Score(ind, ctx)
+		s.ID = r.factor.Name()
+		s.Extra = !r.core
+		if w, ok := weights[s.ID]; ok && s.Missing == "" {
+			s.Weight, s.Weighted = w, s.RawScore*w
+		}
+		scores[i], enabled[i] = s, true
+	}
+
+	off := func(r registration) bool {
+		if w, ok := weights[r.factor.Name()]; ok && w == 0 {
+			return true
+		}
+		return disabled[r.factor.Name()] || (r.applies != nil && !r.applies(ind))
+	}
+	sum, n := 0.0, 0
+	for i, r := range registry {
+		if off(r) || r.dependsOnOthers {
+			continue
+		}
+		score(i, FactorContext{AsOf: asOf})
+		if r.core && !r.notInAvg && scores[i].Missing == "" {
+			sum += scores[i].RawScore
+			n++
+		}
+	}
+	ctx := FactorContext{AsOf: asOf}
+	if n > 0 {
+		ctx.OtherFactorsAvg = sum / float64(n)
+	}
+	for i, r := range registry {
+		if r.dependsOnOthers && !off(r) {
+			score(i, ctx)
+		}
+	}
+
+	out := make([]model.FactorScore, 0, len(scores))
+	weight := 0.0
+	for i, s := range scores {
+		if enabled[i] {
+			out = append(out, s)
+			if !s.Extra {
+				weight += s.Weight
+			}
+		}
+	}
+	if weight > 0 && math.Abs(weight-coreWeight) > 1e-9 {
+		for i := range out {
+			if !out[i].Extra {
+				out[i].Weight *= coreWeight / weight
+				out[i].Weighted = out[i].RawScore * out[i].Weight
+			}
+		}
+	}
+	return out
+}
@@ -0,0 +1,212 @@
+package strategy
+
+import (
+	"math"
+	"testing"
+
+	"MarketSentinel/internal/model"
+)
+
+// oversold scores +2 on every core factor but the trend, which is bearish
+// at a 30-day low.
+func oversold() *model.MarketIndicators {
+	return &model.MarketIndicators{
+		CurrentPrice: 4000, MA200: 5500, MA20w: 5000, MA50w: 5200,
+		WeeklyRSI: 20, DailyRSI: 20, High52w: 6000, Low52w: 3990,
+		High30d: 4500, Low30d: 4000, Position52w: 0.01,
+	}
+}
+
+func TestDisableFactors_RescalesWeights(t *testing.T) {
+	t.Cleanup(resetFactors)
+	base := Evaluate(oversold())
+	if err := DisableFactors(model.FactorDailyRSI); err != nil {
+		t.Fatal(err)
+	}
+	sig := Evaluate(oversold())
+
+	if len(sig.Factors) != 5 {
+		t.Fatalf("got %d factors, want the 5 still enabled", len(sig.Factors))
+	}
+	weight := 0.0
+	for _, f := range sig.Factors {
+		if f.ID == model.FactorDailyRSI {
+			t.Errorf("disabled factor %s still scored", f.Name)
+		}
+		weight += f.Weight
+	}
+	if math.Abs(weight-1) > 1e-9 {
+		t.Errorf("weights sum to %v, want 1", weight)
+	}
+	// Daily RSI scored +2 like three of the others: dropping it leaves the
+	// -1 trend a larger share, 0.15/0.95 of the total.
+	want := (2*0.35 + 2*0.25 + 2*0.10 - 1*0.15 + 0*0.10) / 0.95
+	if math.Abs(sig.TotalScore-want) > 1e-9 {
+		t.Errorf("total %v, want %v", sig.TotalScore, want)
+	}
+	if sig.TotalScore >= base.TotalScore {
+		t.Errorf("total %v should fall below %v without a +2 factor", sig.TotalScore, base.TotalScore)
+	}
+}
+
+func TestPosition52w_DegenerateRangeSkipped(t *testing.T) {
+	t.Cleanup(resetFactors)
+	for name, set := range map[string]func(*model.MarketIndicators){
+		// A fresh listing: one price all year.
+		"high equals low": func(ind *model.MarketIndicators) {
+			ind.High52w, ind.Low52w, ind.Position52w = 4000, 4000, 0.5
+		},
+		// The collector's fallback after the range failed.
+		"range fallback": func(ind *model.MarketIndicators) {
+			ind.High52w, ind.Low52w, ind.Position52w = 4000, 4000, 0.5
+			ind.MarkMissing(model.IndicatorRange52w, "no daily bars provided")
+		},
+	} {
+		ind := oversold()
+		set(ind)
+		sig := Evaluate(ind)
+
+		weight := 0.0
+		for _, f := range sig.Factors {
+			if f.ID == model.FactorPosition52w {
+				if f.Missing == "" || f.Weight != 0 || f.Commentary != "52周高低点相同，已跳过" {
+					t.Errorf("%s: position factor %+v, want it skipped", name, f)
+				}
+			}
+			weight += f.Weight
+		}
+		if math.Abs(weight-1) > 1e-9 {
+			t.Errorf("%s: weights sum to %v, want 1", name, weight)
+		}
+
+		// The total keeps its scale, as if the factor were disabled.
+		resetFactors()
+		if err := DisableFactors(model.FactorPosition52w); err != nil {
+			t.Fatal(err)
+		}
+		if want := Evaluate(ind).TotalScore; math.Abs(sig.TotalScore-want) > 1e-9 {
+			t.Errorf("%s: total %v, want %v as without the factor", name, sig.TotalScore, want)
+		}
+		resetFactors()
+	}
+}
+
+func TestDisableFactors_UnknownName(t *testing.T) {
+	t.Cleanup(resetFactors)
+	if err := DisableFactors(model.FactorTrend, "macd"); err == nil {
+		t.Fatal("want an error for an unknown factor")
+	}
+	if n := len(Evaluate(oversold()).Factors); n != 6 {
+		t.Errorf("a failed DisableFactors changed the factors: got %d, want 6", n)
+	}
+}
+
+func TestPosition52w_OtherFactorsAvgFollowsEnabled(t *testing.T) {
+	t.Cleanup(resetFactors)
+	// At a 52-week high the factor scores -2 only when the others average
+	// below -1. With all four the average is (-2 -2 -2 +0)/4 = -1.5, the
+	// trend being neutral.
+	ind := &model.MarketIndicators{
+		CurrentPrice: 7000, MA200: 5500, MA20w: 6900, MA50w: 7100,
+		WeeklyRSI: 85, DailyRSI: 85, High52w: 7000, Low52w: 5000,
+		High30d: 7100, Low30d: 6500, Position52w: 1,
+	}
+	position := func(sig *model.TradeSignal) float64 {
+		for _, f := range sig.Factors {
+			if f.ID == model.FactorPosition52w {
+				return f.RawScore
+			}
+		}
+		t.Fatal("52-week position not scored")
+		return 0
+	}
+	if got := position(Evaluate(ind)); got != -2 {
+		t.Errorf("all enabled: 52-week position %v, want -2", got)
+	}
+
+	DisableFactors(model.FactorMA200, model.FactorWeeklyRSI)
+	// Now the others are daily RSI -2 and trend 0: average -1, not below.
+	if got := position(Evaluate(ind)); got != -1 {
+		t.Errorf("MA200 and weekly RSI disabled: 52-week position %v, want -1", got)
+	}
+}
+
+func TestVolatilityFactor(t *testing.T) {
+	t.Cleanup(resetFactors)
+	volatility := func(sig *model.TradeSignal) (model.FactorScore, bool) {
+		for _, f := range sig.Factors {
+			if f.ID == model.FactorVolatility {
+				return f, true
+			}
+		}
+		return model.FactorScore{}, false
+	}
+	base := Evaluate(oversold())
+
+	t.Run("crash", func(t *testing.T) {
+		ind := oversold()
+		ind.VIX = 45
+		sig := Evaluate(ind)
+		f, ok := volatility(sig)
+		if !ok || f.RawScore != 2 {
+			t.Fatalf("volatility factor = %+v, want +2 at VIX 45", f)
+		}
+		weight := 0.0
+		for _, f := range sig.Factors {
+			weight += f.Weight
+		}
+		if len(sig.Factors) != 7 || math.Abs(weight-1) > 1e-9 {
+			t.Errorf("got %d factors weighing %v, want 7 scaled to 1", len(sig.Factors), weight)
+		}
+		// The other six keep their proportions within the remaining weight.
+		if want := (base.TotalScore*1.10 + 2*0.10) / 1.20; math.Abs(sig.TotalScore-want) > 1e-9 {
+			t.Errorf("total %v, want %v", sig.TotalScore, want)
+		}
+	})
+
+	t.Run("calm", func(t *testing.T) {
+		for vix, want := range map[float64]float64{11: -0.5, 13: 0, 22: 0, 30: 0, 31: 1} {
+			ind := oversold()
+			ind.VIX = vix
+			if f, _ := volatility(Evaluate(ind)); f.RawScore != want {
+				t.Errorf("VIX %v scored %v, want %v", vix, f.RawScore, want)
+			}
+		}
+	})
+
+	t.Run("missing", func(t *testing.T) {
+		sig := Evaluate(oversold())
+		if _, ok := volatility(sig); ok || len(sig.Factors) != 6 {
+			t.Errorf("without VIX got %d factors, want the other six", len(sig.Factors))
+		}
+
+		ind := oversold()
+		ind.MarkMissing(model.IndicatorVIX, "no bars for ^VIX")
+		sig = Evaluate(ind)
+		f, ok := volatility(sig)
+		if !ok || f.Missing == "" || f.Weight != 0 {
+			t.Errorf("volatility factor = %+v, want it skipped when the configured index failed", f)
+		}
+		if math.Abs(sig.TotalScore-base.TotalScore) > 1e-9 {
+			t.Errorf("total %v, want %v as without the factor", sig.TotalScore, base.TotalScore)
+		}
+	})
+}
+
+func TestSetFactorWeights(t *testing.T) {
+	t.Cleanup(resetFactors)
+	if err := SetFactorWeights(map[string]float64{model.FactorMA200: 0.7}); err != nil {
+		t.Fatal(err)
+	}
+	sig := Evaluate(oversold())
+	// MA200 is now 0.7 of 1.45 before scaling.
+	if f := sig.Factors[0]; f.ID != model.FactorMA200 || math.Abs(f.Weight-0.7/1.45) > 1e-9 {
+		t.Errorf("MA200 weighs %v, want 0.7/1.45", f.Weight)
+	}
+
+	for _, w := range []map[string]float64{{"macd": 0.1}, {model.FactorTrend: -0.1}, {"seasonal": 0.05}} {
+		if err := SetFactorWeights(w); err == nil {
+			t.Errorf("SetFactorWeights(%v) succeeded, want an error", w)
+		}
+	}
+}
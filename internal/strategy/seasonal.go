@@ -0,0 +1,79 @@
+package strategy
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"MarketSentinel/internal/model"
+)
+
+// Seasonal factor bounds.
+const (
+	MaxSeasonalTilt   = 0.3
+	MaxSeasonalWeight = 0.05
+)
+
+// DefaultSeasonalTilts are raw scores per calendar month, derived from the sign
+// and rough size of long-run S&P 500 average monthly returns: weak
+// September, strong November–December.
+var DefaultSeasonalTilts = map[time.Month]float64{
+	time.January:   0.1,
+	time.February:  0,
+	time.March:     0.1,
+	time.April:     0.2,
+	time.May:       0,
+	time.June:      0,
+	time.July:      0.2,
+	time.August:    -0.1,
+	time.September: -0.2,
+	time.October:   0,
+	time.November:  0.2,
+	time.December:  0.2,
+}
+
+// SeasonalFactorName is the registry key of the seasonal factor.
+const SeasonalFactorName = "seasonal"
+
+// NewSeasonalFactor returns a factor that scores the current month from tilts,
+// the month of FactorContext.AsOf when set and of now otherwise.
+// Months missing from tilts score 0; tilts are capped to ±MaxSeasonalTilt and
+// the weight to MaxSeasonalWeight.
+func NewSeasonalFactor(tilts map[time.Month]float64, weight float64, now func() time.Time) Factor {
+	return seasonalFactor{tilts: tilts, weight: math.Min(weight, MaxSeasonalWeight), now: now}
+}
+
+// seasonalFactor is the factor NewSeasonalFactor returns.
+type seasonalFactor struct {
+	tilts  map[time.Month]float64
+	weight float64
+	now    func() time.Time
+}
+
+func (f seasonalFactor) Name() string { return SeasonalFactorName }
+
+func (f seasonalFactor) config() string {
+	s := fmt.Sprintf("weight=%v", f.weight)
+	for m := time.January; m <= time.December; m++ {
+		if tilt, ok := f.tilts[m]; ok {
+			s += fmt.Sprintf(" %d=%v", m, tilt)
+		}
+	}
+	return s
+}
+
+func (f seasonalFactor) Score(_ *model.MarketIndicators, ctx FactorContext) model.FactorScore {
+	now := ctx.AsOf
+	if now.IsZero() {
+		now = f.now()
+	}
+	month := now.Month()
+	score := math.Max(-MaxSeasonalTilt, math.Min(MaxSeasonalTilt, f.tilts[month]))
+	return model.FactorScore{
+		Name:       "季节性",
+		RawScore:   score,
+		Weight:     f.weight,
+		Weighted:   score * f.weight,
+		Commentary: fmt.Sprintf("%d月", int(month)),
+	}
+}
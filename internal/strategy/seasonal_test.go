@@ -0,0 +1,83 @@
+package strategy
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"MarketSentinel/internal/model"
+)
+
+func monthClock(m time.Month) func() time.Time {
+	return func() time.Time { return time.Date(2025, m, 15, 0, 0, 0, 0, time.UTC) }
+}
+
+func TestSeasonalFactor_Lookup(t *testing.T) {
+	tests := []struct {
+		month time.Month
+		tilts map[time.Month]float64
+		want  float64
+	}{
+		{time.September, DefaultSeasonalTilts, -0.2},
+		{time.December, DefaultSeasonalTilts, 0.2},
+		{time.May, map[time.Month]float64{}, 0},           // missing month
+		{time.March, map[time.Month]float64{3: 0.9}, 0.3}, // capped high
+		{time.March, map[time.Month]float64{3: -1}, -0.3}, // capped low
+	}
+	for _, tt := range tests {
+		f := NewSeasonalFactor(tt.tilts, 0.05, monthClock(tt.month)).Score(&model.MarketIndicators{}, FactorContext{})
+		if f.RawScore != tt.want || math.Abs(f.Weighted-tt.want*0.05) > 1e-9 {
+			t.Errorf("%s: got raw %.2f weighted %.4f, want raw %.2f", tt.month, f.RawScore, f.Weighted, tt.want)
+		}
+	}
+
+	if f := NewSeasonalFactor(DefaultSeasonalTilts, 0.5, monthClock(time.September)).Score(nil, FactorContext{}); f.Weight != MaxSeasonalWeight {
+		t.Errorf("expected weight capped to %.2f, got %.2f", MaxSeasonalWeight, f.Weight)
+	}
+	if f := NewSeasonalFactor(DefaultSeasonalTilts, 0.05, monthClock(time.September)).Score(nil, FactorContext{}); f.Commentary != "9月" {
+		t.Errorf("unexpected label %q", f.Commentary)
+	}
+}
+
+func TestSeasonalFactor_AsOf(t *testing.T) {
+	RegisterFactor(NewSeasonalFactor(DefaultSeasonalTilts, 0.05, monthClock(time.September)))
+	t.Cleanup(resetFactors)
+	ind := &model.MarketIndicators{
+		CurrentPrice: 5000, MA200: 5000, MA20w: 5000, MA50w: 5000,
+		WeeklyRSI: 50, DailyRSI: 50, High52w: 5500, Low52w: 4500, Position52w: 0.5,
+	}
+
+	// A past session's month replaces the clock's.
+	sig := CurrentParams().EvaluateAt(ind, time.Date(2020, time.December, 1, 0, 0, 0, 0, time.UTC))
+	if f := sig.Factors[len(sig.Factors)-1]; f.Commentary != "12月" || f.RawScore != 0.2 {
+		t.Errorf("as of December: got %s %.2f", f.Commentary, f.RawScore)
+	}
+	if f := Evaluate(ind).Factors[len(sig.Factors)-1]; f.Commentary != "9月" {
+		t.Errorf("live: got %s, want the clock's month", f.Commentary)
+	}
+}
+
+func TestSeasonalFactor_ExcludedFromOtherFactorsAvg(t *testing.T) {
+	// Position > 95% with other factors averaging exactly -1 caps factor 4 at -1.
+	// A strongly negative extra factor must not tip the average below -1.
+	ind := &model.MarketIndicators{
+		CurrentPrice: 6600, MA200: 5600, MA20w: 6000, MA50w: 6100,
+		WeeklyRSI: 65, DailyRSI: 65, High52w: 6610, Low52w: 5000,
+		High30d: 6650, Low30d: 6500, Position52w: 0.99,
+	}
+	base := Evaluate(ind)
+
+	RegisterFactor(NewSeasonalFactor(map[time.Month]float64{9: -0.3}, 0.05, monthClock(time.September)))
+	t.Cleanup(resetFactors)
+	withSeasonal := Evaluate(ind)
+
+	if n := len(withSeasonal.Factors); n != 7 || !withSeasonal.Factors[n-1].Extra {
+		t.Fatalf("expected seasonal factor appended, got %+v", withSeasonal.Factors)
+	}
+	if base.Factors[3].RawScore != withSeasonal.Factors[3].RawScore {
+		t.Errorf("52-week position changed: %.1f -> %.1f", base.Factors[3].RawScore, withSeasonal.Factors[3].RawScore)
+	}
+	if diff := withSeasonal.TotalScore - base.TotalScore; math.Abs(diff-(-0.015)) > 1e-9 {
+		t.Errorf("expected total to shift by -0.015, got %+.4f", diff)
+	}
+}
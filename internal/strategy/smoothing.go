@@ -0,0 +1,53 @@
+package strategy
+
+import (
+	"math"
+
+	"MarketSentinel/internal/model"
+)
+
+// SmoothingLookback is how many previous weekly scores Smooth blends in.
+const SmoothingLookback = 2
+
+// smoothingAlpha is the weight of this week's score in Smooth; zero disables
+// smoothing. Guarded by registryMu.
+var smoothingAlpha float64
+
+// SetSmoothing makes Smooth map the tier from alpha times this week's score
+// plus 1-alpha times the average of the previous SmoothingLookback weekly
+// scores. Zero, the default, disables it; alpha is capped to 1.
+func SetSmoothing(alpha float64) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	smoothingAlpha = math.Max(0, math.Min(alpha, 1))
+}
+
+// Smooth remaps signal's tier from its smoothed score when smoothing is on
+// and recent, the previous weekly scores oldest first as in
+// model.FundState.RecentScores, is not empty. TotalScore stays the raw score.
+func Smooth(signal *model.TradeSignal, recent []float64) {
+	CurrentParams().Smooth(signal, recent)
+}
+
+// Smooth is the package-level Smooth with the smoothing alpha in p.
+func (p Params) Smooth(signal *model.TradeSignal, recent []float64) {
+	alpha := p.SmoothingAlpha
+	if alpha == 0 || len(recent) == 0 {
+		return
+	}
+
+	recent = recent[max(0, len(recent)-SmoothingLookback):]
+	prev := 0.0
+	for _, s := range recent {
+		prev += s
+	}
+	prev /= float64(len(recent))
+
+	score := alpha*signal.TotalScore + (1-alpha)*prev
+	boundary := TierBoundaries(score)
+	signal.Smoothed = true
+	signal.SmoothedScore = score
+	signal.RawTierLabel = signal.Tier.Label
+	signal.Tier = boundary.Tier
+	signal.Boundary = boundary
+}
@@ -0,0 +1,49 @@
+package strategy
+
+import (
+	"math"
+	"testing"
+
+	"MarketSentinel/internal/model"
+)
+
+func TestSmooth(t *testing.T) {
+	t.Cleanup(func() { SetSmoothing(0) })
+
+	signal := func(score float64) *model.TradeSignal {
+		b := TierBoundaries(score)
+		return &model.TradeSignal{TotalScore: score, Tier: b.Tier, Boundary: b}
+	}
+
+	// Off by default: the tier follows the raw score.
+	s := signal(0.78)
+	Smooth(s, []float64{0.85, 0.85})
+	if s.Smoothed || s.Tier.Label != "正常定投" {
+		t.Fatalf("smoothing applied while off: %+v", s)
+	}
+
+	SetSmoothing(0.5)
+	// A dip below 0.8 between two 加仓买入 weeks keeps the tier.
+	s = signal(0.78)
+	Smooth(s, []float64{0.1, 0.85, 0.85})
+	if !s.Smoothed || math.Abs(s.SmoothedScore-0.815) > 1e-9 {
+		t.Fatalf("smoothed score %.4f, want 0.815", s.SmoothedScore)
+	}
+	if s.TotalScore != 0.78 || s.Tier.Label != "加仓买入" || s.RawTierLabel != "正常定投" || s.Boundary.Tier != s.Tier {
+		t.Errorf("unexpected smoothed signal %+v", s)
+	}
+
+	// Only one previous score: blended alone.
+	s = signal(1.0)
+	Smooth(s, []float64{0.2})
+	if math.Abs(s.SmoothedScore-0.6) > 1e-9 {
+		t.Errorf("smoothed score %.4f, want 0.6", s.SmoothedScore)
+	}
+
+	// No history: left as is.
+	s = signal(1.0)
+	Smooth(s, nil)
+	if s.Smoothed {
+		t.Errorf("smoothed without history: %+v", s)
+	}
+}
@@ -0,0 +1,119 @@
+package strategy
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+
+	"MarketSentinel/internal/model"
+)
+
+// RSIThresholds are the RSI levels the take-profit warning and the daily
+// bottom-fish trigger fire at, shared by Evaluate and the daily check so the
+// two always agree.
+type RSIThresholds struct {
+	// BottomFish is the daily RSI below which the reserve pool bottom-fishes,
+	// at level 1.
+	BottomFish float64
+	// DeeperBottomFish are the daily RSIs below BottomFish, descending, that
+	// start bottom-fish levels 2, 3, ...
+	DeeperBottomFish []float64
+	// TakeProfit is the daily or weekly RSI above which a take-profit warning
+	// is issued.
+	TakeProfit float64
+	// A sell recommendation of SellPercent of the position is made when the
+	// total score is at or below SellScore, which is negative, and the daily
+	// or weekly RSI is above SellRSI. Zero SellPercent disables it.
+	SellScore   float64
+	SellRSI     float64
+	SellPercent float64
+}
+
+// DefaultRSIThresholds are the thresholds in effect until SetRSIThresholds.
+var DefaultRSIThresholds = RSIThresholds{
+	BottomFish:       30,
+	DeeperBottomFish: []float64{25, 20},
+	TakeProfit:       85,
+	SellScore:        -1.2,
+	SellRSI:          85,
+	SellPercent:      10,
+}
+
+// rsiThresholds are the thresholds in effect, guarded by registryMu.
+var rsiThresholds = DefaultRSIThresholds
+
+// SetRSIThresholds replaces the thresholds in effect.
+func SetRSIThresholds(t RSIThresholds) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	rsiThresholds = t
+}
+
+// Thresholds returns the thresholds in effect.
+func Thresholds() RSIThresholds {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	return rsiThresholds
+}
+
+// BottomFishTriggered reports whether ind's daily RSI is below the bottom-fish threshold.
+func (t RSIThresholds) BottomFishTriggered(ind *model.MarketIndicators) bool {
+	return ind.DailyRSI < t.BottomFish
+}
+
+// BottomFishLevel returns the bottom-fish level ind's daily RSI reaches: 0
+// when not triggered, otherwise 1 plus the number of DeeperBottomFish levels
+// it is below.
+func (t RSIThresholds) BottomFishLevel(ind *model.MarketIndicators) int {
+	if !t.BottomFishTriggered(ind) {
+		return 0
+	}
+	level := 1
+	for _, rsi := range t.DeeperBottomFish {
+		if ind.DailyRSI >= rsi {
+			break
+		}
+		level++
+	}
+	return level
+}
+
+// BottomFishRSI returns the daily RSI below which level starts, counted from
+// 1, or 0 for an unknown level.
+func (t RSIThresholds) BottomFishRSI(level int) float64 {
+	switch {
+	case level == 1:
+		return t.BottomFish
+	case level >= 2 && level-2 < len(t.DeeperBottomFish):
+		return t.DeeperBottomFish[level-2]
+	}
+	return 0
+}
+
+// TakeProfitTriggered reports whether ind's daily or weekly RSI is above the
+// take-profit threshold.
+func (t RSIThresholds) TakeProfitTriggered(ind *model.MarketIndicators) bool {
+	return ind.DailyRSI > t.TakeProfit || ind.WeeklyRSI > t.TakeProfit
+}
+
+// SellRecommendation returns the sell recommendation for totalScore and ind,
+// or nil when the gates are not met. The percentage grows with how far the
+// score is below SellScore, up to twice SellPercent at a score of -2. It is
+// never made for a score of zero or above.
+func (t RSIThresholds) SellRecommendation(totalScore float64, ind *model.MarketIndicators) *model.SellRecommendation {
+	if t.SellPercent <= 0 || t.SellScore >= 0 || totalScore >= 0 || totalScore > t.SellScore {
+		return nil
+	}
+	rsi := math.Max(ind.DailyRSI, ind.WeeklyRSI)
+	if rsi <= t.SellRSI {
+		return nil
+	}
+	pct := t.SellPercent
+	if t.SellScore > -2 {
+		pct *= 1 + math.Min(1, (t.SellScore-totalScore)/(t.SellScore+2))
+	}
+	return &model.SellRecommendation{
+		Percent: pct,
+		Reason:  fmt.Sprintf("评分 %s ≤ %s 且 RSI %s > %s", model.FormatScore(totalScore), model.FormatScore(t.SellScore), model.FormatRSI(rsi), strconv.FormatFloat(t.SellRSI, 'f', -1, 64)),
+	}
+}
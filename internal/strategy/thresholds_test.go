@@ -0,0 +1,59 @@
+package strategy
+
+import (
+	"math"
+	"testing"
+
+	"MarketSentinel/internal/model"
+)
+
+func TestRSIThresholds_BottomFishLevel(t *testing.T) {
+	th := DefaultRSIThresholds
+	for _, tt := range []struct {
+		rsi  float64
+		want int
+	}{
+		{35, 0}, {30, 0}, {29.9, 1}, {25, 1}, {24.9, 2}, {20, 2}, {19.9, 3}, {5, 3},
+	} {
+		if got := th.BottomFishLevel(&model.MarketIndicators{DailyRSI: tt.rsi}); got != tt.want {
+			t.Errorf("RSI %.1f: level %d, want %d", tt.rsi, got, tt.want)
+		}
+	}
+	if th.BottomFishRSI(1) != 30 || th.BottomFishRSI(3) != 20 || th.BottomFishRSI(4) != 0 {
+		t.Errorf("level thresholds %v/%v/%v", th.BottomFishRSI(1), th.BottomFishRSI(3), th.BottomFishRSI(4))
+	}
+}
+
+func TestRSIThresholds_SellRecommendation(t *testing.T) {
+	th := DefaultRSIThresholds
+	hot := &model.MarketIndicators{DailyRSI: 88, WeeklyRSI: 80}
+	tests := []struct {
+		name  string
+		score float64
+		ind   *model.MarketIndicators
+		want  float64 // percent, 0 for none
+	}{
+		{"at the gate", -1.2, hot, 10},
+		{"halfway to -2", -1.6, hot, 15},
+		{"floor", -2, hot, 20},
+		{"score above the gate", -1.1, hot, 0},
+		{"positive score", 0.5, hot, 0},
+		{"RSI at the gate", -1.5, &model.MarketIndicators{DailyRSI: 85, WeeklyRSI: 85}, 0},
+		{"weekly RSI alone", -1.5, &model.MarketIndicators{DailyRSI: 60, WeeklyRSI: 86}, 13.75},
+	}
+	for _, tt := range tests {
+		r := th.SellRecommendation(tt.score, tt.ind)
+		switch {
+		case tt.want == 0 && r != nil:
+			t.Errorf("%s: unexpected recommendation %+v", tt.name, r)
+		case tt.want != 0 && (r == nil || math.Abs(r.Percent-tt.want) > 1e-9 || r.Amount != 0):
+			t.Errorf("%s: got %+v, want %.2f%%", tt.name, r, tt.want)
+		}
+	}
+
+	// A misconfigured positive gate still never recommends selling on a positive score.
+	th.SellScore = 0.5
+	if r := th.SellRecommendation(0.2, hot); r != nil {
+		t.Errorf("recommendation on a positive score: %+v", r)
+	}
+}
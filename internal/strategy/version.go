@@ -0,0 +1,59 @@
+package strategy
+
+import (
+	"fmt"
+	"hash/fnv"
+	"maps"
+	"slices"
+)
+
+// ScoringVersion numbers the scoring logic: factor formulas, the tier table
+// and the rules that adjust a tier. Bump it with every change to them, so
+// signals from before and after can be told apart.
+const ScoringVersion = 1
+
+// configured is implemented by registered factors whose scores depend on how
+// they were constructed, such as their weight, so Version tells apart
+// configurations of the same factor.
+type configured interface {
+	config() string
+}
+
+// Version identifies the rule set signals are produced by: ScoringVersion
+// and a hash of the settings in effect, i.e. the registered factors and
+// their weights, the tiers and the RSI, smoothing, cooldown, confidence and
+// bear boost settings, e.g. "v1-3f9a2c1d".
+func Version() string {
+	return CurrentParams().Version()
+}
+
+// Version is the package-level Version with the settings in p.
+func (p Params) Version() string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	h := fnv.New32a()
+	for _, r := range registry {
+		fmt.Fprintf(h, "factor %s core=%v", r.factor.Name(), r.core)
+		if c, ok := r.factor.(configured); ok {
+			fmt.Fprintf(h, " %s", c.config())
+		}
+		fmt.Fprintln(h)
+	}
+	for _, name := range slices.Sorted(maps.Keys(disabled)) {
+		fmt.Fprintf(h, "disabled %s\n", name)
+	}
+	for _, name := range slices.Sorted(maps.Keys(p.Weights)) {
+		fmt.Fprintf(h, "weight %s=%v\n", name, p.Weights[name])
+	}
+	for _, t := range Tiers {
+		fmt.Fprintf(h, "tier %v %+v\n", t.MinScore, t.Tier)
+	}
+	fmt.Fprintf(h, "tier default %+v\n", DefaultTier)
+	fmt.Fprintf(h, "rsi %+v\n", p.RSI)
+	fmt.Fprintf(h, "smoothing %v cooldown %d/%v confidence %v\n", p.SmoothingAlpha, p.CooldownWeeks, p.CooldownMargin, p.MinConfidence)
+	if p.BearBoostWeeks > 0 {
+		fmt.Fprintf(h, "bear boost %d/%v\n", p.BearBoostWeeks, p.BearBoostMultiplier)
+	}
+	return fmt.Sprintf("v%d-%08x", ScoringVersion, h.Sum32())
+}
@@ -0,0 +1,47 @@
+package strategy
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestVersion(t *testing.T) {
+	t.Cleanup(func() {
+		resetFactors()
+		SetRSIThresholds(DefaultRSIThresholds)
+		SetSmoothing(0)
+	})
+	base := Version()
+	if !regexp.MustCompile(`^v\d+-[0-9a-f]{8}$`).MatchString(base) {
+		t.Fatalf("Version() = %q, want v<n>-<hash>", base)
+	}
+	if again := Version(); again != base {
+		t.Fatalf("Version() not stable: %q then %q", base, again)
+	}
+
+	seen := map[string]string{base: "defaults"}
+	changes := []struct {
+		name  string
+		apply func()
+	}{
+		{"weight override", func() { _ = SetFactorWeights(map[string]float64{"drawdown": 0}) }},
+		{"MACD factor", func() { RegisterFactor(NewMACDFactor(0.1)) }},
+		{"RSI threshold", func() { th := DefaultRSIThresholds; th.TakeProfit = 80; SetRSIThresholds(th) }},
+		{"smoothing", func() { SetSmoothing(0.5) }},
+	}
+	for _, c := range changes {
+		c.apply()
+		v := Version()
+		if prev, ok := seen[v]; ok {
+			t.Errorf("%s: version %s unchanged from %s", c.name, v, prev)
+		}
+		seen[v] = c.name
+	}
+
+	resetFactors()
+	SetRSIThresholds(DefaultRSIThresholds)
+	SetSmoothing(0)
+	if v := Version(); v != base {
+		t.Errorf("restored settings give %s, want %s", v, base)
+	}
+}
@@ -0,0 +1,60 @@
+package strategy
+
+import (
+	"fmt"
+	"strconv"
+
+	"MarketSentinel/internal/model"
+)
+
+// Trend warning windows. A close below MA200 breaks the trend only after
+// ma200BreakRunUp sessions above it, and each warning keeps firing for a few
+// bars after the event so a weekly run does not miss it; the recorder keeps
+// it from repeating while the condition lasts.
+const (
+	ma200BreakRunUp    = 20
+	ma200BreakSessions = 10
+	deathCrossWeeks    = 2
+	new52wLowSessions  = 5
+)
+
+// EvaluateWarnings returns the warnings the indicators raise, in report
+// order: take profit, MA200 break, MA20w/MA50w death cross and a new 52-week
+// low. A warning whose inputs are missing is not raised.
+func EvaluateWarnings(ind *model.MarketIndicators) []model.Warning {
+	return evaluateWarnings(ind, Thresholds())
+}
+
+// evaluateWarnings is EvaluateWarnings with the thresholds t.
+func evaluateWarnings(ind *model.MarketIndicators, t RSIThresholds) []model.Warning {
+	var warnings []model.Warning
+	if t.TakeProfitTriggered(ind) {
+		warnings = append(warnings, model.Warning{
+			Kind: model.WarningTakeProfit,
+			Text: fmt.Sprintf("⚠️ RSI > %s 止盈预警：建议考虑部分止盈", strconv.FormatFloat(t.TakeProfit, 'f', -1, 64)),
+		})
+	}
+	if ind.MissingReason(model.IndicatorMA200) == "" &&
+		ind.DaysBelowMA200 >= 1 && ind.DaysBelowMA200 <= ma200BreakSessions &&
+		ind.DaysAboveMA200Before >= ma200BreakRunUp {
+		warnings = append(warnings, model.Warning{
+			Kind: model.WarningMA200Break,
+			Text: fmt.Sprintf("⚠️ 跌破 MA200：站上 %d 个交易日后收于年线下方", ind.DaysAboveMA200Before),
+		})
+	}
+	if ind.MissingReason(model.IndicatorMA20w, model.IndicatorMA50w) == "" &&
+		ind.WeeksMA20wBelowMA50w >= 1 && ind.WeeksMA20wBelowMA50w <= deathCrossWeeks {
+		warnings = append(warnings, model.Warning{
+			Kind: model.WarningDeathCross,
+			Text: "⚠️ 死叉：MA20w 下穿 MA50w",
+		})
+	}
+	if ind.MissingReason(model.IndicatorRange52w) == "" &&
+		ind.Low52wAge >= 1 && ind.Low52wAge <= new52wLowSessions {
+		warnings = append(warnings, model.Warning{
+			Kind: model.WarningNew52wLow,
+			Text: fmt.Sprintf("⚠️ 创 52 周新低 %.2f", ind.Low52w),
+		})
+	}
+	return warnings
+}
@@ -0,0 +1,50 @@
+package strategy
+
+import (
+	"testing"
+
+	"MarketSentinel/internal/model"
+)
+
+func TestEvaluateWarnings(t *testing.T) {
+	base := func() *model.MarketIndicators {
+		return &model.MarketIndicators{CurrentPrice: 5000, MA200: 5100, Low52w: 4000, DailyRSI: 50, WeeklyRSI: 50}
+	}
+	tests := []struct {
+		name string
+		set  func(*model.MarketIndicators)
+		want []model.WarningKind
+	}{
+		{"quiet", func(*model.MarketIndicators) {}, nil},
+		{"take profit", func(i *model.MarketIndicators) { i.DailyRSI = 90 }, []model.WarningKind{model.WarningTakeProfit}},
+		{"ma200 break", func(i *model.MarketIndicators) { i.DaysBelowMA200, i.DaysAboveMA200Before = 1, 20 }, []model.WarningKind{model.WarningMA200Break}},
+		{"ma200 break short run-up", func(i *model.MarketIndicators) { i.DaysBelowMA200, i.DaysAboveMA200Before = 1, 19 }, nil},
+		{"ma200 break too old", func(i *model.MarketIndicators) { i.DaysBelowMA200, i.DaysAboveMA200Before = 11, 40 }, nil},
+		{"ma200 missing", func(i *model.MarketIndicators) {
+			i.DaysBelowMA200, i.DaysAboveMA200Before = 1, 20
+			i.MarkMissing(model.IndicatorMA200, "too few bars")
+		}, nil},
+		{"death cross", func(i *model.MarketIndicators) { i.WeeksMA20wBelowMA50w = 2 }, []model.WarningKind{model.WarningDeathCross}},
+		{"death cross too old", func(i *model.MarketIndicators) { i.WeeksMA20wBelowMA50w = 3 }, nil},
+		{"new low", func(i *model.MarketIndicators) { i.Low52wAge = 5 }, []model.WarningKind{model.WarningNew52wLow}},
+		{"old low", func(i *model.MarketIndicators) { i.Low52wAge = 6 }, nil},
+		{"all", func(i *model.MarketIndicators) {
+			i.WeeklyRSI, i.Low52wAge, i.WeeksMA20wBelowMA50w = 90, 1, 1
+			i.DaysBelowMA200, i.DaysAboveMA200Before = 3, 60
+		}, []model.WarningKind{model.WarningTakeProfit, model.WarningMA200Break, model.WarningDeathCross, model.WarningNew52wLow}},
+	}
+	for _, tt := range tests {
+		ind := base()
+		tt.set(ind)
+		got := EvaluateWarnings(ind)
+		if len(got) != len(tt.want) {
+			t.Errorf("%s: warnings %+v, want kinds %v", tt.name, got, tt.want)
+			continue
+		}
+		for i, w := range got {
+			if w.Kind != tt.want[i] || w.Text == "" {
+				t.Errorf("%s: warning %d %+v, want kind %s", tt.name, i, w, tt.want[i])
+			}
+		}
+	}
+}